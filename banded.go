@@ -102,6 +102,340 @@ func BandedTrmMatrix(S *matrix.FloatMatrix, K int, flags Flags) (R *matrix.Float
 }
 
 
+/*
+
+GENERAL-BAND STORAGE
+
+General M-by-N band matrix A with KL subdiagonals and KU superdiagonals is stored in
+array AB of size (KL+KU+1)-by-N so that element A[i,j] is in AB[KU+i-j, j] for
+max(0,j-KU) <= i <= min(M-1,j+KL). Given matrix with KL=1 and KU=2:
+
+                    | 11  12  13   0   0 |
+                    | 21  22  23  24   0 |
+                    |  0  32  33  34  35 |
+                    |  0   0  43  44  45 |
+                    |  0   0   0  54  55 |
+
+is stored as:
+
+                    |  *   *  13  24  35 |
+             AB =   |  *  12  23  34  45 |
+                    | 11  22  33  44  55 |
+                    | 21  32  43  54   * |
+
+Band LU factorization requires KL additional rows on top of the band for fill-in
+elements. BandedLUMatrix returns the matrix in that form.
+
+ */
+
+// Convert general band matrix S with KL subdiagonals and KU superdiagonals to
+// new matrix R with elements stored in general-band storage. Returned matrix has
+// dimensions R.Rows() == KL+KU+1 and R.Cols() == S.Cols().
+func BandedMatrix(S *matrix.FloatMatrix, KL, KU int) *matrix.FloatMatrix {
+    R := matrix.FloatZeros(KL+KU+1, S.Cols())
+    copyToBand(R, S, KL, KU, 0)
+    return R
+}
+
+// Convert general N-by-N band matrix S with KL subdiagonals and KU superdiagonals to
+// new matrix R suitable for DecomposeLUBand(). Returned matrix has dimensions
+// R.Rows() == 2*KL+KU+1 and R.Cols() == S.Cols(). First KL rows are reserved for
+// fill-in elements of the factorization.
+func BandedLUMatrix(S *matrix.FloatMatrix, KL, KU int) *matrix.FloatMatrix {
+    R := matrix.FloatZeros(2*KL+KU+1, S.Cols())
+    copyToBand(R, S, KL, KU, KL)
+    return R
+}
+
+func copyToBand(R, S *matrix.FloatMatrix, KL, KU, offset int) {
+    for j := 0; j < S.Cols(); j++ {
+        for i := imax(0, j-KU); i <= imin(S.Rows()-1, j+KL); i++ {
+            R.SetAt(offset+KU+i-j, j, S.GetAt(i, j))
+        }
+    }
+}
+
+// Return data array and element increment of row or column vector X.
+func vectorData(X *matrix.FloatMatrix) ([]float64, int) {
+    if X.Rows() == 1 {
+        // row vector
+        return X.FloatArray(), X.LeadingIndex()
+    }
+    return X.FloatArray(), 1
+}
+
+// Compute
+//      Y = alpha*A*X + beta*Y
+//      Y = alpha*A.T*X + beta*Y  ; flags = TRANSA
+//
+//    A is M*N general band matrix with KL subdiagonals stored in general-band
+//      storage, number of superdiagonals is A.Rows()-KL-1,
+//    X is row or column vector of length N (M if flags&TRANSA)
+//    Y is row or column vector of legth M (N if flags&TRANSA).
+//
+// Number of rows M of A is taken from the length of Y (X if flags&TRANSA). (blas.GBMV)
+func MVMultBand(Y, A, X *matrix.FloatMatrix, alpha, beta float64, KL int, flags Flags) error {
+    if X.Rows() != 1 && X.Cols() != 1 {
+        return sizeError("MVMultBand", Y, A, X)
+    }
+    if Y.Rows() != 1 && Y.Cols() != 1 {
+        return sizeError("MVMultBand", Y, A, X)
+    }
+    KU := A.Rows() - KL - 1
+    if KU < 0 {
+        return sizeError("MVMultBand", Y, A, X)
+    }
+    N := A.Cols()
+    M := Y.NumElements()
+    if flags & TRANSA != 0 {
+        M = X.NumElements()
+        if Y.NumElements() != N {
+            return sizeError("MVMultBand", Y, A, X)
+        }
+    } else if X.NumElements() != N {
+        return sizeError("MVMultBand", Y, A, X)
+    }
+    Ar := A.FloatArray()
+    ldA := A.LeadingIndex()
+    Xr, incX := vectorData(X)
+    Yr, incY := vectorData(Y)
+
+    // beta == 0 sets Y to zero; NaN and Inf in Y do not propagate (blas semantics)
+    if beta == 0.0 {
+        Y.SetIndexes(0.0)
+    } else if beta != 1.0 {
+        Scale(Y, beta)
+    }
+    if flags & TRANSA != 0 {
+        for j := 0; j < N; j++ {
+            temp := 0.0
+            for i := imax(0, j-KU); i <= imin(M-1, j+KL); i++ {
+                temp += Ar[KU+i-j+j*ldA] * Xr[i*incX]
+            }
+            Yr[j*incY] += alpha*temp
+        }
+    } else {
+        for j := 0; j < N; j++ {
+            temp := alpha * Xr[j*incX]
+            for i := imax(0, j-KU); i <= imin(M-1, j+KL); i++ {
+                Yr[i*incY] += temp * Ar[KU+i-j+j*ldA]
+            }
+        }
+    }
+    return nil
+}
+
+// Compute
+//      Y = alpha*A*X + beta*Y
+//
+//    A is N*N symmetric band matrix with K = A.Rows()-1 sub- or superdiagonals
+//      stored in triangular-band-packed storage,
+//    X, Y are row or column vectors of length N.
+//
+// flags
+//   LOWER  lower triangular part of A is stored
+//   UPPER  upper triangular part of A is stored
+// Exactly one of UPPER and LOWER must be set. (blas.SBMV)
+func MVMultSymBand(Y, A, X *matrix.FloatMatrix, alpha, beta float64, flags Flags) error {
+    if ! oneOf(flags, UPPER, LOWER) {
+        return flagError("MVMultSymBand", "UPPER or LOWER")
+    }
+    if X.Rows() != 1 && X.Cols() != 1 {
+        return sizeError("MVMultSymBand", Y, A, X)
+    }
+    if Y.Rows() != 1 && Y.Cols() != 1 {
+        return sizeError("MVMultSymBand", Y, A, X)
+    }
+    N := A.Cols()
+    K := A.Rows() - 1
+    if X.NumElements() != N || Y.NumElements() != N {
+        return sizeError("MVMultSymBand", Y, A, X)
+    }
+    Ar := A.FloatArray()
+    ldA := A.LeadingIndex()
+    Xr, incX := vectorData(X)
+    Yr, incY := vectorData(Y)
+
+    // beta == 0 sets Y to zero; NaN and Inf in Y do not propagate (blas semantics)
+    if beta == 0.0 {
+        Y.SetIndexes(0.0)
+    } else if beta != 1.0 {
+        Scale(Y, beta)
+    }
+    for j := 0; j < N; j++ {
+        temp1 := alpha * Xr[j*incX]
+        temp2 := 0.0
+        if flags & LOWER != 0 {
+            Yr[j*incY] += temp1 * Ar[j*ldA]
+            for i := j+1; i <= imin(N-1, j+K); i++ {
+                aij := Ar[i-j+j*ldA]
+                Yr[i*incY] += temp1 * aij
+                temp2 += aij * Xr[i*incX]
+            }
+        } else {
+            for i := imax(0, j-K); i < j; i++ {
+                aij := Ar[K+i-j+j*ldA]
+                Yr[i*incY] += temp1 * aij
+                temp2 += aij * Xr[i*incX]
+            }
+            Yr[j*incY] += temp1 * Ar[K+j*ldA]
+        }
+        Yr[j*incY] += alpha * temp2
+    }
+    return nil
+}
+
+// Triangular band matrix multiplication; X = A*X or X = A.T*X
+//   A is N*N triangular band matrix with K = A.Rows()-1 sub- or superdiagonals
+//     stored in triangular-band-packed storage,
+//   X is row or column vector of length N.
+// flags
+//   LOWER  A is lower triangular
+//   UPPER  A is upper triangular
+//   UNIT   A diagonal is unit
+//   TRANSA A is transpose
+// Exactly one of UPPER and LOWER must be set. (blas.TBMV)
+func MVMultTrmBand(X, A *matrix.FloatMatrix, flags Flags) error {
+    if ! oneOf(flags, UPPER, LOWER) {
+        return flagError("MVMultTrmBand", "UPPER or LOWER")
+    }
+    if X.Rows() != 1 && X.Cols() != 1 {
+        return sizeError("MVMultTrmBand", X, A)
+    }
+    N := A.Cols()
+    K := A.Rows() - 1
+    if X.NumElements() != N {
+        return sizeError("MVMultTrmBand", X, A)
+    }
+    Ar := A.FloatArray()
+    ldA := A.LeadingIndex()
+    Xr, incX := vectorData(X)
+    unit := flags & UNIT != 0
+
+    switch {
+    case flags & UPPER != 0 && flags & TRANSA == 0:
+        for j := 0; j < N; j++ {
+            temp := Xr[j*incX]
+            for i := imax(0, j-K); i < j; i++ {
+                Xr[i*incX] += temp * Ar[K+i-j+j*ldA]
+            }
+            if ! unit {
+                Xr[j*incX] *= Ar[K+j*ldA]
+            }
+        }
+    case flags & UPPER != 0:
+        for j := N-1; j >= 0; j-- {
+            temp := Xr[j*incX]
+            if ! unit {
+                temp *= Ar[K+j*ldA]
+            }
+            for i := j-1; i >= imax(0, j-K); i-- {
+                temp += Ar[K+i-j+j*ldA] * Xr[i*incX]
+            }
+            Xr[j*incX] = temp
+        }
+    case flags & TRANSA == 0:
+        for j := N-1; j >= 0; j-- {
+            temp := Xr[j*incX]
+            for i := imin(N-1, j+K); i > j; i-- {
+                Xr[i*incX] += temp * Ar[i-j+j*ldA]
+            }
+            if ! unit {
+                Xr[j*incX] *= Ar[j*ldA]
+            }
+        }
+    default:
+        for j := 0; j < N; j++ {
+            temp := Xr[j*incX]
+            if ! unit {
+                temp *= Ar[j*ldA]
+            }
+            for i := j+1; i <= imin(N-1, j+K); i++ {
+                temp += Ar[i-j+j*ldA] * Xr[i*incX]
+            }
+            Xr[j*incX] = temp
+        }
+    }
+    return nil
+}
+
+// Triangular band matrix solve; X = alpha*A.-1*X or X = alpha*A.-T*X
+//   A is N*N triangular band matrix with K = A.Rows()-1 sub- or superdiagonals
+//     stored in triangular-band-packed storage,
+//   X is row or column vector of length N.
+// flags
+//   LOWER  A is lower triangular
+//   UPPER  A is upper triangular
+//   UNIT   A diagonal is unit
+//   TRANSA A is transpose
+// Exactly one of UPPER and LOWER must be set. (blas.TBSV)
+func MVSolveTrmBand(X, A *matrix.FloatMatrix, alpha float64, flags Flags) error {
+    if ! oneOf(flags, UPPER, LOWER) {
+        return flagError("MVSolveTrmBand", "UPPER or LOWER")
+    }
+    if X.Rows() != 1 && X.Cols() != 1 {
+        return sizeError("MVSolveTrmBand", X, A)
+    }
+    N := A.Cols()
+    K := A.Rows() - 1
+    if X.NumElements() != N {
+        return sizeError("MVSolveTrmBand", X, A)
+    }
+    Ar := A.FloatArray()
+    ldA := A.LeadingIndex()
+    Xr, incX := vectorData(X)
+    unit := flags & UNIT != 0
+
+    if alpha != 1.0 {
+        Scale(X, alpha)
+    }
+    switch {
+    case flags & UPPER != 0 && flags & TRANSA == 0:
+        for j := N-1; j >= 0; j-- {
+            if ! unit {
+                Xr[j*incX] /= Ar[K+j*ldA]
+            }
+            temp := Xr[j*incX]
+            for i := j-1; i >= imax(0, j-K); i-- {
+                Xr[i*incX] -= temp * Ar[K+i-j+j*ldA]
+            }
+        }
+    case flags & UPPER != 0:
+        for j := 0; j < N; j++ {
+            temp := Xr[j*incX]
+            for i := imax(0, j-K); i < j; i++ {
+                temp -= Ar[K+i-j+j*ldA] * Xr[i*incX]
+            }
+            if ! unit {
+                temp /= Ar[K+j*ldA]
+            }
+            Xr[j*incX] = temp
+        }
+    case flags & TRANSA == 0:
+        for j := 0; j < N; j++ {
+            if ! unit {
+                Xr[j*incX] /= Ar[j*ldA]
+            }
+            temp := Xr[j*incX]
+            for i := j+1; i <= imin(N-1, j+K); i++ {
+                Xr[i*incX] -= temp * Ar[i-j+j*ldA]
+            }
+        }
+    default:
+        for j := N-1; j >= 0; j-- {
+            temp := Xr[j*incX]
+            for i := imin(N-1, j+K); i > j; i-- {
+                temp -= Ar[i-j+j*ldA] * Xr[i*incX]
+            }
+            if ! unit {
+                temp /= Ar[j*ldA]
+            }
+            Xr[j*incX] = temp
+        }
+    }
+    return nil
+}
+
 // Local Variables:
 // tab-width: 4
 // indent-tabs-mode: nil
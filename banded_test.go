@@ -0,0 +1,197 @@
+
+// Copyright (c) Harri Rautila, 2013
+
+// This file is part of github.com/hrautila/matops package. It is free software,
+// distributed under the terms of GNU Lesser General Public License Version 3, or
+// any later version. See the COPYING tile included in this archive.
+
+package matops
+
+import (
+    "github.com/hrautila/matrix"
+    "math"
+    "testing"
+)
+
+// Zero elements of A outside the band with KL subdiagonals and KU superdiagonals.
+func toBand(A *matrix.FloatMatrix, KL, KU int) *matrix.FloatMatrix {
+    for j := 0; j < A.Cols(); j++ {
+        for i := 0; i < A.Rows(); i++ {
+            if i - j > KL || j - i > KU {
+                A.SetAt(i, j, 0.0)
+            }
+        }
+    }
+    return A
+}
+
+// Symmetric positive definite band matrix with K sub- and superdiagonals.
+func symBand(N, K int) *matrix.FloatMatrix {
+    A := toBand(matrix.FloatUniform(N, N), K, K)
+    A = A.Plus(A.Transpose())
+    for i := 0; i < N; i++ {
+        A.SetAt(i, i, A.GetAt(i, i) + float64(N))
+    }
+    return A
+}
+
+func TestMVMultBand(t *testing.T) {
+    N := 40
+    KL := 3
+    KU := 2
+    A := toBand(matrix.FloatUniform(N, N), KL, KU)
+    Ab := BandedMatrix(A, KL, KU)
+    X := matrix.FloatUniform(N, 1)
+    for _, flags := range []Flags{NOTRANS, TRANSA} {
+        Y0 := matrix.FloatUniform(N, 1)
+        Y1 := Y0.Copy()
+        MVMult(Y0, A, X, 2.0, 0.5, flags)
+        MVMultBand(Y1, Ab, X, 2.0, 0.5, KL, flags)
+        Y1.Minus(Y0)
+        t.Logf("flags=%v ||Y(dense) - Y(band)||_1: %e\n", flags, NormP(Y1, NORM_ONE))
+    }
+}
+
+func TestMVMultSymBand(t *testing.T) {
+    N := 40
+    K := 4
+    A := symBand(N, K)
+    X := matrix.FloatUniform(1, N)
+    for _, flags := range []Flags{LOWER, UPPER} {
+        Ab := BandedTrmMatrix(A, K, flags)
+        Y0 := matrix.FloatUniform(1, N)
+        Y1 := Y0.Copy()
+        MVMult(Y0, A, X, 2.0, 0.5, NOTRANS)
+        MVMultSymBand(Y1, Ab, X, 2.0, 0.5, flags)
+        Y1.Minus(Y0)
+        t.Logf("flags=%v ||Y(dense) - Y(band)||_1: %e\n", flags, NormP(Y1, NORM_ONE))
+    }
+}
+
+func TestMVTrmBand(t *testing.T) {
+    N := 40
+    K := 3
+    A := symBand(N, K)
+    for _, flags := range []Flags{LOWER, LOWER|TRANSA, UPPER, UPPER|TRANSA,
+        LOWER|UNIT, UPPER|UNIT|TRANSA} {
+        T := A.Copy()
+        if flags & LOWER != 0 {
+            TriL(T)
+        } else {
+            TriU(T)
+        }
+        Ab := BandedTrmMatrix(T, K, flags)
+        X0 := matrix.FloatUniform(N, 1)
+        X1 := X0.Copy()
+        MVMultTrm(X0, T, flags)
+        MVMultTrmBand(X1, Ab, flags)
+        X1.Minus(X0)
+        nrm := NormP(X1, NORM_ONE)
+        // solve back
+        X0 = matrix.FloatUniform(N, 1)
+        X1 = X0.Copy()
+        MVMultTrmBand(X1, Ab, flags)
+        MVSolveTrmBand(X1, Ab, 1.0, flags)
+        X1.Minus(X0)
+        t.Logf("flags=%v ||X(dense) - X(band)||_1: %e, ||X - A.-1*A*X||_1: %e\n",
+            flags, nrm, NormP(X1, NORM_ONE))
+    }
+}
+
+func TestDecomposeLUBand(t *testing.T) {
+    N := 40
+    KL := 3
+    KU := 4
+    A := toBand(matrix.FloatUniform(N, N), KL, KU)
+    Ab := BandedLUMatrix(A, KL, KU)
+    pivots := make([]int, N)
+    _, err := DecomposeLUBand(Ab, pivots, KL)
+    if err != nil {
+        t.Errorf("decompose error: %v\n", err)
+        return
+    }
+    for _, flags := range []Flags{NOTRANS, TRANSA} {
+        X0 := matrix.FloatUniform(N, 2)
+        B := matrix.FloatZeros(N, 2)
+        Mult(B, A, X0, 1.0, 0.0, flags)
+        err = SolveLUBand(B, Ab, pivots, KL, flags)
+        if err != nil {
+            t.Errorf("flags=%v solve error: %v\n", flags, err)
+        }
+        B.Minus(X0)
+        t.Logf("flags=%v ||X - A.-1*(A*X)||_1: %e\n", flags, NormP(B, NORM_ONE))
+    }
+}
+
+func TestDecomposeCHOLBand(t *testing.T) {
+    N := 40
+    K := 3
+    A := symBand(N, K)
+    for _, flags := range []Flags{LOWER, UPPER} {
+        Ab := BandedTrmMatrix(A, K, flags)
+        _, err := DecomposeCHOLBand(Ab, flags)
+        if err != nil {
+            t.Errorf("flags=%v decompose error: %v\n", flags, err)
+            continue
+        }
+        // compare to dense factorization
+        C := A.Copy()
+        DecomposeCHOL(C, flags, 0)
+        Cb := BandedTrmMatrix(C, K, flags)
+        Cb.Minus(Ab)
+
+        X0 := matrix.FloatUniform(N, 2)
+        B := matrix.FloatZeros(N, 2)
+        Mult(B, A, X0, 1.0, 0.0, NOTRANS)
+        SolveCHOLBand(B, Ab, flags)
+        B.Minus(X0)
+        t.Logf("flags=%v ||C(dense) - C(band)||_1: %e, ||X - A.-1*(A*X)||_1: %e\n",
+            flags, NormP(Cb, NORM_ONE), NormP(B, NORM_ONE))
+    }
+}
+
+func TestBandArguments(t *testing.T) {
+    N := 10
+    K := 2
+    A := symBand(N, K)
+    Ab := BandedTrmMatrix(A, K, LOWER)
+    X := matrix.FloatUniform(N, 1)
+    Y := matrix.FloatZeros(N, 1)
+    for _, flags := range []Flags{NOTRANS, LOWER|UPPER} {
+        if err := MVMultSymBand(Y, Ab, X, 1.0, 0.0, flags); err == nil {
+            t.Errorf("MVMultSymBand flags %x: expected error\n", flags)
+        }
+        if err := MVMultTrmBand(X, Ab, flags); err == nil {
+            t.Errorf("MVMultTrmBand flags %x: expected error\n", flags)
+        }
+        if err := MVSolveTrmBand(X, Ab, 1.0, flags); err == nil {
+            t.Errorf("MVSolveTrmBand flags %x: expected error\n", flags)
+        }
+        if _, err := DecomposeCHOLBand(Ab.Copy(), flags); err == nil {
+            t.Errorf("DecomposeCHOLBand flags %x: expected error\n", flags)
+        }
+        if err := SolveCHOLBand(X.Copy(), Ab, flags); err == nil {
+            t.Errorf("SolveCHOLBand flags %x: expected error\n", flags)
+        }
+    }
+
+    // beta == 0: NaN in Y must not propagate
+    Y0 := matrix.FloatZeros(N, 1)
+    MVMult(Y0, A, X, 1.0, 0.0, NOTRANS)
+    Y.SetIndexes(math.NaN())
+    MVMultSymBand(Y, Ab, X, 1.0, 0.0, LOWER)
+    if d := NormP(Y.Minus(Y0), NORM_ONE); ! (d < 1e-12) {
+        t.Errorf("MVMultSymBand beta=0: ||Y(dense) - Y(band)||_1: %e\n", d)
+    }
+    Ag := BandedMatrix(A, K, K)
+    Y.SetIndexes(math.Inf(1))
+    MVMultBand(Y, Ag, X, 1.0, 0.0, K, NOTRANS)
+    if d := NormP(Y.Minus(Y0), NORM_ONE); ! (d < 1e-12) {
+        t.Errorf("MVMultBand beta=0: ||Y(dense) - Y(band)||_1: %e\n", d)
+    }
+}
+
+// Local Variables:
+// tab-width: 4
+// indent-tabs-mode: nil
+// End:
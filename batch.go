@@ -0,0 +1,416 @@
+// Copyright (c) Harri Rautila, 2013
+
+// This file is part of github.com/hrautila/matops package. It is free software,
+// distributed under the terms of GNU Lesser General Public License Version 3, or
+// any later version. See the COPYING tile included in this archive.
+
+package matops
+
+import (
+    "github.com/hrautila/matops/calgo"
+    "github.com/hrautila/matrix"
+    "context"
+    "fmt"
+    "sync"
+)
+
+// Batched operations on large numbers of small independent matrices. Each worker
+// runs one kernel call over its range of the batch which avoids the per matrix
+// call and scheduling overhead of the single matrix functions.
+
+// Batch of equally sized column-major matrices in one element array. Element
+// (i, j) of matrix k is at index k*Stride() + i + j*LeadingIndex() of FloatArray().
+// Vectors are batches of rows-by-1 matrices.
+type Batch struct {
+    elements []float64
+    count, rows, cols, step, stride int
+}
+
+// Create new batch of count rows*cols matrices of zeros stored consecutively.
+func NewBatch(count, rows, cols int) *Batch {
+    step := imax(1, rows)
+    return &Batch{make([]float64, count*step*cols), count, rows, cols, step, step*cols}
+}
+
+// Create batch on strided 3-D buffer elements; matrix k starts at elements[k*stride]
+// and has leading index ld. Elements are not copied.
+func BatchFromArray(elements []float64, count, rows, cols, ld, stride int) (*Batch, error) {
+    if ld < imax(1, rows) || stride < 0 {
+        return nil, onError("BatchFromArray: invalid leading index or stride")
+    }
+    if count > 0 && rows > 0 && cols > 0 {
+        if len(elements) < (count-1)*stride + (cols-1)*ld + rows {
+            return nil, onError("BatchFromArray: array too short")
+        }
+        if stride < (cols-1)*ld + rows && count > 1 {
+            return nil, onError("BatchFromArray: matrices overlap")
+        }
+    }
+    return &Batch{elements, count, rows, cols, ld, stride}, nil
+}
+
+// Create batch with copies of equally sized matrices mats.
+func BatchOf(mats []*matrix.FloatMatrix) (*Batch, error) {
+    if len(mats) == 0 {
+        return NewBatch(0, 0, 0), nil
+    }
+    b := NewBatch(len(mats), mats[0].Rows(), mats[0].Cols())
+    for k, A := range mats {
+        if err := b.Set(k, A); err != nil {
+            return nil, err
+        }
+    }
+    return b, nil
+}
+
+// Create batch of column vectors with copies of equally sized vectors vecs.
+func batchOfVectors(vecs []*matrix.FloatMatrix) (*Batch, error) {
+    if len(vecs) == 0 {
+        return NewBatch(0, 0, 1), nil
+    }
+    b := NewBatch(len(vecs), vecs[0].NumElements(), 1)
+    for k, X := range vecs {
+        if err := b.Set(k, X); err != nil {
+            return nil, err
+        }
+    }
+    return b, nil
+}
+
+func (b *Batch) Count() int {
+    return b.count
+}
+
+func (b *Batch) Rows() int {
+    return b.rows
+}
+
+func (b *Batch) Cols() int {
+    return b.cols
+}
+
+func (b *Batch) LeadingIndex() int {
+    return b.step
+}
+
+func (b *Batch) Stride() int {
+    return b.stride
+}
+
+func (b *Batch) FloatArray() []float64 {
+    return b.elements
+}
+
+// Return copy of matrix k.
+func (b *Batch) Get(k int) (*matrix.FloatMatrix, error) {
+    A := matrix.FloatZeros(b.rows, b.cols)
+    if err := b.CopyTo(A, k); err != nil {
+        return nil, err
+    }
+    return A, nil
+}
+
+// Copy matrix k to A. A may also be a row or column vector if batch is a batch
+// of vectors.
+func (b *Batch) CopyTo(A *matrix.FloatMatrix, k int) error {
+    if k < 0 || k >= b.count {
+        return onError(fmt.Sprintf("Batch: matrix index %d out of range", k))
+    }
+    Br := b.elements[k*b.stride:]
+    if b.cols == 1 && isVector(A) && A.NumElements() == b.rows {
+        for i := 0; i < b.rows; i++ {
+            if A.Cols() == 1 {
+                A.SetAt(i, 0, Br[i])
+            } else {
+                A.SetAt(0, i, Br[i])
+            }
+        }
+        return nil
+    }
+    if A.Rows() != b.rows || A.Cols() != b.cols {
+        return onError(fmt.Sprintf("Batch: matrix %d size mismatch", k))
+    }
+    for j := 0; j < b.cols; j++ {
+        for i := 0; i < b.rows; i++ {
+            A.SetAt(i, j, Br[i + j*b.step])
+        }
+    }
+    return nil
+}
+
+// Copy A to matrix k. A may also be a row or column vector if batch is a batch
+// of vectors.
+func (b *Batch) Set(k int, A *matrix.FloatMatrix) error {
+    if k < 0 || k >= b.count {
+        return onError(fmt.Sprintf("Batch: matrix index %d out of range", k))
+    }
+    Br := b.elements[k*b.stride:]
+    if b.cols == 1 && isVector(A) && A.NumElements() == b.rows {
+        for i := 0; i < b.rows; i++ {
+            if A.Cols() == 1 {
+                Br[i] = A.GetAt(i, 0)
+            } else {
+                Br[i] = A.GetAt(0, i)
+            }
+        }
+        return nil
+    }
+    if A.Rows() != b.rows || A.Cols() != b.cols {
+        return onError(fmt.Sprintf("Batch: matrix %d size mismatch", k))
+    }
+    for j := 0; j < b.cols; j++ {
+        for i := 0; i < b.rows; i++ {
+            Br[i + j*b.step] = A.GetAt(i, j)
+        }
+    }
+    return nil
+}
+
+// Copy matrices of batch to mats.
+func (b *Batch) copyOut(mats []*matrix.FloatMatrix) {
+    for k, A := range mats {
+        b.CopyTo(A, k)
+    }
+}
+
+// Run worker on ranges of batch of count matrices each costing about flops
+// operations. Worker returns index of first failed matrix in its range or -1
+// and the failing column of it; returned is the smallest index over all ranges.
+func scheduleBatch(count int, flops int64, worker func(S, E int) (int, int)) (int, int, error) {
+    conf := globalConfig(nil)
+    if int64(count)*flops <= conf.LimitOne || conf.Executor.Workers() <= 1 {
+        first, col := worker(0, count)
+        return first, col, nil
+    }
+    var mu sync.Mutex
+    first, col := -1, 0
+    err := scheduleRanges(context.Background(), conf.Executor, count, func(S, E int) {
+        if k, c := worker(S, E); k >= 0 {
+            mu.Lock()
+            if first < 0 || k < first {
+                first, col = k, c
+            }
+            mu.Unlock()
+        }
+    })
+    return first, col, err
+}
+
+// Batched generic matrix-matrix multiply: C[k] = beta*C[k] + alpha*op(A[k])*op(B[k])
+// for all k where op(X) is X or X.T. See Mult().
+func BatchMultStrided(C, A, B *Batch, alpha, beta float64, flags Flags) error {
+    if A.Count() != C.Count() || B.Count() != C.Count() {
+        return onError("BatchMult: batch size mismatch")
+    }
+    ok, empty := checkMult(C, A, B, flags)
+    if empty || C.Count() == 0 {
+        return nil
+    }
+    if ! ok {
+        return onError("BatchMult: size mismatch")
+    }
+    P := A.Cols()
+    if flags & TRANSA != 0 {
+        P = A.Rows()
+    }
+    M, N := C.Rows(), C.Cols()
+    _, _, err := scheduleBatch(C.Count(), int64(M)*int64(N)*int64(P), func(S, E int) (int, int) {
+        calgo.DBatchMult(C.elements, A.elements, B.elements, alpha, beta, calgo.Flags(flags),
+            M, N, P, C.step, A.step, B.step, C.stride, A.stride, B.stride, S, E)
+        return -1, 0
+    })
+    return err
+}
+
+// Batched matrix-vector multiply: Y[k] = beta*Y[k] + alpha*op(A[k])*X[k] for all k
+// where op(A) is A or A.T. Y and X are batches of vectors. See MVMult().
+func BatchMVMultStrided(Y, A, X *Batch, alpha, beta float64, flags Flags) error {
+    if A.Count() != Y.Count() || X.Count() != Y.Count() {
+        return onError("BatchMVMult: batch size mismatch")
+    }
+    if Y.Cols() != 1 || X.Cols() != 1 {
+        return onError("BatchMVMult: X or Y not a vector batch")
+    }
+    M, N := A.Rows(), A.Cols()
+    ny, nx := M, N
+    if flags & TRANSA != 0 {
+        ny, nx = N, M
+    }
+    if Y.Rows() != ny || X.Rows() != nx {
+        return onError("BatchMVMult: size mismatch")
+    }
+    if M == 0 || N == 0 || Y.Count() == 0 {
+        return nil
+    }
+    _, _, err := scheduleBatch(Y.Count(), int64(M)*int64(N), func(S, E int) (int, int) {
+        calgo.DBatchMultMV(Y.elements, A.elements, X.elements, alpha, beta, calgo.Flags(flags),
+            M, N, A.step, Y.stride, A.stride, X.stride, S, E)
+        return -1, 0
+    })
+    return err
+}
+
+// Batched LU factorization and solve: A[k] = P[k]*L[k]*U[k] and B[k] = A[k].-1*B[k],
+// or B[k] = A[k].-T*B[k] if flags&TRANSA, for all k. Matrices A[k] are overwritten
+// with their LU factors and pivot indices of A[k] are stored in pivots[k*N:(k+1)*N]
+// as in DecomposeLU(); the factors may be reused with SolveLU(). If pivots is nil
+// pivot indices are discarded. If any of A[k] is singular *BatchError with
+// index of the first one and its *SingularError is returned; the other matrices
+// are solved.
+func BatchSolveLUStrided(B, A *Batch, pivots []int, flags Flags) error {
+    if A.Count() != B.Count() {
+        return onError("BatchSolveLU: batch size mismatch")
+    }
+    N := A.Rows()
+    if A.Cols() != N {
+        return onError("BatchSolveLU: A not a square matrix batch")
+    }
+    if B.Rows() != N {
+        return onError("BatchSolveLU: size mismatch")
+    }
+    if N == 0 || A.Count() == 0 {
+        return nil
+    }
+    if pivots == nil {
+        pivots = make([]int, A.Count()*N)
+    }
+    if len(pivots) < A.Count()*N {
+        return onError("BatchSolveLU: pivot array too short")
+    }
+    K := B.Cols()
+    first, col, err := scheduleBatch(A.Count(), int64(N)*int64(N)*int64(N+K), func(S, E int) (int, int) {
+        return calgo.DBatchSolveLU(B.elements, A.elements, pivots, calgo.Flags(flags),
+            N, K, B.step, A.step, B.stride, A.stride, N, S, E)
+    })
+    if err != nil {
+        return err
+    }
+    if first >= 0 {
+        return &BatchError{"BatchSolveLU", first, &SingularError{col}}
+    }
+    return nil
+}
+
+// Batched Cholesky factorization A[k] = L[k]*L[k].T or A[k] = U[k].T*U[k] if
+// flags&UPPER for all k. If any of A[k] is not positive definite *BatchError with
+// index of the first one and its *NotPositiveDefiniteError is returned; the other
+// matrices are factored. See DecomposeCHOL().
+func BatchDecomposeCHOLStrided(A *Batch, flags Flags) error {
+    N := A.Rows()
+    if A.Cols() != N {
+        return onError("BatchDecomposeCHOL: A not a square matrix batch")
+    }
+    if N == 0 || A.Count() == 0 {
+        return nil
+    }
+    first, col, err := scheduleBatch(A.Count(), int64(N)*int64(N)*int64(N), func(S, E int) (int, int) {
+        return calgo.DBatchCHOL(A.elements, calgo.Flags(flags), N, A.step, A.stride, S, E)
+    })
+    if err != nil {
+        return err
+    }
+    if first >= 0 {
+        return &BatchError{"BatchDecomposeCHOL", first, &NotPositiveDefiniteError{col}}
+    }
+    return nil
+}
+
+// Batched generic matrix-matrix multiply on equally sized matrices C[k], A[k]
+// and B[k]. Matrices are copied to strided batches for the operation.
+// See BatchMultStrided().
+func BatchMult(C, A, B []*matrix.FloatMatrix, alpha, beta float64, flags Flags) error {
+    if len(A) != len(C) || len(B) != len(C) {
+        return onError("BatchMult: batch size mismatch")
+    }
+    Cb, err := BatchOf(C)
+    if err != nil {
+        return err
+    }
+    Ab, err := BatchOf(A)
+    if err != nil {
+        return err
+    }
+    Bb, err := BatchOf(B)
+    if err != nil {
+        return err
+    }
+    if err = BatchMultStrided(Cb, Ab, Bb, alpha, beta, flags); err != nil {
+        return err
+    }
+    Cb.copyOut(C)
+    return nil
+}
+
+// Batched matrix-vector multiply on equally sized matrices A[k] and vectors Y[k]
+// and X[k]. See BatchMVMultStrided().
+func BatchMVMult(Y, A, X []*matrix.FloatMatrix, alpha, beta float64, flags Flags) error {
+    if len(A) != len(Y) || len(X) != len(Y) {
+        return onError("BatchMVMult: batch size mismatch")
+    }
+    Yb, err := batchOfVectors(Y)
+    if err != nil {
+        return err
+    }
+    Ab, err := BatchOf(A)
+    if err != nil {
+        return err
+    }
+    Xb, err := batchOfVectors(X)
+    if err != nil {
+        return err
+    }
+    if err = BatchMVMultStrided(Yb, Ab, Xb, alpha, beta, flags); err != nil {
+        return err
+    }
+    Yb.copyOut(Y)
+    return nil
+}
+
+// Batched LU factorization and solve on equally sized matrices A[k] and B[k].
+// If pivots is not nil pivots[k] receives pivot indices of A[k]. See
+// BatchSolveLUStrided().
+func BatchSolveLU(B, A []*matrix.FloatMatrix, pivots [][]int, flags Flags) error {
+    if len(A) != len(B) || (pivots != nil && len(pivots) != len(A)) {
+        return onError("BatchSolveLU: batch size mismatch")
+    }
+    Bb, err := BatchOf(B)
+    if err != nil {
+        return err
+    }
+    Ab, err := BatchOf(A)
+    if err != nil {
+        return err
+    }
+    N := Ab.Rows()
+    for _, p := range pivots {
+        if len(p) < N {
+            return onError("BatchSolveLU: pivot array too short")
+        }
+    }
+    piv := make([]int, len(A)*N)
+    err = BatchSolveLUStrided(Bb, Ab, piv, flags)
+    // results of the non-singular matrices are valid also on error
+    Ab.copyOut(A)
+    Bb.copyOut(B)
+    for k, p := range pivots {
+        copy(p, piv[k*N:(k+1)*N])
+    }
+    return err
+}
+
+// Batched Cholesky factorization of equally sized matrices A[k]. See
+// BatchDecomposeCHOLStrided().
+func BatchDecomposeCHOL(A []*matrix.FloatMatrix, flags Flags) error {
+    Ab, err := BatchOf(A)
+    if err != nil {
+        return err
+    }
+    err = BatchDecomposeCHOLStrided(Ab, flags)
+    Ab.copyOut(A)
+    return err
+}
+
+// Local Variables:
+// tab-width: 4
+// indent-tabs-mode: nil
+// End:
@@ -0,0 +1,239 @@
+
+// Copyright (c) Harri Rautila, 2013
+
+// This file is part of github.com/hrautila/matops package. It is free software,
+// distributed under the terms of GNU Lesser General Public License Version 3, or
+// any later version. See the COPYING tile included in this archive.
+
+package matops
+
+import (
+    "errors"
+    "github.com/hrautila/matrix"
+    "testing"
+)
+
+func batchNormal(count, M, N int) []*matrix.FloatMatrix {
+    mats := make([]*matrix.FloatMatrix, count)
+    for k := range mats {
+        mats[k] = matrix.FloatNormal(M, N)
+    }
+    return mats
+}
+
+func batchCopy(mats []*matrix.FloatMatrix) []*matrix.FloatMatrix {
+    c := make([]*matrix.FloatMatrix, len(mats))
+    for k, A := range mats {
+        c[k] = A.Copy()
+    }
+    return c
+}
+
+// symmetric positive definite N*N matrix
+func spdNormal(N int) *matrix.FloatMatrix {
+    Z := matrix.FloatNormal(N, N)
+    A := matrix.FloatZeros(N, N)
+    Mult(A, Z, Z, 1.0, 0.0, TRANSB)
+    for k := 0; k < N; k++ {
+        A.SetAt(k, k, A.GetAt(k, k) + float64(N))
+    }
+    return A
+}
+
+// run test serially and with multiple workers
+func batchModes(t *testing.T, test func()) {
+    nw := NumWorkers(4)
+    defer NumWorkers(nw)
+    lim := limitOne
+    defer func() { limitOne = lim }()
+    test()
+    limitOne = 1
+    test()
+}
+
+func TestBatchMult(t *testing.T) {
+    count, M, N, P := 37, 7, 5, 6
+    batchModes(t, func() {
+        for _, flags := range []Flags{NOTRANS, TRANSA, TRANSB, TRANSA|TRANSB} {
+            A := batchNormal(count, M, P)
+            if flags & TRANSA != 0 {
+                A = batchNormal(count, P, M)
+            }
+            B := batchNormal(count, P, N)
+            if flags & TRANSB != 0 {
+                B = batchNormal(count, N, P)
+            }
+            C := batchNormal(count, M, N)
+            C0 := batchCopy(C)
+            if err := BatchMult(C, A, B, 2.0, 0.5, flags); err != nil {
+                t.Fatalf("BatchMult: %v\n", err)
+            }
+            for k := range C {
+                Mult(C0[k], A[k], B[k], 2.0, 0.5, flags)
+                if d := NormP(C[k].Minus(C0[k]), NORM_ONE); d > 1e-12 {
+                    t.Errorf("BatchMult flags %x matrix %d: ||C - C0||_1: %e\n", flags, k, d)
+                }
+            }
+        }
+        if err := BatchMult(batchNormal(3, 4, 4), batchNormal(3, 4, 5), batchNormal(3, 4, 4),
+            1.0, 0.0, NOTRANS); err == nil {
+            t.Errorf("BatchMult: size mismatch not detected")
+        }
+    })
+}
+
+func TestBatchMVMult(t *testing.T) {
+    count, M, N := 29, 9, 6
+    batchModes(t, func() {
+        for _, flags := range []Flags{NOTRANS, TRANSA} {
+            A := batchNormal(count, M, N)
+            X := batchNormal(count, N, 1)
+            Y := batchNormal(count, M, 1)
+            if flags & TRANSA != 0 {
+                // row vectors
+                X = batchNormal(count, 1, M)
+                Y = batchNormal(count, 1, N)
+            }
+            Y0 := batchCopy(Y)
+            if err := BatchMVMult(Y, A, X, -1.0, 2.0, flags); err != nil {
+                t.Fatalf("BatchMVMult: %v\n", err)
+            }
+            for k := range Y {
+                MVMult(Y0[k], A[k], X[k], -1.0, 2.0, flags)
+                if d := NormP(Y[k].Minus(Y0[k]), NORM_ONE); d > 1e-12 {
+                    t.Errorf("BatchMVMult flags %x vector %d: ||Y - Y0||_1: %e\n", flags, k, d)
+                }
+            }
+        }
+    })
+}
+
+func TestBatchSolveLU(t *testing.T) {
+    count, N, K := 41, 8, 3
+    batchModes(t, func() {
+        for _, flags := range []Flags{NOTRANS, TRANSA} {
+            A := batchNormal(count, N, N)
+            B := batchNormal(count, N, K)
+            A0, X := batchCopy(A), batchCopy(B)
+            pivots := make([][]int, count)
+            for k := range pivots {
+                pivots[k] = make([]int, N)
+            }
+            if err := BatchSolveLU(X, A, pivots, flags); err != nil {
+                t.Fatalf("BatchSolveLU: %v\n", err)
+            }
+            for k := range X {
+                // ||B - op(A)*X||_1/(||A||_1*||X||_1)
+                if d := mixedResidual(B[k], A0[k], X[k], flags); d > 1e-14 {
+                    t.Errorf("BatchSolveLU flags %x matrix %d: relative residual: %e\n", flags, k, d)
+                }
+                // factors usable with SolveLU
+                Y := B[k].Copy()
+                SolveLU(Y, A[k], pivots[k], flags)
+                if d := mixedResidual(B[k], A0[k], Y, flags); d > 1e-14 {
+                    t.Errorf("BatchSolveLU matrix %d: SolveLU relative residual: %e\n", k, d)
+                }
+            }
+        }
+        A := batchNormal(count, N, N)
+        B := batchNormal(count, N, K)
+        A[count-3] = matrix.FloatZeros(N, N)
+        A[count-2] = matrix.FloatZeros(N, N)
+        var berr *BatchError
+        var serr *SingularError
+        err := BatchSolveLU(B, A, nil, NOTRANS)
+        if ! errors.As(err, &berr) || ! errors.As(err, &serr) {
+            t.Errorf("BatchSolveLU: expected *BatchError with *SingularError, got %v\n", err)
+        } else if berr.Index != count-3 || serr.Index != 0 {
+            t.Errorf("BatchSolveLU: matrix %d pivot %d, expected matrix %d pivot 0\n",
+                berr.Index, serr.Index, count-3)
+        }
+    })
+}
+
+func TestBatchDecomposeCHOL(t *testing.T) {
+    count, N := 33, 12
+    batchModes(t, func() {
+        for _, flags := range []Flags{LOWER, UPPER} {
+            A := make([]*matrix.FloatMatrix, count)
+            for k := range A {
+                A[k] = spdNormal(N)
+            }
+            A0 := batchCopy(A)
+            if err := BatchDecomposeCHOL(A, flags); err != nil {
+                t.Fatalf("BatchDecomposeCHOL: %v\n", err)
+            }
+            for k := range A {
+                R, _ := DecomposeCHOL(A0[k], flags, 0)
+                if flags & LOWER != 0 {
+                    TriL(A[k])
+                    TriL(R)
+                } else {
+                    TriU(A[k])
+                    TriU(R)
+                }
+                if d := NormP(A[k].Minus(R), NORM_ONE); d > 1e-12 {
+                    t.Errorf("BatchDecomposeCHOL flags %x matrix %d: ||R - R0||_1: %e\n", flags, k, d)
+                }
+            }
+        }
+        A := []*matrix.FloatMatrix{spdNormal(N), spdNormal(N), spdNormal(N)}
+        A[1].SetAt(5, 5, -1.0)
+        var berr *BatchError
+        var perr *NotPositiveDefiniteError
+        err := BatchDecomposeCHOL(A, LOWER)
+        if ! errors.As(err, &berr) || ! errors.As(err, &perr) {
+            t.Errorf("BatchDecomposeCHOL: expected *BatchError with *NotPositiveDefiniteError, got %v\n", err)
+        } else if berr.Index != 1 || perr.Column != 5 {
+            t.Errorf("BatchDecomposeCHOL: matrix %d column %d, expected matrix 1 column 5\n",
+                berr.Index, perr.Column)
+        }
+    })
+}
+
+func TestBatchStrided(t *testing.T) {
+    // 3-D buffer of 10 matrices 5*4 with leading index 7 and stride 30
+    count, M, N, ld, stride := 10, 5, 4, 7, 30
+    elems := matrix.FloatNormal(count*stride, 1).FloatArray()
+    A, err := BatchFromArray(elems, count, M, N, ld, stride)
+    if err != nil {
+        t.Fatalf("BatchFromArray: %v\n", err)
+    }
+    B := NewBatch(count, N, N)
+    for k := 0; k < count; k++ {
+        B.Set(k, matrix.FloatNormal(N, N))
+    }
+    C := NewBatch(count, M, N)
+    if err := BatchMultStrided(C, A, B, 1.0, 0.0, NOTRANS); err != nil {
+        t.Fatalf("BatchMultStrided: %v\n", err)
+    }
+    for k := 0; k < count; k++ {
+        Ak, _ := A.Get(k)
+        if Ak.GetAt(2, 3) != elems[k*stride + 2 + 3*ld] {
+            t.Errorf("Batch.Get: element mismatch")
+        }
+        Bk, _ := B.Get(k)
+        Ck, _ := C.Get(k)
+        C0 := matrix.FloatZeros(M, N)
+        Mult(C0, Ak, Bk, 1.0, 0.0, NOTRANS)
+        if d := NormP(Ck.Minus(C0), NORM_ONE); d > 1e-12 {
+            t.Errorf("BatchMultStrided matrix %d: ||C - C0||_1: %e\n", k, d)
+        }
+    }
+    for _, k := range []int{-1, count} {
+        if _, err := A.Get(k); err == nil {
+            t.Errorf("Batch.Get(%d): index out of range not detected\n", k)
+        }
+        if err := A.Set(k, matrix.FloatZeros(M, N)); err == nil {
+            t.Errorf("Batch.Set(%d): index out of range not detected\n", k)
+        }
+    }
+    if _, err := BatchFromArray(elems, count, M, N, ld, 20); err == nil {
+        t.Errorf("BatchFromArray: overlapping matrices not detected")
+    }
+}
+
+// Local Variables:
+// tab-width: 4
+// indent-tabs-mode: nil
+// End:
@@ -0,0 +1,136 @@
+// Copyright (c) Harri Rautila, 2012,2013
+
+// This file is part of github.com/hrautila/matops package. It is free software,
+// distributed under the terms of GNU Lesser General Public License Version 3, or
+// any later version. See the COPYING tile included in this archive.
+
+
+package calgo
+
+// Batched kernels on strided buffers against single matrix kernels.
+
+import (
+    "math"
+    "math/rand"
+    "testing"
+)
+
+func randBuffer(n int) []float64 {
+    b := make([]float64, n)
+    for k := range b {
+        b[k] = rand.Float64() - 0.5
+    }
+    return b
+}
+
+func maxDiffD(t *testing.T, name string, x, y []float64) {
+    var d float64
+    for k := range x {
+        d = math.Max(d, math.Abs(x[k] - y[k]))
+    }
+    if d > 1e-12 {
+        t.Errorf("%s: max difference %e\n", name, d)
+    }
+}
+
+func TestKernelBatchMult(t *testing.T) {
+    // 8 matrices with leading index 9 and stride 60; [S:E] of the batch computed
+    m, n, p, ld, str := 7, 5, 6, 9, 60
+    S, E := 2, 6
+    for _, trans := range []Flags{NOTRANS, TRANSA, TRANSB, TRANSA|TRANSB} {
+        A, B, C := randBuffer(8*str), randBuffer(8*str), randBuffer(8*str)
+        C0 := append([]float64(nil), C...)
+        DBatchMult(C, A, B, 1.5, 0.5, trans, m, n, p, ld, ld, ld, str, str, str, S, E)
+        for k := S; k < E; k++ {
+            DMult(C0[k*str:], A[k*str:], B[k*str:], 1.5, 0.5, trans, ld, ld, ld, p,
+                0, n, 0, m, 0, 0, 0)
+        }
+        maxDiffD(t, "DBatchMult", C, C0)
+    }
+    for _, trans := range []Flags{NOTRANS, TRANSA} {
+        A, X, Y := randBuffer(8*str), randBuffer(8*str), randBuffer(8*str)
+        Y0 := append([]float64(nil), Y...)
+        DBatchMultMV(Y, A, X, -1.0, 2.0, trans, m, n, ld, str, str, str, S, E)
+        for k := S; k < E; k++ {
+            if trans == NOTRANS {
+                DMultMV(Y0[k*str:], A[k*str:], X[k*str:], -1.0, 2.0, trans, 1, ld, 1, 0, n, 0, m, 0, 0)
+            } else {
+                DMultMV(Y0[k*str:], A[k*str:], X[k*str:], -1.0, 2.0, trans, 1, ld, 1, 0, m, 0, n, 0, 0)
+            }
+        }
+        maxDiffD(t, "DBatchMultMV", Y, Y0)
+    }
+}
+
+func TestKernelBatchSolve(t *testing.T) {
+    n, nrhs, ld, str := 6, 3, 8, 50
+    count := 5
+    for _, trans := range []Flags{NOTRANS, TRANSA} {
+        A, B := randBuffer(count*str), randBuffer(count*str)
+        A0, B0 := append([]float64(nil), A...), append([]float64(nil), B...)
+        piv := make([]int, count*n)
+        if k, _ := DBatchSolveLU(B, A, piv, trans, n, nrhs, ld, ld, str, str, n, 0, count); k >= 0 {
+            t.Fatalf("DBatchSolveLU: matrix %d singular\n", k)
+        }
+        // B0 - op(A0)*X
+        for k := 0; k < count; k++ {
+            DMult(B0[k*str:], A0[k*str:], B[k*str:], -1.0, 1.0, trans, ld, ld, ld, n,
+                0, nrhs, 0, n, 0, 0, 0)
+            for j := 0; j < nrhs; j++ {
+                for i := 0; i < n; i++ {
+                    if math.Abs(B0[k*str + i + j*ld]) > 1e-10 {
+                        t.Fatalf("DBatchSolveLU: matrix %d residual %e\n", k, B0[k*str + i + j*ld])
+                    }
+                }
+            }
+        }
+    }
+
+    for _, uplo := range []Flags{LOWER, UPPER} {
+        // A = 1/2*(Z + Z.T) + n*I is positive definite
+        A := randBuffer(count*str)
+        for k := 0; k < count; k++ {
+            Ak := A[k*str:]
+            for j := 0; j < n; j++ {
+                for i := 0; i < j; i++ {
+                    Ak[i + j*ld] = 0.5*(Ak[i + j*ld] + Ak[j + i*ld])
+                    Ak[j + i*ld] = Ak[i + j*ld]
+                }
+                Ak[j + j*ld] += float64(n)
+            }
+        }
+        A0 := append([]float64(nil), A...)
+        A[3*str + 2 + 2*ld] = -1.0
+        if k, col := DBatchCHOL(A, uplo, n, ld, str, 0, count); k != 3 || col != 2 {
+            t.Errorf("DBatchCHOL: returned %d, column %d, expected 3, column 2\n", k, col)
+        }
+        // reconstruct element (i, j) of the factored matrices
+        for k := 0; k < count; k++ {
+            if k == 3 {
+                continue
+            }
+            Ak := A[k*str:]
+            for j := 0; j < n; j++ {
+                for i := j; i < n; i++ {
+                    var s float64
+                    for l := 0; l <= j; l++ {
+                        if uplo == LOWER {
+                            s += Ak[i + l*ld]*Ak[j + l*ld]
+                        } else {
+                            s += Ak[l + i*ld]*Ak[l + j*ld]
+                        }
+                    }
+                    if math.Abs(s - A0[k*str + i + j*ld]) > 1e-10 {
+                        t.Fatalf("DBatchCHOL flags %x: matrix %d residual %e\n", uplo, k,
+                            s - A0[k*str + i + j*ld])
+                    }
+                }
+            }
+        }
+    }
+}
+
+// Local Variables:
+// tab-width: 4
+// indent-tabs-mode: nil
+// End:
@@ -1,4 +1,6 @@
 
+//go:build cgo && amd64 && !purego
+
 // Copyright (c) Harri Rautila, 2012,2013
 
 // This file is part of github.com/hrautila/matops package. It is free software,
@@ -14,20 +16,10 @@ package calgo
 // #cgo LDFLAGS: -lm
 // #include "cmops.h"
 import "C"
-import "unsafe"
-
-type Flags int
-const (
-    TRANSA = (1 << iota)        // 0x1  ; transpose A
-    TRANSB                      // 0x2  ; transpose B
-    LOWER                       // 0x4  ; lower tridiagonal
-    UPPER                       // 0x8  ; upper tridiagonal
-    LEFT                        // 0x10 ; A on left side
-    RIGHT                       // 0x20 ; A on right side
-    UNIT                        // 0x40 ; unit diagonal
-    TRANS                       // 0x80 ; generic transpose
-    NOTRANS = 0
-    NULL = 0
+import (
+    "errors"
+    "os"
+    "unsafe"
 )
 
 // Matrix-Matrix operators
@@ -704,6 +696,43 @@ func DScal(X []float64, alpha float64, incX, N int) {
         C.double(alpha), C.int(N))
 }
 
+// Kernel sets for matrix-matrix and matrix-vector products. AVX2 kernels require
+// AVX2 and FMA instructions and are used by default if CPU supports them.
+var kernelNames = map[string]C.int{
+    "sse": C.KERNELS_SSE,
+    "avx2": C.KERNELS_AVX2,
+}
+
+func init() {
+    C.dmops_set_kernels(C.dmops_cpu_kernels())
+    if name := os.Getenv("MATOPS_KERNELS"); name != "" {
+        SetKernels(name)
+    }
+}
+
+// Return name of the current kernel set, "avx2" or "sse".
+func Kernels() string {
+    for name, k := range kernelNames {
+        if k == C.dmops_kernels {
+            return name
+        }
+    }
+    return ""
+}
+
+// Select kernel set by name. Returns error if name is unknown or CPU does not
+// support the kernel set. Not safe to call while operations are running.
+func SetKernels(name string) error {
+    k, ok := kernelNames[name]
+    if ! ok {
+        return errors.New("unknown kernel set: " + name)
+    }
+    if C.dmops_set_kernels(k) != 0 {
+        return errors.New("kernel set not supported by CPU: " + name)
+    }
+    return nil
+}
+
 
 // Local Variables:
 // tab-width: 4
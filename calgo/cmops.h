@@ -10,6 +10,8 @@
 #define _CMOPS_H
 
 #include <stdio.h>
+#include <complex.h>
+#include <stdint.h>
 
 enum {
   MTX_NOTRANS = 0,
@@ -88,6 +90,43 @@ extern void
 vpur_daxpy(double *Cc, const double *Aroot, const double *Bc, double alpha,
                 int ldC, int ldA, int ldB, int nSL, int nRE, int nVP);
 
+// Kernel sets selected at runtime; dmops_cpu_kernels() returns the best one
+// supported by CPU and dmops_set_kernels() makes it current.
+enum {
+  KERNELS_SSE  = 0,
+  KERNELS_AVX2 = 1
+};
+
+extern int dmops_kernels;
+
+extern int dmops_cpu_kernels(void);
+extern int dmops_set_kernels(int kernels);
+
+// Micro-kernel for C += alpha*A.T*B on copied blocks; columns of A, B are
+// nVP long and C is nRE*nSL.
+typedef void (*dblock_ddot_t)(double *Cc, const double *Aroot, const double *Bc, double alpha,
+                              int ldC, int ldA, int ldB, int nSL, int nRE, int nVP);
+
+extern dblock_ddot_t _dblock_ddot;
+
+extern void
+_dblock_ddot_sse(double *Cc, const double *Aroot, const double *Bc, double alpha,
+                 int ldC, int ldA, int ldB, int nSL, int nRE, int nVP);
+
+extern void
+_dblock_ddot_avx2(double *Cc, const double *Aroot, const double *Bc, double alpha,
+                  int ldC, int ldA, int ldB, int nSL, int nRE, int nVP);
+
+// GEMV kernels for AVX2;  Y[j] += alpha*A[:,j]*X for nC columns of length nVP
+// and Y += alpha*A*X for nRE rows and nC columns.
+extern void
+_dmvec_ddot_avx2(double *Yc, const double *Ac, const double *Xc, double alpha,
+                 int incY, int ldA, int nVP, int nC);
+
+extern void
+_dmvec_daxpy_avx2(double *Yc, const double *Ac, const double *Xc, double alpha,
+                  int ldA, int incX, int nRE, int nC);
+
 extern void
 _dblock_mult_panel(mdata_t *C, const mdata_t *A, const mdata_t *B,
                    double alpha, int flags, 
@@ -225,6 +264,132 @@ dmmat_trmupd_blk(mdata_t *C, const mdata_t *A, const mdata_t *B,
                  double alpha, double beta,
                  int flags,  int P, int S, int E, int vlen, int NB);
 
+// Single precision kernels; matrix and vector data as in mdata_t and mvec_t.
+typedef struct smdata {
+  float *md;
+  int step;
+} smdata_t;
+
+typedef struct smvec {
+  float *md;
+  int inc;
+} smvec_t;
+
+extern void svec_axpy(smvec_t *Y,  const smvec_t *X, float alpha, int N);
+extern void svec_scal(smvec_t *X,  float alpha, int N);
+extern void svec_invscal(smvec_t *X,  float alpha, int N);
+extern int svec_iamax(const smvec_t *X,  int N);
+extern float svec_nrm2(const smvec_t *X,  int N);
+extern float svec_dot(const smvec_t *X,  const smvec_t *Y, float alpha, int N);
+extern void svec_swap(smvec_t *X,  smvec_t *Y, int N);
+
+// matrix-matrix: C = alpha* A*B + beta*C  (GEMM)
+extern void
+smult_mm_blocked(smdata_t *C, const smdata_t *A, const smdata_t *B,
+                 float alpha, float beta, int flags,
+                 int P, int S, int L, int R, int E, int vlen);
+
+// matrix-vector: Y = alpha*A*X + beta*Y (GEMV)
+extern void
+smult_gemv(smvec_t *Y, const smdata_t *A, const smvec_t *X,
+           float alpha, float beta, int flags, int S, int L, int R, int E);
+
+// A = A + alpha * x * y.T (GER)
+extern void
+smvec_rank(smdata_t *A, const smvec_t *X, const smvec_t *Y, float alpha, 
+           int S, int L, int R, int E);
+
+// A = A + alpha * X * X.T; (SYR)
+extern void
+smvec_symv_rank(smdata_t *A, const smvec_t *X,  float alpha, int flags, int S, int L);
+
+// for TRSM
+extern void
+smmat_solve(smdata_t *B, const smdata_t *A, float alpha, int flags, int N, int S, int E);
+
+// for SYRK
+extern void
+smmat_rank(smdata_t *C, const smdata_t *A, float alpha, float beta,
+           int flags,  int P, int S, int E, int vlen);
+
+// Double precision complex kernels; matrix and vector data as in mdata_t and mvec_t.
+// Flags TRANSA and TRANSB select the conjugate transpose of the operand.
+typedef struct zmdata {
+  double complex *md;
+  int step;
+} zmdata_t;
+
+typedef struct zmvec {
+  double complex *md;
+  int inc;
+} zmvec_t;
+
+extern void zvec_axpy(zmvec_t *Y,  const zmvec_t *X, double complex alpha, int N);
+extern void zvec_scal(zmvec_t *X,  double complex alpha, int N);
+extern void zvec_invscal(zmvec_t *X,  double complex alpha, int N);
+extern int zvec_iamax(const zmvec_t *X,  int N);
+extern double zvec_nrm2(const zmvec_t *X,  int N);
+extern double complex zvec_dotu(const zmvec_t *X,  const zmvec_t *Y, double complex alpha, int N);
+extern double complex zvec_dotc(const zmvec_t *X,  const zmvec_t *Y, double complex alpha, int N);
+extern void zvec_swap(zmvec_t *X,  zmvec_t *Y, int N);
+
+// matrix-matrix: C = alpha* A*B + beta*C  (GEMM)
+extern void
+zmult_mm_blocked(zmdata_t *C, const zmdata_t *A, const zmdata_t *B,
+                 double complex alpha, double complex beta, int flags,
+                 int P, int S, int L, int R, int E);
+
+// matrix-vector: Y = alpha*A*X + beta*Y (GEMV)
+extern void
+zmult_gemv(zmvec_t *Y, const zmdata_t *A, const zmvec_t *X,
+           double complex alpha, double complex beta, int flags, int S, int L, int R, int E);
+
+// A = A + alpha * x * y.H (GERC)
+extern void
+zmvec_rank(zmdata_t *A, const zmvec_t *X, const zmvec_t *Y, double complex alpha,
+           int S, int L, int R, int E);
+
+// A = A + alpha * X * X.H; (HER)
+extern void
+zmvec_her_rank(zmdata_t *A, const zmvec_t *X,  double alpha, int flags, int S, int L);
+
+// for TRSM
+extern void
+zmmat_solve(zmdata_t *B, const zmdata_t *A, double complex alpha, int flags, int N, int S, int E);
+
+// for HERK
+extern void
+zmmat_rank(zmdata_t *C, const zmdata_t *A, double alpha, double beta,
+           int flags,  int P, int S, int E);
+
+// Batch of equally sized matrices; matrix k starts at md + k*stride.
+typedef struct dbatch_t {
+  double *md;
+  int step;
+  int stride;
+} dbatch_t;
+
+// C[k] = alpha*op(A[k])*op(B[k]) + beta*C[k] for k in [S:E) (batched GEMM)
+extern void
+dbatch_gemm(dbatch_t *C, const dbatch_t *A, const dbatch_t *B, double alpha, double beta,
+            int flags, int M, int N, int P, int S, int E);
+
+// Y[k] = alpha*op(A[k])*X[k] + beta*Y[k]; unit stride vectors (batched GEMV)
+extern void
+dbatch_gemv(dbatch_t *Y, const dbatch_t *A, const dbatch_t *X, double alpha, double beta,
+            int flags, int M, int N, int S, int E);
+
+// LU factorization and solve B[k] = A[k].-1*B[k] (batched GESV); returns index
+// of first singular matrix or -1, column of its zero pivot in col.
+extern int
+dbatch_gesv(dbatch_t *B, dbatch_t *A, int64_t *P, int strP, int flags,
+            int N, int K, int S, int E, int *col);
+
+// Cholesky factorization (batched POTRF); returns index of first matrix not
+// positive definite or -1, column of its non-positive pivot in col.
+extern int
+dbatch_potrf(dbatch_t *A, int flags, int N, int S, int E, int *col);
+
 #endif
 
 // Local Variables:
@@ -0,0 +1,275 @@
+//go:build cgo && amd64 && !purego
+
+// Copyright (c) Harri Rautila, 2012,2013
+
+// This file is part of github.com/hrautila/matops package. It is free software,
+// distributed under the terms of GNU Lesser General Public License Version 3, or
+// any later version. See the COPYING tile included in this archive.
+
+// Batched operations on small matrices. Matrices are expected to be small enough
+// to stay in cache so plain unit stride column loops are used instead of the
+// blocked kernels.
+
+#include <math.h>
+#include "cmops.h"
+
+// Y = Y + alpha*X; unit stride
+static inline void _daxpy(double *Y, const double *X, double alpha, int N)
+{
+  register int i;
+  for (i = 0; i < N; i++) {
+    Y[i] += alpha * X[i];
+  }
+}
+
+// return: X.Y; unit stride
+static inline double _ddot(const double *X, const double *Y, int N)
+{
+  register int i;
+  double c0 = 0.0;
+  for (i = 0; i < N; i++) {
+    c0 += X[i] * Y[i];
+  }
+  return c0;
+}
+
+// X = f0*X; zero if f0 is zero
+static inline void _dscale(double *X, double f0, int N)
+{
+  register int i;
+  if (f0 == 1.0) {
+    return;
+  }
+  for (i = 0; i < N; i++) {
+    X[i] = f0 == 0.0 ? 0.0 : f0 * X[i];
+  }
+}
+
+static inline void _dswap_rows(double *A, int ldA, int r0, int r1, int N)
+{
+  register int j;
+  double t0;
+  for (j = 0; j < N; j++) {
+    t0 = A[r0 + j*ldA];
+    A[r0 + j*ldA] = A[r1 + j*ldA];
+    A[r1 + j*ldA] = t0;
+  }
+}
+
+static void _dgemm(double *C, const double *A, const double *B, double alpha, double beta,
+                   int flags, int ldC, int ldA, int ldB, int M, int N, int P)
+{
+  register int i, j, k;
+  double b0;
+
+  for (j = 0; j < N; j++) {
+    _dscale(&C[j*ldC], beta, M);
+    if (flags & MTX_TRANSA) {
+      // rows of A.T are columns of A
+      for (i = 0; i < M; i++) {
+        if (flags & MTX_TRANSB) {
+          b0 = 0.0;
+          for (k = 0; k < P; k++) {
+            b0 += A[k + i*ldA] * B[j + k*ldB];
+          }
+        } else {
+          b0 = _ddot(&A[i*ldA], &B[j*ldB], P);
+        }
+        C[i + j*ldC] += alpha * b0;
+      }
+      continue;
+    }
+    for (k = 0; k < P; k++) {
+      b0 = flags & MTX_TRANSB ? B[j + k*ldB] : B[k + j*ldB];
+      _daxpy(&C[j*ldC], &A[k*ldA], alpha * b0, M);
+    }
+  }
+}
+
+void dbatch_gemm(dbatch_t *C, const dbatch_t *A, const dbatch_t *B, double alpha, double beta,
+                 int flags, int M, int N, int P, int S, int E)
+{
+  register int k;
+  for (k = S; k < E; k++) {
+    _dgemm(&C->md[k*C->stride], &A->md[k*A->stride], &B->md[k*B->stride], alpha, beta,
+           flags, C->step, A->step, B->step, M, N, P);
+  }
+}
+
+void dbatch_gemv(dbatch_t *Y, const dbatch_t *A, const dbatch_t *X, double alpha, double beta,
+                 int flags, int M, int N, int S, int E)
+{
+  register int i, j, k;
+  double *y0;
+  const double *a0, *x0;
+
+  for (k = S; k < E; k++) {
+    y0 = &Y->md[k*Y->stride];
+    a0 = &A->md[k*A->stride];
+    x0 = &X->md[k*X->stride];
+    if (flags & MTX_TRANSA) {
+      _dscale(y0, beta, N);
+      for (i = 0; i < N; i++) {
+        y0[i] += alpha * _ddot(&a0[i*A->step], x0, M);
+      }
+      continue;
+    }
+    _dscale(y0, beta, M);
+    for (j = 0; j < N; j++) {
+      _daxpy(y0, &a0[j*A->step], alpha * x0[j], M);
+    }
+  }
+}
+
+// A = P*L*U; return -1 or column of zero pivot if A is singular.
+static int _dgetrf(double *A, int64_t *piv, int ldA, int N)
+{
+  register int i, j, p;
+  double amax, a0;
+
+  for (j = 0; j < N; j++) {
+    p = j;
+    amax = fabs(A[j + j*ldA]);
+    for (i = j+1; i < N; i++) {
+      if (fabs(A[i + j*ldA]) > amax) {
+        p = i;
+        amax = fabs(A[i + j*ldA]);
+      }
+    }
+    piv[j] = p;
+    if (amax == 0.0) {
+      return j;
+    }
+    if (p != j) {
+      _dswap_rows(A, ldA, p, j, N);
+    }
+    a0 = 1.0/A[j + j*ldA];
+    for (i = j+1; i < N; i++) {
+      A[i + j*ldA] *= a0;
+    }
+    // trailing update column by column
+    for (i = j+1; i < N; i++) {
+      _daxpy(&A[j+1 + i*ldA], &A[j+1 + j*ldA], -A[j + i*ldA], N-j-1);
+    }
+  }
+  return -1;
+}
+
+// X = A.-1*X or X = A.-T*X with LU factors of A.
+static void _dgetrs(double *X, const double *A, const int64_t *piv, int flags, int ldA, int N)
+{
+  register int i, j;
+  double x0;
+
+  if (flags & MTX_TRANSA) {
+    // U.T*y = x; L.T*z = y; x = P*z
+    for (j = 0; j < N; j++) {
+      X[j] = (X[j] - _ddot(&A[j*ldA], X, j)) / A[j + j*ldA];
+    }
+    for (j = N-1; j >= 0; j--) {
+      X[j] -= _ddot(&A[j+1 + j*ldA], &X[j+1], N-j-1);
+    }
+    for (j = N-1; j >= 0; j--) {
+      if (piv[j] != j) {
+        x0 = X[j]; X[j] = X[piv[j]]; X[piv[j]] = x0;
+      }
+    }
+    return;
+  }
+  // x = P.T*x; L*y = x; U*x = y
+  for (j = 0; j < N; j++) {
+    if (piv[j] != j) {
+      x0 = X[j]; X[j] = X[piv[j]]; X[piv[j]] = x0;
+    }
+  }
+  for (j = 0; j < N; j++) {
+    _daxpy(&X[j+1], &A[j+1 + j*ldA], -X[j], N-j-1);
+  }
+  for (j = N-1; j >= 0; j--) {
+    X[j] /= A[j + j*ldA];
+    for (i = 0; i < j; i++) {
+      X[i] -= A[i + j*ldA] * X[j];
+    }
+  }
+}
+
+int dbatch_gesv(dbatch_t *B, dbatch_t *A, int64_t *P, int strP, int flags,
+                int N, int K, int S, int E, int *col)
+{
+  register int j, k;
+  int first = -1, c;
+  double *a0, *b0;
+
+  for (k = S; k < E; k++) {
+    a0 = &A->md[k*A->stride];
+    b0 = &B->md[k*B->stride];
+    if ((c = _dgetrf(a0, &P[k*strP], A->step, N)) >= 0) {
+      if (first < 0) {
+        first = k;
+        *col = c;
+      }
+      continue;
+    }
+    for (j = 0; j < K; j++) {
+      _dgetrs(&b0[j*B->step], a0, &P[k*strP], flags, A->step, N);
+    }
+  }
+  return first;
+}
+
+// A = L*L.T or A = U.T*U; return -1 or column of non-positive pivot if A is not
+// positive definite.
+static int _dpotrf(double *A, int flags, int ldA, int N)
+{
+  register int i, j;
+  double a0;
+
+  if (flags & MTX_UPPER) {
+    // left looking, column j of U from columns [0:j]
+    for (j = 0; j < N; j++) {
+      for (i = 0; i < j; i++) {
+        A[i + j*ldA] = (A[i + j*ldA] - _ddot(&A[i*ldA], &A[j*ldA], i)) / A[i + i*ldA];
+      }
+      a0 = A[j + j*ldA] - _ddot(&A[j*ldA], &A[j*ldA], j);
+      if (a0 <= 0.0) {
+        return j;
+      }
+      A[j + j*ldA] = sqrt(a0);
+    }
+    return -1;
+  }
+  // right looking, column j of L and update of trailing lower triangle
+  for (j = 0; j < N; j++) {
+    a0 = A[j + j*ldA];
+    if (a0 <= 0.0) {
+      return j;
+    }
+    a0 = sqrt(a0);
+    A[j + j*ldA] = a0;
+    for (i = j+1; i < N; i++) {
+      A[i + j*ldA] /= a0;
+    }
+    for (i = j+1; i < N; i++) {
+      _daxpy(&A[i + i*ldA], &A[i + j*ldA], -A[i + j*ldA], N-i);
+    }
+  }
+  return -1;
+}
+
+int dbatch_potrf(dbatch_t *A, int flags, int N, int S, int E, int *col)
+{
+  register int k;
+  int first = -1, c;
+
+  for (k = S; k < E; k++) {
+    if ((c = _dpotrf(&A->md[k*A->stride], flags, A->step, N)) >= 0 && first < 0) {
+      first = k;
+      *col = c;
+    }
+  }
+  return first;
+}
+
+// Local Variables:
+// indent-tabs-mode: nil
+// End:
@@ -0,0 +1,88 @@
+//go:build cgo && amd64 && !purego
+
+// Copyright (c) Harri Rautila, 2012,2013
+
+// This file is part of github.com/hrautila/matops package. It is free software,
+// distributed under the terms of GNU Lesser General Public License Version 3, or
+// any later version. See the COPYING tile included in this archive.
+
+
+package calgo
+
+// #include "cmops.h"
+import "C"
+import "unsafe"
+
+// Batched kernels on equally sized matrices. Matrix k of batch X starts at
+// X[k*strX] and has leading index ldX; vectors have unit stride. Each kernel
+// operates on matrices [S:E] of the batch in one call.
+
+func batch(A []float64, ldA, strA int) C.dbatch_t {
+    var Ab C.dbatch_t
+    Ab.md = (*C.double)(unsafe.Pointer(&A[0]))
+    Ab.step = C.int(ldA)
+    Ab.stride = C.int(strA)
+    return Ab
+}
+
+// Batched GEMM: C[k] = alpha*op(A[k])*op(B[k]) + beta*C[k]; C[k] is M*N,
+// op(A[k]) is M*P and op(B[k]) is P*N.
+func DBatchMult(C, A, B []float64, alpha, beta float64, trans Flags, M, N, P, ldC, ldA, ldB, strC, strA, strB, S, E int) {
+
+    if C == nil || A == nil || B == nil || E - S <= 0 || M == 0 || N == 0 {
+        return
+    }
+    Cb := batch(C, ldC, strC)
+    Ab := batch(A, ldA, strA)
+    Bb := batch(B, ldB, strB)
+    C.dbatch_gemm(&Cb, &Ab, &Bb, C.double(alpha), C.double(beta), C.int(trans),
+        C.int(M), C.int(N), C.int(P), C.int(S), C.int(E))
+}
+
+// Batched GEMV: Y[k] = alpha*op(A[k])*X[k] + beta*Y[k]; A[k] is M*N.
+func DBatchMultMV(Y, A, X []float64, alpha, beta float64, trans Flags, M, N, ldA, strY, strA, strX, S, E int) {
+
+    if Y == nil || A == nil || X == nil || E - S <= 0 || M == 0 || N == 0 {
+        return
+    }
+    Yb := batch(Y, 1, strY)
+    Ab := batch(A, ldA, strA)
+    Xb := batch(X, 1, strX)
+    C.dbatch_gemv(&Yb, &Ab, &Xb, C.double(alpha), C.double(beta), C.int(trans),
+        C.int(M), C.int(N), C.int(S), C.int(E))
+}
+
+// Batched GESV: A[k] = P*L*U and B[k] = op(A[k]).-1*B[k]; A[k] is N*N and B[k]
+// is N*K. Pivot indices of matrix k are stored in piv[k*strP:]. Returns index
+// of first singular matrix and column of its zero pivot, or -1.
+func DBatchSolveLU(B, A []float64, piv []int, flags Flags, N, K, ldB, ldA, strB, strA, strP, S, E int) (int, int) {
+
+    if A == nil || B == nil || E - S <= 0 || N == 0 {
+        return -1, 0
+    }
+    var col C.int
+    Bb := batch(B, ldB, strB)
+    Ab := batch(A, ldA, strA)
+    // cgo build is amd64 only; int is 64 bits
+    k := C.dbatch_gesv(&Bb, &Ab, (*C.int64_t)(unsafe.Pointer(&piv[0])), C.int(strP),
+        C.int(flags), C.int(N), C.int(K), C.int(S), C.int(E), &col)
+    return int(k), int(col)
+}
+
+// Batched POTRF: A[k] = L*L.T or A[k] = U.T*U if flags&UPPER. Returns index of
+// first matrix not positive definite and column of its non-positive pivot, or -1.
+func DBatchCHOL(A []float64, flags Flags, N, ldA, strA, S, E int) (int, int) {
+
+    if A == nil || E - S <= 0 || N == 0 {
+        return -1, 0
+    }
+    var col C.int
+    Ab := batch(A, ldA, strA)
+    k := C.dbatch_potrf(&Ab, C.int(flags), C.int(N), C.int(S), C.int(E), &col)
+    return int(k), int(col)
+}
+
+// Local Variables:
+// tab-width: 4
+// indent-tabs-mode: nil
+// End:
@@ -0,0 +1,274 @@
+//go:build cgo && amd64 && !purego
+
+// Copyright (c) Harri Rautila, 2012,2013
+
+// This file is part of github.com/hrautila/matops package. It is free software,
+// distributed under the terms of GNU Lesser General Public License Version 3, or
+// any later version. See the COPYING tile included in this archive.
+
+// Runtime selection of kernels and the AVX2+FMA kernels. Package is compiled
+// for SSE4.1; AVX2 functions are compiled for their own target and called only
+// if CPU supports AVX2 and FMA.
+
+#include <immintrin.h>
+
+#include "cmops.h"
+
+#define AVX2 __attribute__((target("avx2,fma")))
+
+int dmops_kernels = KERNELS_SSE;
+
+dblock_ddot_t _dblock_ddot = _dblock_ddot_sse;
+
+// Best kernel set supported by this CPU.
+int dmops_cpu_kernels(void)
+{
+  __builtin_cpu_init();
+  if (__builtin_cpu_supports("avx2") && __builtin_cpu_supports("fma")) {
+    return KERNELS_AVX2;
+  }
+  return KERNELS_SSE;
+}
+
+// Make kernels current; returns -1 if not supported by CPU.
+int dmops_set_kernels(int kernels)
+{
+  switch (kernels) {
+  case KERNELS_AVX2:
+    if (dmops_cpu_kernels() != KERNELS_AVX2) {
+      return -1;
+    }
+    _dblock_ddot = _dblock_ddot_avx2;
+    break;
+  case KERNELS_SSE:
+    _dblock_ddot = _dblock_ddot_sse;
+    break;
+  default:
+    return -1;
+  }
+  dmops_kernels = kernels;
+  return 0;
+}
+
+// [sum(a), sum(b), sum(c), sum(d)]
+static inline AVX2
+__m256d _hsum4(__m256d a, __m256d b, __m256d c, __m256d d)
+{
+  __m256d ab, cd, lo, hi;
+  ab = _mm256_hadd_pd(a, b);
+  cd = _mm256_hadd_pd(c, d);
+  lo = _mm256_permute2f128_pd(ab, cd, 0x20);
+  hi = _mm256_permute2f128_pd(ab, cd, 0x31);
+  return _mm256_add_pd(lo, hi);
+}
+
+// return: a.b for vectors of length nVP
+static inline AVX2
+double _ddot_avx2(const double *a, const double *b, int nVP)
+{
+  register int k;
+  __m256d c0, c1;
+  __m128d s;
+  double d;
+
+  c0 = _mm256_setzero_pd();
+  c1 = _mm256_setzero_pd();
+  for (k = 0; k < nVP-7; k += 8) {
+    c0 = _mm256_fmadd_pd(_mm256_loadu_pd(&a[k]), _mm256_loadu_pd(&b[k]), c0);
+    c1 = _mm256_fmadd_pd(_mm256_loadu_pd(&a[k+4]), _mm256_loadu_pd(&b[k+4]), c1);
+  }
+  if (k < nVP-3) {
+    c0 = _mm256_fmadd_pd(_mm256_loadu_pd(&a[k]), _mm256_loadu_pd(&b[k]), c0);
+    k += 4;
+  }
+  c0 = _mm256_add_pd(c0, c1);
+  s = _mm_add_pd(_mm256_castpd256_pd128(c0), _mm256_extractf128_pd(c0, 1));
+  d = _mm_cvtsd_f64(_mm_add_sd(s, _mm_unpackhi_pd(s, s)));
+  for (; k < nVP; k++) {
+    d += a[k]*b[k];
+  }
+  return d;
+}
+
+// C[0:4,0] += alpha*A[:,0:4].T*B[:,0]; C[0:4,1] += alpha*A[:,0:4].T*B[:,1]
+static inline AVX2
+void _ddot4x2_avx2(double *c0, double *c1, const double *Ac, int ldA,
+                   const double *b0, const double *b1, double alpha, int nVP)
+{
+  register int k;
+  const double *a0, *a1, *a2, *a3;
+  __m256d A0, A1, A2, A3, B0, B1, alp;
+  __m256d c00, c10, c20, c30, c01, c11, c21, c31;
+  __m256d s0, s1;
+
+  a0 = Ac;
+  a1 = a0 + ldA;
+  a2 = a1 + ldA;
+  a3 = a2 + ldA;
+  c00 = c10 = c20 = c30 = _mm256_setzero_pd();
+  c01 = c11 = c21 = c31 = _mm256_setzero_pd();
+  for (k = 0; k < nVP-3; k += 4) {
+    B0 = _mm256_loadu_pd(&b0[k]);
+    B1 = _mm256_loadu_pd(&b1[k]);
+    A0 = _mm256_loadu_pd(&a0[k]);
+    A1 = _mm256_loadu_pd(&a1[k]);
+    A2 = _mm256_loadu_pd(&a2[k]);
+    A3 = _mm256_loadu_pd(&a3[k]);
+    c00 = _mm256_fmadd_pd(A0, B0, c00);
+    c10 = _mm256_fmadd_pd(A1, B0, c10);
+    c20 = _mm256_fmadd_pd(A2, B0, c20);
+    c30 = _mm256_fmadd_pd(A3, B0, c30);
+    c01 = _mm256_fmadd_pd(A0, B1, c01);
+    c11 = _mm256_fmadd_pd(A1, B1, c11);
+    c21 = _mm256_fmadd_pd(A2, B1, c21);
+    c31 = _mm256_fmadd_pd(A3, B1, c31);
+  }
+  s0 = _hsum4(c00, c10, c20, c30);
+  s1 = _hsum4(c01, c11, c21, c31);
+  for (; k < nVP; k++) {
+    A0 = _mm256_set_pd(a3[k], a2[k], a1[k], a0[k]);
+    s0 = _mm256_fmadd_pd(A0, _mm256_set1_pd(b0[k]), s0);
+    s1 = _mm256_fmadd_pd(A0, _mm256_set1_pd(b1[k]), s1);
+  }
+  alp = _mm256_set1_pd(alpha);
+  _mm256_storeu_pd(c0, _mm256_fmadd_pd(alp, s0, _mm256_loadu_pd(c0)));
+  _mm256_storeu_pd(c1, _mm256_fmadd_pd(alp, s1, _mm256_loadu_pd(c1)));
+}
+
+// C[0:4,0] += alpha*A[:,0:4].T*B[:,0]
+static inline AVX2
+void _ddot4x1_avx2(double *c0, const double *Ac, int ldA,
+                   const double *b0, double alpha, int nVP)
+{
+  register int k;
+  const double *a0, *a1, *a2, *a3;
+  __m256d B0, c00, c10, c20, c30, s0;
+
+  a0 = Ac;
+  a1 = a0 + ldA;
+  a2 = a1 + ldA;
+  a3 = a2 + ldA;
+  c00 = c10 = c20 = c30 = _mm256_setzero_pd();
+  for (k = 0; k < nVP-3; k += 4) {
+    B0 = _mm256_loadu_pd(&b0[k]);
+    c00 = _mm256_fmadd_pd(_mm256_loadu_pd(&a0[k]), B0, c00);
+    c10 = _mm256_fmadd_pd(_mm256_loadu_pd(&a1[k]), B0, c10);
+    c20 = _mm256_fmadd_pd(_mm256_loadu_pd(&a2[k]), B0, c20);
+    c30 = _mm256_fmadd_pd(_mm256_loadu_pd(&a3[k]), B0, c30);
+  }
+  s0 = _hsum4(c00, c10, c20, c30);
+  for (; k < nVP; k++) {
+    s0 = _mm256_fmadd_pd(_mm256_set_pd(a3[k], a2[k], a1[k], a0[k]),
+                         _mm256_set1_pd(b0[k]), s0);
+  }
+  _mm256_storeu_pd(c0, _mm256_fmadd_pd(_mm256_set1_pd(alpha), s0, _mm256_loadu_pd(c0)));
+}
+
+// Same as _dblock_ddot_sse with 4x2 register blocks of C.
+AVX2
+void _dblock_ddot_avx2(double *Cc, const double *Aroot, const double *Bc, double alpha,
+                       int ldC, int ldA, int ldB, int nSL, int nRE, int nVP)
+{
+  register int i, j;
+  const double *b0, *b1;
+  double *c0, *c1;
+
+  for (j = 0; j < nSL-1; j += 2) {
+    b0 = Bc + j*ldB;
+    b1 = b0 + ldB;
+    c0 = Cc + j*ldC;
+    c1 = c0 + ldC;
+    for (i = 0; i < nRE-3; i += 4) {
+      _ddot4x2_avx2(&c0[i], &c1[i], Aroot + i*ldA, ldA, b0, b1, alpha, nVP);
+    }
+    for (; i < nRE; i++) {
+      c0[i] += alpha * _ddot_avx2(Aroot + i*ldA, b0, nVP);
+      c1[i] += alpha * _ddot_avx2(Aroot + i*ldA, b1, nVP);
+    }
+  }
+  if (j < nSL) {
+    b0 = Bc + j*ldB;
+    c0 = Cc + j*ldC;
+    for (i = 0; i < nRE-3; i += 4) {
+      _ddot4x1_avx2(&c0[i], Aroot + i*ldA, ldA, b0, alpha, nVP);
+    }
+    for (; i < nRE; i++) {
+      c0[i] += alpha * _ddot_avx2(Aroot + i*ldA, b0, nVP);
+    }
+  }
+}
+
+// Y[j*incY] += alpha*A[:,j].T*X for j < nC; columns of A and X are contiguous
+AVX2
+void _dmvec_ddot_avx2(double *Yc, const double *Ac, const double *Xc, double alpha,
+                      int incY, int ldA, int nVP, int nC)
+{
+  register int j;
+  double s[4] __attribute__((aligned(32)));
+
+  for (j = 0; j < nC-3; j += 4) {
+    // A.T rows are A columns, 4 of them make a 4x1 block of Y
+    _mm256_store_pd(s, _mm256_setzero_pd());
+    _ddot4x1_avx2(s, Ac + j*ldA, ldA, Xc, alpha, nVP);
+    Yc[(j+0)*incY] += s[0];
+    Yc[(j+1)*incY] += s[1];
+    Yc[(j+2)*incY] += s[2];
+    Yc[(j+3)*incY] += s[3];
+  }
+  for (; j < nC; j++) {
+    Yc[j*incY] += alpha * _ddot_avx2(Ac + j*ldA, Xc, nVP);
+  }
+}
+
+// Y[0:nRE] += alpha*A[0:nRE,0:nC]*X; Y is contiguous
+AVX2
+void _dmvec_daxpy_avx2(double *Yc, const double *Ac, const double *Xc, double alpha,
+                       int ldA, int incX, int nRE, int nC)
+{
+  register int i, j;
+  const double *a0, *a1, *a2, *a3;
+  double x0, x1, x2, x3;
+  __m256d X0, X1, X2, X3, y;
+
+  for (j = 0; j < nC-3; j += 4) {
+    a0 = Ac + j*ldA;
+    a1 = a0 + ldA;
+    a2 = a1 + ldA;
+    a3 = a2 + ldA;
+    x0 = alpha * Xc[(j+0)*incX];
+    x1 = alpha * Xc[(j+1)*incX];
+    x2 = alpha * Xc[(j+2)*incX];
+    x3 = alpha * Xc[(j+3)*incX];
+    X0 = _mm256_set1_pd(x0);
+    X1 = _mm256_set1_pd(x1);
+    X2 = _mm256_set1_pd(x2);
+    X3 = _mm256_set1_pd(x3);
+    for (i = 0; i < nRE-3; i += 4) {
+      y = _mm256_loadu_pd(&Yc[i]);
+      y = _mm256_fmadd_pd(_mm256_loadu_pd(&a0[i]), X0, y);
+      y = _mm256_fmadd_pd(_mm256_loadu_pd(&a1[i]), X1, y);
+      y = _mm256_fmadd_pd(_mm256_loadu_pd(&a2[i]), X2, y);
+      y = _mm256_fmadd_pd(_mm256_loadu_pd(&a3[i]), X3, y);
+      _mm256_storeu_pd(&Yc[i], y);
+    }
+    for (; i < nRE; i++) {
+      Yc[i] += a0[i]*x0 + a1[i]*x1 + a2[i]*x2 + a3[i]*x3;
+    }
+  }
+  for (; j < nC; j++) {
+    a0 = Ac + j*ldA;
+    x0 = alpha * Xc[j*incX];
+    X0 = _mm256_set1_pd(x0);
+    for (i = 0; i < nRE-3; i += 4) {
+      y = _mm256_fmadd_pd(_mm256_loadu_pd(&a0[i]), X0, _mm256_loadu_pd(&Yc[i]));
+      _mm256_storeu_pd(&Yc[i], y);
+    }
+    for (; i < nRE; i++) {
+      Yc[i] += a0[i]*x0;
+    }
+  }
+}
+
+// Local Variables:
+// indent-tabs-mode: nil
+// End:
@@ -1,3 +1,4 @@
+//go:build cgo && amd64 && !purego
 
 // Copyright (c) Harri Rautila, 2012,2013
 
@@ -148,7 +149,7 @@ void _dblock_mult_cpy(mdata_t *C, const mdata_t *A, const mdata_t *B,
     //printf(".. R=%d, E=%d, S=%d, L=%d, vpS=%d, vpL=%d, F=0x%x\n", R, E, S, L, vpS, vpL, flags);
     //printf(".. A=\n"); print_tile(Acpy, nA, vpL-vpS, E-R);
     //printf(".. B=\n"); print_tile(Bc, B->step, vpL-vpS, L-S);
-    _dblock_ddot_sse(Cc, Acpy, Bcpy, alpha, C->step, nA, nB, L-S, E-R, vpL-vpS);
+    _dblock_ddot(Cc, Acpy, Bcpy, alpha, C->step, nA, nB, L-S, E-R, vpL-vpS);
     //printf(".. C=\n"); print_tile(Cc, C->step, E-R, L-S);
 
     vpS = vpL;
@@ -234,7 +235,7 @@ void _dblock_mult_panel(mdata_t *C, const mdata_t *A, const mdata_t *B,
       colcpy4_trans(Acpy->data, nA, AvpS, A->step, nRE, vpL-vpS);
     }
 
-    _dblock_ddot_sse(C->md, Acpy->data, Bcpy->data, alpha, C->step, nA, nB, nSL, nRE, vpL-vpS);
+    _dblock_ddot(C->md, Acpy->data, Bcpy->data, alpha, C->step, nA, nB, nSL, nRE, vpL-vpS);
 
     vpS = vpL;
     vpL += vlen;
@@ -297,7 +298,7 @@ void dmult_mm_blocked2(mdata_t *C, const mdata_t *A, const mdata_t *B,
 // Cij += alpha * Aik * Bkj
 void dblock_ddot(mdata_t *C, mdata_t* A, mdata_t *B, double alpha, int nSL, int nRE, int nVP)
 {
-  _dblock_ddot_sse(C->md, A->md, B->md, alpha, C->step, A->step, B->step, nSL, nRE, nVP);
+  _dblock_ddot(C->md, A->md, B->md, alpha, C->step, A->step, B->step, nSL, nRE, nVP);
 }
 
 
@@ -351,7 +352,7 @@ void _dblock_mult_gepp(mdata_t *C, const mdata_t *A, const mdata_t *B,
 
     //printf("A=\n"); print_tile(AvpS, A->step, rE-rS, nP);
     //printf("A.T=\n"); print_tile(Acpy->data, nA, nP, rE-rS);
-    _dblock_ddot_sse(Cc, Acpy->data, Bcpy->data, alpha, C->step, nA, nB, nSL, rE-rS, nP);
+    _dblock_ddot(Cc, Acpy->data, Bcpy->data, alpha, C->step, nA, nB, nSL, rE-rS, nP);
     //printf("C=\n"); print_tile(C->md, C->step, nRE, nSL);
     rS = rE;
     rE += mb;
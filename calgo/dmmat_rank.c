@@ -1,3 +1,4 @@
+//go:build cgo && amd64 && !purego
 
 // Copyright (c) Harri Rautila, 2012,2013
 
@@ -102,6 +103,7 @@ void dmmat_rank_blk(mdata_t *C, const mdata_t *A, double alpha, double beta,
       nC = flags & MTX_LOWER ? i : E-i-nI;
 
       //_dblock_mult_panel(&Cd, &Ad, &Bd, alpha, MTX_TRANSA, P, nC, nI, vlen, &Acpy, &Bcpy);
+      dscale_tile(Cd.md, Cd.step, beta, nI, nC);
       _dmult_mm_intern(&Cd, &Ad, &Bd, alpha, MTX_TRANSA, P, nC, nI, vlen, NB, NB, &Acpy, &Bcpy);
     }
   } else {
@@ -120,6 +122,7 @@ void dmmat_rank_blk(mdata_t *C, const mdata_t *A, double alpha, double beta,
       nC = flags & MTX_LOWER ? i : E-i-nI;
 
       //_dblock_mult_panel(&Cd, &Ad, &Bd, alpha, MTX_TRANSB, P, nC, nI, vlen, &Acpy, &Bcpy);
+      dscale_tile(Cd.md, Cd.step, beta, nI, nC);
       _dmult_mm_intern(&Cd, &Ad, &Bd, alpha, MTX_TRANSB, P, nC, nI, vlen, NB, NB, &Acpy, &Bcpy);
     }
   }
@@ -175,6 +178,7 @@ void dmmat_rank2_blk(mdata_t *C, const mdata_t *A, const mdata_t *B,
       nC = flags & MTX_LOWER ? i : E-i-nI;
 
       //_dblock_mult_panel(&Cd, &Ad, &Bd, alpha, MTX_TRANSA, P, nC, nI, vlen, &Acpy, &Bcpy);
+      dscale_tile(Cd.md, Cd.step, beta, nI, nC);
       _dmult_mm_intern(&Cd, &Ad, &Bd, alpha, MTX_TRANSA, P, nC, nI, vlen, NB, NB, &Acpy, &Bcpy);
 
       // 2nd part
@@ -212,13 +216,14 @@ void dmmat_rank2_blk(mdata_t *C, const mdata_t *A, const mdata_t *B,
       nC = flags & MTX_LOWER ? i : E-i-nI;
 
       //_dblock_mult_panel(&Cd, &Ad, &Bd, alpha, MTX_TRANSB, P, nC, nI, vlen, &Acpy, &Bcpy);
+      dscale_tile(Cd.md, Cd.step, beta, nI, nC);
       _dmult_mm_intern(&Cd, &Ad, &Bd, alpha, MTX_TRANSB, P, nC, nI, vlen, NB, NB, &Acpy, &Bcpy);
 
       Cd.md = &C->md[i*C->step+i];
       Ad.md = &B->md[i];
       Bd.md = &A->md[i];
       // 1. update on diagonal
-      _dmmat_rank_diag(&Cd, &Ad, &Bd, alpha, beta, flags, P, nI, vlen, &Acpy, &Bcpy);
+      _dmmat_rank_diag(&Cd, &Ad, &Bd, alpha, 1.0, flags, P, nI, vlen, &Acpy, &Bcpy);
 
       // 2. update block right of diagonal (UPPER) or left of diagonal (LOWER)
       Cd.md = flags & MTX_LOWER ? &C->md[i] : &C->md[(i+nI)*C->step+i];
@@ -325,6 +330,7 @@ void dmmat_trmupd_blk(mdata_t *C, const mdata_t *A, const mdata_t *B, double alp
       Ad.md = &A->md[S];
       Bd.md = flags & MTX_TRANSB ? &B->md[i] : &B->md[i*B->step];
       nR = i;
+      dscale_tile(Cd.md, Cd.step, beta, nR, nI);
       _dmult_mm_intern(&Cd, &Ad, &Bd, alpha, flags, P, nI, nR, vlen, NB, NB, &Acpy, &Bcpy);
 
       // 2. update block on diagonal
@@ -350,6 +356,7 @@ void dmmat_trmupd_blk(mdata_t *C, const mdata_t *A, const mdata_t *B, double alp
       Bd.md = flags & MTX_TRANSB ? &B->md[i] : &B->md[i*B->step];
       nR = E-i-nI;
 
+      dscale_tile(Cd.md, Cd.step, beta, nR, nI);
       _dmult_mm_intern(&Cd, &Ad, &Bd, alpha, flags, P, nI, nR, vlen, NB, NB, &Acpy, &Bcpy);
     }
   }
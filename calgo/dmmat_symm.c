@@ -1,3 +1,4 @@
+//go:build cgo && amd64 && !purego
 
 // Copyright (c) Harri Rautila, 2012,2013
 
@@ -10,11 +11,6 @@
 #include "cmops.h"
 #include "colcpy.h"
 
-extern void
-_dblock_ddot_sse(double *Cc, const double *Aroot, const double *Bc, double alpha,
-                 int ldC, int ldA, int ldB, int nSL, int nRE, int nVP);
-
-
 void _dblock_symm_cpy(mdata_t *C, const mdata_t *A, const mdata_t *B,
                       double alpha, double beta, int flags,
                       int nP, int S, int L, int R, int E, int vlen)
@@ -79,7 +75,7 @@ void _dblock_symm_cpy(mdata_t *C, const mdata_t *A, const mdata_t *B,
     //printf("1. update: B=\n"); print_tile(Bcpy, nB, vpL-vpS, L-S);
 
     if (flags & MTX_LEFT) {
-      _dblock_ddot_sse(Cc, Acpy, Bcpy, alpha, nC, nA, nB, L-S, E-R, vpL-vpS);
+      _dblock_ddot(Cc, Acpy, Bcpy, alpha, nC, nA, nB, L-S, E-R, vpL-vpS);
     } else {
     }
     //printf("1. post update: C=\n"); print_tile(Cpy, nC, E-R, L-S);
@@ -118,7 +114,7 @@ void _dblock_symm_cpy(mdata_t *C, const mdata_t *A, const mdata_t *B,
     }
 
     if (flags & MTX_LEFT) {
-      _dblock_ddot_sse(Cc, Acpy, Bcpy, alpha, nC, nA, nB, L-S, E-R, vpL-vpS);
+      _dblock_ddot(Cc, Acpy, Bcpy, alpha, nC, nA, nB, L-S, E-R, vpL-vpS);
     }
     //printf("2. post update: C=\n"); print_tile(Cpy, nC, E-R, L-S);
 
@@ -160,7 +156,7 @@ void _dblock_symm_cpy(mdata_t *C, const mdata_t *A, const mdata_t *B,
 
     if (flags & MTX_LEFT) {
       // C += alpha * A * B
-      _dblock_ddot_sse(Cc, Acpy, Bcpy, alpha, nC, nA, nB, L-S, E-R, vpL-vpS);
+      _dblock_ddot(Cc, Acpy, Bcpy, alpha, nC, nA, nB, L-S, E-R, vpL-vpS);
     } else {
       // C += alpha * B * A
     }
@@ -242,9 +238,9 @@ void _dblock_mult_diag(mdata_t *C, const mdata_t *A, const mdata_t *B,
     colcpy(Bcpy->data, nB, B->md, B->step, nRE, nSL);
   }
   if (flags & MTX_RIGHT) {
-    _dblock_ddot_sse(C->md, Bcpy->data, Acpy->data, alpha, C->step, nB, nA, nSL, nRE, nP);
+    _dblock_ddot(C->md, Bcpy->data, Acpy->data, alpha, C->step, nB, nA, nSL, nRE, nP);
   } else {
-    _dblock_ddot_sse(C->md, Acpy->data, Bcpy->data, alpha, C->step, nA, nB, nSL, nRE, nP);
+    _dblock_ddot(C->md, Acpy->data, Bcpy->data, alpha, C->step, nA, nB, nSL, nRE, nP);
   }
   //printf("2. post update: C=\n"); print_tile(Cpy, nC, E-R, L-S);
 
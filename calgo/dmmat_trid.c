@@ -1,3 +1,4 @@
+//go:build cgo && amd64 && !purego
 
 // Copyright (c) Harri Rautila, 2012,2013
 
@@ -144,7 +145,7 @@ _dmmat_trid_unb_upper(double *Bc, const double *Ac, double alpha, int unit,
     for (j = 0; j < nC; j++) {
       _inner_daxpy(Bc, Ac, b0, alpha, i);
       Ar = Ac + i;
-      b0[0] = unit ? b0[0] : alpha*b0[0]*Ar[0];
+      b0[0] = unit ? alpha*b0[0] : alpha*b0[0]*Ar[0];
       b0 += ldB;
       Bc += ldB;
     }
@@ -1,3 +1,4 @@
+//go:build cgo && amd64 && !purego
 
 // Copyright (c) Harri Rautila, 2012,2013
 
@@ -238,7 +239,7 @@ void _dmvec_daxpy_unaligned(mvec_t *Y, const mdata_t *A, const mvec_t *X,
   vpS = S;
   vpL = vlen < L-S ? S + vlen : L;
 
-  Yc = &Y->md[R*X->inc];
+  Yc = &Y->md[R*Y->inc];
 
   while (vpS < L) {
     AvpS = &A->md[vpS*A->step + R];
@@ -375,22 +376,26 @@ void dmult_gemv_blocked(mvec_t *Y, const mdata_t *A, const mvec_t *X,
     }
     x_aligned = ((uintptr_t)X->md & 0xF);
 
-    if (lda_even && Y->inc == 1 && a_aligned == x_aligned) {
+    // blocks of columns of A update same elements of Y; scale Y only once
+    if (beta != 1.0) {
+      dscale_vec(&Y->md[R*Y->inc], Y->inc, beta, E-R);
+    }
+    if (dmops_kernels == KERNELS_AVX2 && X->inc == 1) {
+      for (i = S; i < L; i += MB) {
+        nI = L - i < MB ? L - i : MB;
+        _dmvec_ddot_avx2(&Y->md[R*Y->inc], &A->md[R*A->step+i], &X->md[i],
+                         alpha, Y->inc, A->step, nI, E-R);
+      }
+    } else if (lda_even && Y->inc == 1 && X->inc == 1 && a_aligned == x_aligned) {
       //printf("transA aligned ...\n");
       for (i = S; i < L; i += MB) {
         nI = L - i < MB ? L - i : MB;
-        if (beta != 1.0) {
-          dscale_vec(&Y->md[R*Y->inc], Y->inc, beta, E-R);
-        }
         _dmvec_ddot_aligned(Y, A, X, alpha, beta, i, i+nI, R, E, vlen);
       }
     } else {
       //printf("transA unaligned ...\n");
       for (i = S; i < L; i += MB) {
         nI = L - i < MB ? L - i : MB;
-        if (beta != 1.0) {
-          dscale_vec(&Y->md[R*Y->inc], Y->inc, beta, E-R);
-        }
         _dmvec_ddot_unaligned(Y, A, X, alpha, beta, i, i+nI, R, E, vlen);
       }
   }
@@ -404,7 +409,16 @@ void dmult_gemv_blocked(mvec_t *Y, const mdata_t *A, const mvec_t *X,
       vlen = 256;
     }
     y_aligned = ((uintptr_t)Y->md & 0xF);
-    if (lda_even && Y->inc == 1 && a_aligned == y_aligned) {
+    if (dmops_kernels == KERNELS_AVX2 && Y->inc == 1) {
+      for (i = R; i < E; i += MB) {
+        nI = E - i < MB ? E - i : MB;
+        if (beta != 1.0) {
+          dscale_vec(&Y->md[i], 1, beta, nI);
+        }
+        _dmvec_daxpy_avx2(&Y->md[i], &A->md[S*A->step+i], &X->md[S*X->inc],
+                          alpha, A->step, X->inc, nI, L-S);
+      }
+    } else if (lda_even && Y->inc == 1 && a_aligned == y_aligned) {
       //printf("NO trans, aligned ...\n");
       for (i = R; i < E; i += MB) {
         nI = E - i < MB ? E - i : MB;
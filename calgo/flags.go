@@ -0,0 +1,30 @@
+
+// Copyright (c) Harri Rautila, 2012,2013
+
+// This file is part of github.com/hrautila/matops package. It is free software,
+// distributed under the terms of GNU Lesser General Public License Version 3, or
+// any later version. See the COPYING tile included in this archive.
+
+// Package calgo implements the matrix and vector kernels of matops. On amd64
+// with cgo the kernels are C functions using SSE instructions, elsewhere or with
+// build tag purego the same functions are implemented in Go.
+package calgo
+
+type Flags int
+const (
+    TRANSA = (1 << iota)        // 0x1  ; transpose A
+    TRANSB                      // 0x2  ; transpose B
+    LOWER                       // 0x4  ; lower tridiagonal
+    UPPER                       // 0x8  ; upper tridiagonal
+    LEFT                        // 0x10 ; A on left side
+    RIGHT                       // 0x20 ; A on right side
+    UNIT                        // 0x40 ; unit diagonal
+    TRANS                       // 0x80 ; generic transpose
+    NOTRANS = 0
+    NULL = 0
+)
+
+// Local Variables:
+// tab-width: 4
+// indent-tabs-mode: nil
+// End:
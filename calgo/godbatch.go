@@ -0,0 +1,175 @@
+//go:build !cgo || !amd64 || purego
+
+// Copyright (c) Harri Rautila, 2012,2013
+
+// This file is part of github.com/hrautila/matops package. It is free software,
+// distributed under the terms of GNU Lesser General Public License Version 3, or
+// any later version. See the COPYING tile included in this archive.
+
+
+package calgo
+
+import "math"
+
+// Pure Go versions of the batched kernels in dbatch.go.
+
+// Batched GEMM: C[k] = alpha*op(A[k])*op(B[k]) + beta*C[k]; C[k] is M*N,
+// op(A[k]) is M*P and op(B[k]) is P*N.
+func DBatchMult(C, A, B []float64, alpha, beta float64, trans Flags, M, N, P, ldC, ldA, ldB, strC, strA, strB, S, E int) {
+
+    if C == nil || A == nil || B == nil || E - S <= 0 || M == 0 || N == 0 {
+        return
+    }
+    for k := S; k < E; k++ {
+        Ck := C[k*strC:]
+        scaleTile(Ck, beta, ldC, 0, N, 0, M)
+        gemm(Ck, A[k*strA:], B[k*strB:], alpha, trans, ldC, ldA, ldB, P, 0, N, 0, M)
+    }
+}
+
+// Batched GEMV: Y[k] = alpha*op(A[k])*X[k] + beta*Y[k]; A[k] is M*N.
+func DBatchMultMV(Y, A, X []float64, alpha, beta float64, trans Flags, M, N, ldA, strY, strA, strX, S, E int) {
+
+    if Y == nil || A == nil || X == nil || E - S <= 0 || M == 0 || N == 0 {
+        return
+    }
+    for k := S; k < E; k++ {
+        if trans & TRANSA != 0 {
+            DMultMV(Y[k*strY:], A[k*strA:], X[k*strX:], alpha, beta, trans, 1, ldA, 1, 0, M, 0, N, 0, 0)
+        } else {
+            DMultMV(Y[k*strY:], A[k*strA:], X[k*strX:], alpha, beta, trans, 1, ldA, 1, 0, N, 0, M, 0, 0)
+        }
+    }
+}
+
+// Batched GESV: A[k] = P*L*U and B[k] = op(A[k]).-1*B[k]; A[k] is N*N and B[k]
+// is N*K. Pivot indices of matrix k are stored in piv[k*strP:]. Returns index
+// of first singular matrix and column of its zero pivot, or -1.
+func DBatchSolveLU(B, A []float64, piv []int, flags Flags, N, K, ldB, ldA, strB, strA, strP, S, E int) (int, int) {
+
+    if A == nil || B == nil || E - S <= 0 || N == 0 {
+        return -1, 0
+    }
+    first, col := -1, 0
+    for k := S; k < E; k++ {
+        Ak, pk := A[k*strA:], piv[k*strP:]
+        if c := getrf(Ak, pk, ldA, N); c >= 0 {
+            if first < 0 {
+                first, col = k, c
+            }
+            continue
+        }
+        trans := flags & TRANSA != 0
+        for j := 0; j < K; j++ {
+            X := B[k*strB + j*ldB:]
+            if trans {
+                // X = P*L.-T*U.-T*X
+                trsv(X, Ak, true, true, false, 1, ldA, N)
+                trsv(X, Ak, false, true, true, 1, ldA, N)
+                swapPivots(X, pk, N, false)
+            } else {
+                // X = U.-1*L.-1*P.T*X
+                swapPivots(X, pk, N, true)
+                trsv(X, Ak, false, false, true, 1, ldA, N)
+                trsv(X, Ak, true, false, false, 1, ldA, N)
+            }
+        }
+    }
+    return first, col
+}
+
+// Batched POTRF: A[k] = L*L.T or A[k] = U.T*U if flags&UPPER. Returns index of
+// first matrix not positive definite and column of its non-positive pivot, or -1.
+func DBatchCHOL(A []float64, flags Flags, N, ldA, strA, S, E int) (int, int) {
+
+    if A == nil || E - S <= 0 || N == 0 {
+        return -1, 0
+    }
+    first, col := -1, 0
+    for k := S; k < E; k++ {
+        if c := potrf(A[k*strA:], flags & UPPER != 0, ldA, N); c >= 0 && first < 0 {
+            first, col = k, c
+        }
+    }
+    return first, col
+}
+
+// Helpers.
+
+// A = P*L*U; returns -1 or column of zero pivot if A is singular.
+func getrf(A []float64, piv []int, ldA, N int) int {
+    for j := 0; j < N; j++ {
+        p := j
+        amax := math.Abs(A[j + j*ldA])
+        for i := j+1; i < N; i++ {
+            if a := math.Abs(A[i + j*ldA]); a > amax {
+                p, amax = i, a
+            }
+        }
+        piv[j] = p
+        if amax == 0.0 {
+            return j
+        }
+        if p != j {
+            for k := 0; k < N; k++ {
+                A[p + k*ldA], A[j + k*ldA] = A[j + k*ldA], A[p + k*ldA]
+            }
+        }
+        for i := j+1; i < N; i++ {
+            A[i + j*ldA] /= A[j + j*ldA]
+        }
+        for k := j+1; k < N; k++ {
+            akj := A[j + k*ldA]
+            for i := j+1; i < N; i++ {
+                A[i + k*ldA] -= A[i + j*ldA]*akj
+            }
+        }
+    }
+    return -1
+}
+
+// Apply pivots to vector X; forward computes P.T*X, backward P*X.
+func swapPivots(X []float64, piv []int, N int, forward bool) {
+    if forward {
+        for j := 0; j < N; j++ {
+            X[j], X[piv[j]] = X[piv[j]], X[j]
+        }
+        return
+    }
+    for j := N-1; j >= 0; j-- {
+        X[j], X[piv[j]] = X[piv[j]], X[j]
+    }
+}
+
+// A = L*L.T or A = U.T*U; returns -1 or column of non-positive pivot if A is not
+// positive definite.
+func potrf(A []float64, upper bool, ldA, N int) int {
+    for j := 0; j < N; j++ {
+        // left looking; column j of U or row j of L from the previous ones
+        var ajj float64
+        if upper {
+            for i := 0; i < j; i++ {
+                A[i + j*ldA] = (A[i + j*ldA] - dot(A[i*ldA:], A[j*ldA:], 1, 1, i))/A[i + i*ldA]
+            }
+            ajj = A[j + j*ldA] - dot(A[j*ldA:], A[j*ldA:], 1, 1, j)
+        } else {
+            ajj = A[j + j*ldA] - dot(A[j:], A[j:], ldA, ldA, j)
+        }
+        if ajj <= 0.0 {
+            return j
+        }
+        ajj = math.Sqrt(ajj)
+        A[j + j*ldA] = ajj
+        if ! upper {
+            for i := j+1; i < N; i++ {
+                A[i + j*ldA] = (A[i + j*ldA] - dot(A[i:], A[j:], ldA, ldA, j))/ajj
+            }
+        }
+    }
+    return -1
+}
+
+// Local Variables:
+// tab-width: 4
+// indent-tabs-mode: nil
+// End:
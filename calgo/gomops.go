@@ -0,0 +1,680 @@
+//go:build !cgo || !amd64 || purego
+
+// Copyright (c) Harri Rautila, 2012,2013
+
+// This file is part of github.com/hrautila/matops package. It is free software,
+// distributed under the terms of GNU Lesser General Public License Version 3, or
+// any later version. See the COPYING tile included in this archive.
+
+
+package calgo
+
+import (
+    "errors"
+    "math"
+)
+
+// Pure Go versions of the kernels in cmops.go. Functions have the same
+// signatures and compute the same results; blocking parameters H, NB and MB
+// are accepted but not used.
+
+// Matrix-Matrix operators
+
+// matrix-matrix: A = alpha*A + beta*B
+func DScalePlus(A, B []float64, alpha, beta float64, flags Flags, ldA, ldB, S, L, R, E int) {
+
+    if B == nil || A == nil {
+        return
+    }
+    for j := S; j < L; j++ {
+        for i := R; i < E; i++ {
+            ia := i + j*ldA
+            if flags & TRANSA != 0 {
+                ia = j + i*ldA
+            }
+            ib := i + j*ldB
+            if flags & TRANSB != 0 {
+                ib = j + i*ldB
+            }
+            b := B[ib]
+            if alpha == 0.0 {
+                A[ia] = beta*b
+            } else {
+                A[ia] = alpha*A[ia] + beta*b
+            }
+        }
+    }
+}
+
+
+// Generic matrix-matrix multiplication for block [R:E, S:L] with panel length P.
+//
+// if trans is NOTRANS then calculates
+//   C = alpha*A*B + beta*C; C is M*N, A is M*P and B is P*N;
+// if trans is TRANSA then calculates
+//   C = alpha*A.T*B + beta*C; C is M*N, A is P*M and B is P*N;
+// if trans is TRANSB then calculates
+//   C = alpha*A*B.T + beta*C; C is M*N, A is M*P and B is N*P;
+// if trans is TRANSA|TRANSB then calculates
+//   C = alpha*A.T*B.T + beta*C; C is M*N, A is P*M and B is N*P;
+//
+func DMult(C, A, B []float64, alpha, beta float64, trans Flags, ldC, ldA, ldB, P, S, L, R, E, H, NB, MB int) {
+
+    if C == nil || B == nil || A == nil {
+        return
+    }
+    scaleTile(C, beta, ldC, S, L, R, E)
+    gemm(C, A, B, alpha, trans, ldC, ldA, ldB, P, S, L, R, E)
+}
+
+
+//
+func DMultSymm(C, A, B []float64, alpha, beta float64, flags Flags, ldC, ldA, ldB, P, S, L, R, E, H, NB, MB int) {
+
+    if C == nil || B == nil || A == nil {
+        return
+    }
+    scaleTile(C, beta, ldC, S, L, R, E)
+    upper := flags & UPPER != 0
+    unit := flags & UNIT != 0
+    for j := S; j < L; j++ {
+        for k := 0; k < P; k++ {
+            if flags & RIGHT != 0 {
+                // C[:,j] += alpha*B[:,k]*A[k,j]
+                ak := alpha*symmAt(A, ldA, k, j, upper, unit)
+                for i := R; i < E; i++ {
+                    if flags & TRANSB != 0 {
+                        C[i + j*ldC] += ak*B[k + i*ldB]
+                    } else {
+                        C[i + j*ldC] += ak*B[i + k*ldB]
+                    }
+                }
+            } else {
+                // C[:,j] += alpha*A[:,k]*B[k,j]
+                bk := alpha*B[k + j*ldB]
+                for i := R; i < E; i++ {
+                    C[i + j*ldC] += bk*symmAt(A, ldA, i, k, upper, unit)
+                }
+            }
+        }
+    }
+}
+
+// blas TRMM; unblocked
+func DTrmmUnblk(B, A []float64, alpha float64, flags Flags, ldB, ldA, N, S, E, NB int) {
+
+    if B == nil || A == nil {
+        return
+    }
+    trmm(B, A, alpha, flags, flags & UPPER != 0, ldB, ldA, N, S, E)
+}
+
+// blas TRMM; blocked
+func DTrmmBlk(B, A []float64, alpha float64, flags Flags, ldB, ldA, N, S, E, NB int) {
+
+    if B == nil || A == nil {
+        return
+    }
+    trmm(B, A, alpha, flags, flags & UPPER != 0, ldB, ldA, N, S, E)
+}
+
+// blas TRSM; unblocked
+func DSolveUnblk(B, A []float64, alpha float64, flags Flags, ldB, ldA, N, S, E int) {
+
+    if B == nil || A == nil {
+        return
+    }
+    trsm(B, A, alpha, flags, flags & LOWER == 0, ldB, ldA, N, S, E)
+}
+
+// blas TRSM; blocked
+func DSolveBlk(B, A []float64, alpha float64, flags Flags, ldB, ldA, N, S, E, NB int) {
+
+    if B == nil || A == nil {
+        return
+    }
+    trsm(B, A, alpha, flags, flags & UPPER != 0, ldB, ldA, N, S, E)
+}
+
+// blas SYRK; blocked
+//   C = beta*C + alpha*A*A.T; or with TRANSA  C = beta*C + alpha*A.T*A
+func DSymmRankBlk(C, A []float64, alpha, beta float64, flags Flags, ldC, ldA, N, S, E, H, NB int) {
+
+    if C == nil || A == nil {
+        return
+    }
+    trans := Flags(TRANSB)
+    if flags & TRANSA != 0 {
+        trans = TRANSA
+    }
+    for j := S; j < E; j++ {
+        R, E1 := trmRows(flags, j, S, E)
+        scaleTile(C, beta, ldC, j, j+1, R, E1)
+        gemm(C, A, A, alpha, trans, ldC, ldA, ldA, N, j, j+1, R, E1)
+    }
+}
+
+// blas SYR2K; blocked
+//   C = beta*C + alpha*(A*B.T + B*A.T); or with TRANSA C = beta*C + alpha*(A.T*B + B.T*A)
+func DSymmRank2Blk(C, A, B []float64, alpha, beta float64, flags Flags, ldC, ldA, ldB, N, S, E, H, NB int) {
+
+    if C == nil || B == nil || A == nil {
+        return
+    }
+    trans := Flags(TRANSB)
+    if flags & TRANSA != 0 {
+        trans = TRANSA
+    }
+    for j := S; j < E; j++ {
+        R, E1 := trmRows(flags, j, S, E)
+        scaleTile(C, beta, ldC, j, j+1, R, E1)
+        gemm(C, A, B, alpha, trans, ldC, ldA, ldB, N, j, j+1, R, E1)
+        gemm(C, B, A, alpha, trans, ldC, ldB, ldA, N, j, j+1, R, E1)
+    }
+}
+
+// Generic triangular matrix update:
+//   C = beta*C + alpha*op(A)*op(B); only UPPER or LOWER triangle of C updated
+func DTrmUpdBlk(C, A, B []float64, alpha, beta float64, flags Flags, ldC, ldA, ldB, N, S, E, H, NB int) {
+
+    if C == nil || B == nil || A == nil {
+        return
+    }
+    trans := flags & (TRANSA|TRANSB)
+    for j := S; j < E; j++ {
+        R, E1 := trmRows(flags, j, S, E)
+        scaleTile(C, beta, ldC, j, j+1, R, E1)
+        gemm(C, A, B, alpha, trans, ldC, ldA, ldB, N, j, j+1, R, E1)
+    }
+}
+
+// Matrix-Vector operators
+
+// blas GEMV; blocked version
+// Y = alpha*A*X + beta*Y; Y is M*1, X is N*1 and A is M*N
+func DMultMV(Y, A, X []float64, alpha, beta float64, flags Flags, incY, ldA, incX, S, L, R, E, H, MB int) {
+
+    if Y == nil || A == nil || X == nil {
+        return
+    }
+    if L - S <= 0 || E - R <= 0 {
+        return
+    }
+    scaleVec(Y, beta, incY, R, E)
+    if flags & TRANSA != 0 {
+        for i := R; i < E; i++ {
+            var s float64
+            for j := S; j < L; j++ {
+                s += A[j + i*ldA]*X[j*incX]
+            }
+            Y[i*incY] += alpha*s
+        }
+        return
+    }
+    for j := S; j < L; j++ {
+        xj := alpha*X[j*incX]
+        for i := R; i < E; i++ {
+            Y[i*incY] += A[i + j*ldA]*xj
+        }
+    }
+}
+
+
+// blas GER; blocked version
+// A = A + alpha * x * y.T; A is M*N, x is M*1, Y is N*1, 0 < R < E <= M, 0 < S < L <= N
+func DRankMV(A, X, Y []float64, alpha float64, ldA, incX, incY, S, L, R, E, NB, MB int) {
+
+    if A == nil || X == nil || Y == nil {
+        return
+    }
+    for j := S; j < L; j++ {
+        yj := alpha*Y[j*incY]
+        for i := R; i < E; i++ {
+            A[i + j*ldA] += X[i*incX]*yj
+        }
+    }
+}
+
+
+// blas SYR; blocked version
+func DSymmRankMV(A, X []float64, alpha float64, flags Flags, ldA, incX, S, L, NB int) {
+
+    if A == nil || X == nil {
+        return
+    }
+    DTrmUpdMV(A, X, X, alpha, flags, ldA, incX, incX, S, L, NB)
+}
+
+// blas SYR2; blocked version
+func DSymmRank2MV(A, X, Y []float64, alpha float64, flags Flags, ldA, incX, incY, S, L, NB int) {
+
+    if A == nil || X == nil || Y == nil {
+        return
+    }
+    for j := S; j < L; j++ {
+        R, E := 0, j+1
+        if flags & UPPER == 0 {
+            R, E = j, L
+        }
+        xj, yj := alpha*X[j*incX], alpha*Y[j*incY]
+        for i := R; i < E; i++ {
+            A[i + j*ldA] += X[i*incX]*yj + Y[i*incY]*xj
+        }
+    }
+}
+
+// generic triangular matrix rank update; A = A + alpha*X*Y.T
+func DTrmUpdMV(A, X, Y []float64, alpha float64, flags Flags, ldA, incX, incY, S, L, NB int) {
+
+    if A == nil || X == nil || Y == nil {
+        return
+    }
+    for j := S; j < L; j++ {
+        R, E := 0, j+1
+        if flags & UPPER == 0 {
+            R, E = j, L
+        }
+        yj := alpha*Y[j*incY]
+        for i := R; i < E; i++ {
+            A[i + j*ldA] += X[i*incX]*yj
+        }
+    }
+}
+
+
+// blas TSMV; unblocked version
+func DSolveUnblkMV(X, A []float64, flags Flags, incX, ldA, N int) {
+
+    if A == nil || X == nil {
+        return
+    }
+    // as the C kernels, solves with A, not A.T
+    trsv(X, A, flags & LOWER == 0, false, flags & UNIT != 0, incX, ldA, N)
+}
+
+// blas TSMV; blocked version
+func DSolveBlkMV(X, A []float64, flags Flags, incX, ldA, N, NB int) {
+
+    if A == nil || X == nil {
+        return
+    }
+    trsv(X, A, flags & LOWER == 0, false, flags & UNIT != 0, incX, ldA, N)
+}
+
+// blas TRMV; unblocked
+func DTrimvUnblkMV(X, A []float64, flags Flags, incX, ldA, N int) {
+
+    if A == nil || X == nil {
+        return
+    }
+    trmv(X, A, flags & UPPER != 0, flags & (TRANSA|TRANS) != 0, flags & UNIT != 0, incX, ldA, N)
+}
+
+// Z[0] = beta*Z[0] + alpha * X * Y
+func DDotSum(Z, X, Y []float64, alpha, beta float64, incZ, incX, incY, N int) {
+
+    if Z == nil || X == nil || Y == nil || N <= 0 {
+        return
+    }
+    Z[0] = beta*Z[0] + alpha*dot(X, Y, incX, incY, N)
+}
+
+// return: alpha * X * Y
+func DDot(X, Y []float64, alpha float64, incX, incY, N int) float64 {
+
+    if X == nil || Y == nil || N <= 0 {
+        return 0.0
+    }
+    return alpha*dot(X, Y, incX, incY, N)
+}
+
+// Y := alpha*X + Y
+func DAxpy(Y, X []float64, alpha float64, incX, incY, N int) {
+
+    if X == nil || Y == nil || N <= 0 {
+        return
+    }
+    for i := 0; i < N; i++ {
+        Y[i*incY] += alpha*X[i*incX]
+    }
+}
+
+// return: sum (abs(X[i]-Y[i]))^2
+func DiffNorm2(X, Y []float64, incX, incY, N int) float64 {
+
+    if X == nil || Y == nil || N <= 0 {
+        return 0.0
+    }
+    var s float64
+    for i := 0; i < N; i++ {
+        d := X[i*incX] - Y[i*incY]
+        s += d*d
+    }
+    return math.Sqrt(s)
+}
+
+// return: sum (abs(X[i]))^2; Euclidaen norm
+func DNorm2(X []float64, incX, N int) float64 {
+
+    if X == nil || N <= 0 {
+        return 0.0
+    }
+    return math.Sqrt(dot(X, X, incX, incX, N))
+}
+
+// return: sum (abs(X[i]))
+func DAsum(X []float64, incX, N int) float64 {
+
+    if X == nil || N <= 0 {
+        return 0.0
+    }
+    var s float64
+    for i := 0; i < N; i++ {
+        s += math.Abs(X[i*incX])
+    }
+    return s
+}
+
+// return: index of max absolute value
+func DIAMax(X []float64, incX, N int) int {
+
+    if X == nil || N <= 0 {
+        return -1
+    }
+    ix := 0
+    max := 0.0
+    for i := 0; i < N; i++ {
+        if a := math.Abs(X[i*incX]); a > max {
+            ix, max = i, a
+        }
+    }
+    return ix
+}
+
+func DSwap(X, Y []float64, incX, incY, N int) {
+
+    if X == nil || Y == nil || N <= 0 {
+        return
+    }
+    for i := 0; i < N; i++ {
+        X[i*incX], Y[i*incY] = Y[i*incY], X[i*incX]
+    }
+}
+
+// copying: X := Y
+func DCopy(X, Y []float64, incX, incY, N int) {
+
+    if X == nil || Y == nil || N <= 0 {
+        return
+    }
+    for i := 0; i < N; i++ {
+        X[i*incX] = Y[i*incY]
+    }
+}
+
+// inverse scaling: X = X/alpha
+func DInvScal(X []float64, alpha float64, incX, N int) {
+
+    if X == nil || N <= 0 {
+        return
+    }
+    for i := 0; i < N; i++ {
+        X[i*incX] /= alpha
+    }
+}
+
+// scaling: X = alpha*X
+func DScal(X []float64, alpha float64, incX, N int) {
+
+    if X == nil || N <= 0 {
+        return
+    }
+    scaleVec(X, alpha, incX, 0, N)
+}
+
+// Helpers.
+
+// Element types of the kernels.
+type number interface {
+    float32 | float64 | complex128
+}
+
+// C[R:E,S:L] = beta*C[R:E,S:L]; zero if beta is zero.
+func scaleTile[T number](C []T, beta T, ldC, S, L, R, E int) {
+    if beta == 1.0 {
+        return
+    }
+    for j := S; j < L; j++ {
+        for i := R; i < E; i++ {
+            if beta == 0.0 {
+                C[i + j*ldC] = 0.0
+            } else {
+                C[i + j*ldC] *= beta
+            }
+        }
+    }
+}
+
+// X[R:E] = beta*X[R:E]; zero if beta is zero.
+func scaleVec[T number](X []T, beta T, incX, R, E int) {
+    if beta == 1.0 {
+        return
+    }
+    for i := R; i < E; i++ {
+        if beta == 0.0 {
+            X[i*incX] = 0.0
+        } else {
+            X[i*incX] *= beta
+        }
+    }
+}
+
+func dot[T number](X, Y []T, incX, incY, N int) T {
+    var s T
+    for i := 0; i < N; i++ {
+        s += X[i*incX]*Y[i*incY]
+    }
+    return s
+}
+
+// C[R:E,S:L] += alpha*op(A)*op(B) with panel length P.
+func gemm[T number](C, A, B []T, alpha T, trans Flags, ldC, ldA, ldB, P, S, L, R, E int) {
+    for j := S; j < L; j++ {
+        if trans & TRANSA != 0 {
+            // rows of A.T are columns of A; inner products
+            for i := R; i < E; i++ {
+                var s T
+                for k := 0; k < P; k++ {
+                    if trans & TRANSB != 0 {
+                        s += A[k + i*ldA]*B[j + k*ldB]
+                    } else {
+                        s += A[k + i*ldA]*B[k + j*ldB]
+                    }
+                }
+                C[i + j*ldC] += alpha*s
+            }
+            continue
+        }
+        // columns of A scaled with elements of B
+        for k := 0; k < P; k++ {
+            var bk T
+            if trans & TRANSB != 0 {
+                bk = alpha*B[j + k*ldB]
+            } else {
+                bk = alpha*B[k + j*ldB]
+            }
+            for i := R; i < E; i++ {
+                C[i + j*ldC] += A[i + k*ldA]*bk
+            }
+        }
+    }
+}
+
+// Element (i, j) of symmetric matrix stored in upper or lower triangle of A.
+func symmAt(A []float64, ldA, i, j int, upper, unit bool) float64 {
+    if i == j && unit {
+        return 1.0
+    }
+    if (upper && i > j) || (! upper && i < j) {
+        i, j = j, i
+    }
+    return A[i + j*ldA]
+}
+
+// Rows [R:E] of column j within triangle of [S:E, S:E].
+func trmRows(flags Flags, j, S, E int) (int, int) {
+    if flags & UPPER != 0 {
+        return S, j+1
+    }
+    return j, E
+}
+
+// B = alpha*op(A)*B (LEFT, columns S:E of B) or B = alpha*B*op(A) (RIGHT, rows S:E).
+func trmm(B, A []float64, alpha float64, flags Flags, upper bool, ldB, ldA, N, S, E int) {
+    trans := flags & TRANSA != 0
+    unit := flags & UNIT != 0
+    if flags & RIGHT != 0 {
+        // row x of B: x = x*op(A) => x.T = op(A).T*x.T
+        for i := S; i < E; i++ {
+            trmv(B[i:], A, upper, ! trans, unit, ldB, ldA, N)
+            scaleVec(B[i:], alpha, ldB, 0, N)
+        }
+        return
+    }
+    for j := S; j < E; j++ {
+        trmv(B[j*ldB:], A, upper, trans, unit, 1, ldA, N)
+        scaleVec(B[j*ldB:], alpha, 1, 0, N)
+    }
+}
+
+// B = alpha*op(A).-1*B (LEFT, columns S:E of B) or B = alpha*B*op(A).-1 (RIGHT, rows S:E).
+func trsm[T number](B, A []T, alpha T, flags Flags, upper bool, ldB, ldA, N, S, E int) {
+    trans := flags & TRANSA != 0
+    unit := flags & UNIT != 0
+    if flags & RIGHT != 0 {
+        for i := S; i < E; i++ {
+            scaleVec(B[i:], alpha, ldB, 0, N)
+            trsv(B[i:], A, upper, ! trans, unit, ldB, ldA, N)
+        }
+        return
+    }
+    for j := S; j < E; j++ {
+        scaleVec(B[j*ldB:], alpha, 1, 0, N)
+        trsv(B[j*ldB:], A, upper, trans, unit, 1, ldA, N)
+    }
+}
+
+// X = op(A)*X; A is N*N upper or lower triangular.
+func trmv(X, A []float64, upper, trans, unit bool, incX, ldA, N int) {
+    switch {
+    case upper && ! trans:
+        for j := 0; j < N; j++ {
+            xj := X[j*incX]
+            for i := 0; i < j; i++ {
+                X[i*incX] += A[i + j*ldA]*xj
+            }
+            if ! unit {
+                X[j*incX] = xj*A[j + j*ldA]
+            }
+        }
+    case ! upper && ! trans:
+        for j := N-1; j >= 0; j-- {
+            xj := X[j*incX]
+            for i := j+1; i < N; i++ {
+                X[i*incX] += A[i + j*ldA]*xj
+            }
+            if ! unit {
+                X[j*incX] = xj*A[j + j*ldA]
+            }
+        }
+    case upper && trans:
+        for j := N-1; j >= 0; j-- {
+            s := X[j*incX]
+            if ! unit {
+                s *= A[j + j*ldA]
+            }
+            for i := 0; i < j; i++ {
+                s += A[i + j*ldA]*X[i*incX]
+            }
+            X[j*incX] = s
+        }
+    default:
+        for j := 0; j < N; j++ {
+            s := X[j*incX]
+            if ! unit {
+                s *= A[j + j*ldA]
+            }
+            for i := j+1; i < N; i++ {
+                s += A[i + j*ldA]*X[i*incX]
+            }
+            X[j*incX] = s
+        }
+    }
+}
+
+// X = op(A).-1*X; A is N*N upper or lower triangular.
+func trsv[T number](X, A []T, upper, trans, unit bool, incX, ldA, N int) {
+    switch {
+    case upper && ! trans:
+        for j := N-1; j >= 0; j-- {
+            if ! unit {
+                X[j*incX] /= A[j + j*ldA]
+            }
+            xj := X[j*incX]
+            for i := 0; i < j; i++ {
+                X[i*incX] -= A[i + j*ldA]*xj
+            }
+        }
+    case ! upper && ! trans:
+        for j := 0; j < N; j++ {
+            if ! unit {
+                X[j*incX] /= A[j + j*ldA]
+            }
+            xj := X[j*incX]
+            for i := j+1; i < N; i++ {
+                X[i*incX] -= A[i + j*ldA]*xj
+            }
+        }
+    case upper && trans:
+        for j := 0; j < N; j++ {
+            s := X[j*incX]
+            for i := 0; i < j; i++ {
+                s -= A[i + j*ldA]*X[i*incX]
+            }
+            if ! unit {
+                s /= A[j + j*ldA]
+            }
+            X[j*incX] = s
+        }
+    default:
+        for j := N-1; j >= 0; j-- {
+            s := X[j*incX]
+            for i := j+1; i < N; i++ {
+                s -= A[i + j*ldA]*X[i*incX]
+            }
+            if ! unit {
+                s /= A[j + j*ldA]
+            }
+            X[j*incX] = s
+        }
+    }
+}
+
+// Return name of the current kernel set; always "go".
+func Kernels() string {
+    return "go"
+}
+
+// Select kernel set by name. Only "go" is available in this build.
+func SetKernels(name string) error {
+    if name != "go" {
+        return errors.New("unknown kernel set: " + name)
+    }
+    return nil
+}
+
+
+// Local Variables:
+// tab-width: 4
+// indent-tabs-mode: nil
+// End:
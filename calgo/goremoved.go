@@ -0,0 +1,119 @@
+//go:build !cgo || !amd64 || purego
+
+
+package calgo
+
+// Pure Go versions of the functions in removed.go.
+
+func DMult0(C, A, B []float64, alpha, beta float64, trans Flags, ldC, ldA, ldB, P, S, L, R, E, H, NB, MB int) {
+    DMult(C, A, B, alpha, beta, trans, ldC, ldA, ldB, P, S, L, R, E, H, NB, MB)
+}
+
+func DMultOld(C, A, B []float64, alpha, beta float64, trans Flags, ldC, ldA, ldB, P, S, L, R, E, H, NB, MB int) {
+    DMult(C, A, B, alpha, beta, trans, ldC, ldA, ldB, P, S, L, R, E, H, NB, MB)
+}
+
+func DMultSymmOld(C, A, B []float64, alpha, beta float64, flags Flags, ldC, ldA, ldB, P, S, L, R, E, H, NB, MB int) {
+    DMultSymm(C, A, B, alpha, beta, flags, ldC, ldA, ldB, P, S, L, R, E, H, NB, MB)
+}
+
+func DSolveLower(X, A []float64, unit bool, incX, ldA, N, NB int) {
+    var flags Flags = LOWER
+    if unit {
+        flags |= UNIT
+    }
+    DSolveUnblkMV(X, A, flags, incX, ldA, N)
+}
+
+func DSolveLowerBlocked(X, A []float64, unit bool, incX, ldA, N, NB int) {
+    var flags Flags = LOWER
+    if unit {
+        flags |= UNIT
+    }
+    DSolveBlkMV(X, A, flags, incX, ldA, N, NB)
+}
+
+func DSolveUpper(X, A []float64, unit bool, incX, ldA, N, NB int) {
+    var flags Flags = UPPER
+    if unit {
+        flags |= UNIT
+    }
+    DSolveUnblkMV(X, A, flags, incX, ldA, N)
+}
+
+func DSolveUpperBlocked(X, A []float64, unit bool, incX, ldA, N, NB int) {
+    var flags Flags = UPPER
+    if unit {
+        flags |= UNIT
+    }
+    DSolveBlkMV(X, A, flags, incX, ldA, N, NB)
+}
+
+func DTrimvUpper(X, A []float64, unit bool, incX, ldA, N, NB int) {
+    var flags Flags = UPPER
+    if unit {
+        flags |= UNIT
+    }
+    DTrimvUnblkMV(X, A, flags, incX, ldA, N)
+}
+
+func DTrimvUpperTransA(X, A []float64, unit bool, incX, ldA, N, NB int) {
+    var flags Flags = UPPER|TRANSA
+    if unit {
+        flags |= UNIT
+    }
+    DTrimvUnblkMV(X, A, flags, incX, ldA, N)
+}
+
+func DTrimvLower(X, A []float64, unit bool, incX, ldA, N, NB int) {
+    var flags Flags = LOWER
+    if unit {
+        flags |= UNIT
+    }
+    DTrimvUnblkMV(X, A, flags, incX, ldA, N)
+}
+
+func DTrimvLowerTransA(X, A []float64, unit bool, incX, ldA, N, NB int) {
+    var flags Flags = LOWER|TRANSA
+    if unit {
+        flags |= UNIT
+    }
+    DTrimvUnblkMV(X, A, flags, incX, ldA, N)
+}
+
+func DTrmmUpper(B, A []float64, alpha float64, unit bool, ldB, ldA, N, S, L int) {
+    var flags Flags = UPPER
+    if unit {
+        flags |= UNIT
+    }
+    DTrmmUnblk(B, A, alpha, flags, ldB, ldA, N, S, L, 0)
+}
+
+func DTrmmUpperTransA(B, A []float64, alpha float64, unit bool, ldB, ldA, N, S, L int) {
+    var flags Flags = UPPER|TRANSA
+    if unit {
+        flags |= UNIT
+    }
+    DTrmmUnblk(B, A, alpha, flags, ldB, ldA, N, S, L, 0)
+}
+
+func DTrmmLower(B, A []float64, alpha float64, unit bool, ldB, ldA, N, S, L int) {
+    var flags Flags = LOWER
+    if unit {
+        flags |= UNIT
+    }
+    DTrmmUnblk(B, A, alpha, flags, ldB, ldA, N, S, L, 0)
+}
+
+func DTrmmLowerTransA(B, A []float64, alpha float64, unit bool, ldB, ldA, N, S, L int) {
+    var flags Flags = LOWER|TRANSA
+    if unit {
+        flags |= UNIT
+    }
+    DTrmmUnblk(B, A, alpha, flags, ldB, ldA, N, S, L, 0)
+}
+
+// Local Variables:
+// tab-width: 4
+// indent-tabs-mode: nil
+// End:
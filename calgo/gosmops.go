@@ -0,0 +1,198 @@
+//go:build !cgo || !amd64 || purego
+
+// Copyright (c) Harri Rautila, 2012,2013
+
+// This file is part of github.com/hrautila/matops package. It is free software,
+// distributed under the terms of GNU Lesser General Public License Version 3, or
+// any later version. See the COPYING tile included in this archive.
+
+
+package calgo
+
+import "math"
+
+// Pure Go versions of the single precision kernels in smops.go.
+
+// blas SGEMM; see DMult().
+func SMult(C, A, B []float32, alpha, beta float32, trans Flags, ldC, ldA, ldB, P, S, L, R, E, H, NB, MB int) {
+
+    if C == nil || B == nil || A == nil {
+        return
+    }
+    if L - S <= 0 || E - R <= 0 {
+        return
+    }
+    scaleTile(C, beta, ldC, S, L, R, E)
+    gemm(C, A, B, alpha, trans, ldC, ldA, ldB, P, S, L, R, E)
+}
+
+// blas STRSM; see DSolveBlk().
+func SSolveBlk(B, A []float32, alpha float32, flags Flags, ldB, ldA, N, S, E, NB int) {
+
+    if B == nil || A == nil || N == 0 {
+        return
+    }
+    trsm(B, A, alpha, flags, flags & UPPER != 0, ldB, ldA, N, S, E)
+}
+
+// blas SSYRK; see DSymmRankBlk().
+func SSymmRankBlk(C, A []float32, alpha, beta float32, flags Flags, ldC, ldA, N, S, E, H, NB int) {
+
+    if C == nil || A == nil {
+        return
+    }
+    trans := Flags(TRANSB)
+    if flags & TRANSA != 0 {
+        trans = TRANSA
+    }
+    for j := S; j < E; j++ {
+        R, E1 := trmRows(flags, j, S, E)
+        scaleTile(C, beta, ldC, j, j+1, R, E1)
+        gemm(C, A, A, alpha, trans, ldC, ldA, ldA, N, j, j+1, R, E1)
+    }
+}
+
+// blas SGEMV; see DMultMV().
+func SMultMV(Y, A, X []float32, alpha, beta float32, flags Flags, incY, ldA, incX, S, L, R, E, H, MB int) {
+
+    if Y == nil || A == nil || X == nil {
+        return
+    }
+    if L - S <= 0 || E - R <= 0 {
+        return
+    }
+    scaleVec(Y, beta, incY, R, E)
+    if flags & TRANSA != 0 {
+        for i := R; i < E; i++ {
+            Y[i*incY] += alpha*dot(A[S + i*ldA:], X[S*incX:], 1, incX, L-S)
+        }
+        return
+    }
+    for j := S; j < L; j++ {
+        xj := alpha*X[j*incX]
+        for i := R; i < E; i++ {
+            Y[i*incY] += A[i + j*ldA]*xj
+        }
+    }
+}
+
+// blas SGER; see DRankMV().
+func SRankMV(A, X, Y []float32, alpha float32, ldA, incX, incY, S, L, R, E, NB, MB int) {
+
+    if A == nil || X == nil || Y == nil {
+        return
+    }
+    for j := S; j < L; j++ {
+        yj := alpha*Y[j*incY]
+        for i := R; i < E; i++ {
+            A[i + j*ldA] += X[i*incX]*yj
+        }
+    }
+}
+
+// blas SSYR; see DSymmRankMV().
+func SSymmRankMV(A, X []float32, alpha float32, flags Flags, ldA, incX, S, L, NB int) {
+
+    if A == nil || X == nil {
+        return
+    }
+    for j := S; j < L; j++ {
+        R, E := 0, j+1
+        if flags & UPPER == 0 {
+            R, E = j, L
+        }
+        xj := alpha*X[j*incX]
+        for i := R; i < E; i++ {
+            A[i + j*ldA] += X[i*incX]*xj
+        }
+    }
+}
+
+// return: alpha * X * Y
+func SDot(X, Y []float32, alpha float32, incX, incY, N int) float32 {
+
+    if X == nil || Y == nil || N <= 0 {
+        return 0.0
+    }
+    return alpha*dot(X, Y, incX, incY, N)
+}
+
+// Y := alpha*X + Y
+func SAxpy(Y, X []float32, alpha float32, incX, incY, N int) {
+
+    if X == nil || Y == nil || N <= 0 {
+        return
+    }
+    for i := 0; i < N; i++ {
+        Y[i*incY] += alpha*X[i*incX]
+    }
+}
+
+// return: Euclidean norm of X; squares are summed in double precision
+func SNorm2(X []float32, incX, N int) float32 {
+
+    if X == nil || N <= 0 {
+        return 0.0
+    }
+    var s float64
+    for i := 0; i < N; i++ {
+        x := float64(X[i*incX])
+        s += x*x
+    }
+    return float32(math.Sqrt(s))
+}
+
+// return: index of max absolute value
+func SIAMax(X []float32, incX, N int) int {
+
+    if X == nil || N <= 0 {
+        return -1
+    }
+    ix := 0
+    var max float32
+    for i := 0; i < N; i++ {
+        a := X[i*incX]
+        if a < 0.0 {
+            a = -a
+        }
+        if a > max {
+            ix, max = i, a
+        }
+    }
+    return ix
+}
+
+func SSwap(X, Y []float32, incX, incY, N int) {
+
+    if X == nil || Y == nil || N <= 0 {
+        return
+    }
+    for i := 0; i < N; i++ {
+        X[i*incX], Y[i*incY] = Y[i*incY], X[i*incX]
+    }
+}
+
+// inverse scaling: X = X/alpha
+func SInvScal(X []float32, alpha float32, incX, N int) {
+
+    if X == nil || N <= 0 {
+        return
+    }
+    for i := 0; i < N; i++ {
+        X[i*incX] /= alpha
+    }
+}
+
+// scaling: X = alpha*X
+func SScal(X []float32, alpha float32, incX, N int) {
+
+    if X == nil || N <= 0 {
+        return
+    }
+    scaleVec(X, alpha, incX, 0, N)
+}
+
+// Local Variables:
+// tab-width: 4
+// indent-tabs-mode: nil
+// End:
@@ -0,0 +1,326 @@
+//go:build !cgo || !amd64 || purego
+
+// Copyright (c) Harri Rautila, 2012,2013
+
+// This file is part of github.com/hrautila/matops package. It is free software,
+// distributed under the terms of GNU Lesser General Public License Version 3, or
+// any later version. See the COPYING tile included in this archive.
+
+
+package calgo
+
+import (
+    "math"
+    "math/cmplx"
+)
+
+// Pure Go versions of the double precision complex kernels in zmops.go.
+
+// blas ZGEMM; see DMult().
+func ZMult(C, A, B []complex128, alpha, beta complex128, trans Flags, ldC, ldA, ldB, P, S, L, R, E, H, NB, MB int) {
+
+    if C == nil || B == nil || A == nil {
+        return
+    }
+    if L - S <= 0 || E - R <= 0 {
+        return
+    }
+    scaleTile(C, beta, ldC, S, L, R, E)
+    zgemm(C, A, B, alpha, trans, ldC, ldA, ldB, P, S, L, R, E)
+}
+
+// blas ZTRSM; see DSolveBlk().
+func ZSolveBlk(B, A []complex128, alpha complex128, flags Flags, ldB, ldA, N, S, E, NB int) {
+
+    if B == nil || A == nil || N == 0 {
+        return
+    }
+    upper := flags & UPPER != 0
+    trans := flags & TRANSA != 0
+    unit := flags & UNIT != 0
+    if flags & RIGHT != 0 {
+        // row x of B: x = x*op(A).-1 => op(A).T*x.T = x.T
+        for i := S; i < E; i++ {
+            scaleVec(B[i:], alpha, ldB, 0, N)
+            ztrsv(B[i:], A, upper, ! trans, trans, unit, ldB, ldA, N)
+        }
+        return
+    }
+    for j := S; j < E; j++ {
+        scaleVec(B[j*ldB:], alpha, 1, 0, N)
+        ztrsv(B[j*ldB:], A, upper, trans, trans, unit, 1, ldA, N)
+    }
+}
+
+// blas ZHERK; C = beta*C + alpha*A*A.H or C = beta*C + alpha*A.H*A if flags&TRANSA.
+// See DSymmRankBlk().
+func ZHermRankBlk(C, A []complex128, alpha, beta float64, flags Flags, ldC, ldA, N, S, E, H, NB int) {
+
+    if C == nil || A == nil {
+        return
+    }
+    trans := Flags(TRANSB)
+    if flags & TRANSA != 0 {
+        trans = TRANSA
+    }
+    for j := S; j < E; j++ {
+        R, E1 := trmRows(flags, j, S, E)
+        scaleTile(C, complex(beta, 0.0), ldC, j, j+1, R, E1)
+        zgemm(C, A, A, complex(alpha, 0.0), trans, ldC, ldA, ldA, N, j, j+1, R, E1)
+        C[j + j*ldC] = complex(real(C[j + j*ldC]), 0.0)
+    }
+}
+
+// blas ZGEMV; see DMultMV().
+func ZMultMV(Y, A, X []complex128, alpha, beta complex128, flags Flags, incY, ldA, incX, S, L, R, E, H, MB int) {
+
+    if Y == nil || A == nil || X == nil {
+        return
+    }
+    if L - S <= 0 || E - R <= 0 {
+        return
+    }
+    scaleVec(Y, beta, incY, R, E)
+    if flags & TRANSA != 0 {
+        for i := R; i < E; i++ {
+            Y[i*incY] += alpha*zdotc(A[S + i*ldA:], X[S*incX:], 1, incX, L-S)
+        }
+        return
+    }
+    for j := S; j < L; j++ {
+        xj := alpha*X[j*incX]
+        for i := R; i < E; i++ {
+            Y[i*incY] += A[i + j*ldA]*xj
+        }
+    }
+}
+
+// blas ZGERC; A = A + alpha*X*Y.H. See DRankMV().
+func ZRankMV(A, X, Y []complex128, alpha complex128, ldA, incX, incY, S, L, R, E, NB, MB int) {
+
+    if A == nil || X == nil || Y == nil {
+        return
+    }
+    for j := S; j < L; j++ {
+        yj := alpha*cmplx.Conj(Y[j*incY])
+        for i := R; i < E; i++ {
+            A[i + j*ldA] += X[i*incX]*yj
+        }
+    }
+}
+
+// blas ZHER; A = A + alpha*X*X.H. See DSymmRankMV().
+func ZHermRankMV(A, X []complex128, alpha float64, flags Flags, ldA, incX, S, L, NB int) {
+
+    if A == nil || X == nil {
+        return
+    }
+    for j := S; j < L; j++ {
+        R, E := 0, j+1
+        if flags & UPPER == 0 {
+            R, E = j, L
+        }
+        xj := complex(alpha, 0.0)*cmplx.Conj(X[j*incX])
+        for i := R; i < E; i++ {
+            A[i + j*ldA] += X[i*incX]*xj
+        }
+        A[j + j*ldA] = complex(real(A[j + j*ldA]), 0.0)
+    }
+}
+
+// return: alpha * X * Y
+func ZDotu(X, Y []complex128, alpha complex128, incX, incY, N int) complex128 {
+
+    if X == nil || Y == nil || N <= 0 {
+        return 0.0
+    }
+    var s complex128
+    for i := 0; i < N; i++ {
+        s += X[i*incX]*Y[i*incY]
+    }
+    return alpha*s
+}
+
+// return: alpha * X.H * Y
+func ZDotc(X, Y []complex128, alpha complex128, incX, incY, N int) complex128 {
+
+    if X == nil || Y == nil || N <= 0 {
+        return 0.0
+    }
+    return alpha*zdotc(X, Y, incX, incY, N)
+}
+
+// Y := alpha*X + Y
+func ZAxpy(Y, X []complex128, alpha complex128, incX, incY, N int) {
+
+    if X == nil || Y == nil || N <= 0 {
+        return
+    }
+    for i := 0; i < N; i++ {
+        Y[i*incY] += alpha*X[i*incX]
+    }
+}
+
+// return: Euclidean norm of X
+func ZNorm2(X []complex128, incX, N int) float64 {
+
+    if X == nil || N <= 0 {
+        return 0.0
+    }
+    var s float64
+    for i := 0; i < N; i++ {
+        x := X[i*incX]
+        s += real(x)*real(x) + imag(x)*imag(x)
+    }
+    return math.Sqrt(s)
+}
+
+// return: index of max |real| + |imag| value
+func ZIAMax(X []complex128, incX, N int) int {
+
+    if X == nil || N <= 0 {
+        return -1
+    }
+    ix := 0
+    max := -1.0
+    for i := 0; i < N; i++ {
+        a := math.Abs(real(X[i*incX])) + math.Abs(imag(X[i*incX]))
+        if a > max {
+            ix, max = i, a
+        }
+    }
+    return ix
+}
+
+func ZSwap(X, Y []complex128, incX, incY, N int) {
+
+    if X == nil || Y == nil || N <= 0 {
+        return
+    }
+    for i := 0; i < N; i++ {
+        X[i*incX], Y[i*incY] = Y[i*incY], X[i*incX]
+    }
+}
+
+// inverse scaling: X = X/alpha
+func ZInvScal(X []complex128, alpha complex128, incX, N int) {
+
+    if X == nil || N <= 0 {
+        return
+    }
+    for i := 0; i < N; i++ {
+        X[i*incX] /= alpha
+    }
+}
+
+// scaling: X = alpha*X
+func ZScal(X []complex128, alpha complex128, incX, N int) {
+
+    if X == nil || N <= 0 {
+        return
+    }
+    scaleVec(X, alpha, incX, 0, N)
+}
+
+// Helpers.
+
+func zdotc(X, Y []complex128, incX, incY, N int) complex128 {
+    var s complex128
+    for i := 0; i < N; i++ {
+        s += cmplx.Conj(X[i*incX])*Y[i*incY]
+    }
+    return s
+}
+
+// C[R:E,S:L] += alpha*op(A)*op(B) with panel length P; op(X) is X or X.H
+func zgemm(C, A, B []complex128, alpha complex128, trans Flags, ldC, ldA, ldB, P, S, L, R, E int) {
+    for j := S; j < L; j++ {
+        if trans & TRANSA != 0 {
+            // rows of A.H are conjugated columns of A; inner products
+            for i := R; i < E; i++ {
+                var s complex128
+                for k := 0; k < P; k++ {
+                    if trans & TRANSB != 0 {
+                        s += cmplx.Conj(A[k + i*ldA]*B[j + k*ldB])
+                    } else {
+                        s += cmplx.Conj(A[k + i*ldA])*B[k + j*ldB]
+                    }
+                }
+                C[i + j*ldC] += alpha*s
+            }
+            continue
+        }
+        // columns of A scaled with elements of B
+        for k := 0; k < P; k++ {
+            var bk complex128
+            if trans & TRANSB != 0 {
+                bk = alpha*cmplx.Conj(B[j + k*ldB])
+            } else {
+                bk = alpha*B[k + j*ldB]
+            }
+            for i := R; i < E; i++ {
+                C[i + j*ldC] += A[i + k*ldA]*bk
+            }
+        }
+    }
+}
+
+// X = op(A).-1*X; A is N*N upper or lower triangular, op(A) is A or A.T with
+// elements of A conjugated if cj is true.
+func ztrsv(X, A []complex128, upper, trans, cj, unit bool, incX, ldA, N int) {
+    at := func(i, j int) complex128 {
+        if cj {
+            return cmplx.Conj(A[i + j*ldA])
+        }
+        return A[i + j*ldA]
+    }
+    switch {
+    case upper && ! trans:
+        for j := N-1; j >= 0; j-- {
+            if ! unit {
+                X[j*incX] /= at(j, j)
+            }
+            xj := X[j*incX]
+            for i := 0; i < j; i++ {
+                X[i*incX] -= at(i, j)*xj
+            }
+        }
+    case ! upper && ! trans:
+        for j := 0; j < N; j++ {
+            if ! unit {
+                X[j*incX] /= at(j, j)
+            }
+            xj := X[j*incX]
+            for i := j+1; i < N; i++ {
+                X[i*incX] -= at(i, j)*xj
+            }
+        }
+    case upper && trans:
+        for j := 0; j < N; j++ {
+            s := X[j*incX]
+            for i := 0; i < j; i++ {
+                s -= at(i, j)*X[i*incX]
+            }
+            if ! unit {
+                s /= at(j, j)
+            }
+            X[j*incX] = s
+        }
+    default:
+        for j := N-1; j >= 0; j-- {
+            s := X[j*incX]
+            for i := j+1; i < N; i++ {
+                s -= at(i, j)*X[i*incX]
+            }
+            if ! unit {
+                s /= at(j, j)
+            }
+            X[j*incX] = s
+        }
+    }
+}
+
+// Local Variables:
+// tab-width: 4
+// indent-tabs-mode: nil
+// End:
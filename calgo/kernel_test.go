@@ -0,0 +1,431 @@
+
+// Copyright (c) Harri Rautila, 2012,2013
+
+// This file is part of github.com/hrautila/matops package. It is free software,
+// distributed under the terms of GNU Lesser General Public License Version 3, or
+// any later version. See the COPYING tile included in this archive.
+
+
+package calgo
+
+// Kernels against straightforward reference computations; run with both the C
+// and the pure Go kernels (-tags purego or CGO_ENABLED=0).
+
+import (
+    "testing"
+    "math/rand"
+    "math"
+)
+
+// column-major test matrix with leading index rows+3
+type tmat struct {
+    r, c, ld int
+    d []float64
+}
+
+func newTmat(r, c int) *tmat {
+    m := &tmat{r, c, r+3, make([]float64, (r+3)*c)}
+    for k := range m.d {
+        m.d[k] = rand.Float64() - 0.5
+    }
+    return m
+}
+
+// well conditioned triangular-friendly square matrix
+func newTmatDiag(n int) *tmat {
+    m := newTmat(n, n)
+    for k := 0; k < n; k++ {
+        m.d[k + k*m.ld] = 2.0 + float64(k % 3)
+    }
+    return m
+}
+
+func (m *tmat) at(i, j int) float64 {
+    return m.d[i + j*m.ld]
+}
+
+func (m *tmat) copy() *tmat {
+    c := *m
+    c.d = append([]float64(nil), m.d...)
+    return &c
+}
+
+// op(m)(i,j)
+func (m *tmat) op(trans bool, i, j int) float64 {
+    if trans {
+        return m.at(j, i)
+    }
+    return m.at(i, j)
+}
+
+// element of triangular matrix stored in m
+func (m *tmat) trm(upper, unit bool, i, j int) float64 {
+    switch {
+    case i == j && unit:
+        return 1.0
+    case upper && i > j, ! upper && i < j:
+        return 0.0
+    }
+    return m.at(i, j)
+}
+
+func maxDiff(t *testing.T, name string, x, y []float64) {
+    var d float64
+    for k := range x {
+        d = math.Max(d, math.Abs(x[k] - y[k]))
+    }
+    if d > 1e-12 {
+        t.Errorf("%s: max difference %e\n", name, d)
+    }
+}
+
+func TestKernelMult(t *testing.T) {
+    m, n, p := 23, 17, 29
+    for _, trans := range []Flags{NOTRANS, TRANSA, TRANSB, TRANSA|TRANSB} {
+        ta, tb := trans & TRANSA != 0, trans & TRANSB != 0
+        A := newTmat(m, p)
+        if ta {
+            A = newTmat(p, m)
+        }
+        B := newTmat(p, n)
+        if tb {
+            B = newTmat(n, p)
+        }
+        C := newTmat(m, n)
+        C0 := C.copy()
+        // update a sub-block only
+        S, L, R, E := 2, n-3, 1, m-4
+        DMult(C.d, A.d, B.d, 2.0, 0.5, trans, C.ld, A.ld, B.ld, p, S, L, R, E, 0, 0, 0)
+        for j := S; j < L; j++ {
+            for i := R; i < E; i++ {
+                var s float64
+                for k := 0; k < p; k++ {
+                    s += A.op(ta, i, k)*B.op(tb, k, j)
+                }
+                C0.d[i + j*C0.ld] = 0.5*C0.at(i, j) + 2.0*s
+            }
+        }
+        maxDiff(t, "DMult", C.d, C0.d)
+    }
+}
+
+func TestKernelMultSymm(t *testing.T) {
+    m, n := 19, 13
+    for _, flags := range []Flags{LEFT|LOWER, LEFT|UPPER, RIGHT|LOWER, RIGHT|UPPER} {
+        upper := flags & UPPER != 0
+        p := m
+        if flags & RIGHT != 0 {
+            p = n
+        }
+        A := newTmat(p, p)
+        B := newTmat(m, n)
+        C := newTmat(m, n)
+        C0 := C.copy()
+        DMultSymm(C.d, A.d, B.d, -1.0, 2.0, flags, C.ld, A.ld, B.ld, p, 0, n, 0, m, 0, 0, 0)
+        sym := func(i, j int) float64 {
+            if (upper && i > j) || (! upper && i < j) {
+                return A.at(j, i)
+            }
+            return A.at(i, j)
+        }
+        for j := 0; j < n; j++ {
+            for i := 0; i < m; i++ {
+                var s float64
+                for k := 0; k < p; k++ {
+                    if flags & RIGHT != 0 {
+                        s += B.at(i, k)*sym(k, j)
+                    } else {
+                        s += sym(i, k)*B.at(k, j)
+                    }
+                }
+                C0.d[i + j*C0.ld] = 2.0*C0.at(i, j) - s
+            }
+        }
+        maxDiff(t, "DMultSymm", C.d, C0.d)
+    }
+}
+
+func TestKernelTrmmSolve(t *testing.T) {
+    n, nb := 21, 16
+    for _, side := range []Flags{LEFT, RIGHT} {
+        for _, uplo := range []Flags{LOWER, UPPER} {
+            for _, tr := range []Flags{NOTRANS, TRANSA} {
+                for _, unit := range []Flags{0, UNIT} {
+                    flags := side|uplo|tr|unit
+                    A := newTmatDiag(n)
+                    B := newTmat(n, n)
+                    B0 := B.copy()
+                    DTrmmBlk(B.d, A.d, 2.0, flags, B.ld, A.ld, n, 0, n, nb)
+                    for j := 0; j < n; j++ {
+                        for i := 0; i < n; i++ {
+                            var s float64
+                            for k := 0; k < n; k++ {
+                                if side == RIGHT {
+                                    s += B0.at(i, k)*A.trm(uplo == UPPER, unit != 0, k, j)
+                                } else {
+                                    s += A.trm(uplo == UPPER, unit != 0, i, k)*B0.at(k, j)
+                                }
+                            }
+                            if tr != 0 {
+                                s = 0.0
+                                for k := 0; k < n; k++ {
+                                    if side == RIGHT {
+                                        s += B0.at(i, k)*A.trm(uplo == UPPER, unit != 0, j, k)
+                                    } else {
+                                        s += A.trm(uplo == UPPER, unit != 0, k, i)*B0.at(k, j)
+                                    }
+                                }
+                            }
+                            if math.Abs(B.at(i, j) - 2.0*s) > 1e-12 {
+                                t.Errorf("DTrmmBlk flags=%x: (%d,%d) %e != %e\n",
+                                    flags, i, j, B.at(i, j), 2.0*s)
+                            }
+                        }
+                    }
+                    // solve back; B = 0.5*op(A).-1*B
+                    DSolveBlk(B.d, A.d, 0.5, flags, B.ld, A.ld, n, 0, n, nb)
+                    maxDiff(t, "DSolveBlk", B.d, B0.d)
+                }
+            }
+        }
+    }
+}
+
+// Unit diagonal upper TRMM with alpha != 1; the implicit diagonal term must be
+// scaled by alpha in both unblocked and blocked kernels.
+func TestKernelTrmmUnitUpper(t *testing.T) {
+    n := 13
+    alpha := -1.5
+    for _, nb := range []int{0, 4} {
+        A := newTmat(n, n)
+        B := newTmat(n, 3)
+        B0 := B.copy()
+        if nb == 0 {
+            DTrmmUnblk(B.d, A.d, alpha, LEFT|UPPER|UNIT, B.ld, A.ld, n, 0, 3, 0)
+        } else {
+            DTrmmBlk(B.d, A.d, alpha, LEFT|UPPER|UNIT, B.ld, A.ld, n, 0, 3, nb)
+        }
+        ref := B0.copy()
+        for j := 0; j < 3; j++ {
+            for i := 0; i < n; i++ {
+                s := B0.at(i, j)
+                for k := i+1; k < n; k++ {
+                    s += A.at(i, k)*B0.at(k, j)
+                }
+                ref.d[i + j*ref.ld] = alpha*s
+            }
+        }
+        maxDiff(t, "DTrmm LEFT|UPPER|UNIT", B.d, ref.d)
+    }
+}
+
+func TestKernelRank(t *testing.T) {
+    n, p := 19, 11
+    // NB 4 splits C to blocks with beta applied off the diagonal
+    for _, nb := range []int{0, 4} {
+        for _, flags := range []Flags{LOWER, UPPER, LOWER|TRANSA, UPPER|TRANSA} {
+            upper, ta := flags & UPPER != 0, flags & TRANSA != 0
+            A := newTmat(n, p)
+            B := newTmat(n, p)
+            if ta {
+                A = newTmat(p, n)
+                B = newTmat(p, n)
+            }
+            C := newTmat(n, n)
+            C0 := C.copy()
+            C1 := C.copy()
+            C2 := C.copy()
+            DSymmRankBlk(C.d, A.d, 1.5, 0.5, flags, C.ld, A.ld, p, 0, n, 0, nb)
+            DSymmRank2Blk(C1.d, A.d, B.d, 1.5, 0.5, flags, C1.ld, A.ld, B.ld, p, 0, n, 0, nb)
+            // C = 0.5*C + 1.5*A*B.T or C = 0.5*C + 1.5*A.T*B
+            tflags := flags
+            if ! ta {
+                tflags |= TRANSB
+            }
+            DTrmUpdBlk(C2.d, A.d, B.d, 1.5, 0.5, tflags, C2.ld, A.ld, B.ld, p, 0, n, 0, 0)
+            R0, R1, R2 := C0.copy(), C0.copy(), C0.copy()
+            for j := 0; j < n; j++ {
+                for i := 0; i < n; i++ {
+                    if (upper && i > j) || (! upper && i < j) {
+                        continue
+                    }
+                    var aa, ab, ba float64
+                    for k := 0; k < p; k++ {
+                        aa += A.op(! ta, k, i)*A.op(! ta, k, j)
+                        ab += A.op(! ta, k, i)*B.op(! ta, k, j)
+                        ba += B.op(! ta, k, i)*A.op(! ta, k, j)
+                    }
+                    R0.d[i + j*C0.ld] = 0.5*C0.at(i, j) + 1.5*aa
+                    R1.d[i + j*C0.ld] = 0.5*C0.at(i, j) + 1.5*(ab + ba)
+                    R2.d[i + j*C0.ld] = 0.5*C0.at(i, j) + 1.5*ab
+                }
+            }
+            maxDiff(t, "DSymmRankBlk", C.d, R0.d)
+            maxDiff(t, "DSymmRank2Blk", C1.d, R1.d)
+            maxDiff(t, "DTrmUpdBlk", C2.d, R2.d)
+        }
+    }
+}
+
+func TestKernelMV(t *testing.T) {
+    m, n := 23, 17
+    for _, trans := range []Flags{NOTRANS, TRANSA} {
+        ta := trans != 0
+        A := newTmat(m, n)
+        ny, nx := m, n
+        if ta {
+            A = newTmat(n, m)
+        }
+        // X with stride 2 and Y with stride 4, strided X with contiguous Y (even
+        // leading index of A selects aligned transposed kernel), then both contiguous
+        for _, inc := range [][2]int{{2*4, 4}, {2, 1}, {1, 1}} {
+            incX, incY := inc[0], inc[1]
+            // MB 5 runs several column blocks over same elements of Y
+            for _, mb := range []int{0, 5} {
+                X := newTmat(nx*incX, 1)
+                Y := newTmat(ny*incY, 1)
+                Y0 := Y.copy()
+                // rows of Y in two parts as parallel tasks do; second starts at R > 0
+                DMultMV(Y.d, A.d, X.d, 2.0, -1.0, trans, incY, A.ld, incX, 0, nx, 0, ny/2, 0, mb)
+                DMultMV(Y.d, A.d, X.d, 2.0, -1.0, trans, incY, A.ld, incX, 0, nx, ny/2, ny, 0, mb)
+                for i := 0; i < ny; i++ {
+                    var s float64
+                    for j := 0; j < nx; j++ {
+                        s += A.op(ta, i, j)*X.d[j*incX]
+                    }
+                    Y0.d[i*incY] = 2.0*s - Y0.d[i*incY]
+                }
+                maxDiff(t, "DMultMV", Y.d, Y0.d)
+            }
+        }
+    }
+
+    A := newTmat(m, n)
+    A0 := A.copy()
+    X := newTmat(m, 1)
+    Y := newTmat(n, 1)
+    DRankMV(A.d, X.d, Y.d, 0.5, A.ld, 1, 1, 0, n, 0, m, 0, 0)
+    for j := 0; j < n; j++ {
+        for i := 0; i < m; i++ {
+            A0.d[i + j*A0.ld] += 0.5*X.d[i]*Y.d[j]
+        }
+    }
+    maxDiff(t, "DRankMV", A.d, A0.d)
+
+    for _, uplo := range []Flags{LOWER, UPPER} {
+        S := newTmat(n, n)
+        S0, S1 := S.copy(), S.copy()
+        R0, R1 := S.copy(), S.copy()
+        DSymmRankMV(S0.d, Y.d, 0.5, uplo, S.ld, 1, 0, n, 0)
+        DSymmRank2MV(S1.d, Y.d, X.d, 0.5, uplo, S.ld, 1, 1, 0, n, 0)
+        for j := 0; j < n; j++ {
+            for i := 0; i < n; i++ {
+                if (uplo == UPPER && i > j) || (uplo == LOWER && i < j) {
+                    continue
+                }
+                R0.d[i + j*S.ld] += 0.5*Y.d[i]*Y.d[j]
+                R1.d[i + j*S.ld] += 0.5*(Y.d[i]*X.d[j] + X.d[i]*Y.d[j])
+            }
+        }
+        maxDiff(t, "DSymmRankMV", S0.d, R0.d)
+        maxDiff(t, "DSymmRank2MV", S1.d, R1.d)
+
+        for _, flags := range []Flags{uplo, uplo|UNIT, uplo|TRANSA} {
+            T := newTmatDiag(n)
+            Z := Y.copy()
+            DTrimvUnblkMV(Z.d, T.d, flags, 1, T.ld, n)
+            R := Y.copy()
+            for i := 0; i < n; i++ {
+                var s float64
+                for k := 0; k < n; k++ {
+                    if flags & TRANSA != 0 {
+                        s += T.trm(uplo == UPPER, flags & UNIT != 0, k, i)*Y.d[k]
+                    } else {
+                        s += T.trm(uplo == UPPER, flags & UNIT != 0, i, k)*Y.d[k]
+                    }
+                }
+                R.d[i] = s
+            }
+            maxDiff(t, "DTrimvUnblkMV", Z.d, R.d)
+            if flags & TRANSA == 0 {
+                DSolveBlkMV(Z.d, T.d, flags, 1, T.ld, n, 8)
+                maxDiff(t, "DSolveBlkMV", Z.d, Y.d)
+            }
+        }
+    }
+}
+
+func TestKernelVectors(t *testing.T) {
+    n := 13
+    X := newTmat(n, 1)
+    Y := newTmat(2*n, 1)
+    var dot, nrm, diff, asum float64
+    imax := 0
+    for i := 0; i < n; i++ {
+        dot += X.d[i]*Y.d[2*i]
+        nrm += X.d[i]*X.d[i]
+        diff += (X.d[i] - Y.d[2*i])*(X.d[i] - Y.d[2*i])
+        asum += math.Abs(X.d[i])
+        if math.Abs(X.d[i]) > math.Abs(X.d[imax]) {
+            imax = i
+        }
+    }
+    Z := []float64{1.0}
+    DDotSum(Z, X.d, Y.d, 2.0, 3.0, 1, 1, 2, n)
+    maxDiff(t, "DDot", []float64{DDot(X.d, Y.d, 2.0, 1, 2, n), Z[0]},
+        []float64{2.0*dot, 3.0 + 2.0*dot})
+    maxDiff(t, "DNorm2", []float64{DNorm2(X.d, 1, n), DiffNorm2(X.d, Y.d, 1, 2, n), DAsum(X.d, 1, n)},
+        []float64{math.Sqrt(nrm), math.Sqrt(diff), asum})
+    if ix := DIAMax(X.d, 1, n); ix != imax {
+        t.Errorf("DIAMax: %d != %d\n", ix, imax)
+    }
+
+    X0, Y0 := X.copy(), Y.copy()
+    DAxpy(Y.d, X.d, 2.0, 1, 2, n)
+    DScal(X.d, 4.0, 1, n)
+    DInvScal(X.d, 2.0, 1, n)
+    for i := 0; i < n; i++ {
+        Y0.d[2*i] += 2.0*X0.d[i]
+        X0.d[i] *= 2.0
+    }
+    maxDiff(t, "DAxpy", Y.d, Y0.d)
+    maxDiff(t, "DScal", X.d, X0.d)
+    DSwap(X.d, Y.d, 1, 2, n)
+    for i := 0; i < n; i++ {
+        X0.d[i], Y0.d[2*i] = Y0.d[2*i], X0.d[i]
+    }
+    maxDiff(t, "DSwap", X.d, X0.d)
+    maxDiff(t, "DSwap", Y.d, Y0.d)
+    W := make([]float64, 2*n)
+    DCopy(W, X.d, 2, 1, n)
+    for i := 0; i < n; i++ {
+        X0.d[i] = W[2*i]
+    }
+    maxDiff(t, "DCopy", X.d, X0.d)
+}
+
+// Run kernel tests with every kernel set available in this build and CPU.
+func TestKernelSets(t *testing.T) {
+    current := Kernels()
+    defer SetKernels(current)
+    tests := map[string]func(*testing.T){
+        "Mult": TestKernelMult,
+        "MultSymm": TestKernelMultSymm,
+        "TrmmSolve": TestKernelTrmmSolve,
+        "Rank": TestKernelRank,
+        "MV": TestKernelMV,
+    }
+    for _, kernels := range []string{"go", "sse", "avx2"} {
+        if SetKernels(kernels) != nil {
+            continue
+        }
+        for name, test := range tests {
+            t.Run(kernels + "/" + name, test)
+        }
+    }
+}
+
+// Local Variables:
+// tab-width: 4
+// indent-tabs-mode: nil
+// End:
@@ -1,3 +1,4 @@
+//go:build cgo && amd64 && !purego
 
 
 package calgo
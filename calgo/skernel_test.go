@@ -0,0 +1,154 @@
+// Copyright (c) Harri Rautila, 2012,2013
+
+// This file is part of github.com/hrautila/matops package. It is free software,
+// distributed under the terms of GNU Lesser General Public License Version 3, or
+// any later version. See the COPYING tile included in this archive.
+
+
+package calgo
+
+// Single precision kernels against the double precision kernels on same data.
+
+import (
+    "testing"
+    "math"
+)
+
+// single precision copy of m; elements of m rounded to single precision
+func (m *tmat) single() []float32 {
+    s := make([]float32, len(m.d))
+    for k, v := range m.d {
+        s[k] = float32(v)
+        m.d[k] = float64(s[k])
+    }
+    return s
+}
+
+func maxDiff32(t *testing.T, name string, x []float32, y []float64) {
+    var d float64
+    for k := range y {
+        d = math.Max(d, math.Abs(float64(x[k]) - y[k]))
+    }
+    if d > 1e-4 {
+        t.Errorf("%s: max difference %e\n", name, d)
+    }
+}
+
+func TestKernelSingleMult(t *testing.T) {
+    m, n, p := 23, 17, 29
+    for _, trans := range []Flags{NOTRANS, TRANSA, TRANSB, TRANSA|TRANSB} {
+        A := newTmat(m, p)
+        if trans & TRANSA != 0 {
+            A = newTmat(p, m)
+        }
+        B := newTmat(p, n)
+        if trans & TRANSB != 0 {
+            B = newTmat(n, p)
+        }
+        C := newTmat(m, n)
+        As, Bs, Cs := A.single(), B.single(), C.single()
+        S, L, R, E := 2, n-3, 1, m-4
+        DMult(C.d, A.d, B.d, 2.0, 0.5, trans, C.ld, A.ld, B.ld, p, S, L, R, E, 0, 0, 0)
+        // panel length smaller than P
+        SMult(Cs, As, Bs, 2.0, 0.5, trans, C.ld, A.ld, B.ld, p, S, L, R, E, 8, 0, 0)
+        maxDiff32(t, "SMult", Cs, C.d)
+    }
+
+    for _, flags := range []Flags{LOWER, UPPER, LOWER|TRANSA, UPPER|TRANSA} {
+        A := newTmat(m, p)
+        if flags & TRANSA != 0 {
+            A = newTmat(p, m)
+        }
+        C := newTmat(m, m)
+        As, Cs := A.single(), C.single()
+        DSymmRankBlk(C.d, A.d, 1.5, 0.5, flags, C.ld, A.ld, p, 0, m, 0, 0)
+        SSymmRankBlk(Cs, As, 1.5, 0.5, flags, C.ld, A.ld, p, 0, m, 0, 0)
+        maxDiff32(t, "SSymmRankBlk", Cs, C.d)
+    }
+}
+
+func TestKernelSingleSolve(t *testing.T) {
+    n, nb := 21, 16
+    for _, side := range []Flags{LEFT, RIGHT} {
+        for _, uplo := range []Flags{LOWER, UPPER} {
+            for _, tr := range []Flags{NOTRANS, TRANSA} {
+                for _, unit := range []Flags{0, UNIT} {
+                    flags := side|uplo|tr|unit
+                    A := newTmatDiag(n)
+                    B := newTmat(n, n-2)
+                    if side == RIGHT {
+                        B = newTmat(n-2, n)
+                    }
+                    As, Bs := A.single(), B.single()
+                    DSolveBlk(B.d, A.d, 2.0, flags, B.ld, A.ld, n, 1, n-3, nb)
+                    SSolveBlk(Bs, As, 2.0, flags, B.ld, A.ld, n, 1, n-3, nb)
+                    maxDiff32(t, "SSolveBlk", Bs, B.d)
+                }
+            }
+        }
+    }
+}
+
+func TestKernelSingleMV(t *testing.T) {
+    m, n := 23, 17
+    for _, trans := range []Flags{NOTRANS, TRANSA} {
+        A := newTmat(m, n)
+        ny, nx := m, n
+        if trans != 0 {
+            A = newTmat(n, m)
+        }
+        for _, inc := range [][2]int{{2, 3}, {1, 1}} {
+            incX, incY := inc[0], inc[1]
+            X := newTmat(nx*incX, 1)
+            Y := newTmat(ny*incY, 1)
+            As, Xs, Ys := A.single(), X.single(), Y.single()
+            DMultMV(Y.d, A.d, X.d, 2.0, -1.0, trans, incY, A.ld, incX, 1, nx, 0, ny-2, 0, 0)
+            SMultMV(Ys, As, Xs, 2.0, -1.0, trans, incY, A.ld, incX, 1, nx, 0, ny-2, 0, 0)
+            maxDiff32(t, "SMultMV", Ys, Y.d)
+        }
+    }
+
+    A := newTmat(m, n)
+    X := newTmat(2*m, 1)
+    Y := newTmat(n, 1)
+    As, Xs, Ys := A.single(), X.single(), Y.single()
+    DRankMV(A.d, X.d, Y.d, 0.5, A.ld, 2, 1, 1, n, 2, m, 0, 0)
+    SRankMV(As, Xs, Ys, 0.5, A.ld, 2, 1, 1, n, 2, m, 0, 0)
+    maxDiff32(t, "SRankMV", As, A.d)
+
+    for _, uplo := range []Flags{LOWER, UPPER} {
+        S := newTmat(n, n)
+        Ss := S.single()
+        DSymmRankMV(S.d, X.d, 0.5, uplo, S.ld, 2, 0, n, 0)
+        SSymmRankMV(Ss, Xs, 0.5, uplo, S.ld, 2, 0, n, 0)
+        maxDiff32(t, "SSymmRankMV", Ss, S.d)
+    }
+}
+
+func TestKernelSingleVectors(t *testing.T) {
+    n := 13
+    X := newTmat(n, 1)
+    Y := newTmat(2*n, 1)
+    Xs, Ys := X.single(), Y.single()
+    maxDiff32(t, "SDot", []float32{SDot(Xs, Ys, 2.0, 1, 2, n), SNorm2(Ys, 2, n)},
+        []float64{DDot(X.d, Y.d, 2.0, 1, 2, n), DNorm2(Y.d, 2, n)})
+    if ix, iy := SIAMax(Ys, 2, n), DIAMax(Y.d, 2, n); ix != iy {
+        t.Errorf("SIAMax: %d != %d\n", ix, iy)
+    }
+    DAxpy(Y.d, X.d, 2.0, 1, 2, n)
+    SAxpy(Ys, Xs, 2.0, 1, 2, n)
+    maxDiff32(t, "SAxpy", Ys, Y.d)
+    DScal(X.d, 4.0, 1, n)
+    SScal(Xs, 4.0, 1, n)
+    DInvScal(Y.d, 2.0, 2, n)
+    SInvScal(Ys, 2.0, 2, n)
+    DSwap(X.d, Y.d, 1, 2, n)
+    SSwap(Xs, Ys, 1, 2, n)
+    maxDiff32(t, "SScal", Xs, X.d)
+    maxDiff32(t, "SInvScal", Ys, Y.d)
+}
+
+// Local Variables:
+// tab-width: 4
+// indent-tabs-mode: nil
+// End:
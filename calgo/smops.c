@@ -0,0 +1,431 @@
+//go:build cgo && amd64 && !purego
+
+// Copyright (c) Harri Rautila, 2012,2013
+
+// This file is part of github.com/hrautila/matops package. It is free software,
+// distributed under the terms of GNU Lesser General Public License Version 3, or
+// any later version. See the COPYING tile included in this archive.
+
+// Single precision kernels. Computation is organized to unit stride DOT and AXPY
+// loops over columns that the compiler vectorizes.
+
+#include <math.h>
+#include "cmops.h"
+
+// max panel length for copied rows of B
+#define MAX_VP_SROW 512
+
+// return: X.Y; unit stride
+static inline float _sdot(const float *X, const float *Y, int N)
+{
+  register int i;
+  register float c0 = 0.0;
+  for (i = 0; i < N; i++) {
+    c0 += X[i] * Y[i];
+  }
+  return c0;
+}
+
+// Y = Y + alpha*X; unit stride
+static inline void _saxpy(float *Y, const float *X, float alpha, int N)
+{
+  register int i;
+  for (i = 0; i < N; i++) {
+    Y[i] += alpha * X[i];
+  }
+}
+
+// X = f0*X; zero if f0 is zero
+static void _sscale(float *X, int incX, float f0, int N)
+{
+  register int i;
+  if (f0 == 1.0) {
+    return;
+  }
+  for (i = 0; i < N; i++) {
+    X[i*incX] = f0 == 0.0 ? 0.0 : f0 * X[i*incX];
+  }
+}
+
+// return: alpha * X * Y
+float svec_dot(const smvec_t *X,  const smvec_t *Y, float alpha, int N)
+{
+  register int i;
+  register float c0;
+
+  if (X->inc == 1 && Y->inc == 1) {
+    return alpha * _sdot(X->md, Y->md, N);
+  }
+  c0 = 0.0;
+  for (i = 0; i < N; i++) {
+    c0 += X->md[i*X->inc] * Y->md[i*Y->inc];
+  }
+  return alpha * c0;
+}
+
+// Y := alpha*X + Y
+void svec_axpy(smvec_t *Y,  const smvec_t *X, float alpha, int N)
+{
+  register int i;
+
+  if (X->inc == 1 && Y->inc == 1) {
+    _saxpy(Y->md, X->md, alpha, N);
+    return;
+  }
+  for (i = 0; i < N; i++) {
+    Y->md[i*Y->inc] += alpha * X->md[i*X->inc];
+  }
+}
+
+// return: sqrt(sum X[i]^2); sum computed in double precision
+float svec_nrm2(const smvec_t *X,  int N)
+{
+  register int i;
+  register double c0, x;
+
+  c0 = 0.0;
+  for (i = 0; i < N; i++) {
+    x = X->md[i*X->inc];
+    c0 += x * x;
+  }
+  return (float)sqrt(c0);
+}
+
+// return: index of max absolute value
+int svec_iamax(const smvec_t *X,  int N)
+{
+  register int i, ix;
+  register float max, a;
+
+  if (N <= 0) {
+    return -1;
+  }
+  ix = 0;
+  max = fabsf(X->md[0]);
+  for (i = 1; i < N; i++) {
+    a = fabsf(X->md[i*X->inc]);
+    if (a > max) {
+      max = a;
+      ix = i;
+    }
+  }
+  return ix;
+}
+
+void svec_swap(smvec_t *X,  smvec_t *Y, int N)
+{
+  register int i;
+  register float t;
+
+  for (i = 0; i < N; i++) {
+    t = X->md[i*X->inc];
+    X->md[i*X->inc] = Y->md[i*Y->inc];
+    Y->md[i*Y->inc] = t;
+  }
+}
+
+// X = alpha*X
+void svec_scal(smvec_t *X,  float alpha, int N)
+{
+  _sscale(X->md, X->inc, alpha, N);
+}
+
+// X = X/alpha
+void svec_invscal(smvec_t *X,  float alpha, int N)
+{
+  register int i;
+  for (i = 0; i < N; i++) {
+    X->md[i*X->inc] /= alpha;
+  }
+}
+
+// C[R:E,S:L] += alpha*op(A)*op(B); inner dimension divided to blocks of vlen
+static void
+_smult_mm_intern(smdata_t *C, const smdata_t *A, const smdata_t *B,
+                 float alpha, int flags, int P, int S, int L, int R, int E, int vlen)
+{
+  register int i, j, k, kb, nK;
+  float Brow[MAX_VP_SROW];
+  float *Cc;
+  const float *Ad = A->md, *Bd = B->md;
+  int ldA = A->step, ldB = B->step;
+
+  if (vlen <= 0 || vlen > MAX_VP_SROW) {
+    vlen = MAX_VP_SROW;
+  }
+  for (kb = 0; kb < P; kb += vlen) {
+    nK = P - kb < vlen ? P - kb : vlen;
+    for (j = S; j < L; j++) {
+      Cc = &C->md[j*C->step];
+      switch (flags & (MTX_TRANSA|MTX_TRANSB)) {
+      case MTX_TRANSA|MTX_TRANSB:
+        // row j of B to contiguous buffer
+        for (k = 0; k < nK; k++) {
+          Brow[k] = Bd[j + (kb+k)*ldB];
+        }
+        for (i = R; i < E; i++) {
+          Cc[i] += alpha * _sdot(&Ad[kb + i*ldA], Brow, nK);
+        }
+        break;
+      case MTX_TRANSA:
+        for (i = R; i < E; i++) {
+          Cc[i] += alpha * _sdot(&Ad[kb + i*ldA], &Bd[kb + j*ldB], nK);
+        }
+        break;
+      case MTX_TRANSB:
+        for (k = kb; k < kb+nK; k++) {
+          _saxpy(&Cc[R], &Ad[R + k*ldA], alpha * Bd[j + k*ldB], E-R);
+        }
+        break;
+      default:
+        for (k = kb; k < kb+nK; k++) {
+          _saxpy(&Cc[R], &Ad[R + k*ldA], alpha * Bd[k + j*ldB], E-R);
+        }
+        break;
+      }
+    }
+  }
+}
+
+// C[R:E,S:L] = beta*C[R:E,S:L] + alpha*op(A)*op(B); P is the inner dimension.
+void
+smult_mm_blocked(smdata_t *C, const smdata_t *A, const smdata_t *B,
+                 float alpha, float beta, int flags,
+                 int P, int S, int L, int R, int E, int vlen)
+{
+  register int j;
+
+  if (L - S <= 0 || E - R <= 0) {
+    return;
+  }
+  for (j = S; j < L; j++) {
+    _sscale(&C->md[R + j*C->step], 1, beta, E-R);
+  }
+  if (alpha == 0.0) {
+    return;
+  }
+  _smult_mm_intern(C, A, B, alpha, flags, P, S, L, R, E, vlen);
+}
+
+// C = beta*C + alpha*A*A.T or C = beta*C + alpha*A.T*A if flags&TRANSA;
+// only UPPER or LOWER triangle of C[S:E,S:E] is updated.
+void
+smmat_rank(smdata_t *C, const smdata_t *A, float alpha, float beta,
+           int flags,  int P, int S, int E, int vlen)
+{
+  register int j, R, E1;
+  int trans = flags & MTX_TRANSA ? MTX_TRANSA : MTX_TRANSB;
+
+  for (j = S; j < E; j++) {
+    R  = flags & MTX_UPPER ? S : j;
+    E1 = flags & MTX_UPPER ? j+1 : E;
+    _sscale(&C->md[R + j*C->step], 1, beta, E1-R);
+    if (alpha != 0.0) {
+      _smult_mm_intern(C, A, A, alpha, trans, P, j, j+1, R, E1, vlen);
+    }
+  }
+}
+
+// Y[R:E] = beta*Y[R:E] + alpha*op(A)*X[S:L]
+void
+smult_gemv(smvec_t *Y, const smdata_t *A, const smvec_t *X,
+           float alpha, float beta, int flags, int S, int L, int R, int E)
+{
+  register int i, j, k;
+  register float c0;
+  const float *Ad = A->md;
+  int ldA = A->step;
+
+  if (L - S <= 0 || E - R <= 0) {
+    return;
+  }
+  _sscale(&Y->md[R*Y->inc], Y->inc, beta, E-R);
+  if (flags & MTX_TRANSA) {
+    for (i = R; i < E; i++) {
+      if (X->inc == 1) {
+        c0 = _sdot(&Ad[S + i*ldA], &X->md[S], L-S);
+      } else {
+        c0 = 0.0;
+        for (k = S; k < L; k++) {
+          c0 += Ad[k + i*ldA] * X->md[k*X->inc];
+        }
+      }
+      Y->md[i*Y->inc] += alpha * c0;
+    }
+    return;
+  }
+  for (j = S; j < L; j++) {
+    c0 = alpha * X->md[j*X->inc];
+    if (Y->inc == 1) {
+      _saxpy(&Y->md[R], &Ad[R + j*ldA], c0, E-R);
+    } else {
+      for (i = R; i < E; i++) {
+        Y->md[i*Y->inc] += Ad[i + j*ldA] * c0;
+      }
+    }
+  }
+}
+
+// A[R:E,S:L] = A[R:E,S:L] + alpha*X[R:E]*Y[S:L].T
+void
+smvec_rank(smdata_t *A, const smvec_t *X, const smvec_t *Y, float alpha,
+           int S, int L, int R, int E)
+{
+  register int i, j;
+  register float c0;
+  float *Ac;
+
+  for (j = S; j < L; j++) {
+    Ac = &A->md[j*A->step];
+    c0 = alpha * Y->md[j*Y->inc];
+    if (X->inc == 1) {
+      _saxpy(&Ac[R], &X->md[R], c0, E-R);
+    } else {
+      for (i = R; i < E; i++) {
+        Ac[i] += X->md[i*X->inc] * c0;
+      }
+    }
+  }
+}
+
+// A = A + alpha*X*X.T; UPPER or LOWER triangle of A
+void
+smvec_symv_rank(smdata_t *A, const smvec_t *X,  float alpha, int flags, int S, int L)
+{
+  register int i, j, R, E;
+  register float c0;
+  float *Ac;
+
+  for (j = S; j < L; j++) {
+    R = flags & MTX_UPPER ? 0 : j;
+    E = flags & MTX_UPPER ? j+1 : L;
+    Ac = &A->md[j*A->step];
+    c0 = alpha * X->md[j*X->inc];
+    if (X->inc == 1) {
+      _saxpy(&Ac[R], &X->md[R], c0, E-R);
+    } else {
+      for (i = R; i < E; i++) {
+        Ac[i] += X->md[i*X->inc] * c0;
+      }
+    }
+  }
+}
+
+// X = op(A).-1*X; X is unit stride column, A is N*N triangular
+static void
+_ssolve_vec(float *X, const smdata_t *A, int flags, int N)
+{
+  register int k;
+  const float *Ad = A->md;
+  int ldA = A->step;
+  int unit = flags & MTX_UNIT;
+
+  switch (flags & (MTX_UPPER|MTX_TRANSA)) {
+  case MTX_UPPER:
+    for (k = N-1; k >= 0; k--) {
+      if (! unit)
+        X[k] /= Ad[k + k*ldA];
+      _saxpy(X, &Ad[k*ldA], -X[k], k);
+    }
+    break;
+  case MTX_UPPER|MTX_TRANSA:
+    for (k = 0; k < N; k++) {
+      X[k] -= _sdot(&Ad[k*ldA], X, k);
+      if (! unit)
+        X[k] /= Ad[k + k*ldA];
+    }
+    break;
+  case MTX_TRANSA:
+    for (k = N-1; k >= 0; k--) {
+      X[k] -= _sdot(&Ad[k+1 + k*ldA], &X[k+1], N-k-1);
+      if (! unit)
+        X[k] /= Ad[k + k*ldA];
+    }
+    break;
+  default:
+    for (k = 0; k < N; k++) {
+      if (! unit)
+        X[k] /= Ad[k + k*ldA];
+      _saxpy(&X[k+1], &Ad[k+1 + k*ldA], -X[k], N-k-1);
+    }
+    break;
+  }
+}
+
+// X = X*op(A).-1 for rows [S:E] of X; columns of X are updated with AXPY
+static void
+_ssolve_right(smdata_t *B, const smdata_t *A, int flags, int N, int S, int E)
+{
+  register int j, k;
+  float *Bd = &B->md[S];
+  const float *Ad = A->md;
+  int ldA = A->step, ldB = B->step, nR = E-S;
+  int unit = flags & MTX_UNIT;
+
+  switch (flags & (MTX_UPPER|MTX_TRANSA)) {
+  case MTX_UPPER:
+    // X[:,j] = (B[:,j] - sum(k<j) X[:,k]*A[k,j])/A[j,j]
+    for (j = 0; j < N; j++) {
+      for (k = 0; k < j; k++) {
+        _saxpy(&Bd[j*ldB], &Bd[k*ldB], -Ad[k + j*ldA], nR);
+      }
+      if (! unit)
+        _sscale(&Bd[j*ldB], 1, 1.0/Ad[j + j*ldA], nR);
+    }
+    break;
+  case MTX_UPPER|MTX_TRANSA:
+    // X[:,j] = (B[:,j] - sum(k>j) X[:,k]*A[j,k])/A[j,j]
+    for (j = N-1; j >= 0; j--) {
+      for (k = j+1; k < N; k++) {
+        _saxpy(&Bd[j*ldB], &Bd[k*ldB], -Ad[j + k*ldA], nR);
+      }
+      if (! unit)
+        _sscale(&Bd[j*ldB], 1, 1.0/Ad[j + j*ldA], nR);
+    }
+    break;
+  case MTX_TRANSA:
+    // X[:,j] = (B[:,j] - sum(k<j) X[:,k]*A[j,k])/A[j,j]
+    for (j = 0; j < N; j++) {
+      for (k = 0; k < j; k++) {
+        _saxpy(&Bd[j*ldB], &Bd[k*ldB], -Ad[j + k*ldA], nR);
+      }
+      if (! unit)
+        _sscale(&Bd[j*ldB], 1, 1.0/Ad[j + j*ldA], nR);
+    }
+    break;
+  default:
+    // X[:,j] = (B[:,j] - sum(k>j) X[:,k]*A[k,j])/A[j,j]
+    for (j = N-1; j >= 0; j--) {
+      for (k = j+1; k < N; k++) {
+        _saxpy(&Bd[j*ldB], &Bd[k*ldB], -Ad[k + j*ldA], nR);
+      }
+      if (! unit)
+        _sscale(&Bd[j*ldB], 1, 1.0/Ad[j + j*ldA], nR);
+    }
+    break;
+  }
+}
+
+// B = alpha*op(A).-1*B for columns [S:E] of B if flags&LEFT or
+// B = alpha*B*op(A).-1 for rows [S:E] of B if flags&RIGHT; A is N*N
+void
+smmat_solve(smdata_t *B, const smdata_t *A, float alpha, int flags, int N, int S, int E)
+{
+  register int j;
+
+  if (flags & MTX_RIGHT) {
+    for (j = 0; j < N; j++) {
+      _sscale(&B->md[S + j*B->step], 1, alpha, E-S);
+    }
+    _ssolve_right(B, A, flags, N, S, E);
+    return;
+  }
+  for (j = S; j < E; j++) {
+    _sscale(&B->md[j*B->step], 1, alpha, N);
+    _ssolve_vec(&B->md[j*B->step], A, flags, N);
+  }
+}
+
+// Local Variables:
+// indent-tabs-mode: nil
+// End:
@@ -0,0 +1,181 @@
+//go:build cgo && amd64 && !purego
+
+// Copyright (c) Harri Rautila, 2012,2013
+
+// This file is part of github.com/hrautila/matops package. It is free software,
+// distributed under the terms of GNU Lesser General Public License Version 3, or
+// any later version. See the COPYING tile included in this archive.
+
+
+package calgo
+
+// #include "cmops.h"
+import "C"
+import "unsafe"
+
+// Single precision kernels. Arguments are as in the corresponding double precision
+// functions; blocking parameters NB and MB are not used.
+
+func smatrix(A []float32, ldA int) C.smdata_t {
+    var Am C.smdata_t
+    Am.md = (*C.float)(unsafe.Pointer(&A[0]))
+    Am.step = C.int(ldA)
+    return Am
+}
+
+func svector(X []float32, incX int) C.smvec_t {
+    var Xv C.smvec_t
+    Xv.md = (*C.float)(unsafe.Pointer(&X[0]))
+    Xv.inc = C.int(incX)
+    return Xv
+}
+
+// blas SGEMM; see DMult().
+func SMult(C, A, B []float32, alpha, beta float32, trans Flags, ldC, ldA, ldB, P, S, L, R, E, H, NB, MB int) {
+
+    if C == nil || B == nil || A == nil {
+        return
+    }
+    Cm := smatrix(C, ldC)
+    Am := smatrix(A, ldA)
+    Bm := smatrix(B, ldB)
+    C.smult_mm_blocked(&Cm, &Am, &Bm,
+        C.float(alpha), C.float(beta), C.int(trans),
+        C.int(P), C.int(S), C.int(L), C.int(R), C.int(E), C.int(H))
+}
+
+// blas STRSM; see DSolveBlk().
+func SSolveBlk(B, A []float32, alpha float32, flags Flags, ldB, ldA, N, S, E, NB int) {
+
+    if B == nil || A == nil || N == 0 {
+        return
+    }
+    Bm := smatrix(B, ldB)
+    Am := smatrix(A, ldA)
+    C.smmat_solve(&Bm, &Am, C.float(alpha), C.int(flags), C.int(N), C.int(S), C.int(E))
+}
+
+// blas SSYRK; see DSymmRankBlk().
+func SSymmRankBlk(C, A []float32, alpha, beta float32, flags Flags, ldC, ldA, N, S, E, H, NB int) {
+
+    if C == nil || A == nil || E - S <= 0 {
+        return
+    }
+    Cm := smatrix(C, ldC)
+    Am := smatrix(A, ldA)
+    C.smmat_rank(&Cm, &Am, C.float(alpha), C.float(beta),
+        C.int(flags), C.int(N), C.int(S), C.int(E), C.int(H))
+}
+
+// blas SGEMV; see DMultMV().
+func SMultMV(Y, A, X []float32, alpha, beta float32, flags Flags, incY, ldA, incX, S, L, R, E, H, MB int) {
+
+    if Y == nil || A == nil || X == nil {
+        return
+    }
+    Yv := svector(Y, incY)
+    Am := smatrix(A, ldA)
+    Xv := svector(X, incX)
+    C.smult_gemv(&Yv, &Am, &Xv, C.float(alpha), C.float(beta), C.int(flags),
+        C.int(S), C.int(L), C.int(R), C.int(E))
+}
+
+// blas SGER; see DRankMV().
+func SRankMV(A, X, Y []float32, alpha float32, ldA, incX, incY, S, L, R, E, NB, MB int) {
+
+    if A == nil || X == nil || Y == nil {
+        return
+    }
+    Am := smatrix(A, ldA)
+    Xv := svector(X, incX)
+    Yv := svector(Y, incY)
+    C.smvec_rank(&Am, &Xv, &Yv, C.float(alpha), C.int(S), C.int(L), C.int(R), C.int(E))
+}
+
+// blas SSYR; see DSymmRankMV().
+func SSymmRankMV(A, X []float32, alpha float32, flags Flags, ldA, incX, S, L, NB int) {
+
+    if A == nil || X == nil {
+        return
+    }
+    Am := smatrix(A, ldA)
+    Xv := svector(X, incX)
+    C.smvec_symv_rank(&Am, &Xv, C.float(alpha), C.int(flags), C.int(S), C.int(L))
+}
+
+// return: alpha * X * Y
+func SDot(X, Y []float32, alpha float32, incX, incY, N int) float32 {
+
+    if X == nil || Y == nil || N <= 0 {
+        return 0.0
+    }
+    Xv := svector(X, incX)
+    Yv := svector(Y, incY)
+    return float32(C.svec_dot(&Xv, &Yv, C.float(alpha), C.int(N)))
+}
+
+// Y := alpha*X + Y
+func SAxpy(Y, X []float32, alpha float32, incX, incY, N int) {
+
+    if X == nil || Y == nil || N <= 0 {
+        return
+    }
+    Xv := svector(X, incX)
+    Yv := svector(Y, incY)
+    C.svec_axpy(&Yv, &Xv, C.float(alpha), C.int(N))
+}
+
+// return: Euclidean norm of X; squares are summed in double precision
+func SNorm2(X []float32, incX, N int) float32 {
+
+    if X == nil || N <= 0 {
+        return 0.0
+    }
+    Xv := svector(X, incX)
+    return float32(C.svec_nrm2(&Xv, C.int(N)))
+}
+
+// return: index of max absolute value
+func SIAMax(X []float32, incX, N int) int {
+
+    if X == nil || N <= 0 {
+        return -1
+    }
+    Xv := svector(X, incX)
+    return int(C.svec_iamax(&Xv, C.int(N)))
+}
+
+func SSwap(X, Y []float32, incX, incY, N int) {
+
+    if X == nil || Y == nil || N <= 0 {
+        return
+    }
+    Xv := svector(X, incX)
+    Yv := svector(Y, incY)
+    C.svec_swap(&Xv, &Yv, C.int(N))
+}
+
+// inverse scaling: X = X/alpha
+func SInvScal(X []float32, alpha float32, incX, N int) {
+
+    if X == nil || N <= 0 {
+        return
+    }
+    Xv := svector(X, incX)
+    C.svec_invscal(&Xv, C.float(alpha), C.int(N))
+}
+
+// scaling: X = alpha*X
+func SScal(X []float32, alpha float32, incX, N int) {
+
+    if X == nil || N <= 0 {
+        return
+    }
+    Xv := svector(X, incX)
+    C.svec_scal(&Xv, C.float(alpha), C.int(N))
+}
+
+// Local Variables:
+// tab-width: 4
+// indent-tabs-mode: nil
+// End:
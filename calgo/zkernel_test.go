@@ -0,0 +1,268 @@
+// Copyright (c) Harri Rautila, 2012,2013
+
+// This file is part of github.com/hrautila/matops package. It is free software,
+// distributed under the terms of GNU Lesser General Public License Version 3, or
+// any later version. See the COPYING tile included in this archive.
+
+
+package calgo
+
+// Complex kernels against straightforward reference computations.
+
+import (
+    "testing"
+    "math/cmplx"
+    "math/rand"
+)
+
+type ztmat struct {
+    r, c, ld int
+    d []complex128
+}
+
+func newZTmat(r, c int) *ztmat {
+    m := &ztmat{r, c, r+3, make([]complex128, (r+3)*c)}
+    for k := range m.d {
+        m.d[k] = complex(rand.Float64() - 0.5, rand.Float64() - 0.5)
+    }
+    return m
+}
+
+func (m *ztmat) at(i, j int) complex128 {
+    return m.d[i + j*m.ld]
+}
+
+func (m *ztmat) copy() *ztmat {
+    c := *m
+    c.d = append([]complex128(nil), m.d...)
+    return &c
+}
+
+// op(m)(i,j); op is conjugate transpose if trans
+func (m *ztmat) op(trans bool, i, j int) complex128 {
+    if trans {
+        return cmplx.Conj(m.at(j, i))
+    }
+    return m.at(i, j)
+}
+
+// rows [R:E] of column j within triangle of [S:E, S:E]
+func ztrmRows(flags Flags, j, S, E int) (int, int) {
+    if flags & UPPER != 0 {
+        return S, j+1
+    }
+    return j, E
+}
+
+func maxDiffZ(t *testing.T, name string, x, y []complex128) {
+    var d float64
+    for k := range x {
+        if a := cmplx.Abs(x[k] - y[k]); a > d {
+            d = a
+        }
+    }
+    if d > 1e-12 {
+        t.Errorf("%s: max difference %e\n", name, d)
+    }
+}
+
+func TestKernelComplexMult(t *testing.T) {
+    m, n, p := 13, 11, 9
+    alpha, beta := complex(1.5, -0.5), complex(0.5, 0.25)
+    for _, trans := range []Flags{NOTRANS, TRANSA, TRANSB, TRANSA|TRANSB} {
+        A := newZTmat(m, p)
+        if trans & TRANSA != 0 {
+            A = newZTmat(p, m)
+        }
+        B := newZTmat(p, n)
+        if trans & TRANSB != 0 {
+            B = newZTmat(n, p)
+        }
+        C := newZTmat(m, n)
+        C0 := C.copy()
+        S, L, R, E := 2, n-3, 1, m-4
+        ZMult(C.d, A.d, B.d, alpha, beta, trans, C.ld, A.ld, B.ld, p, S, L, R, E, 0, 0, 0)
+        for j := S; j < L; j++ {
+            for i := R; i < E; i++ {
+                var s complex128
+                for k := 0; k < p; k++ {
+                    s += A.op(trans & TRANSA != 0, i, k)*B.op(trans & TRANSB != 0, k, j)
+                }
+                C0.d[i + j*C0.ld] = beta*C0.at(i, j) + alpha*s
+            }
+        }
+        maxDiffZ(t, "ZMult", C.d, C0.d)
+    }
+
+    for _, flags := range []Flags{LOWER, UPPER, LOWER|TRANSA, UPPER|TRANSA} {
+        A := newZTmat(m, p)
+        if flags & TRANSA != 0 {
+            A = newZTmat(p, m)
+        }
+        C := newZTmat(m, m)
+        C0 := C.copy()
+        ZHermRankBlk(C.d, A.d, 1.5, 0.5, flags, C.ld, A.ld, p, 0, m, 0, 0)
+        for j := 0; j < m; j++ {
+            R, E := ztrmRows(flags, j, 0, m)
+            for i := R; i < E; i++ {
+                var s complex128
+                for k := 0; k < p; k++ {
+                    if flags & TRANSA != 0 {
+                        s += cmplx.Conj(A.at(k, i))*A.at(k, j)
+                    } else {
+                        s += A.at(i, k)*cmplx.Conj(A.at(j, k))
+                    }
+                }
+                C0.d[i + j*C0.ld] = 0.5*C0.at(i, j) + 1.5*s
+            }
+            C0.d[j + j*C0.ld] = complex(real(C0.at(j, j)), 0.0)
+        }
+        maxDiffZ(t, "ZHermRankBlk", C.d, C0.d)
+    }
+}
+
+func TestKernelComplexSolve(t *testing.T) {
+    n := 12
+    alpha := complex(2.0, 1.0)
+    for _, side := range []Flags{LEFT, RIGHT} {
+        for _, uplo := range []Flags{LOWER, UPPER} {
+            for _, tr := range []Flags{NOTRANS, TRANSA} {
+                for _, unit := range []Flags{0, UNIT} {
+                    flags := side|uplo|tr|unit
+                    A := newZTmat(n, n)
+                    for k := 0; k < n; k++ {
+                        A.d[k + k*A.ld] = complex(2.0 + float64(k % 3), 0.5)
+                    }
+                    B := newZTmat(n, n-2)
+                    if side == RIGHT {
+                        B = newZTmat(n-2, n)
+                    }
+                    B0 := B.copy()
+                    ZSolveBlk(B.d, A.d, alpha, flags, B.ld, A.ld, n, 1, n-3, 0)
+                    // op(A)(i,j) of triangular A
+                    opA := func(i, j int) complex128 {
+                        r, c := i, j
+                        if tr != 0 {
+                            r, c = j, i
+                        }
+                        switch {
+                        case r == c && unit != 0:
+                            return 1.0
+                        case uplo == UPPER && r > c, uplo == LOWER && r < c:
+                            return 0.0
+                        }
+                        return A.op(tr != 0, i, j)
+                    }
+                    // op(A)*X == alpha*B or X*op(A) == alpha*B on solved columns/rows
+                    for s := 1; s < n-3; s++ {
+                        for k := 0; k < n; k++ {
+                            var v complex128
+                            for l := 0; l < n; l++ {
+                                if side == LEFT {
+                                    v += opA(k, l)*B.at(l, s)
+                                } else {
+                                    v += B.at(s, l)*opA(l, k)
+                                }
+                            }
+                            b := B0.at(k, s)
+                            if side == RIGHT {
+                                b = B0.at(s, k)
+                            }
+                            if cmplx.Abs(v - alpha*b) > 1e-12 {
+                                t.Fatalf("ZSolveBlk flags %x: residual %e\n", flags, cmplx.Abs(v - alpha*b))
+                            }
+                        }
+                    }
+                }
+            }
+        }
+    }
+}
+
+func TestKernelComplexMV(t *testing.T) {
+    m, n := 13, 9
+    alpha, beta := complex(2.0, 0.5), complex(-1.0, 0.0)
+    for _, trans := range []Flags{NOTRANS, TRANSA} {
+        A := newZTmat(m, n)
+        ny, nx := m, n
+        if trans != 0 {
+            A = newZTmat(n, m)
+        }
+        X := newZTmat(nx*2, 1)
+        Y := newZTmat(ny*3, 1)
+        Y0 := Y.copy()
+        ZMultMV(Y.d, A.d, X.d, alpha, beta, trans, 3, A.ld, 2, 1, nx, 0, ny-2, 0, 0)
+        for i := 0; i < ny-2; i++ {
+            var s complex128
+            for k := 1; k < nx; k++ {
+                s += A.op(trans != 0, i, k)*X.d[k*2]
+            }
+            Y0.d[i*3] = beta*Y0.d[i*3] + alpha*s
+        }
+        maxDiffZ(t, "ZMultMV", Y.d, Y0.d)
+    }
+
+    A := newZTmat(m, n)
+    A0 := A.copy()
+    X := newZTmat(2*m, 1)
+    Y := newZTmat(n, 1)
+    ZRankMV(A.d, X.d, Y.d, alpha, A.ld, 2, 1, 1, n, 2, m, 0, 0)
+    for j := 1; j < n; j++ {
+        for i := 2; i < m; i++ {
+            A0.d[i + j*A0.ld] += alpha*X.d[i*2]*cmplx.Conj(Y.d[j])
+        }
+    }
+    maxDiffZ(t, "ZRankMV", A.d, A0.d)
+
+    for _, uplo := range []Flags{LOWER, UPPER} {
+        H := newZTmat(n, n)
+        H0 := H.copy()
+        ZHermRankMV(H.d, X.d, 0.5, uplo, H.ld, 2, 0, n, 0)
+        for j := 0; j < n; j++ {
+            R, E := ztrmRows(uplo, j, 0, n)
+            for i := R; i < E; i++ {
+                H0.d[i + j*H0.ld] += 0.5*X.d[i*2]*cmplx.Conj(X.d[j*2])
+            }
+            H0.d[j + j*H0.ld] = complex(real(H0.at(j, j)), 0.0)
+        }
+        maxDiffZ(t, "ZHermRankMV", H.d, H0.d)
+    }
+}
+
+func TestKernelComplexVectors(t *testing.T) {
+    n := 13
+    alpha := complex(0.5, 2.0)
+    X := newZTmat(n, 1)
+    Y := newZTmat(2*n, 1)
+    var du, dc complex128
+    var nrm float64
+    for i := 0; i < n; i++ {
+        du += X.d[i]*Y.d[2*i]
+        dc += cmplx.Conj(X.d[i])*Y.d[2*i]
+        nrm += real(X.d[i]*cmplx.Conj(X.d[i]))
+    }
+    maxDiffZ(t, "ZDot",
+        []complex128{ZDotu(X.d, Y.d, alpha, 1, 2, n), ZDotc(X.d, Y.d, alpha, 1, 2, n),
+            complex(ZNorm2(X.d, 1, n), 0.0)},
+        []complex128{alpha*du, alpha*dc, cmplx.Sqrt(complex(nrm, 0.0))})
+
+    X.d[5] = complex(-3.0, 2.0)
+    if ix := ZIAMax(X.d, 1, n); ix != 5 {
+        t.Errorf("ZIAMax: %d != 5\n", ix)
+    }
+    X0, Y0 := X.copy(), Y.copy()
+    ZAxpy(Y.d, X.d, alpha, 1, 2, n)
+    ZScal(X.d, alpha, 1, n)
+    ZInvScal(Y.d, alpha, 2, n)
+    ZSwap(X.d, Y.d, 1, 2, n)
+    for i := 0; i < n; i++ {
+        X0.d[i], Y0.d[2*i] = (Y0.d[2*i] + alpha*X0.d[i])/alpha, alpha*X0.d[i]
+    }
+    maxDiffZ(t, "ZAxpy", X.d, X0.d)
+    maxDiffZ(t, "ZScal", Y.d, Y0.d)
+}
+
+// Local Variables:
+// tab-width: 4
+// indent-tabs-mode: nil
+// End:
@@ -0,0 +1,418 @@
+//go:build cgo && amd64 && !purego
+
+// Copyright (c) Harri Rautila, 2012,2013
+
+// This file is part of github.com/hrautila/matops package. It is free software,
+// distributed under the terms of GNU Lesser General Public License Version 3, or
+// any later version. See the COPYING tile included in this archive.
+
+// Double precision complex kernels. Flags TRANSA and TRANSB select conjugate
+// transpose of the operand as in the complex Hermitian BLAS functions.
+
+#include <math.h>
+#include "cmops.h"
+
+// return: X.Y; unit stride
+static inline double complex _zdotu(const double complex *X, const double complex *Y, int N)
+{
+  register int i;
+  double complex c0 = 0.0;
+  for (i = 0; i < N; i++) {
+    c0 += X[i] * Y[i];
+  }
+  return c0;
+}
+
+// return: conj(X).Y; unit stride
+static inline double complex _zdotc(const double complex *X, const double complex *Y, int N)
+{
+  register int i;
+  double complex c0 = 0.0;
+  for (i = 0; i < N; i++) {
+    c0 += conj(X[i]) * Y[i];
+  }
+  return c0;
+}
+
+// Y = Y + alpha*X; unit stride
+static inline void _zaxpy(double complex *Y, const double complex *X, double complex alpha, int N)
+{
+  register int i;
+  for (i = 0; i < N; i++) {
+    Y[i] += alpha * X[i];
+  }
+}
+
+// X = f0*X; zero if f0 is zero
+static void _zscale(double complex *X, int incX, double complex f0, int N)
+{
+  register int i;
+  if (f0 == 1.0) {
+    return;
+  }
+  for (i = 0; i < N; i++) {
+    X[i*incX] = f0 == 0.0 ? 0.0 : f0 * X[i*incX];
+  }
+}
+
+// return: alpha * X * Y
+double complex zvec_dotu(const zmvec_t *X,  const zmvec_t *Y, double complex alpha, int N)
+{
+  register int i;
+  double complex c0;
+
+  if (X->inc == 1 && Y->inc == 1) {
+    return alpha * _zdotu(X->md, Y->md, N);
+  }
+  c0 = 0.0;
+  for (i = 0; i < N; i++) {
+    c0 += X->md[i*X->inc] * Y->md[i*Y->inc];
+  }
+  return alpha * c0;
+}
+
+// return: alpha * conj(X) * Y
+double complex zvec_dotc(const zmvec_t *X,  const zmvec_t *Y, double complex alpha, int N)
+{
+  register int i;
+  double complex c0;
+
+  if (X->inc == 1 && Y->inc == 1) {
+    return alpha * _zdotc(X->md, Y->md, N);
+  }
+  c0 = 0.0;
+  for (i = 0; i < N; i++) {
+    c0 += conj(X->md[i*X->inc]) * Y->md[i*Y->inc];
+  }
+  return alpha * c0;
+}
+
+// Y := alpha*X + Y
+void zvec_axpy(zmvec_t *Y,  const zmvec_t *X, double complex alpha, int N)
+{
+  register int i;
+
+  if (X->inc == 1 && Y->inc == 1) {
+    _zaxpy(Y->md, X->md, alpha, N);
+    return;
+  }
+  for (i = 0; i < N; i++) {
+    Y->md[i*Y->inc] += alpha * X->md[i*X->inc];
+  }
+}
+
+// return: sqrt(sum |X[i]|^2)
+double zvec_nrm2(const zmvec_t *X,  int N)
+{
+  register int i;
+  register double c0, re, im;
+
+  c0 = 0.0;
+  for (i = 0; i < N; i++) {
+    re = creal(X->md[i*X->inc]);
+    im = cimag(X->md[i*X->inc]);
+    c0 += re * re + im * im;
+  }
+  return sqrt(c0);
+}
+
+// return: index of max |real| + |imag| value
+int zvec_iamax(const zmvec_t *X,  int N)
+{
+  register int i, ix;
+  register double max, a;
+
+  if (N <= 0) {
+    return -1;
+  }
+  ix = 0;
+  max = fabs(creal(X->md[0])) + fabs(cimag(X->md[0]));
+  for (i = 1; i < N; i++) {
+    a = fabs(creal(X->md[i*X->inc])) + fabs(cimag(X->md[i*X->inc]));
+    if (a > max) {
+      max = a;
+      ix = i;
+    }
+  }
+  return ix;
+}
+
+void zvec_swap(zmvec_t *X,  zmvec_t *Y, int N)
+{
+  register int i;
+  double complex t;
+
+  for (i = 0; i < N; i++) {
+    t = X->md[i*X->inc];
+    X->md[i*X->inc] = Y->md[i*Y->inc];
+    Y->md[i*Y->inc] = t;
+  }
+}
+
+// X = alpha*X
+void zvec_scal(zmvec_t *X,  double complex alpha, int N)
+{
+  _zscale(X->md, X->inc, alpha, N);
+}
+
+// X = X/alpha
+void zvec_invscal(zmvec_t *X,  double complex alpha, int N)
+{
+  register int i;
+  for (i = 0; i < N; i++) {
+    X->md[i*X->inc] /= alpha;
+  }
+}
+
+// C[R:E,S:L] += alpha*op(A)*op(B); op(X) is X or X.H
+static void
+_zmult_mm_intern(zmdata_t *C, const zmdata_t *A, const zmdata_t *B,
+                 double complex alpha, int flags, int P, int S, int L, int R, int E)
+{
+  register int i, j, k;
+  double complex c0, *Cc;
+  const double complex *Ad = A->md, *Bd = B->md;
+  int ldA = A->step, ldB = B->step;
+
+  for (j = S; j < L; j++) {
+    Cc = &C->md[j*C->step];
+    switch (flags & (MTX_TRANSA|MTX_TRANSB)) {
+    case MTX_TRANSA|MTX_TRANSB:
+      for (i = R; i < E; i++) {
+        c0 = 0.0;
+        for (k = 0; k < P; k++) {
+          c0 += conj(Ad[k + i*ldA] * Bd[j + k*ldB]);
+        }
+        Cc[i] += alpha * c0;
+      }
+      break;
+    case MTX_TRANSA:
+      for (i = R; i < E; i++) {
+        Cc[i] += alpha * _zdotc(&Ad[i*ldA], &Bd[j*ldB], P);
+      }
+      break;
+    case MTX_TRANSB:
+      for (k = 0; k < P; k++) {
+        _zaxpy(&Cc[R], &Ad[R + k*ldA], alpha * conj(Bd[j + k*ldB]), E-R);
+      }
+      break;
+    default:
+      for (k = 0; k < P; k++) {
+        _zaxpy(&Cc[R], &Ad[R + k*ldA], alpha * Bd[k + j*ldB], E-R);
+      }
+      break;
+    }
+  }
+}
+
+// C[R:E,S:L] = beta*C[R:E,S:L] + alpha*op(A)*op(B); P is the inner dimension.
+void
+zmult_mm_blocked(zmdata_t *C, const zmdata_t *A, const zmdata_t *B,
+                 double complex alpha, double complex beta, int flags,
+                 int P, int S, int L, int R, int E)
+{
+  register int j;
+
+  if (L - S <= 0 || E - R <= 0) {
+    return;
+  }
+  for (j = S; j < L; j++) {
+    _zscale(&C->md[R + j*C->step], 1, beta, E-R);
+  }
+  if (alpha == 0.0) {
+    return;
+  }
+  _zmult_mm_intern(C, A, B, alpha, flags, P, S, L, R, E);
+}
+
+// C = beta*C + alpha*A*A.H or C = beta*C + alpha*A.H*A if flags&TRANSA;
+// only UPPER or LOWER triangle of C[S:E,S:E] is updated and imaginary parts
+// of the diagonal are set to zero.
+void
+zmmat_rank(zmdata_t *C, const zmdata_t *A, double alpha, double beta,
+           int flags,  int P, int S, int E)
+{
+  register int j, R, E1;
+  int trans = flags & MTX_TRANSA ? MTX_TRANSA : MTX_TRANSB;
+  double complex *Cd;
+
+  for (j = S; j < E; j++) {
+    R  = flags & MTX_UPPER ? S : j;
+    E1 = flags & MTX_UPPER ? j+1 : E;
+    _zscale(&C->md[R + j*C->step], 1, beta, E1-R);
+    if (alpha != 0.0) {
+      _zmult_mm_intern(C, A, A, alpha, trans, P, j, j+1, R, E1);
+    }
+    Cd = &C->md[j + j*C->step];
+    *Cd = creal(*Cd);
+  }
+}
+
+// Y[R:E] = beta*Y[R:E] + alpha*op(A)*X[S:L]
+void
+zmult_gemv(zmvec_t *Y, const zmdata_t *A, const zmvec_t *X,
+           double complex alpha, double complex beta, int flags, int S, int L, int R, int E)
+{
+  register int i, j, k;
+  double complex c0;
+  const double complex *Ad = A->md;
+  int ldA = A->step;
+
+  if (L - S <= 0 || E - R <= 0) {
+    return;
+  }
+  _zscale(&Y->md[R*Y->inc], Y->inc, beta, E-R);
+  if (flags & MTX_TRANSA) {
+    for (i = R; i < E; i++) {
+      if (X->inc == 1) {
+        c0 = _zdotc(&Ad[S + i*ldA], &X->md[S], L-S);
+      } else {
+        c0 = 0.0;
+        for (k = S; k < L; k++) {
+          c0 += conj(Ad[k + i*ldA]) * X->md[k*X->inc];
+        }
+      }
+      Y->md[i*Y->inc] += alpha * c0;
+    }
+    return;
+  }
+  for (j = S; j < L; j++) {
+    c0 = alpha * X->md[j*X->inc];
+    if (Y->inc == 1) {
+      _zaxpy(&Y->md[R], &Ad[R + j*ldA], c0, E-R);
+    } else {
+      for (i = R; i < E; i++) {
+        Y->md[i*Y->inc] += Ad[i + j*ldA] * c0;
+      }
+    }
+  }
+}
+
+// A[R:E,S:L] = A[R:E,S:L] + alpha*X[R:E]*Y[S:L].H
+void
+zmvec_rank(zmdata_t *A, const zmvec_t *X, const zmvec_t *Y, double complex alpha,
+           int S, int L, int R, int E)
+{
+  register int i, j;
+  double complex c0, *Ac;
+
+  for (j = S; j < L; j++) {
+    Ac = &A->md[j*A->step];
+    c0 = alpha * conj(Y->md[j*Y->inc]);
+    if (X->inc == 1) {
+      _zaxpy(&Ac[R], &X->md[R], c0, E-R);
+    } else {
+      for (i = R; i < E; i++) {
+        Ac[i] += X->md[i*X->inc] * c0;
+      }
+    }
+  }
+}
+
+// A = A + alpha*X*X.H; UPPER or LOWER triangle of A, imaginary parts of the
+// diagonal set to zero.
+void
+zmvec_her_rank(zmdata_t *A, const zmvec_t *X,  double alpha, int flags, int S, int L)
+{
+  register int i, j, R, E;
+  double complex c0, *Ac;
+
+  for (j = S; j < L; j++) {
+    R = flags & MTX_UPPER ? 0 : j;
+    E = flags & MTX_UPPER ? j+1 : L;
+    Ac = &A->md[j*A->step];
+    c0 = alpha * conj(X->md[j*X->inc]);
+    if (X->inc == 1) {
+      _zaxpy(&Ac[R], &X->md[R], c0, E-R);
+    } else {
+      for (i = R; i < E; i++) {
+        Ac[i] += X->md[i*X->inc] * c0;
+      }
+    }
+    Ac[j] = creal(Ac[j]);
+  }
+}
+
+// X = op(A).-1*X; A is N*N triangular, op(A) is A or A.T with elements of A
+// conjugated if cj is non-zero.
+static void
+_zsolve_vec(double complex *X, int incX, const zmdata_t *A, int flags, int cj, int N)
+{
+  register int i, k;
+  double complex c0, a;
+  const double complex *Ad = A->md;
+  int ldA = A->step;
+  int unit = flags & MTX_UNIT;
+
+#define _AT(i, j) (cj ? conj(Ad[(i) + (j)*ldA]) : Ad[(i) + (j)*ldA])
+
+  switch (flags & (MTX_UPPER|MTX_TRANSA)) {
+  case MTX_UPPER:
+    for (k = N-1; k >= 0; k--) {
+      if (! unit)
+        X[k*incX] /= _AT(k, k);
+      c0 = X[k*incX];
+      for (i = 0; i < k; i++) {
+        a = _AT(i, k);
+        X[i*incX] -= a * c0;
+      }
+    }
+    break;
+  case MTX_UPPER|MTX_TRANSA:
+    for (k = 0; k < N; k++) {
+      c0 = X[k*incX];
+      for (i = 0; i < k; i++) {
+        c0 -= _AT(i, k) * X[i*incX];
+      }
+      X[k*incX] = unit ? c0 : c0 / _AT(k, k);
+    }
+    break;
+  case MTX_TRANSA:
+    for (k = N-1; k >= 0; k--) {
+      c0 = X[k*incX];
+      for (i = k+1; i < N; i++) {
+        c0 -= _AT(i, k) * X[i*incX];
+      }
+      X[k*incX] = unit ? c0 : c0 / _AT(k, k);
+    }
+    break;
+  default:
+    for (k = 0; k < N; k++) {
+      if (! unit)
+        X[k*incX] /= _AT(k, k);
+      c0 = X[k*incX];
+      for (i = k+1; i < N; i++) {
+        a = _AT(i, k);
+        X[i*incX] -= a * c0;
+      }
+    }
+    break;
+  }
+#undef _AT
+}
+
+// B = alpha*op(A).-1*B for columns [S:E] of B if flags&LEFT or
+// B = alpha*B*op(A).-1 for rows [S:E] of B if flags&RIGHT; A is N*N
+void
+zmmat_solve(zmdata_t *B, const zmdata_t *A, double complex alpha, int flags, int N, int S, int E)
+{
+  register int j;
+
+  if (flags & MTX_RIGHT) {
+    // row x of B: x = x*op(A).-1 => op(A).T*x.T = x.T where op(A).T is A.T
+    // or conj(A) for op(A) = A.H
+    for (j = S; j < E; j++) {
+      _zscale(&B->md[j], B->step, alpha, N);
+      _zsolve_vec(&B->md[j], B->step, A, flags ^ MTX_TRANSA, flags & MTX_TRANSA, N);
+    }
+    return;
+  }
+  for (j = S; j < E; j++) {
+    _zscale(&B->md[j*B->step], 1, alpha, N);
+    _zsolve_vec(&B->md[j*B->step], 1, A, flags, flags & MTX_TRANSA, N);
+  }
+}
+
+// Local Variables:
+// indent-tabs-mode: nil
+// End:
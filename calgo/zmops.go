@@ -0,0 +1,194 @@
+//go:build cgo && amd64 && !purego
+
+// Copyright (c) Harri Rautila, 2012,2013
+
+// This file is part of github.com/hrautila/matops package. It is free software,
+// distributed under the terms of GNU Lesser General Public License Version 3, or
+// any later version. See the COPYING tile included in this archive.
+
+
+package calgo
+
+// #include "cmops.h"
+import "C"
+import "unsafe"
+
+// Double precision complex kernels. Arguments are as in the corresponding double
+// precision functions; flags TRANSA and TRANSB select conjugate transpose of the
+// operand. Blocking parameters NB and MB are not used.
+
+func zmatrix(A []complex128, ldA int) C.zmdata_t {
+    var Am C.zmdata_t
+    Am.md = (*C.complexdouble)(unsafe.Pointer(&A[0]))
+    Am.step = C.int(ldA)
+    return Am
+}
+
+func zvector(X []complex128, incX int) C.zmvec_t {
+    var Xv C.zmvec_t
+    Xv.md = (*C.complexdouble)(unsafe.Pointer(&X[0]))
+    Xv.inc = C.int(incX)
+    return Xv
+}
+
+// blas ZGEMM; see DMult().
+func ZMult(C, A, B []complex128, alpha, beta complex128, trans Flags, ldC, ldA, ldB, P, S, L, R, E, H, NB, MB int) {
+
+    if C == nil || B == nil || A == nil {
+        return
+    }
+    Cm := zmatrix(C, ldC)
+    Am := zmatrix(A, ldA)
+    Bm := zmatrix(B, ldB)
+    C.zmult_mm_blocked(&Cm, &Am, &Bm,
+        C.complexdouble(alpha), C.complexdouble(beta), C.int(trans),
+        C.int(P), C.int(S), C.int(L), C.int(R), C.int(E))
+}
+
+// blas ZTRSM; see DSolveBlk().
+func ZSolveBlk(B, A []complex128, alpha complex128, flags Flags, ldB, ldA, N, S, E, NB int) {
+
+    if B == nil || A == nil || N == 0 {
+        return
+    }
+    Bm := zmatrix(B, ldB)
+    Am := zmatrix(A, ldA)
+    C.zmmat_solve(&Bm, &Am, C.complexdouble(alpha), C.int(flags), C.int(N), C.int(S), C.int(E))
+}
+
+// blas ZHERK; C = beta*C + alpha*A*A.H or C = beta*C + alpha*A.H*A if flags&TRANSA.
+// See DSymmRankBlk().
+func ZHermRankBlk(C, A []complex128, alpha, beta float64, flags Flags, ldC, ldA, N, S, E, H, NB int) {
+
+    if C == nil || A == nil || E - S <= 0 {
+        return
+    }
+    Cm := zmatrix(C, ldC)
+    Am := zmatrix(A, ldA)
+    C.zmmat_rank(&Cm, &Am, C.double(alpha), C.double(beta),
+        C.int(flags), C.int(N), C.int(S), C.int(E))
+}
+
+// blas ZGEMV; see DMultMV().
+func ZMultMV(Y, A, X []complex128, alpha, beta complex128, flags Flags, incY, ldA, incX, S, L, R, E, H, MB int) {
+
+    if Y == nil || A == nil || X == nil {
+        return
+    }
+    Yv := zvector(Y, incY)
+    Am := zmatrix(A, ldA)
+    Xv := zvector(X, incX)
+    C.zmult_gemv(&Yv, &Am, &Xv, C.complexdouble(alpha), C.complexdouble(beta), C.int(flags),
+        C.int(S), C.int(L), C.int(R), C.int(E))
+}
+
+// blas ZGERC; A = A + alpha*X*Y.H. See DRankMV().
+func ZRankMV(A, X, Y []complex128, alpha complex128, ldA, incX, incY, S, L, R, E, NB, MB int) {
+
+    if A == nil || X == nil || Y == nil {
+        return
+    }
+    Am := zmatrix(A, ldA)
+    Xv := zvector(X, incX)
+    Yv := zvector(Y, incY)
+    C.zmvec_rank(&Am, &Xv, &Yv, C.complexdouble(alpha), C.int(S), C.int(L), C.int(R), C.int(E))
+}
+
+// blas ZHER; A = A + alpha*X*X.H. See DSymmRankMV().
+func ZHermRankMV(A, X []complex128, alpha float64, flags Flags, ldA, incX, S, L, NB int) {
+
+    if A == nil || X == nil {
+        return
+    }
+    Am := zmatrix(A, ldA)
+    Xv := zvector(X, incX)
+    C.zmvec_her_rank(&Am, &Xv, C.double(alpha), C.int(flags), C.int(S), C.int(L))
+}
+
+// return: alpha * X * Y
+func ZDotu(X, Y []complex128, alpha complex128, incX, incY, N int) complex128 {
+
+    if X == nil || Y == nil || N <= 0 {
+        return 0.0
+    }
+    Xv := zvector(X, incX)
+    Yv := zvector(Y, incY)
+    return complex128(C.zvec_dotu(&Xv, &Yv, C.complexdouble(alpha), C.int(N)))
+}
+
+// return: alpha * X.H * Y
+func ZDotc(X, Y []complex128, alpha complex128, incX, incY, N int) complex128 {
+
+    if X == nil || Y == nil || N <= 0 {
+        return 0.0
+    }
+    Xv := zvector(X, incX)
+    Yv := zvector(Y, incY)
+    return complex128(C.zvec_dotc(&Xv, &Yv, C.complexdouble(alpha), C.int(N)))
+}
+
+// Y := alpha*X + Y
+func ZAxpy(Y, X []complex128, alpha complex128, incX, incY, N int) {
+
+    if X == nil || Y == nil || N <= 0 {
+        return
+    }
+    Xv := zvector(X, incX)
+    Yv := zvector(Y, incY)
+    C.zvec_axpy(&Yv, &Xv, C.complexdouble(alpha), C.int(N))
+}
+
+// return: Euclidean norm of X
+func ZNorm2(X []complex128, incX, N int) float64 {
+
+    if X == nil || N <= 0 {
+        return 0.0
+    }
+    Xv := zvector(X, incX)
+    return float64(C.zvec_nrm2(&Xv, C.int(N)))
+}
+
+// return: index of max |real| + |imag| value
+func ZIAMax(X []complex128, incX, N int) int {
+
+    if X == nil || N <= 0 {
+        return -1
+    }
+    Xv := zvector(X, incX)
+    return int(C.zvec_iamax(&Xv, C.int(N)))
+}
+
+func ZSwap(X, Y []complex128, incX, incY, N int) {
+
+    if X == nil || Y == nil || N <= 0 {
+        return
+    }
+    Xv := zvector(X, incX)
+    Yv := zvector(Y, incY)
+    C.zvec_swap(&Xv, &Yv, C.int(N))
+}
+
+// inverse scaling: X = X/alpha
+func ZInvScal(X []complex128, alpha complex128, incX, N int) {
+
+    if X == nil || N <= 0 {
+        return
+    }
+    Xv := zvector(X, incX)
+    C.zvec_invscal(&Xv, C.complexdouble(alpha), C.int(N))
+}
+
+// scaling: X = alpha*X
+func ZScal(X []complex128, alpha complex128, incX, N int) {
+
+    if X == nil || N <= 0 {
+        return
+    }
+    Xv := zvector(X, incX)
+    C.zvec_scal(&Xv, C.complexdouble(alpha), C.int(N))
+}
+
+// Local Variables:
+// tab-width: 4
+// indent-tabs-mode: nil
+// End:
@@ -9,88 +9,168 @@ package matops
 
 import (
     "github.com/hrautila/matrix"
-    "errors"
+    "context"
     "math"
-    "fmt"
 )
 
-func unblockedCHOL(A *matrix.FloatMatrix, flags Flags, nr int) (err error) {
-    var ATL, ATR, ABL, ABR matrix.FloatMatrix
-    var A00, a01, A02, a10, a11, a12, A20, a21, A22 matrix.FloatMatrix
+// Unblocked Cholesky factorization; nr is row offset of A for error index.
+func unblockedCHOL[T any, M partitionable[T]](ops factorOps[M], A M, flags Flags, nr int) error {
+    var ATL, ATR, ABL, ABR M
+    var A00, a01, A02, a10, a11, a12, A20, a21, A22 M
 
-    err = nil
+    newParts[T](&ATL, &ATR, &ABL, &ABR)
+    newParts[T](&A00, &a01, &A02, &a10, &a11, &a12, &A20, &a21, &A22)
     partition2x2(
-        &ATL, &ATR,
-        &ABL, &ABR,   A, 0, 0, pTOPLEFT)
+        ATL, ATR,
+        ABL, ABR,   A, 0, 0, pTOPLEFT)
 
     for ATL.Rows() < A.Rows() {
-        repartition2x2to3x3(&ATL,
-            &A00, &a01, &A02,
-            &a10, &a11, &a12,
-            &A20, &a21, &A22,   A, 1, pBOTTOMRIGHT)
+        repartition2x2to3x3(ATL,
+            A00, a01, A02,
+            a10, a11, a12,
+            A20, a21, A22,   A, 1, pBOTTOMRIGHT)
 
         // a11 = sqrt(a11)
-        aval := math.Sqrt(a11.Float())
-        if math.IsNaN(aval) {
-            panic(fmt.Sprintf("illegal value at %d: %e", nr+ATL.Rows(), a11.Float()))
+        if ! (ops.get(a11) > 0.0) {
+            return &NotPositiveDefiniteError{nr+ATL.Rows()}
         }
-        a11.SetAt(0, 0, aval)
+        ops.set(a11, math.Sqrt(ops.get(a11)))
 
         if flags & LOWER != 0 {
             // a21 = a21/a11
-            InvScale(&a21, a11.Float())
+            ops.invScale(a21, ops.get(a11))
             // A22 = A22 - a21*a21' (SYR)
-            err = MVRankUpdateSym(&A22, &a21, -1.0, flags)
+            if err := ops.mvRankUpdateSym(A22, a21, -1.0, flags); err != nil {
+                return err
+            }
         } else {
             // a21 = a12/a11
-            InvScale(&a12, a11.Float())
+            ops.invScale(a12, ops.get(a11))
             // A22 = A22 - a12'*a12 (SYR)
-            err = MVRankUpdateSym(&A22, &a12, -1.0, flags)
+            if err := ops.mvRankUpdateSym(A22, a12, -1.0, flags); err != nil {
+                return err
+            }
         }
 
         continue3x3to2x2(
-            &ATL, &ATR,
-            &ABL, &ABR,   &A00, &a11, &A22,  A, pBOTTOMRIGHT)
+            ATL, ATR,
+            ABL, ABR,   A00, a11, A22,  A, pBOTTOMRIGHT)
     }
-    return
+    return nil
 }
 
-func blockedCHOL(A *matrix.FloatMatrix, flags Flags, nb int) error {
-    var err error
-    var ATL, ATR, ABL, ABR matrix.FloatMatrix
-    var A00, A01, A02, A10, A11, A12, A20, A21, A22 matrix.FloatMatrix
+func blockedCHOL[T any, M partitionable[T]](ops factorOps[M], A M, flags Flags, nb int) error {
+    var ATL, ATR, ABL, ABR M
+    var A00, A01, A02, A10, A11, A12, A20, A21, A22 M
 
-    err = nil
+    newParts[T](&ATL, &ATR, &ABL, &ABR)
+    newParts[T](&A00, &A01, &A02, &A10, &A11, &A12, &A20, &A21, &A22)
     partition2x2(
-        &ATL, &ATR,
-        &ABL, &ABR,   A, 0, 0, pTOPLEFT)
+        ATL, ATR,
+        ABL, ABR,   A, 0, 0, pTOPLEFT)
 
     for ATL.Rows() < A.Rows() && ATL.Cols() < A.Cols() {
-        repartition2x2to3x3(&ATL,
-            &A00, &A01, &A02,
-            &A10, &A11, &A12,
-            &A20, &A21, &A22,   A, nb, pBOTTOMRIGHT)
+        repartition2x2to3x3(ATL,
+            A00, A01, A02,
+            A10, A11, A12,
+            A20, A21, A22,   A, nb, pBOTTOMRIGHT)
 
         // A11 = chol(A11)
-        err = unblockedCHOL(&A11, flags, ATL.Rows())
+        if err := unblockedCHOL(ops, A11, flags, ATL.Rows()); err != nil {
+            return err
+        }
 
         if flags & LOWER != 0 {
             // A21 = A21 * tril(A11).-1
-            SolveTrm(&A21, &A11, 1.0, RIGHT|LOWER|TRANSA)
+            ops.solveTrm(A21, A11, 1.0, RIGHT|LOWER|TRANSA)
             // A22 = A22 - A21*A21.T
-            RankUpdateSym(&A22, &A21, -1.0, 1.0, LOWER)
+            ops.rankUpdateSym(A22, A21, -1.0, 1.0, LOWER)
         } else {
             // A12 = triu(A11).-1 * A12
-            SolveTrm(&A12, &A11, 1.0, UPPER|TRANSA)
+            ops.solveTrm(A12, A11, 1.0, LEFT|UPPER|TRANSA)
             // A22 = A22 - A12.T*A12
-            RankUpdateSym(&A22, &A12, -1.0, 1.0, UPPER|TRANSA)
+            ops.rankUpdateSym(A22, A12, -1.0, 1.0, UPPER|TRANSA)
         }
 
         continue3x3to2x2(
-            &ATL, &ATR,
-            &ABL, &ABR,   &A00, &A11, &A22,   A, pBOTTOMRIGHT)
+            ATL, ATR,
+            ABL, ABR,   A00, A11, A22,   A, pBOTTOMRIGHT)
     }
-    return err
+    return nil
+}
+
+// parallel blocked Cholesky factorization, right-looking variant.
+//
+// Task graph: panel k factors diagonal block k and solves the rest of column block k
+// (LOWER) or row block k (UPPER); depends on the update of the block with panel k-1.
+// Update (k,j) updates block j with panel k and depends on panel k and update (k-1,j).
+func parallelCHOL(ctx context.Context, conf *Config, A *matrix.FloatMatrix, flags Flags, nb int) error {
+    N := A.Rows()
+    nblock := (N + nb - 1)/nb
+    last := make([]*graphTask, nblock)
+    bg := context.Background()
+    sconf := conf.serial()
+    g := newTaskGraph()
+
+    for k := 0; k < nblock; k++ {
+        k0 := k*nb
+        kb := imin(nb, N-k0)
+        panel := func() error {
+            var A11, A21 matrix.FloatMatrix
+            A.SubMatrix(&A11, k0, k0, kb, kb)
+            // A11 = chol(A11)
+            if err := unblockedCHOL(float64Ops{}, &A11, flags, k0); err != nil {
+                return err
+            }
+            if N == k0+kb {
+                return nil
+            }
+            if flags & LOWER != 0 {
+                // A21 = A21 * tril(A11).-1
+                A.SubMatrix(&A21, k0+kb, k0, N-k0-kb, kb)
+                solveTrmConf(bg, sconf, &A21, &A11, 1.0, RIGHT|LOWER|TRANSA)
+            } else {
+                // A12 = triu(A11).-1 * A12
+                A.SubMatrix(&A21, k0, k0+kb, kb, N-k0-kb)
+                solveTrmConf(bg, sconf, &A21, &A11, 1.0, UPPER|TRANSA)
+            }
+            return nil
+        }
+        last[k] = g.add(k*nblock+k, panel, last[k])
+
+        for j := k+1; j < nblock; j++ {
+            j0 := j*nb
+            jb := imin(nb, N-j0)
+            update := func() error {
+                var Ajj, Aj, Ao, Co matrix.FloatMatrix
+                A.SubMatrix(&Ajj, j0, j0, jb, jb)
+                if flags & LOWER != 0 {
+                    // Ajj = Ajj - Lj*Lj.T; Lj = A[j0:j0+jb, k0:k0+kb]
+                    A.SubMatrix(&Aj, j0, k0, jb, kb)
+                    rankUpdateSymConf(bg, sconf, &Ajj, &Aj, -1.0, 1.0, LOWER)
+                    if N > j0+jb {
+                        // column block below diagonal: Co = Co - Lo*Lj.T
+                        A.SubMatrix(&Ao, j0+jb, k0, N-j0-jb, kb)
+                        A.SubMatrix(&Co, j0+jb, j0, N-j0-jb, jb)
+                        multConf(bg, sconf, &Co, &Ao, &Aj, -1.0, 1.0, TRANSB)
+                    }
+                } else {
+                    // Ajj = Ajj - Uj.T*Uj; Uj = A[k0:k0+kb, j0:j0+jb]
+                    A.SubMatrix(&Aj, k0, j0, kb, jb)
+                    rankUpdateSymConf(bg, sconf, &Ajj, &Aj, -1.0, 1.0, UPPER|TRANSA)
+                    if N > j0+jb {
+                        // row block right of diagonal: Co = Co - Uj.T*Uo
+                        A.SubMatrix(&Ao, k0, j0+jb, kb, N-j0-jb)
+                        A.SubMatrix(&Co, j0, j0+jb, jb, N-j0-jb)
+                        multConf(bg, sconf, &Co, &Aj, &Ao, -1.0, 1.0, TRANSA)
+                    }
+                }
+                return nil
+            }
+            last[j] = g.add(j*nblock+k, update, last[k], last[j])
+        }
+    }
+    return g.run(ctx, conf.Executor)
 }
 
 /*
@@ -111,21 +191,60 @@ func blockedCHOL(A *matrix.FloatMatrix, flags Flags, nb int) error {
  *  nb    The blocking factor for blocked invocations. If nb == 0 or N < nb unblocked
  *        algorithm is used.
  *
+ * Returns:
+ *  Factored matrix and error indicator. If A is not positive definite error is
+ *  *NotPositiveDefiniteError with the failed column.
+ *
  * Compatible with lapack.DPOTRF
  */
 func DecomposeCHOL(A *matrix.FloatMatrix, flags Flags, nb int) (*matrix.FloatMatrix, error) {
+    return DecomposeCHOLContext(context.Background(), nil, A, flags, nb)
+}
+
+// Cholesky factorization with blocked invocation run as task graph with the
+// workers of executor ex. If ex is nil the default executor is used. See
+// DecomposeLUContext() and DecomposeCHOL().
+func DecomposeCHOLContext(ctx context.Context, ex Executor, A *matrix.FloatMatrix,
+    flags Flags, nb int) (*matrix.FloatMatrix, error) {
+
     var err error
     if A.Cols() != A.Rows() {
-        return A, errors.New("A not a square matrix")
+        return A, sizeError("DecomposeCHOL", A)
     }
+    ex = executorOrDefault(ex)
     if A.Cols() < nb || nb == 0 {
-        err = unblockedCHOL(A, flags, 0)
+        if err = ctx.Err(); err != nil {
+            return A, err
+        }
+        err = unblockedCHOL(float64Ops{}, A, flags, 0)
+    } else if ex.Workers() > 1 || ctx.Done() != nil {
+        err = parallelCHOL(ctx, globalConfig(ex), A, flags, nb)
     } else {
-        err = blockedCHOL(A, flags, nb)
+        err = blockedCHOL(float64Ops{}, A, flags, nb)
     }
     return A, err
 }
 
+// Cholesky factorization with block size conf.DecompNB and parameters from conf.
+// Blocked invocation is always run as task graph. See Config and DecomposeCHOLContext().
+func DecomposeCHOLConfig(ctx context.Context, conf *Config, A *matrix.FloatMatrix,
+    flags Flags) (*matrix.FloatMatrix, error) {
+
+    c, release := conf.resolve()
+    defer release()
+    if A.Cols() != A.Rows() {
+        return A, c.sizeError("DecomposeCHOL", A)
+    }
+    if err := ctx.Err(); err != nil {
+        return A, err
+    }
+    nb := c.DecompNB
+    if A.Cols() < nb || nb == 0 {
+        return A, unblockedCHOL(float64Ops{}, A, flags, 0)
+    }
+    return A, parallelCHOL(ctx, c, A, flags, nb)
+}
+
 /*
  * Solves a system system of linear equations A*X = B with symmetric positive
  * definite matrix A using the Cholesky factorization A = U.T*U or A = L*L.T
@@ -142,19 +261,44 @@ func DecomposeCHOL(A *matrix.FloatMatrix, flags Flags, nb int) (*matrix.FloatMat
  *        triangle of A is stored. If flags&LOWER then lower triangle of A is
  *        stored.
  *
+ * Returns:
+ *  Error if flags does not have exactly one of UPPER and LOWER or if sizes of
+ *  A and B do not match. If factor has zero on diagonal error is *SingularError
+ *  with its index and B is not changed.
+ *
  * Compatible with lapack.DPOTRS.
  */
-func SolveCHOL(B, A *matrix.FloatMatrix, flags Flags) {
+func SolveCHOLChecked(B, A *matrix.FloatMatrix, flags Flags) error {
+    var err error
+    if ! oneOf(flags, UPPER, LOWER) {
+        return flagError("SolveCHOL", "UPPER or LOWER")
+    }
+    if A.Rows() != A.Cols() || B.Rows() != A.Rows() {
+        return sizeError("SolveCHOL", B, A)
+    }
+    if k := zeroPivot(A); k >= 0 {
+        return &SingularError{k}
+    }
     // A*X = B; X = A.-1*B == (LU).-1*B == U.-1*L.-1*B == U.-1*(L.-1*B)
     if flags&UPPER != 0 {
         // X = (U.T*U).-1*B => U.-1*(U.-T*B)
-        SolveTrm(B, A, 1.0, UPPER|TRANSA)
-        SolveTrm(B, A, 1.0, UPPER)
-    } else if flags&LOWER != 0 {
+        if err = SolveTrm(B, A, 1.0, UPPER|TRANSA); err == nil {
+            err = SolveTrm(B, A, 1.0, UPPER)
+        }
+    } else {
         // X = (L*L.T).-1*B = L.-T*(L.1*B)
-        SolveTrm(B, A, 1.0, LOWER)
-        SolveTrm(B, A, 1.0, LOWER|TRANSA)
+        if err = SolveTrm(B, A, 1.0, LOWER); err == nil {
+            err = SolveTrm(B, A, 1.0, LOWER|TRANSA)
+        }
     }
+    return err
+}
+
+// Solves A*X = B with Cholesky factorization of A.
+//
+// Deprecated: Use SolveCHOLChecked, which validates its arguments and returns an error.
+func SolveCHOL(B, A *matrix.FloatMatrix, flags Flags) {
+    SolveCHOLChecked(B, A, flags)
 }
 
 // Local Variables:
@@ -9,8 +9,9 @@ package matops
 
 import (
     "github.com/hrautila/matrix"
+    "errors"
     "testing"
-    //"math"
+    "math"
 )
 
 
@@ -62,6 +63,162 @@ func TestLowerCHOL(t *testing.T) {
 
 
 
+// symmetric positive semidefinite N*N matrix of rank r
+func psdNormal(N, r int) *matrix.FloatMatrix {
+    Z := matrix.FloatNormal(N, r)
+    A := matrix.FloatZeros(N, N)
+    Mult(A, Z, Z, 1.0, 0.0, TRANSB)
+    return A
+}
+
+// ||op(R)*op(R).T - S||_1 for R = L[:,0:r] or R = U[0:r,:], S symmetric.
+func cholResidual(R, S *matrix.FloatMatrix, r int, flags Flags) float64 {
+    var Rr matrix.FloatMatrix
+    N := S.Rows()
+    C := S.Copy()
+    if flags & LOWER != 0 {
+        R.SubMatrix(&Rr, 0, 0, N, r)
+        TriL(&Rr)
+        Mult(C, &Rr, &Rr, -1.0, 1.0, TRANSB)
+    } else {
+        R.SubMatrix(&Rr, 0, 0, r, N)
+        TriU(&Rr)
+        Mult(C, &Rr, &Rr, -1.0, 1.0, TRANSA)
+    }
+    return NormP(C, NORM_ONE)
+}
+
+func TestCHOLPiv(t *testing.T) {
+    N, r := 60, 25
+    A := psdNormal(N, r)
+    for _, nb := range []int{0, 8} {
+        for _, flags := range []Flags{LOWER, UPPER} {
+            piv := make([]int, N)
+            R, rank, err := DecomposeCHOLPiv(A.Copy(), piv, -1.0, flags, nb)
+            if err != nil {
+                t.Fatalf("DecomposeCHOLPiv: %v\n", err)
+            }
+            if rank != r {
+                t.Errorf("DecomposeCHOLPiv flags %x nb %d: rank %d, expected %d\n", flags, nb, rank, r)
+            }
+            // P.T*A*P
+            Ap := matrix.FloatZeros(N, N)
+            for j := 0; j < N; j++ {
+                for i := 0; i < N; i++ {
+                    Ap.SetAt(i, j, A.GetAt(piv[i], piv[j]))
+                }
+            }
+            d := cholResidual(R, Ap, rank, flags)
+            t.Logf("flags %x nb %d: rank %d, ||P.T*A*P - R.T*R||_1: %e\n", flags, nb, rank, d)
+            if d > 1e-10*NormP(A, NORM_ONE) {
+                t.Errorf("DecomposeCHOLPiv flags %x nb %d: residual too large\n", flags, nb)
+            }
+        }
+    }
+}
+
+func TestCHOLMod(t *testing.T) {
+    N := 50
+    for _, nb := range []int{0, 8} {
+        for _, flags := range []Flags{LOWER, UPPER} {
+            // positive definite; no perturbation
+            A := spdNormal(N)
+            E := matrix.FloatZeros(N, 1)
+            R, err := DecomposeCHOLMod(A.Copy(), E, flags, nb)
+            if err != nil {
+                t.Fatalf("DecomposeCHOLMod: %v\n", err)
+            }
+            R0, _ := DecomposeCHOL(A.Copy(), flags, nb)
+            if e := NormP(E, NORM_INF); e != 0.0 {
+                t.Errorf("DecomposeCHOLMod flags %x nb %d: ||E||: %e, expected zero\n", flags, nb, e)
+            }
+            if d := cholResidual(R, A, N, flags) - cholResidual(R0, A, N, flags); d > 1e-10 {
+                t.Errorf("DecomposeCHOLMod flags %x nb %d: factor differs from DecomposeCHOL\n", flags, nb)
+            }
+
+            // indefinite
+            A = matrix.FloatNormal(N, N)
+            Mult(A, matrix.FloatNormal(N, N), matrix.FloatNormal(N, N), 1.0, 0.0, TRANSB)
+            ScalePlus(A, A.Transpose(), 0.5, 0.5, NOTRANS)
+            R, _ = DecomposeCHOLMod(A.Copy(), E, flags, nb)
+            emin := AMax(E)
+            for k := 0; k < N; k++ {
+                emin = math.Min(emin, E.GetAt(k, 0))
+                A.SetAt(k, k, A.GetAt(k, k) + E.GetAt(k, 0))
+            }
+            d := cholResidual(R, A, N, flags)
+            t.Logf("flags %x nb %d: ||E||_inf: %e, ||A + E - R.T*R||_1: %e\n", flags, nb, NormP(E, NORM_INF), d)
+            if emin < 0.0 || NormP(E, NORM_INF) == 0.0 {
+                t.Errorf("DecomposeCHOLMod flags %x nb %d: invalid perturbation\n", flags, nb)
+            }
+            if d > 1e-10*NormP(A, NORM_ONE) {
+                t.Errorf("DecomposeCHOLMod flags %x nb %d: residual too large\n", flags, nb)
+            }
+        }
+    }
+}
+
+func TestUpdateCHOL(t *testing.T) {
+    N, K := 40, 3
+    for _, flags := range []Flags{LOWER, UPPER} {
+        A := spdNormal(N)
+        X := matrix.FloatNormal(N, K)
+        // A1 = A + X*X.T
+        A1 := A.Copy()
+        Mult(A1, X, X, 1.0, 1.0, TRANSB)
+        var x matrix.FloatMatrix
+        X.SubMatrix(&x, 0, 0, N, 1)
+        A2 := A.Copy()
+        MVRankUpdate(A2, &x, &x, 1.0)
+
+        R, _ := DecomposeCHOL(A.Copy(), flags, 0)
+        if err := UpdateCHOL(R, x.Transpose(), flags); err != nil {
+            t.Fatalf("UpdateCHOL: %v\n", err)
+        }
+        d := cholResidual(R, A2, N, flags)
+        t.Logf("flags %x: ||A + x*x.T - R.T*R||_1: %e\n", flags, d)
+        if d > 1e-10 {
+            t.Errorf("UpdateCHOL flags %x: residual too large\n", flags)
+        }
+        if err := DowndateCHOL(R, &x, flags); err != nil {
+            t.Fatalf("DowndateCHOL: %v\n", err)
+        }
+        d = cholResidual(R, A, N, flags)
+        t.Logf("flags %x: ||A - R.T*R||_1 after downdate: %e\n", flags, d)
+        if d > 1e-10 {
+            t.Errorf("DowndateCHOL flags %x: residual too large\n", flags)
+        }
+
+        if err := RankUpdateCHOL(R, X, flags); err != nil {
+            t.Fatalf("RankUpdateCHOL: %v\n", err)
+        }
+        if d := cholResidual(R, A1, N, flags); d > 1e-10 {
+            t.Errorf("RankUpdateCHOL flags %x: ||A + X*X.T - R.T*R||_1: %e\n", flags, d)
+        }
+        if err := RankDowndateCHOL(R, X, flags); err != nil {
+            t.Fatalf("RankDowndateCHOL: %v\n", err)
+        }
+        if d := cholResidual(R, A, N, flags); d > 1e-10 {
+            t.Errorf("RankDowndateCHOL flags %x: ||A - R.T*R||_1: %e\n", flags, d)
+        }
+
+        // loss of definiteness; factor not changed
+        var npd *NotPositiveDefiniteError
+        R0 := R.Copy()
+        Y := X.Copy()
+        Y.Scale(1e3)
+        if err := DowndateCHOL(R, Y.SubMatrix(&x, 0, 1, N, 1), flags); ! errors.As(err, &npd) {
+            t.Errorf("DowndateCHOL flags %x: expected *NotPositiveDefiniteError, got %v\n", flags, err)
+        }
+        if err := RankDowndateCHOL(R, Y, flags); ! errors.As(err, &npd) {
+            t.Errorf("RankDowndateCHOL flags %x: expected *NotPositiveDefiniteError, got %v\n", flags, err)
+        }
+        if d := NormP(R.Minus(R0), NORM_ONE); d != 0.0 {
+            t.Errorf("DowndateCHOL flags %x: factor changed on error\n", flags)
+        }
+    }
+}
+
 // Local Variables:
 // tab-width: 4
 // indent-tabs-mode: nil
@@ -0,0 +1,142 @@
+
+// Copyright (c) Harri Rautila, 2013
+
+// This file is part of github.com/hrautila/matops package. It is free software,
+// distributed under the terms of GNU Lesser General Public License Version 3, or
+// any later version. See the COPYING tile included in this archive.
+
+package matops
+
+import (
+    "github.com/hrautila/matrix"
+    "math"
+)
+
+// Unblocked band Cholesky factorization. (LAPACK/dpbtf2.f)
+func unblockedCHOLBand(A *matrix.FloatMatrix, flags Flags) error {
+    N := A.Cols()
+    K := A.Rows() - 1
+    Ar := A.FloatArray()
+    ldA := A.LeadingIndex()
+
+    for j := 0; j < N; j++ {
+        kn := imin(K, N-1-j)
+        if flags & UPPER != 0 {
+            // compute U[j,j] and row j of U within the band
+            ajj := Ar[K+j*ldA]
+            if ajj <= 0.0 || math.IsNaN(ajj) {
+                return &NotPositiveDefiniteError{j}
+            }
+            ajj = math.Sqrt(ajj)
+            Ar[K+j*ldA] = ajj
+            for c := 0; c < kn; c++ {
+                Ar[K-1-c+(j+1+c)*ldA] /= ajj
+            }
+            // update trailing upper triangle within the band
+            for c := 0; c < kn; c++ {
+                xc := Ar[K-1-c+(j+1+c)*ldA]
+                for r := 0; r <= c; r++ {
+                    Ar[K+r-c+(j+1+c)*ldA] -= Ar[K-1-r+(j+1+r)*ldA] * xc
+                }
+            }
+        } else {
+            // compute L[j,j] and column j of L within the band
+            ajj := Ar[j*ldA]
+            if ajj <= 0.0 || math.IsNaN(ajj) {
+                return &NotPositiveDefiniteError{j}
+            }
+            ajj = math.Sqrt(ajj)
+            Ar[j*ldA] = ajj
+            for r := 1; r <= kn; r++ {
+                Ar[r+j*ldA] /= ajj
+            }
+            // update trailing lower triangle within the band
+            for c := 0; c < kn; c++ {
+                xc := Ar[1+c+j*ldA]
+                for r := c; r < kn; r++ {
+                    Ar[r-c+(j+1+c)*ldA] -= Ar[1+r+j*ldA] * xc
+                }
+            }
+        }
+    }
+    return nil
+}
+
+/*
+ * Compute the Cholesky factorization of a symmetric positive definite N-by-N
+ * band matrix A.
+ *
+ * Arguments:
+ *  A     On entry, the symmetric band matrix in triangular-band-packed storage as
+ *        returned by BandedTrmMatrix(). Number of sub- or superdiagonals is A.Rows()-1.
+ *        On exit, the triangular factor U or L from the Cholesky factorization
+ *        A = U.T*U or A = L*L.T in the same storage.
+ *
+ *  flags Indicator of which triangle is stored. If flags&UPPER then upper
+ *        triangle is stored and factorization A = U.T*U is computed. If
+ *        flags&LOWER then lower triangle is stored and factorization A = L*L.T
+ *        is computed. Error is returned if flags has neither or both.
+ *
+ * Returns:
+ *  Cholesky factorization and error indicator. Error is returned if leading
+ *  minor of some order is not positive definite.
+ *
+ * Compatible with lapack.DPBTRF
+ */
+func DecomposeCHOLBand(A *matrix.FloatMatrix, flags Flags) (*matrix.FloatMatrix, error) {
+    if ! oneOf(flags, UPPER, LOWER) {
+        return A, flagError("DecomposeCHOLBand", "UPPER or LOWER")
+    }
+    if A.Rows() < 1 {
+        return A, sizeError("DecomposeCHOLBand", A)
+    }
+    err := unblockedCHOLBand(A, flags)
+    return A, err
+}
+
+/*
+ * Solves a system system of linear equations A*X = B with symmetric positive
+ * definite band matrix A using the Cholesky factorization A = U.T*U or A = L*L.T
+ * computed by DecomposeCHOLBand().
+ *
+ * Arguments:
+ *  B   On entry, the right hand side matrix B. On exit, the solution
+ *      matrix X.
+ *
+ *  A   The triangular factor U or L from Cholesky factorization as computed by
+ *      DecomposeCHOLBand().
+ *
+ *  flags Indicator of which factor is stored in A. If flags&UPPER then upper
+ *      triangle of A is stored. If flags&LOWER then lower triangle of A is
+ *      stored.
+ *
+ * Compatible with lapack.DPBTRS.
+ */
+func SolveCHOLBand(B, A *matrix.FloatMatrix, flags Flags) error {
+    var b matrix.FloatMatrix
+    if ! oneOf(flags, UPPER, LOWER) {
+        return flagError("SolveCHOLBand", "UPPER or LOWER")
+    }
+    N := A.Cols()
+    if B.Rows() != N {
+        return sizeError("SolveCHOLBand", B, A)
+    }
+    for k := 0; k < B.Cols(); k++ {
+        B.SubMatrix(&b, 0, k, N, 1)
+        if flags & UPPER != 0 {
+            // X = (U.T*U).-1*B => U.-1*(U.-T*B)
+            MVSolveTrmBand(&b, A, 1.0, UPPER|TRANSA)
+            MVSolveTrmBand(&b, A, 1.0, UPPER)
+        } else {
+            // X = (L*L.T).-1*B = L.-T*(L.1*B)
+            MVSolveTrmBand(&b, A, 1.0, LOWER)
+            MVSolveTrmBand(&b, A, 1.0, LOWER|TRANSA)
+        }
+    }
+    return nil
+}
+
+// Local Variables:
+// tab-width: 4
+// indent-tabs-mode: nil
+// End:
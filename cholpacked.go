@@ -0,0 +1,111 @@
+
+// Copyright (c) Harri Rautila, 2013
+
+// This file is part of github.com/hrautila/matops package. It is free software,
+// distributed under the terms of GNU Lesser General Public License Version 3, or
+// any later version. See the COPYING tile included in this archive.
+
+package matops
+
+import (
+    "github.com/hrautila/matrix"
+    "math"
+)
+
+// Unblocked packed Cholesky factorization. (LAPACK/dpptrf.f)
+func unblockedCHOLPacked(A *PackedMatrix) error {
+    N := A.n
+    Ar := A.data
+    for j := 0; j < N; j++ {
+        k := A.colIndex(j)
+        if A.flags & UPPER != 0 {
+            // compute column j of U: U[0:j,j] = U[0:j,0:j].-T*A[0:j,j]
+            ajj := Ar[k+j]
+            if j > 0 {
+                tpsv(Ar[k:k+j], 1, A.subPacked(j), TRANSA)
+                for i := 0; i < j; i++ {
+                    ajj -= Ar[k+i] * Ar[k+i]
+                }
+            }
+            if ajj <= 0.0 || math.IsNaN(ajj) {
+                return &NotPositiveDefiniteError{j}
+            }
+            Ar[k+j] = math.Sqrt(ajj)
+        } else {
+            // compute L[j,j] and column j of L, update trailing submatrix
+            ajj := Ar[k+j]
+            if ajj <= 0.0 || math.IsNaN(ajj) {
+                return &NotPositiveDefiniteError{j}
+            }
+            ajj = math.Sqrt(ajj)
+            Ar[k+j] = ajj
+            if j < N-1 {
+                for i := j+1; i < N; i++ {
+                    Ar[k+i] /= ajj
+                }
+                spr2(A.subPacked(N-j-1), Ar[k+j+1:k+N], 1, nil, 0, -1.0)
+            }
+        }
+    }
+    return nil
+}
+
+/*
+ * Compute the Cholesky factorization of a symmetric positive definite
+ * N-by-N matrix A in packed storage.
+ *
+ * Arguments:
+ *  A     On entry, the symmetric matrix in packed storage. On exit, the triangular
+ *        factor U or L from the Cholesky factorization A = U.T*U (upper triangle
+ *        stored) or A = L*L.T (lower triangle stored) in packed storage.
+ *
+ * Returns:
+ *  Cholesky factorization and error indicator. Error is returned if leading
+ *  minor of some order is not positive definite.
+ *
+ * Compatible with lapack.DPPTRF
+ */
+func DecomposeCHOLPacked(A *PackedMatrix) (*PackedMatrix, error) {
+    err := unblockedCHOLPacked(A)
+    return A, err
+}
+
+/*
+ * Solves a system system of linear equations A*X = B with symmetric positive
+ * definite matrix A in packed storage using the Cholesky factorization A = U.T*U
+ * or A = L*L.T computed by DecomposeCHOLPacked().
+ *
+ * Arguments:
+ *  B   On entry, the right hand side matrix B. On exit, the solution
+ *      matrix X.
+ *
+ *  A   The triangular factor U or L from Cholesky factorization as computed by
+ *      DecomposeCHOLPacked().
+ *
+ * Compatible with lapack.DPPTRS.
+ */
+func SolveCHOLPacked(B *matrix.FloatMatrix, A *PackedMatrix) error {
+    if B.Rows() != A.n {
+        return sizeError("SolveCHOLPacked", B, shape{A.n, A.n})
+    }
+    Br := B.FloatArray()
+    ldB := B.LeadingIndex()
+    for k := 0; k < B.Cols(); k++ {
+        b := Br[k*ldB:]
+        if A.flags & UPPER != 0 {
+            // X = (U.T*U).-1*B => U.-1*(U.-T*B)
+            tpsv(b, 1, A, TRANSA)
+            tpsv(b, 1, A, NOTRANS)
+        } else {
+            // X = (L*L.T).-1*B = L.-T*(L.1*B)
+            tpsv(b, 1, A, NOTRANS)
+            tpsv(b, 1, A, TRANSA)
+        }
+    }
+    return nil
+}
+
+// Local Variables:
+// tab-width: 4
+// indent-tabs-mode: nil
+// End:
@@ -0,0 +1,308 @@
+// Copyright (c) Harri Rautila, 2013
+
+// This file is part of github.com/hrautila/matops package. It is free software,
+// distributed under the terms of GNU Lesser General Public License Version 3, or
+// any later version. See the COPYING tile included in this archive.
+
+package matops
+
+import (
+    "github.com/hrautila/matrix"
+    "math"
+)
+
+// Element (i, j) of factor L (flags&LOWER) or (j, i) of factor U (flags&UPPER).
+func factorAt(A *matrix.FloatMatrix, i, j int, flags Flags) float64 {
+    if flags & LOWER != 0 {
+        return A.GetAt(i, j)
+    }
+    return A.GetAt(j, i)
+}
+
+/*
+ * Column j below diagonal (LOWER) or row j right of diagonal (UPPER) of A updated
+ * with panel columns k..j-1 of the factor. Previous panels are already applied.
+ *
+ *   c = c - L[j+1:,k:j]*L[j,k:j].T  or  c = c - U[k:j,j].T*U[k:j,j+1:]
+ */
+func updateCHOLPanel(c, A *matrix.FloatMatrix, j, k int, flags Flags) {
+    var Ap, ap matrix.FloatMatrix
+    N := A.Rows()
+    if flags & LOWER != 0 {
+        A.SubMatrix(c, j+1, j, N-j-1, 1)
+        if j > k {
+            A.SubMatrix(&Ap, j+1, k, N-j-1, j-k)
+            A.SubMatrix(&ap, j, k, 1, j-k)
+            MVMult(c, &Ap, &ap, -1.0, 1.0, NOTRANS)
+        }
+    } else {
+        A.SubMatrix(c, j, j+1, 1, N-j-1)
+        if j > k {
+            A.SubMatrix(&Ap, k, j+1, j-k, N-j-1)
+            A.SubMatrix(&ap, k, j, j-k, 1)
+            MVMult(c, &Ap, &ap, -1.0, 1.0, TRANSA)
+        }
+    }
+}
+
+/*
+ * Factor panel columns k..k+nb-1 of A with diagonal pivoting. Updates from the
+ * panel columns are delayed; dots[i] holds sum of squares of the panel part of
+ * row i of the factor. Returns number of factored columns, less than nb if all
+ * remaining diagonal elements are not greater than tol.
+ *
+ * Corresponds lapack.DPSTF2 when k == 0 and nb == N.
+ */
+func panelCHOLPiv(A *matrix.FloatMatrix, piv []int, dots []float64, k, nb int,
+    tol float64, flags Flags) int {
+
+    var c matrix.FloatMatrix
+    N := A.Rows()
+    for i := k; i < N; i++ {
+        dots[i] = 0.0
+    }
+    for j := k; j < k+nb; j++ {
+        // pivot is the largest diagonal element of the trailing matrix
+        p := j
+        ajj := math.Inf(-1)
+        for i := j; i < N; i++ {
+            if j > k {
+                l := factorAt(A, i, j-1, flags)
+                dots[i] += l*l
+            }
+            if d := A.GetAt(i, i) - dots[i]; d > ajj {
+                ajj = d
+                p = i
+            }
+        }
+        if ! (ajj > tol) {
+            return j - k
+        }
+        if p != j {
+            swapSym(A, j, p, flags)
+            dots[j], dots[p] = dots[p], dots[j]
+            piv[j], piv[p] = piv[p], piv[j]
+        }
+        ajj = math.Sqrt(ajj)
+        A.SetAt(j, j, ajj)
+        // a21 = (a21 - A20*a10.T)/a11
+        updateCHOLPanel(&c, A, j, k, flags)
+        InvScale(&c, ajj)
+    }
+    return nb
+}
+
+func blockedCHOLPiv(A *matrix.FloatMatrix, piv []int, tol float64, flags Flags, nb int) int {
+    var ATL, ATR, ABL, ABR matrix.FloatMatrix
+    var A00, A01, A02, A10, A11, A12, A20, A21, A22 matrix.FloatMatrix
+
+    dots := make([]float64, A.Rows())
+    partition2x2(
+        &ATL, &ATR,
+        &ABL, &ABR,   A, 0, 0, pTOPLEFT)
+
+    for ATL.Rows() < A.Rows() && ATL.Cols() < A.Cols() {
+        repartition2x2to3x3(&ATL,
+            &A00, &A01, &A02,
+            &A10, &A11, &A12,
+            &A20, &A21, &A22,   A, nb, pBOTTOMRIGHT)
+
+        // [A11; A21] = chol([A11; A21]) with pivoting over the trailing matrix
+        k := ATL.Rows()
+        if nc := panelCHOLPiv(A, piv, dots, k, A11.Cols(), tol, flags); nc < A11.Cols() {
+            return k + nc
+        }
+
+        if flags & LOWER != 0 {
+            // A22 = A22 - A21*A21.T
+            RankUpdateSym(&A22, &A21, -1.0, 1.0, LOWER)
+        } else {
+            // A22 = A22 - A12.T*A12
+            RankUpdateSym(&A22, &A12, -1.0, 1.0, UPPER|TRANSA)
+        }
+
+        continue3x3to2x2(
+            &ATL, &ATR,
+            &ABL, &ABR,   &A00, &A11, &A22,   A, pBOTTOMRIGHT)
+    }
+    return A.Rows()
+}
+
+/*
+ * Compute the Cholesky factorization with complete (diagonal) pivoting of
+ * a symmetric positive semidefinite N-by-N matrix A.
+ *
+ *   P.T*A*P = U.T*U  or  P.T*A*P = L*L.T
+ *
+ * Arguments:
+ *  A     On entry, the symmetric matrix A stored in upper (flags&UPPER) or
+ *        lower (flags&LOWER) triangular part. On exit, the first rank columns
+ *        of factor L (rows of factor U). Trailing part of the matrix is
+ *        partially updated and should not be used.
+ *
+ *  piv   On exit, the permutation: column k of P is column piv[k] of identity
+ *        matrix, ie. (P.T*A*P)[i,j] == A[piv[i],piv[j]].
+ *
+ *  tol   Stopping tolerance; factorization stops when all remaining diagonal
+ *        elements are not greater than tol. If tol < 0 then N*eps*max(diag(A))
+ *        is used.
+ *
+ *  flags The matrix structure indicator, UPPER or LOWER.
+ *
+ *  nb    The blocking factor for blocked invocations. If nb == 0 or N <= nb
+ *        unblocked algorithm is used.
+ *
+ * Returns:
+ *  Factored matrix, computed rank of A and error indicator.
+ *
+ * Compatible with lapack.DPSTRF
+ */
+func DecomposeCHOLPiv(A *matrix.FloatMatrix, piv []int, tol float64, flags Flags,
+    nb int) (*matrix.FloatMatrix, int, error) {
+
+    N := A.Rows()
+    if A.Cols() != N || len(piv) < N {
+        return A, 0, sizeError("DecomposeCHOLPiv", A, shape{len(piv), 1})
+    }
+    if ! oneOf(flags, UPPER, LOWER) {
+        return A, 0, flagError("DecomposeCHOLPiv", "UPPER or LOWER")
+    }
+    for k := 0; k < N; k++ {
+        piv[k] = k
+    }
+    if N == 0 {
+        return A, 0, nil
+    }
+    if tol < 0.0 {
+        amax := 0.0
+        for k := 0; k < N; k++ {
+            amax = math.Max(amax, A.GetAt(k, k))
+        }
+        tol = float64(N)*math.Pow(2.0, -53.0)*amax
+    }
+    if nb == 0 || N <= nb {
+        return A, panelCHOLPiv(A, piv, make([]float64, N), 0, N, tol, flags), nil
+    }
+    return A, blockedCHOLPiv(A, piv, tol, flags, nb), nil
+}
+
+/*
+ * Factor panel columns k..k+nb-1 of A+E with Gill-Murray choice of diagonal
+ * perturbation E. Updates from the panel columns are delayed.
+ */
+func panelCHOLMod(A, E *matrix.FloatMatrix, k, nb int, beta2, delta float64, flags Flags) {
+    var c matrix.FloatMatrix
+    for j := k; j < k+nb; j++ {
+        cjj := A.GetAt(j, j)
+        for p := k; p < j; p++ {
+            l := factorAt(A, j, p, flags)
+            cjj -= l*l
+        }
+        // c = a21 - A20*a10.T; theta = max |c|
+        updateCHOLPanel(&c, A, j, k, flags)
+        theta := 0.0
+        if c.NumElements() > 0 {
+            theta = math.Abs(AMax(&c))
+        }
+        // d = max(|cjj|, theta^2/beta^2, delta) keeps elements of factor bounded by beta
+        d := math.Max(math.Max(math.Abs(cjj), theta*theta/beta2), delta)
+        E.SetIndex(j, d - cjj)
+        ajj := math.Sqrt(d)
+        A.SetAt(j, j, ajj)
+        InvScale(&c, ajj)
+    }
+}
+
+func blockedCHOLMod(A, E *matrix.FloatMatrix, beta2, delta float64, flags Flags, nb int) {
+    var ATL, ATR, ABL, ABR matrix.FloatMatrix
+    var A00, A01, A02, A10, A11, A12, A20, A21, A22 matrix.FloatMatrix
+
+    partition2x2(
+        &ATL, &ATR,
+        &ABL, &ABR,   A, 0, 0, pTOPLEFT)
+
+    for ATL.Rows() < A.Rows() && ATL.Cols() < A.Cols() {
+        repartition2x2to3x3(&ATL,
+            &A00, &A01, &A02,
+            &A10, &A11, &A12,
+            &A20, &A21, &A22,   A, nb, pBOTTOMRIGHT)
+
+        // [A11; A21] = chol([A11; A21] + E1)
+        panelCHOLMod(A, E, ATL.Rows(), A11.Cols(), beta2, delta, flags)
+
+        if flags & LOWER != 0 {
+            // A22 = A22 - A21*A21.T
+            RankUpdateSym(&A22, &A21, -1.0, 1.0, LOWER)
+        } else {
+            // A22 = A22 - A12.T*A12
+            RankUpdateSym(&A22, &A12, -1.0, 1.0, UPPER|TRANSA)
+        }
+
+        continue3x3to2x2(
+            &ATL, &ATR,
+            &ABL, &ABR,   &A00, &A11, &A22,   A, pBOTTOMRIGHT)
+    }
+}
+
+/*
+ * Compute the modified Cholesky factorization of a symmetric N-by-N matrix A
+ *
+ *   A + E = U.T*U  or  A + E = L*L.T
+ *
+ * where E is non-negative diagonal perturbation that makes A + E positive definite.
+ * E is computed with the Gill-Murray rule that bounds elements of the factor and
+ * the size of E; E is zero if A is sufficiently positive definite.
+ *
+ * Arguments:
+ *  A     On entry, the symmetric matrix A stored in upper (flags&UPPER) or
+ *        lower (flags&LOWER) triangular part. On exit, factor U or L.
+ *
+ *  E     On exit, diagonal of the perturbation E, vector of length N.
+ *
+ *  flags The matrix structure indicator, UPPER or LOWER.
+ *
+ *  nb    The blocking factor for blocked invocations. If nb == 0 or N <= nb
+ *        unblocked algorithm is used.
+ *
+ * Returns:
+ *  Factored matrix and error indicator.
+ *
+ * Reference:
+ *  Gill, Murray and Wright, Practical Optimization, 1981, section 4.4.2.2.
+ */
+func DecomposeCHOLMod(A, E *matrix.FloatMatrix, flags Flags, nb int) (*matrix.FloatMatrix, error) {
+    N := A.Rows()
+    if A.Cols() != N || ! isVector(E) || E.NumElements() != N {
+        return A, sizeError("DecomposeCHOLMod", A, E)
+    }
+    if ! oneOf(flags, UPPER, LOWER) {
+        return A, flagError("DecomposeCHOLMod", "UPPER or LOWER")
+    }
+    if N == 0 {
+        return A, nil
+    }
+    // gamma = max |a_ii|, xi = max |a_ij|, i != j
+    gamma, xi := 0.0, 0.0
+    for j := 0; j < N; j++ {
+        gamma = math.Max(gamma, math.Abs(A.GetAt(j, j)))
+        for i := j+1; i < N; i++ {
+            xi = math.Max(xi, math.Abs(factorAt(A, i, j, flags)))
+        }
+    }
+    eps := math.Pow(2.0, -53.0)
+    nu := math.Max(1.0, math.Sqrt(float64(N*N - 1)))
+    beta2 := math.Max(math.Max(gamma, xi/nu), eps)
+    delta := eps*math.Max(gamma + xi, 1.0)
+
+    if nb == 0 || N <= nb {
+        panelCHOLMod(A, E, 0, N, beta2, delta, flags)
+    } else {
+        blockedCHOLMod(A, E, beta2, delta, flags, nb)
+    }
+    return A, nil
+}
+
+// Local Variables:
+// tab-width: 4
+// indent-tabs-mode: nil
+// End:
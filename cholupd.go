@@ -0,0 +1,241 @@
+// Copyright (c) Harri Rautila, 2013
+
+// This file is part of github.com/hrautila/matops package. It is free software,
+// distributed under the terms of GNU Lesser General Public License Version 3, or
+// any later version. See the COPYING tile included in this archive.
+
+package matops
+
+import (
+    "github.com/hrautila/matrix"
+    "math"
+)
+
+// Part of column k below diagonal of factor L (flags&LOWER) or part of row k
+// right of diagonal of factor U (flags&UPPER).
+func factorVec(v, A *matrix.FloatMatrix, k int, flags Flags) {
+    N := A.Rows()
+    if flags & LOWER != 0 {
+        A.SubMatrix(v, k+1, k, N-k-1, 1)
+    } else {
+        A.SubMatrix(v, k, k+1, 1, N-k-1)
+    }
+}
+
+// Copy of vector x as column vector.
+func columnCopy(x *matrix.FloatMatrix) *matrix.FloatMatrix {
+    w := matrix.FloatZeros(x.NumElements(), 1)
+    for k := 0; k < x.NumElements(); k++ {
+        w.SetIndex(k, x.GetIndex(k))
+    }
+    return w
+}
+
+// Check arguments of factor update op with vectors or matrix X.
+func checkCHOLUpdate(op string, A, X *matrix.FloatMatrix, flags Flags, vector bool) error {
+    if ! oneOf(flags, UPPER, LOWER) {
+        return flagError(op, "UPPER or LOWER")
+    }
+    ok := A.Rows() == A.Cols()
+    if vector {
+        ok = ok && isVector(X) && X.NumElements() == A.Rows()
+    } else {
+        ok = ok && X.Rows() == A.Rows()
+    }
+    if ! ok {
+        return sizeError(op, A, X)
+    }
+    return nil
+}
+
+/*
+ * Factor A of A*A.T + w*w.T with Givens rotations; w is overwritten. Rotation k
+ * in plane of column k of L (row k of U) and w annihilates w[k].
+ *
+ *   r   = sqrt(a11^2 + w1^2), c = a11/r, s = w1/r
+ *   a21 = c*a21 + s*w2
+ *   w2  = c*w2 - s*a21
+ */
+func unblkUpdateCHOL(A, w *matrix.FloatMatrix, flags Flags) {
+    var a, wv matrix.FloatMatrix
+    N := A.Rows()
+    for k := 0; k < N; k++ {
+        c, s, r := computeGivens(A.GetAt(k, k), w.GetAt(k, 0))
+        if r < 0.0 {
+            c, s, r = -c, -s, -r
+        }
+        A.SetAt(k, k, r)
+        factorVec(&a, A, k, flags)
+        w.SubMatrix(&wv, k+1, 0, N-k-1, 1)
+        applyGivens(&a, &wv, c, s)
+    }
+}
+
+/*
+ * Factor A of A*A.T - w*w.T with hyperbolic rotations; w is overwritten.
+ * Returns *NotPositiveDefiniteError if A*A.T - w*w.T is not positive definite.
+ *
+ *   r   = sqrt(a11^2 - w1^2), c = r/a11, s = w1/a11
+ *   a21 = (a21 - s*w2)/c
+ *   w2  = c*w2 - s*a21
+ */
+func unblkDowndateCHOL(A, w *matrix.FloatMatrix, flags Flags) error {
+    var a matrix.FloatMatrix
+    N := A.Rows()
+    for k := 0; k < N; k++ {
+        akk := A.GetAt(k, k)
+        wk := w.GetAt(k, 0)
+        r := (akk - wk)*(akk + wk)
+        if ! (r > 0.0) {
+            return &NotPositiveDefiniteError{k}
+        }
+        r = math.Sqrt(r)
+        c := r/akk
+        s := wk/akk
+        A.SetAt(k, k, r)
+        factorVec(&a, A, k, flags)
+        for i := 0; i < a.NumElements(); i++ {
+            ai := (a.GetIndex(i) - s*w.GetAt(k+1+i, 0))/c
+            a.SetIndex(i, ai)
+            w.SetAt(k+1+i, 0, c*w.GetAt(k+1+i, 0) - s*ai)
+        }
+    }
+    return nil
+}
+
+/*
+ * Index of first leading submatrix of A*A.T - x*x.T that is not positive definite
+ * or -1. Leading submatrix of order k+1 is positive definite if ||p[0:k+1]|| < 1
+ * where A*p = x.
+ */
+func downdateColumn(A, x *matrix.FloatMatrix, flags Flags) int {
+    p := columnCopy(x)
+    if flags & LOWER != 0 {
+        MVSolveTrm(p, A, 1.0, LOWER)
+    } else {
+        MVSolveTrm(p, A, 1.0, UPPER|TRANSA)
+    }
+    nrm := 0.0
+    for k := 0; k < p.NumElements(); k++ {
+        nrm += p.GetAt(k, 0)*p.GetAt(k, 0)
+        if ! (nrm < 1.0) {
+            return k
+        }
+    }
+    return -1
+}
+
+/*
+ * Update Cholesky factorization A = L*L.T or A = U.T*U with rank one modification
+ *
+ *   L*L.T + x*x.T  or  U.T*U + x*x.T
+ *
+ * in O(N^2) operations.
+ *
+ * Arguments:
+ *  A     On entry, factor L or U as computed by DecomposeCHOL(). On exit, the
+ *        factor of the updated matrix.
+ *
+ *  x     Row or column vector of length N. Not changed.
+ *
+ *  flags Indicator of which factor is stored in A, UPPER or LOWER.
+ *
+ * Returns:
+ *  Error indicator.
+ */
+func UpdateCHOL(A, x *matrix.FloatMatrix, flags Flags) error {
+    if err := checkCHOLUpdate("UpdateCHOL", A, x, flags, true); err != nil {
+        return err
+    }
+    unblkUpdateCHOL(A, columnCopy(x), flags)
+    return nil
+}
+
+/*
+ * Downdate Cholesky factorization A = L*L.T or A = U.T*U with rank one modification
+ *
+ *   L*L.T - x*x.T  or  U.T*U - x*x.T
+ *
+ * in O(N^2) operations.
+ *
+ * Arguments:
+ *  A     On entry, factor L or U as computed by DecomposeCHOL(). On exit, the
+ *        factor of the downdated matrix.
+ *
+ *  x     Row or column vector of length N. Not changed.
+ *
+ *  flags Indicator of which factor is stored in A, UPPER or LOWER.
+ *
+ * Returns:
+ *  Error indicator. If downdated matrix is not positive definite error is
+ *  *NotPositiveDefiniteError with the failing column and A is not changed.
+ */
+func DowndateCHOL(A, x *matrix.FloatMatrix, flags Flags) error {
+    if err := checkCHOLUpdate("DowndateCHOL", A, x, flags, true); err != nil {
+        return err
+    }
+    if k := downdateColumn(A, x, flags); k >= 0 {
+        return &NotPositiveDefiniteError{k}
+    }
+    // rotations may still fail on rounding when downdated matrix is nearly singular
+    A0 := A.Copy()
+    if err := unblkDowndateCHOL(A, columnCopy(x), flags); err != nil {
+        A0.CopyTo(A)
+        return err
+    }
+    return nil
+}
+
+/*
+ * Update Cholesky factorization A = L*L.T or A = U.T*U with rank K modification
+ *
+ *   L*L.T + X*X.T  or  U.T*U + X*X.T
+ *
+ * where X is N-by-K matrix. See UpdateCHOL().
+ */
+func RankUpdateCHOL(A, X *matrix.FloatMatrix, flags Flags) error {
+    var x matrix.FloatMatrix
+    if err := checkCHOLUpdate("RankUpdateCHOL", A, X, flags, false); err != nil {
+        return err
+    }
+    for j := 0; j < X.Cols(); j++ {
+        X.SubMatrix(&x, 0, j, X.Rows(), 1)
+        unblkUpdateCHOL(A, columnCopy(&x), flags)
+    }
+    return nil
+}
+
+/*
+ * Downdate Cholesky factorization A = L*L.T or A = U.T*U with rank K modification
+ *
+ *   L*L.T - X*X.T  or  U.T*U - X*X.T
+ *
+ * where X is N-by-K matrix. If downdated matrix is not positive definite error
+ * is *NotPositiveDefiniteError and A is not changed. See DowndateCHOL().
+ */
+func RankDowndateCHOL(A, X *matrix.FloatMatrix, flags Flags) error {
+    var x matrix.FloatMatrix
+    if err := checkCHOLUpdate("RankDowndateCHOL", A, X, flags, false); err != nil {
+        return err
+    }
+    A0 := A.Copy()
+    for j := 0; j < X.Cols(); j++ {
+        X.SubMatrix(&x, 0, j, X.Rows(), 1)
+        var err error
+        if k := downdateColumn(A, &x, flags); k >= 0 {
+            err = &NotPositiveDefiniteError{k}
+        } else {
+            err = unblkDowndateCHOL(A, columnCopy(&x), flags)
+        }
+        if err != nil {
+            A0.CopyTo(A)
+            return err
+        }
+    }
+    return nil
+}
+
+// Local Variables:
+// tab-width: 4
+// indent-tabs-mode: nil
+// End:
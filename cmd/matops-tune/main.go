@@ -0,0 +1,80 @@
+
+// Copyright (c) Harri Rautila, 2013
+
+// This file is part of github.com/hrautila/matops package. It is free software,
+// distributed under the terms of GNU Lesser General Public License Version 3, or
+// any later version. See the COPYING tile included in this archive.
+
+// Command matops-tune finds block sizes for the current machine and writes them
+// to the profile read by matops.LoadProfile().
+//
+//   matops-tune [-N size] [-o file] [-n] [-v]
+//
+// Profile file is given with -o or by MATOPS_PROFILE environment variable; one
+// of them is required unless -n is set. Package matops applies the profile at
+// startup only from MATOPS_PROFILE, so set it to the written file, or call
+// matops.LoadProfile(file) in the program.
+package main
+
+import (
+    "github.com/hrautila/matops"
+    "encoding/json"
+    "flag"
+    "fmt"
+    "io"
+    "os"
+)
+
+var N int
+var output string
+var dryRun bool
+var verbose bool
+
+func init() {
+    flag.IntVar(&N, "N", 600, "Size of test matrices.")
+    flag.StringVar(&output, "o", "", "Profile file, default from MATOPS_PROFILE.")
+    flag.BoolVar(&dryRun, "n", false, "Print profile, do not write it.")
+    flag.BoolVar(&verbose, "v", false, "Print timings of candidates.")
+}
+
+func main() {
+    flag.Parse()
+
+    path := output
+    if path == "" {
+        path = os.Getenv(matops.ProfileEnv)
+    }
+    if path == "" && ! dryRun {
+        fmt.Fprintf(os.Stderr, "matops-tune: profile file not given; use -o file or set %s\n",
+            matops.ProfileEnv)
+        os.Exit(2)
+    }
+    var w io.Writer
+    if verbose {
+        w = os.Stderr
+    }
+    p, err := matops.Autotune(N, w)
+    if err != nil {
+        fmt.Fprintf(os.Stderr, "matops-tune: %v\n", err)
+        os.Exit(1)
+    }
+    if dryRun {
+        buf, _ := json.MarshalIndent(p, "", "  ")
+        fmt.Printf("%s\n", buf)
+        return
+    }
+    if err = p.Save(path); err != nil {
+        fmt.Fprintf(os.Stderr, "matops-tune: %v\n", err)
+        os.Exit(1)
+    }
+    fmt.Printf("MB=%d NB=%d KB=%d DecompNB=%d written to %s\n",
+        p.MB, p.NB, p.KB, p.DecompNB, path)
+    if os.Getenv(matops.ProfileEnv) != path {
+        fmt.Printf("set %s=%s to apply it at startup\n", matops.ProfileEnv, path)
+    }
+}
+
+// Local Variables:
+// tab-width: 4
+// indent-tabs-mode: nil
+// End:
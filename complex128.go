@@ -0,0 +1,171 @@
+// Copyright (c) Harri Rautila, 2013
+
+// This file is part of github.com/hrautila/matops package. It is free software,
+// distributed under the terms of GNU Lesser General Public License Version 3, or
+// any later version. See the COPYING tile included in this archive.
+
+package matops
+
+import (
+    "github.com/hrautila/matrix"
+    "fmt"
+)
+
+// Double precision complex column-major matrix. Element (i, j) is at index
+// i + j*LeadingIndex() of Complex128Array(). Submatrices share elements with their
+// parent matrix as with matrix.FloatMatrix.
+type Complex128Matrix struct {
+    elements []complex128
+    offset int
+    rows, cols, step int
+}
+
+// Create new rows*cols matrix with elements copied from column-major array elems.
+// If elems is nil or shorter than rows*cols remaining elements are zero.
+func Complex128New(rows, cols int, elems []complex128) *Complex128Matrix {
+    A := Complex128Zeros(rows, cols)
+    copy(A.elements, elems)
+    return A
+}
+
+// Create new rows*cols matrix of zeros.
+func Complex128Zeros(rows, cols int) *Complex128Matrix {
+    return &Complex128Matrix{make([]complex128, rows*cols), 0, rows, cols, imax(1, rows)}
+}
+
+// Create complex matrix with real parts from Re and imaginary parts from Im.
+// If Im is nil imaginary parts are zero.
+func Complex128FromFloat(Re, Im *matrix.FloatMatrix) *Complex128Matrix {
+    B := Complex128Zeros(Re.Rows(), Re.Cols())
+    for j := 0; j < Re.Cols(); j++ {
+        for i := 0; i < Re.Rows(); i++ {
+            var im float64
+            if Im != nil {
+                im = Im.GetAt(i, j)
+            }
+            B.elements[i + j*B.step] = complex(Re.GetAt(i, j), im)
+        }
+    }
+    return B
+}
+
+// Real parts of A.
+func (A *Complex128Matrix) Real() *matrix.FloatMatrix {
+    B := matrix.FloatZeros(A.rows, A.cols)
+    for j := 0; j < A.cols; j++ {
+        for i := 0; i < A.rows; i++ {
+            B.SetAt(i, j, real(A.GetAt(i, j)))
+        }
+    }
+    return B
+}
+
+// Imaginary parts of A.
+func (A *Complex128Matrix) Imag() *matrix.FloatMatrix {
+    B := matrix.FloatZeros(A.rows, A.cols)
+    for j := 0; j < A.cols; j++ {
+        for i := 0; i < A.rows; i++ {
+            B.SetAt(i, j, imag(A.GetAt(i, j)))
+        }
+    }
+    return B
+}
+
+func (A *Complex128Matrix) Rows() int {
+    return A.rows
+}
+
+func (A *Complex128Matrix) Cols() int {
+    return A.cols
+}
+
+func (A *Complex128Matrix) Size() (int, int) {
+    return A.rows, A.cols
+}
+
+func (A *Complex128Matrix) NumElements() int {
+    return A.rows*A.cols
+}
+
+// Distance between elements (i, j) and (i, j+1) in element array.
+func (A *Complex128Matrix) LeadingIndex() int {
+    return A.step
+}
+
+// Element array starting at element (0, 0); nil for empty matrix past the end
+// of parent matrix.
+func (A *Complex128Matrix) Complex128Array() []complex128 {
+    if A.offset >= len(A.elements) {
+        return nil
+    }
+    return A.elements[A.offset:]
+}
+
+func (A *Complex128Matrix) index(i, j int) int {
+    if i < 0 || i >= A.rows || j < 0 || j >= A.cols {
+        panic(fmt.Sprintf("index (%d,%d) out of bounds [%d,%d]", i, j, A.rows, A.cols))
+    }
+    return A.offset + i + j*A.step
+}
+
+func (A *Complex128Matrix) GetAt(i, j int) complex128 {
+    return A.elements[A.index(i, j)]
+}
+
+func (A *Complex128Matrix) SetAt(i, j int, val complex128) {
+    A.elements[A.index(i, j)] = val
+}
+
+// Value of element (0, 0).
+func (A *Complex128Matrix) Complex() complex128 {
+    return A.GetAt(0, 0)
+}
+
+// Copy of A with leading index A.Rows().
+func (A *Complex128Matrix) Copy() *Complex128Matrix {
+    B := Complex128Zeros(A.rows, A.cols)
+    for j := 0; j < A.cols; j++ {
+        for i := 0; i < A.rows; i++ {
+            B.elements[i + j*B.step] = A.GetAt(i, j)
+        }
+    }
+    return B
+}
+
+// Make B submatrix of A starting at (row, col). Optional sizes are rows and cols
+// of the submatrix; default is the rest of A. If B is nil new matrix is allocated.
+func (A *Complex128Matrix) SubMatrix(B *Complex128Matrix, row, col int, sizes ...int) *Complex128Matrix {
+    if B == nil {
+        B = new(Complex128Matrix)
+    }
+    nr, nc := A.rows - row, A.cols - col
+    if len(sizes) >= 2 {
+        nr, nc = sizes[0], sizes[1]
+    }
+    if row < 0 || col < 0 || nr < 0 || nc < 0 || row+nr > A.rows || col+nc > A.cols {
+        panic(fmt.Sprintf("submatrix [%d:%d, %d:%d] out of bounds [%d,%d]",
+            row, row+nr, col, col+nc, A.rows, A.cols))
+    }
+    *B = Complex128Matrix{A.elements, A.offset + row + col*A.step, nr, nc, A.step}
+    return B
+}
+
+func (A *Complex128Matrix) String() string {
+    s := ""
+    for i := 0; i < A.rows; i++ {
+        s += "["
+        for j := 0; j < A.cols; j++ {
+            if j > 0 {
+                s += ", "
+            }
+            s += fmt.Sprintf("%9.2e", A.GetAt(i, j))
+        }
+        s += "]\n"
+    }
+    return s
+}
+
+// Local Variables:
+// tab-width: 4
+// indent-tabs-mode: nil
+// End:
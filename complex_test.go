@@ -0,0 +1,275 @@
+
+// Copyright (c) Harri Rautila, 2013
+
+// This file is part of github.com/hrautila/matops package. It is free software,
+// distributed under the terms of GNU Lesser General Public License Version 3, or
+// any later version. See the COPYING tile included in this archive.
+
+package matops
+
+import (
+    "github.com/hrautila/matrix"
+    "testing"
+    "math/cmplx"
+)
+
+func zNormal(M, N int) *Complex128Matrix {
+    return Complex128FromFloat(matrix.FloatNormal(M, N), matrix.FloatNormal(M, N))
+}
+
+// max |A - B|
+func zDiff(A, B *Complex128Matrix) float64 {
+    var d float64
+    for j := 0; j < A.Cols(); j++ {
+        for i := 0; i < A.Rows(); i++ {
+            if a := cmplx.Abs(A.GetAt(i, j) - B.GetAt(i, j)); a > d {
+                d = a
+            }
+        }
+    }
+    return d
+}
+
+// op(A)(i, j); op(A) is A.H if trans
+func zOpAt(A *Complex128Matrix, trans bool, i, j int) complex128 {
+    if trans {
+        return cmplx.Conj(A.GetAt(j, i))
+    }
+    return A.GetAt(i, j)
+}
+
+// upper or lower triangular part of A
+func zTriangle(A *Complex128Matrix, flags Flags) *Complex128Matrix {
+    T := Complex128Zeros(A.Rows(), A.Cols())
+    for j := 0; j < A.Cols(); j++ {
+        for i := 0; i < A.Rows(); i++ {
+            if (flags & UPPER != 0 && i <= j) || (flags & LOWER != 0 && i >= j) {
+                T.SetAt(i, j, A.GetAt(i, j))
+            }
+        }
+    }
+    return T
+}
+
+func TestComplexMult(t *testing.T) {
+    M, N, P := 31, 27, 19
+    alpha, beta := complex(1.5, -0.5), complex(0.5, 2.0)
+    for _, flags := range []Flags{NOTRANS, TRANSA, TRANSB, TRANSA|TRANSB} {
+        A := zNormal(M, P)
+        if flags & TRANSA != 0 {
+            A = zNormal(P, M)
+        }
+        B := zNormal(P, N)
+        if flags & TRANSB != 0 {
+            B = zNormal(N, P)
+        }
+        C := zNormal(M, N)
+        C0 := C.Copy()
+        ZMult(C, A, B, alpha, beta, flags)
+        for j := 0; j < N; j++ {
+            for i := 0; i < M; i++ {
+                var s complex128
+                for k := 0; k < P; k++ {
+                    s += zOpAt(A, flags & TRANSA != 0, i, k)*zOpAt(B, flags & TRANSB != 0, k, j)
+                }
+                C0.SetAt(i, j, beta*C0.GetAt(i, j) + alpha*s)
+            }
+        }
+        if d := zDiff(C, C0); d > 1e-12 {
+            t.Errorf("ZMult flags %x: max difference %e\n", flags, d)
+        }
+        if err := ZMult(C, B, B, alpha, beta, flags); err == nil {
+            t.Errorf("ZMult: size mismatch not detected")
+        }
+    }
+
+    for _, flags := range []Flags{NOTRANS, TRANSA} {
+        A := zNormal(M, N)
+        X := zNormal(N, 1)
+        Y := zNormal(M, 1)
+        if flags & TRANSA != 0 {
+            X = zNormal(M, 1)
+            Y = zNormal(N, 1)
+        }
+        Y0 := Y.Copy()
+        ZMVMult(Y, A, X, alpha, beta, flags)
+        ZMult(Y0, A, X, alpha, beta, flags)
+        if d := zDiff(Y, Y0); d > 1e-12 {
+            t.Errorf("ZMVMult flags %x: max difference %e\n", flags, d)
+        }
+    }
+}
+
+func TestComplexSolveTrm(t *testing.T) {
+    N, K := 41, 13
+    alpha := complex(2.0, -1.0)
+    for _, uplo := range []Flags{LOWER, UPPER} {
+        A := zTriangle(zNormal(N, N), uplo)
+        for k := 0; k < N; k++ {
+            A.SetAt(k, k, complex(4.0, 1.0))
+        }
+        for _, flags := range []Flags{LEFT, LEFT|TRANSA, RIGHT, RIGHT|TRANSA} {
+            B := zNormal(N, K)
+            if flags & RIGHT != 0 {
+                B = zNormal(K, N)
+            }
+            X := B.Copy()
+            ZSolveTrm(X, A, alpha, flags|uplo)
+            // op(A)*X - alpha*B or X*op(A) - alpha*B
+            if flags & RIGHT != 0 {
+                mflags := Flags(NOTRANS)
+                if flags & TRANSA != 0 {
+                    mflags = TRANSB
+                }
+                ZMult(B, X, A, 1.0, -alpha, mflags)
+            } else {
+                ZMult(B, A, X, 1.0, -alpha, flags & TRANSA)
+            }
+            if d := zDiff(B, Complex128Zeros(B.Rows(), B.Cols())); d > 1e-12 {
+                t.Errorf("ZSolveTrm flags %x: max residual %e\n", flags|uplo, d)
+            }
+        }
+    }
+}
+
+func TestComplexRankUpdateHerm(t *testing.T) {
+    N, P := 45, 23
+    // serial and parallel invocation
+    nw := NumWorkers(4)
+    defer NumWorkers(nw)
+    lim := limitOne
+    defer func() { limitOne = lim }()
+    for k, flags := range []Flags{LOWER, UPPER, LOWER|TRANSA, UPPER|TRANSA,
+        LOWER, UPPER, LOWER|TRANSA, UPPER|TRANSA} {
+        if k == 4 {
+            limitOne = 1
+        }
+        A := zNormal(N, P)
+        mflags := Flags(TRANSB)
+        if flags & TRANSA != 0 {
+            A = zNormal(P, N)
+            mflags = TRANSA
+        }
+        C := zNormal(N, N)
+        C0 := C.Copy()
+        ZRankUpdateHerm(C, A, 2.0, 0.5, flags)
+        ZMult(C0, A, A, 2.0, 0.5, mflags)
+        for k := 0; k < N; k++ {
+            C0.SetAt(k, k, complex(real(C0.GetAt(k, k)), 0.0))
+        }
+        if d := zDiff(zTriangle(C, flags), zTriangle(C0, flags)); d > 1e-12 {
+            t.Errorf("ZRankUpdateHerm flags %x: max difference %e\n", flags, d)
+        }
+    }
+}
+
+func TestComplexCHOL(t *testing.T) {
+    N := 60
+    Z := zNormal(N, N)
+    A := Complex128Zeros(N, N)
+    ZMult(A, Z, Z, 1.0, 0.0, TRANSB)
+    for k := 0; k < N; k++ {
+        A.SetAt(k, k, A.GetAt(k, k) + complex(1.0, 0.0))
+    }
+    for _, flags := range []Flags{LOWER, UPPER} {
+        for _, nb := range []int{0, 16} {
+            R, err := ZDecomposeCHOL(A.Copy(), flags, nb)
+            if err != nil {
+                t.Fatalf("ZDecomposeCHOL: %v\n", err)
+            }
+            // A - L*L.H or A - U.H*U
+            R = zTriangle(R, flags)
+            trans := Flags(TRANSB)
+            if flags & UPPER != 0 {
+                trans = TRANSA
+            }
+            C := A.Copy()
+            ZMult(C, R, R, -1.0, 1.0, trans)
+            d := zDiff(zTriangle(C, flags), Complex128Zeros(N, N))
+            t.Logf("flags %x nb %d: max |A - chol(A)|: %e\n", flags, nb, d)
+            if d > 1e-10 {
+                t.Errorf("ZDecomposeCHOL: residual too large")
+            }
+        }
+    }
+    A.SetAt(3, 3, -1.0)
+    if _, err := ZDecomposeCHOL(A, LOWER, 0); err == nil {
+        t.Errorf("ZDecomposeCHOL: indefinite matrix not detected")
+    }
+}
+
+func TestComplexLU(t *testing.T) {
+    M, N := 60, 50
+    A := zNormal(M, N)
+    pivots := make([]int, N)
+    for _, nb := range []int{0, 16} {
+        LU, _ := ZDecomposeLU(A.Copy(), pivots, nb)
+        // P*A - L*U
+        L := zTriangle(LU, LOWER)
+        for k := 0; k < N; k++ {
+            L.SetAt(k, k, 1.0)
+        }
+        var U Complex128Matrix
+        zTriangle(LU, UPPER).SubMatrix(&U, 0, 0, N, N)
+        C := A.Copy()
+        zApplyPivots(C, &pPivots{pivots})
+        ZMult(C, L, &U, -1.0, 1.0, NOTRANS)
+        d := zDiff(C, Complex128Zeros(M, N))
+        t.Logf("nb %d: max |P*A - L*U|: %e\n", nb, d)
+        if d > 1e-10 {
+            t.Errorf("ZDecomposeLU: residual too large")
+        }
+    }
+}
+
+func TestComplexQR(t *testing.T) {
+    M, N := 40, 30
+    A := zNormal(M, N)
+    for _, nb := range []int{0, 8} {
+        tau := Complex128Zeros(N, 1)
+        QR, err := ZDecomposeQR(A.Copy(), tau, nil, nb)
+        if err != nil {
+            t.Fatalf("ZDecomposeQR: %v\n", err)
+        }
+        // Q = H(0)*H(1)*...*H(N-1)*I; H(k) = I - tau[k]*v*v.H
+        Q := Complex128Zeros(M, M)
+        for k := 0; k < M; k++ {
+            Q.SetAt(k, k, 1.0)
+        }
+        v := Complex128Zeros(M, 1)
+        w := Complex128Zeros(M, 1)
+        for k := N-1; k >= 0; k-- {
+            for i := 0; i < M; i++ {
+                switch {
+                case i < k:
+                    v.SetAt(i, 0, 0.0)
+                case i == k:
+                    v.SetAt(i, 0, 1.0)
+                default:
+                    v.SetAt(i, 0, QR.GetAt(i, k))
+                }
+            }
+            // Q = Q - tau*v*(Q.H*v).H
+            ZMVMult(w, Q, v, 1.0, 0.0, TRANSA)
+            zMVRankUpdate(Q, v, w, -tau.GetAt(k, 0))
+        }
+        R := zTriangle(QR, UPPER)
+        C := A.Copy()
+        ZMult(C, Q, R, -1.0, 1.0, NOTRANS)
+        d := zDiff(C, Complex128Zeros(M, N))
+        t.Logf("nb %d: max |A - Q*R|: %e\n", nb, d)
+        if d > 1e-10 {
+            t.Errorf("ZDecomposeQR: residual too large")
+        }
+        for k := 0; k < N; k++ {
+            if imag(QR.GetAt(k, k)) != 0.0 {
+                t.Errorf("ZDecomposeQR: R[%d,%d] not real", k, k)
+            }
+        }
+    }
+}
+
+// Local Variables:
+// tab-width: 4
+// indent-tabs-mode: nil
+// End:
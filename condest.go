@@ -0,0 +1,286 @@
+
+// Copyright (c) Harri Rautila, 2013
+
+// This file is part of github.com/hrautila/matops package. It is free software,
+// distributed under the terms of GNU Lesser General Public License Version 3, or
+// any later version. See the COPYING tile included in this archive.
+
+package matops
+
+import (
+    "github.com/hrautila/matrix"
+    "math"
+    //"fmt"
+)
+
+// Maximum number of iterations in 1-norm estimation.
+const estITMAX = 5
+
+// Returns +1.0 if x >= 0.0 otherwise -1.0
+func signOf(x float64) float64 {
+    if x >= 0.0 {
+        return 1.0
+    }
+    return -1.0
+}
+
+/*
+ * Estimate the 1-norm of N-by-N matrix B using Hager's method with Higham's
+ * modifications. (LAPACK/dlacn2.f)
+ *
+ * Matrix B is accessed only through matrix-vector products: function mult(x)
+ * overwrites column vector x with B*x and multT(x) with B.T*x.
+ *
+ * Reference:
+ *  N.J. Higham, "FORTRAN codes for estimating the one-norm of a real or complex
+ *  matrix, with applications to condition estimation", ACM Trans. Math. Soft.,
+ *  vol. 14, no. 4, pp. 381-396, December 1988.
+ */
+func estimateNorm1(N int, mult, multT func(x *matrix.FloatMatrix)) float64 {
+    if N == 0 {
+        return 0.0
+    }
+    x := matrix.FloatWithValue(N, 1, 1.0/float64(N))
+    isgn := make([]float64, N)
+
+    mult(x)
+    if N == 1 {
+        return math.Abs(x.GetAt(0, 0))
+    }
+    est := ASum(x)
+    for i := 0; i < N; i++ {
+        isgn[i] = signOf(x.GetAt(i, 0))
+        x.SetAt(i, 0, isgn[i])
+    }
+    multT(x)
+    j := IAMax(x)
+
+    for iter := 2; ; iter++ {
+        // x = e_j; x = B*x
+        x.Scale(0.0)
+        x.SetAt(j, 0, 1.0)
+        mult(x)
+        estold := est
+        est = ASum(x)
+
+        // repeated sign vector detected, converged
+        converged := true
+        for i := 0; i < N; i++ {
+            if signOf(x.GetAt(i, 0)) != isgn[i] {
+                converged = false
+                break
+            }
+        }
+        if converged || est <= estold {
+            if est < estold {
+                est = estold
+            }
+            break
+        }
+        for i := 0; i < N; i++ {
+            isgn[i] = signOf(x.GetAt(i, 0))
+            x.SetAt(i, 0, isgn[i])
+        }
+        multT(x)
+        jlast := j
+        j = IAMax(x)
+        if x.GetAt(jlast, 0) == math.Abs(x.GetAt(j, 0)) || iter >= estITMAX {
+            break
+        }
+    }
+
+    // alternating sign vector
+    altsgn := 1.0
+    for i := 0; i < N; i++ {
+        x.SetAt(i, 0, altsgn*(1.0 + float64(i)/float64(N-1)))
+        altsgn = -altsgn
+    }
+    mult(x)
+    temp := 2.0*ASum(x)/float64(3*N)
+    if temp > est {
+        est = temp
+    }
+    return est
+}
+
+// Compute reciprocal condition number from norm and estimated inverse norm.
+func reciprocalCond(anorm, ainvnm float64) float64 {
+    if ainvnm == 0.0 || math.IsNaN(ainvnm) || math.IsInf(ainvnm, 0) {
+        return 0.0
+    }
+    return (1.0/ainvnm)/anorm
+}
+
+/*
+ * Estimate the reciprocal of the condition number of general matrix A in 1-norm
+ * or infinity-norm using the LU factorization computed by DecomposeLU().
+ *
+ *   rcond = 1.0/(||A|| * ||A.-1||)
+ *
+ * Arguments:
+ *  A       The factors L and U from factorization A = P*L*U as computed by
+ *          DecomposeLU().
+ *
+ *  pivots  The pivot indices from DecomposeLU().
+ *
+ *  anorm   The norm of the original matrix A, NormP(A, norm).
+ *
+ *  norm    The norm to use, NORM_ONE or NORM_INF.
+ *
+ * Returns:
+ *  Estimated reciprocal condition number and error indicator. Value of zero
+ *  indicates exactly singular matrix.
+ *
+ * Compatible with lapack.DGECON
+ */
+func EstimateCondLU(A *matrix.FloatMatrix, pivots []int, anorm float64, norm Norms) (float64, error) {
+    if A.Rows() != A.Cols() {
+        return 0.0, sizeError("EstimateCondLU", A)
+    }
+    if norm != NORM_ONE && norm != NORM_INF {
+        return 0.0, onError("EstimateCondLU: unsupported norm")
+    }
+    if anorm < 0.0 {
+        return 0.0, onError("EstimateCondLU: anorm negative")
+    }
+    N := A.Rows()
+    if len(pivots) < N {
+        return 0.0, sizeError("EstimateCondLU", A, shape{len(pivots), 1})
+    }
+    if N == 0 {
+        return 1.0, nil
+    }
+    if anorm == 0.0 {
+        return 0.0, nil
+    }
+    // singular if U has zero on diagonal
+    for k := 0; k < N; k++ {
+        if A.GetAt(k, k) == 0.0 {
+            return 0.0, nil
+        }
+    }
+    var err error
+    solve := func(x *matrix.FloatMatrix) {
+        if e := SolveLU(x, A, pivots, NOTRANS); e != nil && err == nil {
+            err = e
+        }
+    }
+    solveT := func(x *matrix.FloatMatrix) {
+        if e := SolveLU(x, A, pivots, TRANSA); e != nil && err == nil {
+            err = e
+        }
+    }
+    var ainvnm float64
+    if norm == NORM_ONE {
+        ainvnm = estimateNorm1(N, solve, solveT)
+    } else {
+        // ||A.-1||_inf == ||A.-T||_1
+        ainvnm = estimateNorm1(N, solveT, solve)
+    }
+    if err != nil {
+        return 0.0, err
+    }
+    return reciprocalCond(anorm, ainvnm), nil
+}
+
+/*
+ * Estimate the reciprocal of the condition number in 1-norm of symmetric positive
+ * definite matrix A using the Cholesky factorization computed by DecomposeCHOL().
+ *
+ * Arguments:
+ *  A       The triangular factor U or L from Cholesky factorization as computed
+ *          by DecomposeCHOL().
+ *
+ *  anorm   The 1-norm of the original symmetric matrix A.
+ *
+ *  flags   Indicator of which factor is stored in A, UPPER or LOWER.
+ *
+ * Returns:
+ *  Estimated reciprocal condition number and error indicator.
+ *
+ * Compatible with lapack.DPOCON
+ */
+func EstimateCondCHOL(A *matrix.FloatMatrix, anorm float64, flags Flags) (float64, error) {
+    if A.Rows() != A.Cols() {
+        return 0.0, sizeError("EstimateCondCHOL", A)
+    }
+    if anorm < 0.0 {
+        return 0.0, onError("EstimateCondCHOL: anorm negative")
+    }
+    N := A.Rows()
+    if N == 0 {
+        return 1.0, nil
+    }
+    if anorm == 0.0 {
+        return 0.0, nil
+    }
+    var err error
+    solve := func(x *matrix.FloatMatrix) {
+        if e := SolveCHOLChecked(x, A, flags); e != nil && err == nil {
+            err = e
+        }
+    }
+    // A.-1 is symmetric
+    ainvnm := estimateNorm1(N, solve, solve)
+    if err != nil {
+        return 0.0, err
+    }
+    return reciprocalCond(anorm, ainvnm), nil
+}
+
+/*
+ * Estimate the reciprocal of the condition number in 1-norm of symmetric matrix
+ * A using the factorization A = L*D*L.T or A = U*D*U.T computed by DecomposeLDL().
+ *
+ * Arguments:
+ *  A       The factor L or U and diagonal D as computed by DecomposeLDL().
+ *
+ *  ipiv    The pivot indices from DecomposeLDL().
+ *
+ *  anorm   The 1-norm of the original symmetric matrix A.
+ *
+ *  flags   Indicator of which factor is stored in A, UPPER or LOWER.
+ *
+ * Returns:
+ *  Estimated reciprocal condition number and error indicator. Value of zero
+ *  indicates exactly singular matrix.
+ *
+ * Compatible with lapack.DSYCON
+ */
+func EstimateCondLDL(A *matrix.FloatMatrix, ipiv []int, anorm float64, flags Flags) (float64, error) {
+    if A.Rows() != A.Cols() {
+        return 0.0, sizeError("EstimateCondLDL", A)
+    }
+    if anorm < 0.0 {
+        return 0.0, onError("EstimateCondLDL: anorm negative")
+    }
+    N := A.Rows()
+    if N == 0 {
+        return 1.0, nil
+    }
+    if anorm == 0.0 {
+        return 0.0, nil
+    }
+    // singular if D has zero on diagonal
+    for k := 0; k < N; k++ {
+        if A.GetAt(k, k) == 0.0 {
+            return 0.0, nil
+        }
+    }
+    var err error
+    solve := func(x *matrix.FloatMatrix) {
+        if e := SolveLDLChecked(x, A, ipiv, flags); e != nil && err == nil {
+            err = e
+        }
+    }
+    ainvnm := estimateNorm1(N, solve, solve)
+    if err != nil {
+        return 0.0, err
+    }
+    return reciprocalCond(anorm, ainvnm), nil
+}
+
+// Local Variables:
+// tab-width: 4
+// indent-tabs-mode: nil
+// End:
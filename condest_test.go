@@ -0,0 +1,92 @@
+
+// Copyright (c) Harri Rautila, 2013
+
+// This file is part of github.com/hrautila/matops package. It is free software,
+// distributed under the terms of GNU Lesser General Public License Version 3, or
+// any later version. See the COPYING tile included in this archive.
+
+package matops
+
+import (
+    "github.com/hrautila/matrix"
+    "errors"
+    "testing"
+)
+
+// Estimate is a lower bound of ||A.-1|| and rarely more than a factor 3 below it.
+func checkRcond(t *testing.T, name string, rcond, exact float64) {
+    if rcond < exact*(1.0 - 1e-8) || rcond > 10.0*exact {
+        t.Errorf("%s: rcond %e not within a factor 10 of exact %e\n", name, rcond, exact)
+    }
+}
+
+func TestEstimateCondLU(t *testing.T) {
+    N := 60
+    nb := 12
+    A := matrix.FloatUniform(N, N)
+    piv := make([]int, N, N)
+    LU, _ := DecomposeLU(A.Copy(), piv, nb)
+
+    // exact A.-1
+    Ainv := matrix.FloatDiagonal(N, 1.0)
+    SolveLU(Ainv, LU, piv, NOTRANS)
+
+    anorm := NormP(A, NORM_ONE)
+    rcond, _ := EstimateCondLU(LU, piv, anorm, NORM_ONE)
+    exact := 1.0/(anorm*NormP(Ainv, NORM_ONE))
+    t.Logf("1-norm:   rcond: %e, exact: %e\n", rcond, exact)
+    checkRcond(t, "EstimateCondLU 1-norm", rcond, exact)
+
+    anorm = NormP(A, NORM_INF)
+    rcond, _ = EstimateCondLU(LU, piv, anorm, NORM_INF)
+    exact = 1.0/(anorm*NormP(Ainv, NORM_INF))
+    t.Logf("inf-norm: rcond: %e, exact: %e\n", rcond, exact)
+    checkRcond(t, "EstimateCondLU inf-norm", rcond, exact)
+
+    var se *SizeMismatchError
+    if _, err := EstimateCondLU(LU, piv[:3], anorm, NORM_INF); ! errors.As(err, &se) {
+        t.Errorf("EstimateCondLU: expected *SizeMismatchError for short pivots, got %v\n", err)
+    }
+}
+
+func TestEstimateCondCHOL(t *testing.T) {
+    N := 60
+    nb := 12
+    A0 := matrix.FloatUniform(N, N)
+    A := matrix.FloatZeros(N, N)
+    Mult(A, A0, A0, 1.0, 1.0, TRANSB)
+    L, _ := DecomposeCHOL(TriL(A.Copy()), LOWER, nb)
+
+    Ainv := matrix.FloatDiagonal(N, 1.0)
+    SolveCHOL(Ainv, L, LOWER)
+
+    anorm := NormP(A, NORM_ONE)
+    rcond, _ := EstimateCondCHOL(L, anorm, LOWER)
+    exact := 1.0/(anorm*NormP(Ainv, NORM_ONE))
+    t.Logf("rcond: %e, exact: %e\n", rcond, exact)
+    checkRcond(t, "EstimateCondCHOL", rcond, exact)
+}
+
+func TestEstimateCondLDL(t *testing.T) {
+    N := 60
+    nb := 0
+    A0 := matrix.FloatUniform(N, N)
+    A := matrix.FloatZeros(N, N)
+    Mult(A, A0, A0, 1.0, 1.0, TRANSB)
+    ipiv := make([]int, N, N)
+    L, _ := DecomposeLDL(TriL(A.Copy()), nil, ipiv, LOWER, nb)
+
+    Ainv := matrix.FloatDiagonal(N, 1.0)
+    SolveLDL(Ainv, L, ipiv, LOWER)
+
+    anorm := NormP(A, NORM_ONE)
+    rcond, _ := EstimateCondLDL(L, ipiv, anorm, LOWER)
+    exact := 1.0/(anorm*NormP(Ainv, NORM_ONE))
+    t.Logf("rcond: %e, exact: %e\n", rcond, exact)
+    checkRcond(t, "EstimateCondLDL", rcond, exact)
+}
+
+// Local Variables:
+// tab-width: 4
+// indent-tabs-mode: nil
+// End:
@@ -0,0 +1,124 @@
+
+// Copyright (c) Harri Rautila, 2013
+
+// This file is part of github.com/hrautila/matops package. It is free software,
+// distributed under the terms of GNU Lesser General Public License Version 3, or
+// any later version. See the COPYING tile included in this archive.
+
+package matops
+
+import (
+    "errors"
+    "sync"
+)
+
+// Package default blocking parameters.
+const (
+    defaultMB = 68
+    defaultNB = 68
+    defaultKB = 196
+    defaultLimitOne int64 = 200*200*200
+)
+
+/*
+ * Config holds the tuning parameters of a single call. Functions with Config
+ * suffix take their parameters from Config instead of the package globals set
+ * with BlockingParams(), NumWorkers(), DecomposeBlockSize() and SetPanicOnError()
+ * and can be used concurrently with different parameters. A Config may be shared
+ * by concurrent calls but must not be modified while in use.
+ *
+ * Block sizes apply to the matrix-matrix kernels, matrix-vector operations use
+ * the package globals.
+ */
+type Config struct {
+    // Row, column and inner (DOT) block sizes of matrix-matrix kernels. Zero
+    // values are replaced with package defaults. See BlockingParams().
+    MB, NB, KB int
+    // Block size for blocked factorizations, zero for unblocked algorithms.
+    DecompNB int
+    // Executor for the parallel tasks. If nil and Workers > 1 a pool with Workers
+    // workers is created for the duration of the call; if Workers == 1 computation
+    // is serial and if Workers == 0 the default executor is used.
+    Executor Executor
+    Workers int
+    // Operations with less than LimitOne multiply-add operations are not
+    // divided to parallel tasks. Zero value is replaced with package default.
+    LimitOne int64
+    // If set invalid arguments cause panic instead of error return.
+    PanicOnError bool
+}
+
+// Create new configuration with package default parameters.
+func NewConfig() *Config {
+    return &Config{MB: defaultMB, NB: defaultNB, KB: defaultKB, LimitOne: defaultLimitOne}
+}
+
+// Guards the package globals set with BlockingParams(), DecomposeBlockSize() and
+// SetPanicOnError().
+var globalMu sync.RWMutex
+
+// Consistent snapshot of package global parameters; executor is not set.
+func globalParams() Config {
+    globalMu.RLock()
+    defer globalMu.RUnlock()
+    return Config{MB: mB, NB: nB, KB: vpLen, DecompNB: decompNB,
+        LimitOne: limitOne, PanicOnError: panicOnError}
+}
+
+// Configuration from package globals with executor ex.
+func globalConfig(ex Executor) *Config {
+    c := globalParams()
+    c.Executor = executorOrDefault(ex)
+    return &c
+}
+
+// Copy of conf with defaults in place of zero values and executor set. Returned
+// function releases the executor created for the call.
+func (conf *Config) resolve() (*Config, func()) {
+    c := NewConfig()
+    if conf != nil {
+        *c = *conf
+    }
+    if c.MB <= 0 {
+        c.MB = defaultMB
+    }
+    if c.NB <= 0 {
+        c.NB = defaultNB
+    }
+    if c.KB <= 0 {
+        c.KB = defaultKB
+    }
+    if c.LimitOne <= 0 {
+        c.LimitOne = defaultLimitOne
+    }
+    release := func() {}
+    if c.Executor == nil {
+        switch {
+        case c.Workers == 1:
+            c.Executor = serialPool
+        case c.Workers > 1:
+            pool := NewPool(c.Workers)
+            c.Executor = pool
+            release = pool.Close
+        default:
+            c.Executor = defaultExecutor()
+        }
+    }
+    return c, release
+}
+
+// Copy of conf running its tasks in the calling goroutine.
+func (conf *Config) serial() *Config {
+    c := *conf
+    c.Executor = serialPool
+    return &c
+}
+
+func (conf *Config) onError(msg string) error {
+    return conf.raiseError(errors.New(msg))
+}
+
+// Local Variables:
+// tab-width: 4
+// indent-tabs-mode: nil
+// End:
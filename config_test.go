@@ -0,0 +1,233 @@
+
+// Copyright (c) Harri Rautila, 2013
+
+// This file is part of github.com/hrautila/matops package. It is free software,
+// distributed under the terms of GNU Lesser General Public License Version 3, or
+// any later version. See the COPYING tile included in this archive.
+
+package matops
+
+import (
+    "github.com/hrautila/matrix"
+    "context"
+    "sync"
+    "testing"
+)
+
+func TestConfigMult(t *testing.T) {
+    N := 311
+    ctx := context.Background()
+    A := matrix.FloatUniform(N, N)
+    B := matrix.FloatUniform(N, N)
+    C0 := matrix.FloatUniform(N, N)
+    C := C0.Copy()
+    Mult(C, A, B, 1.0, 1.0, NOTRANS)
+
+    // calls with different parameters run concurrently
+    confs := []*Config{
+        &Config{Workers: 1},
+        &Config{MB: 16, NB: 24, KB: 32, Workers: 3, LimitOne: 1},
+        &Config{MB: 100, NB: 40, KB: 64, Workers: 2, LimitOne: 1},
+        NewConfig(),
+    }
+    results := make([]*matrix.FloatMatrix, len(confs))
+    var wg sync.WaitGroup
+    for k, conf := range confs {
+        k, conf := k, conf
+        results[k] = C0.Copy()
+        wg.Add(1)
+        go func() {
+            defer wg.Done()
+            if err := MultConfig(ctx, conf, results[k], A, B, 1.0, 1.0, NOTRANS); err != nil {
+                t.Errorf("conf %d: %v\n", k, err)
+            }
+        }()
+    }
+    wg.Wait()
+    for k, C1 := range results {
+        C1.Minus(C)
+        nrm := NormP(C1, NORM_ONE)
+        t.Logf("conf %d ||Mult - MultConfig||_1: %e\n", k, nrm)
+        if nrm > 1e-8 {
+            t.Errorf("conf %d: MultConfig differs from Mult: %e\n", k, nrm)
+        }
+    }
+
+    // errors follow conf.PanicOnError, not the package global
+    if err := MultConfig(ctx, &Config{}, C, A, matrix.FloatZeros(N-1, N), 1.0, 0.0, NOTRANS); err == nil {
+        t.Errorf("size mismatch not detected\n")
+    }
+    func() {
+        defer func() {
+            if recover() == nil {
+                t.Errorf("no panic with PanicOnError\n")
+            }
+        }()
+        conf := &Config{PanicOnError: true}
+        MultConfig(ctx, conf, C, A, matrix.FloatZeros(N-1, N), 1.0, 0.0, NOTRANS)
+    }()
+}
+
+func TestConfigDecompose(t *testing.T) {
+    N := 411
+    nb := 32
+    ctx := context.Background()
+    A := matrix.FloatUniform(N, N)
+    piv0 := make([]int, N)
+    LU0, _ := DecomposeLU(A.Copy(), piv0, nb)
+
+    S := matrix.FloatZeros(N, N)
+    Mult(S, A, A, 1.0, 0.0, TRANSB)
+    C0, _ := DecomposeCHOL(S.Copy(), LOWER, nb)
+
+    confs := []*Config{
+        &Config{DecompNB: nb, Workers: 1},
+        &Config{DecompNB: nb, Workers: 4, MB: 16, NB: 16, KB: 32, LimitOne: 1},
+    }
+    for k, conf := range confs {
+        piv1 := make([]int, N)
+        LU1, err := DecomposeLUConfig(ctx, conf, A.Copy(), piv1)
+        if err != nil {
+            t.Errorf("conf %d LU: %v\n", k, err)
+        }
+        LU1.Minus(LU0)
+        C1, _ := DecomposeCHOLConfig(ctx, conf, S.Copy(), LOWER)
+        TriL(C1.Minus(C0))
+        t.Logf("conf %d ||LU - LUConfig||_1: %e, ||CHOL - CHOLConfig||_1: %e\n",
+            k, NormP(LU1, NORM_ONE), NormP(C1, NORM_ONE))
+
+        tau0 := matrix.FloatZeros(N, 1)
+        tau1 := matrix.FloatZeros(N, 1)
+        Q0, _ := DecomposeQR(A.Copy(), tau0, nil, nb)
+        Q1, _ := DecomposeQRConfig(ctx, conf, A.Copy(), tau1, nil)
+        Q1.Minus(Q0)
+        t.Logf("conf %d ||QR - QRConfig||_1: %e\n", k, NormP(Q1, NORM_ONE))
+    }
+}
+
+func TestConfigSymmetric(t *testing.T) {
+    N := 137
+    nb := 16
+    ctx := context.Background()
+    A := symIndefinite(N, false)
+    S := matrix.FloatZeros(N, N)
+    Mult(S, A, A, 1.0, 0.0, TRANSB)
+    W := matrix.FloatZeros(N, nb+1)
+    conf := &Config{DecompNB: nb, Workers: 3, MB: 16, NB: 16, KB: 32, LimitOne: 1}
+
+    for _, flags := range []Flags{LOWER, UPPER} {
+        ipiv0 := make([]int, N)
+        ipiv1 := make([]int, N)
+        B0, _ := DecomposeBK(A.Copy(), W, ipiv0, flags, nb)
+        B1, err := DecomposeBKConfig(ctx, conf, A.Copy(), W, ipiv1, flags)
+        if err != nil {
+            t.Errorf("flags %x BK: %v\n", flags, err)
+        }
+        for k := range ipiv0 {
+            if ipiv0[k] != ipiv1[k] {
+                t.Errorf("flags %x BK: pivot %d: %d != %d\n", flags, k, ipiv1[k], ipiv0[k])
+                break
+            }
+        }
+        if d := NormP(B1.Minus(B0), NORM_ONE); d > 1e-10 {
+            t.Errorf("flags %x ||BK - BKConfig||_1: %e\n", flags, d)
+        }
+
+        L0, _ := DecomposeLDLnoPiv(S.Copy(), W, flags, nb)
+        L1, err := DecomposeLDLnoPivConfig(ctx, conf, S.Copy(), W, flags)
+        if err != nil {
+            t.Errorf("flags %x LDLnoPiv: %v\n", flags, err)
+        }
+        if d := NormP(L1.Minus(L0), NORM_ONE); d > 1e-8 {
+            t.Errorf("flags %x ||LDLnoPiv - LDLnoPivConfig||_1: %e\n", flags, d)
+        }
+    }
+    func() {
+        defer func() {
+            if recover() == nil {
+                t.Errorf("no panic on invalid flags with PanicOnError\n")
+            }
+        }()
+        DecomposeBKConfig(ctx, &Config{PanicOnError: true}, A.Copy(), W, make([]int, N), 0)
+    }()
+}
+
+func TestConfigQRT(t *testing.T) {
+    M := 91
+    N := 63
+    nb := 12
+    ctx := context.Background()
+    A := matrix.FloatUniform(M, N)
+    B := matrix.FloatUniform(M, 5)
+    C := matrix.FloatUniform(7, M)
+    T0 := matrix.FloatZeros(N, N)
+    T1 := matrix.FloatZeros(N, N)
+    W := matrix.FloatZeros(M, nb)
+    conf := &Config{DecompNB: nb, Workers: 2, MB: 16, NB: 16, KB: 32, LimitOne: 1}
+
+    QR0, _ := DecomposeQRT(A.Copy(), T0, W, nb)
+    QR1, err := DecomposeQRTConfig(ctx, conf, A.Copy(), T1, W)
+    if err != nil {
+        t.Errorf("QRT: %v\n", err)
+    }
+    if d := NormP(QR1.Copy().Minus(QR0), NORM_ONE) + NormP(T1.Copy().Minus(T0), NORM_ONE); d > 1e-10 {
+        t.Errorf("||QRT - QRTConfig||_1: %e\n", d)
+    }
+
+    X0 := B.Copy()
+    X1 := B.Copy()
+    SolveQRT(X0, QR0, T0, W, NOTRANS, nb)
+    if err = SolveQRTConfig(ctx, conf, X1, QR1, T1, W, NOTRANS); err != nil {
+        t.Errorf("SolveQRT: %v\n", err)
+    }
+    if d := NormP(X1.Minus(X0), NORM_ONE); d > 1e-10 {
+        t.Errorf("||SolveQRT - SolveQRTConfig||_1: %e\n", d)
+    }
+
+    C0 := C.Copy()
+    C1 := C.Copy()
+    MultQT(C0, QR0, T0, W, RIGHT|TRANS, nb)
+    if err = MultQTConfig(ctx, conf, C1, QR1, T1, W, RIGHT|TRANS); err != nil {
+        t.Errorf("MultQT: %v\n", err)
+    }
+    if d := NormP(C1.Minus(C0), NORM_ONE); d > 1e-10 {
+        t.Errorf("||MultQT - MultQTConfig||_1: %e\n", d)
+    }
+}
+
+func TestConfigGlobals(t *testing.T) {
+    // package globals may be set while other calls read them
+    N := 64
+    p := globalParams()
+    defer func() {
+        BlockingParams(p.MB, p.NB, p.KB)
+        DecomposeBlockSize(p.DecompNB)
+    }()
+    A := matrix.FloatUniform(N, N)
+    X := matrix.FloatUniform(N, 1)
+    done := make(chan bool)
+    go func() {
+        for k := 0; k < 50; k++ {
+            BlockingParams(16+k, 16+k, 32+k)
+            DecomposeBlockSize(k % 8)
+            SetPanicOnError(false)
+        }
+        done <- true
+    }()
+    for k := 0; k < 50; k++ {
+        C := matrix.FloatZeros(N, N)
+        Y := matrix.FloatZeros(N, 1)
+        if err := Mult(C, A, A, 1.0, 0.0, NOTRANS); err != nil {
+            t.Errorf("Mult: %v\n", err)
+        }
+        if err := MVMult(Y, A, X, 1.0, 0.0, NOTRANS); err != nil {
+            t.Errorf("MVMult: %v\n", err)
+        }
+    }
+    <-done
+}
+
+// Local Variables:
+// tab-width: 4
+// indent-tabs-mode: nil
+// End:
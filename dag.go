@@ -0,0 +1,162 @@
+
+// Copyright (c) Harri Rautila, 2013
+
+// This file is part of github.com/hrautila/matops package. It is free software,
+// distributed under the terms of GNU Lesser General Public License Version 3, or
+// any later version. See the COPYING tile included in this archive.
+
+package matops
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "sync"
+)
+
+// Executor for the kernels run inside task graph nodes; parallelism comes from
+// the graph itself.
+var serialPool *Pool = NewPool(1)
+
+// Node of a task graph.
+type graphTask struct {
+    run   func() error
+    prio  int
+    ndeps int
+    succ  []*graphTask
+}
+
+/*
+ * Task graph (DAG) of a blocked algorithm. Tasks are added in program order with
+ * the tasks they depend on and run by the workers of an executor when all their
+ * dependencies have finished. Of the ready tasks one with the smallest priority
+ * value is run first; blocked factorizations use the index of the column block
+ * the task writes to as the priority so that the next panel is factored while
+ * the trailing matrix is still being updated (lookahead).
+ */
+type taskGraph struct {
+    mu        sync.Mutex
+    cond      *sync.Cond
+    ready     []*graphTask
+    remaining int
+    err       error
+    perr      interface{}
+}
+
+func newTaskGraph() *taskGraph {
+    g := &taskGraph{}
+    g.cond = sync.NewCond(&g.mu)
+    return g
+}
+
+// Add task with priority prio depending on deps. Nil dependencies are ignored.
+func (g *taskGraph) add(prio int, run func() error, deps ...*graphTask) *graphTask {
+    t := &graphTask{run: run, prio: prio}
+    for _, d := range deps {
+        if d != nil {
+            d.succ = append(d.succ, t)
+            t.ndeps++
+        }
+    }
+    if t.ndeps == 0 {
+        g.ready = append(g.ready, t)
+    }
+    g.remaining++
+    return t
+}
+
+// Take ready task with smallest priority value; nil if graph finished or failed.
+func (g *taskGraph) next(ctx context.Context) *graphTask {
+    g.mu.Lock()
+    defer g.mu.Unlock()
+    for len(g.ready) == 0 && g.remaining > 0 && g.err == nil && g.perr == nil {
+        g.cond.Wait()
+    }
+    if g.err == nil && g.perr == nil && g.remaining > 0 {
+        if err := ctx.Err(); err != nil {
+            g.err = err
+            g.cond.Broadcast()
+        }
+    }
+    if len(g.ready) == 0 || g.err != nil || g.perr != nil {
+        return nil
+    }
+    k := 0
+    for i, t := range g.ready {
+        if t.prio < g.ready[k].prio {
+            k = i
+        }
+    }
+    t := g.ready[k]
+    g.ready[k] = g.ready[len(g.ready)-1]
+    g.ready = g.ready[:len(g.ready)-1]
+    return t
+}
+
+// Mark task finished and release tasks depending on it.
+func (g *taskGraph) done(t *graphTask, err error, perr interface{}) {
+    g.mu.Lock()
+    defer g.mu.Unlock()
+    g.remaining--
+    if perr != nil && g.perr == nil {
+        g.perr = perr
+    }
+    if err != nil && g.err == nil {
+        g.err = err
+    }
+    for _, s := range t.succ {
+        s.ndeps--
+        if s.ndeps == 0 {
+            g.ready = append(g.ready, s)
+        }
+    }
+    g.cond.Broadcast()
+}
+
+func (g *taskGraph) runTask(t *graphTask) {
+    var err error
+    defer func() {
+        perr := recover()
+        g.done(t, err, perr)
+    }()
+    err = t.run()
+}
+
+// Run all tasks of the graph with workers of ex. Execution stops at first error
+// returned by a task or when ctx is cancelled and the error is returned. A panic
+// in a task is raised again in the calling goroutine.
+func (g *taskGraph) run(ctx context.Context, ex Executor) error {
+    if g.remaining == 0 {
+        return nil
+    }
+    nworker := ex.Workers()
+    if nworker > g.remaining {
+        nworker = g.remaining
+    }
+    workers := make([]func(), nworker)
+    for k := range workers {
+        workers[k] = func() {
+            for t := g.next(ctx); t != nil; t = g.next(ctx) {
+                g.runTask(t)
+            }
+        }
+    }
+    // with the pool busy the calling goroutine may run all workers one after
+    // another; first one then runs the whole graph.
+    err := ex.Execute(ctx, workers)
+    if g.perr != nil {
+        panic(g.perr)
+    }
+    if g.err != nil {
+        return g.err
+    }
+    if err == nil && g.remaining > 0 {
+        err = errors.New(fmt.Sprintf("task graph: %d tasks not run", g.remaining))
+    }
+    return err
+}
+
+// Local Variables:
+// tab-width: 4
+// indent-tabs-mode: nil
+// End:
@@ -8,6 +8,7 @@ import (
 )
 
 func Mult0(C, A, B *matrix.FloatMatrix, alpha, beta float64, flags Flags) error {
+    p := globalParams()
     if A.Cols() != B.Rows() {
         return errors.New("A.cols != B.rows: size mismatch")
     }
@@ -19,25 +20,25 @@ func Mult0(C, A, B *matrix.FloatMatrix, alpha, beta float64, flags Flags) error
     Cr := C.FloatArray()
     ldC := C.LeadingIndex()
 
-    if nWorker <= 1 || psize <= limitOne {
+    if defaultExecutor().Workers() <= 1 || psize <= p.LimitOne {
         calgo.DMult0(Cr, Ar, Br, alpha, beta, calgo.Flags(flags), ldC, ldA, ldB, B.Rows(),
             0, C.Cols(), 0, C.Rows(),
-            vpLen, nB, mB)
+            p.KB, p.NB, p.MB)
         return nil
     } 
     // here we have more than one worker available
-    worker := func(cstart, cend, rstart, rend int, ready chan int) {
+    worker := func(cstart, cend, rstart, rend int) {
         calgo.DMult0(Cr, Ar, Br, alpha, beta, calgo.Flags(flags), ldC, ldA, ldB, B.Rows(),
-            cstart, cend, rstart, rend, vpLen, nB, mB)
-        ready <- 1
+            cstart, cend, rstart, rend, p.KB, p.NB, p.MB)
     }
-    colworks, rowworks := divideWork(C.Rows(), C.Cols(), nWorker)
+    colworks, rowworks := divideWork(C.Rows(), C.Cols(), defaultExecutor().Workers())
     scheduleWork(colworks, rowworks, C.Cols(), C.Rows(), worker)
     return nil
 }
 
 // Calculate C = alpha*A*B + beta*C, C is M*N, A is M*P and B is P*N
 func MMMultNoTrans(C, A, B *matrix.FloatMatrix, alpha, beta float64) error {
+    p := globalParams()
     psize := int64(C.NumElements()*A.Cols())
     Ar := A.FloatArray()
     ldA := A.LeadingIndex()
@@ -46,24 +47,24 @@ func MMMultNoTrans(C, A, B *matrix.FloatMatrix, alpha, beta float64) error {
     Cr := C.FloatArray()
     ldC := C.LeadingIndex()
 
-    if nWorker <= 1 || psize <= limitOne {
+    if defaultExecutor().Workers() <= 1 || psize <= p.LimitOne {
         calgo.DMult(Cr, Ar, Br, alpha, beta, calgo.NOTRANS, ldC, ldA, ldB, B.Rows(),
-            0, C.Cols(), 0, C.Rows(), vpLen, nB, mB)
+            0, C.Cols(), 0, C.Rows(), p.KB, p.NB, p.MB)
         return nil
     } 
     // here we have more than one worker available
-    worker := func(cstart, cend, rstart, rend int, ready chan int) {
+    worker := func(cstart, cend, rstart, rend int) {
         calgo.DMult(Cr, Ar, Br, alpha, beta, calgo.NOTRANS, ldC, ldA, ldB, B.Rows(),
-            cstart, cend, rstart, rend, vpLen, nB, mB)
-        ready <- 1
+            cstart, cend, rstart, rend, p.KB, p.NB, p.MB)
     }
-    colworks, rowworks := divideWork(C.Rows(), C.Cols(), nWorker)
+    colworks, rowworks := divideWork(C.Rows(), C.Cols(), defaultExecutor().Workers())
     scheduleWork(colworks, rowworks, C.Cols(), C.Rows(), worker)
     return nil
 }
 
 // Calculate C = alpha*A.T*B + beta*C, C is M*N, A is P*M and B is P*N
 func MMMultTransA(C, A, B *matrix.FloatMatrix, alpha, beta float64) error {
+    p := globalParams()
     psize := int64(C.NumElements()*B.Rows())
     Ar := A.FloatArray()
     ldA := A.LeadingIndex()
@@ -71,19 +72,18 @@ func MMMultTransA(C, A, B *matrix.FloatMatrix, alpha, beta float64) error {
     ldB := B.LeadingIndex()
     Cr := C.FloatArray()
     ldC := C.LeadingIndex()
-    if nWorker <= 1 || psize <= limitOne {
+    if defaultExecutor().Workers() <= 1 || psize <= p.LimitOne {
         calgo.DMult(Cr, Ar, Br, alpha, beta, calgo.TRANSA, ldC, ldA, ldB,
-            B.Rows(), 0, C.Cols(), 0, C.Rows(), vpLen, nB, mB)
+            B.Rows(), 0, C.Cols(), 0, C.Rows(), p.KB, p.NB, p.MB)
         return nil
     }
 
     // here we have more than one worker available
-    worker := func(cstart, cend, rstart, rend int, ready chan int) {
+    worker := func(cstart, cend, rstart, rend int) {
         calgo.DMult(Cr, Ar, Br, alpha, beta, calgo.TRANSA, ldC, ldA, ldB, B.Rows(),
-            cstart, cend, rstart, rend, vpLen, nB, mB)
-        ready <- 1
+            cstart, cend, rstart, rend, p.KB, p.NB, p.MB)
     }
-    colworks, rowworks := divideWork(C.Rows(), C.Cols(), nWorker)
+    colworks, rowworks := divideWork(C.Rows(), C.Cols(), defaultExecutor().Workers())
     scheduleWork(colworks, rowworks, C.Cols(), C.Rows(), worker)
     //scheduleWork(colworks, rowworks, worker)
     return nil
@@ -91,6 +91,7 @@ func MMMultTransA(C, A, B *matrix.FloatMatrix, alpha, beta float64) error {
 
 // Calculate C = alpha*A*B.T + beta*C, C is M*N, A is M*P and B is N*P
 func MMMultTransB(C, A, B *matrix.FloatMatrix, alpha, beta float64) error {
+    p := globalParams()
     psize := int64(C.NumElements()*A.Cols())
     Ar := A.FloatArray()
     ldA := A.LeadingIndex()
@@ -98,19 +99,18 @@ func MMMultTransB(C, A, B *matrix.FloatMatrix, alpha, beta float64) error {
     ldB := B.LeadingIndex()
     Cr := C.FloatArray()
     ldC := C.LeadingIndex()
-    if nWorker <= 1 || psize <= limitOne {
+    if defaultExecutor().Workers() <= 1 || psize <= p.LimitOne {
         calgo.DMult(Cr, Ar, Br, alpha, beta, calgo.TRANSB, ldC, ldA, ldB,
-            B.Rows(), 0, C.Cols(), 0, C.Rows(), vpLen, nB, mB)
+            B.Rows(), 0, C.Cols(), 0, C.Rows(), p.KB, p.NB, p.MB)
         return nil
     }
 
     // here we have more than one worker available
-    worker := func(cstart, cend, rstart, rend int, ready chan int) {
+    worker := func(cstart, cend, rstart, rend int) {
         calgo.DMult(Cr, Ar, Br, alpha, beta, calgo.TRANSB, ldC, ldA, ldB, B.Rows(),
-            cstart, cend, rstart, rend, vpLen, nB, mB)
-        ready <- 1
+            cstart, cend, rstart, rend, p.KB, p.NB, p.MB)
     }
-    colworks, rowworks := divideWork(C.Rows(), C.Cols(), nWorker)
+    colworks, rowworks := divideWork(C.Rows(), C.Cols(), defaultExecutor().Workers())
     scheduleWork(colworks, rowworks, C.Cols(), C.Rows(), worker)
     //scheduleWork(colworks, rowworks, worker)
     return nil
@@ -118,6 +118,7 @@ func MMMultTransB(C, A, B *matrix.FloatMatrix, alpha, beta float64) error {
 
 // Calculate C = alpha*A.T*B.T + beta*C, C is M*N, A is P*M and B is N*P
 func MMMultTransAB(C, A, B *matrix.FloatMatrix, alpha, beta float64) error {
+    p := globalParams()
     psize := int64(C.NumElements()*A.Rows())
     Ar := A.FloatArray()
     ldA := A.LeadingIndex()
@@ -125,19 +126,18 @@ func MMMultTransAB(C, A, B *matrix.FloatMatrix, alpha, beta float64) error {
     ldB := B.LeadingIndex()
     Cr := C.FloatArray()
     ldC := C.LeadingIndex()
-    if nWorker <= 1 || psize <= limitOne{
+    if defaultExecutor().Workers() <= 1 || psize <= p.LimitOne{
         calgo.DMult(Cr, Ar, Br, alpha, beta, calgo.TRANSA|calgo.TRANSB, ldC, ldA, ldB,
-            B.Rows(), 0, C.Cols(), 0, C.Rows(), vpLen, nB, mB)
+            B.Rows(), 0, C.Cols(), 0, C.Rows(), p.KB, p.NB, p.MB)
         return nil
     }
 
     // here we have more than one worker available
-    worker := func(cstart, cend, rstart, rend int, ready chan int) {
+    worker := func(cstart, cend, rstart, rend int) {
         calgo.DMult(Cr, Ar, Br, alpha, beta, calgo.TRANSA|calgo.TRANSB, ldC, ldA, ldB,
-            B.Rows(), cstart, cend, rstart, rend, vpLen, nB, mB)
-        ready <- 1
+            B.Rows(), cstart, cend, rstart, rend, p.KB, p.NB, p.MB)
     }
-    colworks, rowworks := divideWork(C.Rows(), C.Cols(), nWorker)
+    colworks, rowworks := divideWork(C.Rows(), C.Cols(), defaultExecutor().Workers())
     scheduleWork(colworks, rowworks, C.Cols(), C.Rows(), worker)
     //scheduleWork(colworks, rowworks, worker)
     return nil
@@ -146,6 +146,7 @@ func MMMultTransAB(C, A, B *matrix.FloatMatrix, alpha, beta float64) error {
 
 // Calculate C = alpha*A*B + beta*C, C is M*N, A is M*M and B is M*N
 func MMSymm(C, A, B *matrix.FloatMatrix, alpha, beta float64) error {
+    p := globalParams()
 
     if A.Rows() != A.Cols() {
         return errors.New("A matrix not square matrix.");
@@ -158,24 +159,24 @@ func MMSymm(C, A, B *matrix.FloatMatrix, alpha, beta float64) error {
     Cr := C.FloatArray()
     ldC := C.LeadingIndex()
 
-    if nWorker <= 1 || psize <= limitOne {
+    if defaultExecutor().Workers() <= 1 || psize <= p.LimitOne {
         calgo.DMultSymm(Cr, Ar, Br, alpha, beta, calgo.LEFT|calgo.LOWER, ldC, ldA, ldB,
-            A.Cols(),  0, C.Cols(), 0, C.Rows(), vpLen, nB, mB)
+            A.Cols(),  0, C.Cols(), 0, C.Rows(), p.KB, p.NB, p.MB)
         return nil
     } 
     // here we have more than one worker available
-    worker := func(cstart, cend, rstart, rend int, ready chan int) {
+    worker := func(cstart, cend, rstart, rend int) {
         calgo.DMultSymm(Cr, Ar, Br, alpha, beta, calgo.LEFT|calgo.LOWER, ldC, ldA, ldB,
-            A.Cols(), cstart, cend, rstart, rend, vpLen, nB, mB)
-        ready <- 1
+            A.Cols(), cstart, cend, rstart, rend, p.KB, p.NB, p.MB)
     }
-    colworks, rowworks := divideWork(C.Rows(), C.Cols(), nWorker)
+    colworks, rowworks := divideWork(C.Rows(), C.Cols(), defaultExecutor().Workers())
     scheduleWork(colworks, rowworks, C.Cols(), C.Rows(), worker)
     return nil
 }
 
 // Calculate C = alpha*A*B + beta*C, C is M*N, A is M*M and B is M*N
 func MMSymmUpper(C, A, B *matrix.FloatMatrix, alpha, beta float64) error {
+    p := globalParams()
 
     if A.Rows() != A.Cols() {
         return errors.New("A matrix not square matrix.");
@@ -188,24 +189,24 @@ func MMSymmUpper(C, A, B *matrix.FloatMatrix, alpha, beta float64) error {
     Cr := C.FloatArray()
     ldC := C.LeadingIndex()
 
-    if nWorker <= 1 || psize <= limitOne {
+    if defaultExecutor().Workers() <= 1 || psize <= p.LimitOne {
         calgo.DMultSymm(Cr, Ar, Br, alpha, beta, calgo.LEFT|calgo.UPPER, ldC, ldA, ldB,
-            A.Cols(), 0, C.Cols(), 0, C.Rows(), vpLen, nB, mB)
+            A.Cols(), 0, C.Cols(), 0, C.Rows(), p.KB, p.NB, p.MB)
         return nil
     } 
     // here we have more than one worker available
-    worker := func(cstart, cend, rstart, rend int, ready chan int) {
+    worker := func(cstart, cend, rstart, rend int) {
         calgo.DMultSymm(Cr, Ar, Br, alpha, beta, calgo.LEFT|calgo.UPPER, ldC, ldA, ldB,
-            A.Cols(), cstart, cend, rstart, rend, vpLen, nB, mB)
-        ready <- 1
+            A.Cols(), cstart, cend, rstart, rend, p.KB, p.NB, p.MB)
     }
-    colworks, rowworks := divideWork(C.Rows(), C.Cols(), nWorker)
+    colworks, rowworks := divideWork(C.Rows(), C.Cols(), defaultExecutor().Workers())
     scheduleWork(colworks, rowworks, C.Cols(), C.Rows(), worker)
     return nil
 }
 
 // Y = alpha*A.T*X + beta*Y
 func MVMultTransA(Y, A, X *matrix.FloatMatrix, alpha, beta float64) error {
+    p := globalParams()
 
     if Y.Rows() != 1 && Y.Cols() != 1 {
         return errors.New("Y not a vector.");
@@ -233,7 +234,7 @@ func MVMultTransA(Y, A, X *matrix.FloatMatrix, alpha, beta float64) error {
         lenX = X.Cols()
     }
     calgo.DMultMV(Yr, Ar, Xr, alpha, beta, calgo.TRANSA, incY, ldA, incX,
-        0, lenX, 0, lenY, vpLen, mB)
+        0, lenX, 0, lenY, p.KB, p.MB)
     return nil
 }
 
@@ -9,9 +9,45 @@ package matops
 
 import (
 	"github.com/hrautila/matrix"
-    "errors"
 )
 
+// Length of diagonal D stored as column or row vector or as diagonal of square
+// matrix; -1 if D is none of them.
+func diagLen(D *matrix.FloatMatrix) int {
+    switch {
+    case D.Cols() == 1:
+        return D.Rows()
+    case D.Rows() == 1:
+        return D.Cols()
+    case D.Rows() == D.Cols():
+        return D.Rows()
+    }
+    return -1
+}
+
+// Element k of diagonal D; see diagLen().
+func diagAt(D *matrix.FloatMatrix, k int) float64 {
+    switch {
+    case D.Cols() == 1:
+        return D.GetAt(k, 0)
+    case D.Rows() == 1:
+        return D.GetAt(0, k)
+    }
+    return D.GetAt(k, k)
+}
+
+// Check flags and sizes of diagonal operation op on C with diagonal D.
+func checkDiag(op string, C, D *matrix.FloatMatrix, flags Flags) error {
+    if ! oneOf(flags, LEFT, RIGHT) {
+        return flagError(op, "LEFT or RIGHT")
+    }
+    n := diagLen(D)
+    if n < 0 || (flags & LEFT != 0 && n != C.Rows()) || (flags & RIGHT != 0 && n != C.Cols()) {
+        return sizeError(op, C, D)
+    }
+    return nil
+}
+
 /*
  * Compute
  *   C = C*diag(D)      flags & RIGHT == true
@@ -23,8 +59,27 @@ import (
  *   D     N element column or row vector or N-by-N matrix
  *   
  *   flags Indicator bits, LEFT or RIGHT
+ *
+ * Returns:
+ *   Error if flags does not have exactly one of LEFT and RIGHT or if size of D
+ *   does not match C.
  */
+func MultDiagChecked(C, D *matrix.FloatMatrix, flags Flags) error {
+    if err := checkDiag("MultDiag", C, D, flags); err != nil {
+        return err
+    }
+    multDiag(C, D, flags)
+    return nil
+}
+
+// Compute C = C*diag(D) or C = diag(D)*C.
+//
+// Deprecated: Use MultDiagChecked, which validates its arguments and returns an error.
 func MultDiag(C, D *matrix.FloatMatrix, flags Flags) {
+    MultDiagChecked(C, D, flags)
+}
+
+func multDiag(C, D *matrix.FloatMatrix, flags Flags) {
     var c, d0 matrix.FloatMatrix
     if D.Cols() == 1 {
         // diagonal is column vector
@@ -81,8 +136,33 @@ func MultDiag(C, D *matrix.FloatMatrix, flags Flags) {
  *   D     N element column or row vector or N-by-N matrix
  *   
  *   flags Indicator bits, LEFT or RIGHT
+ *
+ * Returns:
+ *   Error if flags does not have exactly one of LEFT and RIGHT or if size of D
+ *   does not match B. If D has zero element error is *SingularError with its
+ *   index and B is not changed.
  */
+func SolveDiagChecked(B, D *matrix.FloatMatrix, flags Flags) error {
+    if err := checkDiag("SolveDiag", B, D, flags); err != nil {
+        return err
+    }
+    for k := 0; k < diagLen(D); k++ {
+        if diagAt(D, k) == 0.0 {
+            return &SingularError{k}
+        }
+    }
+    solveDiag(B, D, flags)
+    return nil
+}
+
+// Compute X = B*diag(D).-1 or X = diag(D).-1*B.
+//
+// Deprecated: Use SolveDiagChecked, which validates its arguments and returns an error.
 func SolveDiag(B, D *matrix.FloatMatrix, flags Flags) {
+    SolveDiagChecked(B, D, flags)
+}
+
+func solveDiag(B, D *matrix.FloatMatrix, flags Flags) {
     var c, d0 matrix.FloatMatrix
     if D.Cols() == 1 {
         // diagonal is column vector
@@ -143,7 +223,7 @@ func MVUpdateDiag(D, x, y *matrix.FloatMatrix, alpha float64) error {
     var dvec matrix.FloatMatrix
 
     if ! isVector(x) || ! isVector(y) {
-        return errors.New("x, y not vectors")
+        return sizeError("MVUpdateDiag", D, x, y)
     }
     if D.Rows() > 0 && D.Cols() == D.Rows() {
         D.Diag(&dvec)
@@ -151,7 +231,7 @@ func MVUpdateDiag(D, x, y *matrix.FloatMatrix, alpha float64) error {
     } else if isVector(D) {
         d = D
     } else {
-        return errors.New("D not a diagonal")
+        return sizeError("MVUpdateDiag", D, x, y)
     }
         
     N := d.NumElements()
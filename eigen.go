@@ -0,0 +1,423 @@
+
+// Copyright (c) Harri Rautila, 2013
+
+// This file is part of github.com/hrautila/matops package. It is free software,
+// distributed under the terms of GNU Lesser General Public License Version 3, or
+// any later version. See the COPYING tile included in this archive.
+
+package matops
+
+import (
+    "github.com/hrautila/matrix"
+    "math"
+    //"fmt"
+)
+
+// Additional flag bits for eigen and singular value decompositions.
+const (
+    // compute eigenvectors or (right) singular vectors
+    WANTV = 0x100
+    // compute left singular vectors
+    WANTU = 0x200
+    // compute full sized singular vector matrices
+    FULL  = 0x400
+)
+
+/*
+ * Unblocked reduction of symmetric matrix to tridiagonal form. (LAPACK/dsytd2.f)
+ *
+ *   Q.T*A*Q = T, Q = H(1)H(2)...H(n-1), H(k) = I - tau*v*v.T
+ *
+ * Lower triangular part of A is used. On exit, diagonal and first subdiagonal
+ * of A hold the tridiagonal T, elementary reflectors are stored below the first
+ * subdiagonal. Vector tau holds the N-1 scalar coefficients, column vector w of
+ * at least N-1 elements is used as workspace.
+ */
+func unblkReduceTridiagLower(A, tau, w *matrix.FloatMatrix) {
+    var ATL, ATR, ABL, ABR matrix.FloatMatrix
+    var A00, a11, a21, A22 matrix.FloatMatrix
+    var tT, tB matrix.FloatMatrix
+    var t0, tau1, t2 matrix.FloatMatrix
+    var alpha1, x21, y21 matrix.FloatMatrix
+
+    partition2x2(
+        &ATL, &ATR,
+        &ABL, &ABR, A, 0, 0, pTOPLEFT)
+    partition2x1(
+        &tT,
+        &tB,  tau, 0, pTOP)
+
+    for ABR.Rows() > 1 {
+        repartition2x2to3x3(&ATL,
+            &A00, nil,  nil,
+            nil,  &a11, nil,
+            nil,  &a21, &A22,   A, 1, pBOTTOMRIGHT)
+        repartition2x1to3x1(&tT,
+            &t0,
+            &tau1,
+            &t2,     tau, 1, pBOTTOM)
+        // ------------------------------------------------------
+
+        // annihilate a21[1:]
+        partition2x1(
+            &alpha1,
+            &x21,   &a21, 1, pTOP)
+        computeHouseholder(&alpha1, &x21, &tau1, LEFT)
+        tauval := tau1.GetAt(0, 0)
+        if tauval != 0.0 {
+            beta := alpha1.GetAt(0, 0)
+            alpha1.SetAt(0, 0, 1.0)
+
+            // y21 = tau*A22*v
+            w.SubMatrix(&y21, 0, 0, A22.Rows(), 1)
+            MultSym(&y21, &A22, &a21, tauval, 0.0, LOWER|LEFT)
+            // y21 = y21 - 0.5*tau*(y21.T*v)*v
+            Axpy(&y21, &a21, Dot(&y21, &a21, -0.5*tauval))
+            // A22 = A22 - v*y21.T - y21*v.T
+            MVRankUpdate2Sym(&A22, &a21, &y21, -1.0, LOWER)
+
+            alpha1.SetAt(0, 0, beta)
+        }
+        // ------------------------------------------------------
+        continue3x3to2x2(
+            &ATL, &ATR,
+            &ABL, &ABR,   &A00, &a11, &A22,   A, pBOTTOMRIGHT)
+        continue3x1to2x1(
+            &tT,
+            &tB,   &t0, &tau1,   tau, pBOTTOM)
+    }
+}
+
+/*
+ * Reduce first nb columns of symmetric matrix A to tridiagonal form and
+ * compute matrix Y needed to update the trailing matrix. (LAPACK/dlatrd.f)
+ *
+ *   A22 = A22 - V*Y.T - Y*V.T
+ *
+ * where V holds the reflectors in columns of A[nb:, 0:nb]. On exit the
+ * first subdiagonal elements of the panel are set to one and the original
+ * values are saved to e.
+ */
+func unblkBuildTridiagLower(A, tau, Y, e *matrix.FloatMatrix, nb int) {
+    var a1, v1, y1, w1, alpha1, x1, Ap, Yp, ap, yp, A22 matrix.FloatMatrix
+
+    n := A.Rows()
+    for i := 0; i < nb; i++ {
+        A.SubMatrix(&a1, i, i, n-i, 1)
+        if i > 0 {
+            // update current column with previous reflectors
+            // a1 = a1 - A[i:, 0:i]*Y[i, 0:i].T - Y[i:, 0:i]*A[i, 0:i].T
+            A.SubMatrix(&Ap, i, 0, n-i, i)
+            Y.SubMatrix(&yp, i, 0, 1, i)
+            MVMult(&a1, &Ap, &yp, -1.0, 1.0, NOTRANS)
+            Y.SubMatrix(&Yp, i, 0, n-i, i)
+            A.SubMatrix(&ap, i, 0, 1, i)
+            MVMult(&a1, &Yp, &ap, -1.0, 1.0, NOTRANS)
+        }
+        if i == n-1 {
+            break
+        }
+        // generate reflector to annihilate A[i+2:, i]
+        A.SubMatrix(&alpha1, i+1, i, 1, 1)
+        A.SubMatrix(&x1, i+2, i, n-i-2, 1)
+        tau.SubMatrix(&w1, i, 0, 1, 1)
+        computeHouseholder(&alpha1, &x1, &w1, LEFT)
+        tauval := w1.GetAt(0, 0)
+        e.SetAt(i, 0, alpha1.GetAt(0, 0))
+        alpha1.SetAt(0, 0, 1.0)
+
+        // y1 = A22*v1 with corrections from previous columns
+        A.SubMatrix(&v1, i+1, i, n-i-1, 1)
+        A.SubMatrix(&A22, i+1, i+1, n-i-1, n-i-1)
+        Y.SubMatrix(&y1, i+1, i, n-i-1, 1)
+        MultSym(&y1, &A22, &v1, 1.0, 0.0, LOWER|LEFT)
+        if i > 0 {
+            // w1 used as temporary space, upper part of Y column
+            Y.SubMatrix(&w1, 0, i, i, 1)
+            Y.SubMatrix(&Yp, i+1, 0, n-i-1, i)
+            A.SubMatrix(&Ap, i+1, 0, n-i-1, i)
+            MVMult(&w1, &Yp, &v1, 1.0, 0.0, TRANSA)
+            MVMult(&y1, &Ap, &w1, -1.0, 1.0, NOTRANS)
+            MVMult(&w1, &Ap, &v1, 1.0, 0.0, TRANSA)
+            MVMult(&y1, &Yp, &w1, -1.0, 1.0, NOTRANS)
+        }
+        Scale(&y1, tauval)
+        Axpy(&y1, &v1, Dot(&y1, &v1, -0.5*tauval))
+    }
+}
+
+/*
+ * Blocked reduction of symmetric matrix to tridiagonal form. (LAPACK/dsytrd.f)
+ * Workspace W is N-by-nb matrix.
+ */
+func blkReduceTridiagLower(A, tau, W *matrix.FloatMatrix, nb int) {
+    var ATL, ATR, ABL, ABR matrix.FloatMatrix
+    var A00, A11, A21, A22 matrix.FloatMatrix
+    var tT, tB matrix.FloatMatrix
+    var t0, tau1, t2 matrix.FloatMatrix
+    var Y, Y2, w matrix.FloatMatrix
+
+    e := matrix.FloatZeros(nb, 1)
+    partition2x2(
+        &ATL, &ATR,
+        &ABL, &ABR, A, 0, 0, pTOPLEFT)
+    partition2x1(
+        &tT,
+        &tB,  tau, 0, pTOP)
+
+    for ABR.Rows() > nb+1 {
+        repartition2x2to3x3(&ATL,
+            &A00, nil,  nil,
+            nil,  &A11, nil,
+            nil,  &A21, &A22,   A, nb, pBOTTOMRIGHT)
+        repartition2x1to3x1(&tT,
+            &t0,
+            &tau1,
+            &t2,     tau, nb, pBOTTOM)
+        // ------------------------------------------------------
+
+        W.SubMatrix(&Y, 0, 0, ABR.Rows(), nb)
+        unblkBuildTridiagLower(&ABR, &tau1, &Y, e, nb)
+
+        // A22 = A22 - V*Y2.T - Y2*V.T
+        Y.SubMatrix(&Y2, nb, 0, A22.Rows(), nb)
+        RankUpdate2Sym(&A22, &A21, &Y2, -1.0, 1.0, LOWER)
+
+        // restore subdiagonal
+        for k := 0; k < nb; k++ {
+            ABR.SetAt(k+1, k, e.GetAt(k, 0))
+        }
+        // ------------------------------------------------------
+        continue3x3to2x2(
+            &ATL, &ATR,
+            &ABL, &ABR,   &A00, &A11, &A22,   A, pBOTTOMRIGHT)
+        continue3x1to2x1(
+            &tT,
+            &tB,   &t0, &tau1,   tau, pBOTTOM)
+    }
+    // last block with unblocked code
+    W.SubMatrix(&w, 0, 0, ABR.Rows(), 1)
+    unblkReduceTridiagLower(&ABR, &tB, &w)
+}
+
+// Copy strictly upper triangular part of A to lower triangular part.
+func symmetrizeLower(A *matrix.FloatMatrix) {
+    for j := 0; j < A.Cols(); j++ {
+        for i := j+1; i < A.Rows(); i++ {
+            A.SetAt(i, j, A.GetAt(j, i))
+        }
+    }
+}
+
+/*
+ * Compute eigenvalues and optionally eigenvectors of symmetric tridiagonal
+ * matrix with implicit QL method. (EISPACK/tql2)
+ *
+ * Vector d holds the diagonal and e the subdiagonal of T with e[n-1] = 0.
+ * If Z is not nil it is updated with the rotations; if Z is orthogonal matrix
+ * used in reduction to tridiagonal form, then on exit Z holds the eigenvectors
+ * of the original matrix. On exit, d holds the eigenvalues in ascending order.
+ */
+func tridiagQL(d, e []float64, Z *matrix.FloatMatrix) error {
+    var zi, zi1 matrix.FloatMatrix
+
+    n := len(d)
+    eps := math.Pow(2.0, -52.0)
+    maxIter := 30*n
+    f := 0.0
+    tst1 := 0.0
+    for l := 0; l < n; l++ {
+        // find small subdiagonal element
+        tst1 = math.Max(tst1, math.Abs(d[l]) + math.Abs(e[l]))
+        m := l
+        for m < n-1 {
+            if math.Abs(e[m]) <= eps*tst1 {
+                break
+            }
+            m++
+        }
+        // if m == l, d[l] is an eigenvalue, otherwise iterate
+        iter := 0
+        for m > l {
+            iter++
+            if iter > maxIter {
+                return &BreakdownError{"EigenSym", l}
+            }
+            // compute implicit shift
+            g := d[l]
+            p := (d[l+1] - g) / (2.0 * e[l])
+            r := sqrtX2Y2(p, 1.0)
+            if p < 0.0 {
+                r = -r
+            }
+            d[l] = e[l] / (p + r)
+            d[l+1] = e[l] * (p + r)
+            dl1 := d[l+1]
+            h := g - d[l]
+            for i := l+2; i < n; i++ {
+                d[i] -= h
+            }
+            f += h
+
+            // implicit QL transformation
+            p = d[m]
+            c, c2, c3 := 1.0, 1.0, 1.0
+            el1 := e[l+1]
+            s, s2 := 0.0, 0.0
+            for i := m-1; i >= l; i-- {
+                c3 = c2
+                c2 = c
+                s2 = s
+                g = c * e[i]
+                h = c * p
+                r = sqrtX2Y2(p, e[i])
+                e[i+1] = s * r
+                s = e[i] / r
+                c = p / r
+                p = c*d[i] - s*g
+                d[i+1] = h + s*(c*g + s*d[i])
+                if Z != nil {
+                    // accumulate transformation to columns i, i+1
+                    Z.SubMatrix(&zi, 0, i, Z.Rows(), 1)
+                    Z.SubMatrix(&zi1, 0, i+1, Z.Rows(), 1)
+                    applyGivens(&zi, &zi1, c, -s)
+                }
+            }
+            p = -s * s2 * c3 * el1 * e[l] / dl1
+            e[l] = s * p
+            d[l] = c * p
+            if math.Abs(e[l]) <= eps*tst1 {
+                break
+            }
+        }
+        d[l] = d[l] + f
+        e[l] = 0.0
+    }
+
+    // sort eigenvalues and corresponding vectors to ascending order
+    for i := 0; i < n-1; i++ {
+        k := i
+        p := d[i]
+        for j := i+1; j < n; j++ {
+            if d[j] < p {
+                k = j
+                p = d[j]
+            }
+        }
+        if k != i {
+            d[k] = d[i]
+            d[i] = p
+            if Z != nil {
+                swapCols(Z, i, k)
+            }
+        }
+    }
+    return nil
+}
+
+/*
+ * Compute eigenvalues and, optionally, eigenvectors of a real symmetric
+ * matrix A. Matrix is first reduced to symmetric tridiagonal form with
+ * orthogonal similarity transformation Q.T*A*Q = T. Eigenvalues of T are
+ * then computed with implicit QL method.
+ *
+ * Arguments:
+ *  A     On entry, the symmetric matrix A. If flags&LOWER the lower triangular
+ *        part of A is used, if flags&UPPER the upper triangular part is used.
+ *        On exit, if flags&WANTV, A holds the orthonormal eigenvectors of
+ *        the matrix A, otherwise the contents of A is destroyed.
+ *
+ *  W     On exit, the eigenvalues in ascending order. Row or column vector
+ *        of length N.
+ *
+ *  flags Indicator bits, LOWER or UPPER. If WANTV is set eigenvectors are
+ *        computed.
+ *
+ *  nb    Blocking factor for tridiagonal reduction. If nb is zero or N <= nb
+ *        unblocked algorithm is used.
+ *
+ * Returns:
+ *  Eigenvectors or destroyed A and error indicator.
+ *
+ * Compatible with lapack.DSYEV
+ */
+func EigenSym(A, W *matrix.FloatMatrix, flags Flags, nb int) (*matrix.FloatMatrix, error) {
+    var Q1 matrix.FloatMatrix
+
+    if A.Rows() != A.Cols() {
+        return nil, sizeError("EigenSym", A)
+    }
+    N := A.Rows()
+    if W.NumElements() < N || ! isVector(W) {
+        return nil, sizeError("EigenSym", A, W)
+    }
+    if N == 0 {
+        return A, nil
+    }
+    if flags & UPPER != 0 {
+        // reduction works on the lower triangular part
+        symmetrizeLower(A)
+    }
+
+    tau := matrix.FloatZeros(N, 1)
+    if nb == 0 || N <= nb {
+        w := matrix.FloatZeros(N, 1)
+        unblkReduceTridiagLower(A, tau, w)
+    } else {
+        Wrk := matrix.FloatZeros(N, nb)
+        blkReduceTridiagLower(A, tau, Wrk, nb)
+    }
+
+    d := make([]float64, N)
+    e := make([]float64, N)
+    for k := 0; k < N; k++ {
+        d[k] = A.GetAt(k, k)
+        if k < N-1 {
+            e[k] = A.GetAt(k+1, k)
+        }
+    }
+
+    var Z *matrix.FloatMatrix = nil
+    if flags & WANTV != 0 {
+        // build Q from reflectors (LAPACK/dorgtr.f); shift reflectors one
+        // column to the right and set first row and column to unit vectors.
+        for j := N-1; j > 0; j-- {
+            A.SetAt(0, j, 0.0)
+            for i := j+1; i < N; i++ {
+                A.SetAt(i, j, A.GetAt(i, j-1))
+            }
+        }
+        A.SetAt(0, 0, 1.0)
+        for i := 1; i < N; i++ {
+            A.SetAt(i, 0, 0.0)
+        }
+        if N > 1 {
+            var tq matrix.FloatMatrix
+            A.SubMatrix(&Q1, 1, 1, N-1, N-1)
+            tau.SubMatrix(&tq, 0, 0, N-1, 1)
+            if nb == 0 || N-1 <= nb {
+                BuildQ(&Q1, &tq, nil, 0)
+            } else {
+                BuildQ(&Q1, &tq, matrix.FloatZeros(N-1, nb), nb)
+            }
+        }
+        Z = A
+    }
+
+    if err := tridiagQL(d, e, Z); err != nil {
+        return A, err
+    }
+    for k := 0; k < N; k++ {
+        if W.Cols() == 1 {
+            W.SetAt(k, 0, d[k])
+        } else {
+            W.SetAt(0, k, d[k])
+        }
+    }
+    return A, nil
+}
+
+// Local Variables:
+// tab-width: 4
+// indent-tabs-mode: nil
+// End:
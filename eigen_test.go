@@ -0,0 +1,105 @@
+
+// Copyright (c) Harri Rautila, 2013
+
+// This file is part of github.com/hrautila/matops package. It is free software,
+// distributed under the terms of GNU Lesser General Public License Version 3, or
+// any later version. See the COPYING tile included in this archive.
+
+package matops
+
+import (
+    "github.com/hrautila/matrix"
+    "math"
+    "testing"
+)
+
+func eigenResidual(A0, V, W *matrix.FloatMatrix) float64 {
+    // ||A*V - V*diag(W)||_1
+    N := A0.Rows()
+    R := matrix.FloatZeros(N, N)
+    Mult(R, A0, V, 1.0, 0.0, NOTRANS)
+    VD := V.Copy()
+    MultDiag(VD, W, RIGHT)
+    R.Minus(VD)
+    return NormP(R, NORM_ONE)
+}
+
+// Check eigen residual against ||A||_1 and that W is sorted ascending.
+func checkEigen(t *testing.T, name string, A0, V, W *matrix.FloatMatrix) {
+    eps := math.Pow(2.0, -52.0)
+    N := A0.Rows()
+    if r := eigenResidual(A0, V, W); r > 10.0*float64(N)*eps*NormP(A0, NORM_ONE) {
+        t.Errorf("%s: ||A*V - V*diag(W)||_1: %e\n", name, r)
+    }
+    for k := 1; k < W.NumElements(); k++ {
+        if W.GetIndex(k) < W.GetIndex(k-1) {
+            t.Errorf("%s: W[%d]: %e < W[%d]: %e\n", name, k, W.GetIndex(k), k-1, W.GetIndex(k-1))
+            break
+        }
+    }
+}
+
+func TestEigenSymLower(t *testing.T) {
+    N := 43
+    nb := 0
+    A := matrix.FloatNormalSymmetric(N, matrix.Lower)
+    W := matrix.FloatZeros(N, 1)
+    // A0 = full symmetric matrix
+    A0 := A.Transpose()
+    symmetrizeLower(A0)
+
+    V, err := EigenSym(A, W, LOWER|WANTV, nb)
+    if err != nil {
+        t.Errorf("EigenSym error: %v\n", err)
+        return
+    }
+    checkEigen(t, "unblk", A0, V, W)
+    checkOrtho(t, "unblk: V", V)
+
+    nb = 8
+    A = matrix.FloatNormalSymmetric(N, matrix.Lower)
+    A0 = A.Transpose()
+    symmetrizeLower(A0)
+    V, err = EigenSym(A, W, LOWER|WANTV, nb)
+    if err != nil {
+        t.Errorf("EigenSym error: %v\n", err)
+        return
+    }
+    checkEigen(t, "blk", A0, V, W)
+    // V.T*V = I
+    checkOrtho(t, "blk: V", V)
+}
+
+func TestEigenSymUpper(t *testing.T) {
+    N := 37
+    nb := 8
+    A := matrix.FloatNormalSymmetric(N, matrix.Upper)
+    A0 := A.Copy()
+    symmetrizeLower(A0)
+    W := matrix.FloatZeros(N, 1)
+    W0 := matrix.FloatZeros(N, 1)
+
+    V, err := EigenSym(A, W, UPPER|WANTV, nb)
+    if err != nil {
+        t.Errorf("EigenSym error: %v\n", err)
+        return
+    }
+    checkEigen(t, "upper", A0, V, W)
+    checkOrtho(t, "upper: V", V)
+
+    // eigenvalues only
+    eps := math.Pow(2.0, -52.0)
+    if _, err = EigenSym(A0.Copy(), W0, LOWER, nb); err != nil {
+        t.Errorf("EigenSym values only error: %v\n", err)
+        return
+    }
+    W0.Minus(W)
+    if r := NormP(W0, NORM_ONE); r > 10.0*float64(N)*eps*NormP(A0, NORM_ONE) {
+        t.Errorf("||W - W0||_1: %e\n", r)
+    }
+}
+
+// Local Variables:
+// tab-width: 4
+// indent-tabs-mode: nil
+// End:
@@ -0,0 +1,187 @@
+// Copyright (c) Harri Rautila, 2013
+
+// This file is part of github.com/hrautila/matops package. It is free software,
+// distributed under the terms of GNU Lesser General Public License Version 3, or
+// any later version. See the COPYING tile included in this archive.
+
+package matops
+
+import (
+    "github.com/hrautila/matrix"
+    "errors"
+    "fmt"
+    "strings"
+)
+
+// Error types of the matrix operations and factorizations. Use errors.As() to
+// find out the reason and location of the failure, for example
+//
+//   var npd *NotPositiveDefiniteError
+//   if _, err := DecomposeCHOL(A, LOWER, 0); errors.As(err, &npd) {
+//       // shift diagonal and retry
+//   }
+//
+// If panic-on-error flag is set (see SetPanicOnError()) argument errors are raised
+// with panic() with the error value.
+
+// Operand sizes are not compatible with the operation. Dims holds rows and columns
+// of the operands in argument order.
+type SizeMismatchError struct {
+    Op string
+    Dims [][2]int
+}
+
+func (e *SizeMismatchError) Error() string {
+    d := make([]string, len(e.Dims))
+    for k, rc := range e.Dims {
+        d[k] = fmt.Sprintf("%dx%d", rc[0], rc[1])
+    }
+    return fmt.Sprintf("%s: size mismatch [%s]", e.Op, strings.Join(d, ", "))
+}
+
+// Cholesky factorization failed; leading minor of order Column+1 is not positive
+// definite. Columns before Column hold the partial factor.
+type NotPositiveDefiniteError struct {
+    Column int
+}
+
+func (e *NotPositiveDefiniteError) Error() string {
+    return fmt.Sprintf("matrix not positive definite: non-positive pivot at column %d", e.Column)
+}
+
+// Factorization completed but factor has exact zero pivot at Index and the matrix
+// is singular; solving with the factors would divide by zero.
+type SingularError struct {
+    Index int
+}
+
+func (e *SingularError) Error() string {
+    return fmt.Sprintf("matrix singular: zero pivot at %d", e.Index)
+}
+
+// Algorithm Op could not continue at step Index, for example symmetric factorization
+// without suitable pivoting found zero on diagonal. Matrix is not necessarily singular.
+type BreakdownError struct {
+    Op string
+    Index int
+}
+
+func (e *BreakdownError) Error() string {
+    return fmt.Sprintf("%s: breakdown at %d", e.Op, e.Index)
+}
+
+// Batched operation Op failed on matrix Index of the batch with Err, e.g.
+// *SingularError; the other matrices of the batch are processed.
+type BatchError struct {
+    Op string
+    Index int
+    Err error
+}
+
+func (e *BatchError) Error() string {
+    return fmt.Sprintf("%s: matrix %d: %v", e.Op, e.Index, e.Err)
+}
+
+func (e *BatchError) Unwrap() error {
+    return e.Err
+}
+
+// Dimensions of a non-matrix operand, e.g. length of pivot array.
+type shape [2]int
+
+func (s shape) Rows() int {
+    return s[0]
+}
+
+func (s shape) Cols() int {
+    return s[1]
+}
+
+// Dimensions of workspace W; zero if W is nil.
+func workDims(W *matrix.FloatMatrix) dimensions {
+    if W == nil {
+        return shape{0, 0}
+    }
+    return W
+}
+
+// Return err; panic with err if panic-on-error flag is set.
+func raiseError(err error) error {
+    if globalParams().PanicOnError {
+        panic(err)
+    }
+    return err
+}
+
+// Return err; panic with err if conf.PanicOnError is set.
+func (conf *Config) raiseError(err error) error {
+    if conf.PanicOnError {
+        panic(err)
+    }
+    return err
+}
+
+func newSizeError(op string, dims ...dimensions) *SizeMismatchError {
+    e := &SizeMismatchError{Op: op, Dims: make([][2]int, len(dims))}
+    for k, d := range dims {
+        e.Dims[k] = [2]int{d.Rows(), d.Cols()}
+    }
+    return e
+}
+
+// Size mismatch error for operation op with operands dims.
+func sizeError(op string, dims ...dimensions) error {
+    return raiseError(newSizeError(op, dims...))
+}
+
+// Size mismatch error for operation op with operands dims; see Config.raiseError().
+func (conf *Config) sizeError(op string, dims ...dimensions) error {
+    return conf.raiseError(newSizeError(op, dims...))
+}
+
+// Invalid flags error for operation op; want names the required flag bits.
+func flagError(op, want string) error {
+    return onError(fmt.Sprintf("%s: flags must have one of %s", op, want))
+}
+
+// Invalid flags error for operation op; see Config.raiseError().
+func (conf *Config) flagError(op, want string) error {
+    return conf.onError(fmt.Sprintf("%s: flags must have one of %s", op, want))
+}
+
+// True if flags has exactly one of bits a and b.
+func oneOf(flags, a, b Flags) bool {
+    return (flags & a != 0) != (flags & b != 0)
+}
+
+// Return err with failure location moved offset steps forward; used when the
+// failed step was computed on a trailing submatrix.
+func shiftError(err error, offset int) error {
+    var npd *NotPositiveDefiniteError
+    var sng *SingularError
+    var bd *BreakdownError
+    switch {
+    case errors.As(err, &npd):
+        return &NotPositiveDefiniteError{npd.Column + offset}
+    case errors.As(err, &sng):
+        return &SingularError{sng.Index + offset}
+    case errors.As(err, &bd):
+        return &BreakdownError{bd.Op, bd.Index + offset}
+    }
+    return err
+}
+
+// Index of first zero on the diagonal of triangular factor A or -1.
+func zeroPivot(A *matrix.FloatMatrix) int {
+    for k := 0; k < imin(A.Rows(), A.Cols()); k++ {
+        if A.GetAt(k, k) == 0.0 {
+            return k
+        }
+    }
+    return -1
+}
+
+// Local Variables:
+// tab-width: 4
+// indent-tabs-mode: nil
+// End:
@@ -0,0 +1,351 @@
+
+// Copyright (c) Harri Rautila, 2013
+
+// This file is part of github.com/hrautila/matops package. It is free software,
+// distributed under the terms of GNU Lesser General Public License Version 3, or
+// any later version. See the COPYING tile included in this archive.
+
+package matops
+
+import (
+    "github.com/hrautila/matrix"
+    "errors"
+    "math"
+    "testing"
+)
+
+func TestSizeMismatchError(t *testing.T) {
+    var e *SizeMismatchError
+    C := matrix.FloatZeros(4, 4)
+    A := matrix.FloatNormal(4, 5)
+    B := matrix.FloatNormal(4, 4)
+    err := Mult(C, A, B, 1.0, 0.0, NOTRANS)
+    if ! errors.As(err, &e) {
+        t.Fatalf("Mult: expected *SizeMismatchError, got %v\n", err)
+    }
+    t.Logf("%v\n", err)
+    if e.Op != "Mult" || len(e.Dims) != 3 || e.Dims[1] != [2]int{4, 5} {
+        t.Errorf("Mult: invalid error %+v\n", e)
+    }
+    x := matrix.FloatNormal(4, 2)
+    if err := MVMult(x, B, x, 1.0, 0.0, NOTRANS); ! errors.As(err, &e) {
+        t.Errorf("MVMult: expected *SizeMismatchError, got %v\n", err)
+    }
+    if _, err := DecomposeLU(A, make([]int, 2), 0); ! errors.As(err, &e) {
+        t.Errorf("DecomposeLU: expected *SizeMismatchError, got %v\n", err)
+    }
+    if _, err := DecomposeQR(matrix.FloatNormal(20, 10), matrix.FloatZeros(10, 1),
+        matrix.FloatZeros(2, 2), 4); ! errors.As(err, &e) {
+        t.Errorf("DecomposeQR: expected *SizeMismatchError, got %v\n", err)
+    }
+}
+
+func TestSizeMismatchBlas(t *testing.T) {
+    var e *SizeMismatchError
+    C := matrix.FloatZeros(10, 10)
+    A := matrix.FloatNormal(3, 2)
+    B := matrix.FloatNormal(3, 2)
+    x := matrix.FloatNormal(3, 1)
+    y := matrix.FloatNormal(3, 1)
+    check := func(op string, err error) {
+        if ! errors.As(err, &e) || e.Op != op {
+            t.Errorf("%s: expected *SizeMismatchError, got %v\n", op, err)
+        }
+    }
+    check("RankUpdateSym", RankUpdateSym(C, A, 1.0, 1.0, LOWER))
+    check("RankUpdate2Sym", RankUpdate2Sym(C, A, B, 1.0, 1.0, LOWER))
+    check("ScalePlus", ScalePlus(C, A, 1.0, 1.0, NOTRANS))
+    check("UpdateTrm", UpdateTrm(C, A, B, 1.0, 1.0, LOWER|TRANSB))
+    check("MVMult", MVMult(y, C, x, 1.0, 0.0, NOTRANS))
+    check("MVRankUpdate", MVRankUpdate(C, x, y, 1.0))
+    check("MVRankUpdateSym", MVRankUpdateSym(C, x, 1.0, LOWER))
+    check("MVRankUpdate2Sym", MVRankUpdate2Sym(C, x, y, 1.0, LOWER))
+    check("MVUpdateTrm", MVUpdateTrm(C, x, y, 1.0, LOWER))
+    check("MVSolveTrm", MVSolveTrm(x, C, 1.0, LOWER))
+    check("MVMultTrm", MVMultTrm(x, C, LOWER))
+    // matching sizes with transposes are accepted
+    A = matrix.FloatNormal(4, 10)
+    if err := RankUpdateSym(C, A, 1.0, 1.0, LOWER|TRANSA); err != nil {
+        t.Errorf("RankUpdateSym TRANSA: %v\n", err)
+    }
+    if err := ScalePlus(C, matrix.FloatNormal(10, 10), 1.0, 1.0, TRANSB); err != nil {
+        t.Errorf("ScalePlus TRANSB: %v\n", err)
+    }
+    if err := MVMult(x, A.Transpose(), matrix.FloatNormal(1, 4), 1.0, 0.0, TRANSA); err == nil {
+        t.Errorf("MVMult TRANSA: size mismatch not detected\n")
+    }
+}
+
+func TestNotPositiveDefiniteError(t *testing.T) {
+    N := 40
+    for _, nb := range []int{0, 8} {
+        for _, flags := range []Flags{LOWER, UPPER} {
+            var e *NotPositiveDefiniteError
+            A := spdNormal(N)
+            A.SetAt(27, 27, -1.0)
+            _, err := DecomposeCHOL(A, flags, nb)
+            if ! errors.As(err, &e) {
+                t.Fatalf("DecomposeCHOL: expected *NotPositiveDefiniteError, got %v\n", err)
+            }
+            if e.Column != 27 {
+                t.Errorf("DecomposeCHOL flags %x nb %d: column %d, expected 27\n", flags, nb, e.Column)
+            }
+        }
+    }
+}
+
+func TestSingularError(t *testing.T) {
+    N := 30
+    for _, nb := range []int{0, 8} {
+        var e *SingularError
+        A := matrix.FloatNormal(N, N)
+        // zero column 12
+        for i := 0; i < N; i++ {
+            A.SetAt(i, 12, 0.0)
+        }
+        _, err := DecomposeLU(A, make([]int, N), nb)
+        if ! errors.As(err, &e) {
+            t.Fatalf("DecomposeLU: expected *SingularError, got %v\n", err)
+        }
+        t.Logf("nb %d: %v\n", nb, err)
+        if e.Index != 12 {
+            t.Errorf("DecomposeLU nb %d: index %d, expected 12\n", nb, e.Index)
+        }
+    }
+
+    // SolveLU on singular factor returns error and leaves B unchanged
+    A := matrix.FloatNormal(N, N)
+    for i := 0; i < N; i++ {
+        A.SetAt(i, 12, 0.0)
+    }
+    pivots := make([]int, N)
+    DecomposeLU(A, pivots, 0)
+    for _, flags := range []Flags{NOTRANS, TRANSA} {
+        var e *SingularError
+        B := matrix.FloatNormal(N, 2)
+        B0 := B.Copy()
+        err := SolveLU(B, A, pivots, flags)
+        if ! errors.As(err, &e) || e.Index != 12 {
+            t.Errorf("SolveLU flags %x: expected *SingularError at 12, got %v\n", flags, err)
+        }
+        if ! B.AllClose(B0) {
+            t.Errorf("SolveLU flags %x: B changed on error\n", flags)
+        }
+    }
+
+    var e *SingularError
+    A = matrix.FloatZeros(6, 6)
+    W := matrix.FloatZeros(6, 2)
+    if _, err := DecomposeBK(A, W, make([]int, 6), LOWER, 0); ! errors.As(err, &e) {
+        t.Errorf("DecomposeBK: expected *SingularError, got %v\n", err)
+    }
+}
+
+func TestBreakdownError(t *testing.T) {
+    var e *BreakdownError
+    // nonsingular but zero on diagonal
+    A := matrix.FloatWithValue(4, 4, 1.0)
+    for k := 0; k < 4; k++ {
+        A.SetAt(k, k, 0.0)
+    }
+    _, err := DecomposeLUnoPiv(A.Copy(), 0)
+    if ! errors.As(err, &e) || e.Index != 0 {
+        t.Errorf("DecomposeLUnoPiv: expected *BreakdownError at 0, got %v\n", err)
+    }
+    _, err = DecomposeLDLnoPiv(A.Copy(), nil, LOWER, 0)
+    if ! errors.As(err, &e) || e.Index != 0 {
+        t.Errorf("DecomposeLDLnoPiv: expected *BreakdownError at 0, got %v\n", err)
+    }
+}
+
+func TestStructuredTypedErrors(t *testing.T) {
+    var npd *NotPositiveDefiniteError
+    var sng *SingularError
+    var sz *SizeMismatchError
+    N := 12
+    S := spdNormal(N)
+    S.SetAt(5, 5, -1.0)
+    for _, flags := range []Flags{LOWER, UPPER} {
+        Ab := BandedTrmMatrix(S, 2, flags)
+        if _, err := DecomposeCHOLBand(Ab, flags); ! errors.As(err, &npd) {
+            t.Errorf("DecomposeCHOLBand flags %x: expected *NotPositiveDefiniteError, got %v\n", flags, err)
+        }
+        Ap, _ := PackMatrix(S, flags)
+        if _, err := DecomposeCHOLPacked(Ap); ! errors.As(err, &npd) || npd.Column != 5 {
+            t.Errorf("DecomposeCHOLPacked flags %x: expected *NotPositiveDefiniteError at 5, got %v\n", flags, err)
+        }
+        if err := SolveCHOLPacked(matrix.FloatZeros(N+1, 1), Ap); ! errors.As(err, &sz) {
+            t.Errorf("SolveCHOLPacked: expected *SizeMismatchError, got %v\n", err)
+        }
+    }
+    d := matrix.FloatWithValue(N, 1, 2.0)
+    e := matrix.FloatWithValue(N-1, 1, 1.0)
+    d.SetIndex(3, -1.0)
+    if err := DecomposeTridiagSPD(d, e); ! errors.As(err, &npd) || npd.Column != 3 {
+        t.Errorf("DecomposeTridiagSPD: expected *NotPositiveDefiniteError at 3, got %v\n", err)
+    }
+    d = matrix.FloatZeros(N, 1)
+    B := matrix.FloatNormal(N, 1)
+    dl, du := matrix.FloatZeros(N-1, 1), matrix.FloatZeros(N-1, 1)
+    if err := SolveTridiag(dl, d, du, B); ! errors.As(err, &sng) || sng.Index != 0 {
+        t.Errorf("SolveTridiag: expected *SingularError at 0, got %v\n", err)
+    }
+    if err := SolveTridiag(nil, d, du, B); ! errors.As(err, &sz) {
+        t.Errorf("SolveTridiag: expected *SizeMismatchError, got %v\n", err)
+    }
+    A := matrix.FloatNormal(N, N)
+    for i := 0; i < N; i++ {
+        A.SetAt(i, 7, 0.0)
+    }
+    Alu := BandedLUMatrix(A, N-1, N-1)
+    if _, err := DecomposeLUBand(Alu, make([]int, N), N-1); ! errors.As(err, &sng) || sng.Index != 7 {
+        t.Errorf("DecomposeLUBand: expected *SingularError at 7, got %v\n", err)
+    }
+    if _, err := DecomposeLUBand(Alu, make([]int, 2), N-1); ! errors.As(err, &sz) {
+        t.Errorf("DecomposeLUBand: expected *SizeMismatchError, got %v\n", err)
+    }
+    A.SetAt(7, 7, 0.0)
+    if _, err := InverseLU(A, make([]int, N), nil, 0); ! errors.As(err, &sng) || sng.Index != 7 {
+        t.Errorf("InverseLU: expected *SingularError at 7, got %v\n", err)
+    }
+    if _, err := EstimateCondLU(matrix.FloatNormal(N, N+1), nil, 1.0, NORM_ONE); ! errors.As(err, &sz) {
+        t.Errorf("EstimateCondLU: expected *SizeMismatchError, got %v\n", err)
+    }
+    if _, err := BuildLQ(matrix.FloatNormal(N+1, N), nil, nil, 0); ! errors.As(err, &sz) {
+        t.Errorf("BuildLQ: expected *SizeMismatchError, got %v\n", err)
+    }
+    x := matrix.FloatNormal(N+1, 1)
+    if err := MVMultBand(x, Alu, x, 1.0, 0.0, N-1, NOTRANS); ! errors.As(err, &sz) {
+        t.Errorf("MVMultBand: expected *SizeMismatchError, got %v\n", err)
+    }
+    if err := DecomposeSVD(A, matrix.FloatZeros(2, 1), nil, nil, nil, 0, 0); ! errors.As(err, &sz) {
+        t.Errorf("DecomposeSVD: expected *SizeMismatchError, got %v\n", err)
+    }
+    if _, err := EigenSym(matrix.FloatNormal(N, N+1), d, LOWER, 0); ! errors.As(err, &sz) {
+        t.Errorf("EigenSym: expected *SizeMismatchError, got %v\n", err)
+    }
+    // NaN never converges
+    var bd *BreakdownError
+    A.SetAt(2, 3, math.NaN())
+    err := DecomposeSVD(A, matrix.FloatZeros(N, 1), nil, nil, nil, 0, 0)
+    if ! errors.As(err, &bd) || bd.Op != "DecomposeSVD" {
+        t.Errorf("DecomposeSVD: expected *BreakdownError, got %v\n", err)
+    }
+}
+
+func TestSolveChecked(t *testing.T) {
+    N := 20
+    S := spdNormal(N)
+    solve := map[string]func(B, F *matrix.FloatMatrix, ipiv []int, flags Flags) error {
+        "SolveCHOL": func(B, F *matrix.FloatMatrix, ipiv []int, flags Flags) error {
+            return SolveCHOLChecked(B, F, flags)
+        },
+        "SolveLDL": SolveLDLChecked,
+        "SolveLDLnoPiv": func(B, F *matrix.FloatMatrix, ipiv []int, flags Flags) error {
+            return SolveLDLnoPivChecked(B, F, flags)
+        },
+        "SolveBK": SolveBKChecked,
+    }
+    for name, solver := range solve {
+        for _, flags := range []Flags{LOWER, UPPER} {
+            F := S.Copy()
+            ipiv := make([]int, N)
+            switch name {
+            case "SolveCHOL":
+                DecomposeCHOL(F, flags, 0)
+            case "SolveLDL":
+                DecomposeLDL(F, nil, ipiv, flags, 0)
+            case "SolveLDLnoPiv":
+                DecomposeLDLnoPiv(F, nil, flags, 0)
+            case "SolveBK":
+                DecomposeBK(F, matrix.FloatZeros(N, 2), ipiv, flags, 0)
+            }
+            X := matrix.FloatNormal(N, 3)
+            B := matrix.FloatZeros(N, 3)
+            MultSym(B, S, X, 1.0, 0.0, flags|LEFT)
+            if err := solver(B, F, ipiv, flags); err != nil {
+                t.Fatalf("%s flags %x: %v\n", name, flags, err)
+            }
+            if d := NormP(B.Minus(X), NORM_ONE); d > 1e-10 {
+                t.Errorf("%s flags %x: ||X - A.-1*(A*X)||_1: %e\n", name, flags, d)
+            }
+
+            var se *SizeMismatchError
+            if err := solver(matrix.FloatZeros(N-1, 3), F, ipiv, flags); ! errors.As(err, &se) {
+                t.Errorf("%s: expected *SizeMismatchError, got %v\n", name, err)
+            }
+            if err := solver(B, F, ipiv, NOTRANS); err == nil {
+                t.Errorf("%s: missing UPPER or LOWER not detected\n", name)
+            }
+            if err := solver(B, F, ipiv, LOWER|UPPER); err == nil {
+                t.Errorf("%s: both UPPER and LOWER not detected\n", name)
+            }
+            var sg *SingularError
+            F.SetAt(7, 7, 0.0)
+            for k := range ipiv {
+                // 1x1 pivots only
+                ipiv[k] = k + 1
+            }
+            B0 := B.Copy()
+            if err := solver(B, F, ipiv, flags); ! errors.As(err, &sg) || sg.Index != 7 {
+                t.Errorf("%s: expected *SingularError at 7, got %v\n", name, err)
+            }
+            if d := NormP(B.Minus(B0), NORM_ONE); d != 0.0 {
+                t.Errorf("%s: B changed on error\n", name)
+            }
+        }
+    }
+    var se *SizeMismatchError
+    if err := SolveLDLChecked(matrix.FloatZeros(N, 1), S, make([]int, 3), LOWER); ! errors.As(err, &se) {
+        t.Errorf("SolveLDL: short pivot array not detected, got %v\n", err)
+    }
+}
+
+func TestDiagChecked(t *testing.T) {
+    C := matrix.FloatNormal(4, 6)
+    for _, D := range []*matrix.FloatMatrix{matrix.FloatNormal(4, 1), matrix.FloatNormal(1, 4),
+        matrix.FloatNormal(4, 4)} {
+        C0 := C.Copy()
+        if err := MultDiagChecked(C, D, LEFT); err != nil {
+            t.Fatalf("MultDiag: %v\n", err)
+        }
+        if err := SolveDiagChecked(C, D, LEFT); err != nil {
+            t.Fatalf("SolveDiag: %v\n", err)
+        }
+        if d := NormP(C0.Minus(C), NORM_ONE); d > 1e-12 {
+            t.Errorf("||C - D.-1*D*C||_1: %e\n", d)
+        }
+        var se *SizeMismatchError
+        if err := MultDiagChecked(C, D, RIGHT); ! errors.As(err, &se) {
+            t.Errorf("MultDiag: expected *SizeMismatchError, got %v\n", err)
+        }
+        if err := SolveDiagChecked(C, D, LEFT|RIGHT); err == nil {
+            t.Errorf("SolveDiag: both LEFT and RIGHT not detected\n")
+        }
+    }
+    var sg *SingularError
+    D := matrix.FloatWithValue(6, 1, 2.0)
+    D.SetAt(4, 0, 0.0)
+    if err := SolveDiagChecked(C, D, RIGHT); ! errors.As(err, &sg) || sg.Index != 4 {
+        t.Errorf("SolveDiag: expected *SingularError at 4, got %v\n", err)
+    }
+}
+
+func TestPanicOnTypedError(t *testing.T) {
+    SetPanicOnError(true)
+    defer SetPanicOnError(false)
+    defer func() {
+        var e *SizeMismatchError
+        r := recover()
+        err, ok := r.(error)
+        if ! ok || ! errors.As(err, &e) {
+            t.Errorf("panic value %v is not *SizeMismatchError\n", r)
+        }
+    }()
+    Mult(matrix.FloatZeros(3, 3), matrix.FloatZeros(3, 2), matrix.FloatZeros(3, 3), 1.0, 0.0, NOTRANS)
+}
+
+// Local Variables:
+// tab-width: 4
+// indent-tabs-mode: nil
+// End:
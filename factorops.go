@@ -0,0 +1,93 @@
+// Copyright (c) Harri Rautila, 2013
+
+// This file is part of github.com/hrautila/matops package. It is free software,
+// distributed under the terms of GNU Lesser General Public License Version 3, or
+// any later version. See the COPYING tile included in this archive.
+
+package matops
+
+import (
+    "github.com/hrautila/matrix"
+)
+
+/*
+ * Element type specific operations of the serial Cholesky and LU factorizations
+ * in chol.go and lu.go. Implemented by float64Ops for matrix.FloatMatrix and by
+ * float32Ops for Float32Matrix; scalars are passed as float64.
+ */
+type factorOps[M any] interface {
+    // element [0,0] of A
+    get(A M) float64
+    set(A M, val float64)
+    // alpha*X.T*Y for vectors X, Y
+    dot(X, Y M, alpha float64) float64
+    // X = X/alpha
+    invScale(X M, alpha float64)
+    // X = A.-1*X, A triangular
+    mvSolveTrm(X, A M, flags Flags) error
+    // Y = beta*Y + alpha*A*X
+    mvMult(Y, A, X M, alpha, beta float64, flags Flags) error
+    // A = A + alpha*X*X.T, UPPER or LOWER triangle
+    mvRankUpdateSym(A, X M, alpha float64, flags Flags) error
+    solveTrm(B, A M, alpha float64, flags Flags) error
+    mult(C, A, B M, alpha, beta float64, flags Flags) error
+    rankUpdateSym(C, A M, alpha, beta float64, flags Flags) error
+    // index of largest absolute value on first column of A to p.pivots[0]
+    pivotIndex(A M, p *pPivots)
+    applyPivots(A M, p *pPivots)
+}
+
+type float64Ops struct{}
+
+func (float64Ops) get(A *matrix.FloatMatrix) float64 {
+    return A.Float()
+}
+
+func (float64Ops) set(A *matrix.FloatMatrix, val float64) {
+    A.SetAt(0, 0, val)
+}
+
+func (float64Ops) dot(X, Y *matrix.FloatMatrix, alpha float64) float64 {
+    return Dot(X, Y, alpha)
+}
+
+func (float64Ops) invScale(X *matrix.FloatMatrix, alpha float64) {
+    InvScale(X, alpha)
+}
+
+func (float64Ops) mvSolveTrm(X, A *matrix.FloatMatrix, flags Flags) error {
+    return MVSolveTrm(X, A, 1.0, flags)
+}
+
+func (float64Ops) mvMult(Y, A, X *matrix.FloatMatrix, alpha, beta float64, flags Flags) error {
+    return MVMult(Y, A, X, alpha, beta, flags)
+}
+
+func (float64Ops) mvRankUpdateSym(A, X *matrix.FloatMatrix, alpha float64, flags Flags) error {
+    return MVRankUpdateSym(A, X, alpha, flags)
+}
+
+func (float64Ops) solveTrm(B, A *matrix.FloatMatrix, alpha float64, flags Flags) error {
+    return SolveTrm(B, A, alpha, flags)
+}
+
+func (float64Ops) mult(C, A, B *matrix.FloatMatrix, alpha, beta float64, flags Flags) error {
+    return Mult(C, A, B, alpha, beta, flags)
+}
+
+func (float64Ops) rankUpdateSym(C, A *matrix.FloatMatrix, alpha, beta float64, flags Flags) error {
+    return RankUpdateSym(C, A, alpha, beta, flags)
+}
+
+func (float64Ops) pivotIndex(A *matrix.FloatMatrix, p *pPivots) {
+    pivotIndex(A, p)
+}
+
+func (float64Ops) applyPivots(A *matrix.FloatMatrix, p *pPivots) {
+    applyPivots(A, p)
+}
+
+// Local Variables:
+// tab-width: 4
+// indent-tabs-mode: nil
+// End:
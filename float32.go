@@ -0,0 +1,155 @@
+// Copyright (c) Harri Rautila, 2013
+
+// This file is part of github.com/hrautila/matops package. It is free software,
+// distributed under the terms of GNU Lesser General Public License Version 3, or
+// any later version. See the COPYING tile included in this archive.
+
+package matops
+
+import (
+    "github.com/hrautila/matrix"
+    "fmt"
+)
+
+// Single precision column-major matrix. Element (i, j) is at index i + j*LeadingIndex()
+// of Float32Array(). Submatrices share elements with their parent matrix as with
+// matrix.FloatMatrix.
+type Float32Matrix struct {
+    elements []float32
+    offset int
+    rows, cols, step int
+}
+
+// Create new rows*cols matrix with elements copied from column-major array elems.
+// If elems is nil or shorter than rows*cols remaining elements are zero.
+func Float32New(rows, cols int, elems []float32) *Float32Matrix {
+    A := Float32Zeros(rows, cols)
+    copy(A.elements, elems)
+    return A
+}
+
+// Create new rows*cols matrix of zeros.
+func Float32Zeros(rows, cols int) *Float32Matrix {
+    return &Float32Matrix{make([]float32, rows*cols), 0, rows, cols, imax(1, rows)}
+}
+
+// Create single precision copy of A.
+func Float32FromFloat(A *matrix.FloatMatrix) *Float32Matrix {
+    B := Float32Zeros(A.Rows(), A.Cols())
+    for j := 0; j < A.Cols(); j++ {
+        for i := 0; i < A.Rows(); i++ {
+            B.elements[i + j*B.step] = float32(A.GetAt(i, j))
+        }
+    }
+    return B
+}
+
+// Create double precision copy of A.
+func (A *Float32Matrix) ToFloat() *matrix.FloatMatrix {
+    B := matrix.FloatZeros(A.rows, A.cols)
+    for j := 0; j < A.cols; j++ {
+        for i := 0; i < A.rows; i++ {
+            B.SetAt(i, j, float64(A.GetAt(i, j)))
+        }
+    }
+    return B
+}
+
+func (A *Float32Matrix) Rows() int {
+    return A.rows
+}
+
+func (A *Float32Matrix) Cols() int {
+    return A.cols
+}
+
+func (A *Float32Matrix) Size() (int, int) {
+    return A.rows, A.cols
+}
+
+func (A *Float32Matrix) NumElements() int {
+    return A.rows*A.cols
+}
+
+// Distance between elements (i, j) and (i, j+1) in element array.
+func (A *Float32Matrix) LeadingIndex() int {
+    return A.step
+}
+
+// Element array starting at element (0, 0); nil for empty matrix past the end
+// of parent matrix.
+func (A *Float32Matrix) Float32Array() []float32 {
+    if A.offset >= len(A.elements) {
+        return nil
+    }
+    return A.elements[A.offset:]
+}
+
+func (A *Float32Matrix) index(i, j int) int {
+    if i < 0 || i >= A.rows || j < 0 || j >= A.cols {
+        panic(fmt.Sprintf("index (%d,%d) out of bounds [%d,%d]", i, j, A.rows, A.cols))
+    }
+    return A.offset + i + j*A.step
+}
+
+func (A *Float32Matrix) GetAt(i, j int) float32 {
+    return A.elements[A.index(i, j)]
+}
+
+func (A *Float32Matrix) SetAt(i, j int, val float32) {
+    A.elements[A.index(i, j)] = val
+}
+
+// Value of element (0, 0).
+func (A *Float32Matrix) Float() float32 {
+    return A.GetAt(0, 0)
+}
+
+// Copy of A with leading index A.Rows().
+func (A *Float32Matrix) Copy() *Float32Matrix {
+    B := Float32Zeros(A.rows, A.cols)
+    for j := 0; j < A.cols; j++ {
+        for i := 0; i < A.rows; i++ {
+            B.elements[i + j*B.step] = A.GetAt(i, j)
+        }
+    }
+    return B
+}
+
+// Make B submatrix of A starting at (row, col). Optional sizes are rows and cols
+// of the submatrix; default is the rest of A. If B is nil new matrix is allocated.
+func (A *Float32Matrix) SubMatrix(B *Float32Matrix, row, col int, sizes ...int) *Float32Matrix {
+    if B == nil {
+        B = new(Float32Matrix)
+    }
+    nr, nc := A.rows - row, A.cols - col
+    if len(sizes) >= 2 {
+        nr, nc = sizes[0], sizes[1]
+    }
+    if row < 0 || col < 0 || nr < 0 || nc < 0 || row+nr > A.rows || col+nc > A.cols {
+        panic(fmt.Sprintf("submatrix [%d:%d, %d:%d] out of bounds [%d,%d]",
+            row, row+nr, col, col+nc, A.rows, A.cols))
+    }
+    *B = Float32Matrix{A.elements, A.offset + row + col*A.step, nr, nc, A.step}
+    return B
+}
+
+func (A *Float32Matrix) String() string {
+    s := ""
+    for i := 0; i < A.rows; i++ {
+        s += "["
+        for j := 0; j < A.cols; j++ {
+            if j > 0 {
+                s += ", "
+            }
+            s += fmt.Sprintf("%9.2e", A.GetAt(i, j))
+        }
+        s += "]\n"
+    }
+    return s
+}
+
+// Local Variables:
+// tab-width: 4
+// indent-tabs-mode: nil
+// End:
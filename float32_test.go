@@ -0,0 +1,182 @@
+
+// Copyright (c) Harri Rautila, 2013
+
+// This file is part of github.com/hrautila/matops package. It is free software,
+// distributed under the terms of GNU Lesser General Public License Version 3, or
+// any later version. See the COPYING tile included in this archive.
+
+package matops
+
+import (
+    "github.com/hrautila/matrix"
+    "testing"
+    "math"
+)
+
+// max |A - B| with B in single precision
+func sDiff(A *matrix.FloatMatrix, B *Float32Matrix) float64 {
+    var d float64
+    for j := 0; j < A.Cols(); j++ {
+        for i := 0; i < A.Rows(); i++ {
+            d = math.Max(d, math.Abs(A.GetAt(i, j) - float64(B.GetAt(i, j))))
+        }
+    }
+    return d
+}
+
+func TestSingleMult(t *testing.T) {
+    M, N, P := 73, 61, 47
+    A := matrix.FloatNormal(M, P)
+    B := matrix.FloatNormal(P, N)
+    C := matrix.FloatZeros(M, N)
+    As, Bs := Float32FromFloat(A), Float32FromFloat(B)
+    Cs := Float32Zeros(M, N)
+    Mult(C, As.ToFloat(), Bs.ToFloat(), 1.0, 0.0, NOTRANS)
+    SMult(Cs, As, Bs, 1.0, 0.0, NOTRANS)
+    t.Logf("SMult: max |C - Cs|: %e\n", sDiff(C, Cs))
+    if sDiff(C, Cs) > 1e-4 {
+        t.Errorf("SMult: difference too large")
+    }
+    if err := SMult(Cs, Bs, As, 1.0, 0.0, NOTRANS); err == nil {
+        t.Errorf("SMult: size mismatch not detected")
+    }
+
+    X := matrix.FloatNormal(P, 1)
+    Y := matrix.FloatZeros(M, 1)
+    Xs, Ys := Float32FromFloat(X), Float32Zeros(M, 1)
+    MVMult(Y, As.ToFloat(), Xs.ToFloat(), 2.0, 0.0, NOTRANS)
+    SMVMult(Ys, As, Xs, 2.0, 0.0, NOTRANS)
+    t.Logf("SMVMult: max |Y - Ys|: %e\n", sDiff(Y, Ys))
+    if sDiff(Y, Ys) > 1e-4 {
+        t.Errorf("SMVMult: difference too large")
+    }
+}
+
+func TestSingleSolveTrm(t *testing.T) {
+    N, K := 41, 13
+    A := TriL(matrix.FloatNormal(N, N))
+    for k := 0; k < N; k++ {
+        A.SetAt(k, k, 4.0)
+    }
+    As := Float32FromFloat(A)
+    for _, flags := range []Flags{LEFT|LOWER, LEFT|LOWER|TRANSA, RIGHT|LOWER, RIGHT|LOWER|TRANSA} {
+        B := matrix.FloatNormal(N, K)
+        if flags & RIGHT != 0 {
+            B = matrix.FloatNormal(K, N)
+        }
+        Bs := Float32FromFloat(B)
+        SolveTrm(B, As.ToFloat(), 2.0, flags)
+        SSolveTrm(Bs, As, 2.0, flags)
+        if d := sDiff(B, Bs); d > 1e-4 {
+            t.Errorf("SSolveTrm flags %x: max difference %e\n", flags, d)
+        }
+    }
+}
+
+func TestSingleCHOL(t *testing.T) {
+    N := 60
+    Z := matrix.FloatUniform(N, N)
+    A := matrix.Times(Z, Z.Transpose())
+    for k := 0; k < N; k++ {
+        A.SetAt(k, k, A.GetAt(k, k) + 1.0)
+    }
+    for _, flags := range []Flags{LOWER, UPPER} {
+        for _, nb := range []int{0, 16} {
+            As := Float32FromFloat(A)
+            R, err := SDecomposeCHOL(As, flags, nb)
+            if err != nil {
+                t.Fatalf("SDecomposeCHOL: %v\n", err)
+            }
+            // A - R*R.T or A - R.T*R
+            Rd := TriL(R.ToFloat())
+            trans := Flags(TRANSB)
+            if flags & UPPER != 0 {
+                Rd = TriU(R.ToFloat())
+                trans = TRANSA
+            }
+            C := A.Copy()
+            Mult(C, Rd, Rd, -1.0, 1.0, trans)
+            nrm := NormP(C, NORM_ONE)/NormP(A, NORM_ONE)
+            t.Logf("flags %x nb %d: ||A - chol(A)||_1/||A||_1: %e\n", flags, nb, nrm)
+            if nrm > 1e-5 {
+                t.Errorf("SDecomposeCHOL: residual too large")
+            }
+        }
+    }
+    As := Float32FromFloat(A)
+    As.SetAt(3, 3, -1.0)
+    for _, nb := range []int{0, 2} {
+        _, err := SDecomposeCHOL(As.Copy(), LOWER, nb)
+        if e, ok := err.(*NotPositiveDefiniteError); ! ok || e.Column != 3 {
+            t.Errorf("nb %d: SDecomposeCHOL: indefinite matrix: %v\n", nb, err)
+        }
+    }
+    if _, err := SDecomposeCHOL(Float32Zeros(3, 4), LOWER, 0); err == nil {
+        t.Errorf("SDecomposeCHOL: non-square matrix not detected\n")
+    } else if _, ok := err.(*SizeMismatchError); ! ok {
+        t.Errorf("SDecomposeCHOL: non-square matrix: %v\n", err)
+    }
+}
+
+func TestSingleLU(t *testing.T) {
+    M, N := 60, 50
+    A := matrix.FloatNormal(M, N)
+    pivots := make([]int, N)
+    for _, nb := range []int{0, 16} {
+        As := Float32FromFloat(A)
+        LU, _ := SDecomposeLU(As, pivots, nb)
+        // P*L*U - A
+        L := matrix.FloatZeros(M, N)
+        U := matrix.FloatZeros(N, N)
+        for j := 0; j < N; j++ {
+            for i := 0; i < M; i++ {
+                switch {
+                case i == j:
+                    L.SetAt(i, j, 1.0)
+                case i > j:
+                    L.SetAt(i, j, float64(LU.GetAt(i, j)))
+                }
+            }
+        }
+        for j := 0; j < N; j++ {
+            for i := 0; i <= j; i++ {
+                U.SetAt(i, j, float64(LU.GetAt(i, j)))
+            }
+        }
+        C := A.Copy()
+        applyPivots(C, &pPivots{pivots})
+        Mult(C, L, U, -1.0, 1.0, NOTRANS)
+        nrm := NormP(C, NORM_ONE)/NormP(A, NORM_ONE)
+        t.Logf("nb %d: ||P*A - L*U||_1/||A||_1: %e\n", nb, nrm)
+        if nrm > 1e-5 {
+            t.Errorf("SDecomposeLU: residual too large")
+        }
+    }
+    if _, err := SDecomposeLU(Float32FromFloat(A), pivots[:N-1], 0); err == nil {
+        t.Errorf("SDecomposeLU: short pivot array not detected\n")
+    } else if _, ok := err.(*SizeMismatchError); ! ok {
+        t.Errorf("SDecomposeLU: short pivot array: %v\n", err)
+    }
+}
+
+func TestSingleVector(t *testing.T) {
+    N := 27
+    X := matrix.FloatNormal(N, 1)
+    Y := matrix.FloatNormal(1, N)
+    Xs, Ys := Float32FromFloat(X), Float32FromFloat(Y)
+    d := math.Abs(Dot(Xs.ToFloat(), Ys.ToFloat(), 2.0) - float64(SDot(Xs, Ys, 2.0)))
+    d = math.Max(d, math.Abs(Norm2(Xs.ToFloat()) - float64(SNorm2(Xs))))
+    Axpy(Y, Xs.ToFloat(), 2.0)
+    SAxpy(Ys, Xs, 2.0)
+    Scale(Y, 0.5)
+    SScale(Ys, 0.5)
+    d = math.Max(d, sDiff(Y, Ys))
+    if d > 1e-5 {
+        t.Errorf("single precision vector operations: max difference %e\n", d)
+    }
+}
+
+// Local Variables:
+// tab-width: 4
+// indent-tabs-mode: nil
+// End:
@@ -0,0 +1,80 @@
+
+// Copyright (c) Harri Rautila, 2013
+
+// This file is part of github.com/hrautila/matops package. It is free software,
+// distributed under the terms of GNU Lesser General Public License Version 3, or
+// any later version. See the COPYING tile included in this archive.
+
+package matops
+
+import (
+    "github.com/hrautila/matrix"
+    "math"
+)
+
+/* From LAPACK/dlartg.f
+ *
+ * computeGivens() generates a plane rotation so that
+ *
+ *     (  c  s ) ( f )   ( r )
+ *     ( -s  c ) ( g ) = ( 0 ),    c**2 + s**2 = 1
+ *
+ * If g == 0, then c = 1 and s = 0. If f == 0 and g != 0 then c = 0 and s = 1.
+ */
+func computeGivens(f, g float64) (c, s, r float64) {
+    if g == 0.0 {
+        return 1.0, 0.0, f
+    }
+    if f == 0.0 {
+        return 0.0, 1.0, g
+    }
+    r = sqrtX2Y2(f, g)
+    c = f / r
+    s = g / r
+    if math.Abs(f) > math.Abs(g) && c < 0.0 {
+        c = -c
+        s = -s
+        r = -r
+    }
+    return
+}
+
+/*
+ * Apply plane rotation to vectors X and Y (blas.DROT).
+ *
+ *     X = c*X + s*Y
+ *     Y = c*Y - s*X
+ *
+ * Vectors X and Y are row or column vectors of same length.
+ */
+func applyGivens(X, Y *matrix.FloatMatrix, c, s float64) {
+    if X.NumElements() == 0 {
+        return
+    }
+    Xr := X.FloatArray()
+    incX := 1
+    if X.Cols() != 1 {
+        // Row vector
+        incX = X.LeadingIndex()
+    }
+    Yr := Y.FloatArray()
+    incY := 1
+    if Y.Cols() != 1 {
+        // Row vector
+        incY = Y.LeadingIndex()
+    }
+    ix, iy := 0, 0
+    for k := 0; k < X.NumElements(); k++ {
+        x := Xr[ix]
+        y := Yr[iy]
+        Xr[ix] = c*x + s*y
+        Yr[iy] = c*y - s*x
+        ix += incX
+        iy += incY
+    }
+}
+
+// Local Variables:
+// tab-width: 4
+// indent-tabs-mode: nil
+// End:
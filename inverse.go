@@ -0,0 +1,468 @@
+
+// Copyright (c) Harri Rautila, 2013
+
+// This file is part of github.com/hrautila/matops package. It is free software,
+// distributed under the terms of GNU Lesser General Public License Version 3, or
+// any later version. See the COPYING tile included in this archive.
+
+package matops
+
+import (
+    "github.com/hrautila/matrix"
+    //"fmt"
+)
+
+// Compute product L.T*L of lower triangular matrix in place (LAPACK/dlauu2.f)
+func unblockedTrmProductLower(A *matrix.FloatMatrix) {
+    var ATL, ATR, ABL, ABR matrix.FloatMatrix
+    var A00, a10t, a11, A20, a21, A22 matrix.FloatMatrix
+
+    partition2x2(
+        &ATL, &ATR,
+        &ABL, &ABR,   A, 0, 0, pTOPLEFT)
+
+    for ATL.Rows() < A.Rows() {
+        repartition2x2to3x3(&ATL,
+            &A00,  nil,  nil,
+            &a10t, &a11, nil,
+            &A20,  &a21, &A22,   A, 1, pBOTTOMRIGHT)
+        // -------------------------------------------------
+        aval := a11.Float()
+        // a10t = a11*a10t + a21.T*A20
+        Scale(&a10t, aval)
+        MVMult(&a10t, &A20, &a21, 1.0, 1.0, TRANSA)
+        // a11 = a11*a11 + a21.T*a21
+        aval *= aval
+        if a21.NumElements() > 0 {
+            aval += Dot(&a21, &a21, 1.0)
+        }
+        a11.SetAt(0, 0, aval)
+        // -------------------------------------------------
+        continue3x3to2x2(
+            &ATL, &ATR,
+            &ABL, &ABR,   &A00, &a11, &A22,  A, pBOTTOMRIGHT)
+    }
+}
+
+// Compute product U*U.T of upper triangular matrix in place (LAPACK/dlauu2.f)
+func unblockedTrmProductUpper(A *matrix.FloatMatrix) {
+    var ATL, ATR, ABL, ABR matrix.FloatMatrix
+    var A00, a01, A02, a11, a12t, A22 matrix.FloatMatrix
+
+    partition2x2(
+        &ATL, &ATR,
+        &ABL, &ABR,   A, 0, 0, pTOPLEFT)
+
+    for ATL.Rows() < A.Rows() {
+        repartition2x2to3x3(&ATL,
+            &A00, &a01, &A02,
+            nil,  &a11, &a12t,
+            nil,  nil,  &A22,   A, 1, pBOTTOMRIGHT)
+        // -------------------------------------------------
+        aval := a11.Float()
+        // a01 = a11*a01 + A02*a12
+        Scale(&a01, aval)
+        MVMult(&a01, &A02, &a12t, 1.0, 1.0, NOTRANS)
+        // a11 = a11*a11 + a12*a12.T
+        aval *= aval
+        if a12t.NumElements() > 0 {
+            aval += Dot(&a12t, &a12t, 1.0)
+        }
+        a11.SetAt(0, 0, aval)
+        // -------------------------------------------------
+        continue3x3to2x2(
+            &ATL, &ATR,
+            &ABL, &ABR,   &A00, &a11, &A22,  A, pBOTTOMRIGHT)
+    }
+}
+
+// Blocked version of L.T*L (LAPACK/dlauum.f)
+func blockedTrmProductLower(A *matrix.FloatMatrix, nb int) {
+    var ATL, ATR, ABL, ABR matrix.FloatMatrix
+    var A00, A10, A11, A20, A21, A22 matrix.FloatMatrix
+
+    partition2x2(
+        &ATL, &ATR,
+        &ABL, &ABR,   A, 0, 0, pTOPLEFT)
+
+    for ATL.Rows() < A.Rows() {
+        repartition2x2to3x3(&ATL,
+            &A00, nil,  nil,
+            &A10, &A11, nil,
+            &A20, &A21, &A22,   A, nb, pBOTTOMRIGHT)
+        // -------------------------------------------------
+        // A10 = L11.T*A10
+        MultTrm(&A10, &A11, 1.0, LOWER|LEFT|TRANSA)
+        // A11 = L11.T*L11
+        unblockedTrmProductLower(&A11)
+        if A21.Rows() > 0 {
+            // A10 = A10 + A21.T*A20
+            Mult(&A10, &A21, &A20, 1.0, 1.0, TRANSA)
+            // A11 = A11 + A21.T*A21
+            RankUpdateSym(&A11, &A21, 1.0, 1.0, LOWER|TRANSA)
+        }
+        // -------------------------------------------------
+        continue3x3to2x2(
+            &ATL, &ATR,
+            &ABL, &ABR,   &A00, &A11, &A22,  A, pBOTTOMRIGHT)
+    }
+}
+
+// Blocked version of U*U.T (LAPACK/dlauum.f)
+func blockedTrmProductUpper(A *matrix.FloatMatrix, nb int) {
+    var ATL, ATR, ABL, ABR matrix.FloatMatrix
+    var A00, A01, A02, A11, A12, A22 matrix.FloatMatrix
+
+    partition2x2(
+        &ATL, &ATR,
+        &ABL, &ABR,   A, 0, 0, pTOPLEFT)
+
+    for ATL.Rows() < A.Rows() {
+        repartition2x2to3x3(&ATL,
+            &A00, &A01, &A02,
+            nil,  &A11, &A12,
+            nil,  nil,  &A22,   A, nb, pBOTTOMRIGHT)
+        // -------------------------------------------------
+        // A01 = A01*U11.T
+        MultTrm(&A01, &A11, 1.0, UPPER|RIGHT|TRANSA)
+        // A11 = U11*U11.T
+        unblockedTrmProductUpper(&A11)
+        if A12.Cols() > 0 {
+            // A01 = A01 + A02*A12.T
+            Mult(&A01, &A02, &A12, 1.0, 1.0, TRANSB)
+            // A11 = A11 + A12*A12.T
+            RankUpdateSym(&A11, &A12, 1.0, 1.0, UPPER)
+        }
+        // -------------------------------------------------
+        continue3x3to2x2(
+            &ATL, &ATR,
+            &ABL, &ABR,   &A00, &A11, &A22,  A, pBOTTOMRIGHT)
+    }
+}
+
+// Compute M.T*D.-1*M in place where M is unit lower triangular matrix and
+// diagonal D is stored on the diagonal of A. Vector w of length A.Rows()
+// is used as workspace.
+func unblockedLDLProductLower(A, w *matrix.FloatMatrix) error {
+    var ATL, ATR, ABL, ABR matrix.FloatMatrix
+    var A00, a10t, a11, A20, a21, A22, w1 matrix.FloatMatrix
+
+    partition2x2(
+        &ATL, &ATR,
+        &ABL, &ABR,   A, 0, 0, pTOPLEFT)
+
+    for ATL.Rows() < A.Rows() {
+        repartition2x2to3x3(&ATL,
+            &A00,  nil,  nil,
+            &a10t, &a11, nil,
+            &A20,  &a21, &A22,   A, 1, pBOTTOMRIGHT)
+        // -------------------------------------------------
+        dval := a11.Float()
+        if dval == 0.0 {
+            return &SingularError{A00.Rows()}
+        }
+        // a10t = a10t/d1; a11 = 1/d1
+        InvScale(&a10t, dval)
+        dval = 1.0/dval
+        if a21.Rows() > 0 {
+            // w1 = D2.-1*a21
+            w.SubMatrix(&w1, 0, 0, a21.Rows(), 1)
+            a21.CopyTo(&w1)
+            solveDiag(&w1, &A22, LEFT)
+            // a10t = a10t + w1.T*A20
+            MVMult(&a10t, &A20, &w1, 1.0, 1.0, TRANSA)
+            // a11 = a11 + w1.T*a21
+            dval += Dot(&w1, &a21, 1.0)
+        }
+        a11.SetAt(0, 0, dval)
+        // -------------------------------------------------
+        continue3x3to2x2(
+            &ATL, &ATR,
+            &ABL, &ABR,   &A00, &a11, &A22,  A, pBOTTOMRIGHT)
+    }
+    return nil
+}
+
+// Compute M.T*D.-1*M in place where M is unit upper triangular matrix and
+// diagonal D is stored on the diagonal of A. Vector w of length A.Rows()
+// is used as workspace.
+func unblockedLDLProductUpper(A, w *matrix.FloatMatrix) error {
+    var ATL, ATR, ABL, ABR matrix.FloatMatrix
+    var A00, a01, A02, a11, a12t, A22, w1 matrix.FloatMatrix
+
+    partition2x2(
+        &ATL, &ATR,
+        &ABL, &ABR,   A, 0, 0, pBOTTOMRIGHT)
+
+    for ATL.Rows() > 0 {
+        repartition2x2to3x3(&ATL,
+            &A00, &a01, &A02,
+            nil,  &a11, &a12t,
+            nil,  nil,  &A22,   A, 1, pTOPLEFT)
+        // -------------------------------------------------
+        dval := a11.Float()
+        if dval == 0.0 {
+            return &SingularError{A00.Rows()}
+        }
+        // a12t = a12t/d1; a11 = 1/d1
+        InvScale(&a12t, dval)
+        dval = 1.0/dval
+        if a01.Rows() > 0 {
+            // w1 = D0.-1*a01
+            w.SubMatrix(&w1, 0, 0, a01.Rows(), 1)
+            a01.CopyTo(&w1)
+            solveDiag(&w1, &A00, LEFT)
+            // a12t = a12t + w1.T*A02
+            MVMult(&a12t, &A02, &w1, 1.0, 1.0, TRANSA)
+            // a11 = a11 + w1.T*a01
+            dval += Dot(&w1, &a01, 1.0)
+        }
+        a11.SetAt(0, 0, dval)
+        // -------------------------------------------------
+        continue3x3to2x2(
+            &ATL, &ATR,
+            &ABL, &ABR,   &A00, &a11, &A22,  A, pTOPLEFT)
+    }
+    return nil
+}
+
+// Symmetric interchange of rows and columns i and j, i < j, of symmetric
+// matrix stored in lower or upper triangular part of A.
+func swapSym(A *matrix.FloatMatrix, i, j int, flags Flags) {
+    var s, d matrix.FloatMatrix
+    N := A.Rows()
+    if flags & LOWER != 0 {
+        A.SubMatrix(&s, i, 0, 1, i)
+        A.SubMatrix(&d, j, 0, 1, i)
+        Swap(&s, &d)
+        A.SubMatrix(&s, i+1, i, j-i-1, 1)
+        A.SubMatrix(&d, j, i+1, 1, j-i-1)
+        Swap(&s, &d)
+        A.SubMatrix(&s, j+1, i, N-j-1, 1)
+        A.SubMatrix(&d, j+1, j, N-j-1, 1)
+        Swap(&s, &d)
+    } else {
+        A.SubMatrix(&s, 0, i, i, 1)
+        A.SubMatrix(&d, 0, j, i, 1)
+        Swap(&s, &d)
+        A.SubMatrix(&s, i, i+1, 1, j-i-1)
+        A.SubMatrix(&d, i+1, j, j-i-1, 1)
+        Swap(&s, &d)
+        A.SubMatrix(&s, i, j+1, 1, N-j-1)
+        A.SubMatrix(&d, j, j+1, 1, N-j-1)
+        Swap(&s, &d)
+    }
+    t0 := A.GetAt(i, i)
+    A.SetAt(i, i, A.GetAt(j, j))
+    A.SetAt(j, j, t0)
+}
+
+// Solve X*L = U.-1 for X = A.-1 where A = P*L*U (LAPACK/dgetri.f, unblocked part)
+func unblockedInverseLU(A, w *matrix.FloatMatrix) {
+    var l21, w1, a1, A2 matrix.FloatMatrix
+    N := A.Rows()
+    for j := N-1; j >= 0; j-- {
+        // copy current column of L to workspace and zero it
+        A.SubMatrix(&l21, j+1, j, N-j-1, 1)
+        w.SubMatrix(&w1, 0, 0, N-j-1, 1)
+        l21.CopyTo(&w1)
+        l21.SetIndexes(0.0)
+        // a1 = a1 - A2*w1
+        A.SubMatrix(&a1, 0, j, N, 1)
+        A.SubMatrix(&A2, 0, j+1, N, N-j-1)
+        MVMult(&a1, &A2, &w1, -1.0, 1.0, NOTRANS)
+    }
+}
+
+// Blocked version of unblockedInverseLU; W is N-by-nb workspace (LAPACK/dgetri.f)
+func blockedInverseLU(A, W *matrix.FloatMatrix, nb int) {
+    var l21, w1, A1, A2, W1, W2 matrix.FloatMatrix
+    N := A.Rows()
+    for j := ((N-1)/nb)*nb; j >= 0; j -= nb {
+        jb := imin(nb, N-j)
+        // copy current block column of L to workspace and zero it
+        for jj := 0; jj < jb; jj++ {
+            A.SubMatrix(&l21, j+jj+1, j+jj, N-j-jj-1, 1)
+            W.SubMatrix(&w1, j+jj+1, jj, N-j-jj-1, 1)
+            l21.CopyTo(&w1)
+            l21.SetIndexes(0.0)
+        }
+        A.SubMatrix(&A1, 0, j, N, jb)
+        // A1 = A1 - A2*W2
+        if j+jb < N {
+            A.SubMatrix(&A2, 0, j+jb, N, N-j-jb)
+            W.SubMatrix(&W2, j+jb, 0, N-j-jb, jb)
+            Mult(&A1, &A2, &W2, -1.0, 1.0, NOTRANS)
+        }
+        // A1 = A1*L11.-1
+        W.SubMatrix(&W1, j, 0, jb, jb)
+        SolveTrm(&A1, &W1, 1.0, RIGHT|LOWER|UNIT)
+    }
+}
+
+/*
+ * Compute the inverse of a general N-by-N matrix using the LU factorization
+ * computed by DecomposeLU().
+ *
+ * Arguments:
+ *  A      On entry, the factors L and U from factorization A = P*L*U as computed
+ *         by DecomposeLU(). On exit, the inverse of the original matrix A.
+ *
+ *  pivots The pivot indices from DecomposeLU().
+ *
+ *  W      Workspace, N-by-nb matrix for blocked invocations. If nil, workspace
+ *         is allocated.
+ *
+ *  nb     Blocking factor for blocked invocations. If nb == 0 or N < nb unblocked
+ *         algorithm is used.
+ *
+ * Returns:
+ *  Inverse matrix and error indicator. Error is returned if matrix is singular.
+ *
+ * Compatible with lapack.DGETRI
+ */
+func InverseLU(A *matrix.FloatMatrix, pivots []int, W *matrix.FloatMatrix, nb int) (*matrix.FloatMatrix, error) {
+    if A.Rows() != A.Cols() {
+        return nil, sizeError("InverseLU", A)
+    }
+    N := A.Rows()
+    if len(pivots) < N {
+        return nil, sizeError("InverseLU", A, shape{len(pivots), 1})
+    }
+    if k := zeroPivot(A); k >= 0 {
+        return nil, &SingularError{k}
+    }
+    // A = U.-1
+    InverseTrm(A, UPPER, nb)
+    // solve A.-1*L = U.-1
+    if nb == 0 || N < nb {
+        w := matrix.FloatZeros(N, 1)
+        unblockedInverseLU(A, w)
+    } else {
+        if W == nil {
+            W = matrix.FloatZeros(N, nb)
+        } else if W.Rows() < N || W.Cols() < nb {
+            return nil, sizeError("InverseLU", A, W)
+        }
+        blockedInverseLU(A, W, nb)
+    }
+    // apply column interchanges in reverse order
+    for k := N-2; k >= 0; k-- {
+        if pivots[k] != k {
+            swapCols(A, k, pivots[k])
+        }
+    }
+    return A, nil
+}
+
+/*
+ * Compute the inverse of a symmetric positive definite matrix using the
+ * Cholesky factorization computed by DecomposeCHOL().
+ *
+ * Arguments:
+ *  A      On entry, the triangular factor U or L from Cholesky factorization
+ *         A = U.T*U or A = L*L.T as computed by DecomposeCHOL(). On exit, the
+ *         upper or lower triangle of the inverse of the original matrix A.
+ *
+ *  flags  Indicator of which factor is stored in A, UPPER or LOWER. Error is
+ *         returned if flags has neither or both.
+ *
+ *  nb     Blocking factor for blocked invocations. If nb == 0 or N < nb unblocked
+ *         algorithm is used.
+ *
+ * Returns:
+ *  Inverse matrix and error indicator. Error is returned if factor is singular.
+ *
+ * Compatible with lapack.DPOTRI
+ */
+func InverseCHOL(A *matrix.FloatMatrix, flags Flags, nb int) (*matrix.FloatMatrix, error) {
+    if A.Rows() != A.Cols() {
+        return nil, sizeError("InverseCHOL", A)
+    }
+    if ! oneOf(flags, UPPER, LOWER) {
+        return nil, flagError("InverseCHOL", "UPPER or LOWER")
+    }
+    if k := zeroPivot(A); k >= 0 {
+        return nil, &SingularError{k}
+    }
+    if flags & UPPER != 0 {
+        // A.-1 = U.-1*U.-T
+        InverseTrm(A, UPPER, nb)
+        if nb == 0 || A.Rows() < nb {
+            unblockedTrmProductUpper(A)
+        } else {
+            blockedTrmProductUpper(A, nb)
+        }
+    } else if flags & LOWER != 0 {
+        // A.-1 = L.-T*L.-1
+        InverseTrm(A, LOWER, nb)
+        if nb == 0 || A.Rows() < nb {
+            unblockedTrmProductLower(A)
+        } else {
+            blockedTrmProductLower(A, nb)
+        }
+    }
+    return A, nil
+}
+
+/*
+ * Compute the inverse of a symmetric matrix using the factorization
+ * A = L*D*L.T or A = U*D*U.T computed by DecomposeLDL().
+ *
+ * Arguments:
+ *  A      On entry, the factor L or U and diagonal D as computed by DecomposeLDL().
+ *         On exit, the upper or lower triangle of the inverse of the original
+ *         matrix A.
+ *
+ *  ipiv   The pivot indices from DecomposeLDL().
+ *
+ *  flags  Indicator of which factor is stored in A, UPPER or LOWER. Error is
+ *         returned if flags has neither or both.
+ *
+ * Returns:
+ *  Inverse matrix and error indicator. Error is returned if D is singular.
+ *
+ * Compatible with lapack.DSYTRI
+ */
+func InverseLDL(A *matrix.FloatMatrix, ipiv []int, flags Flags) (*matrix.FloatMatrix, error) {
+    if A.Rows() != A.Cols() {
+        return nil, sizeError("InverseLDL", A)
+    }
+    N := A.Rows()
+    if len(ipiv) < N {
+        return nil, sizeError("InverseLDL", A, shape{len(ipiv), 1})
+    }
+    if ! oneOf(flags, UPPER, LOWER) {
+        return nil, flagError("InverseLDL", "UPPER or LOWER")
+    }
+    if k := zeroPivot(A); k >= 0 {
+        return nil, &SingularError{k}
+    }
+    w := matrix.FloatZeros(N, 1)
+    if flags & UPPER != 0 {
+        // A.-1 = P*U.-T*D.-1*U.-1*P.T
+        InverseTrm(A, UPPER|UNIT, 0)
+        unblockedLDLProductUpper(A, w)
+        // pivots in order of factorization
+        for k := 0; k < N; k++ {
+            if ipiv[k] > 0 && ipiv[k]-1 != k {
+                swapSym(A, ipiv[k]-1, k, UPPER)
+            }
+        }
+    } else if flags & LOWER != 0 {
+        // A.-1 = P*L.-T*D.-1*L.-1*P.T
+        InverseTrm(A, LOWER|UNIT, 0)
+        unblockedLDLProductLower(A, w)
+        for k := N-1; k >= 0; k-- {
+            if ipiv[k] > 0 && ipiv[k]-1 != k {
+                swapSym(A, k, ipiv[k]-1, LOWER)
+            }
+        }
+    }
+    return A, nil
+}
+
+// Local Variables:
+// tab-width: 4
+// indent-tabs-mode: nil
+// End:
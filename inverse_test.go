@@ -0,0 +1,117 @@
+
+// Copyright (c) Harri Rautila, 2013
+
+// This file is part of github.com/hrautila/matops package. It is free software,
+// distributed under the terms of GNU Lesser General Public License Version 3, or
+// any later version. See the COPYING tile included in this archive.
+
+package matops
+
+import (
+    "github.com/hrautila/matrix"
+    "math"
+    "testing"
+)
+
+// Fill the other triangle of symmetric matrix stored in upper or lower part of A
+func fillSymmetric(A *matrix.FloatMatrix, flags Flags) *matrix.FloatMatrix {
+    for j := 0; j < A.Cols(); j++ {
+        for i := j+1; i < A.Rows(); i++ {
+            if flags & LOWER != 0 {
+                A.SetAt(j, i, A.GetAt(i, j))
+            } else {
+                A.SetAt(i, j, A.GetAt(j, i))
+            }
+        }
+    }
+    return A
+}
+
+// Compute ||I - A*X||_1
+func inverseResidual(A, X *matrix.FloatMatrix) float64 {
+    I := matrix.FloatDiagonal(A.Rows(), 1.0)
+    Mult(I, A, X, -1.0, 1.0, NOTRANS)
+    return NormP(I, NORM_ONE)
+}
+
+// Check ||I - A*X||_1 against N*eps*cond(A), cond(A) = ||A||_1*||X||_1.
+func checkInverse(t *testing.T, name string, A, X *matrix.FloatMatrix) {
+    eps := math.Pow(2.0, -52.0)
+    cond := NormP(A, NORM_ONE) * NormP(X, NORM_ONE)
+    if r := inverseResidual(A, X); r > 10.0*float64(A.Rows())*eps*cond {
+        t.Errorf("%s: ||I - A*A.-1||_1: %e, cond(A): %e\n", name, r, cond)
+    }
+}
+
+func TestInverseLU(t *testing.T) {
+    N := 40
+    A := matrix.FloatUniform(N, N)
+    for _, nb := range []int{0, 8} {
+        pivots := make([]int, N)
+        LU, _ := DecomposeLU(A.Copy(), pivots, nb)
+        X, err := InverseLU(LU, pivots, nil, nb)
+        if err != nil {
+            t.Errorf("nb=%d inverse error: %v\n", nb, err)
+            continue
+        }
+        checkInverse(t, "LU", A, X)
+    }
+}
+
+func TestInverseCHOL(t *testing.T) {
+    N := 40
+    B := matrix.FloatUniform(N, N)
+    A := matrix.FloatDiagonal(N, float64(N))
+    Mult(A, B, B, 1.0, 1.0, TRANSB)
+    for _, flags := range []Flags{LOWER, UPPER} {
+        for _, nb := range []int{0, 8} {
+            L, _ := DecomposeCHOL(A.Copy(), flags, nb)
+            X, err := InverseCHOL(L, flags, nb)
+            if err != nil {
+                t.Errorf("nb=%d inverse error: %v\n", nb, err)
+                continue
+            }
+            fillSymmetric(X, flags)
+            checkInverse(t, "CHOL", A, X)
+        }
+    }
+}
+
+func TestInverseLDL(t *testing.T) {
+    N := 40
+    B := matrix.FloatUniform(N, N)
+    A := B.Transpose()
+    A.Plus(B)
+    for _, flags := range []Flags{LOWER, UPPER} {
+        ipiv := make([]int, N)
+        LD, _ := DecomposeLDL(A.Copy(), nil, ipiv, flags, 0)
+        X, err := InverseLDL(LD, ipiv, flags)
+        if err != nil {
+            t.Errorf("flags=%v inverse error: %v\n", flags, err)
+            continue
+        }
+        fillSymmetric(X, flags)
+        checkInverse(t, "LDL", A, X)
+    }
+}
+
+func TestInverseFlags(t *testing.T) {
+    A := matrix.FloatDiagonal(4, 2.0)
+    for _, flags := range []Flags{NOTRANS, LOWER|UPPER} {
+        A0 := A.Copy()
+        if _, err := InverseCHOL(A0, flags, 0); err == nil {
+            t.Errorf("InverseCHOL flags %x: expected error\n", flags)
+        }
+        if d := NormP(A0.Minus(A), NORM_ONE); d != 0.0 {
+            t.Errorf("InverseCHOL flags %x: A changed on error\n", flags)
+        }
+        if _, err := InverseLDL(A.Copy(), make([]int, 4), flags); err == nil {
+            t.Errorf("InverseLDL flags %x: expected error\n", flags)
+        }
+    }
+}
+
+// Local Variables:
+// tab-width: 4
+// indent-tabs-mode: nil
+// End:
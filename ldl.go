@@ -9,7 +9,7 @@ package matops
 
 import (
 	"github.com/hrautila/matrix"
-    "errors"
+    "context"
     //"fmt"
 )
 
@@ -57,7 +57,7 @@ func unblkLowerLDL(A *matrix.FloatMatrix, p *pPivots) (err error) {
             p1.pivots[0] = 0
         }
         if a11.Float() == 0.0 {
-            err = onError("zero value on diagonal")
+            err = &BreakdownError{"DecomposeLDL", ATL.Rows()}
             return
         }
         
@@ -224,7 +224,7 @@ func unblkBoundedLowerLDL(A, W *matrix.FloatMatrix, p *pPivots, ncol int) (error
  *   A21  =   L21*D1*L11.t                 => L21 = A21*(D1*L11.t).-1 = A21*L11.-T*D1.-1
  *   A22  =   L21*D1*L21.t + L22*D2*L22.t  => L22 = A22 - L21*D1*L21.t
  */
-func blkLowerLDL(A, W *matrix.FloatMatrix, p *pPivots, nb int) (err error) {
+func blkLowerLDL(ctx context.Context, conf *Config, A, W *matrix.FloatMatrix, p *pPivots, nb int) (err error) {
     var ATL, ATR, ABL, ABR matrix.FloatMatrix
     var A00, A10, A11,  A20, A21, A22 matrix.FloatMatrix
     var /*D1,*/ wrk matrix.FloatMatrix
@@ -254,8 +254,11 @@ func blkLowerLDL(A, W *matrix.FloatMatrix, p *pPivots, nb int) (err error) {
         // wrk = D1*L21.T 
         W.SubMatrix(&wrk, nblk, 0, A21.Rows(), nblk)
 
-        // A22 = A22 - L21*D1*L21.T = A22 - L21*wrk.T
-        UpdateTrm(&A22, &A21, &wrk, -1.0, 1.0, LOWER|TRANSB)
+        // A22 = A22 - L21*D1*L21.T = A22 - L21*wrk.T; pivot search of next block
+        // needs all of A22, trailing update is run in parallel without lookahead.
+        if err = updateTrmConf(ctx, conf, &A22, &A21, &wrk, -1.0, 1.0, LOWER|TRANSB); err != nil {
+            return
+        }
 
         applyRowPivots(&ABL, &p1, 0, FORWARD)
         scalePivots(&p1, ATL.Rows())
@@ -277,7 +280,7 @@ func blkLowerLDL(A, W *matrix.FloatMatrix, p *pPivots, nb int) (err error) {
         // A11 = LDL(A11)
         err = unblkLowerLDL(&ABR, &pB)
         if err != nil {
-            return
+            return shiftError(err, ATL.Rows())
         }
         //fmt.Printf("unblk pivots: %v\n", pB.pivots)
         applyRowPivots(&ABL, &pB, 0, FORWARD)
@@ -332,7 +335,7 @@ func unblkUpperLDL(A *matrix.FloatMatrix, p *pPivots) (err error) {
         }
 
         if a11.Float() == 0.0 {
-            err = onError("zero on diagonal.")
+            err = &BreakdownError{"DecomposeLDL", ATL.Rows()-1}
             return
         }
         // A00 = A00 - u01*d11*u01.T = A00 - a01*a01.T/a11; triangular update
@@ -353,7 +356,7 @@ func unblkUpperLDL(A *matrix.FloatMatrix, p *pPivots) (err error) {
 }
 
 
-func blkUpperLDL(A, W *matrix.FloatMatrix, p *pPivots, nb int) (err error) {
+func blkUpperLDL(ctx context.Context, conf *Config, A, W *matrix.FloatMatrix, p *pPivots, nb int) (err error) {
     var ATL, ATR, ABL, ABR matrix.FloatMatrix
     var A00, A01, A02, A11, A12, A22 matrix.FloatMatrix
     var D1, wrk matrix.FloatMatrix
@@ -380,7 +383,7 @@ func blkUpperLDL(A, W *matrix.FloatMatrix, p *pPivots, nb int) (err error) {
         // A11 = LDL(A11)
         err = unblkUpperLDL(&A11, &p1)
         if err != nil {
-            return
+            return shiftError(err, ATL.Rows()-A11.Rows())
         }
         applyColPivots(&A01, &p1, 0, BACKWARD)
         applyRowPivots(&A12, &p1, 0, BACKWARD)
@@ -389,17 +392,19 @@ func blkUpperLDL(A, W *matrix.FloatMatrix, p *pPivots, nb int) (err error) {
         A11.Diag(&D1)
 
         // A01 = A01*A11.-T
-        SolveTrm(&A01, &A11, 1.0, UPPER|UNIT|RIGHT|TRANSA)
+        solveTrmConf(ctx, conf, &A01, &A11, 1.0, UPPER|UNIT|RIGHT|TRANSA)
         // A01 = A01*D1.-1
-        SolveDiag(&A01, &D1, RIGHT)
+        solveDiag(&A01, &D1, RIGHT)
 
         // W = D1*U01.T = U01*D1
-        W.SubMatrix(&wrk, 0, 0, A01.Rows(), nb)
+        W.SubMatrix(&wrk, 0, 0, A01.Rows(), A11.Cols())
         A01.CopyTo(&wrk)
-        MultDiag(&wrk, &D1, RIGHT)
+        multDiag(&wrk, &D1, RIGHT)
 
         // A00 = A00 - U01*D1*U01.T = A22 - U01*W.T
-        UpdateTrm(&A00, &A01, &wrk, -1.0, 1.0, UPPER|TRANSB)
+        if err = updateTrmConf(ctx, conf, &A00, &A01, &wrk, -1.0, 1.0, UPPER|TRANSB); err != nil {
+            return
+        }
 
         // ---------------------------------------------------------
 
@@ -433,17 +438,30 @@ func blkUpperLDL(A, W *matrix.FloatMatrix, p *pPivots, nb int) (err error) {
  *          N < nb unblocked algorithm is used.
  *
  * Returns:
- *  LDL factorization and error indicator.
+ *  LDL factorization and error indicator. If factorization cannot continue
+ *  because of zero diagonal error is *BreakdownError with its index.
  *
  */
 func DecomposeLDL(A, W *matrix.FloatMatrix, ipiv []int, flags Flags, nb int) (*matrix.FloatMatrix, error) {
+    return DecomposeLDLContext(context.Background(), nil, A, W, ipiv, flags, nb)
+}
+
+// LDL factorization with trailing matrix updates of blocked invocation run with
+// the workers of executor ex. If ex is nil the default executor is used. See
+// DecomposeLUContext() and DecomposeLDL().
+func DecomposeLDLContext(ctx context.Context, ex Executor, A, W *matrix.FloatMatrix,
+    ipiv []int, flags Flags, nb int) (*matrix.FloatMatrix, error) {
+
     var err error
     if A.Cols() != A.Rows() {
-        return nil, errors.New("A not a square matrix")
+        return nil, sizeError("DecomposeLDL", A)
     }
     for k, _ := range ipiv {
         ipiv[k] = 0
     }
+    if err = ctx.Err(); err != nil {
+        return A, err
+    }
     if A.Cols() < nb || nb == 0 {
         if flags & LOWER != 0 {
             err = unblkLowerLDL(A, &pPivots{ipiv})
@@ -451,10 +469,50 @@ func DecomposeLDL(A, W *matrix.FloatMatrix, ipiv []int, flags Flags, nb int) (*m
             err = unblkUpperLDL(A, &pPivots{ipiv})
         }
     } else {
+        if W == nil || W.Rows() < A.Rows() || W.Cols() < nb {
+            return nil, sizeError("DecomposeLDL", A, workDims(W))
+        }
         if flags & LOWER != 0 {
-            err = blkLowerLDL(A, W, &pPivots{ipiv}, nb)
+            err = blkLowerLDL(ctx, globalConfig(ex), A, W, &pPivots{ipiv}, nb)
         } else {
-            err = blkUpperLDL(A, W, &pPivots{ipiv}, nb)
+            err = blkUpperLDL(ctx, globalConfig(ex), A, W, &pPivots{ipiv}, nb)
+        }
+    }
+    return A, err
+}
+
+// LDL factorization with block size conf.DecompNB and parameters from conf.
+// See Config and DecomposeLDLContext().
+func DecomposeLDLConfig(ctx context.Context, conf *Config, A, W *matrix.FloatMatrix,
+    ipiv []int, flags Flags) (*matrix.FloatMatrix, error) {
+
+    var err error
+    c, release := conf.resolve()
+    defer release()
+    if A.Cols() != A.Rows() {
+        return nil, c.sizeError("DecomposeLDL", A)
+    }
+    for k, _ := range ipiv {
+        ipiv[k] = 0
+    }
+    if err = ctx.Err(); err != nil {
+        return A, err
+    }
+    nb := c.DecompNB
+    if A.Cols() < nb || nb == 0 {
+        if flags & LOWER != 0 {
+            err = unblkLowerLDL(A, &pPivots{ipiv})
+        } else {
+            err = unblkUpperLDL(A, &pPivots{ipiv})
+        }
+    } else {
+        if W == nil || W.Rows() < A.Rows() || W.Cols() < nb {
+            return nil, c.sizeError("DecomposeLDL", A, workDims(W))
+        }
+        if flags & LOWER != 0 {
+            err = blkLowerLDL(ctx, c, A, W, &pPivots{ipiv}, nb)
+        } else {
+            err = blkUpperLDL(ctx, c, A, W, &pPivots{ipiv}, nb)
         }
     }
     return A, err
@@ -479,33 +537,58 @@ func DecomposeLDL(A, W *matrix.FloatMatrix, ipiv []int, flags Flags, nb int) (*m
  *         triangle of A is stored. If flags&LOWER then lower triangle of A is
  *         stored.
  *
+ * Returns:
+ *  Error if flags does not have exactly one of UPPER and LOWER or if sizes of
+ *  A, B and ipiv do not match. If D has zero on diagonal error is *SingularError
+ *  with its index and B is not changed.
+ *
  * Notes:
  *  On entry matrix B is permuted according ipiv vector and on exit
  *  rearraged to original row order.
  */
-func SolveLDL(B, A *matrix.FloatMatrix, ipiv []int, flags Flags)  {
+func SolveLDLChecked(B, A *matrix.FloatMatrix, ipiv []int, flags Flags) error {
+    var err error
+    if ! oneOf(flags, UPPER, LOWER) {
+        return flagError("SolveLDL", "UPPER or LOWER")
+    }
+    if A.Rows() != A.Cols() || B.Rows() != A.Rows() || len(ipiv) < A.Rows() {
+        return sizeError("SolveLDL", B, A, shape{len(ipiv), 1})
+    }
+    if k := zeroPivot(A); k >= 0 {
+        return &SingularError{k}
+    }
     if flags & UPPER != 0 {
         // X = (U*D*U.T).-1*B => U.-T*(D.-1*(U.-1*B))
         // arrange to match factorization
         applyRowPivots(B, &pPivots{ipiv}, 0, BACKWARD)
         // solve
-        SolveTrm(B, A, 1.0, UPPER|UNIT)
-        SolveDiag(B, A, LEFT)
-        SolveTrm(B, A, 1.0, UPPER|UNIT|TRANSA)
+        if err = SolveTrm(B, A, 1.0, UPPER|UNIT); err == nil {
+            solveDiag(B, A, LEFT)
+            err = SolveTrm(B, A, 1.0, UPPER|UNIT|TRANSA)
+        }
         // rearrange to original
         applyRowPivots(B, &pPivots{ipiv}, 0, FORWARD)
 
-    } else if flags & LOWER != 0 {
+    } else {
         // X = (L*D*L.T).-1*B = L.-T*(D*-1(L.-1*B))
         // arrange to match factorization
         applyRowPivots(B, &pPivots{ipiv}, 0, FORWARD)
         // solve
-        SolveTrm(B, A, 1.0, LOWER|UNIT)
-        SolveDiag(B, A, LEFT)
-        SolveTrm(B, A, 1.0, LOWER|UNIT|TRANSA)
+        if err = SolveTrm(B, A, 1.0, LOWER|UNIT); err == nil {
+            solveDiag(B, A, LEFT)
+            err = SolveTrm(B, A, 1.0, LOWER|UNIT|TRANSA)
+        }
         // rearrange to original
         applyRowPivots(B, &pPivots{ipiv}, 0, BACKWARD)
     }
+    return err
+}
+
+// Solves A*X = B with LDL factorization of A.
+//
+// Deprecated: Use SolveLDLChecked, which validates its arguments and returns an error.
+func SolveLDL(B, A *matrix.FloatMatrix, ipiv []int, flags Flags)  {
+    SolveLDLChecked(B, A, ipiv, flags)
 }
 
 // Local Variables:
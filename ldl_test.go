@@ -142,6 +142,41 @@ func _TestLDLupper(t *testing.T) {
     t.Logf("pivots: %v\n", ipiv)
 }
 
+// ||A*B - P*U*D*U.T*P.T*B||_1 for factorization U of A.
+func upperLDLResidual(A, U *matrix.FloatMatrix, ipiv []int) float64 {
+    B := matrix.FloatNormal(A.Rows(), 2)
+    B0 := matrix.FloatZeros(A.Rows(), 2)
+    Mult(B0, A, B, 1.0, 0.0, NOTRANS)
+    ApplyRowPivots(B, ipiv, BACKWARD)
+    MultTrm(B, U, 1.0, UPPER|UNIT|TRANSA)
+    MultDiag(B, U, LEFT)
+    MultTrm(B, U, 1.0, UPPER|UNIT)
+    ApplyRowPivots(B0, ipiv, BACKWARD)
+    return NormP(B.Minus(B0), NORM_ONE)
+}
+
+// Blocked upper factorization with N not multiple of nb; top left block is
+// smaller than nb.
+func TestLDLUpperBlocked(t *testing.T) {
+    N := 30
+    A0 := matrix.FloatUniform(N, N)
+    A := matrix.FloatZeros(N, N)
+    Mult(A, A0, A0, 1.0, 1.0, TRANSB)
+
+    for _, nb := range []int{0, 4} {
+        ipiv := make([]int, N)
+        U, err := DecomposeLDL(A.Copy(), matrix.FloatZeros(N, nb), ipiv, UPPER, nb)
+        if err != nil {
+            t.Fatalf("nb %d: %v\n", nb, err)
+        }
+        nrm := upperLDLResidual(A, U, ipiv)
+        t.Logf("nb %d: ||A*B - U*D*U.T*B||_1: %e\n", nb, nrm)
+        if nrm > 1e-10 {
+            t.Errorf("nb %d: residual too large\n", nb)
+        }
+    }
+}
+
 func TestLDLSolve(t *testing.T) {
     N := 7
     nb := 4
@@ -9,8 +9,8 @@ package matops
 
 import (
     "github.com/hrautila/matrix"
+    "context"
     "math"
-    "errors"
     //"fmt"
 )
 
@@ -358,7 +358,7 @@ func unblkDecompBKUpper(A, wrk *matrix.FloatMatrix, p *pPivots) (error, int) {
 
         nr := ATL.Rows() - 1
         r, np := findBKPivot(&ATL, UPPER)
-        if r != -1 /*&& r != np-1*/  { 
+        if r != -1 && r != ATL.Rows()-np { 
             // pivoting needed; do swaping here
             //fmt.Printf("pre-pivot ATL [%d]:\n%v\n", ATL.Rows()-np, &ATL)
             applyBKPivotSym(&ATL, ATL.Rows()-np, r, UPPER)
@@ -572,8 +572,8 @@ func unblkBoundedBKLower(A, wrk *matrix.FloatMatrix, p *pPivots, ncol int) (erro
         &pT,
         &pB, p, 0, pTOP)
 
-    // permanent working space for symmetric inverse of a11
-    wrk.SubMatrix(&a11inv, 0, wrk.Cols()-2, 2, 2)
+    // symmetric inverse of a11; not in wrk as block columns overlap it for small ncol
+    matrix.FloatZeros(2, 2).SubMatrix(&a11inv, 0, 0, 2, 2)
     a11inv.SetAt(1, 0, -1.0)
     a11inv.SetAt(0, 1, -1.0)
 
@@ -700,7 +700,7 @@ func unblkBoundedBKLower(A, wrk *matrix.FloatMatrix, p *pPivots, ncol int) (erro
 
 
 
-func blkDecompBKLower(A, W *matrix.FloatMatrix, p *pPivots, nb int) (err error) {
+func blkDecompBKLower(ctx context.Context, conf *Config, A, W *matrix.FloatMatrix, p *pPivots, nb int) (err error) {
     var ATL, ATR, ABL, ABR matrix.FloatMatrix
     var A00, A10, A11,  A20, A21, A22 matrix.FloatMatrix
     var wrk matrix.FloatMatrix
@@ -734,7 +734,9 @@ func blkDecompBKLower(A, W *matrix.FloatMatrix, p *pPivots, nb int) (err error)
         W.SubMatrix(&wrk, nblk, 0, A21.Rows(), nblk)
 
         // A22 = A22 - L21*D1*L21.T = A22 - L21*W.T
-        UpdateTrm(&A22, &A21, &wrk, -1.0, 1.0, LOWER|TRANSB)
+        if err = updateTrmConf(ctx, conf, &A22, &A21, &wrk, -1.0, 1.0, LOWER|TRANSB); err != nil {
+            return
+        }
 
         // partially undo row pivots left of diagonal
         for k := nblk; k > 0; k-- {
@@ -745,17 +747,17 @@ func blkDecompBKLower(A, W *matrix.FloatMatrix, p *pPivots, nb int) (err error)
                 r = -r
                 rlen--
             }
-            if r == k {
+            rowno := k-1
+            if p1.pivots[k-1] < 0 {
+                k-- // skip other entry in 2x2 pivots
+            }
+            if r == rowno + 1 {
                 // no pivot
                 continue
             }
-            ABR.SubMatrix(&s, k-1, 0, 1, rlen)
-            ABR.SubMatrix(&d, r-1, 0, 1, rlen)
+            ABR.SubMatrix(&s, rowno, 0, 1, rlen)
+            ABR.SubMatrix(&d, r-1,   0, 1, rlen)
             Swap(&d, &s)
-
-            if p1.pivots[k-1] < 0 {
-                k-- // skip other entry in 2x2 pivots
-            }
         }
 
         // shift pivot values
@@ -905,8 +907,8 @@ func unblkBoundedBKUpper(A, wrk *matrix.FloatMatrix, p *pPivots, ncol int) (erro
         &pT,
         &pB, p, 0, pBOTTOM)
 
-    // permanent working space for symmetric inverse of a11
-    wrk.SubMatrix(&a11inv, wrk.Rows()-2, 0, 2, 2)
+    // symmetric inverse of a11; not in wrk as block columns overlap it for small ncol
+    matrix.FloatZeros(2, 2).SubMatrix(&a11inv, 0, 0, 2, 2)
     a11inv.SetAt(0, 1, -1.0)
     a11inv.SetAt(1, 0, -1.0)
 
@@ -928,7 +930,7 @@ func unblkBoundedBKUpper(A, wrk *matrix.FloatMatrix, p *pPivots, ncol int) (erro
             // return with number of factorized columns
             return err, nc
         }
-        if r != -1 {
+        if r != -1 && r != ATL.Rows()-np {
             // pivoting needed; np == 1, last row; np == 2; next to last rows
             nrow := ATL.Rows() - np
             applyBKPivotSym(&ATL, nrow, r, UPPER)
@@ -1030,10 +1032,10 @@ func unblkBoundedBKUpper(A, wrk *matrix.FloatMatrix, p *pPivots, ncol int) (erro
     return err, nc
 }
 
-func blkDecompBKUpper(A, W *matrix.FloatMatrix, p *pPivots, nb int) (err error) {
+func blkDecompBKUpper(ctx context.Context, conf *Config, A, W *matrix.FloatMatrix, p *pPivots, nb int) (err error) {
     var ATL, ATR, ABL, ABR matrix.FloatMatrix
     var A00, A01, A02, A11, A12, A22 matrix.FloatMatrix
-    var wrk matrix.FloatMatrix
+    var wrk, wblk matrix.FloatMatrix
     var pT, pB, p0, p1, p2 pPivots
     var nblk int = 0
 
@@ -1046,7 +1048,9 @@ func blkDecompBKUpper(A, W *matrix.FloatMatrix, p *pPivots, nb int) (err error)
         &pB, p, 0, pBOTTOM)
 
     for ATL.Cols() >= nb {
-        err, nblk = unblkBoundedBKUpper(&ATL, W, &pT, nb)
+        // work space indexed from bottom; rows must match ATL
+        W.SubMatrix(&wblk, 0, 0, ATL.Rows(), W.Cols())
+        err, nblk = unblkBoundedBKUpper(&ATL, &wblk, &pT, nb)
 
         // repartition nblk size
         repartition2x2to3x3(&ATL,
@@ -1064,7 +1068,9 @@ func blkDecompBKUpper(A, W *matrix.FloatMatrix, p *pPivots, nb int) (err error)
         W.SubMatrix(&wrk, 0, W.Cols()-nblk, A01.Rows(), nblk)
 
         // A00 = A00 - L01*D1*L01.T = A00 - L01*W.T
-        UpdateTrm(&A00, &A01, &wrk, -1.0, 1.0, UPPER|TRANSB)
+        if err = updateTrmConf(ctx, conf, &A00, &A01, &wrk, -1.0, 1.0, UPPER|TRANSB); err != nil {
+            return
+        }
 
         // partially undo row pivots right of diagonal
         for k := 0; k < nblk; k++ {
@@ -1078,6 +1084,9 @@ func blkDecompBKUpper(A, W *matrix.FloatMatrix, p *pPivots, nb int) (err error)
             }
             rlen := ATL.Cols() - colno - np
             //fmt.Printf("undo: k=%d, r=%d, colno=%d, rlen=%d\n", k, r, colno, rlen)
+            if np == 2 {
+                k++ // skip other entry in 2x2 pivots
+            }
             if r == colno + 1 {
                 // no pivot
                 continue
@@ -1087,10 +1096,6 @@ func blkDecompBKUpper(A, W *matrix.FloatMatrix, p *pPivots, nb int) (err error)
             //fmt.Printf("s %d: %v\n", colno, &s)
             //fmt.Printf("d %d: %v\n", r-1,   &d)
             Swap(&d, &s)
-
-            if p1.pivots[k] < 0 {
-                k++ // skip other entry in 2x2 pivots
-            }
         }
 
         // ---------------------------------------------------------
@@ -1110,15 +1115,65 @@ func blkDecompBKUpper(A, W *matrix.FloatMatrix, p *pPivots, nb int) (err error)
     return
 }
 
+// SingularError if 1x1 diagonal block of D in Bunch-Kaufman factorization is zero.
+func singularBK(A *matrix.FloatMatrix, ipiv []int) error {
+    for k := 0; k < len(ipiv) && k < A.Rows(); k++ {
+        if ipiv[k] < 0 {
+            // 2x2 block; nonsingular by pivot selection
+            k++
+            continue
+        }
+        if A.GetAt(k, k) == 0.0 {
+            return &SingularError{k}
+        }
+    }
+    return nil
+}
 
+/*
+ * Symmetric indefinite LDL factorization with Bunch-Kaufman pivoting.
+ *
+ * Arguments:
+ *  flags  Exactly one of UPPER and LOWER must be set.
+ *
+ *  W      Work space, at least N-by-2 for unblocked and N-by-(nb+1) for blocked
+ *         invocations.
+ *
+ * Returns:
+ *  LDL factorization and error indicator. If block diagonal D has zero 1x1
+ *  block error is *SingularError with its index; if W is too small error is
+ *  *SizeMismatchError.
+ */
 func DecomposeBK(A, W *matrix.FloatMatrix, ipiv []int, flags Flags, nb int) (*matrix.FloatMatrix, error) {
+    return decomposeBK(context.Background(), globalConfig(nil), A, W, ipiv, flags, nb)
+}
+
+// Bunch-Kaufman factorization with block size conf.DecompNB and parameters from
+// conf. See Config and DecomposeBK().
+func DecomposeBKConfig(ctx context.Context, conf *Config, A, W *matrix.FloatMatrix,
+    ipiv []int, flags Flags) (*matrix.FloatMatrix, error) {
+
+    c, release := conf.resolve()
+    defer release()
+    if err := ctx.Err(); err != nil {
+        return A, err
+    }
+    return decomposeBK(ctx, c, A, W, ipiv, flags, c.DecompNB)
+}
+
+func decomposeBK(ctx context.Context, conf *Config, A, W *matrix.FloatMatrix,
+    ipiv []int, flags Flags, nb int) (*matrix.FloatMatrix, error) {
+
     var err error = nil
+    if ! oneOf(flags, UPPER, LOWER) {
+        return nil, conf.flagError("DecomposeBK", "UPPER or LOWER")
+    }
     for k, _ := range ipiv {
         ipiv[k] = 0
     }
     if A.Cols() < nb || nb == 0 {
-        if W.Cols() < 2 || W.Rows() < A.Rows() {
-            return nil, errors.New("Workspace too small")
+        if W == nil || W.Cols() < 2 || W.Rows() < A.Rows() {
+            return nil, conf.sizeError("DecomposeBK", A, workDims(W))
         }
         if flags & LOWER != 0 {
             err, _ = unblkDecompBKLower(A, W, &pPivots{ipiv})
@@ -1126,15 +1181,18 @@ func DecomposeBK(A, W *matrix.FloatMatrix, ipiv []int, flags Flags, nb int) (*ma
             err, _ = unblkDecompBKUpper(A, W, &pPivots{ipiv})
         }
     } else {
-        if W.Cols() < nb+1 || W.Rows() < A.Rows() {
-            return nil, errors.New("Workspace too small")
+        if W == nil || W.Cols() < nb+1 || W.Rows() < A.Rows() {
+            return nil, conf.sizeError("DecomposeBK", A, workDims(W))
         }
         if flags & LOWER != 0 {
-            err = blkDecompBKLower(A, W,  &pPivots{ipiv}, nb)
+            err = blkDecompBKLower(ctx, conf, A, W,  &pPivots{ipiv}, nb)
         } else if flags & UPPER != 0 {
-            err = blkDecompBKUpper(A, W,  &pPivots{ipiv}, nb)
+            err = blkDecompBKUpper(ctx, conf, A, W,  &pPivots{ipiv}, nb)
         }
     }        
+    if err == nil {
+        err = singularBK(A, ipiv)
+    }
     return A, err
 }
 
@@ -0,0 +1,398 @@
+
+// Copyright (c) Harri Rautila, 2013
+
+// This file is part of github.com/hrautila/matops package. It is free software,
+// distributed under the terms of GNU Lesser General Public License Version 3, or
+// any later version. See the COPYING tile included in this archive.
+
+package matops
+
+import (
+    "github.com/hrautila/matrix"
+    "math"
+)
+
+// Swap elements i and j of packed matrix A.
+func swapPacked(A *PackedMatrix, i0, j0, i1, j1 int) {
+    k0 := A.index(i0, j0)
+    k1 := A.index(i1, j1)
+    A.data[k0], A.data[k1] = A.data[k1], A.data[k0]
+}
+
+/*
+ * Find Bunch-Kauffman pivot for column k of packed matrix A. Pivot selection is
+ * same as in findBKPivot(). Returns pivot row and pivot size. Pivot row is -1 if
+ * the column is exactly zero.
+ */
+func findBKPivotPacked(A *PackedMatrix, k int) (int, int) {
+    var i0, i1, q0, q1 int
+    // off-diagonal part of column k is on rows [i0, i1)
+    if A.flags & LOWER != 0 {
+        i0, i1 = k+1, A.n
+    } else {
+        i0, i1 = 0, k
+    }
+    amax := math.Abs(A.GetAt(k, k))
+    r := k
+    rmax := 0.0
+    for i := i0; i < i1; i++ {
+        if math.Abs(A.GetAt(i, k)) > rmax {
+            rmax = math.Abs(A.GetAt(i, k))
+            r = i
+        }
+    }
+    if amax == 0.0 && rmax == 0.0 {
+        return -1, 1
+    }
+    if amax >= bkALPHA*rmax {
+        // no pivoting, 1x1 diagonal
+        return k, 1
+    }
+    // max off-diagonal on r'th row within the unfactored part
+    if A.flags & LOWER != 0 {
+        q0, q1 = k, A.n
+    } else {
+        q0, q1 = 0, k+1
+    }
+    qmax := 0.0
+    for j := q0; j < q1; j++ {
+        if j != r && math.Abs(A.GetAt(r, j)) > qmax {
+            qmax = math.Abs(A.GetAt(r, j))
+        }
+    }
+    if amax >= bkALPHA*rmax*(rmax/qmax) {
+        // no pivoting, 1x1 diagonal
+        return k, 1
+    }
+    if math.Abs(A.GetAt(r, r)) >= bkALPHA*qmax {
+        // 1x1 pivoting and interchange with k, r
+        return r, 1
+    }
+    // 2x2 pivoting and interchange with k+1 (k-1 if upper), r
+    return r, 2
+}
+
+/*
+ * Unblocked Bunch-Kauffman LDL factorization of lower packed matrix.
+ *
+ * Corresponds lapack.DSPTF2
+ */
+func unblkDecompBKLowerPacked(A *PackedMatrix, ipiv []int) error {
+    var err error = nil
+    N := A.n
+    for k := 0; k < N; {
+        r, np := findBKPivotPacked(A, k)
+        if r < 0 {
+            // column is zero; D[k,k] is exactly zero
+            if err == nil {
+                err = &SingularError{k}
+            }
+            ipiv[k] = k + 1
+            k++
+            continue
+        }
+        kk := k + np - 1
+        if r != kk {
+            // interchange rows and columns kk and r in trailing submatrix
+            for i := r+1; i < N; i++ {
+                swapPacked(A, i, kk, i, r)
+            }
+            for j := kk+1; j < r; j++ {
+                swapPacked(A, j, kk, r, j)
+            }
+            swapPacked(A, kk, kk, r, r)
+            if np == 2 {
+                swapPacked(A, k+1, k, r, k)
+            }
+        }
+        if np == 1 {
+            // A22 = A22 - a21*a21.T/a11; a21 = a21/a11
+            if k < N-1 {
+                c := A.colIndex(k)
+                a21 := A.data[c+k+1:c+N]
+                spr2(A.subPacked(N-k-1), a21, 1, nil, 0, -1.0/A.data[c+k])
+                for i := range a21 {
+                    a21[i] /= A.data[c+k]
+                }
+            }
+            ipiv[k] = r + 1
+        } else {
+            // A22 = A22 - [a21 a22]*E.-1*[a21 a22].T; inverse of 2x2 as in lapack.SYTF2
+            if k < N-2 {
+                d21 := A.GetAt(k+1, k)
+                d11 := A.GetAt(k+1, k+1)/d21
+                d22 := A.GetAt(k, k)/d21
+                t := 1.0/(d11*d22 - 1.0)
+                d21 = t/d21
+                for j := k+2; j < N; j++ {
+                    wk := d21*(d11*A.GetAt(j, k) - A.GetAt(j, k+1))
+                    wkp1 := d21*(d22*A.GetAt(j, k+1) - A.GetAt(j, k))
+                    for i := j; i < N; i++ {
+                        A.SetAt(i, j, A.GetAt(i, j) - A.GetAt(i, k)*wk - A.GetAt(i, k+1)*wkp1)
+                    }
+                    A.SetAt(j, k, wk)
+                    A.SetAt(j, k+1, wkp1)
+                }
+            }
+            ipiv[k] = -(r + 1)
+            ipiv[k+1] = ipiv[k]
+        }
+        k += np
+    }
+    return err
+}
+
+/*
+ * Unblocked Bunch-Kauffman LDL factorization of upper packed matrix.
+ *
+ * Corresponds lapack.DSPTF2
+ */
+func unblkDecompBKUpperPacked(A *PackedMatrix, ipiv []int) error {
+    var err error = nil
+    for k := A.n-1; k >= 0; {
+        r, np := findBKPivotPacked(A, k)
+        if r < 0 {
+            // column is zero; D[k,k] is exactly zero
+            if err == nil {
+                err = &SingularError{k}
+            }
+            ipiv[k] = k + 1
+            k--
+            continue
+        }
+        kk := k - np + 1
+        if r != kk {
+            // interchange rows and columns kk and r in leading submatrix
+            for i := 0; i < r; i++ {
+                swapPacked(A, i, kk, i, r)
+            }
+            for j := r+1; j < kk; j++ {
+                swapPacked(A, j, kk, r, j)
+            }
+            swapPacked(A, kk, kk, r, r)
+            if np == 2 {
+                swapPacked(A, k-1, k, r, k)
+            }
+        }
+        if np == 1 {
+            // A00 = A00 - a01*a01.T/a11; a01 = a01/a11
+            if k > 0 {
+                c := A.colIndex(k)
+                a01 := A.data[c:c+k]
+                spr2(A.subPacked(k), a01, 1, nil, 0, -1.0/A.data[c+k])
+                for i := range a01 {
+                    a01[i] /= A.data[c+k]
+                }
+            }
+            ipiv[k] = r + 1
+        } else {
+            // A00 = A00 - [a01 a02]*E.-1*[a01 a02].T; inverse of 2x2 as in lapack.SYTF2
+            if k > 1 {
+                d12 := A.GetAt(k-1, k)
+                d22 := A.GetAt(k-1, k-1)/d12
+                d11 := A.GetAt(k, k)/d12
+                t := 1.0/(d11*d22 - 1.0)
+                d12 = t/d12
+                for j := k-2; j >= 0; j-- {
+                    wkm1 := d12*(d11*A.GetAt(j, k-1) - A.GetAt(j, k))
+                    wk := d12*(d22*A.GetAt(j, k) - A.GetAt(j, k-1))
+                    for i := j; i >= 0; i-- {
+                        A.SetAt(i, j, A.GetAt(i, j) - A.GetAt(i, k)*wk - A.GetAt(i, k-1)*wkm1)
+                    }
+                    A.SetAt(j, k, wk)
+                    A.SetAt(j, k-1, wkm1)
+                }
+            }
+            ipiv[k] = -(r + 1)
+            ipiv[k-1] = ipiv[k]
+        }
+        k -= np
+    }
+    return err
+}
+
+/*
+ * Compute the Bunch-Kauffman LDL factorization of a symmetric N-by-N matrix A
+ * in packed storage.
+ *
+ * Arguments:
+ *  A     On entry, the symmetric matrix in packed storage. On exit, the block
+ *        diagonal matrix D and the multipliers used to obtain the factor L
+ *        (lower triangle stored) or U (upper triangle stored) in packed storage.
+ *
+ *  ipiv  On exit, the pivot indices in the same format as computed by
+ *        DecomposeBK(). If ipiv[k] > 0 then rows and columns k and ipiv[k]-1
+ *        were interchanged and D[k,k] is 1x1 diagonal block. If ipiv[k] < 0
+ *        then D[k:k+2,k:k+2] (D[k-1:k+1,k-1:k+1] for upper) is a 2x2 diagonal block.
+ *
+ * Returns:
+ *  LDL factorization and error indicator. Error is returned if exactly zero
+ *  pivot was found; factorization is completed but D is singular.
+ *
+ * Compatible with lapack.DSPTRF
+ */
+func DecomposeBKPacked(A *PackedMatrix, ipiv []int) (*PackedMatrix, error) {
+    if len(ipiv) < A.n {
+        return A, sizeError("DecomposeBKPacked", shape{A.n, A.n}, shape{len(ipiv), 1})
+    }
+    for k, _ := range ipiv {
+        ipiv[k] = 0
+    }
+    if A.flags & LOWER != 0 {
+        return A, unblkDecompBKLowerPacked(A, ipiv)
+    }
+    return A, unblkDecompBKUpperPacked(A, ipiv)
+}
+
+func swapRowsPacked(B *matrix.FloatMatrix, i, j int) {
+    if i == j {
+        return
+    }
+    Br := B.FloatArray()
+    ldB := B.LeadingIndex()
+    for c := 0; c < B.Cols(); c++ {
+        Br[i+c*ldB], Br[j+c*ldB] = Br[j+c*ldB], Br[i+c*ldB]
+    }
+}
+
+func scaleRowPacked(B *matrix.FloatMatrix, k int, alpha float64) {
+    Br := B.FloatArray()
+    ldB := B.LeadingIndex()
+    for c := 0; c < B.Cols(); c++ {
+        Br[k+c*ldB] *= alpha
+    }
+}
+
+// Solve with 2x2 diagonal block at rows k0, k1 of B. (LAPACK/dsptrs.f)
+func solve2x2Packed(B *matrix.FloatMatrix, A *PackedMatrix, k0, k1 int) {
+    Br := B.FloatArray()
+    ldB := B.LeadingIndex()
+    d21 := A.GetAt(k1, k0)
+    d11 := A.GetAt(k0, k0)/d21
+    d22 := A.GetAt(k1, k1)/d21
+    denom := d11*d22 - 1.0
+    for c := 0; c < B.Cols(); c++ {
+        b0 := Br[k0+c*ldB]/d21
+        b1 := Br[k1+c*ldB]/d21
+        Br[k0+c*ldB] = (d22*b0 - b1)/denom
+        Br[k1+c*ldB] = (d11*b1 - b0)/denom
+    }
+}
+
+// B[i0:i1,:] = B[i0:i1,:] - A[i0:i1,col]*B[k,:]
+func updateRowsPacked(B *matrix.FloatMatrix, A *PackedMatrix, col, k, i0, i1 int) {
+    Br := B.FloatArray()
+    ldB := B.LeadingIndex()
+    ac := A.colIndex(col)
+    for c := 0; c < B.Cols(); c++ {
+        bk := Br[k+c*ldB]
+        for i := i0; i < i1; i++ {
+            Br[i+c*ldB] -= A.data[ac+i] * bk
+        }
+    }
+}
+
+// B[k,:] = B[k,:] - A[i0:i1,col].T*B[i0:i1,:]
+func updateRowPacked(B *matrix.FloatMatrix, A *PackedMatrix, col, k, i0, i1 int) {
+    Br := B.FloatArray()
+    ldB := B.LeadingIndex()
+    ac := A.colIndex(col)
+    for c := 0; c < B.Cols(); c++ {
+        bk := Br[k+c*ldB]
+        for i := i0; i < i1; i++ {
+            bk -= A.data[ac+i] * Br[i+c*ldB]
+        }
+        Br[k+c*ldB] = bk
+    }
+}
+
+/*
+ * Solves a system system of linear equations A*X = B with symmetric matrix A
+ * in packed storage using the Bunch-Kauffman factorization computed by
+ * DecomposeBKPacked().
+ *
+ * Arguments:
+ *  B     On entry, the right hand side matrix B. On exit, the solution
+ *        matrix X.
+ *
+ *  A     The block diagonal matrix D and the multipliers as computed by
+ *        DecomposeBKPacked().
+ *
+ *  ipiv  The pivot indices from DecomposeBKPacked().
+ *
+ * Compatible with lapack.DSPTRS.
+ */
+func SolveBKPacked(B *matrix.FloatMatrix, A *PackedMatrix, ipiv []int) error {
+    N := A.n
+    if B.Rows() != N {
+        return sizeError("SolveBKPacked", B, shape{A.n, A.n})
+    }
+    if len(ipiv) < N {
+        return sizeError("SolveBKPacked", B, shape{A.n, A.n}, shape{len(ipiv), 1})
+    }
+    if A.flags & LOWER != 0 {
+        // Z = D.-1*(L.-1*B)
+        for k := 0; k < N; {
+            if ipiv[k] > 0 {
+                swapRowsPacked(B, k, ipiv[k]-1)
+                updateRowsPacked(B, A, k, k, k+1, N)
+                scaleRowPacked(B, k, 1.0/A.GetAt(k, k))
+                k++
+            } else {
+                swapRowsPacked(B, k+1, -ipiv[k]-1)
+                updateRowsPacked(B, A, k, k, k+2, N)
+                updateRowsPacked(B, A, k+1, k+1, k+2, N)
+                solve2x2Packed(B, A, k, k+1)
+                k += 2
+            }
+        }
+        // X = L.-T*Z
+        for k := N-1; k >= 0; {
+            if ipiv[k] > 0 {
+                updateRowPacked(B, A, k, k, k+1, N)
+                swapRowsPacked(B, k, ipiv[k]-1)
+                k--
+            } else {
+                updateRowPacked(B, A, k, k, k+1, N)
+                updateRowPacked(B, A, k-1, k-1, k+1, N)
+                swapRowsPacked(B, k, -ipiv[k]-1)
+                k -= 2
+            }
+        }
+        return nil
+    }
+    // Z = D.-1*(U.-1*B)
+    for k := N-1; k >= 0; {
+        if ipiv[k] > 0 {
+            swapRowsPacked(B, k, ipiv[k]-1)
+            updateRowsPacked(B, A, k, k, 0, k)
+            scaleRowPacked(B, k, 1.0/A.GetAt(k, k))
+            k--
+        } else {
+            swapRowsPacked(B, k-1, -ipiv[k]-1)
+            updateRowsPacked(B, A, k, k, 0, k-1)
+            updateRowsPacked(B, A, k-1, k-1, 0, k-1)
+            solve2x2Packed(B, A, k-1, k)
+            k -= 2
+        }
+    }
+    // X = U.-T*Z
+    for k := 0; k < N; {
+        if ipiv[k] > 0 {
+            updateRowPacked(B, A, k, k, 0, k)
+            swapRowsPacked(B, k, ipiv[k]-1)
+            k++
+        } else {
+            updateRowPacked(B, A, k, k, 0, k)
+            updateRowPacked(B, A, k+1, k+1, 0, k)
+            swapRowsPacked(B, k, -ipiv[k]-1)
+            k += 2
+        }
+    }
+    return nil
+}
+
+// Local Variables:
+// tab-width: 4
+// indent-tabs-mode: nil
+// End:
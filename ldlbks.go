@@ -9,7 +9,6 @@ package matops
 
 import (
 	"github.com/hrautila/matrix"
-    "fmt"
 )
 
 func unblkSolveBKLower(B, A /*, wrk*/ *matrix.FloatMatrix, p *pPivots, phase int) error {
@@ -156,162 +155,140 @@ func unblkSolveBKLower(B, A /*, wrk*/ *matrix.FloatMatrix, p *pPivots, phase int
     return err
 }
 
+/*
+ * Solve with U*D*U.T factorization of DecomposeBK(). Factor U is product
+ * U = P(N)*U(N)* ... *P(k)*U(k)* ... where interchanges P(k) apply to leading
+ * k-by-k part of A (lapack.DSYTRS).
+ *
+ * Phase 1 computes Z = D.-1*(U.-1*B) and phase 2 X = U.-T*Z.
+ */
 func unblkSolveBKUpper(B, A *matrix.FloatMatrix, p *pPivots, phase int) error {
-    var err error
-    var ATL, ATR, ABL, ABR matrix.FloatMatrix
-    var A00, a01, A02, a11, a12t, A22 matrix.FloatMatrix
-    var Aref *matrix.FloatMatrix
-    var BT, BB, B0, b1, B2, Bx matrix.FloatMatrix
-    var pT, pB, p0, p1, p2 pPivots
-    var aStart, aDir, bStart, bDir pDirection
-    var nc int
-
-    err = nil
-    np := 0
-
-    if phase == 2 {
-        aStart = pTOPLEFT
-        aDir = pBOTTOMRIGHT
-        bStart = pTOP
-        bDir = pBOTTOM
-        nc = 1
-        Aref = &ABR
-    } else {
-        aStart = pBOTTOMRIGHT
-        aDir = pTOPLEFT
-        bStart = pBOTTOM
-        bDir = pTOP
-        nc = A.Rows()
-        Aref = &ATL
-    }
-    partition2x2(
-        &ATL, &ATR,
-        &ABL, &ABR, A, 0, 0, aStart)
-    partition2x1(
-        &BT, 
-        &BB,  B, 0, bStart)
-    partitionPivot2x1(
-        &pT,
-        &pB, p, 0, bStart)
+    var a01, b1, B0 matrix.FloatMatrix
+    N := A.Rows()
+    P := B.Cols()
 
-    // ABR.Cols() == 0 is end of matrix,
-    for Aref.Cols() > 0 {
-
-        // see if next diagonal block is 1x1 or 2x2
-        np = 1
-        if p.pivots[nc-1] < 0 {
-            np = 2
-        }
-        fmt.Printf("nc=%d, np=%d, m(ABR)=%d\n", nc, np, m(&ABR))
-
-        // repartition according the pivot size
-        repartition2x2to3x3(&ATL, 
-            &A00, &a01, &A02,
-            nil,  &a11, &a12t,
-            nil,  nil,  &A22,   /**/ A, np, aDir)
-        repartition2x1to3x1(&BT, 
-            &B0,
-            &b1,
-            &B2,   /**/ B, np, bDir)
-        repartPivot2x1to3x1(&pT, 
-            &p0,
-            &p1,
-            &p2,   /**/ p, np, bDir)
-        // ------------------------------------------------------------
-
-        switch phase {
-        case 1:
-            // computes D.-1*(L.-1*B)
-            if np == 1 {
-                if p1.pivots[0] != nc {
-                    // swap rows in top part of B
-                    //fmt.Printf("1x1 pivot top with %d [%d]\n", p1.pivots[0], p1.pivots[0]-BT.Rows())
-                    swapRows(&BT, BT.Rows()-1, p1.pivots[0]-1)
+    if phase == 1 {
+        for k := N-1; k >= 0; {
+            if p.pivots[k] > 0 {
+                // 1x1 block
+                if p.pivots[k]-1 != k {
+                    swapRows(B, k, p.pivots[k]-1)
                 }
-                // B2 = B2 - a21*b1
-                MVRankUpdate(&B2, &a01, &b1, -1.0)
-                // b1 = b1/d1 
-                InvScale(&b1, a11.Float())
-                nc += 1
-            } else if np == 2 {
-                if p1.pivots[0] != -nc {
-                    // swap rows on bottom part of B
-                    //fmt.Printf("2x2 pivot %d with %d [%d]\n", nc+1, -p1.pivots[0])
-                    //fmt.Printf("pre :\n%v\n", B)
-                    swapRows(&BT, BT.Rows()-2, -p1.pivots[0]-1)
-                    //fmt.Printf("post:\n%v\n", B)
+                B.SubMatrix(&b1, k, 0, 1, P)
+                if k > 0 {
+                    // B0 = B0 - a01*b1
+                    A.SubMatrix(&a01, 0, k, k, 1)
+                    B.SubMatrix(&B0, 0, 0, k, P)
+                    MVRankUpdate(&B0, &a01, &b1, -1.0)
                 }
-                b := a11.GetAt(0, 1)
-                apb := a11.GetAt(0, 0) / b
-                dpb := a11.GetAt(1, 1) / b
+                // b1 = b1/d1
+                InvScale(&b1, A.GetAt(k, k))
+                k -= 1
+            } else {
+                // 2x2 block at k-1, k; interchange with k-1
+                if -p.pivots[k]-1 != k-1 {
+                    swapRows(B, k-1, -p.pivots[k]-1)
+                }
+                B.SubMatrix(&b1, k-1, 0, 2, P)
+                if k > 1 {
+                    // B0 = B0 - a01*b1
+                    A.SubMatrix(&a01, 0, k-1, k-1, 2)
+                    B.SubMatrix(&B0, 0, 0, k-1, P)
+                    Mult(&B0, &a01, &b1, -1.0, 1.0, NOTRANS)
+                }
+                b := A.GetAt(k-1, k)
+                apb := A.GetAt(k-1, k-1) / b
+                dpb := A.GetAt(k, k) / b
                 // (a/b)*(d/b)-1.0 == (a*d - b^2)/b^2
                 scale := apb*dpb - 1.0
                 scale *= b
-
-                // B2 = B2 - a21*b1
-                Mult(&B2, &a01, &b1, -1.0, 1.0, NOTRANS)
-                // b1 = a11.-1*b1.T
-                //(2x2 block, no subroutine for doing this in-place)
-                for k := 0; k < b1.Cols(); k++ {
-                    s0 := b1.GetAt(0, k) 
-                    s1 := b1.GetAt(1, k) 
-                    b1.SetAt(0, k, (dpb*s0-s1)/scale)
-                    b1.SetAt(1, k, (apb*s1-s0)/scale)
-                }
-                nc += 2
-            }
-        case 2:
-            if np == 1 {
-                MVMult(&b1, &B2, &a01, -1.0, 1.0, TRANSA)
-                if p1.pivots[0] != nc {
-                    // swap rows on bottom part of B
-                    //fmt.Printf("1x1 pivot top with %d [%d]\n", p1.pivots[0], p1.pivots[0]-BT.Rows())
-                    merge2x1(&Bx, &B0, &b1)
-                    swapRows(&Bx, Bx.Rows()-1, p1.pivots[0]-1)
-                }
-                nc -= 1
-            } else if np == 2 {
-                Mult(&b1, &a01, &B2, -1.0, 1.0, TRANSA)
-                if p1.pivots[0] != -nc {
-                    // swap rows on bottom part of B
-                    //fmt.Printf("2x2 pivot %d with %d\n", nc, -p1.pivots[0])
-                    merge2x1(&Bx, &B0, &b1)
-                    //fmt.Printf("pre :\n%v\n", B)
-                    swapRows(&Bx, Bx.Rows()-2, -p1.pivots[0]-1)
-                    //fmt.Printf("post:\n%v\n", B)
+                // b1 = a11.-1*b1
+                for j := 0; j < P; j++ {
+                    s0 := b1.GetAt(0, j)
+                    s1 := b1.GetAt(1, j)
+                    b1.SetAt(0, j, (dpb*s0-s1)/scale)
+                    b1.SetAt(1, j, (apb*s1-s0)/scale)
                 }
-                nc -= 2
+                k -= 2
             }
         }
+        return nil
+    }
 
-        // ------------------------------------------------------------
-
-        continue3x3to2x2(
-            &ATL, &ATR,
-            &ABL, &ABR,   &A00, &a11, &A22,   A, aDir)
-        continue3x1to2x1(
-            &BT,
-            &BB,    &B0, &b1,    B, bDir)
-        contPivot3x1to2x1(
-            &pT,
-            &pB,    &p0, &p1,    p, bDir)
-
+    for k := 0; k < N; {
+        np := 1
+        if p.pivots[k] < 0 {
+            np = 2
+        }
+        if k > 0 {
+            // b1 = b1 - a01.T*B0
+            A.SubMatrix(&a01, 0, k, k, np)
+            B.SubMatrix(&B0, 0, 0, k, P)
+            B.SubMatrix(&b1, k, 0, np, P)
+            Mult(&b1, &a01, &B0, -1.0, 1.0, TRANSA)
+        }
+        if np == 1 && p.pivots[k]-1 != k {
+            swapRows(B, k, p.pivots[k]-1)
+        } else if np == 2 && -p.pivots[k]-1 != k {
+            swapRows(B, k, -p.pivots[k]-1)
+        }
+        k += np
     }
-    return err
+    return nil
 }
 
-func SolveBK(B, A *matrix.FloatMatrix, ipiv []int, flags Flags) {
+/*
+ * Solves a system of linear equations A*X = B with symmetric matrix A using
+ * the Bunch-Kaufman factorization A = L*D*L.T or A = U*D*U.T computed by
+ * DecomposeBK().
+ *
+ * Arguments:
+ *  B      On entry, the right hand side matrix B. On exit, the solution matrix X.
+ *
+ *  A      The block diagonal D and triangular factor U or L as computed by
+ *         DecomposeBK().
+ *
+ *  ipiv   Pivot indeces as computed by DecomposeBK().
+ *
+ *  flags  Indicator of which factor is stored in A, UPPER or LOWER.
+ *
+ * Returns:
+ *  Error if flags does not have exactly one of UPPER and LOWER or if sizes of
+ *  A, B and ipiv do not match. If D has zero 1x1 block error is *SingularError
+ *  with its index and B is not changed.
+ */
+func SolveBKChecked(B, A *matrix.FloatMatrix, ipiv []int, flags Flags) error {
+    var err error
+    if ! oneOf(flags, UPPER, LOWER) {
+        return flagError("SolveBK", "UPPER or LOWER")
+    }
+    if A.Rows() != A.Cols() || B.Rows() != A.Rows() || len(ipiv) < A.Rows() {
+        return sizeError("SolveBK", B, A, shape{len(ipiv), 1})
+    }
+    if err = singularBK(A, ipiv); err != nil {
+        return err
+    }
     if flags & LOWER != 0 {
         // first part: Z = D.-1*(L.-1*B)
-        unblkSolveBKLower(B, A, &pPivots{ipiv}, 1)
-        // second part: X = L.-T*Z
-        unblkSolveBKLower(B, A, &pPivots{ipiv}, 2)
-    } else if flags & UPPER != 0 {
+        if err = unblkSolveBKLower(B, A, &pPivots{ipiv}, 1); err == nil {
+            // second part: X = L.-T*Z
+            err = unblkSolveBKLower(B, A, &pPivots{ipiv}, 2)
+        }
+    } else {
         // first part: Z = D.-1*(U.-1*B)
-        unblkSolveBKUpper(B, A, &pPivots{ipiv}, 1)
-        // second part: X = U.-T*Z
-        unblkSolveBKUpper(B, A, &pPivots{ipiv}, 2)
+        if err = unblkSolveBKUpper(B, A, &pPivots{ipiv}, 1); err == nil {
+            // second part: X = U.-T*Z
+            err = unblkSolveBKUpper(B, A, &pPivots{ipiv}, 2)
+        }
     }
+    return err
+}
+
+// Solves A*X = B with Bunch-Kaufman factorization of A.
+//
+// Deprecated: Use SolveBKChecked, which validates its arguments and returns an error.
+func SolveBK(B, A *matrix.FloatMatrix, ipiv []int, flags Flags) {
+    SolveBKChecked(B, A, ipiv, flags)
 }
 
 // Local Variables:
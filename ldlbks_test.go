@@ -0,0 +1,131 @@
+
+// Copyright (c) Harri Rautila, 2013
+
+// This file is part of github.com/hrautila/matops package. It is free software,
+// distributed under the terms of GNU Lesser General Public License Version 3, or
+// any later version. See the COPYING tile included in this archive.
+
+package matops
+
+import (
+    "github.com/hrautila/matrix"
+    "testing"
+)
+
+// Symmetric indefinite N-by-N matrix; zero diagonal forces 2x2 pivots.
+func symIndefinite(N int, zeroDiag bool) *matrix.FloatMatrix {
+    B := matrix.FloatNormal(N, N)
+    A := B.Transpose()
+    A.Plus(B)
+    if zeroDiag {
+        for k := 0; k < N; k++ {
+            A.SetAt(k, k, 0.0)
+        }
+    }
+    return A
+}
+
+// ||B - A*X||_1 for X solved with Bunch-Kaufman factorization of A
+func solveBKResidual(t *testing.T, A *matrix.FloatMatrix, flags Flags, nb int) float64 {
+    N := A.Rows()
+    ipiv := make([]int, N)
+    W := matrix.FloatZeros(N, imax(nb+1, 2))
+    F, err := DecomposeBK(A.Copy(), W, ipiv, flags, nb)
+    if err != nil {
+        t.Fatalf("DecomposeBK flags %x nb %d: %v\n", flags, nb, err)
+    }
+    B := matrix.FloatNormal(N, 3)
+    X := B.Copy()
+    if err := SolveBKChecked(X, F, ipiv, flags); err != nil {
+        t.Fatalf("SolveBK flags %x nb %d: %v\n", flags, nb, err)
+    }
+    Mult(B, A, X, -1.0, 1.0, NOTRANS)
+    return NormP(B, NORM_ONE)
+}
+
+func TestSolveBK(t *testing.T) {
+    N := 40
+    for _, zeroDiag := range []bool{false, true} {
+        A := symIndefinite(N, zeroDiag)
+        for _, flags := range []Flags{LOWER, UPPER} {
+            // nb 3 keeps 2x2 pivots close to work space boundaries
+            for _, nb := range []int{0, 3, 8} {
+                d := solveBKResidual(t, A, flags, nb)
+                t.Logf("zero diag %v flags %x nb %d: ||B - A*X||_1: %e\n", zeroDiag, flags, nb, d)
+                if d > 1e-9 {
+                    t.Errorf("zero diag %v flags %x nb %d: residual too large\n", zeroDiag, flags, nb)
+                }
+            }
+        }
+    }
+}
+
+// Blocked factorization gives same factors and pivots as unblocked one.
+func TestDecomposeBKBlocked(t *testing.T) {
+    N := 33
+    for _, zeroDiag := range []bool{false, true} {
+        A := symIndefinite(N, zeroDiag)
+        for _, flags := range []Flags{LOWER, UPPER} {
+            ipiv0 := make([]int, N)
+            F0, _ := DecomposeBK(A.Copy(), matrix.FloatZeros(N, 2), ipiv0, flags, 0)
+            for _, nb := range []int{2, 3, 4, 8} {
+                ipiv := make([]int, N)
+                F, _ := DecomposeBK(A.Copy(), matrix.FloatZeros(N, nb+1), ipiv, flags, nb)
+                for k := range ipiv {
+                    if ipiv[k] != ipiv0[k] {
+                        t.Errorf("zero diag %v flags %x nb %d: pivots %v, expected %v\n",
+                            zeroDiag, flags, nb, ipiv, ipiv0)
+                        break
+                    }
+                }
+                if ! F.AllClose(F0) {
+                    t.Errorf("zero diag %v flags %x nb %d: factors differ\n", zeroDiag, flags, nb)
+                }
+            }
+        }
+    }
+}
+
+// 2x2 pivots where pivot row is next to the diagonal block; no interchange.
+func TestDecomposeBKAdjacent(t *testing.T) {
+    N := 6
+    A := matrix.FloatWithValue(N, N, 0.1)
+    for k := 0; k < N; k += 2 {
+        A.SetAt(k, k, 0.0)
+        A.SetAt(k+1, k+1, 0.0)
+        A.SetAt(k, k+1, 5.0)
+        A.SetAt(k+1, k, 5.0)
+    }
+    for _, flags := range []Flags{LOWER, UPPER} {
+        for _, nb := range []int{0, 2} {
+            ipiv := make([]int, N)
+            DecomposeBK(A.Copy(), matrix.FloatZeros(N, nb+2), ipiv, flags, nb)
+            for k := 0; k < N; k++ {
+                if ipiv[k] != -(k - k%2 + 1) && ipiv[k] != -(k - k%2 + 2) {
+                    t.Errorf("flags %x nb %d: pivots %v\n", flags, nb, ipiv)
+                    break
+                }
+            }
+            d := solveBKResidual(t, A, flags, nb)
+            t.Logf("flags %x nb %d: ||B - A*X||_1: %e\n", flags, nb, d)
+            if d > 1e-9 {
+                t.Errorf("flags %x nb %d: residual too large\n", flags, nb)
+            }
+        }
+    }
+}
+
+func TestDecomposeBKFlags(t *testing.T) {
+    N := 8
+    A := symIndefinite(N, false)
+    for _, flags := range []Flags{0, UPPER|LOWER} {
+        if _, err := DecomposeBK(A.Copy(), matrix.FloatZeros(N, 2), make([]int, N), flags, 0); err == nil {
+            t.Errorf("flags %x: expected error\n", flags)
+        }
+    }
+}
+
+// Local Variables:
+// tab-width: 4
+// indent-tabs-mode: nil
+// End:
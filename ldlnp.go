@@ -9,7 +9,7 @@ package matops
 
 import (
 	"github.com/hrautila/matrix"
-    "errors"
+	"context"
 )
 
 /*
@@ -101,7 +101,7 @@ func unblkUpperLDLnoPiv(A *matrix.FloatMatrix) (err error) {
  *   A21  =   L21*D1*L11.t                 => L21 = A21*(D1*L11.t).-1 = A21*L11.-T*D1.-1
  *   A22  =   L21*D1*L21.t + L22*D2*L22.t  => L22 = A22 - L21*D1*L21.t
  */
-func blkLowerLDLnoPiv(A, W *matrix.FloatMatrix, nb int) (err error) {
+func blkLowerLDLnoPiv(ctx context.Context, conf *Config, A, W *matrix.FloatMatrix, nb int) (err error) {
     var ATL, ATR, ABL, ABR matrix.FloatMatrix
     var A00, A10, A11,  A20, A21, A22 matrix.FloatMatrix
     var D1, wrk matrix.FloatMatrix
@@ -124,17 +124,19 @@ func blkLowerLDLnoPiv(A, W *matrix.FloatMatrix, nb int) (err error) {
         A11.Diag(&D1)
 
         // A21 = A21*A11.-T
-        SolveTrm(&A21, &A11, 1.0, LOWER|UNIT|RIGHT|TRANSA)
+        solveTrmConf(ctx, conf, &A21, &A11, 1.0, LOWER|UNIT|RIGHT|TRANSA)
         // A21 = A21*D1.-1
-        SolveDiag(&A21, &D1, RIGHT)
+        solveDiag(&A21, &D1, RIGHT)
 
         // W = D1*L21.T = L21*D1
-        W.SubMatrix(&wrk, 0, 0, A21.Rows(), nb)
+        W.SubMatrix(&wrk, 0, 0, A21.Rows(), A11.Cols())
         A21.CopyTo(&wrk)
-        MultDiag(&wrk, &D1, RIGHT)
+        multDiag(&wrk, &D1, RIGHT)
 
         // A22 = A22 - L21*D1*L21.T = A22 - L21*W
-        UpdateTrm(&A22, &A21, &wrk, -1.0, 1.0, LOWER|TRANSB)
+        if err = updateTrmConf(ctx, conf, &A22, &A21, &wrk, -1.0, 1.0, LOWER|TRANSB); err != nil {
+            return
+        }
 
         // ---------------------------------------------------------
 
@@ -145,7 +147,7 @@ func blkLowerLDLnoPiv(A, W *matrix.FloatMatrix, nb int) (err error) {
     return
 }
 
-func blkUpperLDLnoPiv(A, W *matrix.FloatMatrix, nb int) (err error) {
+func blkUpperLDLnoPiv(ctx context.Context, conf *Config, A, W *matrix.FloatMatrix, nb int) (err error) {
     var ATL, ATR, ABL, ABR matrix.FloatMatrix
     var A00, A01, A02, A11, A12, A22 matrix.FloatMatrix
     var D1, wrk matrix.FloatMatrix
@@ -168,17 +170,19 @@ func blkUpperLDLnoPiv(A, W *matrix.FloatMatrix, nb int) (err error) {
         A11.Diag(&D1)
 
         // A01 = A01*A11.-T
-        SolveTrm(&A01, &A11, 1.0, UPPER|UNIT|RIGHT|TRANSA)
+        solveTrmConf(ctx, conf, &A01, &A11, 1.0, UPPER|UNIT|RIGHT|TRANSA)
         // A01 = A01*D1.-1
-        SolveDiag(&A01, &D1, RIGHT)
+        solveDiag(&A01, &D1, RIGHT)
 
         // W = D1*U01.T = U01*D1
-        W.SubMatrix(&wrk, 0, 0, A01.Rows(), nb)
+        W.SubMatrix(&wrk, 0, 0, A01.Rows(), A11.Cols())
         A01.CopyTo(&wrk)
-        MultDiag(&wrk, &D1, RIGHT)
+        multDiag(&wrk, &D1, RIGHT)
 
         // A00 = A00 - U01*D1*U01.T = A22 - U01*W.T
-        UpdateTrm(&A00, &A01, &wrk, -1.0, 1.0, UPPER|TRANSB)
+        if err = updateTrmConf(ctx, conf, &A00, &A01, &wrk, -1.0, 1.0, UPPER|TRANSB); err != nil {
+            return
+        }
 
         // ---------------------------------------------------------
 
@@ -205,13 +209,33 @@ func blkUpperLDLnoPiv(A, W *matrix.FloatMatrix, nb int) (err error) {
  *          N < nb unblocked algorithm is used.
  *
  * Returns:
- *  LDL factorization and error indicator.
+ *  LDL factorization and error indicator. If D has zero on diagonal error is
+ *  *BreakdownError with index of the first zero.
  *
  */
 func DecomposeLDLnoPiv(A, W *matrix.FloatMatrix, flags Flags, nb int) (*matrix.FloatMatrix, error) {
+    return decomposeLDLnoPiv(context.Background(), globalConfig(nil), A, W, flags, nb)
+}
+
+// LDL factorization without pivoting with block size conf.DecompNB and parameters
+// from conf. See Config and DecomposeLDLnoPiv().
+func DecomposeLDLnoPivConfig(ctx context.Context, conf *Config, A, W *matrix.FloatMatrix,
+    flags Flags) (*matrix.FloatMatrix, error) {
+
+    c, release := conf.resolve()
+    defer release()
+    if err := ctx.Err(); err != nil {
+        return A, err
+    }
+    return decomposeLDLnoPiv(ctx, c, A, W, flags, c.DecompNB)
+}
+
+func decomposeLDLnoPiv(ctx context.Context, conf *Config, A, W *matrix.FloatMatrix,
+    flags Flags, nb int) (*matrix.FloatMatrix, error) {
+
     var err error
     if A.Cols() != A.Rows() {
-        return nil, errors.New("A not a square matrix")
+        return nil, conf.sizeError("DecomposeLDLnoPiv", A)
     }
     if A.Cols() < nb || nb == 0 {
         if flags & LOWER != 0 {
@@ -220,15 +244,18 @@ func DecomposeLDLnoPiv(A, W *matrix.FloatMatrix, flags Flags, nb int) (*matrix.F
             err = unblkUpperLDLnoPiv(A)
         }
     } else {
-        if W == nil {
-            return nil, errors.New("No workspace for blocking invocation")
+        if W == nil || W.Rows() < A.Rows() || W.Cols() < nb {
+            return nil, conf.sizeError("DecomposeLDLnoPiv", A, workDims(W))
         }
         if flags & LOWER != 0 {
-            err = blkLowerLDLnoPiv(A, W, nb)
+            err = blkLowerLDLnoPiv(ctx, conf, A, W, nb)
         } else {
-            err = blkUpperLDLnoPiv(A, W, nb)
+            err = blkUpperLDLnoPiv(ctx, conf, A, W, nb)
         }
     }
+    if k := zeroPivot(A); err == nil && k >= 0 {
+        err = &BreakdownError{"DecomposeLDLnoPiv", k}
+    }
     return A, err
 }
 
@@ -247,19 +274,44 @@ func DecomposeLDLnoPiv(A, W *matrix.FloatMatrix, flags Flags, nb int) (*matrix.F
  *  flags Indicator of which factor is stored in A. If flags&UPPER then upper
  *        triangle of A is stored. If flags&LOWER then lower triangle of A is
  *        stored.
+ *
+ * Returns:
+ *  Error if flags does not have exactly one of UPPER and LOWER or if sizes of
+ *  A and B do not match. If D has zero on diagonal error is *SingularError
+ *  with its index and B is not changed.
  */
-func SolveLDLnoPiv(B, A *matrix.FloatMatrix, flags Flags)  {
+func SolveLDLnoPivChecked(B, A *matrix.FloatMatrix, flags Flags) error {
+    var err error
+    if ! oneOf(flags, UPPER, LOWER) {
+        return flagError("SolveLDLnoPiv", "UPPER or LOWER")
+    }
+    if A.Rows() != A.Cols() || B.Rows() != A.Rows() {
+        return sizeError("SolveLDLnoPiv", B, A)
+    }
+    if k := zeroPivot(A); k >= 0 {
+        return &SingularError{k}
+    }
     if flags&UPPER != 0 {
         // X = (U*D*U.T).-1*B => U.-T*(D.-1*(U.-1*B))
-        SolveTrm(B, A, 1.0, UPPER|UNIT)
-        SolveDiag(B, A, LEFT)
-        SolveTrm(B, A, 1.0, UPPER|UNIT|TRANSA)
-    } else if flags&LOWER != 0 {
+        if err = SolveTrm(B, A, 1.0, UPPER|UNIT); err == nil {
+            solveDiag(B, A, LEFT)
+            err = SolveTrm(B, A, 1.0, UPPER|UNIT|TRANSA)
+        }
+    } else {
         // X = (L*D*L.T).-1*B = L.-T*(D*-1(L.-1*B))
-        SolveTrm(B, A, 1.0, LOWER|UNIT)
-        SolveDiag(B, A, LEFT)
-        SolveTrm(B, A, 1.0, LOWER|UNIT|TRANSA)
+        if err = SolveTrm(B, A, 1.0, LOWER|UNIT); err == nil {
+            solveDiag(B, A, LEFT)
+            err = SolveTrm(B, A, 1.0, LOWER|UNIT|TRANSA)
+        }
     }
+    return err
+}
+
+// Solves A*X = B with LDL factorization of A computed without pivoting.
+//
+// Deprecated: Use SolveLDLnoPivChecked, which validates its arguments and returns an error.
+func SolveLDLnoPiv(B, A *matrix.FloatMatrix, flags Flags)  {
+    SolveLDLnoPivChecked(B, A, flags)
 }
 
 // Local Variables:
@@ -0,0 +1,692 @@
+
+// Copyright (c) Harri Rautila, 2013
+
+// This file is part of github.com/hrautila/matops package. It is free software,
+// distributed under the terms of GNU Lesser General Public License Version 3, or
+// any later version. See the COPYING tile included in this archive.
+
+package matops
+
+import (
+    "github.com/hrautila/matrix"
+    //"fmt"
+)
+
+/*
+ * like LAPACK/dlarft.f (forward, rowwise)
+ *
+ * Build block reflector T from HH reflectors stored in rows of TriUU(A) and
+ * coefficients in tau.
+ *
+ * P = H(1)H(2)...H(k) = I - Y.T*T*Y; Householder H = I - tau*v.T*v
+ *
+ * T = | T  z |   z = -tau*T*Y*v.T
+ *     | 0  c |   c = tau
+ */
+func unblkLQBlockReflector(T, A, tau *matrix.FloatMatrix) {
+    var ATL, ATR, ABL, ABR matrix.FloatMatrix
+    var A00, a01, A02, a11, a12, A22 matrix.FloatMatrix
+    var TTL, TTR, TBL, TBR matrix.FloatMatrix
+    var T00, t01, T02, t11, t12, T22 matrix.FloatMatrix
+    var tT, tB matrix.FloatMatrix
+    var t0, tau1, t2  matrix.FloatMatrix
+
+    partition2x2(
+        &ATL, &ATR,
+        &ABL, &ABR, A, 0, 0, pTOPLEFT)
+    partition2x2(
+        &TTL, &TTR,
+        &TBL, &TBR, T, 0, 0, pTOPLEFT)
+    partition2x1(
+        &tT,
+        &tB,  tau, 0, pTOP)
+
+    for ABR.Rows() > 0 && ABR.Cols() > 0 {
+        repartition2x2to3x3(&ATL,
+            &A00, &a01, &A02,
+            nil,  &a11, &a12,
+            nil,  nil,  &A22,   A, 1, pBOTTOMRIGHT)
+        repartition2x2to3x3(&TTL,
+            &T00, &t01, &T02,
+            nil,  &t11, &t12,
+            nil,  nil,  &T22,   T, 1, pBOTTOMRIGHT)
+        repartition2x1to3x1(&tT,
+            &t0,
+            &tau1,
+            &t2,     tau, 1, pBOTTOM)
+        // --------------------------------------------------
+
+        // t11 := tau
+        tauval := tau1.GetAt(0, 0)
+        t11.SetAt(0, 0, tauval)
+
+        // t01 := -tauval*(a01 + A02*a12.T)
+        a01.CopyTo(&t01)
+        MVMult(&t01, &A02, &a12, -tauval, -tauval, NOTRANS)
+        // t01 := T00*t01
+        MVMultTrm(&t01, &T00, UPPER)
+
+        // --------------------------------------------------
+        continue3x3to2x2(
+            &ATL, &ATR,
+            &ABL, &ABR,   &A00, &a11, &A22,   A, pBOTTOMRIGHT)
+        continue3x3to2x2(
+            &TTL, &TTR,
+            &TBL, &TBR,   &T00, &t11, &T22,   T, pBOTTOMRIGHT)
+        continue3x1to2x1(
+            &tT,
+            &tB,   &t0, &tau1,   tau, pBOTTOM)
+    }
+}
+
+/*
+ * Unblocked LQ decomposition. As implemented in lapack.xGELQ2 subroutine.
+ *
+ * Column vector w of length A.Rows() is used as workspace.
+ */
+func unblockedLQ(A, tau, w *matrix.FloatMatrix) {
+    var ATL, ATR, ABL, ABR matrix.FloatMatrix
+    var A00, a11, a12, a21, A22 matrix.FloatMatrix
+    var tT, tB matrix.FloatMatrix
+    var t0, tau1, t2, w1  matrix.FloatMatrix
+
+    partition2x2(
+        &ATL, &ATR,
+        &ABL, &ABR, A, 0, 0, pTOPLEFT)
+    partition2x1(
+        &tT,
+        &tB,  tau, 0, pTOP)
+
+    for ABR.Rows() > 0 && ABR.Cols() > 0 {
+        repartition2x2to3x3(&ATL,
+            &A00, nil,  nil,
+            nil,  &a11, &a12,
+            nil,  &a21, &A22,   A, 1, pBOTTOMRIGHT)
+        repartition2x1to3x1(&tT,
+            &t0,
+            &tau1,
+            &t2,     tau, 1, pBOTTOM)
+
+        // ------------------------------------------------------
+        computeHouseholder(&a11, &a12, &tau1, RIGHT)
+
+        // [a21 A22] = [a21 A22]*H
+        w.SubMatrix(&w1, 0, 0, a21.Rows(), 1)
+        applyHHTo2x1(&tau1, &a12, &a21, &A22, &w1, RIGHT)
+
+        // ------------------------------------------------------
+        continue3x3to2x2(
+            &ATL, &ATR,
+            &ABL, &ABR,   &A00, &a11, &A22,   A, pBOTTOMRIGHT)
+        continue3x1to2x1(
+            &tT,
+            &tB,   &t0, &tau1,   tau, pBOTTOM)
+    }
+}
+
+/*
+ * Unblocked LQ decomposition with block reflector T.
+ */
+func unblockedLQT(A, T, w *matrix.FloatMatrix) {
+    var ATL, ATR, ABL, ABR matrix.FloatMatrix
+    var A00, a01, A02, a11, a12, a21, A22 matrix.FloatMatrix
+    var TTL, TTR, TBL, TBR matrix.FloatMatrix
+    var T00, t01, T02, t11, t12, T22 matrix.FloatMatrix
+    var w1 matrix.FloatMatrix
+
+    partition2x2(
+        &ATL, &ATR,
+        &ABL, &ABR, A, 0, 0, pTOPLEFT)
+    partition2x2(
+        &TTL, &TTR,
+        &TBL, &TBR, T, 0, 0, pTOPLEFT)
+
+    for ABR.Rows() > 0 && ABR.Cols() > 0 {
+        repartition2x2to3x3(&ATL,
+            &A00, &a01, &A02,
+            nil,  &a11, &a12,
+            nil,  &a21, &A22,   A, 1, pBOTTOMRIGHT)
+        repartition2x2to3x3(&TTL,
+            &T00, &t01, &T02,
+            nil,  &t11, &t12,
+            nil,  nil,  &T22,   T, 1, pBOTTOMRIGHT)
+
+        // ------------------------------------------------------
+
+        computeHouseholder(&a11, &a12, &t11, RIGHT)
+
+        // [a21 A22] = [a21 A22]*H
+        w.SubMatrix(&w1, 0, 0, a21.Rows(), 1)
+        applyHHTo2x1(&t11, &a12, &a21, &A22, &w1, RIGHT)
+
+        // update T
+        tauval := t11.GetAt(0, 0)
+        // t01 := -tauval*(a01 + A02*a12.T)
+        a01.CopyTo(&t01)
+        MVMult(&t01, &A02, &a12, -tauval, -tauval, NOTRANS)
+        // t01 := T00*t01
+        MVMultTrm(&t01, &T00, UPPER)
+
+        // ------------------------------------------------------
+        continue3x3to2x2(
+            &ATL, &ATR,
+            &ABL, &ABR,   &A00, &a11, &A22,   A, pBOTTOMRIGHT)
+        continue3x3to2x2(
+            &TTL, &TTR,
+            &TBL, &TBR,   &T00, &t11, &T22,   T, pBOTTOMRIGHT)
+    }
+}
+
+// compute:
+//      C*P   = C*(I - Y.T*T*Y)   ==  C - C*Y.T*T*Y
+// or
+//      C*P.T = C*(I - Y.T*T*Y).T ==  C - C*Y.T*T.T*Y
+//
+// where  C = ( C1 C2 )   Y = ( Y1 Y2 )
+//
+// C1 is K*nb, C2 is K*P, Y1 is nb*nb triuu, Y2 is nb*P, T is nb*nb
+// W = K*nb
+func updateWithLQTRight(C1, C2, Y1, Y2, T, W *matrix.FloatMatrix, transpose bool) {
+
+    // W = C1*Y1.T
+    ScalePlus(W, C1, 0.0, 1.0, NOTRANS)
+    MultTrm(W, Y1, 1.0, UPPER|UNIT|RIGHT|TRANSA)
+    // W = W + C2*Y2.T
+    Mult(W, C2, Y2, 1.0, 1.0, TRANSB)
+
+    // --- here: W == C*Y.T ---
+    tflags := UPPER|RIGHT
+    if transpose {
+        tflags |= TRANSA
+    }
+    // W = W*T or W*T.T
+    MultTrm(W, T, 1.0, Flags(tflags))
+
+    // C2 = C2 - W*Y2
+    Mult(C2, W, Y2, -1.0, 1.0, NOTRANS)
+    // C1 = C1 - W*Y1
+    MultTrm(W, Y1, 1.0, UPPER|UNIT|RIGHT)
+    ScalePlus(C1, W, 1.0, -1.0, NOTRANS)
+}
+
+// compute:
+//      P*C   = (I - Y.T*T*Y)*C   ==  C - Y.T*(C.T*Y.T*T.T).T
+// or
+//      P.T*C = (I - Y.T*T*Y).T*C ==  C - Y.T*(C.T*Y.T*T).T
+//
+// where  C = /C1\   Y = ( Y1 Y2 )
+//            \C2/
+//
+// C1 is nb*K, C2 is P*K, Y1 is nb*nb triuu, Y2 is nb*P, T is nb*nb
+// W = K*nb
+func updateWithLQTLeft(C1, C2, Y1, Y2, T, W *matrix.FloatMatrix, transpose bool) {
+
+    // W = C1.T*Y1.T
+    ScalePlus(W, C1, 0.0, 1.0, TRANSB)
+    MultTrm(W, Y1, 1.0, UPPER|UNIT|RIGHT|TRANSA)
+    // W = W + C2.T*Y2.T
+    Mult(W, C2, Y2, 1.0, 1.0, TRANSA|TRANSB)
+
+    // --- here: W == C.T*Y.T ---
+    tflags := UPPER|RIGHT
+    if ! transpose {
+        tflags |= TRANSA
+    }
+    // W = W*T.T or W*T
+    MultTrm(W, T, 1.0, Flags(tflags))
+
+    // C2 = C2 - Y2.T*W.T
+    Mult(C2, Y2, W, -1.0, 1.0, TRANSA|TRANSB)
+    // C1 = C1 - Y1.T*W.T = C1 - (W*Y1).T
+    MultTrm(W, Y1, 1.0, UPPER|UNIT|RIGHT)
+    ScalePlus(C1, W, 1.0, -1.0, TRANSB)
+}
+
+// update T: T = -T1*Y1*Y2.T*T2
+//  Y1 = ( Y01 Y02 )  Y2 = ( Y11 Y12 )
+//
+//  T = -T1 * [Y01*Y11.T + Y02*Y12.T]*T2
+//
+//  T1 is K*K upper triangular matrix
+//  T2 is nb*nb upper triangular matrix
+//  T  is K*nb block matrix
+//  Y01 is K*nb block matrix
+//  Y02 is K*(N-K-nb) block matrix
+//  Y11 is nb*nb upper triangular unit diagonal matrix
+//  Y12 is nb*(N-K-nb) block matrix
+func updateLQTReflector(T, Y01, Y02, Y11, Y12, T1, T2 *matrix.FloatMatrix) {
+    if Y01.Rows() == 0 {
+        return
+    }
+    // T = Y01*Y11.T
+    ScalePlus(T, Y01, 0.0, 1.0, NOTRANS)
+    MultTrm(T, Y11, 1.0, UPPER|UNIT|RIGHT|TRANSA)
+    // T = T + Y02*Y12.T
+    Mult(T, Y02, Y12, 1.0, 1.0, TRANSB)
+    // T = -T1*T
+    MultTrm(T, T1, -1.0, UPPER)
+    // T = T*T2
+    MultTrm(T, T2, 1.0, UPPER|RIGHT)
+}
+
+/*
+ * Blocked LQ decomposition with compact WY transform. As implemented
+ * in lapack.xGELQF subroutine.
+ */
+func blockedLQ(A, tau, Twork, W *matrix.FloatMatrix, nb int) {
+    var ATL, ATR, ABL, ABR, AT matrix.FloatMatrix
+    var A00, A11, A12, A21, A22 matrix.FloatMatrix
+    var tT, tB matrix.FloatMatrix
+    var t0, tau1, t2, Tw, Tdiag, Wrk, w matrix.FloatMatrix
+
+    partition2x2(
+        &ATL, &ATR,
+        &ABL, &ABR, A, 0, 0, pTOPLEFT)
+    partition2x1(
+        &tT,
+        &tB,  tau, 0, pTOP)
+
+    for ABR.Rows() > 0 && ABR.Cols() > 0 {
+        repartition2x2to3x3(&ATL,
+            &A00, nil,  nil,
+            nil,  &A11, &A12,
+            nil,  &A21, &A22,   A, imin(nb, ABR.Rows()), pBOTTOMRIGHT)
+        cb := A11.Cols()
+        repartition2x1to3x1(&tT,
+            &t0,
+            &tau1,
+            &t2,     tau, cb, pBOTTOM)
+
+        // --------------------------------------------------------
+
+        // decompose top side AT == ( A11 A12 )
+        merge1x2(&AT, &A11, &A12)
+        Twork.SubMatrix(&Tw, 0, 0, cb, cb)
+        W.SubMatrix(&w, 0, 0, cb, 1)
+        unblockedLQT(&AT, &Tw, &w)
+
+        // copy scaling from T diagonal to tau-vector
+        Tdiag.DiagOf(&Tw)
+        Tdiag.CopyTo(&tau1)
+
+        // update A'tail i.e. A21 and A22 with A'tail*(I - Y.T*T*Y)
+        W.SubMatrix(&Wrk, 0, 0, A21.Rows(), cb)
+        updateWithLQTRight(&A21, &A22, &A11, &A12, &Tw, &Wrk, false)
+
+        // --------------------------------------------------------
+        continue3x3to2x2(
+            &ATL, &ATR,
+            &ABL, &ABR,   &A00, &A11, &A22,   A, pBOTTOMRIGHT)
+        continue3x1to2x1(
+            &tT,
+            &tB,   &t0, &tau1,   tau, pBOTTOM)
+    }
+}
+
+func blockedLQT(A, T, W *matrix.FloatMatrix, nb int) {
+    var ATL, ATR, ABL, ABR, AT matrix.FloatMatrix
+    var A00, A01, A02, A11, A12, A21, A22 matrix.FloatMatrix
+    var TTL, TTR, TBL, TBR matrix.FloatMatrix
+    var T00, T01, T02, T11, T12, T22 matrix.FloatMatrix
+    var Wrk, w matrix.FloatMatrix
+
+    partition2x2(
+        &ATL, &ATR,
+        &ABL, &ABR, A, 0, 0, pTOPLEFT)
+    partition2x2(
+        &TTL, &TTR,
+        &TBL, &TBR, T, 0, 0, pTOPLEFT)
+
+    for ABR.Rows() > 0 && ABR.Cols() > 0 {
+        repartition2x2to3x3(&ATL,
+            &A00, &A01, &A02,
+            nil,  &A11, &A12,
+            nil,  &A21, &A22,   A, imin(nb, ABR.Rows()), pBOTTOMRIGHT)
+        cb := A11.Cols()
+        repartition2x2to3x3(&TTL,
+            &T00, &T01, &T02,
+            nil,  &T11, &T12,
+            nil,  nil,  &T22,   T, cb, pBOTTOMRIGHT)
+
+        // --------------------------------------------------------
+
+        // decompose top side AT == ( A11 A12 )
+        merge1x2(&AT, &A11, &A12)
+        W.SubMatrix(&w, 0, 0, cb, 1)
+        unblockedLQT(&AT, &T11, &w)
+
+        // update A'tail i.e. A21 and A22 with A'tail*(I - Y.T*T*Y)
+        W.SubMatrix(&Wrk, 0, 0, A21.Rows(), cb)
+        updateWithLQTRight(&A21, &A22, &A11, &A12, &T11, &Wrk, false)
+
+        // update T01: T01 = -T00*Y1*Y2.T*T11
+        //  Y1 = ( A01 A02 )  Y2 = ( A11 A12 )
+        updateLQTReflector(&T01, &A01, &A02, &A11, &A12, &T00, &T11)
+
+        // --------------------------------------------------------
+        continue3x3to2x2(
+            &ATL, &ATR,
+            &ABL, &ABR,   &A00, &A11, &A22,   A, pBOTTOMRIGHT)
+        continue3x3to2x2(
+            &TTL, &TTR,
+            &TBL, &TBR,   &T00, &T11, &T22,   T, pBOTTOMRIGHT)
+    }
+}
+
+// Build Q in place by applying elementary reflectors in reverse order to
+// an implied identity matrix.  This forms Q = H(k) ... H(2)H(1)
+//
+// this is compatibe with lapack.DORGL2
+func unblockedBuildLQ(A, tau, w *matrix.FloatMatrix) {
+    var ATL, ATR, ABL, ABR matrix.FloatMatrix
+    var A00, a01, A02, a10t, a11, a12t, A20, a21, A22 matrix.FloatMatrix
+    var tT, tB matrix.FloatMatrix
+    var t0, tau1, t2, w1 matrix.FloatMatrix
+
+    partition2x2(
+        &ATL, &ATR,
+        &ABL, &ABR,  A, 0, A.Cols()-A.Rows(), pBOTTOMRIGHT)
+    partition2x1(
+        &tT,
+        &tB,    tau, 0, pBOTTOM)
+
+    for ATL.Rows() > 0 && ATL.Cols() > 0 {
+        repartition2x2to3x3(&ATL,
+            &A00,  &a01, &A02,
+            &a10t, &a11, &a12t,
+            &A20,  &a21, &A22,   A, 1, pTOPLEFT)
+        repartition2x1to3x1(&tT,
+            &t0,
+            &tau1,
+            &t2,     tau, 1, pTOP)
+
+        // --------------------------------------------------------
+
+        // apply Householder reflection from right
+        w.SubMatrix(&w1, 0, 0, a21.Rows(), 1)
+        applyHHTo2x1(&tau1, &a12t, &a21, &A22, &w1, RIGHT)
+
+        // apply (in-place) current elementary reflector to unit vector
+        a12t.Scale(-tau1.Float())
+        a11.SetAt(0, 0, 1.0 - tau1.Float())
+
+        // zero the left part
+        a10t.SetIndexes(0.0)
+
+        // --------------------------------------------------------
+        continue3x3to2x2(
+            &ATL, &ATR,
+            &ABL, &ABR,   &A00, &a11, &A22,   A, pTOPLEFT)
+        continue3x1to2x1(
+            &tT,
+            &tB,    &t0, &tau1,   tau, pTOP)
+    }
+}
+
+// Blocked version of BuildLQ and BuildLQT. If T is nil block reflectors
+// are built from elementary reflectors and scalar coefficients in tau.
+func blockedBuildLQ(A, tau, T, W *matrix.FloatMatrix, nb int) {
+    var ATL, ATR, ABL, ABR, AT matrix.FloatMatrix
+    var A00, A01, A02, A10, A11, A12, A20, A21, A22 matrix.FloatMatrix
+    var TTL, TTR, TBL, TBR matrix.FloatMatrix
+    var T00, T01, T02, T11, T12, T22 matrix.FloatMatrix
+    var tT, tB matrix.FloatMatrix
+    var t0, tau1, t2, td, Tw, Wrk matrix.FloatMatrix
+    var Twork *matrix.FloatMatrix
+
+    if T == nil {
+        Twork = matrix.FloatZeros(nb, nb)
+    } else {
+        partition2x2(
+            &TTL, &TTR,
+            &TBL, &TBR,  T, 0, 0, pBOTTOMRIGHT)
+    }
+    partition2x2(
+        &ATL, &ATR,
+        &ABL, &ABR,  A, 0, A.Cols()-A.Rows(), pBOTTOMRIGHT)
+    partition2x1(
+        &tT,
+        &tB,    tau, 0, pBOTTOM)
+
+    for ATL.Rows() > 0 && ATL.Cols() > 0 {
+        repartition2x2to3x3(&ATL,
+            &A00,  &A01, &A02,
+            &A10,  &A11, &A12,
+            &A20,  &A21, &A22,   A, nb, pTOPLEFT)
+        cb := A11.Cols()
+        repartition2x1to3x1(&tT,
+            &t0,
+            &tau1,
+            &t2,     tau, cb, pTOP)
+        if T != nil {
+            repartition2x2to3x3(&TTL,
+                &T00, &T01, &T02,
+                nil,  &T11, &T12,
+                nil,  nil,  &T22,   T, cb, pTOPLEFT)
+        }
+
+        // --------------------------------------------------------
+
+        merge1x2(&AT, &A11, &A12)
+        if T == nil {
+            // build block reflector from current block
+            Twork.SubMatrix(&Tw, 0, 0, cb, cb)
+            unblkLQBlockReflector(&Tw, &AT, &tau1)
+        } else {
+            T11.SubMatrix(&Tw, 0, 0, cb, cb)
+            // elementary scalar coefficients on the diagonal
+            T11.Diag(&td)
+            td.CopyTo(&tau1)
+        }
+
+        // update with current block reflector Atrailing*(I - Y.T*T*Y).T
+        W.SubMatrix(&Wrk, 0, 0, A21.Rows(), cb)
+        updateWithLQTRight(&A21, &A22, &A11, &A12, &Tw, &Wrk, true)
+
+        // use unblocked version to compute current block
+        W.SubMatrix(&Wrk, 0, 0, cb, 1)
+        unblockedBuildLQ(&AT, &tau1, &Wrk)
+
+        // zero left part
+        A10.SetIndexes(0.0)
+
+        // --------------------------------------------------------
+        continue3x3to2x2(
+            &ATL, &ATR,
+            &ABL, &ABR,   &A00, &A11, &A22,   A, pTOPLEFT)
+        continue3x1to2x1(
+            &tT,
+            &tB,    &t0, &tau1,   tau, pTOP)
+        if T != nil {
+            continue3x3to2x2(
+                &TTL, &TTR,
+                &TBL, &TBR,   &T00, &T11, &T22,   T, pTOPLEFT)
+        }
+    }
+}
+
+/*
+ * Compute LQ factorization of a M-by-N matrix A: A = L * Q.
+ *
+ * Arguments:
+ *  A   On entry, the M-by-N matrix A. On exit, the elements on and below
+ *      the diagonal contain the M-by-min(M,N) lower trapezoidal matrix L.
+ *      The elements above the diagonal with the column vector 'tau', represent
+ *      the ortogonal matrix Q as product of elementary reflectors.
+ *
+ * tau  On exit, the scalar factors of the elemenentary reflectors.
+ *
+ * W    Workspace, M-by-nb matrix used for work space in blocked invocations.
+ *
+ * nb   The block size used in blocked invocations. If nb is zero on M <= nb
+ *      unblocked algorithm is used.
+ *
+ * Returns:
+ *      Decomposed matrix A and error indicator.
+ *
+ * DecomposeLQ is compatible with lapack.DGELQF
+ */
+func DecomposeLQ(A, tau, W *matrix.FloatMatrix, nb int) (*matrix.FloatMatrix, error) {
+    var err error = nil
+
+    if tau.NumElements() < imin(A.Rows(), A.Cols()) {
+        return nil, sizeError("DecomposeLQ", A, tau)
+    }
+    if nb == 0 || A.Rows() <= nb {
+        w := matrix.FloatZeros(A.Rows(), 1)
+        unblockedLQ(A, tau, w)
+    } else {
+        Twork := matrix.FloatZeros(nb, nb)
+        if W == nil {
+            W = matrix.FloatZeros(A.Rows(), nb)
+        } else if W.Cols() < nb || W.Rows() < A.Rows() {
+            return nil, sizeError("DecomposeLQ", A, W)
+        }
+        var Wrk matrix.FloatMatrix
+        Wrk.SubMatrixOf(W, 0, 0, A.Rows(), nb)
+        blockedLQ(A, tau, Twork, &Wrk, nb)
+    }
+    return A, err
+}
+
+/*
+ * Compute LQ factorization of a M-by-N matrix A using compact WY transformation: A = L * Q,
+ * where Q = I - Y.T*T.T*Y, T is block reflector and Y holds elementary reflectors as upper
+ * trapezoidal matrix saved above diagonal elements of the matrix A.
+ *
+ * Arguments:
+ *  A   On entry, the M-by-N matrix A. On exit, the elements on and below
+ *      the diagonal contain the M-by-min(M,N) lower trapezoidal matrix L.
+ *      The elements above the diagonal with the matrix 'T', represent
+ *      the ortogonal matrix Q as product of elementary reflectors.
+ *
+ * T    On exit, the M-by-M block reflector which, together with triuu(A) represent
+ *      the ortogonal matrix Q as Q = (I - Y.T*T*Y).T where Y = triuu(A).
+ *
+ * W    Workspace, M-by-nb matrix used for work space in blocked invocations.
+ *
+ * nb   The block size used in blocked invocations. If nb is zero on M <= nb
+ *      unblocked algorithm is used.
+ *
+ * Returns:
+ *      Decomposed matrix A and error indicator.
+ */
+func DecomposeLQT(A, T, W *matrix.FloatMatrix, nb int) (*matrix.FloatMatrix, error) {
+    var err error = nil
+    K := imin(A.Rows(), A.Cols())
+    if T.Rows() < K || T.Cols() < K {
+        return nil, sizeError("DecomposeLQT", A, T)
+    }
+    if nb == 0 || A.Rows() <= nb {
+        w := matrix.FloatZeros(A.Rows(), 1)
+        unblockedLQT(A, T, w)
+    } else {
+        if W == nil {
+            W = matrix.FloatZeros(A.Rows(), nb)
+        } else if W.Cols() < nb || W.Rows() < A.Rows() {
+            return nil, sizeError("DecomposeLQT", A, W)
+        }
+        var Wrk matrix.FloatMatrix
+        Wrk.SubMatrixOf(W, 0, 0, A.Rows(), nb)
+        blockedLQT(A, T, &Wrk, nb)
+    }
+    return A, err
+}
+
+/*
+ * Build block reflector T from HH elementary reflectors stored in rows of
+ * TriUU(A) and scalar factors in tau.
+ *
+ * Q.T = I - Y.T*T*Y; Householder H = I - tau*v.T*v
+ *
+ * Compatible with lapack.DLAFRT (forward, rowwise)
+ */
+func BuildTLQ(T, A, tau *matrix.FloatMatrix) (*matrix.FloatMatrix, error) {
+    var err error = nil
+
+    if T.Cols() < A.Rows() || T.Rows() < A.Rows() {
+        return nil, sizeError("BuildTLQ", T, A)
+    }
+    unblkLQBlockReflector(T, A, tau)
+    return T, err
+}
+
+/*
+ * Generate an M-by-N real matrix Q with ortonormal rows, which is
+ * defined as the product of M elementary reflectors
+ *
+ *    Q = H(k) . . . H(2) H(1)
+ *
+ * as returned by DecomposeLQ().
+ *
+ * Arguments:
+ *  A     On entry, LQ factorization as returned by DecomposeLQ() where the upper
+ *        trapezoidal part holds the elementary reflectors. On exit, the M-by-N
+ *        matrix Q. Requires M <= N.
+ *
+ *  tau   The scalar factors of elementary reflectors as returned by DecomposeLQ()
+ *
+ *  W     Workspace, size A.Rows()-by-nb.
+ *
+ *  nb    Blocksize for blocked invocations. If nb == 0 unblocked algorith is used
+ *
+ * Compatible with lapack.DORGLQ
+ */
+func BuildLQ(A, tau, W *matrix.FloatMatrix, nb int) (*matrix.FloatMatrix, error) {
+    if A.Rows() > A.Cols() {
+        return nil, sizeError("BuildLQ", A)
+    }
+    if nb == 0 {
+        w := matrix.FloatZeros(A.Rows(), 1)
+        unblockedBuildLQ(A, tau, w)
+        return A, nil
+    }
+    if W == nil {
+        W = matrix.FloatZeros(A.Rows(), nb)
+    } else if W.Cols() < nb || W.Rows() < A.Rows() {
+        return nil, sizeError("BuildLQ", A, W)
+    }
+    blockedBuildLQ(A, tau, nil, W, nb)
+    return A, nil
+}
+
+/*
+ * Generate an M-by-N real matrix Q with ortonormal rows, which is
+ * defined as the product of M elementary reflectors and block reflector T
+ * as returned by DecomposeLQT().
+ *
+ * Arguments:
+ *  A     On entry, LQ factorization as returned by DecomposeLQT() where the upper
+ *        trapezoidal part holds the elementary reflectors. On exit, the M-by-N
+ *        matrix Q. Requires M <= N.
+ *
+ *  T     The block reflector computed from elementary reflectors as returned by
+ *        DecomposeLQT() or computed from elementary reflectors and scalar coefficients
+ *        by BuildTLQ()
+ *
+ *  W     Workspace, size A.Rows()-by-nb.
+ *
+ *  nb    Blocksize for blocked invocations. If nb == 0 default value T.Cols()
+ *        is used.
+ */
+func BuildLQT(A, T, W *matrix.FloatMatrix, nb int) (*matrix.FloatMatrix, error) {
+    if A.Rows() > A.Cols() {
+        return nil, sizeError("BuildLQT", A, T)
+    }
+    if nb == 0 {
+        nb = T.Cols()
+    }
+    if W == nil {
+        W = matrix.FloatZeros(A.Rows(), nb)
+    } else if W.Cols() < nb || W.Rows() < A.Rows() {
+        return nil, sizeError("BuildLQT", A, W)
+    }
+    tau := matrix.FloatZeros(A.Rows(), 1)
+    blockedBuildLQ(A, tau, T, W, nb)
+    return A, nil
+}
+
+// Local Variables:
+// tab-width: 4
+// indent-tabs-mode: nil
+// End:
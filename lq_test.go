@@ -0,0 +1,228 @@
+
+// Copyright (c) Harri Rautila, 2013
+
+// This file is part of github.com/hrautila/matops package. It is free software,
+// distributed under the terms of GNU Lesser General Public License Version 3, or
+// any later version. See the COPYING tile included in this archive.
+
+package matops
+
+import (
+    "fmt"
+    "github.com/hrautila/matrix"
+    "math"
+    "testing"
+)
+
+// Compute ||A - L*Q||_1 where Q is applied with MultLQ or MultLQT
+func lqResidual(A, LQ *matrix.FloatMatrix, mult func(C *matrix.FloatMatrix)) float64 {
+    var Ld, Ls matrix.FloatMatrix
+    M := A.Rows()
+    L := matrix.FloatZeros(M, A.Cols())
+    L.SubMatrix(&Ld, 0, 0, M, M)
+    LQ.SubMatrix(&Ls, 0, 0, M, M)
+    Ls.CopyTo(&Ld)
+    TriL(&Ld)
+    mult(L)
+    A2 := A.Copy()
+    A2.Minus(L)
+    return NormP(A2, NORM_ONE)
+}
+
+// Fail if r exceeds 10*N*eps*scale.
+func checkNorm(t *testing.T, name string, r float64, N int, scale float64) {
+    eps := math.Pow(2.0, -52.0)
+    if r > 10.0*float64(N)*eps*scale {
+        t.Errorf("%s: %e exceeds %e\n", name, r, 10.0*float64(N)*eps*scale)
+    }
+}
+
+func TestDecomposeLQ(t *testing.T) {
+    M := 40
+    N := 60
+    for _, nb := range []int{0, 8} {
+        A := matrix.FloatUniform(M, N)
+        W := matrix.FloatZeros(M, 8)
+        tau := matrix.FloatZeros(M, 1)
+
+        LQ, err := DecomposeLQ(A.Copy(), tau, W, nb)
+        if err != nil {
+            t.Errorf("nb=%d decompose error: %v\n", nb, err)
+            continue
+        }
+        nrm := lqResidual(A, LQ, func(C *matrix.FloatMatrix) {
+            Wr := matrix.FloatZeros(C.Rows(), 8)
+            MultLQ(C, LQ, tau, Wr, RIGHT, nb)
+        })
+        checkNorm(t, fmt.Sprintf("nb=%d ||A - L*Q||_1", nb), nrm, N, NormP(A, NORM_ONE))
+    }
+}
+
+func TestDecomposeLQT(t *testing.T) {
+    M := 40
+    N := 60
+    for _, nb := range []int{0, 12} {
+        A := matrix.FloatUniform(M, N)
+        W := matrix.FloatZeros(M, M)
+        T := matrix.FloatZeros(M, M)
+
+        LQ, err := DecomposeLQT(A.Copy(), T, W, nb)
+        if err != nil {
+            t.Errorf("nb=%d decompose error: %v\n", nb, err)
+            continue
+        }
+        nrm := lqResidual(A, LQ, func(C *matrix.FloatMatrix) {
+            MultLQT(C, LQ, T, W, RIGHT, nb)
+        })
+        checkNorm(t, fmt.Sprintf("nb=%d ||A - L*Q||_1", nb), nrm, N, NormP(A, NORM_ONE))
+
+        // T matches block reflector built from reflectors
+        tau := matrix.FloatZeros(M, 1)
+        T.Diag().CopyTo(tau)
+        T2 := matrix.FloatZeros(M, M)
+        BuildTLQ(T2, LQ, tau)
+        T2.Minus(T)
+        checkNorm(t, fmt.Sprintf("nb=%d ||T - BuildTLQ(A, tau)||_1", nb),
+            NormP(T2, NORM_ONE), M, NormP(T, NORM_ONE))
+    }
+}
+
+func TestMultLQ(t *testing.T) {
+    M := 40
+    N := 60
+    K := 30
+    nb := 8
+    A := matrix.FloatUniform(M, N)
+    W := matrix.FloatZeros(N, nb)
+    tau := matrix.FloatZeros(M, 1)
+    LQ, _ := DecomposeLQ(A, tau, W, nb)
+
+    for _, flags := range []Flags{LEFT, LEFT|TRANS} {
+        C := matrix.FloatUniform(N, K)
+        C0 := C.Copy()
+        C1 := C.Copy()
+        MultLQ(C, LQ, tau, W, flags, 0)
+        MultLQ(C1, LQ, tau, W, flags, nb)
+        C1.Minus(C)
+        // apply inverse
+        MultLQ(C, LQ, tau, W, flags ^ TRANS, nb)
+        C.Minus(C0)
+        checkNorm(t, fmt.Sprintf("flags=%v ||unblk - blk||_1", flags),
+            NormP(C1, NORM_ONE), N, NormP(C0, NORM_ONE))
+        checkNorm(t, fmt.Sprintf("flags=%v ||C - Q.T*Q*C||_1", flags),
+            NormP(C, NORM_ONE), N, NormP(C0, NORM_ONE))
+    }
+    for _, flags := range []Flags{RIGHT, RIGHT|TRANS} {
+        C := matrix.FloatUniform(K, N)
+        C0 := C.Copy()
+        C1 := C.Copy()
+        MultLQ(C, LQ, tau, W, flags, 0)
+        MultLQ(C1, LQ, tau, W, flags, nb)
+        C1.Minus(C)
+        MultLQ(C, LQ, tau, W, flags ^ TRANS, nb)
+        C.Minus(C0)
+        checkNorm(t, fmt.Sprintf("flags=%v ||unblk - blk||_1", flags),
+            NormP(C1, NORM_ONE), N, NormP(C0, NORM_ONE))
+        checkNorm(t, fmt.Sprintf("flags=%v ||C - C*Q*Q.T||_1", flags),
+            NormP(C, NORM_ONE), N, NormP(C0, NORM_ONE))
+    }
+}
+
+func TestBuildLQ(t *testing.T) {
+    M := 40
+    N := 60
+    nb := 8
+    A := matrix.FloatUniform(M, N)
+    W := matrix.FloatZeros(M, M)
+    tau := matrix.FloatZeros(M, 1)
+    T := matrix.FloatZeros(M, M)
+
+    LQ, _ := DecomposeLQ(A.Copy(), tau, W, nb)
+    LQT, _ := DecomposeLQT(A.Copy(), T, W, nb)
+    for _, nbq := range []int{0, nb} {
+        Q, err := BuildLQ(LQ.Copy(), tau, W, nbq)
+        if err != nil {
+            t.Errorf("nb=%d build error: %v\n", nbq, err)
+            continue
+        }
+        QT, err := BuildLQT(LQT.Copy(), T, W, nbq)
+        if err != nil {
+            t.Errorf("nb=%d build error: %v\n", nbq, err)
+            continue
+        }
+
+        // ||I - Q*Q.T||_1
+        I := matrix.FloatDiagonal(M, 1.0)
+        Mult(I, Q, Q, -1.0, 1.0, TRANSB)
+        // ||Q - Q(T)||_1
+        QT.Minus(Q)
+        checkNorm(t, fmt.Sprintf("nb=%d ||I - Q*Q.T||_1", nbq), NormP(I, NORM_ONE), N, 1.0)
+        checkNorm(t, fmt.Sprintf("nb=%d ||Q - Q(T)||_1", nbq), NormP(QT, NORM_ONE), N, 1.0)
+    }
+}
+
+func TestSolveLQ(t *testing.T) {
+    var Xm matrix.FloatMatrix
+    M := 40
+    N := 60
+    K := 5
+    nb := 8
+    A := matrix.FloatUniform(M, N)
+    B0 := matrix.FloatUniform(M, K)
+    W := matrix.FloatZeros(K, nb)
+    Wd := matrix.FloatZeros(M, nb)
+
+    // minimum norm solution of A*X = B
+    tau := matrix.FloatZeros(M, 1)
+    LQ, _ := DecomposeLQ(A.Copy(), tau, Wd, nb)
+    X := matrix.FloatZeros(N, K)
+    X.SubMatrix(&Xm, 0, 0, M, K)
+    B0.CopyTo(&Xm)
+    err := SolveLQ(X, LQ, tau, W, NOTRANS, nb)
+    if err != nil {
+        t.Errorf("solve error: %v\n", err)
+    }
+    nA := NormP(A, NORM_ONE)
+    B := B0.Copy()
+    Mult(B, A, X, -1.0, 1.0, NOTRANS)
+    checkNorm(t, "||B - A*X||_1", NormP(B, NORM_ONE), N,
+        nA*NormP(X, NORM_ONE) + NormP(B0, NORM_ONE))
+
+    // same with QR factorization of A.T
+    At := A.Transpose()
+    tauq := matrix.FloatZeros(M, 1)
+    QR, _ := DecomposeQR(At, tauq, Wd, nb)
+    X2 := matrix.FloatZeros(N, K)
+    X2.SubMatrix(&Xm, 0, 0, M, K)
+    B0.CopyTo(&Xm)
+    SolveQR(X2, QR, tauq, W, TRANS, nb)
+    X2.Minus(X)
+    // both are backward stable; difference is bounded by cond(A)
+    S := matrix.FloatZeros(M, 1)
+    DecomposeSVD(A.Copy(), S, nil, nil, nil, 0, 0)
+    cond := S.GetAt(0, 0) / S.GetAt(M-1, 0)
+    checkNorm(t, "||X(LQ) - X(QR)||_1", NormP(X2, NORM_ONE), N, cond*NormP(X, NORM_ONE))
+
+    // least squares solution of A.T*X = B
+    T := matrix.FloatZeros(M, M)
+    LQT, _ := DecomposeLQT(A.Copy(), T, Wd, nb)
+    B1 := matrix.FloatUniform(N, K)
+    nB1 := NormP(B1, NORM_ONE)
+    X = B1.Copy()
+    err = SolveLQT(X, LQT, T, W, TRANS, nb)
+    if err != nil {
+        t.Errorf("solve error: %v\n", err)
+    }
+    // residual orthogonal to range of A.T: ||A*(B - A.T*X)||_1
+    X.SubMatrix(&Xm, 0, 0, M, K)
+    Mult(B1, A, &Xm, -1.0, 1.0, TRANSA)
+    R := matrix.FloatZeros(M, K)
+    Mult(R, A, B1, 1.0, 0.0, NOTRANS)
+    checkNorm(t, "||A*(B - A.T*X)||_1", NormP(R, NORM_ONE), N,
+        nA*(nB1 + nA*NormP(&Xm, NORM_ONE)))
+}
+
+// Local Variables:
+// tab-width: 4
+// indent-tabs-mode: nil
+// End:
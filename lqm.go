@@ -0,0 +1,675 @@
+
+// Copyright (c) Harri Rautila, 2013
+
+// This file is part of github.com/hrautila/matops package. It is free software,
+// distributed under the terms of GNU Lesser General Public License Version 3, or
+// any later version. See the COPYING tile included in this archive.
+
+package matops
+
+import (
+    "github.com/hrautila/matrix"
+    //"fmt"
+)
+
+/*
+ * Unblocked algorith for computing C = Q*C and C = Q.T*C.
+ *
+ * Q = H(k)...H(2)H(1) where elementary reflectors H(i) are stored on i'th row
+ * right of diagonal in A.
+ *
+ * Progressing A from top-left to bottom-right i.e from smaller row numbers
+ * to larger, produces H(k)...H(2)H(1) == Q and C = Q*C
+ *
+ * Progressing from bottom-right to top-left produces H(1)H(2)...H(k) == Q.T and C = Q.T*C
+ */
+func unblockedMultLQLeft(C, A, tau, w *matrix.FloatMatrix, flags Flags) {
+    var ATL, ATR, ABL, ABR matrix.FloatMatrix
+    var A00, a11, a12, A22 matrix.FloatMatrix
+    var CT, CB, C0, c1t, C2 matrix.FloatMatrix
+    var tT, tB matrix.FloatMatrix
+    var t0, tau1, t2  matrix.FloatMatrix
+
+    var Aref *matrix.FloatMatrix
+    var pAdir, pAstart, pDir, pStart pDirection
+    var nb int
+
+    // partitioning start and direction
+    if flags & TRANS != 0 {
+        // from bottom-right to top-left to produce transposed sequence (Q.T*C)
+        pAstart = pBOTTOMRIGHT
+        pAdir   = pTOPLEFT
+        pStart  = pBOTTOM
+        pDir    = pTOP
+        nb      = A.Cols() - A.Rows()
+        Aref    = &ATL
+    } else {
+        // from top-left to bottom-right to produce normal sequence (Q*C)
+        pAstart = pTOPLEFT
+        pAdir   = pBOTTOMRIGHT
+        pStart  = pTOP
+        pDir    = pBOTTOM
+        nb      = 0
+        Aref    = &ABR
+    }
+
+    partition2x2(
+        &ATL, &ATR,
+        &ABL, &ABR,  A, 0, nb, pAstart)
+    partition2x1(
+        &CT,
+        &CB,    C, nb, pStart)
+    partition2x1(
+        &tT,
+        &tB,    tau, 0, pStart)
+
+    for Aref.Rows() > 0 && Aref.Cols() > 0 {
+        repartition2x2to3x3(&ATL,
+            &A00, nil,  nil,
+            nil,  &a11, &a12,
+            nil,  nil,  &A22,   A, 1, pAdir)
+        repartition2x1to3x1(&CT,
+            &C0,
+            &c1t,
+            &C2,     C,   1, pDir)
+        repartition2x1to3x1(&tT,
+            &t0,
+            &tau1,
+            &t2,     tau, 1, pDir)
+
+        // --------------------------------------------------------
+
+        applyHHTo2x1(&tau1, &a12, &c1t, &C2, w, LEFT)
+
+        // --------------------------------------------------------
+        continue3x3to2x2(
+            &ATL, &ATR,
+            &ABL, &ABR,   &A00, &a11, &A22,   A, pAdir)
+        continue3x1to2x1(
+            &CT,
+            &CB,    &C0, &c1t,    C, pDir)
+        continue3x1to2x1(
+            &tT,
+            &tB,    &t0, &tau1,   tau, pDir)
+    }
+}
+
+/*
+ * Unblocked algorith for computing C = C*Q and C = C*Q.T.
+ *
+ * Q = H(k)...H(2)H(1) where elementary reflectors H(i) are stored on i'th row
+ * right of diagonal in A.
+ *
+ * Progressing A from top-left to bottom-right i.e from smaller row numbers
+ * to larger, produces C*H(1)H(2)...H(k) == C*Q.T.
+ *
+ * Progressing from bottom-right to top-left produces C*H(k)...H(2)H(1) == C*Q.
+ */
+func unblockedMultLQRight(C, A, tau, w *matrix.FloatMatrix, flags Flags) {
+    var ATL, ATR, ABL, ABR matrix.FloatMatrix
+    var A00, a11, a12, A22 matrix.FloatMatrix
+    var CL, CR, C0, c1, C2 matrix.FloatMatrix
+    var tT, tB matrix.FloatMatrix
+    var t0, tau1, t2  matrix.FloatMatrix
+
+    var Aref *matrix.FloatMatrix
+    var pAdir, pAstart, pDir, pStart, pCstart, pCdir pDirection
+    var nb int
+
+    // partitioning start and direction
+    if flags & TRANS != 0 {
+        // from top-left to bottom-right to produce transpose sequence (C*Q.T)
+        pAstart = pTOPLEFT
+        pAdir   = pBOTTOMRIGHT
+        pStart  = pTOP
+        pDir    = pBOTTOM
+        pCstart = pLEFT
+        pCdir   = pRIGHT
+        nb      = 0
+        Aref    = &ABR
+    } else {
+        // from bottom-right to top-left to produce normal sequence (C*Q)
+        pAstart = pBOTTOMRIGHT
+        pAdir   = pTOPLEFT
+        pStart  = pBOTTOM
+        pDir    = pTOP
+        pCstart = pRIGHT
+        pCdir   = pLEFT
+        nb      = A.Cols() - A.Rows()
+        Aref    = &ATL
+    }
+
+    partition2x2(
+        &ATL, &ATR,
+        &ABL, &ABR,  A, 0, nb, pAstart)
+    partition1x2(
+        &CL, &CR,    C, nb, pCstart)
+    partition2x1(
+        &tT,
+        &tB,  tau, 0, pStart)
+
+    for Aref.Rows() > 0 && Aref.Cols() > 0 {
+        repartition2x2to3x3(&ATL,
+            &A00, nil,  nil,
+            nil,  &a11, &a12,
+            nil,  nil,  &A22,   A, 1, pAdir)
+        repartition1x2to1x3(&CL,
+            &C0, &c1, &C2,      C, 1, pCdir)
+        repartition2x1to3x1(&tT,
+            &t0,
+            &tau1,
+            &t2,     tau, 1, pDir)
+
+        // --------------------------------------------------------
+
+        applyHHTo2x1(&tau1, &a12, &c1, &C2, w, RIGHT)
+
+        // --------------------------------------------------------
+        continue3x3to2x2(
+            &ATL, &ATR,
+            &ABL, &ABR,   &A00, &a11, &A22,   A, pAdir)
+        continue1x3to1x2(
+            &CL, &CR,     &C0, &c1,           C, pCdir)
+        continue3x1to2x1(
+            &tT,
+            &tB,          &t0, &tau1,         tau, pDir)
+    }
+}
+
+/*
+ * Blocked version for computing C = Q*C and C = Q.T*C from elementary reflectors
+ * and scalar coefficients or from block reflector T.
+ *
+ * If T is nil then elementary reflectors and scalar coefficients are used to build
+ * block reflector for current block. Matrix C is updated by applying block reflector
+ * using compact WY algorithm.
+ */
+func blockedMultLQLeft(C, A, tau, T, W *matrix.FloatMatrix, nb int, flags Flags) {
+    var ATL, ATR, ABL, ABR, AT matrix.FloatMatrix
+    var A00, A11, A12, A22 matrix.FloatMatrix
+    var CT, CB, C0, C1, C2 matrix.FloatMatrix
+    var TTL, TTR, TBL, TBR matrix.FloatMatrix
+    var T00, T01, T02, T11, T12, T22 matrix.FloatMatrix
+    var tT, tB matrix.FloatMatrix
+    var t0, tau1, t2  matrix.FloatMatrix
+    var Wrk, Tw matrix.FloatMatrix
+    var Twork *matrix.FloatMatrix
+
+    var Aref *matrix.FloatMatrix
+    var pAdir, pAstart, pDir, pStart pDirection
+    var bsz, cb int
+
+    // partitioning start and direction
+    if flags & TRANS != 0 {
+        // from bottom-right to top-left to produce transposed sequence (Q.T*C)
+        pAstart = pBOTTOMRIGHT
+        pAdir   = pTOPLEFT
+        pStart  = pBOTTOM
+        pDir    = pTOP
+        cb      = A.Cols() - A.Rows()
+        Aref    = &ATL
+    } else {
+        // from top-left to bottom-right to produce normal sequence (Q*C)
+        pAstart = pTOPLEFT
+        pAdir   = pBOTTOMRIGHT
+        pStart  = pTOP
+        pDir    = pBOTTOM
+        cb      = 0
+        Aref    = &ABR
+    }
+
+    if T == nil {
+        Twork = matrix.FloatZeros(nb, nb)
+    } else {
+        partition2x2(
+            &TTL, &TTR,
+            &TBL, &TBR,   T, 0, 0, pAstart)
+    }
+    partition2x2(
+        &ATL, &ATR,
+        &ABL, &ABR, A, 0, cb, pAstart)
+    partition2x1(
+        &CT,
+        &CB,    C, cb, pStart)
+    partition2x1(
+        &tT,
+        &tB,    tau, 0, pStart)
+
+    // Q = P.T where P = H(1)H(2)...H(k) = I - Y.T*T*Y
+    transpose := flags & TRANS == 0
+
+    for Aref.Rows() > 0 && Aref.Cols() > 0 {
+        bsz = nb
+        if pAdir == pBOTTOMRIGHT {
+            bsz = imin(nb, ABR.Rows())
+        }
+        repartition2x2to3x3(&ATL,
+            &A00, nil,  nil,
+            nil,  &A11, &A12,
+            nil,  nil,  &A22,   A, bsz, pAdir)
+        bsz = A11.Cols()
+        repartition2x1to3x1(&tT,
+            &t0,
+            &tau1,
+            &t2,     tau, bsz, pDir)
+        repartition2x1to3x1(&CT,
+            &C0,
+            &C1,
+            &C2,     C, bsz, pDir)
+        if T != nil {
+            repartition2x2to3x3(&TTL,
+                &T00, &T01, &T02,
+                nil,  &T11, &T12,
+                nil,  nil,  &T22,   T, bsz, pAdir)
+        }
+
+        // --------------------------------------------------------
+
+        if T == nil {
+            // build block reflector from current block
+            merge1x2(&AT, &A11, &A12)
+            Twork.SubMatrix(&Tw, 0, 0, bsz, bsz)
+            unblkLQBlockReflector(&Tw, &AT, &tau1)
+        } else {
+            T11.SubMatrix(&Tw, 0, 0, bsz, bsz)
+        }
+
+        // compute: Q.T*C == C - Y.T*(C.T*Y.T*T.T).T  transpose == false
+        //          Q*C   == C - Y.T*(C.T*Y.T*T).T    transpose == true
+        W.SubMatrix(&Wrk, 0, 0, C1.Cols(), bsz)
+        updateWithLQTLeft(&C1, &C2, &A11, &A12, &Tw, &Wrk, transpose)
+
+        // --------------------------------------------------------
+        continue3x3to2x2(
+            &ATL, &ATR,
+            &ABL, &ABR,   &A00, &A11, &A22,   A, pAdir)
+        continue3x1to2x1(
+            &CT,
+            &CB,     &C0, &C1,     C, pDir)
+        continue3x1to2x1(
+            &tT,
+            &tB,     &t0, &tau1,   tau, pDir)
+        if T != nil {
+            continue3x3to2x2(
+                &TTL, &TTR,
+                &TBL, &TBR,   &T00, &T11, &T22,   T, pAdir)
+        }
+    }
+}
+
+/*
+ * Blocked version for computing C = C*Q and C = C*Q.T from elementary reflectors
+ * and scalar coefficients or from block reflector T.
+ */
+func blockedMultLQRight(C, A, tau, T, W *matrix.FloatMatrix, nb int, flags Flags) {
+    var ATL, ATR, ABL, ABR, AT matrix.FloatMatrix
+    var A00, A11, A12, A22 matrix.FloatMatrix
+    var CL, CR, C0, C1, C2 matrix.FloatMatrix
+    var TTL, TTR, TBL, TBR matrix.FloatMatrix
+    var T00, T01, T02, T11, T12, T22 matrix.FloatMatrix
+    var tT, tB matrix.FloatMatrix
+    var t0, tau1, t2  matrix.FloatMatrix
+    var Wrk, Tw matrix.FloatMatrix
+    var Twork *matrix.FloatMatrix
+
+    var Aref *matrix.FloatMatrix
+    var pAdir, pAstart, pDir, pStart, pCstart, pCdir pDirection
+    var bsz, cb int
+
+    // partitioning start and direction
+    if flags & TRANS != 0 {
+        // from top-left to bottom-right to produce transpose sequence (C*Q.T)
+        pAstart = pTOPLEFT
+        pAdir   = pBOTTOMRIGHT
+        pStart  = pTOP
+        pDir    = pBOTTOM
+        pCstart = pLEFT
+        pCdir   = pRIGHT
+        cb      = 0
+        Aref    = &ABR
+    } else {
+        // from bottom-right to top-left to produce normal sequence (C*Q)
+        pAstart = pBOTTOMRIGHT
+        pAdir   = pTOPLEFT
+        pStart  = pBOTTOM
+        pDir    = pTOP
+        pCstart = pRIGHT
+        pCdir   = pLEFT
+        cb      = A.Cols() - A.Rows()
+        Aref    = &ATL
+    }
+
+    if T == nil {
+        Twork = matrix.FloatZeros(nb, nb)
+    } else {
+        partition2x2(
+            &TTL, &TTR,
+            &TBL, &TBR,   T, 0, 0, pAstart)
+    }
+    partition2x2(
+        &ATL, &ATR,
+        &ABL, &ABR, A, 0, cb, pAstart)
+    partition1x2(
+        &CL, &CR,   C, cb, pCstart)
+    partition2x1(
+        &tT,
+        &tB,        tau, 0, pStart)
+
+    // Q = P.T where P = H(1)H(2)...H(k) = I - Y.T*T*Y
+    transpose := flags & TRANS == 0
+
+    for Aref.Rows() > 0 && Aref.Cols() > 0 {
+        bsz = nb
+        if pAdir == pBOTTOMRIGHT {
+            bsz = imin(nb, ABR.Rows())
+        }
+        repartition2x2to3x3(&ATL,
+            &A00, nil,  nil,
+            nil,  &A11, &A12,
+            nil,  nil,  &A22,   A, bsz, pAdir)
+        bsz = A11.Cols()
+        repartition2x1to3x1(&tT,
+            &t0,
+            &tau1,
+            &t2,     tau, bsz, pDir)
+        repartition1x2to1x3(&CL,
+            &C0, &C1, &C2,      C, bsz, pCdir)
+        if T != nil {
+            repartition2x2to3x3(&TTL,
+                &T00, &T01, &T02,
+                nil,  &T11, &T12,
+                nil,  nil,  &T22,   T, bsz, pAdir)
+        }
+
+        // --------------------------------------------------------
+
+        if T == nil {
+            // build block reflector from current block
+            merge1x2(&AT, &A11, &A12)
+            Twork.SubMatrix(&Tw, 0, 0, bsz, bsz)
+            unblkLQBlockReflector(&Tw, &AT, &tau1)
+        } else {
+            T11.SubMatrix(&Tw, 0, 0, bsz, bsz)
+        }
+
+        // compute: C*Q.T == C - C*Y.T*T*Y    transpose == false
+        //          C*Q   == C - C*Y.T*T.T*Y  transpose == true
+        W.SubMatrix(&Wrk, 0, 0, C1.Rows(), bsz)
+        updateWithLQTRight(&C1, &C2, &A11, &A12, &Tw, &Wrk, transpose)
+
+        // --------------------------------------------------------
+        continue3x3to2x2(
+            &ATL, &ATR,
+            &ABL, &ABR,   &A00, &A11, &A22,   A, pAdir)
+        continue1x3to1x2(
+            &CL,   &CR,   &C0, &C1,    C, pCdir)
+        continue3x1to2x1(
+            &tT,
+            &tB,   &t0, &tau1,   tau, pDir)
+        if T != nil {
+            continue3x3to2x2(
+                &TTL, &TTR,
+                &TBL, &TBR,   &T00, &T11, &T22,   T, pAdir)
+        }
+    }
+}
+
+/*
+ * Multiply and replace C with Q*C or Q.T*C where Q is a real orthogonal matrix
+ * defined as the product of k elementary reflectors.
+ *
+ *    Q = H(k) . . . H(2) H(1)
+ *
+ * as returned by DecomposeLQ().
+ *
+ * Arguments:
+ *  C     On entry, the M-by-N matrix C. On exit C is overwritten by Q*C or Q.T*C.
+ *
+ *  A     LQ factorization as returned by DecomposeLQ() where the upper trapezoidal
+ *        part holds the elementary reflectors. Matrix A is K-by-P, K <= P.
+ *
+ *  tau   The scalar factors of the elementary reflectors.
+ *
+ *  W     Workspace, used for blocked invocations. Size C.Cols()-by-nb if
+ *        multiplying from left, otherwise C.Rows()-by-nb.
+ *
+ *  nb    Blocksize for blocked invocations. If nb is zero unblocked algorithm
+ *        is used.
+ *
+ *  flags Indicators. Valid indicators LEFT, RIGHT, TRANS, NOTRANS
+ *
+ * Compatible with lapack.DORMLQ
+ */
+func MultLQ(C, A, tau, W *matrix.FloatMatrix, flags Flags, nb int) error {
+    if A.Rows() > A.Cols() {
+        return sizeError("MultLQ", A)
+    }
+    if flags & RIGHT != 0 {
+        // from right; C*Q or C*Q.T
+        if C.Cols() != A.Cols() {
+            return sizeError("MultLQ", C, A)
+        }
+        if nb != 0 && (W == nil || W.Cols() < nb || W.Rows() < C.Rows()) {
+            return sizeError("MultLQ", C, A, workDims(W))
+        }
+    } else {
+        // default is from LEFT; Q*C or Q.T*C
+        if C.Rows() != A.Cols() {
+            return sizeError("MultLQ", C, A)
+        }
+        if nb != 0 && (W == nil || W.Cols() < nb || W.Rows() < C.Cols()) {
+            return sizeError("MultLQ", C, A, workDims(W))
+        }
+    }
+    if nb == 0 {
+        if flags & RIGHT != 0 {
+            w := matrix.FloatZeros(C.Rows(), 1)
+            unblockedMultLQRight(C, A, tau, w, flags)
+        } else {
+            w := matrix.FloatZeros(1, C.Cols())
+            unblockedMultLQLeft(C, A, tau, w, flags)
+        }
+    } else {
+        if flags & RIGHT != 0 {
+            blockedMultLQRight(C, A, tau, nil, W, nb, flags)
+        } else {
+            blockedMultLQLeft(C, A, tau, nil, W, nb, flags)
+        }
+    }
+    return nil
+}
+
+/*
+ * Multiply and replace C with Q*C or Q.T*C where Q is a real orthogonal matrix
+ * defined as the product of k elementary reflectors and block reflector T
+ *
+ *    Q = H(k) . . . H(2) H(1)
+ *
+ * as returned by DecomposeLQT().
+ *
+ * Arguments:
+ *  C     On entry, the M-by-N matrix C. On exit C is overwritten by Q*C or Q.T*C.
+ *
+ *  A     LQ factorization as returned by DecomposeLQT() where the upper trapezoidal
+ *        part holds the elementary reflectors. Matrix A is K-by-P, K <= P.
+ *
+ *  T     The block reflector computed from elementary reflectors as returned by
+ *        DecomposeLQT() or computed from elementary reflectors and scalar coefficients
+ *        by BuildTLQ()
+ *
+ *  W     Workspace, size C.Cols()-by-nb or C.Rows()-by-nb
+ *
+ *  nb    Blocksize for blocked invocations. If nb == 0 default value T.Cols()
+ *        is used.
+ *
+ *  flags Indicators. Valid indicators LEFT, RIGHT, TRANS, NOTRANS
+ */
+func MultLQT(C, A, T, W *matrix.FloatMatrix, flags Flags, nb int) error {
+    if nb == 0 {
+        nb = T.Cols()
+    }
+    if A.Rows() > A.Cols() {
+        return sizeError("MultLQT", A)
+    }
+    if T.Rows() < A.Rows() || T.Cols() < A.Rows() {
+        return sizeError("MultLQT", A, T)
+    }
+    if W == nil {
+        return sizeError("MultLQT", C, A, workDims(W))
+    }
+    tau := matrix.FloatZeros(A.Rows(), 1)
+    if flags & RIGHT != 0 {
+        if C.Cols() != A.Cols() {
+            return sizeError("MultLQT", C, A)
+        }
+        if W.Cols() < nb || W.Rows() < C.Rows() {
+            return sizeError("MultLQT", C, A, W)
+        }
+        blockedMultLQRight(C, A, tau, T, W, nb, flags)
+    } else {
+        if C.Rows() != A.Cols() {
+            return sizeError("MultLQT", C, A)
+        }
+        if W.Cols() < nb || W.Rows() < C.Cols() {
+            return sizeError("MultLQT", C, A, W)
+        }
+        blockedMultLQLeft(C, A, tau, T, W, nb, flags)
+    }
+    return nil
+}
+
+/*
+ * Solve a system of linear equations A*X = B with general M-by-N
+ * matrix A, M <= N, using the LQ factorization computed by DecomposeLQ().
+ *
+ * If flags&TRANS != 0:
+ *   find the least squares solution of an overdetermined system, i.e.,
+ *   solve the least squares problem: min || B - A.T*X ||.
+ *
+ * Otherwise:
+ *   find the minimum norm solution of an underdetermined system A * X = B.
+ *   i.e min ||X|| s.t A*X = B
+ *
+ * Arguments:
+ *  B    On entry, the right hand side N-by-P matrix B. On exit, the solution matrix X.
+ *
+ *  A    The elements on and below the diagonal contain the M-by-M lower
+ *       triangular matrix L. The elements above the diagonal with the vector 'tau',
+ *       represent the ortogonal matrix Q as product of elementary reflectors.
+ *       Matrix A and tau are as returned by DecomposeLQ()
+ *
+ *  tau  The vector of M scalar coefficients that together with triuu(A) define
+ *       the ortogonal matrix Q as Q = H(M)...H(2)H(1)
+ *
+ *  W    Workspace, P-by-nb matrix used for work space in blocked invocations.
+ *
+ *  flags Indicator flag
+ *
+ *  nb   The block size used in blocked invocations. If nb is zero
+ *       unblocked algorithm is used.
+ *
+ * Compatible with lapack.GELS (the m < n part)
+ */
+func SolveLQ(B, A, tau, W *matrix.FloatMatrix, flags Flags, nb int) error {
+    var err error = nil
+    var L, BT, BB matrix.FloatMatrix
+
+    if B.Rows() != A.Cols() {
+        return sizeError("SolveLQ", B, A)
+    }
+    A.SubMatrix(&L, 0, 0, A.Rows(), A.Rows())
+    B.SubMatrix(&BT, 0, 0, A.Rows(), B.Cols())
+    if flags & TRANS != 0 {
+        // solve least square problem min ||A.T*X - B||
+
+        // B' = Q*B
+        err = MultLQ(B, A, tau, W, LEFT, nb)
+        if err != nil {
+            return err
+        }
+        // X = L.-T*B'
+        err = SolveTrm(&BT, &L, 1.0, LEFT|LOWER|TRANSA)
+    } else {
+        // Solve underdetermined system A*X = B
+
+        // B' = L.-1*B
+        err = SolveTrm(&BT, &L, 1.0, LEFT|LOWER)
+
+        // Clear bottom part of B
+        B.SubMatrix(&BB, A.Rows(), 0, B.Rows()-A.Rows(), B.Cols())
+        BB.SetIndexes(0.0)
+
+        // X = Q.T*B'
+        err = MultLQ(B, A, tau, W, LEFT|TRANS, nb)
+    }
+    return err
+}
+
+/*
+ * Solve a system of linear equations A*X = B with general M-by-N
+ * matrix A, M <= N, using the LQ factorization computed by DecomposeLQT().
+ *
+ * If flags&TRANS != 0:
+ *   find the least squares solution of an overdetermined system, i.e.,
+ *   solve the least squares problem: min || B - A.T*X ||.
+ *
+ * Otherwise:
+ *   find the minimum norm solution of an underdetermined system A * X = B.
+ *   i.e min ||X|| s.t A*X = B
+ *
+ * Arguments:
+ *  B     On entry, the right hand side N-by-P matrix B. On exit, the solution matrix X.
+ *
+ *  A     The elements on and below the diagonal contain the M-by-M lower
+ *        triangular matrix L. The elements above the diagonal with the matrix 'T',
+ *        represent the ortogonal matrix Q as product of elementary reflectors.
+ *        Matrix A and T are as returned by DecomposeLQT()
+ *
+ *  T     The M-by-M block reflector.
+ *
+ *  W     Workspace, P-by-nb matrix used for work space in blocked invocations.
+ *
+ *  flags Indicator flag
+ *
+ *  nb    The block size used in blocked invocations. If nb is zero default
+ *        value M is used.
+ */
+func SolveLQT(B, A, T, W *matrix.FloatMatrix, flags Flags, nb int) error {
+    var err error = nil
+    var L, BT, BB matrix.FloatMatrix
+
+    if B.Rows() != A.Cols() {
+        return sizeError("SolveLQT", B, A)
+    }
+    A.SubMatrix(&L, 0, 0, A.Rows(), A.Rows())
+    B.SubMatrix(&BT, 0, 0, A.Rows(), B.Cols())
+    if flags & TRANS != 0 {
+        // solve least square problem min ||A.T*X - B||
+
+        // B' = Q*B
+        err = MultLQT(B, A, T, W, LEFT, nb)
+        if err != nil {
+            return err
+        }
+        // X = L.-T*B'
+        err = SolveTrm(&BT, &L, 1.0, LEFT|LOWER|TRANSA)
+    } else {
+        // Solve underdetermined system A*X = B
+
+        // B' = L.-1*B
+        err = SolveTrm(&BT, &L, 1.0, LEFT|LOWER)
+
+        // Clear bottom part of B
+        B.SubMatrix(&BB, A.Rows(), 0, B.Rows()-A.Rows(), B.Cols())
+        BB.SetIndexes(0.0)
+
+        // X = Q.T*B'
+        err = MultLQT(B, A, T, W, LEFT|TRANS, nb)
+    }
+    return err
+}
+
+// Local Variables:
+// tab-width: 4
+// indent-tabs-mode: nil
+// End:
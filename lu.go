@@ -9,7 +9,7 @@ package matops
 
 import (
     "github.com/hrautila/matrix"
-    "errors"
+    "context"
     //"math"
     //"fmt"
 )
@@ -21,6 +21,13 @@ func imin(a, b int) int {
     return b
 }
 
+func imax(a, b int) int {
+    if a > b {
+        return a
+    }
+    return b
+}
+
 func m(A *matrix.FloatMatrix) int {
     return A.Rows()
 }
@@ -33,6 +40,8 @@ var decompNB int = 0
 
 // Set global decomposition block size for blocked versions.
 func DecomposeBlockSize(nb int) {
+    globalMu.Lock()
+    defer globalMu.Unlock()
     decompNB = nb
 }
 
@@ -100,139 +109,218 @@ func blockedLUnoPiv(A *matrix.FloatMatrix, nb int) (err error) {
 
 
 // unblocked LU decomposition with pivots: FLAME LU variant 3
-func unblockedLUpiv(A *matrix.FloatMatrix, p *pPivots) error {
-    var err error
-    var ATL, ATR, ABL, ABR matrix.FloatMatrix
-    var A00, a01, A02, a10, a11, a12, A20, a21, A22 matrix.FloatMatrix
-    var AL, AR, A0, a1, A2, aB1, AB0 matrix.FloatMatrix
+func unblockedLUpiv[T any, M partitionable[T]](ops factorOps[M], A M, p *pPivots) error {
+    var ATL, ATR, ABL, ABR M
+    var A00, a01, A02, a10, a11, a12, A20, a21, A22 M
+    var AL, AR, A0, a1, A2, aB1, AB0 M
     var pT, pB, p0, p1, p2 pPivots
 
-    err = nil
+    newParts[T](&ATL, &ATR, &ABL, &ABR)
+    newParts[T](&A00, &a01, &A02, &a10, &a11, &a12, &A20, &a21, &A22)
+    newParts[T](&AL, &AR, &A0, &a1, &A2, &aB1, &AB0)
     partition2x2(
-        &ATL, &ATR,
-        &ABL, &ABR, A, 0, 0, pTOPLEFT)
+        ATL, ATR,
+        ABL, ABR, A, 0, 0, pTOPLEFT)
     partition1x2(
-        &AL, &AR, A, 0, pLEFT)
+        AL, AR, A, 0, pLEFT)
     partitionPivot2x1(
         &pT,
         &pB, p, 0, pTOP)
 
     for ATL.Rows() < A.Rows() && ATL.Cols() < A.Cols() {
-        repartition2x2to3x3(&ATL, 
-            &A00, &a01, &A02,
-            &a10, &a11, &a12,
-            &A20, &a21, &A22,   /**/ A, 1, pBOTTOMRIGHT)
-        repartition1x2to1x3(&AL, 
-            &A0, &a1, &A2,   /**/ A, 1, pRIGHT)
+        repartition2x2to3x3(ATL, 
+            A00, a01, A02,
+            a10, a11, a12,
+            A20, a21, A22,   /**/ A, 1, pBOTTOMRIGHT)
+        repartition1x2to1x3(AL, 
+            A0, a1, A2,   /**/ A, 1, pRIGHT)
         repartPivot2x1to3x1(&pT, 
             &p0, &p1, &p2,   /**/ p, 1, pBOTTOM)
 
         // apply previously computed pivots
-        applyPivots(&a1, &p0)
+        ops.applyPivots(a1, &p0)
 
         // a01 = trilu(A00) \ a01 (TRSV)
-        MVSolveTrm(&a01, &A00, 1.0, LOWER|UNIT)
+        ops.mvSolveTrm(a01, A00, LOWER|UNIT)
         // a11 = a11 - a10 *a01 
-        a11.Add(Dot(&a10, &a01, -1.0))
+        ops.set(a11, ops.get(a11) + ops.dot(a10, a01, -1.0))
         // a21 = a21 -A20*a01
-        MVMult(&a21, &A20, &a01, -1.0, 1.0, NOTRANS)
+        ops.mvMult(a21, A20, a01, -1.0, 1.0, NOTRANS)
 
         // pivot index on current column [a11, a21].T
-        aB1.SubMatrixOf(&ABR, 0, 0, ABR.Rows(), 1)
-        pivotIndex(&aB1, &p1)
+        ABR.SubMatrix(aB1, 0, 0, ABR.Rows(), 1)
+        ops.pivotIndex(aB1, &p1)
 
         // pivots to current column
-        applyPivots(&aB1, &p1)
+        ops.applyPivots(aB1, &p1)
         
         // a21 = a21 / a11
-        InvScale(&a21, a11.Float())
+        ops.invScale(a21, ops.get(a11))
 
         // apply pivots to previous columns
-        AB0.SubMatrixOf(&ABL, 0, 0)
-        applyPivots(&AB0, &p1)
+        ABL.SubMatrix(AB0, 0, 0)
+        ops.applyPivots(AB0, &p1)
         // scale last pivots to origin matrix row numbers
         p1.pivots[0] += ATL.Rows()
 
         continue3x3to2x2(
-            &ATL, &ATR,
-            &ABL, &ABR,   &A00, &a11, &A22,   A, pBOTTOMRIGHT)
+            ATL, ATR,
+            ABL, ABR,   A00, a11, A22,   A, pBOTTOMRIGHT)
         continue1x3to1x2(
-            &AL, &AR,     &A0, &a1,   A, pRIGHT)
+            AL, AR,     A0, a1,   A, pRIGHT)
         contPivot3x1to2x1(
             &pT,
             &pB,    &p0, &p1,    p, pBOTTOM)
     }
     if ATL.Cols() < A.Cols() {
-        applyPivots(&ATR, p)
-        SolveTrm(&ATR, &ATL, 1.0, LEFT|UNIT|LOWER)
+        ops.applyPivots(ATR, p)
+        ops.solveTrm(ATR, ATL, 1.0, LEFT|UNIT|LOWER)
     }
-    return err
+    return nil
 }
 
 // blocked LU decomposition with pivots: FLAME LU variant 3
-func blockedLUpiv(A *matrix.FloatMatrix, p *pPivots, nb int) error {
-    var err error
-    var ATL, ATR, ABL, ABR matrix.FloatMatrix
-    var A00, A01, A02, A10, A11, A12, A20, A21, A22 matrix.FloatMatrix
-    var AL, AR, A0, A1, A2, AB1, AB0 matrix.FloatMatrix
+func blockedLUpiv[T any, M partitionable[T]](ops factorOps[M], A M, p *pPivots, nb int) error {
+    var ATL, ATR, ABL, ABR M
+    var A00, A01, A02, A10, A11, A12, A20, A21, A22 M
+    var AL, AR, A0, A1, A2, AB1, AB0 M
     var pT, pB, p0, p1, p2 pPivots
 
-    err = nil
+    newParts[T](&ATL, &ATR, &ABL, &ABR)
+    newParts[T](&A00, &A01, &A02, &A10, &A11, &A12, &A20, &A21, &A22)
+    newParts[T](&AL, &AR, &A0, &A1, &A2, &AB1, &AB0)
     partition2x2(
-        &ATL, &ATR,
-        &ABL, &ABR,    A, 0, 0, pTOPLEFT)
+        ATL, ATR,
+        ABL, ABR,    A, 0, 0, pTOPLEFT)
     partition1x2(
-        &AL, &AR,      A, 0, pLEFT)
+        AL, AR,      A, 0, pLEFT)
     partitionPivot2x1(
         &pT,
         &pB,     p, 0, pTOP)
 
     for ATL.Rows() < A.Rows() && ATL.Cols() < A.Cols() {
-        repartition2x2to3x3(&ATL,
-            &A00, &A01, &A02,
-            &A10, &A11, &A12,
-            &A20, &A21, &A22, A, nb, pBOTTOMRIGHT)
-        repartition1x2to1x3(&AL,
-            &A0, &A1, &A2,  /**/ A, nb, pRIGHT)
+        repartition2x2to3x3(ATL,
+            A00, A01, A02,
+            A10, A11, A12,
+            A20, A21, A22, A, nb, pBOTTOMRIGHT)
+        repartition1x2to1x3(AL,
+            A0, A1, A2,  /**/ A, nb, pRIGHT)
         repartPivot2x1to3x1(&pT,
             &p0, &p1, &p2,  /**/ p, nb, pBOTTOM)
 
         // apply previously computed pivots
-        applyPivots(&A1, &p0)
+        ops.applyPivots(A1, &p0)
 
         // a01 = trilu(A00) \ a01 (TRSV)
-        SolveTrm(&A01, &A00, 1.0, LOWER|UNIT)
+        ops.solveTrm(A01, A00, 1.0, LEFT|LOWER|UNIT)
         // A11 = A11 - A10*A01
-        Mult(&A11, &A10, &A01, -1.0, 1.0, NOTRANS)
+        ops.mult(A11, A10, A01, -1.0, 1.0, NOTRANS)
         // A21 = A21 - A20*A01
-        Mult(&A21, &A20, &A01, -1.0, 1.0, NOTRANS)
+        ops.mult(A21, A20, A01, -1.0, 1.0, NOTRANS)
 
         // LU_piv(AB1, p1)
-        AB1.SubMatrixOf(&ABR, 0, 0, ABR.Rows(), A11.Cols())
-        unblockedLUpiv(&AB1, &p1)
+        ABR.SubMatrix(AB1, 0, 0, ABR.Rows(), A11.Cols())
+        unblockedLUpiv(ops, AB1, &p1)
 
         // apply pivots to previous columns
-        AB0.SubMatrixOf(&ABL, 0, 0)
-        applyPivots(&AB0, &p1)
+        ABL.SubMatrix(AB0, 0, 0)
+        ops.applyPivots(AB0, &p1)
         // scale last pivots to origin matrix row numbers
         for k, _ := range p1.pivots {
             p1.pivots[k] += ATL.Rows()
         }
 
         continue3x3to2x2(
-            &ATL, &ATR,
-            &ABL, &ABR, /**/ &A00, &A11, &A22, A, pBOTTOMRIGHT)
+            ATL, ATR,
+            ABL, ABR, /**/ A00, A11, A22, A, pBOTTOMRIGHT)
         continue1x3to1x2(
-            &AL, &AR, /**/ &A0, &A1, A, pRIGHT)
+            AL, AR, /**/ A0, A1, A, pRIGHT)
         contPivot3x1to2x1(
             &pT,
             &pB, /**/ &p0, &p1, p, pBOTTOM)
     }
     if ATL.Cols() < A.Cols() {
-        applyPivots(&ATR, p)
-        SolveTrm(&ATR, &ATL, 1.0, LEFT|UNIT|LOWER)
+        ops.applyPivots(ATR, p)
+        ops.solveTrm(ATR, ATL, 1.0, LEFT|UNIT|LOWER)
     }
-    return err
+    return nil
+}
+
+// parallel blocked LU decomposition with pivots, right-looking variant.
+//
+// Task graph: panel k factors column block k and depends on the update of that
+// block with panel k-1. Update (k,j) pivots column block j, solves its rows in
+// block row k and updates the rest; depends on panel k and update (k-1,j). Pivots
+// of the later panels are applied to the left columns when all panels are done.
+func parallelLUpiv(ctx context.Context, conf *Config, A *matrix.FloatMatrix, p *pPivots, nb int) error {
+    M, N := A.Size()
+    mlen := imin(M, N)
+    npanel := (mlen + nb - 1)/nb
+    nblock := (N + nb - 1)/nb
+    // unscaled pivots of panels, relative to the first row of panel
+    rel := make([][]int, npanel)
+    last := make([]*graphTask, nblock)
+    bg := context.Background()
+    sconf := conf.serial()
+    g := newTaskGraph()
+
+    for k := 0; k < npanel; k++ {
+        k0 := k*nb
+        kb := imin(nb, mlen-k0)
+        rel[k] = make([]int, kb)
+        rk := rel[k]
+        pk := p.pivots[k0:k0+kb]
+        panel := func() error {
+            var AB1 matrix.FloatMatrix
+            AB1.SubMatrixOf(A, k0, k0, M-k0, kb)
+            err := unblockedLUpiv(float64Ops{}, &AB1, &pPivots{pk})
+            copy(rk, pk)
+            for i, _ := range pk {
+                pk[i] += k0
+            }
+            return err
+        }
+        last[k] = g.add(k*npanel+k, panel, last[k])
+
+        for j := k+1; j < nblock; j++ {
+            j0 := j*nb
+            jb := imin(nb, N-j0)
+            update := func() error {
+                var AB, A11, A12, A21, A22 matrix.FloatMatrix
+                AB.SubMatrixOf(A, k0, j0, M-k0, jb)
+                applyPivots(&AB, &pPivots{rk})
+                A.SubMatrix(&A11, k0, k0, kb, kb)
+                A.SubMatrix(&A12, k0, j0, kb, jb)
+                // A12 = trilu(A11).-1*A12
+                solveTrmConf(bg, sconf, &A12, &A11, 1.0, LEFT|LOWER|UNIT)
+                if M > k0+kb {
+                    A.SubMatrix(&A21, k0+kb, k0, M-k0-kb, kb)
+                    A.SubMatrix(&A22, k0+kb, j0, M-k0-kb, jb)
+                    // A22 = A22 - A21*A12
+                    multConf(bg, sconf, &A22, &A21, &A12, -1.0, 1.0, NOTRANS)
+                }
+                return nil
+            }
+            last[j] = g.add(j*npanel+k, update, last[k], last[j])
+        }
+    }
+    ex := conf.Executor
+    if err := g.run(ctx, ex); err != nil {
+        return err
+    }
+    // apply pivots of panels to columns left of them
+    leftPivots := func(S, E int) {
+        var AB matrix.FloatMatrix
+        for k := 1; k < npanel; k++ {
+            k0 := k*nb
+            if k0 > S {
+                AB.SubMatrixOf(A, k0, S, M-k0, imin(E, k0)-S)
+                applyPivots(&AB, &pPivots{rel[k]})
+            }
+        }
+    }
+    ranges := splitRange(ctx, 0, (npanel-1)*nb, ex.Workers(), 4*conf.NB)
+    return scheduleList(ctx, ex, ranges, leftPivots)
 }
 
 /*
@@ -250,24 +338,82 @@ func blockedLUpiv(A *matrix.FloatMatrix, p *pPivots, nb int) error {
  *          min(M,N) < nb unblocked algorithm is used.
  *
  * Returns:
- *  LU factorization and error indicator.
+ *  LU factorization and error indicator. If factor U has exact zero on diagonal
+ *  factorization is completed and *SingularError returned with its index.
  *
  * Compatible with lapack.DGETRF
  */
 func DecomposeLU(A *matrix.FloatMatrix, pivots []int, nb int) (*matrix.FloatMatrix, error) {
+    return DecomposeLUContext(context.Background(), nil, A, pivots, nb)
+}
+
+// LU factorization with blocked invocation run as task graph with the workers
+// of executor ex. If ex is nil the default executor set with NumWorkers() is used.
+// Factorization is aborted and ctx.Err() returned when ctx is cancelled; A is then
+// partially factored. See DecomposeLU().
+func DecomposeLUContext(ctx context.Context, ex Executor, A *matrix.FloatMatrix,
+    pivots []int, nb int) (*matrix.FloatMatrix, error) {
+
     var err error
     mlen := imin(A.Rows(), A.Cols())
     if len(pivots) < mlen {
-        return A, errors.New("pivot array < min(A.Rows(),A.Cols())")
+        return A, sizeError("DecomposeLU", A, shape{len(pivots), 1})
     }
     // clear pivot array
     for k, _ := range pivots {
         pivots[k] = 0
     }
+    ex = executorOrDefault(ex)
+    if mlen <= nb || nb == 0 {
+        if err = ctx.Err(); err != nil {
+            return A, err
+        }
+        err = unblockedLUpiv(float64Ops{}, A, &pPivots{pivots})
+    } else if ex.Workers() > 1 || ctx.Done() != nil {
+        err = parallelLUpiv(ctx, globalConfig(ex), A, &pPivots{pivots}, nb)
+    } else {
+        err = blockedLUpiv(float64Ops{}, A, &pPivots{pivots}, nb)
+    }
+    if err == nil {
+        err = singularLU(A)
+    }
+    return A, err
+}
+
+// SingularError if factor U of A = P*L*U has zero on diagonal.
+func singularLU(A *matrix.FloatMatrix) error {
+    if k := zeroPivot(A); k >= 0 {
+        return &SingularError{k}
+    }
+    return nil
+}
+
+// LU factorization with block size conf.DecompNB and parameters from conf.
+// Blocked invocation is always run as task graph. See Config and DecomposeLUContext().
+func DecomposeLUConfig(ctx context.Context, conf *Config, A *matrix.FloatMatrix,
+    pivots []int) (*matrix.FloatMatrix, error) {
+
+    c, release := conf.resolve()
+    defer release()
+    mlen := imin(A.Rows(), A.Cols())
+    if len(pivots) < mlen {
+        return A, c.sizeError("DecomposeLU", A, shape{len(pivots), 1})
+    }
+    for k, _ := range pivots {
+        pivots[k] = 0
+    }
+    if err := ctx.Err(); err != nil {
+        return A, err
+    }
+    var err error
+    nb := c.DecompNB
     if mlen <= nb || nb == 0 {
-        err = unblockedLUpiv(A, &pPivots{pivots})
+        err = unblockedLUpiv(float64Ops{}, A, &pPivots{pivots})
     } else {
-        err = blockedLUpiv(A, &pPivots{pivots}, nb)
+        err = parallelLUpiv(ctx, c, A, &pPivots{pivots}, nb)
+    }
+    if err == nil {
+        err = singularLU(A)
     }
     return A, err
 }
@@ -284,7 +430,8 @@ func DecomposeLU(A *matrix.FloatMatrix, pivots []int, nb int) (*matrix.FloatMatr
  *       min(M,N) < nb unblocked algorithm is used.
  *
  * Returns:
- *  LU factorization and error indicator.
+ *  LU factorization and error indicator. If zero pivot is found error is
+ *  *BreakdownError with its index.
  *
  * Compatible with lapack.DGETRF
  */
@@ -296,6 +443,9 @@ func DecomposeLUnoPiv(A *matrix.FloatMatrix, nb int) (*matrix.FloatMatrix, error
     } else {
         err = blockedLUnoPiv(A, nb)
     }
+    if k := zeroPivot(A); err == nil && k >= 0 {
+        err = &BreakdownError{"DecomposeLUnoPiv", k}
+    }
     return A, err
 }
 
@@ -315,21 +465,38 @@ func DecomposeLUnoPiv(A *matrix.FloatMatrix, nb int) (*matrix.FloatMatrix, error
  *         If flags&TRANSA then system is transposed. All other values
  *         indicate non transposed system.
  *
+ * Returns:
+ *  Error if sizes of A, B and pivots do not match. If U has zero on diagonal
+ *  error is *SingularError with its index and B is not changed.
+ *
  * Compatible with lapack.DGETRS.
  */
 func SolveLU(B, A *matrix.FloatMatrix, pivots []int, flags Flags) error {
     var err error = nil
-    applyPivots(B, &pPivots{pivots})
+    if A.Rows() != A.Cols() || B.Rows() != A.Rows() || len(pivots) < A.Rows() {
+        return sizeError("SolveLU", B, A, shape{len(pivots), 1})
+    }
+    if err = singularLU(A); err != nil {
+        return err
+    }
     if flags&TRANSA != 0 {
-        // transposed X = A.-1*B == (L.T*U.T).-1*B == U.-T*(L.-T*B)
-        SolveTrm(B, A, 1.0, LOWER|UNIT|TRANSA)
-        SolveTrm(B, A, 1.0, UPPER|TRANSA)
+        // transposed X = A.-T*B == (P*L*U).-T*B == P*L.-T*(U.-T*B)
+        if err = SolveTrm(B, A, 1.0, UPPER|TRANSA); err == nil {
+            err = SolveTrm(B, A, 1.0, LOWER|UNIT|TRANSA)
+        }
+        for k := len(pivots)-1; k >= 0; k-- {
+            if pivots[k] > 0 {
+                swapRows(B, pivots[k], k)
+            }
+        }
     } else {
-        // non-transposed X = A.-1*B == (L*U).-1*B == U.-1*(L.-1*B)
-        SolveTrm(B, A, 1.0, LOWER|UNIT)
-        SolveTrm(B, A, 1.0, UPPER)
+        // non-transposed X = A.-1*B == (L*U).-1*P.T*B == U.-1*(L.-1*P.T*B)
+        applyPivots(B, &pPivots{pivots})
+        if err = SolveTrm(B, A, 1.0, LOWER|UNIT); err == nil {
+            err = SolveTrm(B, A, 1.0, UPPER)
+        }
     }
-        
+
     return err
 }
 
@@ -33,6 +33,35 @@ func TestLU(t *testing.T) {
 
     nrm := NormP(B, NORM_ONE)
 	t.Logf("||B - A*X||_1: %e\n", nrm)
+    if nrm > 1e-8 {
+        t.Errorf("||B - A*X||_1 too large\n")
+    }
+}
+
+func TestLUTrans(t *testing.T) {
+	N := 60
+	K := 30
+	nb := 12
+	A := matrix.FloatUniform(N, N)
+	B := matrix.FloatUniform(N, K)
+	X := B.Copy()
+	piv := make([]int, N, N)
+
+	// R = lu(A) = P*L*U
+	R, _ := DecomposeLU(A.Copy(), piv, nb)
+
+	// X = A.-T*B
+	SolveLU(X, R, piv, TRANSA)
+
+	// B = B - A.T*X
+	Mult(B, A, X, -1.0, 1.0, TRANSA)
+
+    nrm := NormP(B, NORM_ONE)
+	t.Logf("||B - A.T*X||_1: %e\n", nrm)
+    if nrm > 1e-8 {
+        // transposed solve must apply the row pivots after the triangular solves
+        t.Errorf("||B - A.T*X||_1 too large\n")
+    }
 }
 
 // Local Variables:
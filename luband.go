@@ -0,0 +1,203 @@
+
+// Copyright (c) Harri Rautila, 2013
+
+// This file is part of github.com/hrautila/matops package. It is free software,
+// distributed under the terms of GNU Lesser General Public License Version 3, or
+// any later version. See the COPYING tile included in this archive.
+
+package matops
+
+import (
+    "github.com/hrautila/matrix"
+    "math"
+)
+
+// Unblocked band LU factorization with partial pivoting. (LAPACK/dgbtf2.f)
+func unblockedLUBand(A *matrix.FloatMatrix, pivots []int, KL int) error {
+    var err error = nil
+    N := A.Cols()
+    KV := A.Rows() - KL - 1
+    KU := KV - KL
+    Ar := A.FloatArray()
+    ldA := A.LeadingIndex()
+
+    // zero the fill-in elements in columns KU+1:KV-1
+    for j := KU+1; j < imin(KV, N); j++ {
+        for i := KV-j; i < KL; i++ {
+            Ar[i+j*ldA] = 0.0
+        }
+    }
+    ju := 0
+    for j := 0; j < N; j++ {
+        // zero the fill-in elements in column j+KV
+        if j+KV < N {
+            for i := 0; i < KL; i++ {
+                Ar[i+(j+KV)*ldA] = 0.0
+            }
+        }
+        km := imin(KL, N-1-j)
+        jp := 0
+        amax := math.Abs(Ar[KV+j*ldA])
+        for i := 1; i <= km; i++ {
+            if math.Abs(Ar[KV+i+j*ldA]) > amax {
+                amax = math.Abs(Ar[KV+i+j*ldA])
+                jp = i
+            }
+        }
+        pivots[j] = j + jp
+        if Ar[KV+jp+j*ldA] == 0.0 {
+            // singular; record first zero pivot and continue
+            if err == nil {
+                err = &SingularError{j}
+            }
+            continue
+        }
+        ju = imax(ju, imin(j+KU+jp, N-1))
+        if jp != 0 {
+            // interchange rows j and j+jp in columns j:ju
+            for c := 0; c <= ju-j; c++ {
+                k0 := KV-c+(j+c)*ldA
+                Ar[k0+jp], Ar[k0] = Ar[k0], Ar[k0+jp]
+            }
+        }
+        if km > 0 {
+            r11 := 1.0/Ar[KV+j*ldA]
+            for i := 1; i <= km; i++ {
+                Ar[KV+i+j*ldA] *= r11
+            }
+            // rank update of the trailing submatrix within the band
+            for c := 0; c < ju-j; c++ {
+                yc := Ar[KV-1-c+(j+1+c)*ldA]
+                if yc == 0.0 {
+                    continue
+                }
+                for r := 0; r < km; r++ {
+                    Ar[KV+r-c+(j+1+c)*ldA] -= Ar[KV+1+r+j*ldA] * yc
+                }
+            }
+        }
+    }
+    return err
+}
+
+/*
+ * Compute an LU factorization of a N-by-N band matrix with KL subdiagonals using
+ * partial pivoting with row interchanges.
+ *
+ * Arguments:
+ *   A      On entry, the band matrix in general-band storage with KL extra rows
+ *          on top of the band as returned by BandedLUMatrix(). Number of superdiagonals
+ *          is A.Rows()-2*KL-1. On exit the factor U as an upper triangular band matrix
+ *          with KL+KU superdiagonals in rows 0:KL+KU and the multipliers of L
+ *          in rows KL+KU+1:2*KL+KU.
+ *
+ *   pivots On exit the pivot indices; row i was interchanged with row pivots[i].
+ *
+ *   KL     Number of subdiagonals.
+ *
+ * Returns:
+ *  LU factorization and error indicator. Error is returned if exactly zero pivot
+ *  was found.
+ *
+ * Compatible with lapack.DGBTRF
+ */
+func DecomposeLUBand(A *matrix.FloatMatrix, pivots []int, KL int) (*matrix.FloatMatrix, error) {
+    if KL < 0 || A.Rows() < 2*KL+1 {
+        return A, sizeError("DecomposeLUBand", A, shape{2*KL+1, A.Cols()})
+    }
+    if len(pivots) < A.Cols() {
+        return A, sizeError("DecomposeLUBand", A, shape{len(pivots), 1})
+    }
+    err := unblockedLUBand(A, pivots, KL)
+    return A, err
+}
+
+/*
+ * Solve a system of linear equations A*X = B or A.T*X = B with N-by-N band
+ * matrix A using the LU factorization computed by DecomposeLUBand().
+ *
+ * Arguments:
+ *  B      On entry, the right hand side matrix B. On exit, the solution matrix X.
+ *
+ *  A      The factor L and U from the factorization A = P*L*U as computed by
+ *         DecomposeLUBand()
+ *
+ *  pivots The pivot indices from DecomposeLUBand().
+ *
+ *  KL     Number of subdiagonals.
+ *
+ *  flags  The indicator of the form of the system of equations.
+ *         If flags&TRANSA then system is transposed. All other values
+ *         indicate non transposed system.
+ *
+ * Compatible with lapack.DGBTRS.
+ */
+func SolveLUBand(B, A *matrix.FloatMatrix, pivots []int, KL int, flags Flags) error {
+    var U, b matrix.FloatMatrix
+    N := A.Cols()
+    KV := A.Rows() - KL - 1
+    if KL < 0 || KV < KL {
+        return sizeError("SolveLUBand", A, shape{2*KL+1, N})
+    }
+    if B.Rows() != N {
+        return sizeError("SolveLUBand", B, A)
+    }
+    if len(pivots) < N {
+        return sizeError("SolveLUBand", A, shape{len(pivots), 1})
+    }
+    Ar := A.FloatArray()
+    ldA := A.LeadingIndex()
+    Br := B.FloatArray()
+    ldB := B.LeadingIndex()
+    // U is upper triangular band matrix with KV superdiagonals
+    A.SubMatrix(&U, 0, 0, KV+1, N)
+
+    if flags & TRANSA == 0 {
+        // solve L*X = B, overwriting B with X
+        for j := 0; j < N-1 && KL > 0; j++ {
+            lm := imin(KL, N-1-j)
+            l := pivots[j]
+            for k := 0; k < B.Cols(); k++ {
+                if l != j {
+                    Br[l+k*ldB], Br[j+k*ldB] = Br[j+k*ldB], Br[l+k*ldB]
+                }
+                bj := Br[j+k*ldB]
+                for r := 0; r < lm; r++ {
+                    Br[j+1+r+k*ldB] -= Ar[KV+1+r+j*ldA] * bj
+                }
+            }
+        }
+        // solve U*X = B, overwriting B with X
+        for k := 0; k < B.Cols(); k++ {
+            B.SubMatrix(&b, 0, k, N, 1)
+            MVSolveTrmBand(&b, &U, 1.0, UPPER)
+        }
+        return nil
+    }
+    // solve U.T*X = B, overwriting B with X
+    for k := 0; k < B.Cols(); k++ {
+        B.SubMatrix(&b, 0, k, N, 1)
+        MVSolveTrmBand(&b, &U, 1.0, UPPER|TRANSA)
+    }
+    // solve L.T*X = B, overwriting B with X
+    for j := N-2; j >= 0 && KL > 0; j-- {
+        lm := imin(KL, N-1-j)
+        l := pivots[j]
+        for k := 0; k < B.Cols(); k++ {
+            bj := Br[j+k*ldB]
+            for r := 0; r < lm; r++ {
+                bj -= Ar[KV+1+r+j*ldA] * Br[j+1+r+k*ldB]
+            }
+            Br[j+k*ldB] = bj
+            if l != j {
+                Br[l+k*ldB], Br[j+k*ldB] = Br[j+k*ldB], Br[l+k*ldB]
+            }
+        }
+    }
+    return nil
+}
+
+// Local Variables:
+// tab-width: 4
+// indent-tabs-mode: nil
+// End:
@@ -0,0 +1,232 @@
+// Copyright (c) Harri Rautila, 2013
+
+// This file is part of github.com/hrautila/matops package. It is free software,
+// distributed under the terms of GNU Lesser General Public License Version 3, or
+// any later version. See the COPYING tile included in this archive.
+
+package matops
+
+import (
+    "github.com/hrautila/matrix"
+    "math"
+)
+
+// Maximum number of refinement steps in SolveMixed.
+const mixedITMAX = 30
+
+// Single precision copy of A; returns false if any element overflows single precision.
+func float32Copy(A *matrix.FloatMatrix) (*Float32Matrix, bool) {
+    B := Float32Zeros(A.Rows(), A.Cols())
+    for j := 0; j < A.Cols(); j++ {
+        for i := 0; i < A.Rows(); i++ {
+            a := A.GetAt(i, j)
+            if math.Abs(a) > math.MaxFloat32 {
+                return nil, false
+            }
+            B.SetAt(i, j, float32(a))
+        }
+    }
+    return B, true
+}
+
+// Infinity norm of general matrix or of symmetric matrix stored in lower or upper
+// triangular part if flags&(LOWER|UPPER).
+func mixedNormInf(A *matrix.FloatMatrix, flags Flags) float64 {
+    if flags & (LOWER|UPPER) == 0 {
+        return mNormInf(A)
+    }
+    N := A.Rows()
+    amax := 0.0
+    for i := 0; i < N; i++ {
+        s := 0.0
+        for j := 0; j < N; j++ {
+            if (flags & LOWER != 0 && i < j) || (flags & UPPER != 0 && i > j) {
+                s += math.Abs(A.GetAt(j, i))
+            } else {
+                s += math.Abs(A.GetAt(i, j))
+            }
+        }
+        amax = math.Max(amax, s)
+    }
+    return amax
+}
+
+// Largest ||r||_inf/||x||_inf for columns r of R and x of X.
+func mixedRatio(R, X *matrix.FloatMatrix) float64 {
+    var r, x matrix.FloatMatrix
+    ratio := 0.0
+    for j := 0; j < X.Cols(); j++ {
+        R.SubMatrix(&r, 0, j, R.Rows(), 1)
+        X.SubMatrix(&x, 0, j, X.Rows(), 1)
+        rn, xn := math.Abs(AMax(&r)), math.Abs(AMax(&x))
+        switch {
+        case rn == 0.0:
+        case xn == 0.0:
+            return math.Inf(1)
+        default:
+            ratio = math.Max(ratio, rn/xn)
+        }
+    }
+    return ratio
+}
+
+// True if upper triangular factor U has zero on diagonal.
+func sSingular(U *Float32Matrix) bool {
+    for k := 0; k < imin(U.Rows(), U.Cols()); k++ {
+        if U.GetAt(k, k) == 0.0 {
+            return true
+        }
+    }
+    return false
+}
+
+// X = op(A).-1*X with single precision Cholesky factor of A if flags&(LOWER|UPPER)
+// or with LU factors and pivots of A otherwise. See SolveCHOLChecked() and SolveLU().
+func sSolveFactors(X, A *Float32Matrix, pivots []int, flags Flags) {
+    switch {
+    case flags & UPPER != 0:
+        SSolveTrm(X, A, 1.0, UPPER|TRANSA)
+        SSolveTrm(X, A, 1.0, UPPER)
+    case flags & LOWER != 0:
+        SSolveTrm(X, A, 1.0, LOWER)
+        SSolveTrm(X, A, 1.0, LOWER|TRANSA)
+    case flags & TRANSA != 0:
+        SSolveTrm(X, A, 1.0, UPPER|TRANSA)
+        SSolveTrm(X, A, 1.0, LOWER|UNIT|TRANSA)
+        for k := len(pivots)-1; k >= 0; k-- {
+            if pivots[k] > 0 {
+                sSwapRows(X, pivots[k], k)
+            }
+        }
+    default:
+        sApplyPivots(X, &pPivots{pivots})
+        SSolveTrm(X, A, 1.0, LOWER|UNIT)
+        SSolveTrm(X, A, 1.0, UPPER)
+    }
+}
+
+/*
+ * Solves a system of linear equations A*X = B or A.T*X = B using mixed precision
+ * iterative refinement. A is factored in single precision and the solution is
+ * refined in double precision with residuals R = B - op(A)*X until it is accurate
+ * to double precision. If single precision factorization fails or refinement does
+ * not converge A is factored and the system solved in double precision.
+ *
+ * Refinement stops after 30 steps or when a step does not halve the largest
+ * relative residual ||r||_inf/||x||_inf of the columns of X.
+ *
+ * Arguments:
+ *  B     The right hand side matrix B. Not changed.
+ *
+ *  A     The N-by-N matrix A. If flags&(LOWER|UPPER) A is symmetric positive
+ *        definite and only its lower or upper triangular part is used. Not changed.
+ *
+ *  X     On exit, the solution matrix X.
+ *
+ *  flags Indicator bits, LOWER or UPPER for symmetric positive definite A,
+ *        TRANSA for solving A.T*X = B with general A.
+ *
+ *  nb    Block size of single and double precision factorizations. If nb is
+ *        zero or N < nb unblocked algorithms are used.
+ *
+ * Returns:
+ *  Number of refinement steps if refinement converged or negative value if
+ *  double precision factorization was used: -2 if A or B overflows single
+ *  precision, -3 if single precision factorization failed and -31 if refinement
+ *  did not converge. Error is returned if double precision factorization or
+ *  computing a residual fails.
+ *
+ * Compatible with lapack.DSGESV and lapack.DSPOSV
+ */
+func SolveMixed(B, A, X *matrix.FloatMatrix, flags Flags, nb int) (int, error) {
+    N := A.Rows()
+    if A.Cols() != N {
+        return 0, sizeError("SolveMixed", A)
+    }
+    if B.Rows() != N || X.Rows() != N || X.Cols() != B.Cols() {
+        return 0, sizeError("SolveMixed", B, A, X)
+    }
+    if N == 0 || B.Cols() == 0 {
+        return 0, nil
+    }
+    symmetric := flags & (LOWER|UPPER) != 0
+    if symmetric {
+        flags &= LOWER|UPPER
+    } else {
+        flags &= TRANSA
+    }
+    // R = B - op(A)*X
+    residual := func(R *matrix.FloatMatrix) error {
+        B.CopyTo(R)
+        if symmetric {
+            return MultSym(R, A, X, -1.0, 1.0, flags|LEFT)
+        }
+        return Mult(R, A, X, -1.0, 1.0, flags)
+    }
+    eps := math.Pow(2.0, -53.0)
+    cte := mixedNormInf(A, flags)*eps*math.Sqrt(float64(N))
+    pivots := make([]int, N)
+
+    iter := -2
+    As, okA := float32Copy(A)
+    Xs, okB := float32Copy(B)
+    if okA && okB {
+        var err error
+        if symmetric {
+            _, err = SDecomposeCHOL(As, flags, nb)
+        } else {
+            _, err = SDecomposeLU(As, pivots, nb)
+        }
+        iter = -3
+        if err == nil && (symmetric || ! sSingular(As)) {
+            iter = -(mixedITMAX+1)
+            sSolveFactors(Xs, As, pivots, flags)
+            Xs.ToFloat().CopyTo(X)
+            R := matrix.FloatZeros(N, B.Cols())
+            if err = residual(R); err != nil {
+                return 0, err
+            }
+            prev := math.Inf(1)
+            for k := 0; k <= mixedITMAX; k++ {
+                ratio := mixedRatio(R, X)
+                if ratio <= cte {
+                    return k, nil
+                }
+                // stagnated if last step did not halve the residual
+                if k == mixedITMAX || ! (ratio <= 0.5*prev) {
+                    break
+                }
+                prev = ratio
+                Rs, ok := float32Copy(R)
+                if ! ok {
+                    break
+                }
+                // X = X + op(A).-1*R
+                sSolveFactors(Rs, As, pivots, flags)
+                ScalePlus(X, Rs.ToFloat(), 1.0, 1.0, NOTRANS)
+                if err = residual(R); err != nil {
+                    return 0, err
+                }
+            }
+        }
+    }
+
+    // double precision factorization and solve
+    F := A.Copy()
+    B.CopyTo(X)
+    if symmetric {
+        if _, err := DecomposeCHOL(F, flags, nb); err != nil {
+            return iter, err
+        }
+        return iter, SolveCHOLChecked(X, F, flags)
+    }
+    if _, err := DecomposeLU(F, pivots, nb); err != nil {
+        return iter, err
+    }
+    return iter, SolveLU(X, F, pivots, flags)
+}
+
+// Local Variables:
+// tab-width: 4
+// indent-tabs-mode: nil
+// End:
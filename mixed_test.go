@@ -0,0 +1,98 @@
+
+// Copyright (c) Harri Rautila, 2013
+
+// This file is part of github.com/hrautila/matops package. It is free software,
+// distributed under the terms of GNU Lesser General Public License Version 3, or
+// any later version. See the COPYING tile included in this archive.
+
+package matops
+
+import (
+    "github.com/hrautila/matrix"
+    "testing"
+)
+
+// ||B - op(A)*X||_1 / (||A||_1*||X||_1)
+func mixedResidual(B, A, X *matrix.FloatMatrix, flags Flags) float64 {
+    R := B.Copy()
+    if flags & (LOWER|UPPER) != 0 {
+        MultSym(R, A, X, -1.0, 1.0, flags|LEFT)
+    } else {
+        Mult(R, A, X, -1.0, 1.0, flags)
+    }
+    return NormP(R, NORM_ONE)/(NormP(A, NORM_ONE)*NormP(X, NORM_ONE))
+}
+
+func TestSolveMixed(t *testing.T) {
+    N, K := 80, 5
+    A := matrix.FloatNormal(N, N)
+    for k := 0; k < N; k++ {
+        A.SetAt(k, k, A.GetAt(k, k) + 10.0)
+    }
+    S := spdNormal(N)
+    for _, nb := range []int{0, 16} {
+        for _, flags := range []Flags{NOTRANS, TRANSA, LOWER, UPPER} {
+            B := matrix.FloatNormal(N, K)
+            X := matrix.FloatZeros(N, K)
+            A0 := A
+            if flags & (LOWER|UPPER) != 0 {
+                A0 = S
+            }
+            iter, err := SolveMixed(B, A0, X, flags, nb)
+            if err != nil {
+                t.Fatalf("SolveMixed: %v\n", err)
+            }
+            r := mixedResidual(B, A0, X, flags)
+            t.Logf("nb %d flags %x: iter %d, ||B - op(A)*X||_1/(||A||_1*||X||_1): %e\n",
+                nb, flags, iter, r)
+            if iter < 0 {
+                t.Errorf("SolveMixed nb %d flags %x: refinement did not converge", nb, flags)
+            }
+            if r > 1e-15 {
+                t.Errorf("SolveMixed nb %d flags %x: residual too large", nb, flags)
+            }
+        }
+    }
+}
+
+func TestSolveMixedFallback(t *testing.T) {
+    N := 12
+    // Hilbert matrix is too ill-conditioned for single precision
+    H := matrix.FloatZeros(N, N)
+    for j := 0; j < N; j++ {
+        for i := 0; i < N; i++ {
+            H.SetAt(i, j, 1.0/float64(i + j + 1))
+        }
+    }
+    B := matrix.FloatNormal(N, 2)
+    X := matrix.FloatZeros(N, 2)
+    iter, err := SolveMixed(B, H, X, NOTRANS, 0)
+    t.Logf("Hilbert: iter %d, err %v, residual %e\n", iter, err, mixedResidual(B, H, X, NOTRANS))
+    if iter >= 0 {
+        t.Errorf("SolveMixed: expected fallback to double precision")
+    }
+    if r := mixedResidual(B, H, X, NOTRANS); r > 1e-15 {
+        t.Errorf("SolveMixed: residual too large after fallback")
+    }
+
+    // single precision overflow
+    A := matrix.FloatNormal(N, N)
+    A.SetAt(3, 3, 1e40)
+    if iter, _ := SolveMixed(B, A, X, NOTRANS, 0); iter != -2 {
+        t.Errorf("SolveMixed: iter %d, expected -2 on overflow", iter)
+    }
+
+    // singular matrix
+    A = matrix.FloatNormal(N, N)
+    for k := 0; k < N; k++ {
+        A.SetAt(k, 4, 0.0)
+    }
+    if _, err := SolveMixed(B, A, X, NOTRANS, 0); err == nil {
+        t.Errorf("SolveMixed: singular matrix not detected")
+    }
+}
+
+// Local Variables:
+// tab-width: 4
+// indent-tabs-mode: nil
+// End:
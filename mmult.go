@@ -10,6 +10,7 @@ package matops
 import (
     "github.com/hrautila/matrix"
     "github.com/hrautila/matops/calgo"
+    "context"
     "errors"
     "math"
     //"fmt"
@@ -30,29 +31,28 @@ const (
 )
     
 // blocking parameter size for DOT based algorithms
-var vpLen int = 196
-var nB int = 68
-var mB int = 68
-
-// Number of parallel workers to use.
-var nWorker int = 1
+var vpLen int = defaultKB
+var nB int = defaultNB
+var mB int = defaultMB
 
 // problems small than this do not benefit from parallelism
-var limitOne int64 = 200*200*200
+var limitOne int64 = defaultLimitOne
+
+// matrix-vector problems smaller than this do not benefit from parallelism
+var limitMV int64 = 500*500
 
 // flag indicating what to do on error
 var panicOnError bool = false
 
 func onError(msg string) error {
-    if panicOnError {
-        panic(msg)
-    }
-    return errors.New(msg)
+    return raiseError(errors.New(msg))
 }
 
 // Set panic-on-error flag to newval. If set to true errors cause call
 // to panic(). If set to false errors are propagated to caller.
 func SetPanicOnError(newval bool) {
+    globalMu.Lock()
+    defer globalMu.Unlock()
     panicOnError = newval
 }
 
@@ -61,17 +61,13 @@ func SetPanicOnError(newval bool) {
 // Parameter nb defines column direction block size, mb defines row direction
 // block size and kb defines inner block size for matrix-matrix multiplication.
 func BlockingParams(mb, nb, kb int) {
+    globalMu.Lock()
+    defer globalMu.Unlock()
     vpLen = kb
     nB = nb
     mB = mb
 }
 
-func NumWorkers(newWorkers int) int {
-    oldWorkers := nWorker
-    nWorker = newWorkers
-    return oldWorkers
-}
-
 func row(A *matrix.FloatMatrix, inds ...int) *matrix.FloatMatrix {
     var r matrix.FloatMatrix
     switch len(inds) {
@@ -122,7 +118,7 @@ func isSquared(num int) (int, bool) {
     return nsqrt, issquared
 }
 
-func isVector(X *matrix.FloatMatrix) bool {
+func isVector(X dimensions) bool {
     return X.Rows() == 1 || X.Cols() == 1
 }
 
@@ -166,11 +162,11 @@ func divideWork(rows, cols, workers int) (colWorkers int, rowWorkers int) {
     return
 }
 
-type task func(int, int, int, int, chan int)
+type task func(int, int, int, int)
 
 func scheduleWork(colworks, rowworks, cols, rows int, worker task) {
     ntask := colworks*rowworks
-    ch := make(chan int, ntask)
+    tasks := make([]func(), 0, ntask)
     for k := 0; k < colworks; k++ {
         colstart := blockIndex4(k, colworks, cols)
         colend   := blockIndex4(k+1, colworks, cols)
@@ -178,13 +174,10 @@ func scheduleWork(colworks, rowworks, cols, rows int, worker task) {
             rowstart := blockIndex4(l, rowworks, rows)
             rowend   := blockIndex4(l+1, rowworks, rows)
             //fmt.Printf("schedule: S=%d, L=%d, R=%d, E=%d\n", colstart, colend, rowstart, rowend)
-            go worker(colstart, colend, rowstart, rowend, ch)
+            tasks = append(tasks, func() { worker(colstart, colend, rowstart, rowend) })
         }
     }
-    nready := 0
-    for nready < ntask {
-        nready += <- ch
-    }
+    defaultExecutor().Execute(context.Background(), tasks)
 }
 
 // Generic matrix-matrix multpily. (blas.GEMM). Calculates
@@ -196,12 +189,34 @@ func scheduleWork(colworks, rowworks, cols, rows int, worker task) {
 // C is M*N, A is M*P or P*M if flags&TRANSA. B is P*N or N*P if flags&TRANSB.
 //
 func Mult(C, A, B *matrix.FloatMatrix, alpha, beta float64, flags Flags) error {
-    var ok, empty bool
-    // error checking must take in account flag values!
+    return MultContext(context.Background(), nil, C, A, B, alpha, beta, flags)
+}
 
-    ar, ac := A.Size()
-    br, bc := B.Size()
-    cr, cc := C.Size()
+// Generic matrix-matrix multiply with tasks run by executor ex. If ex is nil
+// the default executor set with NumWorkers() is used. Computation is aborted
+// and ctx.Err() returned when ctx is cancelled; C is then partially updated.
+// See Mult().
+func MultContext(ctx context.Context, ex Executor, C, A, B *matrix.FloatMatrix,
+    alpha, beta float64, flags Flags) error {
+    return multConf(ctx, globalConfig(ex), C, A, B, alpha, beta, flags)
+}
+
+// Generic matrix-matrix multiply with blocking, worker and error parameters
+// from conf. See Config and MultContext().
+func MultConfig(ctx context.Context, conf *Config, C, A, B *matrix.FloatMatrix,
+    alpha, beta float64, flags Flags) error {
+    c, release := conf.resolve()
+    defer release()
+    return multConf(ctx, c, C, A, B, alpha, beta, flags)
+}
+
+// Check sizes of C = op(A)*op(B). Returns ok false if sizes do not match and
+// empty true if there is nothing to compute.
+func checkMult(C, A, B dimensions, flags Flags) (ok, empty bool) {
+    // error checking must take in account flag values!
+    ar, ac := A.Rows(), A.Cols()
+    br, bc := B.Rows(), B.Cols()
+    cr, cc := C.Rows(), C.Cols()
     switch flags & (TRANSA|TRANSB) {
     case TRANSA|TRANSB:
         empty = ac == 0 || br == 0 
@@ -217,11 +232,19 @@ func Mult(C, A, B *matrix.FloatMatrix, alpha, beta float64, flags Flags) error {
         ok    = cr == ar && cc == bc && ac == br
             
     }
+    return
+}
+
+// Generic matrix-matrix multiply with parameters from resolved configuration conf.
+func multConf(ctx context.Context, conf *Config, C, A, B *matrix.FloatMatrix,
+    alpha, beta float64, flags Flags) error {
+
+    ok, empty := checkMult(C, A, B, flags)
     if empty {
         return nil
     }
     if ! ok {
-        return errors.New("Mult: size mismatch")
+        return conf.sizeError("Mult", C, A, B)
     }
 
     psize := int64(C.NumElements())*int64(A.Cols())
@@ -238,21 +261,19 @@ func Mult(C, A, B *matrix.FloatMatrix, alpha, beta float64, flags Flags) error {
         P = A.Rows()
     }
 
-    if nWorker <= 1 || psize <= limitOne {
+    worker := func(cstart, cend, rstart, rend int) {
         calgo.DMult(Cr, Ar, Br, alpha, beta, calgo.Flags(flags), ldC, ldA, ldB, P,
-            0, C.Cols(), 0, C.Rows(),
-            vpLen, nB, mB)
+            cstart, cend, rstart, rend, conf.KB, conf.NB, conf.MB)
+    }
+    ex := conf.Executor
+    if psize <= conf.LimitOne || (ex.Workers() <= 1 && ctx.Done() == nil) {
+        if err := ctx.Err(); err != nil {
+            return err
+        }
+        worker(0, C.Cols(), 0, C.Rows())
         return nil
     } 
-    // here we have more than one worker available
-    worker := func(cstart, cend, rstart, rend int, ready chan int) {
-        calgo.DMult(Cr, Ar, Br, alpha, beta, calgo.Flags(flags), ldC, ldA, ldB, P,
-            cstart, cend, rstart, rend, vpLen, nB, mB)
-        ready <- 1
-    }
-    colworks, rowworks := divideWork(C.Rows(), C.Cols(), nWorker)
-    scheduleWork(colworks, rowworks, C.Cols(), C.Rows(), worker)
-    return nil
+    return scheduleTiles(ctx, conf, C.Cols(), C.Rows(), worker)
 }
 
 // Symmetric matrix multiply. (blas.SYMM)
@@ -264,6 +285,29 @@ func Mult(C, A, B *matrix.FloatMatrix, alpha, beta float64, flags Flags) error {
 // C is N*P, A is N*N symmetric matrix. B is N*P or P*N if flags&TRANSB.
 //
 func MultSym(C, A, B *matrix.FloatMatrix, alpha, beta float64, flags Flags) error {
+    return MultSymContext(context.Background(), nil, C, A, B, alpha, beta, flags)
+}
+
+// Symmetric matrix multiply with tasks run by executor ex. If ex is nil
+// the default executor is used. See MultContext() and MultSym().
+func MultSymContext(ctx context.Context, ex Executor, C, A, B *matrix.FloatMatrix,
+    alpha, beta float64, flags Flags) error {
+    return multSymConf(ctx, globalConfig(ex), C, A, B, alpha, beta, flags)
+}
+
+// Symmetric matrix multiply with blocking, worker and error parameters from
+// conf. See Config and MultSymContext().
+func MultSymConfig(ctx context.Context, conf *Config, C, A, B *matrix.FloatMatrix,
+    alpha, beta float64, flags Flags) error {
+    c, release := conf.resolve()
+    defer release()
+    return multSymConf(ctx, c, C, A, B, alpha, beta, flags)
+}
+
+// Symmetric matrix multiply with parameters from resolved configuration conf.
+func multSymConf(ctx context.Context, conf *Config, C, A, B *matrix.FloatMatrix,
+    alpha, beta float64, flags Flags) error {
+
     var ok, empty bool
 
     ar, ac := A.Size()
@@ -287,7 +331,7 @@ func MultSym(C, A, B *matrix.FloatMatrix, alpha, beta float64, flags Flags) erro
         return nil
     }
     if ! ok {
-        return errors.New("MultSym: size mismatch")
+        return conf.sizeError("MultSym", C, A, B)
     }
     /*
     if A.Rows() != A.Cols() {
@@ -305,20 +349,19 @@ func MultSym(C, A, B *matrix.FloatMatrix, alpha, beta float64, flags Flags) erro
     Cr := C.FloatArray()
     ldC := C.LeadingIndex()
 
-    if nWorker <= 1 || psize <= limitOne {
+    worker := func(cstart, cend, rstart, rend int) {
         calgo.DMultSymm(Cr, Ar, Br, alpha, beta, calgo.Flags(flags), ldC, ldA, ldB,
-            A.Cols(),  0, C.Cols(), 0, C.Rows(), vpLen, nB, mB)
+            A.Cols(), cstart, cend, rstart, rend, conf.KB, conf.NB, conf.MB)
+    }
+    ex := conf.Executor
+    if psize <= conf.LimitOne || (ex.Workers() <= 1 && ctx.Done() == nil) {
+        if err := ctx.Err(); err != nil {
+            return err
+        }
+        worker(0, C.Cols(), 0, C.Rows())
         return nil
     } 
-    // here we have more than one worker available
-    worker := func(cstart, cend, rstart, rend int, ready chan int) {
-        calgo.DMultSymm(Cr, Ar, Br, alpha, beta, calgo.Flags(flags), ldC, ldA, ldB,
-            A.Cols(), cstart, cend, rstart, rend, vpLen, nB, mB)
-        ready <- 1
-    }
-    colworks, rowworks := divideWork(C.Rows(), C.Cols(), nWorker)
-    scheduleWork(colworks, rowworks, C.Cols(), C.Rows(), worker)
-    return nil
+    return scheduleTiles(ctx, conf, C.Cols(), C.Rows(), worker)
 }
 
 // Triangular matrix multiply. (blas.TRMM) 
@@ -336,6 +379,19 @@ func MultSym(C, A, B *matrix.FloatMatrix, alpha, beta float64, flags Flags) erro
 // Matrix B is N*P if flags&LEFT or P*N if flags&RIGHT.
 //
 func MultTrm(B, A *matrix.FloatMatrix, alpha float64, flags Flags) error {
+    return MultTrmContext(context.Background(), nil, B, A, alpha, flags)
+}
+
+// Triangular matrix multiply with tasks run by executor ex. If ex is nil
+// the default executor is used. See MultContext() and MultTrm().
+func MultTrmContext(ctx context.Context, ex Executor, B, A *matrix.FloatMatrix,
+    alpha float64, flags Flags) error {
+    return multTrmConf(ctx, globalConfig(ex), B, A, alpha, flags)
+}
+
+// Triangular matrix multiply with parameters from resolved configuration conf.
+func multTrmConf(ctx context.Context, conf *Config, B, A *matrix.FloatMatrix,
+    alpha float64, flags Flags) error {
 
     ok := true
     empty := false
@@ -353,21 +409,44 @@ func MultTrm(B, A *matrix.FloatMatrix, alpha float64, flags Flags) error {
         return nil
     }
     if ! ok {
-        return onError("A, B size mismatch")
+        return conf.sizeError("MultTrm", B, A)
     }
-    Ar := A.FloatArray()
-    ldA := A.LeadingIndex()
-    Br := B.FloatArray()
-    ldB := B.LeadingIndex()
-    
-    E := bc
+    worker := func(S, E int) {
+        var Bs matrix.FloatMatrix
+        trmSubBlock(&Bs, B, S, E, flags)
+        calgo.DTrmmBlk(Bs.FloatArray(), A.FloatArray(), alpha, calgo.Flags(flags),
+            Bs.LeadingIndex(), A.LeadingIndex(), ac, 0, E-S, conf.NB)
+    }
+    // divide to tasks by B columns if flags&LEFT or by B rows if flags&RIGHT.
+    return scheduleTrm(ctx, conf, B, ac, flags, worker)
+}
+
+// B columns [S:E] if flags&LEFT or B rows [S:E] if flags&RIGHT
+func trmSubBlock[T any, M partitionable[T]](Bs, B M, S, E int, flags Flags) {
     if flags & RIGHT != 0 {
-        E = br
+        B.SubMatrix(Bs, S, 0, E-S, B.Cols())
+    } else {
+        B.SubMatrix(Bs, 0, S, B.Rows(), E-S)
     }
-    // if more workers available can divide to tasks by B columns if flags&LEFT or by
-    // B rows if flags&RIGHT.
-    calgo.DTrmmBlk(Br, Ar, alpha, calgo.Flags(flags), ldB, ldA, ac, 0, E, nB)
-    return nil
+}
+
+func scheduleTrm(ctx context.Context, conf *Config, B dimensions, N int,
+    flags Flags, worker func(S, E int)) error {
+
+    E := B.Cols()
+    if flags & RIGHT != 0 {
+        E = B.Rows()
+    }
+    psize := int64(N)*int64(N)*int64(E)
+    ex := conf.Executor
+    if psize <= conf.LimitOne || (ex.Workers() <= 1 && ctx.Done() == nil) {
+        if err := ctx.Err(); err != nil {
+            return err
+        }
+        worker(0, E)
+        return nil
+    }
+    return scheduleRanges(ctx, ex, E, worker)
 }
 
 // Solve multiple right sides. If flags&UNIT then A diagonal is assumed to
@@ -386,11 +465,22 @@ func MultTrm(B, A *matrix.FloatMatrix, alpha float64, flags Flags) error {
 // Matrix B is N*P if flags&LEFT or P*N if flags&RIGHT.
 //
 func SolveTrm(B, A *matrix.FloatMatrix, alpha float64, flags Flags) error {
+    return SolveTrmContext(context.Background(), nil, B, A, alpha, flags)
+}
 
-    ok := true
-    empty := false
-    br, bc := B.Size()
-    ar, ac := A.Size()
+// Triangular solve with multiple right sides with tasks run by executor ex.
+// If ex is nil the default executor is used. See MultContext() and SolveTrm().
+func SolveTrmContext(ctx context.Context, ex Executor, B, A *matrix.FloatMatrix,
+    alpha float64, flags Flags) error {
+    return solveTrmConf(ctx, globalConfig(ex), B, A, alpha, flags)
+}
+
+// Check sizes of B and triangular matrix A for op(A)*B or B*op(A). Returns ok false
+// if sizes do not match and empty true if there is nothing to compute.
+func checkTrm(B, A dimensions, flags Flags) (ok, empty bool) {
+    ok = true
+    br, bc := B.Rows(), B.Cols()
+    ar, ac := A.Rows(), A.Cols()
     switch flags & (LEFT|RIGHT) {
     case LEFT:
         empty = br == 0
@@ -399,49 +489,110 @@ func SolveTrm(B, A *matrix.FloatMatrix, alpha float64, flags Flags) error {
         empty = bc == 0
         ok    = bc == ar && ac == ar
     }
+    return
+}
+
+// Triangular solve with parameters from resolved configuration conf.
+func solveTrmConf(ctx context.Context, conf *Config, B, A *matrix.FloatMatrix,
+    alpha float64, flags Flags) error {
+
+    ok, empty := checkTrm(B, A, flags)
     if empty {
         return nil
     }
     if ! ok {
-        return onError("A, B size mismatch")
+        return conf.sizeError("SolveTrm", B, A)
+    }
+    ac := A.Cols()
+    worker := func(S, E int) {
+        var Bs matrix.FloatMatrix
+        trmSubBlock(&Bs, B, S, E, flags)
+        calgo.DSolveBlk(Bs.FloatArray(), A.FloatArray(), alpha, calgo.Flags(flags),
+            Bs.LeadingIndex(), A.LeadingIndex(), ac, 0, E-S, conf.NB)
     }
+    // divide to tasks by B columns if flags&LEFT or by B rows if flags&RIGHT.
+    return scheduleTrm(ctx, conf, B, ac, flags, worker)
+}
 
-    Ar := A.FloatArray()
-    ldA := A.LeadingIndex()
-    Br := B.FloatArray()
-    ldB := B.LeadingIndex()
-    
-    E := bc
-    if flags & RIGHT != 0 {
-        E = br
+// True if C is N-by-N and op(A) is N-by-P in symmetric rank update.
+func checkRankUpdate(C, A dimensions, flags Flags) bool {
+    N := A.Rows()
+    if flags & TRANSA != 0 {
+        N = A.Cols()
     }
-    // if more workers available can divide to tasks by B columns if flags&LEFT or by
-    // B rows if flags&RIGHT.
-    calgo.DSolveBlk(Br, Ar, alpha, calgo.Flags(flags), ldB, ldA, ac, 0, E, nB)
-    return nil
+    return C.Rows() == C.Cols() && C.Rows() == N
 }
 
 // Rank update for symmetric lower or upper matrix (blas.SYRK)
 //      C = beta*C + alpha*A*A.T + alpha*A.T*A
 func RankUpdateSym(C, A *matrix.FloatMatrix, alpha, beta float64, flags Flags) error {
-    if C.Rows() != C.Cols() {
-        return onError("C not a square matrix")
+    return RankUpdateSymContext(context.Background(), nil, C, A, alpha, beta, flags)
+}
+
+// Rank update for symmetric matrix with tasks run by executor ex. If ex is nil
+// the default executor is used. See MultContext() and RankUpdateSym().
+func RankUpdateSymContext(ctx context.Context, ex Executor, C, A *matrix.FloatMatrix,
+    alpha, beta float64, flags Flags) error {
+    return rankUpdateSymConf(ctx, globalConfig(ex), C, A, alpha, beta, flags)
+}
+
+// Symmetric rank update with parameters from resolved configuration conf.
+func rankUpdateSymConf(ctx context.Context, conf *Config, C, A *matrix.FloatMatrix,
+    alpha, beta float64, flags Flags) error {
+
+    if ! checkRankUpdate(C, A, flags) {
+        return conf.sizeError("RankUpdateSym", C, A)
     }
-    Ar := A.FloatArray()
-    ldA := A.LeadingIndex()
-    Cr := C.FloatArray()
-    ldC := C.LeadingIndex()
-    S := 0
-    E := C.Rows()
+    N := C.Rows()
     P := A.Cols()
     if flags & TRANSA != 0 {
         P = A.Rows()
     }
-    // if more workers available C can be divided to blocks [S:E, S:E] along diagonal
-    // and updated in separate tasks. 
-    calgo.DSymmRankBlk(Cr, Ar, alpha, beta, calgo.Flags(flags), ldC, ldA, P, S, E,
-        vpLen, nB)
-    return nil
+    // rows [S:E] of A or columns [S:E] of A.T
+    subA := func(As *matrix.FloatMatrix, S, E int) {
+        if flags & TRANSA != 0 {
+            A.SubMatrix(As, 0, S, P, E-S)
+        } else {
+            A.SubMatrix(As, S, 0, E-S, P)
+        }
+    }
+    // C is divided to blocks of rows [S:E]; diagonal block C[S:E,S:E] is updated
+    // with symmetric rank update and the rest of the block rows with general
+    // matrix multiplication.
+    worker := func(S, E int) {
+        var Cd, Ad, Co, Ao matrix.FloatMatrix
+        C.SubMatrix(&Cd, S, S, E-S, E-S)
+        subA(&Ad, S, E)
+        calgo.DSymmRankBlk(Cd.FloatArray(), Ad.FloatArray(), alpha, beta, calgo.Flags(flags),
+            Cd.LeadingIndex(), Ad.LeadingIndex(), P, 0, E-S, conf.KB, conf.NB)
+        mflags := calgo.Flags(calgo.TRANSB)
+        if flags & TRANSA != 0 {
+            mflags = calgo.Flags(calgo.TRANSA)
+        }
+        if flags & LOWER != 0 && S > 0 {
+            C.SubMatrix(&Co, S, 0, E-S, S)
+            subA(&Ao, 0, S)
+        } else if flags & UPPER != 0 && E < N {
+            C.SubMatrix(&Co, S, E, E-S, N-E)
+            subA(&Ao, E, N)
+        } else {
+            return
+        }
+        calgo.DMult(Co.FloatArray(), Ad.FloatArray(), Ao.FloatArray(), alpha, beta,
+            mflags, Co.LeadingIndex(), Ad.LeadingIndex(), Ao.LeadingIndex(), P,
+            0, Co.Cols(), 0, Co.Rows(), conf.KB, conf.NB, conf.MB)
+    }
+    psize := int64(N)*int64(N)*int64(P)
+    ex := conf.Executor
+    if N == 0 || psize <= conf.LimitOne || (ex.Workers() <= 1 && ctx.Done() == nil) {
+        if err := ctx.Err(); err != nil {
+            return err
+        }
+        calgo.DSymmRankBlk(C.FloatArray(), A.FloatArray(), alpha, beta, calgo.Flags(flags),
+            C.LeadingIndex(), A.LeadingIndex(), P, 0, N, conf.KB, conf.NB)
+        return nil
+    }
+    return scheduleList(ctx, ex, splitTriangle(ctx, N, ex.Workers(), flags), worker)
 }
 
 // Rank 2 update for symmetric lower or upper matrix. (blas.SYR2K)
@@ -451,8 +602,8 @@ func RankUpdateSym(C, A *matrix.FloatMatrix, alpha, beta float64, flags Flags) e
 //   lower triangular if flags&LOWER
 //   upper triangular if flags&UPPER
 func RankUpdate2Sym(C, A, B *matrix.FloatMatrix, alpha, beta float64, flags Flags) error {
-    if C.Rows() != C.Cols() {
-        return onError("C not a square matrix")
+    if ! checkRankUpdate(C, A, flags) || A.Rows() != B.Rows() || A.Cols() != B.Cols() {
+        return sizeError("RankUpdate2Sym", C, A, B)
     }
     Ar := A.FloatArray()
     ldA := A.LeadingIndex()
@@ -468,15 +619,25 @@ func RankUpdate2Sym(C, A, B *matrix.FloatMatrix, alpha, beta float64, flags Flag
     }
     // if more workers available C can be divided to blocks [S:E, S:E] along diagonal
     // and updated in separate tasks. 
+    p := globalParams()
     calgo.DSymmRank2Blk(Cr, Ar, Br, alpha, beta, calgo.Flags(flags), ldC, ldA, ldB,
-        P, S, E, vpLen, nB)
+        P, S, E, p.KB, p.NB)
     return nil
 }
 
 // A = alpha*A + beta*B   
 // A = alpha*A + beta*B.T  if flags&TRANSB
 func ScalePlus(A, B *matrix.FloatMatrix, alpha, beta float64, flags Flags) error {
-
+    if A.Rows() == 0 || A.Cols() == 0 {
+        return nil
+    }
+    br, bc := B.Rows(), B.Cols()
+    if flags & TRANSB != 0 {
+        br, bc = bc, br
+    }
+    if br != A.Rows() || bc != A.Cols() {
+        return sizeError("ScalePlus", A, B)
+    }
     Ar := A.FloatArray()
     ldA := A.LeadingIndex()
     Br := B.FloatArray()
@@ -499,26 +660,79 @@ func ScalePlus(A, B *matrix.FloatMatrix, alpha, beta float64, flags Flags) error
 //   lower triangular if flags has set LOWER 
 //   upper triangular if flags has set UPPER
 func UpdateTrm(C, A, B *matrix.FloatMatrix, alpha, beta float64, flags Flags) error {
-    if C.Rows() != C.Cols() {
-        return onError("C not a square matrix")
+    return UpdateTrmContext(context.Background(), nil, C, A, B, alpha, beta, flags)
+}
+
+// Triangular matrix update with tasks run by executor ex. If ex is nil the
+// default executor is used. See MultContext() and UpdateTrm().
+func UpdateTrmContext(ctx context.Context, ex Executor, C, A, B *matrix.FloatMatrix,
+    alpha, beta float64, flags Flags) error {
+    return updateTrmConf(ctx, globalConfig(ex), C, A, B, alpha, beta, flags)
+}
+
+// Triangular update with parameters from resolved configuration conf.
+func updateTrmConf(ctx context.Context, conf *Config, C, A, B *matrix.FloatMatrix,
+    alpha, beta float64, flags Flags) error {
+
+    if ok, _ := checkMult(C, A, B, flags); ! ok || C.Rows() != C.Cols() {
+        return conf.sizeError("UpdateTrm", C, A, B)
     }
-    Ar := A.FloatArray()
-    ldA := A.LeadingIndex()
-    Br := B.FloatArray()
-    ldB := B.LeadingIndex()
-    Cr := C.FloatArray()
-    ldC := C.LeadingIndex()
-    S := 0
-    E := C.Rows()
+    N := C.Rows()
     P := A.Cols()
     if flags & TRANSA != 0 {
         P = A.Rows()
     }
-    // if more workers available C can be divided to blocks [S:E, S:E] along diagonal
-    // and updated in separate tasks. 
-    calgo.DTrmUpdBlk(Cr, Ar, Br, alpha, beta, calgo.Flags(flags), ldC, ldA, ldB,
-        P, S, E, vpLen, nB)
-    return nil
+    // rows [S:E] of op(A) and columns [S:E] of op(B)
+    subA := func(As *matrix.FloatMatrix, S, E int) {
+        if flags & TRANSA != 0 {
+            A.SubMatrix(As, 0, S, P, E-S)
+        } else {
+            A.SubMatrix(As, S, 0, E-S, P)
+        }
+    }
+    subB := func(Bs *matrix.FloatMatrix, S, E int) {
+        if flags & TRANSB != 0 {
+            B.SubMatrix(Bs, S, 0, E-S, P)
+        } else {
+            B.SubMatrix(Bs, 0, S, P, E-S)
+        }
+    }
+    // C is divided to blocks of rows [S:E]; diagonal block C[S:E,S:E] is updated
+    // with triangular update and the rest of the block rows with general matrix
+    // multiplication.
+    worker := func(S, E int) {
+        var Cd, Ad, Bd, Co, Bo matrix.FloatMatrix
+        C.SubMatrix(&Cd, S, S, E-S, E-S)
+        subA(&Ad, S, E)
+        subB(&Bd, S, E)
+        calgo.DTrmUpdBlk(Cd.FloatArray(), Ad.FloatArray(), Bd.FloatArray(), alpha, beta,
+            calgo.Flags(flags), Cd.LeadingIndex(), Ad.LeadingIndex(), Bd.LeadingIndex(),
+            P, 0, E-S, conf.KB, conf.NB)
+        if flags & LOWER != 0 && S > 0 {
+            C.SubMatrix(&Co, S, 0, E-S, S)
+            subB(&Bo, 0, S)
+        } else if flags & UPPER != 0 && E < N {
+            C.SubMatrix(&Co, S, E, E-S, N-E)
+            subB(&Bo, E, N)
+        } else {
+            return
+        }
+        calgo.DMult(Co.FloatArray(), Ad.FloatArray(), Bo.FloatArray(), alpha, beta,
+            calgo.Flags(flags & (TRANSA|TRANSB)), Co.LeadingIndex(), Ad.LeadingIndex(),
+            Bo.LeadingIndex(), P, 0, Co.Cols(), 0, Co.Rows(), conf.KB, conf.NB, conf.MB)
+    }
+    psize := int64(N)*int64(N)*int64(P)
+    ex := conf.Executor
+    if N == 0 || psize <= conf.LimitOne || (ex.Workers() <= 1 && ctx.Done() == nil) {
+        if err := ctx.Err(); err != nil {
+            return err
+        }
+        calgo.DTrmUpdBlk(C.FloatArray(), A.FloatArray(), B.FloatArray(), alpha, beta,
+            calgo.Flags(flags), C.LeadingIndex(), A.LeadingIndex(), B.LeadingIndex(),
+            P, 0, N, conf.KB, conf.NB)
+        return nil
+    }
+    return scheduleList(ctx, ex, splitTriangle(ctx, N, ex.Workers(), flags), worker)
 }
 
 
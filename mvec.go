@@ -10,7 +10,7 @@ package matops
 import (
     "github.com/hrautila/matrix"
     "github.com/hrautila/matops/calgo"
-    "errors"
+    "context"
     "math"
     //"fmt"
 )
@@ -26,15 +26,29 @@ import (
 // MVMult is vector orientation agnostic. It does not matter if Y, X are row or
 // column vectors, they are always handled as if they were column vectors.
 func MVMult(Y, A, X *matrix.FloatMatrix, alpha, beta float64, flags Flags) error {
+    return MVMultContext(context.Background(), nil, Y, A, X, alpha, beta, flags)
+}
+
+// Matrix-vector multiplication with tasks run by executor ex. If ex is nil
+// the default executor is used. See MultContext() and MVMult().
+func MVMultContext(ctx context.Context, ex Executor, Y, A, X *matrix.FloatMatrix,
+    alpha, beta float64, flags Flags) error {
 
     if A.Rows() == 0 || A.Cols() == 0 {
         return nil
     }
     if Y.Rows() != 1 && Y.Cols() != 1 {
-        return errors.New("Y not a vector.");
+        return sizeError("MVMult", Y, A, X)
     }
     if X.Rows() != 1 && X.Cols() != 1 {
-        return errors.New("X not a vector.");
+        return sizeError("MVMult", Y, A, X)
+    }
+    M, N := A.Rows(), A.Cols()
+    if flags & TRANSA != 0 {
+        M, N = N, M
+    }
+    if Y.NumElements() != M || X.NumElements() != N {
+        return sizeError("MVMult", Y, A, X)
     }
 
     Ar := A.FloatArray()
@@ -53,27 +67,51 @@ func MVMult(Y, A, X *matrix.FloatMatrix, alpha, beta float64, flags Flags) error
         // row vector
         incX = X.LeadingIndex()
     }
-    // NOTE: This could diveded to parallel tasks by rows.
-    calgo.DMultMV(Yr, Ar, Xr, alpha, beta, calgo.Flags(flags), incY, ldA, incX,
-        0, lenX, 0, lenY, vpLen, mB)
-    return nil
+    // divided to parallel tasks by elements of Y
+    p := globalParams()
+    worker := func(R, E int) {
+        calgo.DMultMV(Yr, Ar, Xr, alpha, beta, calgo.Flags(flags), incY, ldA, incX,
+            0, lenX, R, E, p.KB, p.MB)
+    }
+    return scheduleMV(ctx, ex, lenY, lenX, worker)
 }
 
+func scheduleMV(ctx context.Context, ex Executor, N, M int, worker func(S, E int)) error {
+    ex = executorOrDefault(ex)
+    if int64(N)*int64(M) <= limitMV || (ex.Workers() <= 1 && ctx.Done() == nil) {
+        if err := ctx.Err(); err != nil {
+            return err
+        }
+        worker(0, N)
+        return nil
+    }
+    return scheduleRanges(ctx, ex, N, worker)
+}
 
 // Matrix-vector rank update A = A + alpha*X*Y.T
 //    A is M*N generic matrix,
 //    X is row or column vector of length M 
 //    Y is row or column vector of legth N.
 func MVRankUpdate(A, X, Y *matrix.FloatMatrix, alpha float64) error {
+    return MVRankUpdateContext(context.Background(), nil, A, X, Y, alpha)
+}
+
+// Matrix-vector rank update with tasks run by executor ex. If ex is nil
+// the default executor is used. See MultContext() and MVRankUpdate().
+func MVRankUpdateContext(ctx context.Context, ex Executor, A, X, Y *matrix.FloatMatrix,
+    alpha float64) error {
 
     if A.Rows() == 0 || A.Cols() == 0 {
         return nil
     }
     if Y.Rows() != 1 && Y.Cols() != 1 {
-        return errors.New("Y not a vector.");
+        return sizeError("MVRankUpdate", A, X, Y)
     }
     if X.Rows() != 1 && X.Cols() != 1 {
-        return errors.New("X not a vector.");
+        return sizeError("MVRankUpdate", A, X, Y)
+    }
+    if X.NumElements() != A.Rows() || Y.NumElements() != A.Cols() {
+        return sizeError("MVRankUpdate", A, X, Y)
     }
 
     Ar := A.FloatArray()
@@ -90,24 +128,35 @@ func MVRankUpdate(A, X, Y *matrix.FloatMatrix, alpha float64) error {
         // row vector
         incX = X.LeadingIndex()
     }
-    // NOTE: This could diveded to parallel tasks like matrix-matrix multiplication
-    calgo.DRankMV(Ar, Xr, Yr, alpha, ldA, incX, incY, 0, A.Cols(), 0, A.Rows(), 0, 0)
-    return nil
+    // divided to parallel tasks by columns of A
+    worker := func(S, L int) {
+        calgo.DRankMV(Ar, Xr, Yr, alpha, ldA, incX, incY, S, L, 0, A.Rows(), 0, 0)
+    }
+    return scheduleMV(ctx, ex, A.Cols(), A.Rows(), worker)
 }
 
 // Matrix-vector symmetric rank update A = A + alpha*X*X.T
 //   A is N*N symmetric,
 //   X is row or column vector of length N.
 func MVRankUpdateSym(A, X *matrix.FloatMatrix, alpha float64, flags Flags) error {
+    return MVRankUpdateSymContext(context.Background(), nil, A, X, alpha, flags)
+}
+
+// Matrix-vector symmetric rank update with tasks run by executor ex. If ex is nil
+// the default executor is used. See MultContext() and MVRankUpdateSym().
+func MVRankUpdateSymContext(ctx context.Context, ex Executor, A, X *matrix.FloatMatrix,
+    alpha float64, flags Flags) error {
 
     if A.Rows() == 0 || A.Cols() == 0 {
         return nil
     }
     if X.Rows() != 1 && X.Cols() != 1 {
-        return errors.New("X not a vector.");
+        return sizeError("MVRankUpdateSym", A, X)
+    }
+    if A.Rows() != A.Cols() || X.NumElements() != A.Rows() {
+        return sizeError("MVRankUpdateSym", A, X)
     }
 
-    Ar := A.FloatArray()
     ldA := A.LeadingIndex()
     Xr := X.FloatArray()
     incX := 1
@@ -115,9 +164,45 @@ func MVRankUpdateSym(A, X *matrix.FloatMatrix, alpha float64, flags Flags) error
         // row vector
         incX = X.LeadingIndex()
     }
-    // NOTE: This could diveded to parallel tasks per column.
-    calgo.DSymmRankMV(Ar, Xr, alpha, calgo.Flags(flags), ldA, incX, 0, A.Cols(), 0)
-    return nil
+    // A is divided to blocks of rows [S:E]; diagonal block A[S:E,S:E] is updated
+    // with symmetric rank update and the rest of the block rows with general
+    // rank update.
+    worker := func(S, E int) {
+        var Ad, Ao matrix.FloatMatrix
+        A.SubMatrix(&Ad, S, S, E-S, E-S)
+        calgo.DSymmRankMV(Ad.FloatArray(), Xr[S*incX:], alpha, calgo.Flags(flags),
+            ldA, incX, 0, E-S, 0)
+        if c0, c1, ok := symOffDiagonal(&Ao, A, S, E, flags); ok {
+            calgo.DRankMV(Ao.FloatArray(), Xr[S*incX:], Xr[c0*incX:], alpha, ldA,
+                incX, incX, 0, c1-c0, 0, E-S, 0, 0)
+        }
+    }
+    return scheduleSymMV(ctx, ex, A.Rows(), flags, worker)
+}
+
+// Off-diagonal part of rows [S:E] of symmetric matrix A; returns the column range.
+func symOffDiagonal(Ao, A *matrix.FloatMatrix, S, E int, flags Flags) (int, int, bool) {
+    if flags & LOWER != 0 && S > 0 {
+        A.SubMatrix(Ao, S, 0, E-S, S)
+        return 0, S, true
+    }
+    if flags & UPPER != 0 && E < A.Cols() {
+        A.SubMatrix(Ao, S, E, E-S, A.Cols()-E)
+        return E, A.Cols(), true
+    }
+    return 0, 0, false
+}
+
+func scheduleSymMV(ctx context.Context, ex Executor, N int, flags Flags, worker func(S, E int)) error {
+    ex = executorOrDefault(ex)
+    if int64(N)*int64(N) <= limitMV || (ex.Workers() <= 1 && ctx.Done() == nil) {
+        if err := ctx.Err(); err != nil {
+            return err
+        }
+        worker(0, N)
+        return nil
+    }
+    return scheduleList(ctx, ex, splitTriangle(ctx, N, ex.Workers(), flags), worker)
 }
 
 // Matrix-vector symmetric rank 2 update A = A + alpha*X*Y.T + alpha*X.T*Y
@@ -125,18 +210,28 @@ func MVRankUpdateSym(A, X *matrix.FloatMatrix, alpha float64, flags Flags) error
 //   X is row or column vector of length N
 //   Y is row or column vector of legth N.
 func MVRankUpdate2Sym(A, X, Y *matrix.FloatMatrix, alpha float64, flags Flags) error {
+    return MVRankUpdate2SymContext(context.Background(), nil, A, X, Y, alpha, flags)
+}
+
+// Matrix-vector symmetric rank 2 update with tasks run by executor ex. If ex is nil
+// the default executor is used. See MultContext() and MVRankUpdate2Sym().
+func MVRankUpdate2SymContext(ctx context.Context, ex Executor, A, X, Y *matrix.FloatMatrix,
+    alpha float64, flags Flags) error {
 
     if A.Rows() == 0 || A.Cols() == 0 {
         return nil
     }
     if Y.Rows() != 1 && Y.Cols() != 1 {
-        return errors.New("Y not a vector.");
+        return sizeError("MVRankUpdate2Sym", A, X, Y)
     }
     if X.Rows() != 1 && X.Cols() != 1 {
-        return errors.New("X not a vector.");
+        return sizeError("MVRankUpdate2Sym", A, X, Y)
+    }
+    N := A.Rows()
+    if A.Cols() != N || X.NumElements() != N || Y.NumElements() != N {
+        return sizeError("MVRankUpdate2Sym", A, X, Y)
     }
 
-    Ar := A.FloatArray()
     ldA := A.LeadingIndex()
     Yr := Y.FloatArray()
     incY := 1
@@ -150,9 +245,20 @@ func MVRankUpdate2Sym(A, X, Y *matrix.FloatMatrix, alpha float64, flags Flags) e
         // row vector
         incX = X.LeadingIndex()
     }
-    // NOTE: This could diveded to parallel tasks like matrix-matrix multiplication
-    calgo.DSymmRank2MV(Ar, Xr, Yr, alpha, calgo.Flags(flags), ldA, incY, incX, 0, A.Cols(), 0)
-    return nil
+    // as in MVRankUpdateSymContext()
+    worker := func(S, E int) {
+        var Ad, Ao matrix.FloatMatrix
+        A.SubMatrix(&Ad, S, S, E-S, E-S)
+        calgo.DSymmRank2MV(Ad.FloatArray(), Xr[S*incX:], Yr[S*incY:], alpha, calgo.Flags(flags),
+            ldA, incX, incY, 0, E-S, 0)
+        if c0, c1, ok := symOffDiagonal(&Ao, A, S, E, flags); ok {
+            calgo.DRankMV(Ao.FloatArray(), Xr[S*incX:], Yr[c0*incY:], alpha, ldA,
+                incX, incY, 0, c1-c0, 0, E-S, 0, 0)
+            calgo.DRankMV(Ao.FloatArray(), Yr[S*incY:], Xr[c0*incX:], alpha, ldA,
+                incY, incX, 0, c1-c0, 0, E-S, 0, 0)
+        }
+    }
+    return scheduleSymMV(ctx, ex, A.Rows(), flags, worker)
 }
 
 // Matrix-vector triangular update A = A + alpha*X*Y.T 
@@ -166,10 +272,14 @@ func MVUpdateTrm(A, X, Y *matrix.FloatMatrix, alpha float64, flags Flags) error
         return nil
     }
     if Y.Rows() != 1 && Y.Cols() != 1 {
-        return errors.New("Y not a vector.");
+        return sizeError("MVUpdateTrm", A, X, Y)
     }
     if X.Rows() != 1 && X.Cols() != 1 {
-        return errors.New("X not a vector.");
+        return sizeError("MVUpdateTrm", A, X, Y)
+    }
+    N := A.Rows()
+    if A.Cols() != N || X.NumElements() != N || Y.NumElements() != N {
+        return sizeError("MVUpdateTrm", A, X, Y)
     }
 
     Ar := A.FloatArray()
@@ -187,7 +297,7 @@ func MVUpdateTrm(A, X, Y *matrix.FloatMatrix, alpha float64, flags Flags) error
         incX = X.LeadingIndex()
     }
     // NOTE: This could diveded to parallel tasks like matrix-matrix multiplication
-    calgo.DTrmUpdMV(Ar, Xr, Yr, alpha, calgo.Flags(flags), ldA, incX, incY, 0, A.Cols(), nB)
+    calgo.DTrmUpdMV(Ar, Xr, Yr, alpha, calgo.Flags(flags), ldA, incX, incY, 0, A.Cols(), globalParams().NB)
     return nil
 }
 
@@ -206,7 +316,10 @@ func MVSolveTrm(X, A *matrix.FloatMatrix, alpha float64, flags Flags) error {
         return nil
     }
     if X.Rows() != 1 && X.Cols() != 1 {
-        return errors.New("X not a vector.");
+        return sizeError("MVSolveTrm", X, A)
+    }
+    if A.Rows() != A.Cols() || X.NumElements() != A.Rows() {
+        return sizeError("MVSolveTrm", X, A)
     }
 
     Ar := A.FloatArray()
@@ -217,7 +330,7 @@ func MVSolveTrm(X, A *matrix.FloatMatrix, alpha float64, flags Flags) error {
         // row vector
         incX = X.LeadingIndex()
     }
-    calgo.DSolveBlkMV(Xr, Ar, calgo.Flags(flags), incX, ldA, A.Cols(), nB)
+    calgo.DSolveBlkMV(Xr, Ar, calgo.Flags(flags), incX, ldA, A.Cols(), globalParams().NB)
     return nil
 }
 
@@ -229,7 +342,10 @@ func MVMultTrm(X, A*matrix.FloatMatrix, flags Flags) error {
         return nil
     }
     if X.Rows() != 1 && X.Cols() != 1 {
-        return errors.New("X not a vector.");
+        return sizeError("MVMultTrm", X, A)
+    }
+    if A.Rows() != A.Cols() || X.NumElements() != A.Rows() {
+        return sizeError("MVMultTrm", X, A)
     }
 
     Ar := A.FloatArray()
@@ -354,7 +470,7 @@ func Axpy(Y, X *matrix.FloatMatrix, alpha float64)  {
         // Row vector
         incY = Y.LeadingIndex()
     }
-    calgo.DAxpy(Xr, Yr, alpha, incX, incY, X.NumElements())
+    calgo.DAxpy(Yr, Xr, alpha, incX, incY, X.NumElements())
     return
 }
 
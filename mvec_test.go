@@ -0,0 +1,67 @@
+
+// Copyright (c) Harri Rautila, 2013
+
+// This file is part of github.com/hrautila/matops package. It is free software,
+// distributed under the terms of GNU Lesser General Public License Version 3, or
+// any later version. See the COPYING tile included in this archive.
+
+package matops
+
+import (
+    "github.com/hrautila/matrix"
+    "testing"
+)
+
+func TestAxpy(t *testing.T) {
+    N := 13
+    alpha := 1.5
+    // X as row of a matrix, non-unit increment; Y column vector
+    Xm := matrix.FloatNormal(3, N)
+    var X matrix.FloatMatrix
+    Xm.SubMatrix(&X, 1, 0, 1, N)
+    X0 := X.Copy()
+    Y := matrix.FloatNormal(N, 1)
+    Yref := Y.Copy()
+    for k := 0; k < N; k++ {
+        Yref.SetIndex(k, Yref.GetIndex(k) + alpha*X.GetIndex(k))
+    }
+    Axpy(Y, &X, alpha)
+    if d := NormP(Y.Minus(Yref), NORM_ONE); d > 1e-14 {
+        t.Errorf("||Y + alpha*X - Axpy(Y, X)||_1: %e\n", d)
+    }
+    if d := NormP(X.Copy().Minus(X0), NORM_ONE); d != 0.0 {
+        t.Errorf("Axpy changed X\n")
+    }
+}
+
+func TestRankUpdate2Sym(t *testing.T) {
+    N := 13
+    alpha := 1.5
+    // X as row of a matrix, Y column vector; incX != incY
+    Xm := matrix.FloatNormal(3, N)
+    var X matrix.FloatMatrix
+    Xm.SubMatrix(&X, 1, 0, 1, N)
+    Y := matrix.FloatNormal(N, 1)
+    A := matrix.FloatNormal(N, N)
+    for _, flags := range []Flags{LOWER, UPPER} {
+        A0 := A.Copy()
+        MVRankUpdate2Sym(A0, &X, Y, alpha, flags)
+        Aref := A.Copy()
+        MVRankUpdate(Aref, &X, Y, alpha)
+        MVRankUpdate(Aref, Y, &X, alpha)
+        A0.Minus(Aref)
+        if flags & LOWER != 0 {
+            TriL(A0)
+        } else {
+            TriU(A0)
+        }
+        if d := NormP(A0, NORM_ONE); d > 1e-13 {
+            t.Errorf("flags %x: ||SYR2 - (GER + GER)||_1: %e\n", flags, d)
+        }
+    }
+}
+
+// Local Variables:
+// tab-width: 4
+// indent-tabs-mode: nil
+// End:
@@ -9,6 +9,7 @@ package matops
 
 import (
     "github.com/hrautila/matrix"
+    "math"
 )
 
 type Norms int
@@ -35,7 +36,7 @@ func mNormInf(A *matrix.FloatMatrix) float64 {
     var amax float64 = 0.0
     var row matrix.FloatMatrix
     for k := 0; k < A.Rows(); k++ {
-        row.SubMatrixOf(A, k, 0, A.Cols(), 1)
+        row.SubMatrixOf(A, k, 0, 1, A.Cols())
         rmax := ASum(&row)
         if rmax > amax {
             amax = rmax
@@ -44,6 +45,18 @@ func mNormInf(A *matrix.FloatMatrix) float64 {
     return amax
 }
 
+// Matrix 2-norm, the largest singular value; zero for empty matrix.
+func mNorm2(A *matrix.FloatMatrix) float64 {
+    K := imin(A.Rows(), A.Cols())
+    if K == 0 {
+        return 0.0
+    }
+    S := matrix.FloatZeros(K, 1)
+    if err := DecomposeSVD(A.Copy(), S, nil, nil, nil, 0, 0); err != nil {
+        return math.NaN()
+    }
+    return S.GetAt(0, 0)
+}
 
 /*
  * Compute matrix and vector norms.
@@ -54,7 +67,7 @@ func mNormInf(A *matrix.FloatMatrix) float64 {
  *  norm Norm to compute
  *         NORM_ONE, NORM_TWO, NORM_INF
  *
- * Note: matrix NORM_TWO not yet implemented.
+ * Matrix NORM_TWO is the largest singular value of X computed with DecomposeSVD().
  */
 func NormP(X *matrix.FloatMatrix, norm Norms) float64 {
     if isVector(X) {
@@ -64,7 +77,7 @@ func NormP(X *matrix.FloatMatrix, norm Norms) float64 {
         case NORM_TWO:
             return Norm2(X)
         case NORM_INF:
-            return AMax(X)
+            return math.Abs(AMax(X))
         }
         return 0.0
     }
@@ -72,7 +85,7 @@ func NormP(X *matrix.FloatMatrix, norm Norms) float64 {
     case NORM_ONE:
         return mNorm1(X)
     case NORM_TWO:
-        return 0.0
+        return mNorm2(X)
     case NORM_INF:
         return mNormInf(X)
     }
@@ -0,0 +1,52 @@
+
+// Copyright (c) Harri Rautila, 2013
+
+// This file is part of github.com/hrautila/matops package. It is free software,
+// distributed under the terms of GNU Lesser General Public License Version 3, or
+// any later version. See the COPYING tile included in this archive.
+
+package matops
+
+import (
+    "github.com/hrautila/matrix"
+    "math"
+    "testing"
+)
+
+func TestNormInf(t *testing.T) {
+    // non-square matrix; inf-norm is the maximum absolute row sum
+    A := matrix.FloatNormal(7, 4)
+    nrm := 0.0
+    for i := 0; i < A.Rows(); i++ {
+        s := 0.0
+        for j := 0; j < A.Cols(); j++ {
+            s += math.Abs(A.GetAt(i, j))
+        }
+        nrm = math.Max(nrm, s)
+    }
+    if d := math.Abs(NormP(A, NORM_INF) - nrm); d > 1e-14 {
+        t.Errorf("||A||_inf: %e, expected %e\n", NormP(A, NORM_INF), nrm)
+    }
+    // vector inf-norm is the maximum absolute value
+    x := matrix.FloatWithValue(5, 1, 1.0)
+    x.SetAt(3, 0, -4.0)
+    if v := NormP(x, NORM_INF); v != 4.0 {
+        t.Errorf("||x||_inf: %e, expected 4.0\n", v)
+    }
+    if v := NormP(x.Transpose(), NORM_INF); v != 4.0 {
+        t.Errorf("||x.T||_inf: %e, expected 4.0\n", v)
+    }
+}
+
+func TestNorm2Empty(t *testing.T) {
+    for _, A := range []*matrix.FloatMatrix{matrix.FloatZeros(0, 5), matrix.FloatZeros(5, 0)} {
+        if v := NormP(A, NORM_TWO); v != 0.0 {
+            t.Errorf("%dx%d ||A||_2: %e, expected 0.0\n", A.Rows(), A.Cols(), v)
+        }
+    }
+}
+
+// Local Variables:
+// tab-width: 4
+// indent-tabs-mode: nil
+// End:
@@ -0,0 +1,425 @@
+
+// Copyright (c) Harri Rautila, 2013
+
+// This file is part of github.com/hrautila/matops package. It is free software,
+// distributed under the terms of GNU Lesser General Public License Version 3, or
+// any later version. See the COPYING tile included in this archive.
+
+package matops
+
+import (
+    "github.com/hrautila/matrix"
+)
+
+/*
+
+PACKED STORAGE
+
+Upper or lower triangle of a symmetric or triangular N-by-N matrix is stored column
+by column in a linear array of N*(N+1)/2 elements.
+
+Upper triangle: element A[i,j], i <= j, is stored in AP[i + j*(j+1)/2]
+
+Lower triangle: element A[i,j], i >= j, is stored in AP[i + j*(2*N-j-1)/2]
+
+Given matrix of size 4:
+
+                    | 11  12  13  14 |
+                    | 21  22  23  24 |
+                    | 31  32  33  34 |
+                    | 41  42  43  44 |
+
+upper triangle is stored as
+
+             AP = [ 11 12 22 13 23 33 14 24 34 44 ]
+
+and lower triangle as
+
+             AP = [ 11 21 31 41 22 32 42 33 43 44 ]
+
+ */
+
+// Symmetric or triangular matrix in packed storage.
+type PackedMatrix struct {
+    n     int
+    flags Flags
+    data  []float64
+}
+
+// Create new N-by-N zero matrix in packed storage. Parameter flags must have
+// either UPPER or LOWER bit set.
+func NewPackedMatrix(N int, flags Flags) *PackedMatrix {
+    return &PackedMatrix{N, flags & (UPPER|LOWER), make([]float64, N*(N+1)/2)}
+}
+
+// Create new packed matrix from upper or lower triangle of square matrix S.
+// Error is returned if S is not square or flags does not have exactly one of
+// UPPER and LOWER set.
+func PackMatrix(S *matrix.FloatMatrix, flags Flags) (*PackedMatrix, error) {
+    if S.Rows() != S.Cols() {
+        return nil, sizeError("PackMatrix", S)
+    }
+    if ! oneOf(flags, UPPER, LOWER) {
+        return nil, flagError("PackMatrix", "UPPER or LOWER")
+    }
+    N := S.Rows()
+    flags &= UPPER|LOWER
+    P := NewPackedMatrix(N, flags)
+    for j := 0; j < N; j++ {
+        k := P.colIndex(j)
+        if flags & UPPER != 0 {
+            for i := 0; i <= j; i++ {
+                P.data[k+i] = S.GetAt(i, j)
+            }
+        } else {
+            for i := j; i < N; i++ {
+                P.data[k+i] = S.GetAt(i, j)
+            }
+        }
+    }
+    return P, nil
+}
+
+// Create new N-by-N matrix with the stored triangle of A. Elements of other
+// triangle are zero.
+func (A *PackedMatrix) Unpack() *matrix.FloatMatrix {
+    S := matrix.FloatZeros(A.n, A.n)
+    for j := 0; j < A.n; j++ {
+        k := A.colIndex(j)
+        if A.flags & UPPER != 0 {
+            for i := 0; i <= j; i++ {
+                S.SetAt(i, j, A.data[k+i])
+            }
+        } else {
+            for i := j; i < A.n; i++ {
+                S.SetAt(i, j, A.data[k+i])
+            }
+        }
+    }
+    return S
+}
+
+// Copy of A.
+func (A *PackedMatrix) Copy() *PackedMatrix {
+    P := &PackedMatrix{A.n, A.flags, make([]float64, len(A.data))}
+    copy(P.data, A.data)
+    return P
+}
+
+// Number of rows and columns.
+func (A *PackedMatrix) Size() int {
+    return A.n
+}
+
+// Stored triangle, UPPER or LOWER.
+func (A *PackedMatrix) Flags() Flags {
+    return A.flags
+}
+
+// The packed data array.
+func (A *PackedMatrix) FloatArray() []float64 {
+    return A.data
+}
+
+// Get element A[i,j]. Indexes in the triangle not stored refer to the symmetric
+// element A[j,i].
+func (A *PackedMatrix) GetAt(i, j int) float64 {
+    return A.data[A.index(i, j)]
+}
+
+// Set element A[i,j]. Indexes in the triangle not stored refer to the symmetric
+// element A[j,i].
+func (A *PackedMatrix) SetAt(i, j int, val float64) {
+    A.data[A.index(i, j)] = val
+}
+
+// Index of A[0,j] in data array, element A[i,j] of stored triangle is at colIndex(j)+i.
+func (A *PackedMatrix) colIndex(j int) int {
+    if A.flags & UPPER != 0 {
+        return j*(j+1)/2
+    }
+    return j*(2*A.n-j-1)/2
+}
+
+func (A *PackedMatrix) index(i, j int) int {
+    if (A.flags & UPPER != 0 && i > j) || (A.flags & UPPER == 0 && i < j) {
+        i, j = j, i
+    }
+    return A.colIndex(j) + i
+}
+
+// Leading k-by-k submatrix of upper packed matrix or trailing k-by-k submatrix
+// of lower packed matrix. Shares data with A.
+func (A *PackedMatrix) subPacked(k int) *PackedMatrix {
+    if A.flags & UPPER != 0 {
+        return &PackedMatrix{k, A.flags, A.data[:k*(k+1)/2]}
+    }
+    return &PackedMatrix{k, A.flags, A.data[len(A.data)-k*(k+1)/2:]}
+}
+
+// Y = alpha*A*X + beta*Y for symmetric packed A (blas.SPMV)
+func spmv(Yr []float64, incY int, A *PackedMatrix, Xr []float64, incX int, alpha, beta float64) {
+    N := A.n
+    if beta != 1.0 {
+        for i := 0; i < N; i++ {
+            Yr[i*incY] *= beta
+        }
+    }
+    Ar := A.data
+    for j := 0; j < N; j++ {
+        temp1 := alpha * Xr[j*incX]
+        temp2 := 0.0
+        k := A.colIndex(j)
+        if A.flags & UPPER != 0 {
+            for i := 0; i < j; i++ {
+                Yr[i*incY] += temp1 * Ar[k+i]
+                temp2 += Ar[k+i] * Xr[i*incX]
+            }
+            Yr[j*incY] += temp1 * Ar[k+j] + alpha * temp2
+        } else {
+            Yr[j*incY] += temp1 * Ar[k+j]
+            for i := j+1; i < N; i++ {
+                Yr[i*incY] += temp1 * Ar[k+i]
+                temp2 += Ar[k+i] * Xr[i*incX]
+            }
+            Yr[j*incY] += alpha * temp2
+        }
+    }
+}
+
+// A = A + alpha*X*X.T + alpha*Y*X.T for symmetric packed A (blas.SPR2),
+// if Yr is nil then A = A + alpha*X*X.T (blas.SPR)
+func spr2(A *PackedMatrix, Xr []float64, incX int, Yr []float64, incY int, alpha float64) {
+    N := A.n
+    Ar := A.data
+    for j := 0; j < N; j++ {
+        k := A.colIndex(j)
+        i0, i1 := j, N
+        if A.flags & UPPER != 0 {
+            i0, i1 = 0, j+1
+        }
+        if Yr == nil {
+            temp := alpha * Xr[j*incX]
+            if temp == 0.0 {
+                continue
+            }
+            for i := i0; i < i1; i++ {
+                Ar[k+i] += Xr[i*incX] * temp
+            }
+            continue
+        }
+        temp1 := alpha * Yr[j*incY]
+        temp2 := alpha * Xr[j*incX]
+        for i := i0; i < i1; i++ {
+            Ar[k+i] += Xr[i*incX] * temp1 + Yr[i*incY] * temp2
+        }
+    }
+}
+
+// X = A*X or X = A.T*X for triangular packed A (blas.TPMV)
+func tpmv(Xr []float64, incX int, A *PackedMatrix, flags Flags) {
+    N := A.n
+    Ar := A.data
+    unit := flags & UNIT != 0
+
+    switch {
+    case A.flags & UPPER != 0 && flags & TRANSA == 0:
+        for j := 0; j < N; j++ {
+            k := A.colIndex(j)
+            temp := Xr[j*incX]
+            for i := 0; i < j; i++ {
+                Xr[i*incX] += temp * Ar[k+i]
+            }
+            if ! unit {
+                Xr[j*incX] *= Ar[k+j]
+            }
+        }
+    case A.flags & UPPER != 0:
+        for j := N-1; j >= 0; j-- {
+            k := A.colIndex(j)
+            temp := Xr[j*incX]
+            if ! unit {
+                temp *= Ar[k+j]
+            }
+            for i := j-1; i >= 0; i-- {
+                temp += Ar[k+i] * Xr[i*incX]
+            }
+            Xr[j*incX] = temp
+        }
+    case flags & TRANSA == 0:
+        for j := N-1; j >= 0; j-- {
+            k := A.colIndex(j)
+            temp := Xr[j*incX]
+            for i := N-1; i > j; i-- {
+                Xr[i*incX] += temp * Ar[k+i]
+            }
+            if ! unit {
+                Xr[j*incX] *= Ar[k+j]
+            }
+        }
+    default:
+        for j := 0; j < N; j++ {
+            k := A.colIndex(j)
+            temp := Xr[j*incX]
+            if ! unit {
+                temp *= Ar[k+j]
+            }
+            for i := j+1; i < N; i++ {
+                temp += Ar[k+i] * Xr[i*incX]
+            }
+            Xr[j*incX] = temp
+        }
+    }
+}
+
+// X = A.-1*X or X = A.-T*X for triangular packed A (blas.TPSV)
+func tpsv(Xr []float64, incX int, A *PackedMatrix, flags Flags) {
+    N := A.n
+    Ar := A.data
+    unit := flags & UNIT != 0
+
+    switch {
+    case A.flags & UPPER != 0 && flags & TRANSA == 0:
+        for j := N-1; j >= 0; j-- {
+            k := A.colIndex(j)
+            if ! unit {
+                Xr[j*incX] /= Ar[k+j]
+            }
+            temp := Xr[j*incX]
+            for i := j-1; i >= 0; i-- {
+                Xr[i*incX] -= temp * Ar[k+i]
+            }
+        }
+    case A.flags & UPPER != 0:
+        for j := 0; j < N; j++ {
+            k := A.colIndex(j)
+            temp := Xr[j*incX]
+            for i := 0; i < j; i++ {
+                temp -= Ar[k+i] * Xr[i*incX]
+            }
+            if ! unit {
+                temp /= Ar[k+j]
+            }
+            Xr[j*incX] = temp
+        }
+    case flags & TRANSA == 0:
+        for j := 0; j < N; j++ {
+            k := A.colIndex(j)
+            if ! unit {
+                Xr[j*incX] /= Ar[k+j]
+            }
+            temp := Xr[j*incX]
+            for i := j+1; i < N; i++ {
+                Xr[i*incX] -= temp * Ar[k+i]
+            }
+        }
+    default:
+        for j := N-1; j >= 0; j-- {
+            k := A.colIndex(j)
+            temp := Xr[j*incX]
+            for i := N-1; i > j; i-- {
+                temp -= Ar[k+i] * Xr[i*incX]
+            }
+            if ! unit {
+                temp /= Ar[k+j]
+            }
+            Xr[j*incX] = temp
+        }
+    }
+}
+
+func checkPackedVector(op string, X *matrix.FloatMatrix, A *PackedMatrix) error {
+    if ! isVector(X) || X.NumElements() != A.n {
+        return sizeError(op, X, shape{A.n, A.n})
+    }
+    return nil
+}
+
+// Compute
+//      Y = alpha*A*X + beta*Y
+//
+//    A is N*N symmetric matrix in packed storage,
+//    X, Y are row or column vectors of length N.
+// (blas.SPMV)
+func MVMultSymPacked(Y *matrix.FloatMatrix, A *PackedMatrix, X *matrix.FloatMatrix, alpha, beta float64) error {
+    if err := checkPackedVector("MVMultSymPacked", X, A); err != nil {
+        return err
+    }
+    if err := checkPackedVector("MVMultSymPacked", Y, A); err != nil {
+        return err
+    }
+    Xr, incX := vectorData(X)
+    Yr, incY := vectorData(Y)
+    spmv(Yr, incY, A, Xr, incX, alpha, beta)
+    return nil
+}
+
+// Symmetric packed matrix rank update; A = A + alpha*X*X.T
+//   A is N*N symmetric matrix in packed storage,
+//   X is row or column vector of length N.
+// (blas.SPR)
+func MVRankUpdateSymPacked(A *PackedMatrix, X *matrix.FloatMatrix, alpha float64) error {
+    if err := checkPackedVector("MVRankUpdateSymPacked", X, A); err != nil {
+        return err
+    }
+    Xr, incX := vectorData(X)
+    spr2(A, Xr, incX, nil, 0, alpha)
+    return nil
+}
+
+// Symmetric packed matrix rank 2 update; A = A + alpha*X*Y.T + alpha*Y*X.T
+//   A is N*N symmetric matrix in packed storage,
+//   X, Y are row or column vectors of length N.
+// (blas.SPR2)
+func MVRankUpdate2SymPacked(A *PackedMatrix, X, Y *matrix.FloatMatrix, alpha float64) error {
+    if err := checkPackedVector("MVRankUpdate2SymPacked", X, A); err != nil {
+        return err
+    }
+    if err := checkPackedVector("MVRankUpdate2SymPacked", Y, A); err != nil {
+        return err
+    }
+    Xr, incX := vectorData(X)
+    Yr, incY := vectorData(Y)
+    spr2(A, Xr, incX, Yr, incY, alpha)
+    return nil
+}
+
+// Triangular packed matrix multiplication; X = A*X or X = A.T*X
+//   A is N*N upper or lower triangular matrix in packed storage,
+//   X is row or column vector of length N.
+// flags
+//   UNIT   A diagonal is unit
+//   TRANSA A is transpose
+// (blas.TPMV)
+func MVMultTrmPacked(X *matrix.FloatMatrix, A *PackedMatrix, flags Flags) error {
+    if err := checkPackedVector("MVMultTrmPacked", X, A); err != nil {
+        return err
+    }
+    Xr, incX := vectorData(X)
+    tpmv(Xr, incX, A, flags)
+    return nil
+}
+
+// Triangular packed matrix solve; X = alpha*A.-1*X or X = alpha*A.-T*X
+//   A is N*N upper or lower triangular matrix in packed storage,
+//   X is row or column vector of length N.
+// flags
+//   UNIT   A diagonal is unit
+//   TRANSA A is transpose
+// (blas.TPSV)
+func MVSolveTrmPacked(X *matrix.FloatMatrix, A *PackedMatrix, alpha float64, flags Flags) error {
+    if err := checkPackedVector("MVSolveTrmPacked", X, A); err != nil {
+        return err
+    }
+    if alpha != 1.0 {
+        Scale(X, alpha)
+    }
+    Xr, incX := vectorData(X)
+    tpsv(Xr, incX, A, flags)
+    return nil
+}
+
+// Local Variables:
+// tab-width: 4
+// indent-tabs-mode: nil
+// End:
@@ -0,0 +1,185 @@
+
+// Copyright (c) Harri Rautila, 2013
+
+// This file is part of github.com/hrautila/matops package. It is free software,
+// distributed under the terms of GNU Lesser General Public License Version 3, or
+// any later version. See the COPYING tile included in this archive.
+
+package matops
+
+import (
+    "github.com/hrautila/matrix"
+    "testing"
+)
+
+func symMatrix(N int) *matrix.FloatMatrix {
+    A := matrix.FloatUniform(N, N)
+    return A.Plus(A.Transpose())
+}
+
+func TestMVMultSymPacked(t *testing.T) {
+    N := 37
+    A := symMatrix(N)
+    X := matrix.FloatUniform(N, 1)
+    for _, flags := range []Flags{LOWER, UPPER} {
+        Ap, _ := PackMatrix(A, flags)
+        Y0 := matrix.FloatUniform(1, N)
+        Y1 := Y0.Copy()
+        MVMult(Y0, A, X, 2.0, 0.5, NOTRANS)
+        MVMultSymPacked(Y1, Ap, X, 2.0, 0.5)
+        Y1.Minus(Y0)
+        t.Logf("flags=%v ||Y(dense) - Y(packed)||_1: %e\n", flags, NormP(Y1, NORM_ONE))
+    }
+}
+
+func TestMVRankUpdateSymPacked(t *testing.T) {
+    N := 37
+    A := symMatrix(N)
+    X := matrix.FloatUniform(N, 1)
+    Y := matrix.FloatUniform(1, N)
+    for _, flags := range []Flags{LOWER, UPPER} {
+        A0 := A.Copy()
+        A1 := A.Copy()
+        Ap, _ := PackMatrix(A, flags)
+        MVRankUpdateSym(A0, X, 2.0, flags)
+        MVRankUpdateSymPacked(Ap, X, 2.0)
+        A0.Minus(Ap.Unpack())
+        MVRankUpdate2Sym(A1, X, Y, 2.0, flags)
+        Ap, _ = PackMatrix(A, flags)
+        MVRankUpdate2SymPacked(Ap, X, Y, 2.0)
+        A1.Minus(Ap.Unpack())
+        if flags & LOWER != 0 {
+            TriL(A0)
+            TriL(A1)
+        } else {
+            TriU(A0)
+            TriU(A1)
+        }
+        t.Logf("flags=%v ||SYR - SPR||_1: %e, ||SYR2 - SPR2||_1: %e\n",
+            flags, NormP(A0, NORM_ONE), NormP(A1, NORM_ONE))
+    }
+}
+
+func TestMVTrmPacked(t *testing.T) {
+    N := 37
+    A := symMatrix(N)
+    for i := 0; i < N; i++ {
+        A.SetAt(i, i, A.GetAt(i, i) + float64(N))
+    }
+    for _, flags := range []Flags{LOWER, LOWER|TRANSA, UPPER, UPPER|TRANSA,
+        LOWER|UNIT, UPPER|UNIT|TRANSA} {
+        T := A.Copy()
+        if flags & LOWER != 0 {
+            TriL(T)
+        } else {
+            TriU(T)
+        }
+        Ap, _ := PackMatrix(T, flags)
+        X0 := matrix.FloatUniform(N, 1)
+        X1 := X0.Copy()
+        MVMultTrm(X0, T, flags)
+        MVMultTrmPacked(X1, Ap, flags & ^(UPPER|LOWER))
+        X1.Minus(X0)
+        nrm := NormP(X1, NORM_ONE)
+        // solve back
+        X0 = matrix.FloatUniform(N, 1)
+        X1 = X0.Copy()
+        MVMultTrmPacked(X1, Ap, flags & ^(UPPER|LOWER))
+        MVSolveTrmPacked(X1, Ap, 1.0, flags & ^(UPPER|LOWER))
+        X1.Minus(X0)
+        t.Logf("flags=%v ||TRMV - TPMV||_1: %e, ||X - A.-1*A*X||_1: %e\n",
+            flags, nrm, NormP(X1, NORM_ONE))
+    }
+}
+
+func TestDecomposeCHOLPacked(t *testing.T) {
+    N := 37
+    A := symMatrix(N)
+    for i := 0; i < N; i++ {
+        A.SetAt(i, i, A.GetAt(i, i) + float64(N))
+    }
+    for _, flags := range []Flags{LOWER, UPPER} {
+        Ap, _ := PackMatrix(A, flags)
+        _, err := DecomposeCHOLPacked(Ap)
+        if err != nil {
+            t.Errorf("flags=%v decompose error: %v\n", flags, err)
+            continue
+        }
+        C := A.Copy()
+        DecomposeCHOL(C, flags, 0)
+        C.Minus(Ap.Unpack())
+        if flags & LOWER != 0 {
+            TriL(C)
+        } else {
+            TriU(C)
+        }
+        X0 := matrix.FloatUniform(N, 2)
+        B := matrix.FloatZeros(N, 2)
+        Mult(B, A, X0, 1.0, 0.0, NOTRANS)
+        SolveCHOLPacked(B, Ap)
+        B.Minus(X0)
+        t.Logf("flags=%v ||C(dense) - C(packed)||_1: %e, ||X - A.-1*(A*X)||_1: %e\n",
+            flags, NormP(C, NORM_ONE), NormP(B, NORM_ONE))
+    }
+}
+
+func TestDecomposeBKPacked(t *testing.T) {
+    N := 37
+    A := symMatrix(N)
+    W := matrix.FloatZeros(N, 2)
+    for _, flags := range []Flags{LOWER, UPPER} {
+        ipiv := make([]int, N)
+        ipivp := make([]int, N)
+        Ap, _ := PackMatrix(A, flags)
+        _, err := DecomposeBKPacked(Ap, ipivp)
+        if err != nil {
+            t.Errorf("flags=%v decompose error: %v\n", flags, err)
+            continue
+        }
+        // compare to unblocked DecomposeBK
+        C := A.Copy()
+        DecomposeBK(C, W, ipiv, flags, 0)
+        C.Minus(Ap.Unpack())
+        if flags & LOWER != 0 {
+            TriL(C)
+        } else {
+            TriU(C)
+        }
+        for k := 0; k < N; k++ {
+            if ipiv[k] != ipivp[k] {
+                t.Errorf("flags=%v pivot %d differs: %d != %d\n", flags, k, ipivp[k], ipiv[k])
+                break
+            }
+        }
+        X0 := matrix.FloatUniform(N, 2)
+        B := matrix.FloatZeros(N, 2)
+        Mult(B, A, X0, 1.0, 0.0, NOTRANS)
+        err = SolveBKPacked(B, Ap, ipivp)
+        if err != nil {
+            t.Errorf("flags=%v solve error: %v\n", flags, err)
+        }
+        B.Minus(X0)
+        t.Logf("flags=%v ||C(BK) - C(packed)||_1: %e, ||X - A.-1*(A*X)||_1: %e\n",
+            flags, NormP(C, NORM_ONE), NormP(B, NORM_ONE))
+    }
+}
+
+func TestPackMatrixArguments(t *testing.T) {
+    A := symMatrix(5)
+    for _, flags := range []Flags{0, UPPER|LOWER, TRANSA} {
+        if Ap, err := PackMatrix(A, flags); Ap != nil || err == nil {
+            t.Errorf("flags=%v: expected error\n", flags)
+        }
+    }
+    if _, err := PackMatrix(matrix.FloatZeros(5, 4), LOWER); err == nil {
+        t.Errorf("non-square matrix: expected error\n")
+    }
+    if Ap, err := PackMatrix(A, LOWER|UNIT); err != nil || ! Ap.Unpack().AllClose(TriL(A.Copy())) {
+        t.Errorf("flags=%v: expected lower triangle, error %v\n", LOWER|UNIT, err)
+    }
+}
+
+// Local Variables:
+// tab-width: 4
+// indent-tabs-mode: nil
+// End:
@@ -7,14 +7,33 @@
 
 package matops
 
-import (
-    "github.com/hrautila/matrix"
-    //"fmt"
-)
+//import "fmt"
 
 // Functions here are support functions for libFLAME-like implementation
 // of various linear algebra algorithms.
 
+// Matrix with row and column count.
+type dimensions interface {
+    Rows() int
+    Cols() int
+}
+
+// Matrix types that can be partitioned; M is a pointer to matrix type T that
+// has SubMatrix() method as matrix.FloatMatrix.
+type partitionable[T any] interface {
+    *T
+    dimensions
+    SubMatrix(B *T, row, col int, sizes ...int) *T
+}
+
+// Allocate matrix headers for partitions declared as variables of type M in
+// generic algorithms.
+func newParts[T any, M partitionable[T]](parts ...*M) {
+    for _, p := range parts {
+        *p = M(new(T))
+    }
+}
+
 type pDirection int
 const (
     pLEFT = iota
@@ -34,7 +53,7 @@ const (
 
  Parameter nb is initial block size for AT (pTOP) or AB (pBOTTOM).  
  */
-func partition2x1(AT, AB, A *matrix.FloatMatrix, nb int, side pDirection) {
+func partition2x1[T any, M partitionable[T]](AT, AB, A M, nb int, side pDirection) {
     if nb > A.Rows() {
         nb = A.Rows()
     }
@@ -57,7 +76,7 @@ func partition2x1(AT, AB, A *matrix.FloatMatrix, nb int, side pDirection) {
                    A2                     A2
 
  */
-func repartition2x1to3x1(AT, A0, A1, A2, A *matrix.FloatMatrix, nb int, pdir pDirection) {
+func repartition2x1to3x1[T any, M partitionable[T]](AT, A0, A1, A2, A M, nb int, pdir pDirection) {
     nT := AT.Rows()
     switch (pdir) {
     case pBOTTOM:
@@ -86,7 +105,7 @@ func repartition2x1to3x1(AT, A0, A1, A2, A *matrix.FloatMatrix, nb int, pdir pDi
                    A2                     A2
 
  */
-func continue3x1to2x1(AT, AB, A0, A1, A *matrix.FloatMatrix, pdir pDirection) {
+func continue3x1to2x1[T any, M partitionable[T]](AT, AB, A0, A1, A M, pdir pDirection) {
     n0 := A0.Rows()
     n1 := A1.Rows()
     switch (pdir) {
@@ -107,7 +126,7 @@ func continue3x1to2x1(AT, AB, A0, A1, A *matrix.FloatMatrix, pdir pDirection) {
  *          AB  
  *
  */
-func merge2x1(ABLK, AT, AB *matrix.FloatMatrix) {
+func merge2x1[T any, M partitionable[T]](ABLK, AT, AB M) {
     AT.SubMatrix(ABLK, 0, 0, AT.Rows()+AB.Rows(), AT.Cols())
 }
 
@@ -117,7 +136,7 @@ func merge2x1(ABLK, AT, AB *matrix.FloatMatrix) {
  * ABLK <--  AL | AR  
  *
  */
-func merge1x2(ABLK, AL, AR *matrix.FloatMatrix) {
+func merge1x2[T any, M partitionable[T]](ABLK, AL, AR M) {
     AL.SubMatrix(ABLK, 0, 0, AL.Rows(), AL.Cols()+AR.Cols())
 }
 
@@ -128,7 +147,7 @@ func merge1x2(ABLK, AL, AR *matrix.FloatMatrix) {
 
  Parameter nb is initial block size for AL (pLEFT) or AR (pRIGHT).  
  */
-func partition1x2(AL, AR, A *matrix.FloatMatrix, nb int, side pDirection) {
+func partition1x2[T any, M partitionable[T]](AL, AR, A M, nb int, side pDirection) {
     if nb > A.Cols() {
         nb = A.Cols()
     }
@@ -152,7 +171,7 @@ func partition1x2(AL, AR, A *matrix.FloatMatrix, nb int, side pDirection) {
 
  Parameter As is left or right block of original 1x2 block.
  */
-func repartition1x2to1x3(AL, A0, A1, A2, A *matrix.FloatMatrix, nb int, pdir pDirection) {
+func repartition1x2to1x3[T any, M partitionable[T]](AL, A0, A1, A2, A M, nb int, pdir pDirection) {
     k := AL.Cols()
     switch (pdir) {
     case pRIGHT:
@@ -181,7 +200,7 @@ func repartition1x2to1x3(AL, A0, A1, A2, A *matrix.FloatMatrix, nb int, pdir pDi
  pLEFT:  AL | AR  <--  A0 | A1 A2 
 
  */
-func continue1x3to1x2(AL, AR, A0, A1, A *matrix.FloatMatrix, pdir pDirection) {
+func continue1x3to1x2[T any, M partitionable[T]](AL, AR, A0, A1, A M, pdir pDirection) {
 
     k := A0.Cols()
     nb := A1.Cols()
@@ -207,7 +226,7 @@ func continue1x3to1x2(AL, AR, A0, A1, A *matrix.FloatMatrix, pdir pDirection) {
  Parameter nb is initial block size for ATL in column direction and mb in row direction.
  ATR and ABL may be nil pointers.
  */
-func partition2x2(ATL, ATR, ABL, ABR, A *matrix.FloatMatrix, mb, nb int, side pDirection) {
+func partition2x2[T any, M partitionable[T]](ATL, ATR, ABL, ABR, A M, mb, nb int, side pDirection) {
     switch (side) {
     case pTOPLEFT:
         A.SubMatrix(ATL, 0, 0,  mb, nb)
@@ -243,8 +262,8 @@ func partition2x2(ATL, ATR, ABL, ABR, A *matrix.FloatMatrix, mb, nb int, side pD
  It is valid to have either the strictly upper or lower submatrices as nil values.
  
  */
-func repartition2x2to3x3(ATL, 
-    A00, A01, A02, A10, A11, A12, A20, A21, A22, A *matrix.FloatMatrix, nb int, pdir pDirection) {
+func repartition2x2to3x3[T any, M partitionable[T]](ATL, 
+    A00, A01, A02, A10, A11, A12, A20, A21, A22, A M, nb int, pdir pDirection) {
 
     k := ATL.Rows()
     switch (pdir) {
@@ -317,9 +336,9 @@ func repartition2x2to3x3(ATL,
 
  New division of ATL, ATR, ABL, ABR defined by diagonal entries A00, A11, A22
  */
-func continue3x3to2x2(
+func continue3x3to2x2[T any, M partitionable[T]](
     ATL, ATR, ABL, ABR, 
-    A00, A11, A22, A *matrix.FloatMatrix, pdir pDirection) {
+    A00, A11, A22, A M, pdir pDirection) {
 
     k := A00.Rows()
     mb := A11.Cols()
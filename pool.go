@@ -0,0 +1,240 @@
+
+// Copyright (c) Harri Rautila, 2013
+
+// This file is part of github.com/hrautila/matops package. It is free software,
+// distributed under the terms of GNU Lesser General Public License Version 3, or
+// any later version. See the COPYING tile included in this archive.
+
+package matops
+
+import (
+    "context"
+    "math"
+    "sync"
+    "sync/atomic"
+)
+
+/*
+ * Executor runs the independent tasks of a parallel matrix operation.
+ *
+ * Execute must not return before all started tasks have finished as tasks
+ * write to the result matrix. When ctx is cancelled tasks not yet started are
+ * skipped and ctx.Err() is returned.
+ */
+type Executor interface {
+    // Number of tasks that can be run in parallel.
+    Workers() int
+    // Run tasks and wait for their completion.
+    Execute(ctx context.Context, tasks []func()) error
+}
+
+/*
+ * Pool is an Executor with fixed number of long running worker goroutines.
+ *
+ * The goroutine calling Execute participates in running the tasks, a pool with
+ * N workers starts N-1 goroutines. Execute may be called concurrently and from
+ * within tasks, workers that are busy are not waited for.
+ */
+type Pool struct {
+    mu      sync.RWMutex
+    closed  bool
+    nworker int
+    jobs    chan *poolJob
+}
+
+type poolJob struct {
+    ctx     context.Context
+    tasks   []func()
+    next    int64
+    skipped int32
+    wg      sync.WaitGroup
+}
+
+// Run tasks of the job until none left.
+func (j *poolJob) run() {
+    for {
+        k := int(atomic.AddInt64(&j.next, 1)) - 1
+        if k >= len(j.tasks) {
+            return
+        }
+        if j.ctx.Err() == nil {
+            j.tasks[k]()
+        } else {
+            atomic.StoreInt32(&j.skipped, 1)
+        }
+        j.wg.Done()
+    }
+}
+
+// Create new pool with nworker workers.
+func NewPool(nworker int) *Pool {
+    if nworker < 1 {
+        nworker = 1
+    }
+    p := &Pool{nworker: nworker, jobs: make(chan *poolJob, nworker)}
+    for k := 1; k < nworker; k++ {
+        go func() {
+            for j := range p.jobs {
+                j.run()
+            }
+        }()
+    }
+    return p
+}
+
+// Number of workers in the pool.
+func (p *Pool) Workers() int {
+    return p.nworker
+}
+
+// Run tasks in the pool and wait for their completion. Returns ctx.Err() if
+// some tasks were skipped because ctx was cancelled.
+func (p *Pool) Execute(ctx context.Context, tasks []func()) error {
+    if err := ctx.Err(); err != nil {
+        return err
+    }
+    j := &poolJob{ctx: ctx, tasks: tasks}
+    j.wg.Add(len(tasks))
+    p.mu.RLock()
+    if ! p.closed {
+        for k := 1; k < p.nworker && k < len(tasks); k++ {
+            select {
+            case p.jobs <- j:
+            default:
+                // all workers busy; caller runs the rest
+            }
+        }
+    }
+    p.mu.RUnlock()
+    j.run()
+    j.wg.Wait()
+    if atomic.LoadInt32(&j.skipped) != 0 {
+        return ctx.Err()
+    }
+    return nil
+}
+
+// Stop the worker goroutines. Execute on closed pool runs all tasks in the
+// calling goroutine.
+func (p *Pool) Close() {
+    p.mu.Lock()
+    defer p.mu.Unlock()
+    if ! p.closed {
+        p.closed = true
+        close(p.jobs)
+    }
+}
+
+// Executor used when none is given; set with NumWorkers().
+var defaultPool *Pool = NewPool(1)
+var defaultMu sync.Mutex
+
+func defaultExecutor() Executor {
+    defaultMu.Lock()
+    defer defaultMu.Unlock()
+    return defaultPool
+}
+
+func executorOrDefault(ex Executor) Executor {
+    if ex == nil {
+        return defaultExecutor()
+    }
+    return ex
+}
+
+// Set the number of workers in the default executor used by functions without
+// explicit Executor argument. Returns the previous number of workers.
+func NumWorkers(newWorkers int) int {
+    defaultMu.Lock()
+    defer defaultMu.Unlock()
+    oldWorkers := defaultPool.Workers()
+    if newWorkers != oldWorkers {
+        defaultPool.Close()
+        defaultPool = NewPool(newWorkers)
+    }
+    return oldWorkers
+}
+
+// Split [start, end) to at most n ranges with starts aligned to multiples of 4.
+// If ctx is cancellable ranges are further divided to at most maxlen long pieces
+// to allow early exit.
+func splitRange(ctx context.Context, start, end, n, maxlen int) [][2]int {
+    ranges := make([][2]int, 0, n)
+    for k := 0; k < n; k++ {
+        s := start + blockIndex4(k, n, end-start)
+        e := start + blockIndex4(k+1, n, end-start)
+        if ctx.Done() != nil && maxlen > 0 {
+            for ; e - s > maxlen; s += maxlen {
+                ranges = append(ranges, [2]int{s, s+maxlen})
+            }
+        }
+        if e > s {
+            ranges = append(ranges, [2]int{s, e})
+        }
+    }
+    return ranges
+}
+
+// Split [0, N) to at most n ranges of triangular matrix rows so that the number
+// of elements in the ranges is roughly equal.
+func splitTriangle(ctx context.Context, N, n int, flags Flags) [][2]int {
+    if ctx.Done() != nil {
+        n *= 4
+    }
+    ranges := make([][2]int, 0, n)
+    s := 0
+    for k := 1; k <= n; k++ {
+        var e int
+        if flags & LOWER != 0 {
+            e = int(float64(N)*math.Sqrt(float64(k)/float64(n)))
+        } else {
+            e = N - int(float64(N)*math.Sqrt(float64(n-k)/float64(n)))
+        }
+        e -= e & 0x3
+        if k == n {
+            e = N
+        }
+        if e > s {
+            ranges = append(ranges, [2]int{s, e})
+            s = e
+        }
+    }
+    return ranges
+}
+
+// Divide cols*rows matrix to tiles for workers of conf.Executor and run worker
+// on each tile.
+func scheduleTiles(ctx context.Context, conf *Config, cols, rows int,
+    worker func(cstart, cend, rstart, rend int)) error {
+
+    ex := conf.Executor
+    colworks, rowworks := divideWork(rows, cols, ex.Workers())
+    tasks := make([]func(), 0, colworks*rowworks)
+    for _, c := range splitRange(ctx, 0, cols, colworks, 4*conf.NB) {
+        for _, r := range splitRange(ctx, 0, rows, rowworks, 0) {
+            cs, ce, rs, re := c[0], c[1], r[0], r[1]
+            tasks = append(tasks, func() { worker(cs, ce, rs, re) })
+        }
+    }
+    return ex.Execute(ctx, tasks)
+}
+
+// Divide [0, n) to ranges for workers of ex and run worker on each range.
+func scheduleRanges(ctx context.Context, ex Executor, n int, worker func(start, end int)) error {
+    return scheduleList(ctx, ex, splitRange(ctx, 0, n, ex.Workers(), 4*globalParams().NB), worker)
+}
+
+// Run worker on each of the ranges.
+func scheduleList(ctx context.Context, ex Executor, ranges [][2]int, worker func(start, end int)) error {
+    tasks := make([]func(), len(ranges))
+    for k, r := range ranges {
+        s, e := r[0], r[1]
+        tasks[k] = func() { worker(s, e) }
+    }
+    return ex.Execute(ctx, tasks)
+}
+
+// Local Variables:
+// tab-width: 4
+// indent-tabs-mode: nil
+// End:
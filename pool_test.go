@@ -0,0 +1,233 @@
+
+// Copyright (c) Harri Rautila, 2013
+
+// This file is part of github.com/hrautila/matops package. It is free software,
+// distributed under the terms of GNU Lesser General Public License Version 3, or
+// any later version. See the COPYING tile included in this archive.
+
+package matops
+
+import (
+    "github.com/hrautila/matrix"
+    "context"
+    "testing"
+)
+
+func TestPoolMult(t *testing.T) {
+    N := 300
+    pool := NewPool(4)
+    defer pool.Close()
+    ctx := context.Background()
+    A := matrix.FloatUniform(N, N)
+    B := matrix.FloatUniform(N, N)
+    for _, flags := range []Flags{NOTRANS, TRANSA, TRANSB, TRANSA|TRANSB} {
+        C0 := matrix.FloatUniform(N, N)
+        C1 := C0.Copy()
+        Mult(C0, A, B, 2.0, 0.5, flags)
+        if err := MultContext(ctx, pool, C1, A, B, 2.0, 0.5, flags); err != nil {
+            t.Errorf("flags=%v: %v\n", flags, err)
+        }
+        C1.Minus(C0)
+        t.Logf("flags=%v ||Mult - MultContext||_1: %e\n", flags, NormP(C1, NORM_ONE))
+    }
+    S := A.Plus(A.Transpose())
+    C0 := matrix.FloatUniform(N, N)
+    C1 := C0.Copy()
+    MultSym(C0, S, B, 2.0, 0.5, LOWER|LEFT)
+    MultSymContext(ctx, pool, C1, S, B, 2.0, 0.5, LOWER|LEFT)
+    C1.Minus(C0)
+    t.Logf("||MultSym - MultSymContext||_1: %e\n", NormP(C1, NORM_ONE))
+}
+
+func TestPoolTrm(t *testing.T) {
+    N := 300
+    pool := NewPool(3)
+    defer pool.Close()
+    ctx := context.Background()
+    A := matrix.FloatUniform(N, N)
+    A.Add(2.0)
+    for _, flags := range []Flags{LEFT|LOWER, LEFT|UPPER|TRANSA, RIGHT|UPPER, RIGHT|LOWER|TRANSA} {
+        B0 := matrix.FloatUniform(N, N)
+        B1 := B0.Copy()
+        MultTrm(B0, A, 2.0, flags)
+        MultTrmContext(ctx, pool, B1, A, 2.0, flags)
+        B1.Minus(B0)
+        nrm := NormP(B1, NORM_ONE)
+        B0 = matrix.FloatUniform(N, N)
+        B1 = B0.Copy()
+        SolveTrm(B0, A, 2.0, flags)
+        SolveTrmContext(ctx, pool, B1, A, 2.0, flags)
+        B1.Minus(B0)
+        t.Logf("flags=%v ||TRMM - parallel||_1: %e, ||TRSM - parallel||_1: %e\n",
+            flags, nrm, NormP(B1, NORM_ONE))
+    }
+}
+
+func TestPoolRankUpdateSym(t *testing.T) {
+    N := 300
+    pool := NewPool(4)
+    defer pool.Close()
+    ctx := context.Background()
+    A := matrix.FloatUniform(N, N-20)
+    for _, flags := range []Flags{LOWER, UPPER, LOWER|TRANSA, UPPER|TRANSA} {
+        C0 := matrix.FloatUniform(N, N)
+        if flags & TRANSA != 0 {
+            A = matrix.FloatUniform(N-20, N)
+        }
+        C1 := C0.Copy()
+        RankUpdateSym(C0, A, 2.0, 0.5, flags)
+        RankUpdateSymContext(ctx, pool, C1, A, 2.0, 0.5, flags)
+        C1.Minus(C0)
+        if flags & LOWER != 0 {
+            TriL(C1)
+        } else {
+            TriU(C1)
+        }
+        t.Logf("flags=%v ||SYRK - parallel||_1: %e\n", flags, NormP(C1, NORM_ONE))
+    }
+}
+
+func TestPoolMV(t *testing.T) {
+    N := 600
+    pool := NewPool(4)
+    defer pool.Close()
+    ctx := context.Background()
+    A := matrix.FloatUniform(N, N)
+    X := matrix.FloatUniform(N, 1)
+    Y := matrix.FloatUniform(1, N)
+    for _, flags := range []Flags{NOTRANS, TRANSA} {
+        Y0 := matrix.FloatUniform(N, 1)
+        Y1 := Y0.Copy()
+        MVMult(Y0, A, X, 2.0, 0.5, flags)
+        MVMultContext(ctx, pool, Y1, A, X, 2.0, 0.5, flags)
+        Y1.Minus(Y0)
+        t.Logf("flags=%v ||GEMV - parallel||_1: %e\n", flags, NormP(Y1, NORM_ONE))
+    }
+    A0 := A.Copy()
+    A1 := A.Copy()
+    MVRankUpdate(A0, X, Y, 2.0)
+    MVRankUpdateContext(ctx, pool, A1, X, Y, 2.0)
+    A1.Minus(A0)
+    t.Logf("||GER - parallel||_1: %e\n", NormP(A1, NORM_ONE))
+
+    for _, flags := range []Flags{LOWER, UPPER} {
+        A0 := A.Copy()
+        A1 := A.Copy()
+        MVRankUpdateSym(A0, X, 2.0, flags)
+        MVRankUpdateSymContext(ctx, pool, A1, X, 2.0, flags)
+        A1.Minus(A0)
+        nrm := NormP(A1, NORM_ONE)
+        A0 = A.Copy()
+        A1 = A.Copy()
+        MVRankUpdate2Sym(A0, X, X.Copy(), 2.0, flags)
+        MVRankUpdate2SymContext(ctx, pool, A1, X, X.Copy(), 2.0, flags)
+        A1.Minus(A0)
+        t.Logf("flags=%v ||SYR - parallel||_1: %e, ||SYR2 - parallel||_1: %e\n",
+            flags, nrm, NormP(A1, NORM_ONE))
+    }
+}
+
+func TestPoolDecompose(t *testing.T) {
+    N := 411
+    nb := 32
+    pool := NewPool(4)
+    defer pool.Close()
+    ctx := context.Background()
+
+    A := matrix.FloatUniform(N, N)
+    piv0 := make([]int, N)
+    piv1 := make([]int, N)
+    LU0, _ := DecomposeLU(A.Copy(), piv0, nb)
+    LU1, _ := DecomposeLUContext(ctx, pool, A.Copy(), piv1, nb)
+    npiv := 0
+    for k := range piv0 {
+        if piv0[k] != piv1[k] {
+            npiv++
+        }
+    }
+    B := matrix.FloatUniform(N, 2)
+    X := B.Copy()
+    SolveLU(X, LU1, piv1, NOTRANS)
+    Mult(B, A, X, -1.0, 1.0, NOTRANS)
+    LU1.Minus(LU0)
+    t.Logf("LU: ||LU - parallel||_1: %e, pivots differ: %d, ||B - A*X||_1: %e\n",
+        NormP(LU1, NORM_ONE), npiv, NormP(B, NORM_ONE))
+
+    S := matrix.FloatZeros(N, N)
+    Mult(S, A, A, 1.0, 0.0, TRANSB)
+    for _, flags := range []Flags{LOWER, UPPER} {
+        C0, _ := DecomposeCHOL(S.Copy(), flags, nb)
+        C1, _ := DecomposeCHOLContext(ctx, pool, S.Copy(), flags, nb)
+        C1.Minus(C0)
+        if flags & LOWER != 0 {
+            TriL(C1)
+        } else {
+            TriU(C1)
+        }
+        t.Logf("CHOL flags=%v ||chol - parallel||_1: %e\n", flags, NormP(C1, NORM_ONE))
+    }
+
+    W := matrix.FloatZeros(N, nb)
+    L0, _ := DecomposeLDL(S.Copy(), W, piv0, UPPER, nb)
+    L1, _ := DecomposeLDLContext(ctx, pool, S.Copy(), W, piv1, UPPER, nb)
+    TriU(L1.Minus(L0))
+    t.Logf("LDL ||ldl - parallel||_1: %e\n", NormP(L1, NORM_ONE))
+
+    for _, M := range []int{N, N+100} {
+        A = matrix.FloatUniform(M, N)
+        tau0 := matrix.FloatZeros(N, 1)
+        tau1 := matrix.FloatZeros(N, 1)
+        Q0, _ := DecomposeQR(A.Copy(), tau0, nil, nb)
+        Q1, _ := DecomposeQRContext(ctx, pool, A.Copy(), tau1, nil, nb)
+        Q1.Minus(Q0)
+        tau1.Minus(tau0)
+        t.Logf("QR M=%d ||qr - parallel||_1: %e, ||tau - parallel||_1: %e\n",
+            M, NormP(Q1, NORM_ONE), NormP(tau1, NORM_ONE))
+    }
+
+    cctx, cancel := context.WithCancel(context.Background())
+    cancel()
+    if _, err := DecomposeLUContext(cctx, pool, A.Copy(), piv1, nb); err != context.Canceled {
+        t.Errorf("cancelled DecomposeLU: expected %v, got %v\n", context.Canceled, err)
+    }
+}
+
+func TestPoolCancel(t *testing.T) {
+    N := 300
+    pool := NewPool(2)
+    defer pool.Close()
+    A := matrix.FloatUniform(N, N)
+    B := matrix.FloatUniform(N, N)
+    C := matrix.FloatZeros(N, N)
+
+    ctx, cancel := context.WithCancel(context.Background())
+    cancel()
+    if err := MultContext(ctx, pool, C, A, B, 1.0, 0.0, NOTRANS); err != context.Canceled {
+        t.Errorf("cancelled Mult: expected %v, got %v\n", context.Canceled, err)
+    }
+    // cancel from within first task; remaining tasks skipped
+    ctx, cancel = context.WithCancel(context.Background())
+    defer cancel()
+    count := 0
+    tasks := make([]func(), 10)
+    for k := range tasks {
+        tasks[k] = func() { count++; cancel() }
+    }
+    if err := NewPool(1).Execute(ctx, tasks); err != context.Canceled || count != 1 {
+        t.Errorf("cancelled Execute: err=%v, %d tasks run\n", err, count)
+    }
+    // nested execution does not deadlock
+    ctx = context.Background()
+    err := pool.Execute(ctx, []func(){
+        func() { MultContext(ctx, pool, C, A, B, 1.0, 0.0, NOTRANS) },
+        func() { MultContext(ctx, pool, C.Copy(), A, B, 1.0, 0.0, NOTRANS) },
+    })
+    if err != nil {
+        t.Errorf("nested execute: %v\n", err)
+    }
+}
+
+// Local Variables:
+// tab-width: 4
+// indent-tabs-mode: nil
+// End:
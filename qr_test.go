@@ -9,6 +9,8 @@ package matops
 
 import (
 	"github.com/hrautila/matrix"
+	"errors"
+	"math"
 	"testing"
 )
 
@@ -174,6 +176,36 @@ func TestSolveLeastSquaresQRT(t *testing.T) {
     t.Logf("||B - A*X0||_1 ~~ 0.0: %e\n",NormP(Bref, NORM_ONE))
 }
 
+func TestSolveMinQR(t *testing.T) {
+    M := 60
+    N := 40
+    K := 30
+    nb := 12
+
+    A := matrix.FloatUniform(M, N)
+    B0 := matrix.FloatUniform(N, K)
+    W := matrix.FloatZeros(N, nb)
+    tau := matrix.FloatZeros(N, 1)
+
+    QR, _ := DecomposeQR(A.Copy(), tau, W, nb)
+    // B holds B0 in top N rows; on exit M-by-K solution X
+    var BT, BB matrix.FloatMatrix
+    B := matrix.FloatZeros(M, K)
+    B.SubMatrix(&BT, 0, 0, N, K)
+    BT.Plus(B0)
+    B.SubMatrix(&BB, N, 0)
+    BB.SetIndexes(1.0)
+    SolveQR(B, QR, tau, W, TRANS, nb)
+
+    // ||B0 - A.T*X||_1; bottom part of B cleared before multiplication with Q
+    Mult(B0, A, B, -1.0, 1.0, TRANSA)
+    nrm := NormP(B0, NORM_ONE)
+    t.Logf("||B - A.T*X||_1: %e\n", nrm)
+    if nrm > 1e-10 {
+        t.Errorf("SolveQR(TRANS): ||B - A.T*X||_1 too large\n")
+    }
+}
+
 func TestSolveMinQRT(t *testing.T) {
     var QR *matrix.FloatMatrix
     var err error
@@ -199,6 +231,141 @@ func TestSolveMinQRT(t *testing.T) {
 }
 
 
+// Full M-by-M Q and M-by-N R of A.
+func fullQR(A *matrix.FloatMatrix) (*matrix.FloatMatrix, *matrix.FloatMatrix) {
+    M, N := A.Rows(), A.Cols()
+    K := imin(M, N)
+    tau := matrix.FloatZeros(K, 1)
+    QR, _ := DecomposeQR(A.Copy(), tau, nil, 0)
+    Q := matrix.FloatZeros(M, M)
+    for j := 0; j < K; j++ {
+        for i := j+1; i < M; i++ {
+            Q.SetAt(i, j, QR.GetAt(i, j))
+        }
+    }
+    taus := matrix.FloatZeros(M, 1)
+    for j := 0; j < K; j++ {
+        taus.SetAt(j, 0, tau.GetAt(j, 0))
+    }
+    BuildQ(Q, taus, nil, 0)
+    return Q, TriU(QR)
+}
+
+// ||A - Q*R||_1 + ||I - Q.T*Q||_1 + ||tril(R, -1)||_1
+func qrUpdateResidual(A, Q, R *matrix.FloatMatrix) float64 {
+    C := A.Copy()
+    Mult(C, Q, R, -1.0, 1.0, NOTRANS)
+    I := matrix.FloatDiagonal(Q.Rows(), 1.0)
+    Mult(I, Q, Q, -1.0, 1.0, TRANSA)
+    d := NormP(C, NORM_ONE) + NormP(I, NORM_ONE)
+    for j := 0; j < R.Cols(); j++ {
+        for i := j+1; i < R.Rows(); i++ {
+            d += math.Abs(R.GetAt(i, j))
+        }
+    }
+    return d
+}
+
+func TestQRUpdate(t *testing.T) {
+    for _, sz := range [][2]int{{30, 12}, {10, 10}, {8, 12}} {
+        M, N := sz[0], sz[1]
+        A := matrix.FloatNormal(M, N)
+        Q, R := fullQR(A)
+        if d := qrUpdateResidual(A, Q, R); d > 1e-12 {
+            t.Fatalf("%dx%d: full QR residual: %e\n", M, N, d)
+        }
+        Q0, R0 := Q.Copy(), R.Copy()
+        // all update functions leave their arguments unchanged
+        unchanged := func(op string, Q, R, Q0, R0 *matrix.FloatMatrix) {
+            if ! Q.AllClose(Q0) || ! R.AllClose(R0) {
+                t.Errorf("%dx%d: %s changed Q or R\n", M, N, op)
+            }
+        }
+        x := matrix.FloatNormal(M, 1)
+        for _, k := range []int{0, N/2, N} {
+            // A1 = [A[:,:k] x A[:,k:]]
+            A1 := matrix.FloatZeros(M, N+1)
+            for j := 0; j < N+1; j++ {
+                for i := 0; i < M; i++ {
+                    switch {
+                    case j < k:
+                        A1.SetAt(i, j, A.GetAt(i, j))
+                    case j == k:
+                        A1.SetAt(i, j, x.GetAt(i, 0))
+                    default:
+                        A1.SetAt(i, j, A.GetAt(i, j-1))
+                    }
+                }
+            }
+            Q1, R1, err := QRInsertCol(Q, R, x, k)
+            if err != nil {
+                t.Fatalf("QRInsertCol: %v\n", err)
+            }
+            unchanged("QRInsertCol", Q, R, Q0, R0)
+            d := qrUpdateResidual(A1, Q1, R1)
+            t.Logf("%dx%d k=%d: QRInsertCol residual: %e\n", M, N, k, d)
+            if d > 1e-12 {
+                t.Errorf("%dx%d k=%d: QRInsertCol residual too large\n", M, N, k)
+            }
+            // deleting the inserted column gives factorization of A
+            Q1c, R1c := Q1.Copy(), R1.Copy()
+            Q2, R2, err := QRDeleteCol(Q1, R1, k)
+            if err != nil {
+                t.Fatalf("QRDeleteCol: %v\n", err)
+            }
+            unchanged("QRDeleteCol", Q1, R1, Q1c, R1c)
+            if d := qrUpdateResidual(A, Q2, R2); d > 1e-12 {
+                t.Errorf("%dx%d k=%d: QRDeleteCol residual: %e\n", M, N, k, d)
+            }
+        }
+
+        y := matrix.FloatNormal(1, N)
+        for _, k := range []int{0, M/2, M} {
+            // A1 = [A[:k,:]; y; A[k:,:]]
+            A1 := matrix.FloatZeros(M+1, N)
+            for i := 0; i < M+1; i++ {
+                for j := 0; j < N; j++ {
+                    switch {
+                    case i < k:
+                        A1.SetAt(i, j, A.GetAt(i, j))
+                    case i == k:
+                        A1.SetAt(i, j, y.GetAt(0, j))
+                    default:
+                        A1.SetAt(i, j, A.GetAt(i-1, j))
+                    }
+                }
+            }
+            Q1, R1, err := QRInsertRow(Q, R, y, k)
+            if err != nil {
+                t.Fatalf("QRInsertRow: %v\n", err)
+            }
+            unchanged("QRInsertRow", Q, R, Q0, R0)
+            d := qrUpdateResidual(A1, Q1, R1)
+            t.Logf("%dx%d k=%d: QRInsertRow residual: %e\n", M, N, k, d)
+            if d > 1e-12 {
+                t.Errorf("%dx%d k=%d: QRInsertRow residual too large\n", M, N, k)
+            }
+            Q1c, R1c := Q1.Copy(), R1.Copy()
+            Q2, R2, err := QRDeleteRow(Q1, R1, k)
+            if err != nil {
+                t.Fatalf("QRDeleteRow: %v\n", err)
+            }
+            unchanged("QRDeleteRow", Q1, R1, Q1c, R1c)
+            if d := qrUpdateResidual(A, Q2, R2); d > 1e-12 {
+                t.Errorf("%dx%d k=%d: QRDeleteRow residual: %e\n", M, N, k, d)
+            }
+        }
+
+        var se *SizeMismatchError
+        if _, _, err := QRInsertCol(Q, R, matrix.FloatNormal(M+1, 1), 0); ! errors.As(err, &se) {
+            t.Errorf("QRInsertCol: expected *SizeMismatchError, got %v\n", err)
+        }
+        if _, _, err := QRDeleteRow(Q, R, M); err == nil {
+            t.Errorf("QRDeleteRow: invalid row index not detected\n")
+        }
+    }
+}
+
 // Local Variables:
 // tab-width: 4
 // indent-tabs-mode: nil
@@ -0,0 +1,376 @@
+
+// Copyright (c) Harri Rautila, 2013
+
+// This file is part of github.com/hrautila/matops package. It is free software,
+// distributed under the terms of GNU Lesser General Public License Version 3, or
+// any later version. See the COPYING tile included in this archive.
+
+package matops
+
+import (
+    "github.com/hrautila/matrix"
+    "math"
+    //"fmt"
+)
+
+// Index of largest element in v.
+func imaxIndex(v []float64) int {
+    k := 0
+    for i := 1; i < len(v); i++ {
+        if v[i] > v[k] {
+            k = i
+        }
+    }
+    return k
+}
+
+// Swap columns src and dst of A and corresponding pivot and norm entries.
+func swapPivotCols(A *matrix.FloatMatrix, jpvt []int, vn1, vn2 []float64, src, dst int) {
+    swapCols(A, src, dst)
+    jpvt[src], jpvt[dst] = jpvt[dst], jpvt[src]
+    vn1[src] = vn1[dst]
+    vn2[src] = vn2[dst]
+}
+
+/*
+ * Unblocked QR factorization with column pivoting of trailing matrix
+ * A[off:, off:]. (LAPACK/dlaqp2.f)
+ *
+ * Vectors vn1 and vn2 hold the partial and exact column norms, row vector w
+ * of length N is used as workspace.
+ */
+func unblkQRPivot(A, tau, w *matrix.FloatMatrix, jpvt []int, vn1, vn2 []float64, off int) {
+    var a11, a21, a12, A22, tau1, w1, ac matrix.FloatMatrix
+
+    M := A.Rows()
+    N := A.Cols()
+    tol3z := math.Sqrt(math.Pow(2.0, -53.0))
+
+    for i := off; i < imin(M, N); i++ {
+        // select pivot column
+        p := i + imaxIndex(vn1[i:N])
+        if p != i {
+            swapPivotCols(A, jpvt, vn1, vn2, p, i)
+        }
+
+        // generate reflector H(i)
+        A.SubMatrix(&a11, i, i, 1, 1)
+        A.SubMatrix(&a21, i+1, i, M-i-1, 1)
+        tau.SubMatrix(&tau1, i, 0, 1, 1)
+        computeHouseholder(&a11, &a21, &tau1, LEFT)
+
+        // apply H(i) to A[i:, i+1:] from left
+        if i < N-1 {
+            A.SubMatrix(&a12, i, i+1, 1, N-i-1)
+            A.SubMatrix(&A22, i+1, i+1, M-i-1, N-i-1)
+            w.SubMatrix(&w1, 0, 0, 1, N-i-1)
+            applyHHTo2x1(&tau1, &a21, &a12, &A22, &w1, LEFT)
+        }
+
+        // update partial column norms
+        for j := i+1; j < N; j++ {
+            if vn1[j] == 0.0 {
+                continue
+            }
+            temp := math.Abs(A.GetAt(i, j)) / vn1[j]
+            temp = math.Max(0.0, 1.0 - temp*temp)
+            temp2 := temp * (vn1[j]/vn2[j]) * (vn1[j]/vn2[j])
+            if temp2 <= tol3z {
+                if i < M-1 {
+                    A.SubMatrix(&ac, i+1, j, M-i-1, 1)
+                    vn1[j] = Norm2(&ac)
+                    vn2[j] = vn1[j]
+                } else {
+                    vn1[j] = 0.0
+                    vn2[j] = 0.0
+                }
+            } else {
+                vn1[j] *= math.Sqrt(temp)
+            }
+        }
+    }
+}
+
+/*
+ * Factor at most nb columns of A[off:, off:] with column pivoting and update
+ * the trailing matrix with block reflector. (LAPACK/dlaqps.f)
+ *
+ * Matrix F, (N-off)-by-nb, holds F.T = tau*Y.T*A, the trailing matrix is updated
+ * as A = A - Y*F.T. Returns the number of columns actually factored. The panel
+ * may end early if column norms must be recomputed.
+ */
+func blkQRPivotPanel(A, tau, F *matrix.FloatMatrix, jpvt []int, vn1, vn2 []float64, off, nb int) int {
+    var a11, a21, tau1, v, ac, Ap, Ar, Fp, f0, f1, fk, aux, arow, ap matrix.FloatMatrix
+    var A21, A22, F2 matrix.FloatMatrix
+
+    M := A.Rows()
+    N := A.Cols()
+    n := N - off
+    lastrk := imin(M, N)
+    tol3z := math.Sqrt(math.Pow(2.0, -53.0))
+    auxv := matrix.FloatZeros(nb, 1)
+
+    // head of linked list of columns needing norm recomputation
+    lsticc := -1
+    k := 0
+    for k < nb && lsticc < 0 {
+        rk := off + k
+        c := off + k
+
+        // select pivot column
+        p := c + imaxIndex(vn1[c:N])
+        if p != c {
+            swapPivotCols(A, jpvt, vn1, vn2, p, c)
+            if k > 0 {
+                F.SubMatrix(&f0, p-off, 0, 1, k)
+                F.SubMatrix(&f1, k, 0, 1, k)
+                Swap(&f0, &f1)
+            }
+        }
+
+        // apply previous reflectors to current column
+        // A[rk:, c] = A[rk:, c] - A[rk:, off:c]*F[k, 0:k].T
+        A.SubMatrix(&v, rk, c, M-rk, 1)
+        if k > 0 {
+            A.SubMatrix(&Ap, rk, off, M-rk, k)
+            F.SubMatrix(&f0, k, 0, 1, k)
+            MVMult(&v, &Ap, &f0, -1.0, 1.0, NOTRANS)
+        }
+
+        // generate reflector H(k)
+        A.SubMatrix(&a11, rk, c, 1, 1)
+        A.SubMatrix(&a21, rk+1, c, M-rk-1, 1)
+        tau.SubMatrix(&tau1, c, 0, 1, 1)
+        computeHouseholder(&a11, &a21, &tau1, LEFT)
+        tauval := tau1.GetAt(0, 0)
+        akk := a11.GetAt(0, 0)
+        a11.SetAt(0, 0, 1.0)
+
+        // F[k+1:, k] = tau*A[rk:, c+1:].T*v
+        if c < N-1 {
+            F.SubMatrix(&fk, k+1, k, n-k-1, 1)
+            A.SubMatrix(&Ar, rk, c+1, M-rk, N-c-1)
+            MVMult(&fk, &Ar, &v, tauval, 0.0, TRANSA)
+        }
+        for j := 0; j <= k; j++ {
+            F.SetAt(j, k, 0.0)
+        }
+        // F[:, k] = F[:, k] - tau*F[:, 0:k]*A[rk:, off:c].T*v
+        if k > 0 {
+            auxv.SubMatrix(&aux, 0, 0, k, 1)
+            A.SubMatrix(&Ap, rk, off, M-rk, k)
+            MVMult(&aux, &Ap, &v, -tauval, 0.0, TRANSA)
+            F.SubMatrix(&fk, 0, k, n, 1)
+            F.SubMatrix(&Fp, 0, 0, n, k)
+            MVMult(&fk, &Fp, &aux, 1.0, 1.0, NOTRANS)
+        }
+
+        // update current row
+        // A[rk, c+1:] = A[rk, c+1:] - A[rk, off:c+1]*F[k+1:, 0:k+1].T
+        if c < N-1 {
+            A.SubMatrix(&arow, rk, c+1, 1, N-c-1)
+            A.SubMatrix(&ap, rk, off, 1, k+1)
+            F.SubMatrix(&Fp, k+1, 0, n-k-1, k+1)
+            MVMult(&arow, &Fp, &ap, -1.0, 1.0, NOTRANS)
+        }
+
+        // update partial column norms
+        if rk < lastrk-1 {
+            for j := c+1; j < N; j++ {
+                if vn1[j] == 0.0 {
+                    continue
+                }
+                temp := math.Abs(A.GetAt(rk, j)) / vn1[j]
+                temp = math.Max(0.0, (1.0 + temp)*(1.0 - temp))
+                temp2 := temp * (vn1[j]/vn2[j]) * (vn1[j]/vn2[j])
+                if temp2 <= tol3z {
+                    vn2[j] = float64(lsticc)
+                    lsticc = j
+                } else {
+                    vn1[j] *= math.Sqrt(temp)
+                }
+            }
+        }
+        a11.SetAt(0, 0, akk)
+        k++
+    }
+    kb := k
+    rk := off + kb
+
+    // A[rk:, off+kb:] = A[rk:, off+kb:] - A[rk:, off:off+kb]*F[kb:, 0:kb].T
+    if kb < imin(n, M-off) {
+        A.SubMatrix(&A22, rk, off+kb, M-rk, N-off-kb)
+        A.SubMatrix(&A21, rk, off, M-rk, kb)
+        F.SubMatrix(&F2, kb, 0, n-kb, kb)
+        Mult(&A22, &A21, &F2, -1.0, 1.0, TRANSB)
+    }
+
+    // recompute difficult columns
+    for lsticc >= 0 {
+        next := int(vn2[lsticc])
+        A.SubMatrix(&ac, rk, lsticc, M-rk, 1)
+        vn1[lsticc] = Norm2(&ac)
+        vn2[lsticc] = vn1[lsticc]
+        lsticc = next
+    }
+    return kb
+}
+
+/*
+ * Compute QR factorization with column pivoting of M-by-N matrix A: A*P = Q*R.
+ *
+ * Arguments:
+ *  A     On entry, the M-by-N matrix A. On exit, the elements on and above
+ *        the diagonal contain the min(M,N)-by-N upper trapezoidal matrix R.
+ *        The elements below the diagonal with the vector 'tau', represent
+ *        the ortogonal matrix Q as product of elementary reflectors.
+ *
+ *  tau   On exit, the scalar factors of the elementary reflectors. Column
+ *        vector of at least min(M,N) elements.
+ *
+ *  jpvt  On exit, if jpvt[j] = k, then the j'th column of A*P was the k'th
+ *        column of A. Length of jpvt must be at least N.
+ *
+ *  W     Workspace, N-by-nb matrix used in blocked invocations. If nil
+ *        workspace is allocated.
+ *
+ *  nb    The block size used in blocked invocations. If nb is zero or
+ *        min(M,N) <= nb unblocked algorithm is used.
+ *
+ * Returns:
+ *  Decomposed matrix A and error indicator.
+ *
+ * Compatible with lapack.DGEQP3
+ */
+func DecomposeQRP(A, tau *matrix.FloatMatrix, jpvt []int, W *matrix.FloatMatrix, nb int) (*matrix.FloatMatrix, error) {
+    var ac, F matrix.FloatMatrix
+
+    M := A.Rows()
+    N := A.Cols()
+    mn := imin(M, N)
+    if len(jpvt) < N {
+        return nil, sizeError("DecomposeQRP", A, shape{len(jpvt), 1})
+    }
+    if tau.NumElements() < mn || tau.Cols() != 1 {
+        return nil, sizeError("DecomposeQRP", A, tau)
+    }
+
+    vn1 := make([]float64, N)
+    vn2 := make([]float64, N)
+    for j := 0; j < N; j++ {
+        jpvt[j] = j
+        A.SubMatrix(&ac, 0, j, M, 1)
+        vn1[j] = Norm2(&ac)
+        vn2[j] = vn1[j]
+    }
+
+    j := 0
+    if nb > 0 && nb < mn {
+        if W == nil {
+            W = matrix.FloatZeros(N, nb)
+        } else if W.Rows() < N || W.Cols() < nb {
+            return nil, sizeError("DecomposeQRP", A, W)
+        }
+        for j < mn - nb {
+            W.SubMatrix(&F, 0, 0, N-j, nb)
+            j += blkQRPivotPanel(A, tau, &F, jpvt, vn1, vn2, j, nb)
+        }
+    }
+    if j < mn {
+        w := matrix.FloatZeros(1, N)
+        unblkQRPivot(A, tau, w, jpvt, vn1, vn2, j)
+    }
+    return A, nil
+}
+
+/*
+ * Estimate numerical rank of matrix from the column pivoted QR factorization
+ * computed by DecomposeQRP(). Rank is the number of diagonal elements of R
+ * for which |R[k,k]| > tol*|R[0,0]|. If tol is zero then max(M,N)*eps is used.
+ */
+func RankQRP(A *matrix.FloatMatrix, tol float64) int {
+    mn := imin(A.Rows(), A.Cols())
+    if mn == 0 {
+        return 0
+    }
+    if tol <= 0.0 {
+        tol = float64(imax(A.Rows(), A.Cols())) * math.Pow(2.0, -52.0)
+    }
+    r00 := math.Abs(A.GetAt(0, 0))
+    if r00 == 0.0 {
+        return 0
+    }
+    rank := 1
+    for rank < mn && math.Abs(A.GetAt(rank, rank)) > tol*r00 {
+        rank++
+    }
+    return rank
+}
+
+/*
+ * Compute basic solution to least squares problem min ||A*X - B|| using
+ * the column pivoted QR factorization computed by DecomposeQRP().
+ *
+ *   X = P*[R11.-1*(Q.T*B)[0:rank]; 0]
+ *
+ * Arguments:
+ *  B     On entry, max(M,N)-by-K right hand side matrix B. On exit, the first N
+ *        rows hold the basic solution X.
+ *
+ *  A     The QR factorization as returned by DecomposeQRP().
+ *
+ *  tau   The scalar factors of elementary reflectors.
+ *
+ *  jpvt  The column pivots as returned by DecomposeQRP().
+ *
+ *  rank  Numerical rank of A, as estimated by RankQRP().
+ *
+ *  W     Workspace, K-by-nb matrix used in blocked invocations.
+ *
+ *  nb    The block size used in blocked invocations.
+ */
+func SolveQRP(B, A, tau *matrix.FloatMatrix, jpvt []int, rank int, W *matrix.FloatMatrix, nb int) error {
+    var Ak, tk, Bm, R11, B1, B2, X matrix.FloatMatrix
+
+    M := A.Rows()
+    N := A.Cols()
+    mn := imin(M, N)
+    if B.Rows() < imax(M, N) {
+        return sizeError("SolveQRP", B, A)
+    }
+    if rank < 0 || rank > mn {
+        return onError("SolveQRP: invalid rank")
+    }
+    if nb != 0 && W == nil {
+        W = matrix.FloatZeros(B.Cols(), nb)
+    }
+    // B = Q.T*B
+    A.SubMatrix(&Ak, 0, 0, M, mn)
+    tau.SubMatrix(&tk, 0, 0, mn, 1)
+    B.SubMatrix(&Bm, 0, 0, M, B.Cols())
+    if err := MultQ(&Bm, &Ak, &tk, W, LEFT|TRANS, nb); err != nil {
+        return err
+    }
+
+    // B[0:rank] = R11.-1*B[0:rank], B[rank:N] = 0
+    A.SubMatrix(&R11, 0, 0, rank, rank)
+    B.SubMatrix(&B1, 0, 0, rank, B.Cols())
+    SolveTrm(&B1, &R11, 1.0, UPPER)
+    B.SubMatrix(&B2, rank, 0, N-rank, B.Cols())
+    B2.Scale(0.0)
+
+    // B[0:N] = P*B[0:N]
+    B.SubMatrix(&X, 0, 0, N, B.Cols())
+    Xc := X.Copy()
+    for k := 0; k < N; k++ {
+        for j := 0; j < B.Cols(); j++ {
+            X.SetAt(jpvt[k], j, Xc.GetAt(k, j))
+        }
+    }
+    return nil
+}
+
+// Local Variables:
+// tab-width: 4
+// indent-tabs-mode: nil
+// End:
@@ -0,0 +1,98 @@
+
+// Copyright (c) Harri Rautila, 2013
+
+// This file is part of github.com/hrautila/matops package. It is free software,
+// distributed under the terms of GNU Lesser General Public License Version 3, or
+// any later version. See the COPYING tile included in this archive.
+
+package matops
+
+import (
+    "github.com/hrautila/matrix"
+    "math"
+    "testing"
+)
+
+// Compute ||A*P - Q*R||_1
+func qrpResidual(A, QR, tau *matrix.FloatMatrix, jpvt []int) float64 {
+    var a, ap matrix.FloatMatrix
+    M := A.Rows()
+    N := A.Cols()
+    AP := matrix.FloatZeros(M, N)
+    for j := 0; j < N; j++ {
+        A.SubMatrix(&a, 0, jpvt[j], M, 1)
+        AP.SubMatrix(&ap, 0, j, M, 1)
+        a.CopyTo(&ap)
+    }
+    R := TriU(QR.Copy())
+    MultQ(R, QR, tau, nil, LEFT, 0)
+    AP.Minus(R)
+    return NormP(AP, NORM_ONE)
+}
+
+func TestDecomposeQRP(t *testing.T) {
+    M := 60
+    N := 40
+    for _, nb := range []int{0, 8} {
+        A := matrix.FloatUniform(M, N)
+        tau := matrix.FloatZeros(N, 1)
+        jpvt := make([]int, N)
+        QR, err := DecomposeQRP(A.Copy(), tau, jpvt, nil, nb)
+        if err != nil {
+            t.Errorf("nb=%d decompose error: %v\n", nb, err)
+            continue
+        }
+        t.Logf("nb=%d ||A*P - Q*R||_1: %e\n", nb, qrpResidual(A, QR, tau, jpvt))
+        // diagonal of R non-increasing
+        for k := 1; k < N; k++ {
+            if math.Abs(QR.GetAt(k, k)) > math.Abs(QR.GetAt(k-1, k-1))*(1.0+1e-12) {
+                t.Errorf("nb=%d |R[%d,%d]| > |R[%d,%d]|\n", nb, k, k, k-1, k-1)
+                break
+            }
+        }
+    }
+}
+
+func TestSolveQRPRankDeficient(t *testing.T) {
+    M := 60
+    N := 40
+    rank := 25
+    K := 3
+    var Xn matrix.FloatMatrix
+    for _, nb := range []int{0, 8} {
+        // A = B*C has rank 25
+        A := matrix.FloatZeros(M, N)
+        Mult(A, matrix.FloatUniform(M, rank), matrix.FloatUniform(rank, N), 1.0, 0.0, NOTRANS)
+        X0 := matrix.FloatUniform(N, K)
+        B := matrix.FloatZeros(M, K)
+        Mult(B, A, X0, 1.0, 0.0, NOTRANS)
+
+        tau := matrix.FloatZeros(N, 1)
+        jpvt := make([]int, N)
+        QR, err := DecomposeQRP(A.Copy(), tau, jpvt, nil, nb)
+        if err != nil {
+            t.Errorf("nb=%d decompose error: %v\n", nb, err)
+            continue
+        }
+        t.Logf("nb=%d ||A*P - Q*R||_1: %e\n", nb, qrpResidual(A, QR, tau, jpvt))
+        r := RankQRP(QR, 1e-10)
+        if r != rank {
+            t.Errorf("nb=%d estimated rank %d, expected %d\n", nb, r, rank)
+        }
+        X := B.Copy()
+        err = SolveQRP(X, QR, tau, jpvt, r, nil, nb)
+        if err != nil {
+            t.Errorf("nb=%d solve error: %v\n", nb, err)
+            continue
+        }
+        // ||B - A*X||_1 with consistent right hand side
+        X.SubMatrix(&Xn, 0, 0, N, K)
+        Mult(B, A, &Xn, -1.0, 1.0, NOTRANS)
+        t.Logf("nb=%d ||B - A*X||_1: %e\n", nb, NormP(B, NORM_ONE))
+    }
+}
+
+// Local Variables:
+// tab-width: 4
+// indent-tabs-mode: nil
+// End:
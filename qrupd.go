@@ -0,0 +1,264 @@
+// Copyright (c) Harri Rautila, 2013
+
+// This file is part of github.com/hrautila/matops package. It is free software,
+// distributed under the terms of GNU Lesser General Public License Version 3, or
+// any later version. See the COPYING tile included in this archive.
+
+package matops
+
+import (
+    "github.com/hrautila/matrix"
+)
+
+// Apply plane rotation to rows i and j of A starting from column col.
+func rotateRows(A *matrix.FloatMatrix, i, j, col int, c, s float64) {
+    var a, b matrix.FloatMatrix
+    A.SubMatrix(&a, i, col, 1, A.Cols()-col)
+    A.SubMatrix(&b, j, col, 1, A.Cols()-col)
+    applyGivens(&a, &b, c, s)
+}
+
+// Zero element (j, col) of R with rotation in rows i and j; columns i and j of Q
+// are updated to keep Q*R unchanged.
+func zeroWithGivens(Q, R *matrix.FloatMatrix, i, j, col int) {
+    c, s, r := computeGivens(R.GetAt(i, col), R.GetAt(j, col))
+    R.SetAt(i, col, r)
+    R.SetAt(j, col, 0.0)
+    rotateRows(R, i, j, col+1, c, s)
+    rotateCols(Q, i, j, c, s)
+}
+
+// QR update functions return new matrices Q1 and R1 and leave Q and R unchanged.
+
+// Check that Q is square and matches the rows of R.
+func checkQRUpdate(op string, Q, R *matrix.FloatMatrix) error {
+    if Q.Rows() != Q.Cols() || Q.Rows() != R.Rows() {
+        return sizeError(op, Q, R)
+    }
+    return nil
+}
+
+/*
+ * Update QR factorization A = Q*R when column x is inserted to A before column k.
+ *
+ *   A1 = ( A[:,:k] x A[:,k:] ) = Q1*R1
+ *
+ * Arguments:
+ *  Q     The M-by-M orthogonal matrix Q. Not changed.
+ *
+ *  R     The M-by-N upper trapezoidal matrix R. Not changed.
+ *
+ *  x     Row or column vector of length M. Not changed.
+ *
+ *  k     Column index, 0 <= k <= N.
+ *
+ * Returns:
+ *  New M-by-M matrix Q1, new M-by-(N+1) matrix R1 and error indicator.
+ *
+ * Full orthogonal Q is built with BuildQ() from an M-by-M matrix that holds
+ * the elementary reflectors of DecomposeQR() in its first N columns and scalar
+ * factors extended with zeros to length M.
+ */
+func QRInsertCol(Q, R, x *matrix.FloatMatrix, k int) (*matrix.FloatMatrix, *matrix.FloatMatrix, error) {
+    var w, v, q1, Q2 matrix.FloatMatrix
+    if err := checkQRUpdate("QRInsertCol", Q, R); err != nil {
+        return Q, R, err
+    }
+    M, N := R.Rows(), R.Cols()
+    if ! isVector(x) || x.NumElements() != M {
+        return Q, R, sizeError("QRInsertCol", Q, x)
+    }
+    if k < 0 || k > N {
+        return Q, R, onError("QRInsertCol: invalid column index")
+    }
+    Q1 := Q.Copy()
+    R1 := matrix.FloatZeros(M, N+1)
+    for j := 0; j < N; j++ {
+        jn := j
+        if j >= k {
+            jn = j + 1
+        }
+        for i := 0; i < M && i <= j; i++ {
+            R1.SetAt(i, jn, R.GetAt(i, j))
+        }
+    }
+    // w = Q.T*x is the new column of R1
+    R1.SubMatrix(&w, 0, k, M, 1)
+    MVMult(&w, Q1, columnCopy(x), 1.0, 0.0, TRANSA)
+
+    // rows N: of R1 are zero except column k; annihilate w[N+1:] with reflector
+    if M - N > 1 {
+        tau := matrix.FloatZeros(1, 1)
+        w1 := matrix.FloatZeros(M, 1)
+        R1.SubMatrix(&v, N+1, k, M-N-1, 1)
+        computeHouseholder(R1.SubMatrix(&q1, N, k, 1, 1), &v, tau, LEFT)
+        // Q1 = Q1*H
+        Q1.SubMatrix(&q1, 0, N, M, 1)
+        Q1.SubMatrix(&Q2, 0, N+1, M, M-N-1)
+        applyHHTo2x1(tau, &v, &q1, &Q2, w1, RIGHT)
+        v.SetIndexes(0.0)
+    }
+    // annihilate w[k+1:N+1] from bottom up with rotations
+    for j := imin(N, M-1); j > k; j-- {
+        zeroWithGivens(Q1, R1, j-1, j, k)
+    }
+    return Q1, R1, nil
+}
+
+/*
+ * Update QR factorization A = Q*R when column k is deleted from A.
+ *
+ *   A1 = ( A[:,:k] A[:,k+1:] ) = Q1*R1
+ *
+ * Arguments:
+ *  Q     The M-by-M orthogonal matrix Q. Not changed.
+ *
+ *  R     The M-by-N upper trapezoidal matrix R. Not changed.
+ *
+ *  k     Column index, 0 <= k < N.
+ *
+ * Returns:
+ *  New M-by-M matrix Q1, new M-by-(N-1) matrix R1 and error indicator.
+ */
+func QRDeleteCol(Q, R *matrix.FloatMatrix, k int) (*matrix.FloatMatrix, *matrix.FloatMatrix, error) {
+    if err := checkQRUpdate("QRDeleteCol", Q, R); err != nil {
+        return Q, R, err
+    }
+    M, N := R.Rows(), R.Cols()
+    if k < 0 || k >= N {
+        return Q, R, onError("QRDeleteCol: invalid column index")
+    }
+    Q1 := Q.Copy()
+    R1 := matrix.FloatZeros(M, N-1)
+    for j := 0; j < N-1; j++ {
+        jo := j
+        if j >= k {
+            jo = j + 1
+        }
+        for i := 0; i < M && i <= jo; i++ {
+            R1.SetAt(i, j, R.GetAt(i, jo))
+        }
+    }
+    // R1 is upper Hessenberg from column k; annihilate subdiagonal
+    for j := k; j < N-1 && j < M-1; j++ {
+        zeroWithGivens(Q1, R1, j, j+1, j)
+    }
+    return Q1, R1, nil
+}
+
+/*
+ * Update QR factorization A = Q*R when row x is inserted to A before row k.
+ *
+ *   A1 = ( A[:k,:] )
+ *        (   x     ) = Q1*R1
+ *        ( A[k:,:] )
+ *
+ * Arguments:
+ *  Q     The M-by-M orthogonal matrix Q. Not changed.
+ *
+ *  R     The M-by-N upper trapezoidal matrix R. Not changed.
+ *
+ *  x     Row or column vector of length N. Not changed.
+ *
+ *  k     Row index, 0 <= k <= M.
+ *
+ * Returns:
+ *  New (M+1)-by-(M+1) matrix Q1, new (M+1)-by-N matrix R1 and error indicator.
+ */
+func QRInsertRow(Q, R, x *matrix.FloatMatrix, k int) (*matrix.FloatMatrix, *matrix.FloatMatrix, error) {
+    if err := checkQRUpdate("QRInsertRow", Q, R); err != nil {
+        return Q, R, err
+    }
+    M, N := R.Rows(), R.Cols()
+    if ! isVector(x) || x.NumElements() != N {
+        return Q, R, sizeError("QRInsertRow", R, x)
+    }
+    if k < 0 || k > M {
+        return Q, R, onError("QRInsertRow: invalid row index")
+    }
+    // A1 = P*( A ) = P*( Q 0 )*( R )
+    //          ( x )     ( 0 1 ) ( x )
+    Q1 := matrix.FloatZeros(M+1, M+1)
+    for i := 0; i < M; i++ {
+        in := i
+        if i >= k {
+            in = i + 1
+        }
+        for j := 0; j < M; j++ {
+            Q1.SetAt(in, j, Q.GetAt(i, j))
+        }
+    }
+    Q1.SetAt(k, M, 1.0)
+    R1 := matrix.FloatZeros(M+1, N)
+    for j := 0; j < N; j++ {
+        for i := 0; i < M && i <= j; i++ {
+            R1.SetAt(i, j, R.GetAt(i, j))
+        }
+        R1.SetAt(M, j, x.GetIndex(j))
+    }
+    // annihilate last row of R1
+    for j := 0; j < N && j < M; j++ {
+        zeroWithGivens(Q1, R1, j, M, j)
+    }
+    return Q1, R1, nil
+}
+
+/*
+ * Update QR factorization A = Q*R when row k is deleted from A.
+ *
+ *   A1 = ( A[:k,:]   ) = Q1*R1
+ *        ( A[k+1:,:] )
+ *
+ * Arguments:
+ *  Q     The M-by-M orthogonal matrix Q. Not changed.
+ *
+ *  R     The M-by-N upper trapezoidal matrix R. Not changed.
+ *
+ *  k     Row index, 0 <= k < M.
+ *
+ * Returns:
+ *  New (M-1)-by-(M-1) matrix Q1, new (M-1)-by-N matrix R1 and error indicator.
+ */
+func QRDeleteRow(Q, R *matrix.FloatMatrix, k int) (*matrix.FloatMatrix, *matrix.FloatMatrix, error) {
+    if err := checkQRUpdate("QRDeleteRow", Q, R); err != nil {
+        return Q, R, err
+    }
+    M, N := R.Rows(), R.Cols()
+    if k < 0 || k >= M {
+        return Q, R, onError("QRDeleteRow: invalid row index")
+    }
+    // reduce row k of Q to (+-1, 0, ..., 0) in copies; R becomes upper Hessenberg
+    Q = Q.Copy()
+    R = R.Copy()
+    for j := M-1; j > 0; j-- {
+        c, s, r := computeGivens(Q.GetAt(k, j-1), Q.GetAt(k, j))
+        rotateCols(Q, j-1, j, c, s)
+        Q.SetAt(k, j-1, r)
+        Q.SetAt(k, j, 0.0)
+        rotateRows(R, j-1, j, 0, c, s)
+    }
+    // A = Q*R with Q[k,1:] == 0 and Q[:,0] == +-e_k; drop row k, column 0 of Q
+    // and row 0 of R
+    Q1 := matrix.FloatZeros(M-1, M-1)
+    for i := 0; i < M-1; i++ {
+        io := i
+        if i >= k {
+            io = i + 1
+        }
+        for j := 0; j < M-1; j++ {
+            Q1.SetAt(i, j, Q.GetAt(io, j+1))
+        }
+    }
+    R1 := matrix.FloatZeros(M-1, N)
+    for i := 0; i < M-1; i++ {
+        for j := i; j < N; j++ {
+            R1.SetAt(i, j, R.GetAt(i+1, j))
+        }
+    }
+    return Q1, R1, nil
+}
+
+// Local Variables:
+// tab-width: 4
+// indent-tabs-mode: nil
+// End:
@@ -9,7 +9,7 @@ package matops
 
 import (
     "github.com/hrautila/matrix"
-    "errors"
+    "context"
     //"fmt"
 )
 
@@ -252,6 +252,61 @@ func blockedQR(A, Tvec, Twork, W *matrix.FloatMatrix, nb int) {
     }
 }
 
+/*
+ * Parallel blocked QR decomposition with compact WY transform, run as task graph.
+ *
+ * Panel k factors column block k and builds its block reflector; depends on the
+ * update of the block with panel k-1. Update (k,j) applies block reflector k to
+ * column block j and depends on panel k and update (k-1,j). Rows of W matching
+ * the columns of block j are used as work space of update (k,j).
+ */
+func parallelQR(ctx context.Context, conf *Config, A, Tvec, W *matrix.FloatMatrix, nb int) error {
+    M, N := A.Size()
+    mlen := imin(M, N)
+    npanel := (mlen + nb - 1)/nb
+    nblock := (N + nb - 1)/nb
+    last := make([]*graphTask, nblock)
+    sconf := conf.serial()
+    g := newTaskGraph()
+
+    for k := 0; k < npanel; k++ {
+        k0 := k*nb
+        kb := imin(nb, N-k0)
+        // number of reflectors in panel
+        cb := imin(kb, M-k0)
+        T := matrix.FloatZeros(cb, cb)
+        panel := func() error {
+            var AL, Tdiag, tau matrix.FloatMatrix
+            AL.SubMatrixOf(A, k0, k0, M-k0, kb)
+            unblockedQRT(&AL, T)
+            // copy scaling from T diagonal to tau-vector
+            Tdiag.DiagOf(T)
+            Tvec.SubMatrix(&tau, k0, 0, cb, 1)
+            Tdiag.CopyTo(&tau)
+            return nil
+        }
+        last[k] = g.add(k*npanel+k, panel, last[k])
+
+        for j := k+1; j < nblock; j++ {
+            j0 := j*nb
+            jb := imin(nb, N-j0)
+            update := func() error {
+                var A1, A2, Y1, Y2, Wj matrix.FloatMatrix
+                A.SubMatrix(&A1, k0, j0, cb, jb)
+                A.SubMatrix(&A2, k0+cb, j0, M-k0-cb, jb)
+                A.SubMatrix(&Y1, k0, k0, cb, cb)
+                A.SubMatrix(&Y2, k0+cb, k0, M-k0-cb, cb)
+                W.SubMatrix(&Wj, j0, 0, jb, cb)
+                // update with (I - Y*T*Y.T).T * A'tail
+                updateWithQTConf(sconf, &A1, &A2, &Y1, &Y2, T, &Wj, true)
+                return nil
+            }
+            last[j] = g.add(j*npanel+k, update, last[k], last[j])
+        }
+    }
+    return g.run(ctx, conf.Executor)
+}
+
 // compute:
 //      Q.T*C = (I -Y*T*Y.T).T*C ==  C - Y*(C.T*Y*T).T 
 // or
@@ -264,6 +319,12 @@ func blockedQR(A, Tvec, Twork, W *matrix.FloatMatrix, nb int) {
 // C1 is nb*K, C2 is P*K, Y1 is nb*nb trilu, Y2 is P*nb, T is nb*nb
 // W = K*nb
 func updateWithQT(C1, C2, Y1, Y2, T, W *matrix.FloatMatrix, nb int, transpose bool) {
+    updateWithQTConf(globalConfig(nil), C1, C2, Y1, Y2, T, W, transpose)
+}
+
+// updateWithQT() with matrix-matrix kernels run with configuration conf.
+func updateWithQTConf(conf *Config, C1, C2, Y1, Y2, T, W *matrix.FloatMatrix, transpose bool) {
+    bg := context.Background()
 
     /*
     if transpose && W.Rows() != C1.Cols() {
@@ -276,9 +337,9 @@ func updateWithQT(C1, C2, Y1, Y2, T, W *matrix.FloatMatrix, nb int, transpose bo
     // W = C1.T
     ScalePlus(W, C1, 0.0, 1.0, TRANSB)
     // W = C1.T*Y1
-    MultTrm(W, Y1, 1.0, LOWER|UNIT|RIGHT)
+    multTrmConf(bg, conf, W, Y1, 1.0, LOWER|UNIT|RIGHT)
     // W = W + C2.T*Y2
-    Mult(W, C2, Y2, 1.0, 1.0, TRANSA)
+    multConf(bg, conf, W, C2, Y2, 1.0, 1.0, TRANSA)
 
     // --- here: W == C.T*Y ---
     tflags := UPPER|RIGHT 
@@ -286,14 +347,14 @@ func updateWithQT(C1, C2, Y1, Y2, T, W *matrix.FloatMatrix, nb int, transpose bo
         tflags |= TRANSA
     }
     // W = W*T or W*T.T
-    MultTrm(W, T, 1.0, Flags(tflags))
+    multTrmConf(bg, conf, W, T, 1.0, Flags(tflags))
 
     // --- here: W == C.T*Y*T or C.T*Y*T.T ---
 
     // C2 = C2 - Y2*W.T
-    Mult(C2, Y2, W, -1.0, 1.0, TRANSB)
+    multConf(bg, conf, C2, Y2, W, -1.0, 1.0, TRANSB)
     //  W = Y1*W.T ==> W.T = W*Y1.T
-    MultTrm(W, Y1, 1.0, LOWER|UNIT|TRANSA|RIGHT)
+    multTrmConf(bg, conf, W, Y1, 1.0, LOWER|UNIT|TRANSA|RIGHT)
     
     // C1 = C1 - W.T
     ScalePlus(C1, W, 1.0, -1.0, TRANSB)
@@ -313,15 +374,21 @@ func updateWithQT(C1, C2, Y1, Y2, T, W *matrix.FloatMatrix, nb int, transpose bo
 // C1 is K*nb, C2 is K*P, Y1 is nb*nb trilu, Y2 is P*nb, T is nb*nb
 // W = K*nb
 func updateWithQTRight(C1, C2, Y1, Y2, T, W *matrix.FloatMatrix, nb int, transpose bool) {
+    updateWithQTRightConf(globalConfig(nil), C1, C2, Y1, Y2, T, W, transpose)
+}
+
+// updateWithQTRight() with matrix-matrix kernels run with configuration conf.
+func updateWithQTRightConf(conf *Config, C1, C2, Y1, Y2, T, W *matrix.FloatMatrix, transpose bool) {
+    bg := context.Background()
 
     // -- compute: W = C*Y = C1*Y1 + C2*Y2
 
     // W = C1
     ScalePlus(W, C1, 0.0, 1.0, NOTRANS)
     // W = C1*Y1
-    MultTrm(W, Y1, 1.0, LOWER|UNIT|RIGHT)
+    multTrmConf(bg, conf, W, Y1, 1.0, LOWER|UNIT|RIGHT)
     // W = W + C2*Y2
-    Mult(W, C2, Y2, 1.0, 1.0, NOTRANS)
+    multConf(bg, conf, W, C2, Y2, 1.0, 1.0, NOTRANS)
 
     // --- here: W == C*Y ---
 
@@ -330,15 +397,15 @@ func updateWithQTRight(C1, C2, Y1, Y2, T, W *matrix.FloatMatrix, nb int, transpo
         tflags |= TRANSA
     }
     // W = W*T or W*T.T
-    MultTrm(W, T, 1.0, Flags(tflags))
+    multTrmConf(bg, conf, W, T, 1.0, Flags(tflags))
 
     // --- here: W == C*Y*T or C*Y*T.T ---
 
     // C2 = C2 - W*Y2.T
-    Mult(C2, W, Y2, -1.0, 1.0, TRANSB)
+    multConf(bg, conf, C2, W, Y2, -1.0, 1.0, TRANSB)
     // C1 = C1 - W*Y1.T
     //  W = W*Y1 
-    MultTrm(W, Y1, 1.0, LOWER|UNIT|RIGHT|TRANSA)
+    multTrmConf(bg, conf, W, Y1, 1.0, LOWER|UNIT|RIGHT|TRANSA)
     
     // C1 = C1 - W
     ScalePlus(C1, W, 1.0, -1.0, NOTRANS)
@@ -348,7 +415,7 @@ func updateWithQTRight(C1, C2, Y1, Y2, T, W *matrix.FloatMatrix, nb int, transpo
 
 
 
-func blockedQRT(A, T, W *matrix.FloatMatrix, nb int) {
+func blockedQRT(conf *Config, A, T, W *matrix.FloatMatrix, nb int) {
     var ATL, ATR, ABL, ABR, AL, AR matrix.FloatMatrix
     var A00, A01, A02, A10, A11, A12, A20, A21, A22 matrix.FloatMatrix
     var WT, WB, W0, W1, W2 matrix.FloatMatrix
@@ -395,13 +462,13 @@ func blockedQRT(A, T, W *matrix.FloatMatrix, nb int) {
 
         // update A'tail i.e. A12 and A22 with (I - Y*T*Y.T).T * A'tail
         // compute: Q*T.C == C - Y*(C.T*Y*T).T
-        updateWithQT(&A12, &A22, &A11, &A21, &T11, &W2, cb, true)
+        updateWithQTConf(conf, &A12, &A22, &A11, &A21, &T11, &W2, true)
 
         // update T01: T01 = -T00*Y1.T*Y2*T11 
         //  Y1 = /A10\   Y2 = /A11\
         //       \A20/        \A21/
         //
-        updateQRTReflector(&T01, &A10, &A20, &A11, &A21, &T00, &T11)
+        updateQRTReflector(conf, &T01, &A10, &A20, &A11, &A21, &T00, &T11)
 
         // --------------------------------------------------------
         continue3x3to2x2(
@@ -430,7 +497,8 @@ func blockedQRT(A, T, W *matrix.FloatMatrix, nb int) {
 //  Y11 is nb*nb tridiagonal lower unit diagonal matrix
 //  Y21 is M-K-nb*nb block matrix
 //  
-func updateQRTReflector(T, Y10, Y20, Y11, Y21, T1, T2 *matrix.FloatMatrix) {
+func updateQRTReflector(conf *Config, T, Y10, Y20, Y11, Y21, T1, T2 *matrix.FloatMatrix) {
+    bg := context.Background()
     // T = Y10.T
     if Y10.Cols() == 0 {
         return
@@ -438,15 +506,15 @@ func updateQRTReflector(T, Y10, Y20, Y11, Y21, T1, T2 *matrix.FloatMatrix) {
     // T = Y10.T
     ScalePlus(T, Y10, 0.0, 1.0, TRANSB)
     // T = Y10.T*Y11
-    MultTrm(T, Y11, 1.0, LOWER|UNIT|RIGHT)
+    multTrmConf(bg, conf, T, Y11, 1.0, LOWER|UNIT|RIGHT)
     // T = T + Y20.T*Y21
-    Mult(T, Y20, Y21, 1.0, 1.0, TRANSA)
+    multConf(bg, conf, T, Y20, Y21, 1.0, 1.0, TRANSA)
     // -- here: T == Y1.T*Y2
 
     // T = -T1*T
-    MultTrm(T, T1, -1.0, UPPER)
+    multTrmConf(bg, conf, T, T1, -1.0, UPPER)
     // T = T*T2
-    MultTrm(T, T2, 1.0, UPPER|RIGHT)
+    multTrmConf(bg, conf, T, T2, 1.0, UPPER|RIGHT)
 }
 
 
@@ -472,24 +540,64 @@ func updateQRTReflector(T, Y10, Y20, Y11, Y21, T1, T2 *matrix.FloatMatrix) {
  * DecomposeQR is compatible with lapack.DGEQRF
  */
 func DecomposeQR(A, tau, W *matrix.FloatMatrix, nb int) (*matrix.FloatMatrix, error) {
+    return DecomposeQRContext(context.Background(), nil, A, tau, W, nb)
+}
+
+// QR factorization with blocked invocation run as task graph with the workers
+// of executor ex. If ex is nil the default executor is used. See
+// DecomposeLUContext() and DecomposeQR().
+func DecomposeQRContext(ctx context.Context, ex Executor, A, tau, W *matrix.FloatMatrix,
+    nb int) (*matrix.FloatMatrix, error) {
+
     var err error = nil
-    
+    if err = ctx.Err(); err != nil {
+        return A, err
+    }
+    ex = executorOrDefault(ex)
     if nb == 0 || A.Cols() <= nb {
         unblockedQR(A, tau)
     } else {
-        Twork := matrix.FloatZeros(nb, nb)
         if W == nil {
             W = matrix.FloatZeros(A.Cols(), nb)
         } else if W.Cols() < nb || W.Rows() < A.Cols() {
-            return nil, errors.New("work space too small")
+            return nil, sizeError("DecomposeQR", A, W)
         }
         var Wrk matrix.FloatMatrix
         Wrk.SubMatrixOf(W, 0, 0, A.Cols(), nb)
-        blockedQR(A, tau, Twork, &Wrk, nb)
+        if ex.Workers() > 1 || ctx.Done() != nil {
+            err = parallelQR(ctx, globalConfig(ex), A, tau, &Wrk, nb)
+        } else {
+            Twork := matrix.FloatZeros(nb, nb)
+            blockedQR(A, tau, Twork, &Wrk, nb)
+        }
     }
     return A, err
 }
 
+// QR factorization with block size conf.DecompNB and parameters from conf.
+// Blocked invocation is always run as task graph. See Config and DecomposeQRContext().
+func DecomposeQRConfig(ctx context.Context, conf *Config, A, tau, W *matrix.FloatMatrix) (*matrix.FloatMatrix, error) {
+
+    c, release := conf.resolve()
+    defer release()
+    if err := ctx.Err(); err != nil {
+        return A, err
+    }
+    nb := c.DecompNB
+    if nb == 0 || A.Cols() <= nb {
+        unblockedQR(A, tau)
+        return A, nil
+    }
+    if W == nil {
+        W = matrix.FloatZeros(A.Cols(), nb)
+    } else if W.Cols() < nb || W.Rows() < A.Cols() {
+        return nil, c.sizeError("DecomposeQR", A, W)
+    }
+    var Wrk matrix.FloatMatrix
+    Wrk.SubMatrixOf(W, 0, 0, A.Cols(), nb)
+    return A, parallelQR(ctx, c, A, tau, &Wrk, nb)
+}
+
 /*
  * Compute QR factorization of a M-by-N matrix A using compact WY transformation: A = Q * R,
  * where Q = I - Y*T*Y.T, T is block reflector and Y holds elementary reflectors as lower
@@ -515,6 +623,22 @@ func DecomposeQR(A, tau, W *matrix.FloatMatrix, nb int) (*matrix.FloatMatrix, er
  * DecomposeQRT is compatible with lapack.DGEQRT
  */
 func DecomposeQRT(A, T, W *matrix.FloatMatrix, nb int) (*matrix.FloatMatrix, error) {
+    return decomposeQRT(globalConfig(nil), A, T, W, nb)
+}
+
+// QRT factorization with block size conf.DecompNB and parameters from conf.
+// See Config and DecomposeQRT().
+func DecomposeQRTConfig(ctx context.Context, conf *Config, A, T, W *matrix.FloatMatrix) (*matrix.FloatMatrix, error) {
+
+    c, release := conf.resolve()
+    defer release()
+    if err := ctx.Err(); err != nil {
+        return A, err
+    }
+    return decomposeQRT(c, A, T, W, c.DecompNB)
+}
+
+func decomposeQRT(conf *Config, A, T, W *matrix.FloatMatrix, nb int) (*matrix.FloatMatrix, error) {
     var err error = nil
     if nb == 0 || A.Cols() <= nb {
         unblockedQRT(A, T)
@@ -522,11 +646,11 @@ func DecomposeQRT(A, T, W *matrix.FloatMatrix, nb int) (*matrix.FloatMatrix, err
         if W == nil {
             W = matrix.FloatZeros(A.Cols(), nb)
         } else if W.Cols() < nb || W.Rows() < A.Cols() {
-            return nil, errors.New("work space too small")
+            return nil, conf.sizeError("DecomposeQRT", A, W)
         }
         var Wrk matrix.FloatMatrix
         Wrk.SubMatrixOf(W, 0, 0, A.Cols(), nb)
-        blockedQRT(A, T, &Wrk, nb)
+        blockedQRT(conf, A, T, &Wrk, nb)
     }
     return A, err
 }
@@ -547,7 +671,7 @@ func BuildT(T, A, tau *matrix.FloatMatrix) (*matrix.FloatMatrix, error) {
     var err error = nil
 
     if T.Cols() < A.Cols() || T.Rows() < A.Cols() {
-        return nil, errors.New("reflector matrix T too small")
+        return nil, sizeError("BuildT", T, A)
     }
 
     unblkQRBlockReflector(T, A, tau)
@@ -9,7 +9,6 @@ package matops
 
 import (
     "github.com/hrautila/matrix"
-    "errors"
     //"fmt"
 )
 
@@ -232,11 +231,11 @@ func blockedBuildQT(A, T, W *matrix.FloatMatrix, nb int) error {
 func BuildQ(A, tau, W *matrix.FloatMatrix, nb int) (*matrix.FloatMatrix, error) {
     var err error = nil
     if nb != 0 && W == nil {
-        return nil, errors.New("workspace not defined")
+        return nil, sizeError("BuildQ", A, workDims(W))
     }
     // default is from LEFT
     if nb != 0 && (W.Cols() < nb || W.Rows() < A.Cols()) {
-        return nil, errors.New("workspace too small")
+        return nil, sizeError("BuildQ", A, W)
     }
 
     if nb == 0 {
@@ -281,8 +280,8 @@ func BuildQT(A, T, W *matrix.FloatMatrix, nb int) (*matrix.FloatMatrix, error) {
         nb = A.Cols()
     }
     // default is from LEFT
-    if nb != 0 && (W.Cols() < nb || W.Rows() < A.Cols()) {
-        return nil, errors.New("workspace too small")
+    if W == nil || W.Cols() < nb || W.Rows() < A.Cols() {
+        return nil, sizeError("BuildQT", A, workDims(W))
     }
 
     var Wrk matrix.FloatMatrix
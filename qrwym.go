@@ -9,7 +9,7 @@ package matops
 
 import (
     "github.com/hrautila/matrix"
-    "errors"
+    "context"
     //"fmt"
 )
 
@@ -379,7 +379,7 @@ func blockedMultQRight(C, A, tau, W *matrix.FloatMatrix, nb int, flags Flags) {
  * Blocked version for computing C = Q*C and C = Q.T*C with block reflector.
  *
  */
-func blockedMultQTLeft(C, A, T, W *matrix.FloatMatrix, nb int, flags Flags) {
+func blockedMultQTLeft(conf *Config, C, A, T, W *matrix.FloatMatrix, nb int, flags Flags) {
     var ATL, ATR, ABL, ABR matrix.FloatMatrix
     var A00, A10, A11, A20, A21, A22 matrix.FloatMatrix
     var CT, CB, C0, C1, C2 matrix.FloatMatrix
@@ -445,7 +445,7 @@ func blockedMultQTLeft(C, A, T, W *matrix.FloatMatrix, nb int, flags Flags) {
 
         var Wrk matrix.FloatMatrix
         Wrk.SubMatrixOf(W, 0, 0, C1.Cols(), bsz)
-        updateWithQT(&C1, &C2, &A11, &A21, &T11, &Wrk, nb, transpose)
+        updateWithQTConf(conf, &C1, &C2, &A11, &A21, &T11, &Wrk, transpose)
 
         // --------------------------------------------------------
         continue3x3to2x2(
@@ -466,7 +466,7 @@ func blockedMultQTLeft(C, A, T, W *matrix.FloatMatrix, nb int, flags Flags) {
  * Blocked version for computing C = C*Q and C = C*Q.T with block reflector.
  *
  */
-func blockedMultQTRight(C, A, T, W *matrix.FloatMatrix, nb int, flags Flags) {
+func blockedMultQTRight(conf *Config, C, A, T, W *matrix.FloatMatrix, nb int, flags Flags) {
     var ATL, ATR, ABL, ABR matrix.FloatMatrix
     var A00, A10, A11, A20, A21, A22 matrix.FloatMatrix
     var CL, CR, C0, C1, C2 matrix.FloatMatrix
@@ -530,7 +530,7 @@ func blockedMultQTRight(C, A, T, W *matrix.FloatMatrix, nb int, flags Flags) {
 
         var Wrk matrix.FloatMatrix
         Wrk.SubMatrixOf(W, 0, 0, C1.Rows(), bsz)
-        updateWithQTRight(&C1, &C2, &A11, &A21, &T11, &Wrk, nb, transpose)
+        updateWithQTRightConf(conf, &C1, &C2, &A11, &A21, &T11, &Wrk, transpose)
 
         // --------------------------------------------------------
         continue3x3to2x2(
@@ -573,15 +573,15 @@ func blockedMultQTRight(C, A, T, W *matrix.FloatMatrix, nb int, flags Flags) {
 func MultQ(C, A, tau, W *matrix.FloatMatrix, flags Flags, nb int) error {
     var err error = nil
     if nb != 0 && W == nil {
-        return errors.New("workspace not defined")
+        return sizeError("MultQ", C, A, workDims(W))
     }
     if flags & RIGHT != 0 {
         // from right; C*A or C*A.T
         if C.Cols() != A.Rows() {
-            return errors.New("C*Q: C.Cols != A.Rows")
+            return sizeError("MultQ", C, A)
         }
         if nb != 0 && (W.Cols() < nb || W.Rows() < C.Rows()) {
-            return errors.New("workspace too small")
+            return sizeError("MultQ", C, A, W)
         }
     } else {
         // default is from LEFT; A*C or A.T*C
@@ -591,7 +591,7 @@ func MultQ(C, A, tau, W *matrix.FloatMatrix, flags Flags, nb int) error {
         }
          */
         if nb != 0 && (W.Cols() < nb || W.Rows() < C.Cols()) {
-            return errors.New("workspace too small")
+            return sizeError("MultQ", C, A, W)
         }
     }
     if nb == 0 {
@@ -644,20 +644,34 @@ func MultQ(C, A, tau, W *matrix.FloatMatrix, flags Flags, nb int) error {
  * Compatible with lapack.DGEMQRT
  */
 func MultQT(C, A, T, W *matrix.FloatMatrix, flags Flags, nb int) error {
+    return multQT(globalConfig(nil), C, A, T, W, flags, nb)
+}
+
+// MultQT() with block size conf.DecompNB and parameters from conf. See Config.
+func MultQTConfig(ctx context.Context, conf *Config, C, A, T, W *matrix.FloatMatrix, flags Flags) error {
+    c, release := conf.resolve()
+    defer release()
+    if err := ctx.Err(); err != nil {
+        return err
+    }
+    return multQT(c, C, A, T, W, flags, c.DecompNB)
+}
+
+func multQT(conf *Config, C, A, T, W *matrix.FloatMatrix, flags Flags, nb int) error {
     var err error = nil
     if nb == 0  {
         nb = T.Cols()
     }
     if W == nil {
-        return errors.New("workspace not defined")
+        return conf.sizeError("MultQT", C, A, workDims(W))
     }
     if flags & RIGHT != 0 {
         // from right; C*A or C*A.T
         if C.Cols() != A.Rows() {
-            return errors.New("C*Q: C.Cols != A.Rows")
+            return conf.sizeError("MultQT", C, A)
         }
         if W.Cols() < nb || W.Rows() < C.Rows() {
-            return errors.New("workspace too small")
+            return conf.sizeError("MultQT", C, A, W)
         }
     } else {
         // default is from LEFT; A*C or A.T*C
@@ -667,17 +681,17 @@ func MultQT(C, A, T, W *matrix.FloatMatrix, flags Flags, nb int) error {
         }
          */
         if W.Cols() < nb || W.Rows() < C.Cols() {
-            return errors.New("workspace too small")
+            return conf.sizeError("MultQT", C, A, W)
         }
     }
 
     var Wrk matrix.FloatMatrix
     if flags & RIGHT != 0 {
         Wrk.SubMatrixOf(W, 0, 0, C.Rows(), nb)
-        blockedMultQTRight(C, A, T, &Wrk, nb, flags)
+        blockedMultQTRight(conf, C, A, T, &Wrk, nb, flags)
     } else {
         Wrk.SubMatrixOf(W, 0, 0, C.Cols(), nb)
-        blockedMultQTLeft(C, A, T, &Wrk, nb, flags)
+        blockedMultQTLeft(conf, C, A, T, &Wrk, nb, flags)
     }
     return err
 }
@@ -726,7 +740,7 @@ func SolveQR(B, A, tau, W *matrix.FloatMatrix, flags Flags, nb int) error {
         err = SolveTrm(&BT, &R, 1.0, LEFT|UPPER|TRANSA)
         
         // Clear bottom part of B
-        B.SubMatrixOf(&BT, A.Cols(), 0)
+        B.SubMatrix(&BT, A.Cols(), 0)
         BT.SetIndexes(0.0)
         
         // X = Q*B'
@@ -782,6 +796,20 @@ func SolveQR(B, A, tau, W *matrix.FloatMatrix, flags Flags, nb int) error {
  * Compatible with lapack.GELS (the m >= n part)
  */
 func SolveQRT(B, A, T, W *matrix.FloatMatrix, flags Flags, nb int) error {
+    return solveQRT(context.Background(), globalConfig(nil), B, A, T, W, flags, nb)
+}
+
+// SolveQRT() with block size conf.DecompNB and parameters from conf. See Config.
+func SolveQRTConfig(ctx context.Context, conf *Config, B, A, T, W *matrix.FloatMatrix, flags Flags) error {
+    c, release := conf.resolve()
+    defer release()
+    if err := ctx.Err(); err != nil {
+        return err
+    }
+    return solveQRT(ctx, c, B, A, T, W, flags, c.DecompNB)
+}
+
+func solveQRT(ctx context.Context, conf *Config, B, A, T, W *matrix.FloatMatrix, flags Flags, nb int) error {
     var err error = nil
     var R, BT matrix.FloatMatrix
     if flags & TRANS != 0 {
@@ -790,19 +818,21 @@ func SolveQRT(B, A, T, W *matrix.FloatMatrix, flags Flags, nb int) error {
         // B' = R.-1*B
         A.SubMatrix(&R, 0, 0, A.Cols(), A.Cols())
         B.SubMatrix(&BT, 0, 0, A.Cols(), B.Cols())
-        err = SolveTrm(&BT, &R, 1.0, LEFT|UPPER|TRANSA)
+        if err = solveTrmConf(ctx, conf, &BT, &R, 1.0, LEFT|UPPER|TRANSA); err != nil {
+            return err
+        }
         
         // Clear bottom part of B
         B.SubMatrix(&BT, A.Cols(), 0)
         BT.SetIndexes(0.0)
         
         // X = Q*B'
-        err = MultQT(B, A, T, W, LEFT, nb)
+        err = multQT(conf, B, A, T, W, LEFT, nb)
     } else {
         // solve least square problem min ||A*X - B||
 
         // B' = Q.T*B
-        err = MultQT(B, A, T, W, LEFT|TRANS, nb)
+        err = multQT(conf, B, A, T, W, LEFT|TRANS, nb)
         if err != nil {
             return err
         }
@@ -810,7 +840,7 @@ func SolveQRT(B, A, T, W *matrix.FloatMatrix, flags Flags, nb int) error {
         // X = R.-1*B'
         A.SubMatrix(&R, 0, 0, A.Cols(), A.Cols())
         B.SubMatrix(&BT, 0, 0, A.Cols(), B.Cols())
-        err = SolveTrm(&BT, &R, 1.0, LEFT|UPPER)
+        err = solveTrmConf(ctx, conf, &BT, &R, 1.0, LEFT|UPPER)
     }
     return err
 }
@@ -0,0 +1,301 @@
+
+// Copyright (c) Harri Rautila, 2013
+
+// This file is part of github.com/hrautila/matops package. It is free software,
+// distributed under the terms of GNU Lesser General Public License Version 3, or
+// any later version. See the COPYING tile included in this archive.
+
+package matops
+
+import (
+    "github.com/hrautila/matrix"
+    "math"
+    //"fmt"
+)
+
+// Default maximum number of refinement steps.
+const refineITMAX = 5
+
+/*
+ * Compute w = |b| + |op(A)|*|x| where A is general matrix if flags has no
+ * LOWER or UPPER bit set, otherwise symmetric matrix stored in lower or upper
+ * triangular part. op(A) = A.T if flags&TRANSA.
+ */
+func absMultPlus(w, A, x, b *matrix.FloatMatrix, flags Flags) {
+    N := A.Rows()
+    for i := 0; i < N; i++ {
+        w.SetAt(i, 0, math.Abs(b.GetAt(i, 0)))
+    }
+    for j := 0; j < N; j++ {
+        xj := math.Abs(x.GetAt(j, 0))
+        for i := 0; i < N; i++ {
+            var aij float64
+            switch {
+            case flags & LOWER != 0 && i < j, flags & UPPER != 0 && i > j:
+                aij = A.GetAt(j, i)
+            case flags & TRANSA != 0:
+                aij = A.GetAt(j, i)
+            default:
+                aij = A.GetAt(i, j)
+            }
+            w.SetAt(i, 0, w.GetAt(i, 0) + math.Abs(aij)*xj)
+        }
+    }
+}
+
+/*
+ * Iterative refinement of the solution of op(A)*X = B and computation of
+ * forward and backward error estimates. (LAPACK/dgerfs.f)
+ *
+ * Function solve(x, trans) overwrites column vector x with op(A).-1*x, or with
+ * op(A).-T*x if trans is true, using a factorization of A.
+ */
+func refineSolution(B, X, A *matrix.FloatMatrix, flags Flags, maxIter int,
+    solve func(x *matrix.FloatMatrix, trans bool)) ([]float64, []float64) {
+
+    var b, x matrix.FloatMatrix
+
+    N := A.Rows()
+    K := B.Cols()
+    ferr := make([]float64, K)
+    berr := make([]float64, K)
+    if maxIter <= 0 {
+        maxIter = refineITMAX
+    }
+    // machine parameters
+    nz := float64(N + 1)
+    eps := math.Pow(2.0, -53.0)
+    safmin := math.SmallestNonzeroFloat64 * math.Pow(2.0, 52.0)
+    safe1 := nz * safmin
+    safe2 := safe1 / eps
+
+    r := matrix.FloatZeros(N, 1)
+    w := matrix.FloatZeros(N, 1)
+    symmetric := flags & (LOWER|UPPER) != 0
+
+    for j := 0; j < K; j++ {
+        B.SubMatrix(&b, 0, j, N, 1)
+        X.SubMatrix(&x, 0, j, N, 1)
+
+        count := 1
+        lstres := 3.0
+        for {
+            // r = b - op(A)*x
+            b.CopyTo(r)
+            if symmetric {
+                MultSym(r, A, &x, -1.0, 1.0, flags|LEFT)
+            } else {
+                MVMult(r, A, &x, -1.0, 1.0, flags)
+            }
+            // w = |b| + |op(A)|*|x|; componentwise relative backward error
+            absMultPlus(w, A, &x, &b, flags)
+            s := 0.0
+            for i := 0; i < N; i++ {
+                var t float64
+                if w.GetAt(i, 0) > safe2 {
+                    t = math.Abs(r.GetAt(i, 0)) / w.GetAt(i, 0)
+                } else {
+                    t = (math.Abs(r.GetAt(i, 0)) + safe1) / (w.GetAt(i, 0) + safe1)
+                }
+                s = math.Max(s, t)
+            }
+            berr[j] = s
+
+            // stop if backward error is small enough, it does not decrease
+            // enough or maximum number of steps reached.
+            if berr[j] <= eps || 2.0*berr[j] > lstres || count > maxIter {
+                break
+            }
+            // x = x + op(A).-1*r
+            solve(r, false)
+            Axpy(&x, r, 1.0)
+            lstres = berr[j]
+            count++
+        }
+
+        // bound error with ||abs(inv(op(A)))*(abs(r) + nz*eps*(abs(op(A))*abs(x)+abs(b)))||
+        for i := 0; i < N; i++ {
+            wi := math.Abs(r.GetAt(i, 0)) + nz*eps*w.GetAt(i, 0)
+            if w.GetAt(i, 0) <= safe2 {
+                wi += safe1
+            }
+            w.SetAt(i, 0, wi)
+        }
+        // estimate ||inv(op(A))*diag(w)||_inf as ||diag(w)*inv(op(A)).T||_1
+        mult := func(z *matrix.FloatMatrix) {
+            solve(z, true)
+            for i := 0; i < N; i++ {
+                z.SetAt(i, 0, w.GetAt(i, 0)*z.GetAt(i, 0))
+            }
+        }
+        multT := func(z *matrix.FloatMatrix) {
+            for i := 0; i < N; i++ {
+                z.SetAt(i, 0, w.GetAt(i, 0)*z.GetAt(i, 0))
+            }
+            solve(z, false)
+        }
+        ferr[j] = estimateNorm1(N, mult, multT)
+        xnorm := NormP(&x, NORM_INF)
+        if xnorm != 0.0 {
+            ferr[j] /= xnorm
+        }
+    }
+    return ferr, berr
+}
+
+func checkRefineArgs(op string, B, X, A, F *matrix.FloatMatrix) error {
+    if A.Rows() != A.Cols() || F.Rows() != A.Rows() || F.Cols() != A.Cols() {
+        return sizeError(op, A, F)
+    }
+    if B.Rows() != A.Rows() || X.Rows() != B.Rows() || X.Cols() != B.Cols() {
+        return sizeError(op, B, X, A)
+    }
+    return nil
+}
+
+/*
+ * Improve the computed solution to a system of linear equations A*X = B or
+ * A.T*X = B and compute error bounds for the solution.
+ *
+ * Arguments:
+ *  B       The right hand side matrix B.
+ *
+ *  X       On entry, the solution matrix X as computed by SolveLU(). On exit,
+ *          the improved solution.
+ *
+ *  A       The original N-by-N matrix A.
+ *
+ *  LU      The factors L and U from factorization A = P*L*U as computed by
+ *          DecomposeLU().
+ *
+ *  pivots  The pivot indices from DecomposeLU().
+ *
+ *  flags   If flags&TRANSA system A.T*X = B is solved.
+ *
+ *  maxIter Maximum number of refinement steps. If zero default value of 5 is used.
+ *
+ * Returns:
+ *  Estimated forward error bound and componentwise relative backward error
+ *  for each column of X and error indicator.
+ *
+ * Compatible with lapack.DGERFS
+ */
+func RefineLU(B, X, A, LU *matrix.FloatMatrix, pivots []int, flags Flags, maxIter int) ([]float64, []float64, error) {
+    if err := checkRefineArgs("RefineLU", B, X, A, LU); err != nil {
+        return nil, nil, err
+    }
+    if len(pivots) < A.Rows() {
+        return nil, nil, sizeError("RefineLU", A, shape{len(pivots), 1})
+    }
+    var err error
+    trans := flags & TRANSA
+    solve := func(x *matrix.FloatMatrix, transpose bool) {
+        var e error
+        if transpose {
+            e = SolveLU(x, LU, pivots, trans ^ TRANSA)
+        } else {
+            e = SolveLU(x, LU, pivots, trans)
+        }
+        if e != nil && err == nil {
+            err = e
+        }
+    }
+    ferr, berr := refineSolution(B, X, A, trans, maxIter, solve)
+    if err != nil {
+        return nil, nil, err
+    }
+    return ferr, berr, nil
+}
+
+/*
+ * Improve the computed solution to a system of linear equations A*X = B with
+ * symmetric positive definite matrix A and compute error bounds for the solution.
+ *
+ * Arguments:
+ *  B       The right hand side matrix B.
+ *
+ *  X       On entry, the solution matrix X as computed by SolveCHOLChecked(). On exit,
+ *          the improved solution.
+ *
+ *  A       The original symmetric matrix A, lower or upper triangular part is
+ *          used as indicated by flags.
+ *
+ *  L       The triangular factor U or L as computed by DecomposeCHOL().
+ *
+ *  flags   Indicator bits, LOWER or UPPER.
+ *
+ *  maxIter Maximum number of refinement steps. If zero default value of 5 is used.
+ *
+ * Returns:
+ *  Estimated forward error bound and componentwise relative backward error
+ *  for each column of X and error indicator.
+ *
+ * Compatible with lapack.DPORFS
+ */
+func RefineCHOL(B, X, A, L *matrix.FloatMatrix, flags Flags, maxIter int) ([]float64, []float64, error) {
+    if err := checkRefineArgs("RefineCHOL", B, X, A, L); err != nil {
+        return nil, nil, err
+    }
+    var err error
+    uplo := flags & (LOWER|UPPER)
+    solve := func(x *matrix.FloatMatrix, transpose bool) {
+        if e := SolveCHOLChecked(x, L, uplo); e != nil && err == nil {
+            err = e
+        }
+    }
+    ferr, berr := refineSolution(B, X, A, uplo, maxIter, solve)
+    if err != nil {
+        return nil, nil, err
+    }
+    return ferr, berr, nil
+}
+
+/*
+ * Improve the computed solution to a system of linear equations A*X = B with
+ * symmetric matrix A and compute error bounds for the solution.
+ *
+ * Arguments:
+ *  B       The right hand side matrix B.
+ *
+ *  X       On entry, the solution matrix X as computed by SolveLDLChecked(). On exit,
+ *          the improved solution.
+ *
+ *  A       The original symmetric matrix A, lower or upper triangular part is
+ *          used as indicated by flags.
+ *
+ *  LD      The factor L or U and diagonal D as computed by DecomposeLDL().
+ *
+ *  ipiv    The pivot indices from DecomposeLDL().
+ *
+ *  flags   Indicator bits, LOWER or UPPER.
+ *
+ *  maxIter Maximum number of refinement steps. If zero default value of 5 is used.
+ *
+ * Returns:
+ *  Estimated forward error bound and componentwise relative backward error
+ *  for each column of X and error indicator.
+ *
+ * Compatible with lapack.DSYRFS
+ */
+func RefineLDL(B, X, A, LD *matrix.FloatMatrix, ipiv []int, flags Flags, maxIter int) ([]float64, []float64, error) {
+    if err := checkRefineArgs("RefineLDL", B, X, A, LD); err != nil {
+        return nil, nil, err
+    }
+    var err error
+    uplo := flags & (LOWER|UPPER)
+    solve := func(x *matrix.FloatMatrix, transpose bool) {
+        if e := SolveLDLChecked(x, LD, ipiv, uplo); e != nil && err == nil {
+            err = e
+        }
+    }
+    ferr, berr := refineSolution(B, X, A, uplo, maxIter, solve)
+    if err != nil {
+        return nil, nil, err
+    }
+    return ferr, berr, nil
+}
+
+// Local Variables:
+// tab-width: 4
+// indent-tabs-mode: nil
+// End:
@@ -0,0 +1,124 @@
+
+// Copyright (c) Harri Rautila, 2013
+
+// This file is part of github.com/hrautila/matops package. It is free software,
+// distributed under the terms of GNU Lesser General Public License Version 3, or
+// any later version. See the COPYING tile included in this archive.
+
+package matops
+
+import (
+    "github.com/hrautila/matrix"
+    "math"
+    "testing"
+)
+
+// true forward error ||X - X0||_inf/||X||_inf for column j
+func forwardError(X, X0 *matrix.FloatMatrix, j int) float64 {
+    var x, x0 matrix.FloatMatrix
+    X.SubMatrix(&x, 0, j, X.Rows(), 1)
+    X0.SubMatrix(&x0, 0, j, X0.Rows(), 1)
+    d := x.Copy()
+    d.Minus(&x0)
+    return NormP(d, NORM_INF)/NormP(&x, NORM_INF)
+}
+
+// True forward error is bounded by ferr and componentwise backward error is
+// of order of machine precision.
+func checkRefine(t *testing.T, name string, X, X0 *matrix.FloatMatrix, ferr, berr []float64) {
+    eps := math.Pow(2.0, -52.0)
+    for j := 0; j < X.Cols(); j++ {
+        fe := forwardError(X, X0, j)
+        t.Logf("%s [%d] ferr: %e, berr: %e, true ferr: %e\n", name, j, ferr[j], berr[j], fe)
+        if fe > ferr[j] {
+            t.Errorf("%s [%d]: true forward error %e exceeds bound %e\n", name, j, fe, ferr[j])
+        }
+        if berr[j] > 10.0*eps {
+            t.Errorf("%s [%d]: backward error %e not near eps\n", name, j, berr[j])
+        }
+    }
+}
+
+func TestRefineLU(t *testing.T) {
+    N := 60
+    K := 3
+    nb := 12
+    A := matrix.FloatUniform(N, N)
+    X0 := matrix.FloatUniform(N, K)
+    B := matrix.FloatZeros(N, K)
+    Mult(B, A, X0, 1.0, 0.0, NOTRANS)
+    piv := make([]int, N, N)
+
+    LU, _ := DecomposeLU(A.Copy(), piv, nb)
+    X := B.Copy()
+    SolveLU(X, LU, piv, NOTRANS)
+    ferr, berr, err := RefineLU(B, X, A, LU, piv, NOTRANS, 0)
+    if err != nil {
+        t.Errorf("RefineLU error: %v\n", err)
+        return
+    }
+    checkRefine(t, "RefineLU", X, X0, ferr, berr)
+
+    // transposed system
+    Mult(B, A, X0, 1.0, 0.0, TRANSA)
+    X = B.Copy()
+    SolveLU(X, LU, piv, TRANSA)
+    ferr, berr, err = RefineLU(B, X, A, LU, piv, TRANSA, 0)
+    if err != nil {
+        t.Fatalf("RefineLU A.T error: %v\n", err)
+    }
+    checkRefine(t, "RefineLU A.T", X, X0, ferr, berr)
+
+    if _, _, err = RefineLU(B, X, A, LU, piv[:3], NOTRANS, 0); err == nil {
+        t.Errorf("RefineLU: short pivot array not detected\n")
+    }
+}
+
+func TestRefineCHOL(t *testing.T) {
+    N := 60
+    K := 2
+    nb := 12
+    A0 := matrix.FloatUniform(N, N)
+    A := matrix.FloatZeros(N, N)
+    Mult(A, A0, A0, 1.0, 1.0, TRANSB)
+    X0 := matrix.FloatUniform(N, K)
+    B := matrix.FloatZeros(N, K)
+    Mult(B, A, X0, 1.0, 0.0, NOTRANS)
+
+    L, _ := DecomposeCHOL(TriL(A.Copy()), LOWER, nb)
+    X := B.Copy()
+    SolveCHOL(X, L, LOWER)
+    ferr, berr, err := RefineCHOL(B, X, TriL(A.Copy()), L, LOWER, 0)
+    if err != nil {
+        t.Errorf("RefineCHOL error: %v\n", err)
+        return
+    }
+    checkRefine(t, "RefineCHOL", X, X0, ferr, berr)
+}
+
+func TestRefineLDL(t *testing.T) {
+    N := 60
+    K := 2
+    A0 := matrix.FloatUniform(N, N)
+    A := matrix.FloatZeros(N, N)
+    Mult(A, A0, A0, 1.0, 1.0, TRANSB)
+    X0 := matrix.FloatUniform(N, K)
+    B := matrix.FloatZeros(N, K)
+    Mult(B, A, X0, 1.0, 0.0, NOTRANS)
+    ipiv := make([]int, N, N)
+
+    LD, _ := DecomposeLDL(TriU(A.Copy()), nil, ipiv, UPPER, 0)
+    X := B.Copy()
+    SolveLDL(X, LD, ipiv, UPPER)
+    ferr, berr, err := RefineLDL(B, X, TriU(A.Copy()), LD, ipiv, UPPER, 0)
+    if err != nil {
+        t.Errorf("RefineLDL error: %v\n", err)
+        return
+    }
+    checkRefine(t, "RefineLDL", X, X0, ferr, berr)
+}
+
+// Local Variables:
+// tab-width: 4
+// indent-tabs-mode: nil
+// End:
@@ -0,0 +1,612 @@
+
+// Copyright (c) Harri Rautila, 2013
+
+// This file is part of github.com/hrautila/matops package. It is free software,
+// distributed under the terms of GNU Lesser General Public License Version 3, or
+// any later version. See the COPYING tile included in this archive.
+
+package matops
+
+import (
+    "github.com/hrautila/matrix"
+    //"fmt"
+)
+
+// In RQ factorization of M-by-N matrix A, K = min(M,N), the elementary reflector
+// H(i) is stored on row M-K+i of A left of column N-K+i. The implicit unit element
+// is on column N-K+i and elements right of it are zero.
+
+/*
+ * Copy block of nb elementary reflectors in rows of nb-by-(c+nb) matrix A to
+ * V with explicit unit element on column c+j of row j and zeros right of it.
+ */
+func explicitRQReflectors(V, A *matrix.FloatMatrix) {
+    var a, v matrix.FloatMatrix
+    nb := A.Rows()
+    c := A.Cols() - nb
+    V.SetIndexes(0.0)
+    for j := 0; j < nb; j++ {
+        A.SubMatrix(&a, j, 0, 1, c+j)
+        V.SubMatrix(&v, j, 0, 1, c+j)
+        a.CopyTo(&v)
+        V.SetAt(j, c+j, 1.0)
+    }
+}
+
+/*
+ * like LAPACK/dlarft.f (forward, rowwise)
+ *
+ * Build block reflector T from explicit HH reflectors in rows of V and
+ * coefficients in tau.
+ *
+ * H(1)H(2)...H(k) = I - V.T*T*V
+ */
+func unblkRQBlockReflector(T, V, tau *matrix.FloatMatrix) {
+    var t01, T00, V0, v1 matrix.FloatMatrix
+    for j := 0; j < V.Rows(); j++ {
+        tauval := tau.GetIndex(j)
+        T.SetAt(j, j, tauval)
+        if j == 0 {
+            continue
+        }
+        // t01 = -tau*T00*V0*v1.T
+        T.SubMatrix(&t01, 0, j, j, 1)
+        V.SubMatrix(&V0, 0, 0, j, V.Cols())
+        V.SubMatrix(&v1, j, 0, 1, V.Cols())
+        MVMult(&t01, &V0, &v1, -tauval, 0.0, NOTRANS)
+        T.SubMatrix(&T00, 0, 0, j, j)
+        MVMultTrm(&t01, &T00, UPPER)
+    }
+}
+
+// compute:
+//      P*C   = C - V.T*T*V*C   or  P.T*C = C - V.T*T.T*V*C   if flags&LEFT
+//      C*P   = C - C*V.T*T*V   or  C*P.T = C - C*V.T*T.T*V   if flags&RIGHT
+//
+// where P = I - V.T*T*V. W = C.Cols()*nb (LEFT) or C.Rows()*nb (RIGHT).
+func updateWithRQT(C, V, T, W *matrix.FloatMatrix, flags Flags, transpose bool) {
+    if flags & RIGHT != 0 {
+        // W = C*V.T
+        Mult(W, C, V, 1.0, 0.0, TRANSB)
+        tflags := UPPER|RIGHT
+        if transpose {
+            tflags |= TRANSA
+        }
+        // W = W*T or W*T.T
+        MultTrm(W, T, 1.0, Flags(tflags))
+        // C = C - W*V
+        Mult(C, W, V, -1.0, 1.0, NOTRANS)
+    } else {
+        // W = C.T*V.T
+        Mult(W, C, V, 1.0, 0.0, TRANSA|TRANSB)
+        tflags := UPPER|RIGHT
+        if ! transpose {
+            tflags |= TRANSA
+        }
+        // W = W*T.T or W*T
+        MultTrm(W, T, 1.0, Flags(tflags))
+        // C = C - V.T*W.T
+        Mult(C, V, W, -1.0, 1.0, TRANSA|TRANSB)
+    }
+}
+
+/*
+ * Unblocked RQ decomposition. As implemented in lapack.xGERQ2 subroutine.
+ *
+ * Column vector w of length A.Rows() is used as workspace.
+ */
+func unblockedRQ(A, tau, w *matrix.FloatMatrix) {
+    var a11, a10, a01, A00, tau1, w1 matrix.FloatMatrix
+
+    M := A.Rows()
+    N := A.Cols()
+    K := imin(M, N)
+    for i := K-1; i >= 0; i-- {
+        r := M - K + i
+        c := N - K + i
+        A.SubMatrix(&a11, r, c, 1, 1)
+        A.SubMatrix(&a10, r, 0, 1, c)
+        tau.SubMatrix(&tau1, i, 0, 1, 1)
+        computeHouseholder(&a11, &a10, &tau1, RIGHT)
+
+        // A[0:r, 0:c+1] = A[0:r, 0:c+1]*H
+        A.SubMatrix(&a01, 0, c, r, 1)
+        A.SubMatrix(&A00, 0, 0, r, c)
+        w.SubMatrix(&w1, 0, 0, r, 1)
+        applyHHTo2x1(&tau1, &a10, &a01, &A00, &w1, RIGHT)
+    }
+}
+
+/*
+ * Blocked RQ decomposition with compact WY transform. As implemented
+ * in lapack.xGERQF subroutine. If T is not nil, the K-by-K block reflector
+ * is computed to T.
+ */
+func blockedRQ(A, tau, T, W *matrix.FloatMatrix, nb int) {
+    var AB, AT, Al, Vb, Tw, T12, T22, t1, Wrk matrix.FloatMatrix
+
+    M := A.Rows()
+    N := A.Cols()
+    K := imin(M, N)
+    V := matrix.FloatZeros(nb, N)
+    Twork := matrix.FloatZeros(nb, nb)
+    w := matrix.FloatZeros(M, 1)
+
+    for kk := K; kk > 0; {
+        ib := imin(nb, kk)
+        i := kk - ib
+        r := M - K + i
+        c := N - K + i
+
+        // decompose current block of rows
+        A.SubMatrix(&AB, r, 0, ib, c+ib)
+        tau.SubMatrix(&t1, i, 0, ib, 1)
+        unblockedRQ(&AB, &t1, w)
+
+        V.SubMatrix(&Vb, 0, 0, ib, c+ib)
+        explicitRQReflectors(&Vb, &AB)
+        if T != nil {
+            T.SubMatrix(&Tw, i, i, ib, ib)
+        } else {
+            Twork.SubMatrix(&Tw, 0, 0, ib, ib)
+        }
+        unblkRQBlockReflector(&Tw, &Vb, &t1)
+
+        // update rows above with A'top*(I - V.T*T*V).T
+        if r > 0 {
+            A.SubMatrix(&AT, 0, 0, r, c+ib)
+            W.SubMatrix(&Wrk, 0, 0, r, ib)
+            updateWithRQT(&AT, &Vb, &Tw, &Wrk, RIGHT, true)
+        }
+
+        // update T12: T12 = -T11*V1*V2.T*T22
+        if T != nil && kk < K {
+            T.SubMatrix(&T12, i, kk, ib, K-kk)
+            T.SubMatrix(&T22, kk, kk, K-kk, K-kk)
+            A.SubMatrix(&Al, r+ib, 0, K-kk, c+ib)
+            Mult(&T12, &Vb, &Al, 1.0, 0.0, TRANSB)
+            MultTrm(&T12, &Tw, -1.0, UPPER)
+            MultTrm(&T12, &T22, 1.0, UPPER|RIGHT)
+        }
+        kk = i
+    }
+}
+
+// Build Q in place by applying elementary reflectors to an implied identity
+// matrix. This forms the last rows of Q = H(1)H(2)...H(k)
+//
+// this is compatibe with lapack.DORGR2
+func unblockedBuildRQ(A, tau, w *matrix.FloatMatrix) {
+    var v, a01, A00, a12, tau1, w1 matrix.FloatMatrix
+
+    K := A.Rows()
+    N := A.Cols()
+    for i := 0; i < K; i++ {
+        c := N - K + i
+        A.SubMatrix(&v, i, 0, 1, c)
+        tau.SubMatrix(&tau1, i, 0, 1, 1)
+
+        // apply H(i) to rows above
+        A.SubMatrix(&a01, 0, c, i, 1)
+        A.SubMatrix(&A00, 0, 0, i, c)
+        w.SubMatrix(&w1, 0, 0, i, 1)
+        applyHHTo2x1(&tau1, &v, &a01, &A00, &w1, RIGHT)
+
+        // apply (in-place) current elementary reflector to unit vector
+        v.Scale(-tau1.Float())
+        A.SetAt(i, c, 1.0 - tau1.Float())
+
+        // zero the right part
+        A.SubMatrix(&a12, i, c+1, 1, N-c-1)
+        a12.SetIndexes(0.0)
+    }
+}
+
+// Blocked version of BuildRQ and BuildRQT. If T is nil block reflectors
+// are built from elementary reflectors and scalar coefficients in tau.
+func blockedBuildRQ(A, tau, T, W *matrix.FloatMatrix, nb int) {
+    var AB, AT, AR, Vb, Tw, t1, td, Wrk matrix.FloatMatrix
+
+    K := A.Rows()
+    N := A.Cols()
+    V := matrix.FloatZeros(nb, N)
+    Twork := matrix.FloatZeros(nb, nb)
+    w := matrix.FloatZeros(K, 1)
+
+    for i := 0; i < K; i += nb {
+        ib := imin(nb, K-i)
+        c := N - K + i
+
+        A.SubMatrix(&AB, i, 0, ib, c+ib)
+        tau.SubMatrix(&t1, i, 0, ib, 1)
+        if T != nil {
+            // elementary scalar coefficients on the diagonal
+            T.SubMatrix(&Tw, i, i, ib, ib)
+            Tw.Diag(&td)
+            td.CopyTo(&t1)
+        }
+
+        // update rows above with A'top*(I - V.T*T*V)
+        if i > 0 {
+            V.SubMatrix(&Vb, 0, 0, ib, c+ib)
+            explicitRQReflectors(&Vb, &AB)
+            if T == nil {
+                Twork.SubMatrix(&Tw, 0, 0, ib, ib)
+                unblkRQBlockReflector(&Tw, &Vb, &t1)
+            }
+            A.SubMatrix(&AT, 0, 0, i, c+ib)
+            W.SubMatrix(&Wrk, 0, 0, i, ib)
+            updateWithRQT(&AT, &Vb, &Tw, &Wrk, RIGHT, false)
+        }
+
+        // use unblocked version to compute current block
+        unblockedBuildRQ(&AB, &t1, w)
+
+        // zero right part
+        A.SubMatrix(&AR, i, c+ib, ib, N-c-ib)
+        AR.SetIndexes(0.0)
+    }
+}
+
+/*
+ * Unblocked algorithm for computing C = Q*C, C = Q.T*C, C = C*Q or C = C*Q.T
+ * where Q = H(1)H(2)...H(k).
+ */
+func unblockedMultRQ(C, A, tau, w *matrix.FloatMatrix, flags Flags) {
+    var v, tau1, c1, C0 matrix.FloatMatrix
+
+    K := A.Rows()
+    N := A.Cols()
+    // C*H(1)...H(k) and H(k)...H(1)*C progress forward
+    forward := (flags & RIGHT != 0) == (flags & TRANS == 0)
+    for k := 0; k < K; k++ {
+        i := k
+        if ! forward {
+            i = K - 1 - k
+        }
+        c := N - K + i
+        A.SubMatrix(&v, i, 0, 1, c)
+        tau.SubMatrix(&tau1, i, 0, 1, 1)
+        if flags & RIGHT != 0 {
+            C.SubMatrix(&c1, 0, c, C.Rows(), 1)
+            C.SubMatrix(&C0, 0, 0, C.Rows(), c)
+            applyHHTo2x1(&tau1, &v, &c1, &C0, w, RIGHT)
+        } else {
+            C.SubMatrix(&c1, c, 0, 1, C.Cols())
+            C.SubMatrix(&C0, 0, 0, c, C.Cols())
+            applyHHTo2x1(&tau1, &v, &c1, &C0, w, LEFT)
+        }
+    }
+}
+
+/*
+ * Blocked version for computing C = Q*C, C = Q.T*C, C = C*Q or C = C*Q.T from
+ * elementary reflectors and scalar coefficients or from block reflector T.
+ */
+func blockedMultRQ(C, A, tau, T, W *matrix.FloatMatrix, nb int, flags Flags) {
+    var AB, Vb, Tw, t1, Cb, Wrk matrix.FloatMatrix
+
+    K := A.Rows()
+    N := A.Cols()
+    V := matrix.FloatZeros(nb, N)
+    Twork := matrix.FloatZeros(nb, nb)
+
+    forward := (flags & RIGHT != 0) == (flags & TRANS == 0)
+    transpose := flags & TRANS != 0
+    nblk := (K + nb - 1) / nb
+    for b := 0; b < nblk; b++ {
+        bi := b
+        if ! forward {
+            bi = nblk - 1 - b
+        }
+        i := bi * nb
+        ib := imin(nb, K-i)
+        c := N - K + i
+
+        A.SubMatrix(&AB, i, 0, ib, c+ib)
+        V.SubMatrix(&Vb, 0, 0, ib, c+ib)
+        explicitRQReflectors(&Vb, &AB)
+        if T != nil {
+            T.SubMatrix(&Tw, i, i, ib, ib)
+        } else {
+            tau.SubMatrix(&t1, i, 0, ib, 1)
+            Twork.SubMatrix(&Tw, 0, 0, ib, ib)
+            unblkRQBlockReflector(&Tw, &Vb, &t1)
+        }
+        if flags & RIGHT != 0 {
+            C.SubMatrix(&Cb, 0, 0, C.Rows(), c+ib)
+            W.SubMatrix(&Wrk, 0, 0, C.Rows(), ib)
+        } else {
+            C.SubMatrix(&Cb, 0, 0, c+ib, C.Cols())
+            W.SubMatrix(&Wrk, 0, 0, C.Cols(), ib)
+        }
+        updateWithRQT(&Cb, &Vb, &Tw, &Wrk, flags, transpose)
+    }
+}
+
+/*
+ * Compute RQ factorization of a M-by-N matrix A: A = R * Q.
+ *
+ * Arguments:
+ *  A   On entry, the M-by-N matrix A. On exit, if M <= N, the upper triangle of
+ *      A[0:M, N-M:N] contains the M-by-M upper triangular matrix R; if M >= N,
+ *      the elements on and above the (M-N)'th subdiagonal contain the M-by-N
+ *      upper trapezoidal matrix R. The remaining elements with the vector 'tau',
+ *      represent the ortogonal matrix Q as product of elementary reflectors.
+ *
+ * tau  On exit, the scalar factors of the elemenentary reflectors.
+ *
+ * W    Workspace, M-by-nb matrix used for work space in blocked invocations.
+ *
+ * nb   The block size used in blocked invocations. If nb is zero on
+ *      min(M,N) <= nb unblocked algorithm is used.
+ *
+ * Returns:
+ *      Decomposed matrix A and error indicator.
+ *
+ * DecomposeRQ is compatible with lapack.DGERQF
+ */
+func DecomposeRQ(A, tau, W *matrix.FloatMatrix, nb int) (*matrix.FloatMatrix, error) {
+    K := imin(A.Rows(), A.Cols())
+    if tau.NumElements() < K {
+        return nil, sizeError("DecomposeRQ", A, tau)
+    }
+    if nb == 0 || K <= nb {
+        w := matrix.FloatZeros(A.Rows(), 1)
+        unblockedRQ(A, tau, w)
+        return A, nil
+    }
+    if W == nil {
+        W = matrix.FloatZeros(A.Rows(), nb)
+    } else if W.Cols() < nb || W.Rows() < A.Rows() {
+        return nil, sizeError("DecomposeRQ", A, W)
+    }
+    blockedRQ(A, tau, nil, W, nb)
+    return A, nil
+}
+
+/*
+ * Compute RQ factorization of a M-by-N matrix A using compact WY transformation:
+ * A = R * Q, where Q = I - Y.T*T*Y, T is block reflector and Y holds elementary
+ * reflectors as returned by DecomposeRQ().
+ *
+ * Arguments:
+ *  A   On entry, the M-by-N matrix A. On exit, the matrix R and the elementary
+ *      reflectors as in DecomposeRQ().
+ *
+ * T    On exit, the K-by-K block reflector, K = min(M,N).
+ *
+ * W    Workspace, M-by-nb matrix used for work space in blocked invocations.
+ *
+ * nb   The block size used in blocked invocations. If nb is zero on
+ *      min(M,N) <= nb unblocked algorithm is used.
+ *
+ * Returns:
+ *      Decomposed matrix A and error indicator.
+ */
+func DecomposeRQT(A, T, W *matrix.FloatMatrix, nb int) (*matrix.FloatMatrix, error) {
+    K := imin(A.Rows(), A.Cols())
+    if T.Rows() < K || T.Cols() < K {
+        return nil, sizeError("DecomposeRQT", A, T)
+    }
+    if nb == 0 || K <= nb {
+        nb = K
+    }
+    if W == nil {
+        W = matrix.FloatZeros(A.Rows(), nb)
+    } else if W.Cols() < nb || W.Rows() < A.Rows() {
+        return nil, sizeError("DecomposeRQT", A, W)
+    }
+    tau := matrix.FloatZeros(K, 1)
+    blockedRQ(A, tau, T, W, nb)
+    return A, nil
+}
+
+/*
+ * Build block reflector T from HH elementary reflectors stored in rows of
+ * K-by-N matrix A, as returned by DecomposeRQ(), and scalar factors in tau.
+ *
+ * Q = H(1)H(2)...H(K) = I - Y.T*T*Y
+ */
+func BuildTRQ(T, A, tau *matrix.FloatMatrix) (*matrix.FloatMatrix, error) {
+    if T.Cols() < A.Rows() || T.Rows() < A.Rows() {
+        return nil, sizeError("BuildTRQ", T, A)
+    }
+    if A.Rows() > A.Cols() {
+        return nil, sizeError("BuildTRQ", A)
+    }
+    V := matrix.FloatZeros(A.Rows(), A.Cols())
+    explicitRQReflectors(V, A)
+    unblkRQBlockReflector(T, V, tau)
+    return T, nil
+}
+
+/*
+ * Multiply and replace C with Q*C or Q.T*C where Q is a real orthogonal matrix
+ * defined as the product of k elementary reflectors.
+ *
+ *    Q = H(1) H(2) . . . H(k)
+ *
+ * as returned by DecomposeRQ().
+ *
+ * Arguments:
+ *  C     On entry, the M-by-N matrix C. On exit C is overwritten by Q*C or Q.T*C.
+ *
+ *  A     K-by-P matrix, K <= P, holding the elementary reflectors as returned by
+ *        DecomposeRQ(). If decomposed matrix has more rows than columns, the last
+ *        P rows of it.
+ *
+ *  tau   The scalar factors of the elementary reflectors.
+ *
+ *  W     Workspace, used for blocked invocations. Size C.Cols()-by-nb if
+ *        multiplying from left, otherwise C.Rows()-by-nb.
+ *
+ *  nb    Blocksize for blocked invocations. If nb is zero unblocked algorithm
+ *        is used.
+ *
+ *  flags Indicators. Valid indicators LEFT, RIGHT, TRANS, NOTRANS
+ *
+ * Compatible with lapack.DORMRQ
+ */
+func MultRQ(C, A, tau, W *matrix.FloatMatrix, flags Flags, nb int) error {
+    if A.Rows() > A.Cols() {
+        return sizeError("MultRQ", A)
+    }
+    if flags & RIGHT != 0 {
+        if C.Cols() != A.Cols() {
+            return sizeError("MultRQ", C, A)
+        }
+        if nb != 0 && (W == nil || W.Cols() < nb || W.Rows() < C.Rows()) {
+            return sizeError("MultRQ", C, A, workDims(W))
+        }
+    } else {
+        if C.Rows() != A.Cols() {
+            return sizeError("MultRQ", C, A)
+        }
+        if nb != 0 && (W == nil || W.Cols() < nb || W.Rows() < C.Cols()) {
+            return sizeError("MultRQ", C, A, workDims(W))
+        }
+    }
+    if nb == 0 {
+        var w *matrix.FloatMatrix
+        if flags & RIGHT != 0 {
+            w = matrix.FloatZeros(C.Rows(), 1)
+        } else {
+            w = matrix.FloatZeros(1, C.Cols())
+        }
+        unblockedMultRQ(C, A, tau, w, flags)
+    } else {
+        blockedMultRQ(C, A, tau, nil, W, nb, flags)
+    }
+    return nil
+}
+
+/*
+ * Multiply and replace C with Q*C or Q.T*C where Q is a real orthogonal matrix
+ * defined as the product of k elementary reflectors and block reflector T
+ * as returned by DecomposeRQT().
+ *
+ * Arguments:
+ *  C     On entry, the M-by-N matrix C. On exit C is overwritten by Q*C or Q.T*C.
+ *
+ *  A     K-by-P matrix, K <= P, holding the elementary reflectors as returned by
+ *        DecomposeRQT().
+ *
+ *  T     The block reflector computed from elementary reflectors as returned by
+ *        DecomposeRQT() or computed from elementary reflectors and scalar coefficients
+ *        by BuildTRQ()
+ *
+ *  W     Workspace, size C.Cols()-by-nb or C.Rows()-by-nb
+ *
+ *  nb    Blocksize for blocked invocations. If nb == 0 default value T.Cols()
+ *        is used.
+ *
+ *  flags Indicators. Valid indicators LEFT, RIGHT, TRANS, NOTRANS
+ */
+func MultRQT(C, A, T, W *matrix.FloatMatrix, flags Flags, nb int) error {
+    if nb == 0 {
+        nb = T.Cols()
+    }
+    if A.Rows() > A.Cols() {
+        return sizeError("MultRQT", A)
+    }
+    if T.Rows() < A.Rows() || T.Cols() < A.Rows() {
+        return sizeError("MultRQT", A, T)
+    }
+    if W == nil {
+        return sizeError("MultRQT", C, A, workDims(W))
+    }
+    if flags & RIGHT != 0 {
+        if C.Cols() != A.Cols() {
+            return sizeError("MultRQT", C, A)
+        }
+        if W.Cols() < nb || W.Rows() < C.Rows() {
+            return sizeError("MultRQT", C, A, W)
+        }
+    } else {
+        if C.Rows() != A.Cols() {
+            return sizeError("MultRQT", C, A)
+        }
+        if W.Cols() < nb || W.Rows() < C.Cols() {
+            return sizeError("MultRQT", C, A, W)
+        }
+    }
+    blockedMultRQ(C, A, nil, T, W, nb, flags)
+    return nil
+}
+
+/*
+ * Generate an M-by-N real matrix Q with ortonormal rows, which is defined as
+ * the last M rows of the product of M elementary reflectors
+ *
+ *    Q = H(1) H(2) . . . H(k)
+ *
+ * as returned by DecomposeRQ().
+ *
+ * Arguments:
+ *  A     On entry, RQ factorization as returned by DecomposeRQ(). On exit, the
+ *        M-by-N matrix Q. Requires M <= N.
+ *
+ *  tau   The scalar factors of elementary reflectors as returned by DecomposeRQ()
+ *
+ *  W     Workspace, size A.Rows()-by-nb.
+ *
+ *  nb    Blocksize for blocked invocations. If nb == 0 unblocked algorith is used
+ *
+ * Compatible with lapack.DORGRQ
+ */
+func BuildRQ(A, tau, W *matrix.FloatMatrix, nb int) (*matrix.FloatMatrix, error) {
+    if A.Rows() > A.Cols() {
+        return nil, sizeError("BuildRQ", A)
+    }
+    if nb == 0 {
+        w := matrix.FloatZeros(A.Rows(), 1)
+        unblockedBuildRQ(A, tau, w)
+        return A, nil
+    }
+    if W == nil {
+        W = matrix.FloatZeros(A.Rows(), nb)
+    } else if W.Cols() < nb || W.Rows() < A.Rows() {
+        return nil, sizeError("BuildRQ", A, W)
+    }
+    blockedBuildRQ(A, tau, nil, W, nb)
+    return A, nil
+}
+
+/*
+ * Generate an M-by-N real matrix Q with ortonormal rows, which is defined as
+ * the last M rows of the product of M elementary reflectors and block reflector T
+ * as returned by DecomposeRQT().
+ *
+ * Arguments:
+ *  A     On entry, RQ factorization as returned by DecomposeRQT(). On exit, the
+ *        M-by-N matrix Q. Requires M <= N.
+ *
+ *  T     The block reflector as returned by DecomposeRQT() or BuildTRQ().
+ *
+ *  W     Workspace, size A.Rows()-by-nb.
+ *
+ *  nb    Blocksize for blocked invocations. If nb == 0 default value T.Cols()
+ *        is used.
+ */
+func BuildRQT(A, T, W *matrix.FloatMatrix, nb int) (*matrix.FloatMatrix, error) {
+    if A.Rows() > A.Cols() {
+        return nil, sizeError("BuildRQT", A)
+    }
+    if nb == 0 {
+        nb = T.Cols()
+    }
+    if W == nil {
+        W = matrix.FloatZeros(A.Rows(), nb)
+    } else if W.Cols() < nb || W.Rows() < A.Rows() {
+        return nil, sizeError("BuildRQT", A, W)
+    }
+    tau := matrix.FloatZeros(A.Rows(), 1)
+    blockedBuildRQ(A, tau, T, W, nb)
+    return A, nil
+}
+
+// Local Variables:
+// tab-width: 4
+// indent-tabs-mode: nil
+// End:
@@ -0,0 +1,189 @@
+
+// Copyright (c) Harri Rautila, 2013
+
+// This file is part of github.com/hrautila/matops package. It is free software,
+// distributed under the terms of GNU Lesser General Public License Version 3, or
+// any later version. See the COPYING tile included in this archive.
+
+package matops
+
+import (
+    "fmt"
+    "github.com/hrautila/matrix"
+    "testing"
+)
+
+// Compute ||A - [0 R]*Q||_1 where Q is applied with MultRQ or MultRQT
+func rqResidual(A, RQ *matrix.FloatMatrix, mult func(C *matrix.FloatMatrix)) float64 {
+    var Rd, Rs matrix.FloatMatrix
+    M := A.Rows()
+    N := A.Cols()
+    R := matrix.FloatZeros(M, N)
+    R.SubMatrix(&Rd, 0, N-M, M, M)
+    RQ.SubMatrix(&Rs, 0, N-M, M, M)
+    Rs.CopyTo(&Rd)
+    TriU(&Rd)
+    mult(R)
+    A2 := A.Copy()
+    A2.Minus(R)
+    return NormP(A2, NORM_ONE)
+}
+
+func TestDecomposeRQ(t *testing.T) {
+    M := 40
+    N := 60
+    for _, nb := range []int{0, 8} {
+        A := matrix.FloatUniform(M, N)
+        W := matrix.FloatZeros(M, 8)
+        tau := matrix.FloatZeros(M, 1)
+
+        RQ, err := DecomposeRQ(A.Copy(), tau, W, nb)
+        if err != nil {
+            t.Errorf("nb=%d decompose error: %v\n", nb, err)
+            continue
+        }
+        nrm := rqResidual(A, RQ, func(C *matrix.FloatMatrix) {
+            Wr := matrix.FloatZeros(C.Rows(), 8)
+            MultRQ(C, RQ, tau, Wr, RIGHT, nb)
+        })
+        checkNorm(t, fmt.Sprintf("nb=%d ||A - R*Q||_1", nb), nrm, N, NormP(A, NORM_ONE))
+    }
+}
+
+func TestDecomposeRQT(t *testing.T) {
+    M := 40
+    N := 60
+    for _, nb := range []int{0, 12} {
+        A := matrix.FloatUniform(M, N)
+        W := matrix.FloatZeros(M, M)
+        T := matrix.FloatZeros(M, M)
+
+        RQ, err := DecomposeRQT(A.Copy(), T, W, nb)
+        if err != nil {
+            t.Errorf("nb=%d decompose error: %v\n", nb, err)
+            continue
+        }
+        nrm := rqResidual(A, RQ, func(C *matrix.FloatMatrix) {
+            MultRQT(C, RQ, T, W, RIGHT, nb)
+        })
+        checkNorm(t, fmt.Sprintf("nb=%d ||A - R*Q||_1", nb), nrm, N, NormP(A, NORM_ONE))
+
+        // T matches block reflector built from reflectors
+        tau := matrix.FloatZeros(M, 1)
+        T.Diag().CopyTo(tau)
+        T2 := matrix.FloatZeros(M, M)
+        BuildTRQ(T2, RQ, tau)
+        T2.Minus(T)
+        checkNorm(t, fmt.Sprintf("nb=%d ||T - BuildTRQ(A, tau)||_1", nb),
+            NormP(T2, NORM_ONE), M, NormP(T, NORM_ONE))
+    }
+}
+
+func TestDecomposeRQTall(t *testing.T) {
+    var Rt, Rb, Ab matrix.FloatMatrix
+    M := 60
+    N := 40
+    nb := 8
+    A := matrix.FloatUniform(M, N)
+    tau := matrix.FloatZeros(N, 1)
+    RQ, err := DecomposeRQ(A.Copy(), tau, nil, nb)
+    if err != nil {
+        t.Errorf("decompose error: %v\n", err)
+        return
+    }
+    // R = [R1; R2], R2 upper triangular in last N rows
+    R := RQ.Copy()
+    R.SubMatrix(&Rb, M-N, 0, N, N)
+    TriU(&Rb)
+    R.SubMatrix(&Rt, 0, 0, M, N)
+    RQ.SubMatrix(&Ab, M-N, 0, N, N)
+    MultRQ(&Rt, &Ab, tau, matrix.FloatZeros(M, nb), RIGHT, nb)
+    R.Minus(A)
+    checkNorm(t, "||A - R*Q||_1", NormP(R, NORM_ONE), M, NormP(A, NORM_ONE))
+}
+
+func TestMultRQ(t *testing.T) {
+    M := 40
+    N := 60
+    K := 30
+    nb := 8
+    A := matrix.FloatUniform(M, N)
+    W := matrix.FloatZeros(N, nb)
+    tau := matrix.FloatZeros(M, 1)
+    RQ, _ := DecomposeRQ(A, tau, W, nb)
+
+    for _, flags := range []Flags{LEFT, LEFT|TRANS} {
+        C := matrix.FloatUniform(N, K)
+        C0 := C.Copy()
+        C1 := C.Copy()
+        MultRQ(C, RQ, tau, W, flags, 0)
+        MultRQ(C1, RQ, tau, W, flags, nb)
+        C1.Minus(C)
+        // apply inverse
+        MultRQ(C, RQ, tau, W, flags ^ TRANS, nb)
+        C.Minus(C0)
+        checkNorm(t, fmt.Sprintf("flags=%v ||unblk - blk||_1", flags),
+            NormP(C1, NORM_ONE), N, NormP(C0, NORM_ONE))
+        checkNorm(t, fmt.Sprintf("flags=%v ||C - Q.T*Q*C||_1", flags),
+            NormP(C, NORM_ONE), N, NormP(C0, NORM_ONE))
+    }
+    for _, flags := range []Flags{RIGHT, RIGHT|TRANS} {
+        C := matrix.FloatUniform(K, N)
+        C0 := C.Copy()
+        C1 := C.Copy()
+        MultRQ(C, RQ, tau, W, flags, 0)
+        MultRQ(C1, RQ, tau, W, flags, nb)
+        C1.Minus(C)
+        MultRQ(C, RQ, tau, W, flags ^ TRANS, nb)
+        C.Minus(C0)
+        checkNorm(t, fmt.Sprintf("flags=%v ||unblk - blk||_1", flags),
+            NormP(C1, NORM_ONE), N, NormP(C0, NORM_ONE))
+        checkNorm(t, fmt.Sprintf("flags=%v ||C - C*Q*Q.T||_1", flags),
+            NormP(C, NORM_ONE), N, NormP(C0, NORM_ONE))
+    }
+}
+
+func TestBuildRQ(t *testing.T) {
+    var Ab matrix.FloatMatrix
+    M := 40
+    N := 60
+    nb := 8
+    A := matrix.FloatUniform(M, N)
+    W := matrix.FloatZeros(M, M)
+    tau := matrix.FloatZeros(M, 1)
+    T := matrix.FloatZeros(M, M)
+
+    RQ, _ := DecomposeRQ(A.Copy(), tau, W, nb)
+    RQT, _ := DecomposeRQT(A.Copy(), T, W, nb)
+    for _, nbq := range []int{0, nb} {
+        Q, err := BuildRQ(RQ.Copy(), tau, W, nbq)
+        if err != nil {
+            t.Errorf("nb=%d build error: %v\n", nbq, err)
+            continue
+        }
+        QT, err := BuildRQT(RQT.Copy(), T, W, nbq)
+        if err != nil {
+            t.Errorf("nb=%d build error: %v\n", nbq, err)
+            continue
+        }
+
+        // ||I - Q*Q.T||_1
+        I := matrix.FloatDiagonal(M, 1.0)
+        Mult(I, Q, Q, -1.0, 1.0, TRANSB)
+        // Q equals last rows of I*Q
+        Q2 := matrix.FloatDiagonal(N, 1.0)
+        MultRQ(Q2, RQ, tau, nil, LEFT, 0)
+        Q2.SubMatrix(&Ab, N-M, 0, M, N)
+        Ab.Minus(Q)
+        // ||Q - Q(T)||_1
+        QT.Minus(Q)
+        checkNorm(t, fmt.Sprintf("nb=%d ||I - Q*Q.T||_1", nbq), NormP(I, NORM_ONE), N, 1.0)
+        checkNorm(t, fmt.Sprintf("nb=%d ||Q - I*Q||_1", nbq), NormP(&Ab, NORM_ONE), N, 1.0)
+        checkNorm(t, fmt.Sprintf("nb=%d ||Q - Q(T)||_1", nbq), NormP(QT, NORM_ONE), N, 1.0)
+    }
+}
+
+// Local Variables:
+// tab-width: 4
+// indent-tabs-mode: nil
+// End:
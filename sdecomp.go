@@ -0,0 +1,132 @@
+// Copyright (c) Harri Rautila, 2013
+
+// This file is part of github.com/hrautila/matops package. It is free software,
+// distributed under the terms of GNU Lesser General Public License Version 3, or
+// any later version. See the COPYING tile included in this archive.
+
+package matops
+
+import (
+    "github.com/hrautila/matops/calgo"
+)
+
+// Single precision Cholesky and LU factorizations. Algorithms are shared with
+// chol.go and lu.go through float32Ops and run serially.
+
+type float32Ops struct{}
+
+func (float32Ops) get(A *Float32Matrix) float64 {
+    return float64(A.Float())
+}
+
+func (float32Ops) set(A *Float32Matrix, val float64) {
+    A.SetAt(0, 0, float32(val))
+}
+
+func (float32Ops) dot(X, Y *Float32Matrix, alpha float64) float64 {
+    return float64(SDot(X, Y, float32(alpha)))
+}
+
+func (float32Ops) invScale(X *Float32Matrix, alpha float64) {
+    sInvScale(X, float32(alpha))
+}
+
+func (float32Ops) mvSolveTrm(X, A *Float32Matrix, flags Flags) error {
+    return SSolveTrm(X, A, 1.0, LEFT|flags)
+}
+
+func (float32Ops) mvMult(Y, A, X *Float32Matrix, alpha, beta float64, flags Flags) error {
+    return SMVMult(Y, A, X, float32(alpha), float32(beta), flags)
+}
+
+func (float32Ops) mvRankUpdateSym(A, X *Float32Matrix, alpha float64, flags Flags) error {
+    sMVRankUpdateSym(A, X, float32(alpha), flags)
+    return nil
+}
+
+func (float32Ops) solveTrm(B, A *Float32Matrix, alpha float64, flags Flags) error {
+    return SSolveTrm(B, A, float32(alpha), flags)
+}
+
+func (float32Ops) mult(C, A, B *Float32Matrix, alpha, beta float64, flags Flags) error {
+    return SMult(C, A, B, float32(alpha), float32(beta), flags)
+}
+
+func (float32Ops) rankUpdateSym(C, A *Float32Matrix, alpha, beta float64, flags Flags) error {
+    sRankUpdateSym(C, A, float32(alpha), float32(beta), flags)
+    return nil
+}
+
+func (float32Ops) pivotIndex(A *Float32Matrix, p *pPivots) {
+    sPivotIndex(A, p)
+}
+
+func (float32Ops) applyPivots(A *Float32Matrix, p *pPivots) {
+    sApplyPivots(A, p)
+}
+
+/*
+ * Compute the Cholesky factorization of a symmetric positive definite
+ * single precision N-by-N matrix A. See DecomposeCHOL().
+ *
+ * Compatible with lapack.SPOTRF
+ */
+func SDecomposeCHOL(A *Float32Matrix, flags Flags, nb int) (*Float32Matrix, error) {
+    if A.Cols() != A.Rows() {
+        return A, sizeError("SDecomposeCHOL", A)
+    }
+    if A.Cols() < nb || nb == 0 {
+        return A, unblockedCHOL(float32Ops{}, A, flags, 0)
+    }
+    return A, blockedCHOL(float32Ops{}, A, flags, nb)
+}
+
+// Swap rows src and dst of A.
+func sSwapRows(A *Float32Matrix, src, dst int) {
+    var r0, r1 Float32Matrix
+    if src == dst || A.Rows() == 0 || A.Cols() == 0 {
+        return
+    }
+    A.SubMatrix(&r0, src, 0, 1, A.Cols())
+    A.SubMatrix(&r1, dst, 0, 1, A.Cols())
+    calgo.SSwap(r0.Float32Array(), r1.Float32Array(), r0.LeadingIndex(), r1.LeadingIndex(), A.Cols())
+}
+
+func sApplyPivots(A *Float32Matrix, p *pPivots) {
+    for k, n := range p.pivots {
+        if n > 0 {
+            sSwapRows(A, n, k)
+        }
+    }
+}
+
+// Find largest absolute value on column
+func sPivotIndex(A *Float32Matrix, p *pPivots) {
+    p.pivots[0] = calgo.SIAMax(A.Float32Array(), 1, A.Rows())
+}
+
+/*
+ * Compute an LU factorization of a general single precision M-by-N matrix using
+ * partial pivoting with row interchanges. See DecomposeLU().
+ *
+ * Compatible with lapack.SGETRF
+ */
+func SDecomposeLU(A *Float32Matrix, pivots []int, nb int) (*Float32Matrix, error) {
+    mlen := imin(A.Rows(), A.Cols())
+    if len(pivots) < mlen {
+        return A, sizeError("SDecomposeLU", A, shape{len(pivots), 1})
+    }
+    // clear pivot array
+    for k, _ := range pivots {
+        pivots[k] = 0
+    }
+    if mlen <= nb || nb == 0 {
+        return A, unblockedLUpiv(float32Ops{}, A, &pPivots{pivots})
+    }
+    return A, blockedLUpiv(float32Ops{}, A, &pPivots{pivots}, nb)
+}
+
+// Local Variables:
+// tab-width: 4
+// indent-tabs-mode: nil
+// End:
@@ -0,0 +1,168 @@
+// Copyright (c) Harri Rautila, 2013
+
+// This file is part of github.com/hrautila/matops package. It is free software,
+// distributed under the terms of GNU Lesser General Public License Version 3, or
+// any later version. See the COPYING tile included in this archive.
+
+package matops
+
+import (
+    "github.com/hrautila/matops/calgo"
+    "context"
+    "math"
+)
+
+// Single precision versions of the matrix operations. Arguments and results are
+// as in the double precision functions with the same name without S prefix.
+
+// Element increment of vector X.
+func sinc(X *Float32Matrix) int {
+    if X.Cols() != 1 {
+        // Row vector
+        return X.LeadingIndex()
+    }
+    return 1
+}
+
+// Generic matrix-matrix multiply. See Mult().
+func SMult(C, A, B *Float32Matrix, alpha, beta float32, flags Flags) error {
+    ok, empty := checkMult(C, A, B, flags)
+    if empty {
+        return nil
+    }
+    if ! ok {
+        return onError("SMult: size mismatch")
+    }
+    conf := globalConfig(nil)
+    psize := int64(C.NumElements())*int64(A.Cols())
+    Ar, ldA := A.Float32Array(), A.LeadingIndex()
+    Br, ldB := B.Float32Array(), B.LeadingIndex()
+    Cr, ldC := C.Float32Array(), C.LeadingIndex()
+
+    // matrix A, B common dimension
+    P := A.Cols()
+    if flags & TRANSA != 0 {
+        P = A.Rows()
+    }
+    worker := func(cstart, cend, rstart, rend int) {
+        calgo.SMult(Cr, Ar, Br, alpha, beta, calgo.Flags(flags), ldC, ldA, ldB, P,
+            cstart, cend, rstart, rend, conf.KB, conf.NB, conf.MB)
+    }
+    if psize <= conf.LimitOne || conf.Executor.Workers() <= 1 {
+        worker(0, C.Cols(), 0, C.Rows())
+        return nil
+    }
+    return scheduleTiles(context.Background(), conf, C.Cols(), C.Rows(), worker)
+}
+
+// Matrix-vector multiply. See MVMult().
+func SMVMult(Y, A, X *Float32Matrix, alpha, beta float32, flags Flags) error {
+    if A.Rows() == 0 || A.Cols() == 0 {
+        return nil
+    }
+    if ! isVector(Y) {
+        return sizeError("SMVMult", Y, A, X)
+    }
+    if ! isVector(X) {
+        return sizeError("SMVMult", Y, A, X)
+    }
+    Ar, ldA := A.Float32Array(), A.LeadingIndex()
+    Yr, incY := Y.Float32Array(), sinc(Y)
+    Xr, incX := X.Float32Array(), sinc(X)
+    lenX := X.NumElements()
+    p := globalParams()
+    worker := func(R, E int) {
+        calgo.SMultMV(Yr, Ar, Xr, alpha, beta, calgo.Flags(flags), incY, ldA, incX,
+            0, lenX, R, E, p.KB, p.MB)
+    }
+    return scheduleMV(context.Background(), nil, Y.NumElements(), lenX, worker)
+}
+
+// Triangular solve with multiple right sides. See SolveTrm().
+func SSolveTrm(B, A *Float32Matrix, alpha float32, flags Flags) error {
+    ok, empty := checkTrm(B, A, flags)
+    if empty {
+        return nil
+    }
+    if ! ok {
+        return onError("A, B size mismatch")
+    }
+    conf := globalConfig(nil)
+    N := A.Cols()
+    worker := func(S, E int) {
+        var Bs Float32Matrix
+        trmSubBlock(&Bs, B, S, E, flags)
+        calgo.SSolveBlk(Bs.Float32Array(), A.Float32Array(), alpha, calgo.Flags(flags),
+            Bs.LeadingIndex(), A.LeadingIndex(), N, 0, E-S, conf.NB)
+    }
+    return scheduleTrm(context.Background(), conf, B, N, flags, worker)
+}
+
+// Symmetric rank update C = beta*C + alpha*A*A.T or C = beta*C + alpha*A.T*A
+// if flags&TRANSA; only UPPER or LOWER triangle of C is referenced.
+func sRankUpdateSym(C, A *Float32Matrix, alpha, beta float32, flags Flags) {
+    P := A.Cols()
+    if flags & TRANSA != 0 {
+        P = A.Rows()
+    }
+    p := globalParams()
+    calgo.SSymmRankBlk(C.Float32Array(), A.Float32Array(), alpha, beta, calgo.Flags(flags),
+        C.LeadingIndex(), A.LeadingIndex(), P, 0, C.Cols(), p.KB, p.NB)
+}
+
+// Rank update A = A + alpha*X*Y.T
+func sMVRankUpdate(A, X, Y *Float32Matrix, alpha float32) {
+    calgo.SRankMV(A.Float32Array(), X.Float32Array(), Y.Float32Array(), alpha,
+        A.LeadingIndex(), sinc(X), sinc(Y), 0, A.Cols(), 0, A.Rows(), 0, 0)
+}
+
+// Symmetric rank update A = A + alpha*X*X.T; UPPER or LOWER triangle of A updated.
+func sMVRankUpdateSym(A, X *Float32Matrix, alpha float32, flags Flags) {
+    calgo.SSymmRankMV(A.Float32Array(), X.Float32Array(), alpha, calgo.Flags(flags),
+        A.LeadingIndex(), sinc(X), 0, A.Cols(), 0)
+}
+
+// Inner product: alpha * X * Y. See Dot().
+func SDot(X, Y *Float32Matrix, alpha float32) float32 {
+    if X == nil || Y == nil || ! isVector(X) || ! isVector(Y) {
+        return float32(math.NaN())
+    }
+    return calgo.SDot(X.Float32Array(), Y.Float32Array(), alpha, sinc(X), sinc(Y), X.NumElements())
+}
+
+// Y := alpha * X + Y. See Axpy().
+func SAxpy(Y, X *Float32Matrix, alpha float32) {
+    if X == nil || Y == nil || ! isVector(X) || ! isVector(Y) {
+        return
+    }
+    calgo.SAxpy(Y.Float32Array(), X.Float32Array(), alpha, sinc(X), sinc(Y), X.NumElements())
+}
+
+// Norm2 of vector: sqrt(||x||^2); squares are summed in double precision.
+func SNorm2(X *Float32Matrix) float32 {
+    if X == nil || ! isVector(X) {
+        return float32(math.NaN())
+    }
+    return calgo.SNorm2(X.Float32Array(), sinc(X), X.NumElements())
+}
+
+// Scaling with scalar: X = alpha * X. See Scale().
+func SScale(X *Float32Matrix, alpha float32) {
+    if X == nil || X.NumElements() == 0 || ! isVector(X) {
+        return
+    }
+    calgo.SScal(X.Float32Array(), alpha, sinc(X), X.NumElements())
+}
+
+// Inverse scaling of vector: X = X / alpha.
+func sInvScale(X *Float32Matrix, alpha float32) {
+    if X.NumElements() == 0 {
+        return
+    }
+    calgo.SInvScal(X.Float32Array(), alpha, sinc(X), X.NumElements())
+}
+
+// Local Variables:
+// tab-width: 4
+// indent-tabs-mode: nil
+// End:
@@ -0,0 +1,455 @@
+
+// Copyright (c) Harri Rautila, 2013
+
+// This file is part of github.com/hrautila/matops package. It is free software,
+// distributed under the terms of GNU Lesser General Public License Version 3, or
+// any later version. See the COPYING tile included in this archive.
+
+package matops
+
+import (
+    "github.com/hrautila/matrix"
+    "math"
+    //"fmt"
+)
+
+/*
+ * Unblocked reduction of M-by-N matrix A, M >= N, to upper bidiagonal form
+ * with orthogonal transformations Q.T*A*P = B. (LAPACK/dgebd2.f)
+ *
+ *   Q = H(1)H(2)...H(n),  H(k) = I - tauq*v*v.T
+ *   P = G(1)G(2)...G(n-1),  G(k) = I - taup*u*u.T
+ *
+ * On exit, diagonal and first superdiagonal of A hold the bidiagonal B, the
+ * vectors v are stored below the diagonal and the vectors u right of first
+ * superdiagonal. Vectors tauq and taup hold the scalar coefficients. Row vector
+ * wr of length N and column vector wc of length M are used as workspace.
+ */
+func unblkReduceBidiag(A, tauq, taup, wr, wc *matrix.FloatMatrix) {
+    var ATL, ATR, ABL, ABR matrix.FloatMatrix
+    var A00, a11, a12t, a21, A22 matrix.FloatMatrix
+    var tqT, tqB, tpT, tpB matrix.FloatMatrix
+    var tq0, tauq1, tq2, tp0, taup1, tp2 matrix.FloatMatrix
+    var alpha12, x12, a22l, A22r, w1 matrix.FloatMatrix
+
+    partition2x2(
+        &ATL, &ATR,
+        &ABL, &ABR, A, 0, 0, pTOPLEFT)
+    partition2x1(
+        &tqT,
+        &tqB,  tauq, 0, pTOP)
+    partition2x1(
+        &tpT,
+        &tpB,  taup, 0, pTOP)
+
+    for ABR.Rows() > 0 && ABR.Cols() > 0 {
+        repartition2x2to3x3(&ATL,
+            &A00, nil,  nil,
+            nil,  &a11, &a12t,
+            nil,  &a21, &A22,   A, 1, pBOTTOMRIGHT)
+        repartition2x1to3x1(&tqT,
+            &tq0,
+            &tauq1,
+            &tq2,     tauq, 1, pBOTTOM)
+        repartition2x1to3x1(&tpT,
+            &tp0,
+            &taup1,
+            &tp2,     taup, 1, pBOTTOM)
+        // ------------------------------------------------------
+
+        // H*[a11 a12t; a21 A22]
+        computeHouseholder(&a11, &a21, &tauq1, LEFT)
+        wr.SubMatrix(&w1, 0, 0, 1, a12t.Cols())
+        applyHHTo2x1(&tauq1, &a21, &a12t, &A22, &w1, LEFT)
+
+        if a12t.Cols() > 0 {
+            // [a12t; A22]*G; annihilate a12t[1:]
+            partition1x2(
+                &alpha12, &x12,   &a12t, 1, pLEFT)
+            computeHouseholder(&alpha12, &x12, &taup1, RIGHT)
+            partition1x2(
+                &a22l, &A22r,   &A22, 1, pLEFT)
+            wc.SubMatrix(&w1, 0, 0, a22l.Rows(), 1)
+            applyHHTo2x1(&taup1, &x12, &a22l, &A22r, &w1, RIGHT)
+        }
+        // ------------------------------------------------------
+        continue3x3to2x2(
+            &ATL, &ATR,
+            &ABL, &ABR,   &A00, &a11, &A22,   A, pBOTTOMRIGHT)
+        continue3x1to2x1(
+            &tqT,
+            &tqB,   &tq0, &tauq1,   tauq, pBOTTOM)
+        continue3x1to2x1(
+            &tpT,
+            &tpB,   &tp0, &taup1,   taup, pBOTTOM)
+    }
+}
+
+// Rotate columns j and k of A with plane rotation (c, s).
+func rotateCols(A *matrix.FloatMatrix, j, k int, c, s float64) {
+    var aj, ak matrix.FloatMatrix
+    A.SubMatrix(&aj, 0, j, A.Rows(), 1)
+    A.SubMatrix(&ak, 0, k, A.Rows(), 1)
+    applyGivens(&aj, &ak, c, s)
+}
+
+/*
+ * Compute singular values of upper bidiagonal matrix with Golub-Kahan implicit
+ * shift QR iteration.
+ *
+ * Vector d holds the diagonal and e the superdiagonal of bidiagonal matrix
+ * with e[n-1] = 0. If U and V are not nil, the left and right rotations are
+ * accumulated to first n columns of U and V. On exit d holds the singular values
+ * in decreasing order.
+ */
+func bidiagQR(d, e []float64, U, V *matrix.FloatMatrix) error {
+    n := len(d)
+    p := n
+    pp := p - 1
+    iter := 0
+    maxIter := 75*n
+    eps := math.Pow(2.0, -52.0)
+    tiny := math.Pow(2.0, -966.0)
+
+    for p > 0 {
+        var k, kase int
+
+        // kase = 1  if d[p-1] and e[k-1] are negligible and k < p
+        // kase = 2  if d[k-1] is negligible and k < p
+        // kase = 3  if e[k-1] is negligible, k < p and d[k], ..., d[p-1] are
+        //           not negligible (QR step)
+        // kase = 4  if e[p-2] is negligible (convergence)
+        for k = p-2; k >= 0; k-- {
+            if math.Abs(e[k]) <= tiny + eps*(math.Abs(d[k]) + math.Abs(d[k+1])) {
+                e[k] = 0.0
+                break
+            }
+        }
+        if k == p-2 {
+            kase = 4
+        } else {
+            var ks int
+            for ks = p-1; ks > k; ks-- {
+                t := math.Abs(e[ks])
+                if ks != k+1 {
+                    t += math.Abs(e[ks-1])
+                }
+                if math.Abs(d[ks]) <= tiny + eps*t {
+                    d[ks] = 0.0
+                    break
+                }
+            }
+            if ks == k {
+                kase = 3
+            } else if ks == p-1 {
+                kase = 1
+            } else {
+                kase = 2
+                k = ks
+            }
+        }
+        k++
+
+        switch kase {
+        case 1:
+            // deflate negligible d[p-1]
+            f := e[p-2]
+            e[p-2] = 0.0
+            for j := p-2; j >= k; j-- {
+                t := sqrtX2Y2(d[j], f)
+                cs := d[j]/t
+                sn := f/t
+                d[j] = t
+                if j != k {
+                    f = -sn*e[j-1]
+                    e[j-1] = cs*e[j-1]
+                }
+                if V != nil {
+                    rotateCols(V, j, p-1, cs, sn)
+                }
+            }
+        case 2:
+            // split at negligible d[k-1]
+            f := e[k-1]
+            e[k-1] = 0.0
+            for j := k; j < p; j++ {
+                t := sqrtX2Y2(d[j], f)
+                cs := d[j]/t
+                sn := f/t
+                d[j] = t
+                f = -sn*e[j]
+                e[j] = cs*e[j]
+                if U != nil {
+                    rotateCols(U, j, k-1, cs, sn)
+                }
+            }
+        case 3:
+            iter++
+            if iter > maxIter {
+                return &BreakdownError{"DecomposeSVD", p-1}
+            }
+            // calculate the shift
+            scale := math.Max(math.Abs(d[p-1]), math.Abs(d[p-2]))
+            scale = math.Max(scale, math.Abs(e[p-2]))
+            scale = math.Max(scale, math.Abs(d[k]))
+            scale = math.Max(scale, math.Abs(e[k]))
+            sp := d[p-1]/scale
+            spm1 := d[p-2]/scale
+            epm1 := e[p-2]/scale
+            sk := d[k]/scale
+            ek := e[k]/scale
+            b := ((spm1 + sp)*(spm1 - sp) + epm1*epm1)/2.0
+            c := (sp*epm1)*(sp*epm1)
+            shift := 0.0
+            if b != 0.0 || c != 0.0 {
+                shift = math.Sqrt(b*b + c)
+                if b < 0.0 {
+                    shift = -shift
+                }
+                shift = c/(b + shift)
+            }
+            f := (sk + sp)*(sk - sp) + shift
+            g := sk*ek
+
+            // chase zeros
+            for j := k; j < p-1; j++ {
+                t := sqrtX2Y2(f, g)
+                cs := f/t
+                sn := g/t
+                if j != k {
+                    e[j-1] = t
+                }
+                f = cs*d[j] + sn*e[j]
+                e[j] = cs*e[j] - sn*d[j]
+                g = sn*d[j+1]
+                d[j+1] = cs*d[j+1]
+                if V != nil {
+                    rotateCols(V, j, j+1, cs, sn)
+                }
+                t = sqrtX2Y2(f, g)
+                cs = f/t
+                sn = g/t
+                d[j] = t
+                f = cs*e[j] + sn*d[j+1]
+                d[j+1] = -sn*e[j] + cs*d[j+1]
+                g = sn*e[j+1]
+                e[j+1] = cs*e[j+1]
+                if U != nil {
+                    rotateCols(U, j, j+1, cs, sn)
+                }
+            }
+            e[p-2] = f
+        case 4:
+            // convergence; make singular value positive
+            if d[k] <= 0.0 {
+                if d[k] < 0.0 {
+                    d[k] = -d[k]
+                } else {
+                    d[k] = 0.0
+                }
+                if V != nil {
+                    var vk matrix.FloatMatrix
+                    V.SubMatrix(&vk, 0, k, V.Rows(), 1)
+                    Scale(&vk, -1.0)
+                }
+            }
+            // order singular values
+            for k < pp {
+                if d[k] >= d[k+1] {
+                    break
+                }
+                d[k], d[k+1] = d[k+1], d[k]
+                if V != nil {
+                    swapCols(V, k, k+1)
+                }
+                if U != nil {
+                    swapCols(U, k, k+1)
+                }
+                k++
+            }
+            iter = 0
+            p--
+        }
+    }
+    return nil
+}
+
+// Set A to M-by-N identity matrix.
+func setIdentity(A *matrix.FloatMatrix) {
+    for j := 0; j < A.Cols(); j++ {
+        for i := 0; i < A.Rows(); i++ {
+            if i == j {
+                A.SetAt(i, j, 1.0)
+            } else {
+                A.SetAt(i, j, 0.0)
+            }
+        }
+    }
+}
+
+/*
+ * Singular value decomposition of M-by-N matrix A, M >= N. Left singular
+ * vectors are computed to U (M-by-N or M-by-M) and right singular vectors to
+ * N-by-N matrix V if they are not nil.
+ */
+func svdTall(A, S, U, V, W *matrix.FloatMatrix, nb int) error {
+    var Vr, At, tp matrix.FloatMatrix
+
+    N := A.Cols()
+    tauq := matrix.FloatZeros(N, 1)
+    taup := matrix.FloatZeros(N, 1)
+    wr := matrix.FloatZeros(1, N)
+    wc := matrix.FloatZeros(A.Rows(), 1)
+    unblkReduceBidiag(A, tauq, taup, wr, wc)
+
+    d := make([]float64, N)
+    e := make([]float64, N)
+    for k := 0; k < N; k++ {
+        d[k] = A.GetAt(k, k)
+        if k < N-1 {
+            e[k] = A.GetAt(k, k+1)
+        }
+    }
+
+    if U != nil {
+        // U = Q*I
+        setIdentity(U)
+        if err := MultQ(U, A, tauq, W, LEFT, nb); err != nil {
+            return err
+        }
+    }
+    if V != nil {
+        // V = P*I; reflectors of P are stored in rows above first superdiagonal
+        setIdentity(V)
+        if N > 1 {
+            A.SubMatrix(&At, 0, 1, N-1, N-1)
+            Y := At.Transpose()
+            V.SubMatrix(&Vr, 1, 1, N-1, N-1)
+            taup.SubMatrix(&tp, 0, 0, N-1, 1)
+            if err := MultQ(&Vr, Y, &tp, W, LEFT, nb); err != nil {
+                return err
+            }
+        }
+    }
+
+    if err := bidiagQR(d, e, U, V); err != nil {
+        return err
+    }
+    for k := 0; k < N; k++ {
+        if S.Cols() == 1 {
+            S.SetAt(k, 0, d[k])
+        } else {
+            S.SetAt(0, k, d[k])
+        }
+    }
+    return nil
+}
+
+/*
+ * Compute singular value decomposition of M-by-N matrix A
+ *
+ *    A = U*S*V.T
+ *
+ * where S is diagonal matrix of singular values in decreasing order, U is
+ * M-by-M orthogonal matrix of left singular vectors and V is N-by-N orthogonal
+ * matrix of right singular vectors. Matrix is first reduced to bidiagonal form
+ * with Householder reflectors from left and right and singular values are then
+ * computed with Golub-Kahan implicit shift QR iteration.
+ *
+ * Arguments:
+ *  A     On entry, the M-by-N matrix A. On exit, the contents of A are destroyed.
+ *
+ *  S     On exit, singular values in decreasing order. Row or column vector
+ *        of length K = min(M, N).
+ *
+ *  U     On exit, left singular vectors. M-by-K matrix if flags&WANTU, M-by-M
+ *        matrix if flags&(WANTU|FULL). Not referenced if WANTU not set.
+ *
+ *  Vt    On exit, transpose of right singular vectors. K-by-N matrix if
+ *        flags&WANTV, N-by-N matrix if flags&(WANTV|FULL). Not referenced if
+ *        WANTV not set.
+ *
+ *  W     Workspace, max(M,N)-by-nb matrix used in blocked invocations. If nil
+ *        workspace is allocated.
+ *
+ *  flags Indicator bits, WANTU, WANTV, FULL. If neither WANTU or WANTV is set
+ *        only singular values are computed.
+ *
+ *  nb    Block size for building singular vectors. If zero unblocked
+ *        algorithm is used.
+ *
+ * Compatible with lapack.DGESVD
+ */
+func DecomposeSVD(A, S, U, Vt, W *matrix.FloatMatrix, flags Flags, nb int) error {
+    var Ut, Vtall *matrix.FloatMatrix = nil, nil
+
+    M := A.Rows()
+    N := A.Cols()
+    K := imin(M, N)
+    if S == nil || ! isVector(S) || S.NumElements() < K {
+        return sizeError("DecomposeSVD", A, workDims(S))
+    }
+    ucols, vrows := K, K
+    if flags & FULL != 0 {
+        ucols, vrows = M, N
+    }
+    if flags & WANTU != 0 {
+        if U == nil || U.Rows() != M || U.Cols() != ucols {
+            return sizeError("DecomposeSVD", A, workDims(U))
+        }
+    }
+    if flags & WANTV != 0 {
+        if Vt == nil || Vt.Rows() != vrows || Vt.Cols() != N {
+            return sizeError("DecomposeSVD", A, workDims(Vt))
+        }
+    }
+    if K == 0 {
+        return nil
+    }
+    if nb != 0 {
+        if W == nil {
+            W = matrix.FloatZeros(imax(M, N), nb)
+        } else if W.Cols() < nb || W.Rows() < imax(M, N) {
+            return sizeError("DecomposeSVD", A, W)
+        }
+    }
+
+    if M >= N {
+        if flags & WANTV != 0 {
+            Vtall = matrix.FloatZeros(N, N)
+        }
+        if flags & WANTU == 0 {
+            U = nil
+        }
+        if err := svdTall(A, S, U, Vtall, W, nb); err != nil {
+            return err
+        }
+        if Vtall != nil {
+            ScalePlus(Vt, Vtall, 0.0, 1.0, TRANSB)
+        }
+        return nil
+    }
+
+    // here M < N; A.T = Ut*S*V.T and A = V*S*Ut.T
+    if flags & WANTV != 0 {
+        Ut = matrix.FloatZeros(N, vrows)
+    }
+    if flags & WANTU != 0 {
+        Vtall = matrix.FloatZeros(M, M)
+    }
+    if err := svdTall(A.Transpose(), S, Ut, Vtall, W, nb); err != nil {
+        return err
+    }
+    if Vtall != nil {
+        ScalePlus(U, Vtall, 0.0, 1.0, NOTRANS)
+    }
+    if Ut != nil {
+        ScalePlus(Vt, Ut, 0.0, 1.0, TRANSB)
+    }
+    return nil
+}
+
+// Local Variables:
+// tab-width: 4
+// indent-tabs-mode: nil
+// End:
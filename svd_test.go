@@ -0,0 +1,142 @@
+
+// Copyright (c) Harri Rautila, 2013
+
+// This file is part of github.com/hrautila/matops package. It is free software,
+// distributed under the terms of GNU Lesser General Public License Version 3, or
+// any later version. See the COPYING tile included in this archive.
+
+package matops
+
+import (
+    "github.com/hrautila/matrix"
+    "math"
+    "testing"
+)
+
+// ||A - U*diag(S)*Vt||_1
+func svdResidual(A, S, U, Vt *matrix.FloatMatrix) float64 {
+    var Uk, Vk matrix.FloatMatrix
+    K := S.NumElements()
+    U.SubMatrix(&Uk, 0, 0, U.Rows(), K)
+    Vt.SubMatrix(&Vk, 0, 0, K, Vt.Cols())
+    US := Uk.Copy()
+    MultDiag(US, S, RIGHT)
+    R := A.Copy()
+    Mult(R, US, &Vk, -1.0, 1.0, NOTRANS)
+    return NormP(R, NORM_ONE)
+}
+
+// ||Q.T*Q - I||_1
+func orthoResidual(Q *matrix.FloatMatrix) float64 {
+    I := matrix.FloatZeros(Q.Cols(), Q.Cols())
+    Mult(I, Q, Q, 1.0, 0.0, TRANSA)
+    I.Minus(matrix.FloatDiagonal(Q.Cols(), 1.0))
+    return NormP(I, NORM_ONE)
+}
+
+// Check SVD residual against ||A||_1 and that S is non-negative and sorted descending.
+func checkSVD(t *testing.T, name string, A, S, U, Vt *matrix.FloatMatrix) {
+    eps := math.Pow(2.0, -52.0)
+    N := imax(A.Rows(), A.Cols())
+    if r := svdResidual(A, S, U, Vt); r > 10.0*float64(N)*eps*NormP(A, NORM_ONE) {
+        t.Errorf("%s: ||A - U*S*V.T||_1: %e\n", name, r)
+    }
+    for k := 0; k < S.NumElements(); k++ {
+        if S.GetIndex(k) < 0.0 || (k > 0 && S.GetIndex(k) > S.GetIndex(k-1)) {
+            t.Errorf("%s: S[%d]: %e not non-negative and descending\n", name, k, S.GetIndex(k))
+            break
+        }
+    }
+}
+
+// Check that columns of Q are orthonormal.
+func checkOrtho(t *testing.T, name string, Q *matrix.FloatMatrix) {
+    eps := math.Pow(2.0, -52.0)
+    if r := orthoResidual(Q); r > 10.0*float64(Q.Rows())*eps {
+        t.Errorf("%s: ||Q.T*Q - I||_1: %e\n", name, r)
+    }
+}
+
+func TestDecomposeSVDThin(t *testing.T) {
+    M := 60
+    N := 40
+    nb := 12
+    A := matrix.FloatUniform(M, N)
+    S := matrix.FloatZeros(N, 1)
+    U := matrix.FloatZeros(M, N)
+    Vt := matrix.FloatZeros(N, N)
+
+    err := DecomposeSVD(A.Copy(), S, U, Vt, nil, WANTU|WANTV, nb)
+    if err != nil {
+        t.Errorf("DecomposeSVD error: %v\n", err)
+        return
+    }
+    checkSVD(t, "M >= N", A, S, U, Vt)
+    checkOrtho(t, "M >= N: U", U)
+    checkOrtho(t, "M >= N: V", Vt)
+
+    // wide matrix
+    A = matrix.FloatUniform(N, M)
+    U = matrix.FloatZeros(N, N)
+    Vt = matrix.FloatZeros(N, M)
+    err = DecomposeSVD(A.Copy(), S, U, Vt, nil, WANTU|WANTV, 0)
+    if err != nil {
+        t.Errorf("DecomposeSVD error: %v\n", err)
+        return
+    }
+    checkSVD(t, "M < N", A, S, U, Vt)
+    checkOrtho(t, "M < N: U", U)
+    checkOrtho(t, "M < N: V", Vt.Transpose())
+}
+
+func TestDecomposeSVDFull(t *testing.T) {
+    M := 50
+    N := 35
+    nb := 8
+    A := matrix.FloatUniform(M, N)
+    S := matrix.FloatZeros(N, 1)
+    S0 := matrix.FloatZeros(N, 1)
+    U := matrix.FloatZeros(M, M)
+    Vt := matrix.FloatZeros(N, N)
+
+    err := DecomposeSVD(A.Copy(), S, U, Vt, nil, WANTU|WANTV|FULL, nb)
+    if err != nil {
+        t.Errorf("DecomposeSVD error: %v\n", err)
+        return
+    }
+    checkSVD(t, "full", A, S, U, Vt)
+    checkOrtho(t, "full: U", U)
+    checkOrtho(t, "full: V", Vt)
+
+    // values only
+    eps := math.Pow(2.0, -52.0)
+    if err = DecomposeSVD(A.Copy(), S0, nil, nil, nil, 0, 0); err != nil {
+        t.Errorf("DecomposeSVD values only error: %v\n", err)
+        return
+    }
+    S0.Minus(S)
+    if r := NormP(S0, NORM_ONE); r > 10.0*float64(M)*eps*S.GetAt(0, 0) {
+        t.Errorf("||S - S0||_1: %e\n", r)
+    }
+    if d := math.Abs(NormP(A, NORM_TWO) - S.GetAt(0, 0)); d > 10.0*float64(M)*eps*S.GetAt(0, 0) {
+        t.Errorf("||A||_2: %e, S[0]: %e\n", NormP(A, NORM_TWO), S.GetAt(0, 0))
+    }
+
+    // wide matrix
+    A = matrix.FloatUniform(N, M)
+    U = matrix.FloatZeros(N, N)
+    Vt = matrix.FloatZeros(M, M)
+    err = DecomposeSVD(A.Copy(), S, U, Vt, nil, WANTU|WANTV|FULL, nb)
+    if err != nil {
+        t.Errorf("DecomposeSVD error: %v\n", err)
+        return
+    }
+    checkSVD(t, "full M < N", A, S, U, Vt)
+    checkOrtho(t, "full M < N: U", U)
+    checkOrtho(t, "full M < N: V", Vt)
+}
+
+// Local Variables:
+// tab-width: 4
+// indent-tabs-mode: nil
+// End:
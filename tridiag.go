@@ -0,0 +1,206 @@
+
+// Copyright (c) Harri Rautila, 2013
+
+// This file is part of github.com/hrautila/matops package. It is free software,
+// distributed under the terms of GNU Lesser General Public License Version 3, or
+// any later version. See the COPYING tile included in this archive.
+
+package matops
+
+import (
+    "github.com/hrautila/matrix"
+    "math"
+)
+
+// Check that diagonals are vectors of matching lengths and B has as many rows as d.
+func checkTridiag(op string, dl, d, du, B *matrix.FloatMatrix) error {
+    dims := []dimensions{d, du, B}
+    if dl != nil {
+        dims = []dimensions{dl, d, du, B}
+    }
+    if ! isVector(d) || (dl != nil && ! isVector(dl)) || ! isVector(du) {
+        return sizeError(op, dims...)
+    }
+    N := d.NumElements()
+    if N > 1 && (du.NumElements() < N-1 || (dl != nil && dl.NumElements() < N-1)) {
+        return sizeError(op, dims...)
+    }
+    if B.Rows() != N {
+        return sizeError(op, dims...)
+    }
+    return nil
+}
+
+/*
+ * Solve a system of linear equations A*X = B with N-by-N tridiagonal matrix A
+ * using Gaussian elimination with partial pivoting.
+ *
+ * Arguments:
+ *  dl    On entry, the N-1 subdiagonal elements of A. On exit, the N-2 elements
+ *        of the second superdiagonal of the upper triangular U from factorization
+ *        A = L*U.
+ *
+ *  d     On entry, the N diagonal elements of A. On exit, the N diagonal elements
+ *        of U.
+ *
+ *  du    On entry, the N-1 superdiagonal elements of A. On exit, the N-1 elements
+ *        of the first superdiagonal of U.
+ *
+ *  B     On entry, the right hand side matrix B. On exit, the solution
+ *        matrix X.
+ *
+ * Returns:
+ *  Error indicator. Error is returned if dl is nil, diagonal lengths do not
+ *  match B, or if exactly zero pivot was found and the solution was not
+ *  computed.
+ *
+ * Compatible with lapack.DGTSV
+ */
+func SolveTridiag(dl, d, du, B *matrix.FloatMatrix) error {
+    if dl == nil {
+        return sizeError("SolveTridiag", shape{0, 0}, d, du, B)
+    }
+    if err := checkTridiag("SolveTridiag", dl, d, du, B); err != nil {
+        return err
+    }
+    N := d.NumElements()
+    Br := B.FloatArray()
+    ldB := B.LeadingIndex()
+    nrhs := B.Cols()
+
+    for i := 0; i < N-1; i++ {
+        di := d.GetIndex(i)
+        dli := dl.GetIndex(i)
+        if math.Abs(di) >= math.Abs(dli) {
+            // no row interchange required
+            if di == 0.0 {
+                return &SingularError{i}
+            }
+            fact := dli/di
+            d.SetIndex(i+1, d.GetIndex(i+1) - fact*du.GetIndex(i))
+            for k := 0; k < nrhs; k++ {
+                Br[i+1+k*ldB] -= fact*Br[i+k*ldB]
+            }
+            if i < N-2 {
+                dl.SetIndex(i, 0.0)
+            }
+        } else {
+            // interchange rows i and i+1
+            fact := di/dli
+            d.SetIndex(i, dli)
+            temp := d.GetIndex(i+1)
+            d.SetIndex(i+1, du.GetIndex(i) - fact*temp)
+            if i < N-2 {
+                dl.SetIndex(i, du.GetIndex(i+1))
+                du.SetIndex(i+1, -fact*dl.GetIndex(i))
+            }
+            du.SetIndex(i, temp)
+            for k := 0; k < nrhs; k++ {
+                temp = Br[i+k*ldB]
+                Br[i+k*ldB] = Br[i+1+k*ldB]
+                Br[i+1+k*ldB] = temp - fact*Br[i+1+k*ldB]
+            }
+        }
+    }
+    if N > 0 && d.GetIndex(N-1) == 0.0 {
+        return &SingularError{N-1}
+    }
+    // back solve with U
+    for k := 0; k < nrhs; k++ {
+        b := Br[k*ldB:]
+        for i := N-1; i >= 0; i-- {
+            bi := b[i]
+            if i < N-1 {
+                bi -= du.GetIndex(i)*b[i+1]
+            }
+            if i < N-2 {
+                bi -= dl.GetIndex(i)*b[i+2]
+            }
+            b[i] = bi/d.GetIndex(i)
+        }
+    }
+    return nil
+}
+
+/*
+ * Compute the L*D*L.T factorization of a symmetric positive definite N-by-N
+ * tridiagonal matrix A.
+ *
+ * Arguments:
+ *  d     On entry, the N diagonal elements of A. On exit, the N diagonal elements
+ *        of D.
+ *
+ *  e     On entry, the N-1 subdiagonal elements of A. On exit, the N-1 subdiagonal
+ *        elements of the unit lower bidiagonal factor L.
+ *
+ * Returns:
+ *  Error indicator. Error is returned if leading minor of some order is not
+ *  positive definite.
+ *
+ * Compatible with lapack.DPTTRF
+ */
+func DecomposeTridiagSPD(d, e *matrix.FloatMatrix) error {
+    if ! isVector(d) || ! isVector(e) {
+        return sizeError("DecomposeTridiagSPD", d, e)
+    }
+    N := d.NumElements()
+    if N > 1 && e.NumElements() < N-1 {
+        return sizeError("DecomposeTridiagSPD", d, e)
+    }
+    for i := 0; i < N; i++ {
+        di := d.GetIndex(i)
+        if di <= 0.0 || math.IsNaN(di) {
+            return &NotPositiveDefiniteError{i}
+        }
+        if i < N-1 {
+            ei := e.GetIndex(i)
+            e.SetIndex(i, ei/di)
+            d.SetIndex(i+1, d.GetIndex(i+1) - ei*ei/di)
+        }
+    }
+    return nil
+}
+
+/*
+ * Solve a system of linear equations A*X = B with symmetric positive definite
+ * tridiagonal matrix A using the factorization A = L*D*L.T computed by
+ * DecomposeTridiagSPD().
+ *
+ * Arguments:
+ *  d     The N diagonal elements of D.
+ *
+ *  e     The N-1 subdiagonal elements of L.
+ *
+ *  B     On entry, the right hand side matrix B. On exit, the solution
+ *        matrix X.
+ *
+ * Compatible with lapack.DPTTRS
+ */
+func SolveTridiagSPD(d, e, B *matrix.FloatMatrix) error {
+    if err := checkTridiag("SolveTridiagSPD", nil, d, e, B); err != nil {
+        return err
+    }
+    N := d.NumElements()
+    Br := B.FloatArray()
+    ldB := B.LeadingIndex()
+    for k := 0; k < B.Cols(); k++ {
+        b := Br[k*ldB:]
+        // solve L*Z = B
+        for i := 1; i < N; i++ {
+            b[i] -= b[i-1]*e.GetIndex(i-1)
+        }
+        // solve D*L.T*X = Z
+        if N > 0 {
+            b[N-1] /= d.GetIndex(N-1)
+        }
+        for i := N-2; i >= 0; i-- {
+            b[i] = b[i]/d.GetIndex(i) - b[i+1]*e.GetIndex(i)
+        }
+    }
+    return nil
+}
+
+// Local Variables:
+// tab-width: 4
+// indent-tabs-mode: nil
+// End:
@@ -0,0 +1,102 @@
+
+// Copyright (c) Harri Rautila, 2013
+
+// This file is part of github.com/hrautila/matops package. It is free software,
+// distributed under the terms of GNU Lesser General Public License Version 3, or
+// any later version. See the COPYING tile included in this archive.
+
+package matops
+
+import (
+    "github.com/hrautila/matrix"
+    "testing"
+)
+
+// Dense tridiagonal matrix from diagonals.
+func tridiagMatrix(dl, d, du *matrix.FloatMatrix) *matrix.FloatMatrix {
+    N := d.NumElements()
+    A := matrix.FloatZeros(N, N)
+    for i := 0; i < N; i++ {
+        A.SetAt(i, i, d.GetIndex(i))
+        if i < N-1 {
+            A.SetAt(i+1, i, dl.GetIndex(i))
+            A.SetAt(i, i+1, du.GetIndex(i))
+        }
+    }
+    return A
+}
+
+// Normwise backward error ||B - A*X||_1/(||A||_1*||X||_1) of solution X.
+func backwardError(A, X, B *matrix.FloatMatrix) float64 {
+    R := B.Copy()
+    Mult(R, A, X, -1.0, 1.0, NOTRANS)
+    return NormP(R, NORM_ONE) / (NormP(A, NORM_ONE) * NormP(X, NORM_ONE))
+}
+
+func TestSolveTridiag(t *testing.T) {
+    N := 40
+    dl := matrix.FloatUniform(N-1, 1)
+    d := matrix.FloatUniform(N, 1)
+    du := matrix.FloatUniform(1, N-1)
+    // small diagonal forces row interchanges
+    d.Scale(0.5)
+    A := tridiagMatrix(dl, d, du)
+
+    X0 := matrix.FloatUniform(N, 3)
+    B := matrix.FloatZeros(N, 3)
+    Mult(B, A, X0, 1.0, 0.0, NOTRANS)
+    B0 := B.Copy()
+    err := SolveTridiag(dl, d, du, B)
+    if err != nil {
+        t.Fatalf("solve error: %v\n", err)
+    }
+    berr := backwardError(A, B, B0)
+    B.Minus(X0)
+    t.Logf("||X - A.-1*(A*X)||_1: %e, backward error: %e\n", NormP(B, NORM_ONE), berr)
+    if berr > 1e-14 {
+        t.Errorf("backward error %e too large\n", berr)
+    }
+
+    // subdiagonal is required
+    if err = SolveTridiag(nil, d, du, B0); err == nil {
+        t.Errorf("nil subdiagonal: no error\n")
+    }
+}
+
+func TestSolveTridiagSPD(t *testing.T) {
+    N := 40
+    e := matrix.FloatUniform(N-1, 1)
+    d := matrix.FloatUniform(N, 1)
+    d.Add(2.0)
+    A := tridiagMatrix(e, d, e)
+
+    err := DecomposeTridiagSPD(d, e)
+    if err != nil {
+        t.Errorf("decompose error: %v\n", err)
+        return
+    }
+    X0 := matrix.FloatUniform(N, 3)
+    B := matrix.FloatZeros(N, 3)
+    Mult(B, A, X0, 1.0, 0.0, NOTRANS)
+    if err = SolveTridiagSPD(d, e, B); err != nil {
+        t.Fatalf("solve error: %v\n", err)
+    }
+    B.Minus(X0)
+    t.Logf("||X - A.-1*(A*X)||_1: %e\n", NormP(B, NORM_ONE))
+    // A is diagonally dominant with condition number < 4
+    if nrm := NormP(B, NORM_ONE); nrm > 1e-12 {
+        t.Errorf("||X - A.-1*(A*X)||_1: %e too large\n", nrm)
+    }
+
+    // not positive definite
+    d = matrix.FloatUniform(N, 1)
+    d.SetIndex(N/2, -1.0)
+    if err = DecomposeTridiagSPD(d, e); err == nil {
+        t.Errorf("indefinite matrix: no error\n")
+    }
+}
+
+// Local Variables:
+// tab-width: 4
+// indent-tabs-mode: nil
+// End:
@@ -0,0 +1,298 @@
+
+// Copyright (c) Harri Rautila, 2013
+
+// This file is part of github.com/hrautila/matops package. It is free software,
+// distributed under the terms of GNU Lesser General Public License Version 3, or
+// any later version. See the COPYING tile included in this archive.
+
+package matops
+
+import (
+    "github.com/hrautila/matrix"
+    "github.com/hrautila/matops/calgo"
+    "context"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "io"
+    "io/ioutil"
+    "os"
+    "path/filepath"
+    "runtime"
+    "time"
+)
+
+// Environment variable naming the profile loaded at package initialization and
+// by LoadProfile("").
+const ProfileEnv = "MATOPS_PROFILE"
+
+// Candidate block sizes; kernels limit KB and NB to 196 and MB to 68.
+// Kernels copy KB*NB and KB*MB blocks to buffers of tuneMaxTile elements.
+const tuneMaxTile = 196*68
+var tuneKB []int = []int{64, 96, 128, 160, 196}
+var tuneNB []int = []int{32, 48, 64, 96, 128, 160, 196}
+var tuneMB []int = []int{24, 36, 48, 60, 68}
+var tuneDecompNB []int = []int{16, 32, 48, 64, 96, 128}
+
+// Number of timed runs of each candidate, fastest one counts.
+const tuneRuns = 3
+
+/*
+ * Profile holds block sizes tuned for one machine. It is written by Autotune()
+ * and the matops-tune command and applied with LoadProfile(). At package
+ * initialization only the file named by environment variable MATOPS_PROFILE is
+ * loaded; DefaultProfilePath() is never read implicitly.
+ */
+type Profile struct {
+    // Blocking parameters of matrix-matrix kernels, see BlockingParams().
+    MB int `json:"mb"`
+    NB int `json:"nb"`
+    KB int `json:"kb"`
+    // Block size of blocked factorizations, see DecomposeBlockSize().
+    DecompNB int `json:"decomp_nb"`
+    // Matrix size used in tuning and the machine tuned.
+    Size   int    `json:"size"`
+    Host   string `json:"host,omitempty"`
+    GOARCH string `json:"goarch"`
+    NumCPU int    `json:"numcpu"`
+    Date   time.Time `json:"date"`
+}
+
+// Default location of tuning profile, matops/profile.json in user configuration
+// directory.
+func DefaultProfilePath() (string, error) {
+    dir, err := os.UserConfigDir()
+    if err != nil {
+        return "", err
+    }
+    return filepath.Join(dir, "matops", "profile.json"), nil
+}
+
+// Read tuning profile from file path.
+func ReadProfile(path string) (*Profile, error) {
+    buf, err := ioutil.ReadFile(path)
+    if err != nil {
+        return nil, err
+    }
+    p := &Profile{}
+    if err = json.Unmarshal(buf, p); err != nil {
+        return nil, errors.New(fmt.Sprintf("%s: %v", path, err))
+    }
+    if p.MB <= 0 || p.NB <= 0 || p.KB <= 0 || p.DecompNB < 0 ||
+        p.KB*p.NB > tuneMaxTile || p.KB*p.MB > tuneMaxTile {
+        return nil, errors.New(fmt.Sprintf("%s: invalid block sizes", path))
+    }
+    return p, nil
+}
+
+// Write profile to file path, creating the directory if needed.
+func (p *Profile) Save(path string) error {
+    buf, err := json.MarshalIndent(p, "", "  ")
+    if err != nil {
+        return err
+    }
+    if err = os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+        return err
+    }
+    return ioutil.WriteFile(path, append(buf, '\n'), 0644)
+}
+
+// Set package block sizes from profile. Calls already running may use old or
+// new block sizes; see Config for per-call parameters.
+func (p *Profile) Apply() {
+    BlockingParams(p.MB, p.NB, p.KB)
+    DecomposeBlockSize(p.DecompNB)
+}
+
+// Configuration with block sizes from profile and other parameters from package
+// defaults.
+func (p *Profile) Config() *Config {
+    conf := NewConfig()
+    conf.MB, conf.NB, conf.KB, conf.DecompNB = p.MB, p.NB, p.KB, p.DecompNB
+    return conf
+}
+
+/*
+ * Read tuning profile and set package block sizes from it.
+ *
+ * Arguments:
+ *  path  Profile file. If empty the file named by MATOPS_PROFILE is used and
+ *        if that is not set, DefaultProfilePath().
+ *
+ * Returns:
+ *  Applied profile and error indicator. On error package parameters are
+ *  not changed.
+ */
+func LoadProfile(path string) (*Profile, error) {
+    if path == "" {
+        path = os.Getenv(ProfileEnv)
+    }
+    if path == "" {
+        var err error
+        if path, err = DefaultProfilePath(); err != nil {
+            return nil, err
+        }
+    }
+    p, err := ReadProfile(path)
+    if err != nil {
+        return nil, err
+    }
+    p.Apply()
+    return p, nil
+}
+
+// Load profile named by MATOPS_PROFILE at startup; without it package defaults
+// are kept. Errors are not reported here, LoadProfile("") returns the same
+// outcome to programs that want to check it.
+func init() {
+    if path := os.Getenv(ProfileEnv); path != "" {
+        LoadProfile(path)
+    }
+}
+
+// Fastest of tuneRuns runs of fn.
+func timeBest(fn func()) time.Duration {
+    var best time.Duration
+    for k := 0; k < tuneRuns; k++ {
+        start := time.Now()
+        fn()
+        if t := time.Since(start); k == 0 || t < best {
+            best = t
+        }
+    }
+    return best
+}
+
+// Candidate not larger than max from cands with smallest time; cost(c) times
+// candidate c.
+func tuneOne(cands []int, max int, cost func(c int) time.Duration, name string, w io.Writer) int {
+    best := cands[0]
+    var tbest time.Duration
+    for k, c := range cands {
+        if c > max {
+            break
+        }
+        t := cost(c)
+        if w != nil {
+            fmt.Fprintf(w, "  %s=%-4d %v\n", name, c, t)
+        }
+        if k == 0 || t < tbest {
+            best, tbest = c, t
+        }
+    }
+    return best
+}
+
+/*
+ * Find block sizes for this machine by timing calgo.DMult, calgo.DMultSymm and
+ * calgo.DSolveBlk and blocked LU, Cholesky and QR factorizations of N-by-N
+ * matrices over candidate block sizes. Kernel block sizes are tuned one at time,
+ * factorization block size with tuned kernel parameters. Kernels are run in the
+ * calling goroutine and package parameters are not changed; use Apply() or
+ * Save() on the result.
+ *
+ * Arguments:
+ *  N    Size of test matrices, sizes of typical problems are good choices.
+ *
+ *  w    If not nil, timings are written to w.
+ *
+ * Returns:
+ *  Tuned profile and error indicator.
+ */
+func Autotune(N int, w io.Writer) (*Profile, error) {
+    if N <= 0 {
+        return nil, errors.New("Autotune: N <= 0")
+    }
+    A := matrix.FloatUniform(N, N)
+    B := matrix.FloatUniform(N, N)
+    C := matrix.FloatZeros(N, N)
+    // well conditioned lower triangular matrix for solves
+    L := matrix.FloatUniform(N, N)
+    L.Scale(1.0/float64(N))
+    for k := 0; k < N; k++ {
+        L.SetAt(k, k, 1.0)
+    }
+    Ar, Br, Cr, Lr := A.FloatArray(), B.FloatArray(), C.FloatArray(), L.FloatArray()
+
+    p := &Profile{MB: defaultMB, NB: defaultNB, KB: defaultKB, Size: N,
+        GOARCH: runtime.GOARCH, NumCPU: runtime.NumCPU(), Date: time.Now()}
+    p.Host, _ = os.Hostname()
+
+    kernels := func(kb, nb, mb int) time.Duration {
+        return timeBest(func() {
+            calgo.DMult(Cr, Ar, Br, 1.0, 0.0, calgo.NOTRANS, N, N, N, N,
+                0, N, 0, N, kb, nb, mb)
+        }) + timeBest(func() {
+            calgo.DMultSymm(Cr, Ar, Br, 1.0, 0.0, calgo.Flags(LEFT|LOWER), N, N, N, N,
+                0, N, 0, N, kb, nb, mb)
+        }) + timeBest(func() {
+            calgo.DSolveBlk(Br, Lr, 1.0, calgo.Flags(LEFT|LOWER), N, N, N, 0, N, nb)
+        })
+    }
+    if w != nil {
+        fmt.Fprintf(w, "kernels, N=%d\n", N)
+    }
+    p.KB = tuneOne(tuneKB, tuneMaxTile/p.NB,
+        func(kb int) time.Duration { return kernels(kb, p.NB, p.MB) }, "KB", w)
+    p.NB = tuneOne(tuneNB, tuneMaxTile/p.KB,
+        func(nb int) time.Duration { return kernels(p.KB, nb, p.MB) }, "NB", w)
+    p.MB = tuneOne(tuneMB, tuneMaxTile/p.KB,
+        func(mb int) time.Duration { return kernels(p.KB, p.NB, mb) }, "MB", w)
+
+    // symmetric positive definite matrix for Cholesky
+    S := matrix.FloatZeros(N, N)
+    Mult(S, A, A, 1.0, 0.0, TRANSB)
+    for k := 0; k < N; k++ {
+        S.SetAt(k, k, S.GetAt(k, k) + float64(N))
+    }
+    pivots := make([]int, N)
+    tau := matrix.FloatZeros(N, 1)
+    bg := context.Background()
+    var err error
+    decomp := func(nb int) time.Duration {
+        conf := p.Config()
+        conf.DecompNB = nb
+        conf.Workers = 1
+        var t time.Duration
+        X := matrix.FloatZeros(N, N)
+        timed := func(Y *matrix.FloatMatrix, fn func() error) {
+            var best time.Duration
+            for k := 0; k < tuneRuns; k++ {
+                Y.CopyTo(X)
+                start := time.Now()
+                if e := fn(); e != nil && err == nil {
+                    err = e
+                }
+                if d := time.Since(start); k == 0 || d < best {
+                    best = d
+                }
+            }
+            t += best
+        }
+        timed(A, func() error {
+            _, e := DecomposeLUConfig(bg, conf, X, pivots)
+            return e
+        })
+        timed(S, func() error {
+            _, e := DecomposeCHOLConfig(bg, conf, X, LOWER)
+            return e
+        })
+        timed(A, func() error {
+            _, e := DecomposeQRConfig(bg, conf, X, tau, nil)
+            return e
+        })
+        return t
+    }
+    if N > tuneDecompNB[0] {
+        if w != nil {
+            fmt.Fprintf(w, "factorizations, N=%d\n", N)
+        }
+        p.DecompNB = tuneOne(tuneDecompNB, N-1, decomp, "DecompNB", w)
+    }
+    return p, err
+}
+
+// Local Variables:
+// tab-width: 4
+// indent-tabs-mode: nil
+// End:
@@ -0,0 +1,78 @@
+
+// Copyright (c) Harri Rautila, 2013
+
+// This file is part of github.com/hrautila/matops package. It is free software,
+// distributed under the terms of GNU Lesser General Public License Version 3, or
+// any later version. See the COPYING tile included in this archive.
+
+package matops
+
+import (
+    "path/filepath"
+    "testing"
+)
+
+func TestAutotune(t *testing.T) {
+    p, err := Autotune(120, nil)
+    if err != nil {
+        t.Fatalf("Autotune: %v\n", err)
+    }
+    t.Logf("MB=%d NB=%d KB=%d DecompNB=%d\n", p.MB, p.NB, p.KB, p.DecompNB)
+    if p.MB <= 0 || p.NB <= 0 || p.KB <= 0 || p.DecompNB <= 0 || p.DecompNB >= 120 {
+        t.Errorf("invalid profile: %+v\n", p)
+    }
+
+    path := filepath.Join(t.TempDir(), "matops", "profile.json")
+    if err = p.Save(path); err != nil {
+        t.Fatalf("Save: %v\n", err)
+    }
+    p1, err := ReadProfile(path)
+    if err != nil {
+        t.Fatalf("ReadProfile: %v\n", err)
+    }
+    if p1.MB != p.MB || p1.NB != p.NB || p1.KB != p.KB || p1.DecompNB != p.DecompNB {
+        t.Errorf("read %+v, saved %+v\n", p1, p)
+    }
+    conf := p1.Config()
+    if conf.MB != p.MB || conf.NB != p.NB || conf.KB != p.KB || conf.DecompNB != p.DecompNB {
+        t.Errorf("Config %+v from profile %+v\n", conf, p1)
+    }
+    if _, err = ReadProfile(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+        t.Errorf("missing profile read without error\n")
+    }
+}
+
+func TestLoadProfile(t *testing.T) {
+    g := globalParams()
+    defer func() {
+        BlockingParams(g.MB, g.NB, g.KB)
+        DecomposeBlockSize(g.DecompNB)
+    }()
+    p := &Profile{MB: 48, NB: 96, KB: 128, DecompNB: 32}
+    path := filepath.Join(t.TempDir(), "profile.json")
+    if err := p.Save(path); err != nil {
+        t.Fatalf("Save: %v\n", err)
+    }
+
+    // missing profile does not change parameters
+    if _, err := LoadProfile(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+        t.Errorf("missing profile loaded without error\n")
+    }
+    if c := globalParams(); c.MB != g.MB || c.NB != g.NB || c.KB != g.KB || c.DecompNB != g.DecompNB {
+        t.Errorf("parameters changed by failed load: %+v\n", c)
+    }
+
+    // empty path uses MATOPS_PROFILE
+    t.Setenv(ProfileEnv, path)
+    if _, err := LoadProfile(""); err != nil {
+        t.Fatalf("LoadProfile: %v\n", err)
+    }
+    if c := globalParams(); c.MB != p.MB || c.NB != p.NB || c.KB != p.KB || c.DecompNB != p.DecompNB {
+        t.Errorf("parameters %+v not from profile %+v\n", c, p)
+    }
+}
+
+// Local Variables:
+// tab-width: 4
+// indent-tabs-mode: nil
+// End:
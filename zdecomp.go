@@ -0,0 +1,561 @@
+// Copyright (c) Harri Rautila, 2013
+
+// This file is part of github.com/hrautila/matops package. It is free software,
+// distributed under the terms of GNU Lesser General Public License Version 3, or
+// any later version. See the COPYING tile included in this archive.
+
+package matops
+
+import (
+    "github.com/hrautila/matops/calgo"
+    "fmt"
+    "math"
+    "math/cmplx"
+)
+
+// Double precision complex Cholesky, LU and QR factorizations. Algorithms are the
+// same as in chol.go, lu.go and qrwy.go and run serially.
+
+func unblockedZCHOL(A *Complex128Matrix, flags Flags, nr int) error {
+    var ATL, ATR, ABL, ABR Complex128Matrix
+    var A00, a01, A02, a10, a11, a12, A20, a21, A22 Complex128Matrix
+
+    partition2x2(
+        &ATL, &ATR,
+        &ABL, &ABR,   A, 0, 0, pTOPLEFT)
+
+    for ATL.Rows() < A.Rows() {
+        repartition2x2to3x3(&ATL,
+            &A00, &a01, &A02,
+            &a10, &a11, &a12,
+            &A20, &a21, &A22,   A, 1, pBOTTOMRIGHT)
+
+        // a11 = sqrt(a11); diagonal of Hermitian matrix is real
+        d := real(a11.Complex())
+        if ! (d > 0.0) {
+            return fmt.Errorf("illegal value at %d: %e", nr+ATL.Rows(), d)
+        }
+        d = math.Sqrt(d)
+        a11.SetAt(0, 0, complex(d, 0.0))
+
+        if flags & LOWER != 0 {
+            // a21 = a21/a11
+            zInvScale(&a21, complex(d, 0.0))
+            // A22 = A22 - a21*a21.H (HER)
+            ZRankUpdateHerm(&A22, &a21, -1.0, 1.0, LOWER)
+        } else {
+            // a12 = a12/a11
+            zInvScale(&a12, complex(d, 0.0))
+            // A22 = A22 - a12.H*a12 (HER)
+            ZRankUpdateHerm(&A22, &a12, -1.0, 1.0, UPPER|TRANSA)
+        }
+
+        continue3x3to2x2(
+            &ATL, &ATR,
+            &ABL, &ABR,   &A00, &a11, &A22,  A, pBOTTOMRIGHT)
+    }
+    return nil
+}
+
+func blockedZCHOL(A *Complex128Matrix, flags Flags, nb int) error {
+    var ATL, ATR, ABL, ABR Complex128Matrix
+    var A00, A01, A02, A10, A11, A12, A20, A21, A22 Complex128Matrix
+
+    partition2x2(
+        &ATL, &ATR,
+        &ABL, &ABR,   A, 0, 0, pTOPLEFT)
+
+    for ATL.Rows() < A.Rows() && ATL.Cols() < A.Cols() {
+        repartition2x2to3x3(&ATL,
+            &A00, &A01, &A02,
+            &A10, &A11, &A12,
+            &A20, &A21, &A22,   A, nb, pBOTTOMRIGHT)
+
+        // A11 = chol(A11)
+        if err := unblockedZCHOL(&A11, flags, ATL.Rows()); err != nil {
+            return err
+        }
+
+        if flags & LOWER != 0 {
+            // A21 = A21 * tril(A11).-H
+            ZSolveTrm(&A21, &A11, 1.0, RIGHT|LOWER|TRANSA)
+            // A22 = A22 - A21*A21.H
+            ZRankUpdateHerm(&A22, &A21, -1.0, 1.0, LOWER)
+        } else {
+            // A12 = triu(A11).-H * A12
+            ZSolveTrm(&A12, &A11, 1.0, LEFT|UPPER|TRANSA)
+            // A22 = A22 - A12.H*A12
+            ZRankUpdateHerm(&A22, &A12, -1.0, 1.0, UPPER|TRANSA)
+        }
+
+        continue3x3to2x2(
+            &ATL, &ATR,
+            &ABL, &ABR,   &A00, &A11, &A22,   A, pBOTTOMRIGHT)
+    }
+    return nil
+}
+
+/*
+ * Compute the Cholesky factorization of a Hermitian positive definite complex
+ * N-by-N matrix A.
+ *
+ * Arguments:
+ *  A     On entry, the Hermitian matrix A. If flags&UPPER the upper triangular part
+ *        of A holds the upper triangular part of the matrix, and the strictly lower
+ *        triangular part of A is not referenced. If flags&LOWER the lower triangular
+ *        part of A holds the lower triangular part of the matrix, and the strictly
+ *        upper triangular part of A is not referenced.
+ *
+ *        On exit, if no error the factor U or L from the Cholesky factorization
+ *        A = U.H*U or A = L*L.H.
+ *
+ *  flags The matrix structure indicator, UPPER for upper tridiagonal and LOWER for
+ *        lower tridiagonal matrix.
+ *
+ *  nb    The block size for blocked invocations. If block size is zero or A.Cols()
+ *        < nb unblocked algorithm is used.
+ *
+ * Compatible with lapack.ZPOTRF
+ */
+func ZDecomposeCHOL(A *Complex128Matrix, flags Flags, nb int) (*Complex128Matrix, error) {
+    if A.Cols() != A.Rows() {
+        return A, sizeError("ZDecomposeCHOL", A)
+    }
+    if A.Cols() < nb || nb == 0 {
+        return A, unblockedZCHOL(A, flags, 0)
+    }
+    return A, blockedZCHOL(A, flags, nb)
+}
+
+// Swap rows src and dst of A.
+func zSwapRows(A *Complex128Matrix, src, dst int) {
+    var r0, r1 Complex128Matrix
+    if src == dst || A.Rows() == 0 || A.Cols() == 0 {
+        return
+    }
+    A.SubMatrix(&r0, src, 0, 1, A.Cols())
+    A.SubMatrix(&r1, dst, 0, 1, A.Cols())
+    calgo.ZSwap(r0.Complex128Array(), r1.Complex128Array(), r0.LeadingIndex(),
+        r1.LeadingIndex(), A.Cols())
+}
+
+func zApplyPivots(A *Complex128Matrix, p *pPivots) {
+    for k, n := range p.pivots {
+        if n > 0 {
+            zSwapRows(A, n, k)
+        }
+    }
+}
+
+// Find largest absolute value on column
+func zPivotIndex(A *Complex128Matrix, p *pPivots) {
+    p.pivots[0] = calgo.ZIAMax(A.Complex128Array(), 1, A.Rows())
+}
+
+// unblocked LU decomposition with pivots: FLAME LU variant 3
+func unblockedZLUpiv(A *Complex128Matrix, p *pPivots) error {
+    var ATL, ATR, ABL, ABR Complex128Matrix
+    var A00, a01, A02, a10, a11, a12, A20, a21, A22 Complex128Matrix
+    var AL, AR, A0, a1, A2, aB1, AB0 Complex128Matrix
+    var pT, pB, p0, p1, p2 pPivots
+
+    partition2x2(
+        &ATL, &ATR,
+        &ABL, &ABR, A, 0, 0, pTOPLEFT)
+    partition1x2(
+        &AL, &AR, A, 0, pLEFT)
+    partitionPivot2x1(
+        &pT,
+        &pB, p, 0, pTOP)
+
+    for ATL.Rows() < A.Rows() && ATL.Cols() < A.Cols() {
+        repartition2x2to3x3(&ATL,
+            &A00, &a01, &A02,
+            &a10, &a11, &a12,
+            &A20, &a21, &A22,   /**/ A, 1, pBOTTOMRIGHT)
+        repartition1x2to1x3(&AL,
+            &A0, &a1, &A2,   /**/ A, 1, pRIGHT)
+        repartPivot2x1to3x1(&pT,
+            &p0, &p1, &p2,   /**/ p, 1, pBOTTOM)
+
+        // apply previously computed pivots
+        zApplyPivots(&a1, &p0)
+
+        // a01 = trilu(A00) \ a01 (TRSV)
+        ZSolveTrm(&a01, &A00, 1.0, LEFT|LOWER|UNIT)
+        // a11 = a11 - a10 *a01
+        a11.SetAt(0, 0, a11.Complex() + zDotu(&a10, &a01, -1.0))
+        // a21 = a21 -A20*a01
+        ZMVMult(&a21, &A20, &a01, -1.0, 1.0, NOTRANS)
+
+        // pivot index on current column [a11, a21].T
+        ABR.SubMatrix(&aB1, 0, 0, ABR.Rows(), 1)
+        zPivotIndex(&aB1, &p1)
+
+        // pivots to current column
+        zApplyPivots(&aB1, &p1)
+
+        // a21 = a21 / a11
+        zInvScale(&a21, a11.Complex())
+
+        // apply pivots to previous columns
+        ABL.SubMatrix(&AB0, 0, 0)
+        zApplyPivots(&AB0, &p1)
+        // scale last pivots to origin matrix row numbers
+        p1.pivots[0] += ATL.Rows()
+
+        continue3x3to2x2(
+            &ATL, &ATR,
+            &ABL, &ABR,   &A00, &a11, &A22,   A, pBOTTOMRIGHT)
+        continue1x3to1x2(
+            &AL, &AR,     &A0, &a1,   A, pRIGHT)
+        contPivot3x1to2x1(
+            &pT,
+            &pB,    &p0, &p1,    p, pBOTTOM)
+    }
+    if ATL.Cols() < A.Cols() {
+        zApplyPivots(&ATR, p)
+        ZSolveTrm(&ATR, &ATL, 1.0, LEFT|UNIT|LOWER)
+    }
+    return nil
+}
+
+// blocked LU decomposition with pivots: FLAME LU variant 3
+func blockedZLUpiv(A *Complex128Matrix, p *pPivots, nb int) error {
+    var ATL, ATR, ABL, ABR Complex128Matrix
+    var A00, A01, A02, A10, A11, A12, A20, A21, A22 Complex128Matrix
+    var AL, AR, A0, A1, A2, AB1, AB0 Complex128Matrix
+    var pT, pB, p0, p1, p2 pPivots
+
+    partition2x2(
+        &ATL, &ATR,
+        &ABL, &ABR,    A, 0, 0, pTOPLEFT)
+    partition1x2(
+        &AL, &AR,      A, 0, pLEFT)
+    partitionPivot2x1(
+        &pT,
+        &pB,     p, 0, pTOP)
+
+    for ATL.Rows() < A.Rows() && ATL.Cols() < A.Cols() {
+        repartition2x2to3x3(&ATL,
+            &A00, &A01, &A02,
+            &A10, &A11, &A12,
+            &A20, &A21, &A22, A, nb, pBOTTOMRIGHT)
+        repartition1x2to1x3(&AL,
+            &A0, &A1, &A2,  /**/ A, nb, pRIGHT)
+        repartPivot2x1to3x1(&pT,
+            &p0, &p1, &p2,  /**/ p, nb, pBOTTOM)
+
+        // apply previously computed pivots
+        zApplyPivots(&A1, &p0)
+
+        // a01 = trilu(A00) \ a01 (TRSV)
+        ZSolveTrm(&A01, &A00, 1.0, LEFT|LOWER|UNIT)
+        // A11 = A11 - A10*A01
+        ZMult(&A11, &A10, &A01, -1.0, 1.0, NOTRANS)
+        // A21 = A21 - A20*A01
+        ZMult(&A21, &A20, &A01, -1.0, 1.0, NOTRANS)
+
+        // LU_piv(AB1, p1)
+        ABR.SubMatrix(&AB1, 0, 0, ABR.Rows(), A11.Cols())
+        unblockedZLUpiv(&AB1, &p1)
+
+        // apply pivots to previous columns
+        ABL.SubMatrix(&AB0, 0, 0)
+        zApplyPivots(&AB0, &p1)
+        // scale last pivots to origin matrix row numbers
+        for k, _ := range p1.pivots {
+            p1.pivots[k] += ATL.Rows()
+        }
+
+        continue3x3to2x2(
+            &ATL, &ATR,
+            &ABL, &ABR, /**/ &A00, &A11, &A22, A, pBOTTOMRIGHT)
+        continue1x3to1x2(
+            &AL, &AR, /**/ &A0, &A1, A, pRIGHT)
+        contPivot3x1to2x1(
+            &pT,
+            &pB, /**/ &p0, &p1, p, pBOTTOM)
+    }
+    if ATL.Cols() < A.Cols() {
+        zApplyPivots(&ATR, p)
+        ZSolveTrm(&ATR, &ATL, 1.0, LEFT|UNIT|LOWER)
+    }
+    return nil
+}
+
+/*
+ * Compute an LU factorization of a general complex M-by-N matrix using partial
+ * pivoting with row interchanges. Pivot rows are selected by the largest
+ * |real| + |imag| value on the column. See DecomposeLU().
+ *
+ * Compatible with lapack.ZGETRF
+ */
+func ZDecomposeLU(A *Complex128Matrix, pivots []int, nb int) (*Complex128Matrix, error) {
+    mlen := imin(A.Rows(), A.Cols())
+    if len(pivots) < mlen {
+        return A, sizeError("ZDecomposeLU", A, shape{len(pivots), 1})
+    }
+    // clear pivot array
+    for k, _ := range pivots {
+        pivots[k] = 0
+    }
+    if mlen <= nb || nb == 0 {
+        return A, unblockedZLUpiv(A, &pPivots{pivots})
+    }
+    return A, blockedZLUpiv(A, &pPivots{pivots}, nb)
+}
+
+/* From LAPACK/zlarfg.f
+ *
+ * Generate a complex elementary reflector H of order n, such that
+ *
+ *       H.H * ( alpha ) = ( beta ),   H.H * H = I.
+ *             (   x   )   (   0  )
+ *
+ * where alpha is complex scalar, beta is real scalar and x is an (n-1)-element
+ * complex vector. H is represented in the form
+ *
+ *       H = I - tau * ( 1 ) * ( 1 v.H ) ,
+ *                     ( v )
+ *
+ * where tau is a complex scalar with 1 <= real(tau) <= 2 and |tau-1| <= 1.
+ *
+ * If the elements of x are all zero and alpha is real, then tau = 0 and H is
+ * taken to be the unit matrix.
+ */
+func computeZHouseholder(a11, x, tau *Complex128Matrix) {
+
+    alpha := a11.Complex()
+    norm_x2 := zNorm2(x)
+    if norm_x2 == 0.0 && imag(alpha) == 0.0 {
+        tau.SetAt(0, 0, 0.0)
+        return
+    }
+    // beta = -sign(real(alpha)) * sqrt(|alpha|**2 + norm_x2**2)
+    beta := -math.Copysign(sqrtX2Y2(cmplx.Abs(alpha), norm_x2), real(alpha))
+
+    tau.SetAt(0, 0, complex((beta - real(alpha))/beta, -imag(alpha)/beta))
+    // x = x/(alpha - beta)
+    zInvScale(x, alpha - complex(beta, 0.0))
+    a11.SetAt(0, 0, complex(beta, 0.0))
+}
+
+/* From LAPACK/zlarf.f
+ *
+ * Apply H.H = I - conj(tau)*v*v.H from left to a complex matrix /a1\ where
+ * v = (1 v2.T).T:                                                \A2/
+ *
+ *      w1 := a1.H + A2.H*v2
+ *      a1 := a1 - conj(tau)*w1.H
+ *      A2 := A2 - conj(tau)*v2*w1.H
+ */
+func applyZHouseholder(tau, v2, a1, A2 *Complex128Matrix) {
+
+    tval := cmplx.Conj(tau.Complex())
+    if tval == 0.0 {
+        return
+    }
+    w1 := Complex128Zeros(a1.NumElements(), 1)
+    // w1 = A2.H*v2
+    ZMVMult(w1, A2, v2, 1.0, 0.0, TRANSA)
+    for k := 0; k < a1.NumElements(); k++ {
+        // w1 = w1 + a1.H; a1 = a1 - tau*w1.H
+        w := w1.GetAt(k, 0) + cmplx.Conj(a1.GetAt(0, k))
+        w1.SetAt(k, 0, w)
+        a1.SetAt(0, k, a1.GetAt(0, k) - tval*cmplx.Conj(w))
+    }
+    // A2 = A2 - tau*v2*w1.H
+    zMVRankUpdate(A2, v2, w1, -tval)
+}
+
+/*
+ * Unblocked complex QR decomposition. As implemented in lapack.ZGEQR2 subroutine.
+ */
+func unblockedZQR(A, tau *Complex128Matrix) {
+    var ATL, ATR, ABL, ABR Complex128Matrix
+    var A00, a01, A02, a10, a11, a12, A20, a21, A22 Complex128Matrix
+    var tT, tB Complex128Matrix
+    var t0, tau1, t2 Complex128Matrix
+
+    partition2x2(
+        &ATL, &ATR,
+        &ABL, &ABR, A, 0, 0, pTOPLEFT)
+    partition2x1(
+        &tT,
+        &tB,  tau, 0, pTOP)
+
+    for ABR.Rows() > 0 && ABR.Cols() > 0 {
+        repartition2x2to3x3(&ATL,
+            &A00, &a01, &A02,
+            &a10, &a11, &a12,
+            &A20, &a21, &A22,   A, 1, pBOTTOMRIGHT)
+        repartition2x1to3x1(&tT,
+            &t0,
+            &tau1,
+            &t2,     tau, 1, pBOTTOM)
+
+        // ------------------------------------------------------
+        computeZHouseholder(&a11, &a21, &tau1)
+        applyZHouseholder(&tau1, &a21, &a12, &A22)
+
+        // ------------------------------------------------------
+        continue3x3to2x2(
+            &ATL, &ATR,
+            &ABL, &ABR,   &A00, &a11, &A22,   A, pBOTTOMRIGHT)
+        continue3x1to2x1(
+            &tT,
+            &tB,   &t0, &tau1,   tau, pBOTTOM)
+    }
+}
+
+/*
+ * Build the upper triangular block reflector T of Q = H(0)*H(1)*...*H(k-1) =
+ * I - Y*T*Y.H where Y is unit lower trapezoidal matrix of the reflectors. As
+ * implemented in lapack.ZLARFT for forward direction and columnwise storage.
+ * Y is returned with unit diagonal and zeros above the diagonal.
+ */
+func buildZT(T, A, tau *Complex128Matrix) *Complex128Matrix {
+    var y0, yk, tk Complex128Matrix
+    M, K := A.Rows(), A.Cols()
+    Y := Complex128Zeros(M, K)
+    for j := 0; j < K; j++ {
+        Y.SetAt(j, j, 1.0)
+        for i := j+1; i < M; i++ {
+            Y.SetAt(i, j, A.GetAt(i, j))
+        }
+    }
+    for k := 0; k < K; k++ {
+        tauk := tau.GetAt(k, 0)
+        T.SetAt(k, k, tauk)
+        if k == 0 {
+            continue
+        }
+        // T[0:k,k] = -tau[k]*Y[:,0:k].H*Y[:,k]
+        Y.SubMatrix(&y0, 0, 0, M, k)
+        Y.SubMatrix(&yk, 0, k, M, 1)
+        T.SubMatrix(&tk, 0, k, k, 1)
+        ZMVMult(&tk, &y0, &yk, -tauk, 0.0, TRANSA)
+        // T[0:k,k] = T[0:k,0:k]*T[0:k,k]; rows in increasing order
+        for i := 0; i < k; i++ {
+            var s complex128
+            for l := i; l < k; l++ {
+                s += T.GetAt(i, l)*tk.GetAt(l, 0)
+            }
+            tk.SetAt(i, 0, s)
+        }
+    }
+    return Y
+}
+
+/*
+ * Blocked complex QR decomposition with compact WY transform. As implemented
+ * in lapack.ZGEQRF subroutine.
+ */
+func blockedZQR(A, tau, T, W *Complex128Matrix, nb int) {
+    var ATL, ATR, ABL, ABR, AL, AR Complex128Matrix
+    var A00, A01, A02, A10, A11, A12, A20, A21, A22 Complex128Matrix
+    var TT, TB, t0, tau1, t2 Complex128Matrix
+    var C, Wc, Tc, w0, wj, tj Complex128Matrix
+
+    partition2x2(
+        &ATL, &ATR,
+        &ABL, &ABR, A, 0, 0, pTOPLEFT)
+    partition2x1(
+        &TT,
+        &TB,  tau, 0, pTOP)
+
+    for ABR.Rows() > 0 && ABR.Cols() > 0 {
+        repartition2x2to3x3(&ATL,
+            &A00, &A01, &A02,
+            &A10, &A11, &A12,
+            &A20, &A21, &A22,   A, nb, pBOTTOMRIGHT)
+        repartition2x1to3x1(&TT,
+            &t0,
+            &tau1,
+            &t2,     tau, nb, pBOTTOM)
+        partition1x2(
+            &AL, &AR,    &ABR, A11.Cols(), pLEFT)
+
+        // --------------------------------------------------------
+        // decompose left side AL == /A11\
+        //                           \A21/
+        unblockedZQR(&AL, &tau1)
+
+        if AR.Cols() > 0 {
+            // update C = (A12 A22).T with Q.H = I - Y*T.H*Y.H:
+            //      W = C.H*Y*T, C = C - Y*W.H
+            cb := imin(AL.Rows(), AL.Cols())
+            T.SubMatrix(&Tc, 0, 0, cb, cb)
+            AL.SubMatrix(&C, 0, 0, AL.Rows(), cb)
+            Y := buildZT(&Tc, &C, &tau1)
+            W.SubMatrix(&Wc, 0, 0, AR.Cols(), cb)
+            ZMult(&Wc, &AR, Y, 1.0, 0.0, TRANSA)
+            // W = W*T; columns in decreasing order
+            for j := cb-1; j >= 0; j-- {
+                Wc.SubMatrix(&wj, 0, j, Wc.Rows(), 1)
+                if j == 0 {
+                    calgo.ZScal(wj.Complex128Array(), Tc.GetAt(0, 0), 1, wj.Rows())
+                    break
+                }
+                Wc.SubMatrix(&w0, 0, 0, Wc.Rows(), j)
+                Tc.SubMatrix(&tj, 0, j, j, 1)
+                ZMVMult(&wj, &w0, &tj, 1.0, Tc.GetAt(j, j), NOTRANS)
+            }
+            ZMult(&AR, Y, &Wc, -1.0, 1.0, TRANSB)
+        }
+
+        // --------------------------------------------------------
+        continue3x3to2x2(
+            &ATL, &ATR,
+            &ABL, &ABR,   &A00, &A11, &A22,   A, pBOTTOMRIGHT)
+        continue3x1to2x1(
+            &TT,
+            &TB,   &t0, &tau1,   tau, pBOTTOM)
+    }
+}
+
+/*
+ * Compute QR factorization of a complex M-by-N matrix A: A = Q * R.
+ *
+ * Arguments:
+ *  A   On entry, the M-by-N matrix A. On exit, the elements on and above
+ *      the diagonal contain the min(M,N)-by-N upper trapezoidal matrix R with
+ *      real diagonal. The elements below the diagonal with the column vector
+ *      'tau', represent the unitary matrix Q as product of elementary reflectors
+ *      H(k) = I - tau[k]*v*v.H.
+ *
+ * tau  On exit, the scalar factors of the elemenentary reflectors.
+ *
+ * W    Workspace, N-by-nb matrix used for work space in blocked invocations.
+ *      If nil, work space is allocated.
+ *
+ * nb   The block size used in blocked invocations. If nb is zero on N <= nb
+ *      unblocked algorithm is used.
+ *
+ * Returns:
+ *      Decomposed matrix A and error indicator.
+ *
+ * ZDecomposeQR is compatible with lapack.ZGEQRF
+ */
+func ZDecomposeQR(A, tau, W *Complex128Matrix, nb int) (*Complex128Matrix, error) {
+    if tau.NumElements() < imin(A.Rows(), A.Cols()) {
+        return A, sizeError("ZDecomposeQR", A, tau)
+    }
+    if nb == 0 || A.Cols() <= nb {
+        unblockedZQR(A, tau)
+        return A, nil
+    }
+    if W == nil {
+        W = Complex128Zeros(A.Cols(), nb)
+    } else if W.Cols() < nb || W.Rows() < A.Cols() {
+        return nil, sizeError("ZDecomposeQR", A, W)
+    }
+    blockedZQR(A, tau, Complex128Zeros(nb, nb), W, nb)
+    return A, nil
+}
+
+// Local Variables:
+// tab-width: 4
+// indent-tabs-mode: nil
+// End:
@@ -0,0 +1,189 @@
+// Copyright (c) Harri Rautila, 2013
+
+// This file is part of github.com/hrautila/matops package. It is free software,
+// distributed under the terms of GNU Lesser General Public License Version 3, or
+// any later version. See the COPYING tile included in this archive.
+
+package matops
+
+import (
+    "github.com/hrautila/matops/calgo"
+    "context"
+)
+
+// Double precision complex versions of the matrix operations. Arguments and results
+// are as in the double precision functions with the same name without Z prefix
+// except that flags TRANSA and TRANSB select the conjugate transpose A.H or B.H.
+
+// Element increment of vector X.
+func zinc(X *Complex128Matrix) int {
+    if X.Cols() != 1 {
+        // Row vector
+        return X.LeadingIndex()
+    }
+    return 1
+}
+
+// Generic matrix-matrix multiply: C = beta*C + alpha*op(A)*op(B) where op(X) is X
+// or X.H. See Mult().
+func ZMult(C, A, B *Complex128Matrix, alpha, beta complex128, flags Flags) error {
+    ok, empty := checkMult(C, A, B, flags)
+    if empty {
+        return nil
+    }
+    if ! ok {
+        return onError("ZMult: size mismatch")
+    }
+    conf := globalConfig(nil)
+    psize := int64(C.NumElements())*int64(A.Cols())
+    Ar, ldA := A.Complex128Array(), A.LeadingIndex()
+    Br, ldB := B.Complex128Array(), B.LeadingIndex()
+    Cr, ldC := C.Complex128Array(), C.LeadingIndex()
+
+    // matrix A, B common dimension
+    P := A.Cols()
+    if flags & TRANSA != 0 {
+        P = A.Rows()
+    }
+    worker := func(cstart, cend, rstart, rend int) {
+        calgo.ZMult(Cr, Ar, Br, alpha, beta, calgo.Flags(flags), ldC, ldA, ldB, P,
+            cstart, cend, rstart, rend, conf.KB, conf.NB, conf.MB)
+    }
+    if psize <= conf.LimitOne || conf.Executor.Workers() <= 1 {
+        worker(0, C.Cols(), 0, C.Rows())
+        return nil
+    }
+    return scheduleTiles(context.Background(), conf, C.Cols(), C.Rows(), worker)
+}
+
+// Matrix-vector multiply: Y = beta*Y + alpha*op(A)*X where op(A) is A or A.H.
+// See MVMult().
+func ZMVMult(Y, A, X *Complex128Matrix, alpha, beta complex128, flags Flags) error {
+    if A.Rows() == 0 || A.Cols() == 0 {
+        return nil
+    }
+    if ! isVector(Y) {
+        return sizeError("ZMVMult", Y, A, X)
+    }
+    if ! isVector(X) {
+        return sizeError("ZMVMult", Y, A, X)
+    }
+    Ar, ldA := A.Complex128Array(), A.LeadingIndex()
+    Yr, incY := Y.Complex128Array(), zinc(Y)
+    Xr, incX := X.Complex128Array(), zinc(X)
+    lenX := X.NumElements()
+    p := globalParams()
+    worker := func(R, E int) {
+        calgo.ZMultMV(Yr, Ar, Xr, alpha, beta, calgo.Flags(flags), incY, ldA, incX,
+            0, lenX, R, E, p.KB, p.MB)
+    }
+    return scheduleMV(context.Background(), nil, Y.NumElements(), lenX, worker)
+}
+
+// Triangular solve with multiple right sides: B = alpha*op(A).-1*B or
+// B = alpha*B*op(A).-1 where op(A) is A or A.H. See SolveTrm().
+func ZSolveTrm(B, A *Complex128Matrix, alpha complex128, flags Flags) error {
+    ok, empty := checkTrm(B, A, flags)
+    if empty {
+        return nil
+    }
+    if ! ok {
+        return onError("A, B size mismatch")
+    }
+    conf := globalConfig(nil)
+    N := A.Cols()
+    worker := func(S, E int) {
+        var Bs Complex128Matrix
+        trmSubBlock(&Bs, B, S, E, flags)
+        calgo.ZSolveBlk(Bs.Complex128Array(), A.Complex128Array(), alpha, calgo.Flags(flags),
+            Bs.LeadingIndex(), A.LeadingIndex(), N, 0, E-S, conf.NB)
+    }
+    return scheduleTrm(context.Background(), conf, B, N, flags, worker)
+}
+
+// Hermitian rank update C = beta*C + alpha*A*A.H or C = beta*C + alpha*A.H*A
+// if flags&TRANSA. Only UPPER or LOWER triangle of C is updated and imaginary
+// parts of the diagonal are set to zero. See RankUpdateSym().
+func ZRankUpdateHerm(C, A *Complex128Matrix, alpha, beta float64, flags Flags) error {
+    if C.Rows() != C.Cols() {
+        return onError("C not a square matrix")
+    }
+    N := C.Rows()
+    P := A.Cols()
+    if flags & TRANSA != 0 {
+        P = A.Rows()
+    }
+    conf := globalConfig(nil)
+    // rows [S:E] of A or columns [S:E] of A.H
+    subA := func(As *Complex128Matrix, S, E int) {
+        if flags & TRANSA != 0 {
+            A.SubMatrix(As, 0, S, P, E-S)
+        } else {
+            A.SubMatrix(As, S, 0, E-S, P)
+        }
+    }
+    // as in RankUpdateSym() C is divided to blocks of rows [S:E]
+    worker := func(S, E int) {
+        var Cd, Ad, Co, Ao Complex128Matrix
+        C.SubMatrix(&Cd, S, S, E-S, E-S)
+        subA(&Ad, S, E)
+        calgo.ZHermRankBlk(Cd.Complex128Array(), Ad.Complex128Array(), alpha, beta,
+            calgo.Flags(flags), Cd.LeadingIndex(), Ad.LeadingIndex(), P, 0, E-S, conf.KB, conf.NB)
+        mflags := calgo.Flags(calgo.TRANSB)
+        if flags & TRANSA != 0 {
+            mflags = calgo.Flags(calgo.TRANSA)
+        }
+        if flags & LOWER != 0 && S > 0 {
+            C.SubMatrix(&Co, S, 0, E-S, S)
+            subA(&Ao, 0, S)
+        } else if flags & UPPER != 0 && E < N {
+            C.SubMatrix(&Co, S, E, E-S, N-E)
+            subA(&Ao, E, N)
+        } else {
+            return
+        }
+        calgo.ZMult(Co.Complex128Array(), Ad.Complex128Array(), Ao.Complex128Array(),
+            complex(alpha, 0.0), complex(beta, 0.0), mflags, Co.LeadingIndex(),
+            Ad.LeadingIndex(), Ao.LeadingIndex(), P, 0, Co.Cols(), 0, Co.Rows(),
+            conf.KB, conf.NB, conf.MB)
+    }
+    if N == 0 {
+        return nil
+    }
+    if int64(N)*int64(N)*int64(P) <= conf.LimitOne || conf.Executor.Workers() <= 1 {
+        worker(0, N)
+        return nil
+    }
+    ctx := context.Background()
+    return scheduleList(ctx, conf.Executor, splitTriangle(ctx, N, conf.Executor.Workers(), flags), worker)
+}
+
+// Rank update A = A + alpha*X*Y.H
+func zMVRankUpdate(A, X, Y *Complex128Matrix, alpha complex128) {
+    calgo.ZRankMV(A.Complex128Array(), X.Complex128Array(), Y.Complex128Array(), alpha,
+        A.LeadingIndex(), zinc(X), zinc(Y), 0, A.Cols(), 0, A.Rows(), 0, 0)
+}
+
+// Inner product without conjugation: alpha * X * Y.
+func zDotu(X, Y *Complex128Matrix, alpha complex128) complex128 {
+    return calgo.ZDotu(X.Complex128Array(), Y.Complex128Array(), alpha, zinc(X), zinc(Y),
+        X.NumElements())
+}
+
+// Norm2 of complex vector.
+func zNorm2(X *Complex128Matrix) float64 {
+    return calgo.ZNorm2(X.Complex128Array(), zinc(X), X.NumElements())
+}
+
+// Inverse scaling of vector: X = X / alpha.
+func zInvScale(X *Complex128Matrix, alpha complex128) {
+    if X.NumElements() == 0 {
+        return
+    }
+    calgo.ZInvScal(X.Complex128Array(), alpha, zinc(X), X.NumElements())
+}
+
+// Local Variables:
+// tab-width: 4
+// indent-tabs-mode: nil
+// End: